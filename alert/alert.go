@@ -1,15 +1,10 @@
 package alert
 
 import (
-	"bytes"
-	"crypto/tls"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
-	"net/smtp"
 	"strings"
+	"sync"
 	"time"
 
 	"bconf.com/monic/types"
@@ -19,16 +14,36 @@ import (
 type AlertManager struct {
 	config   *types.AlertingConfig
 	appName  string
+	sentMu   sync.Mutex           // Guards lastSent, alerts may be sent concurrently
 	lastSent map[string]time.Time // Track last sent alerts to avoid spam
+	// checkTargets and managedTargets are the alert targets of single checks by alert type, of the
+	// configured checks and of those managed at runtime; read by the goroutines sending alerts
+	targetsMu      sync.RWMutex
+	checkTargets   map[string]types.AlertTarget
+	managedTargets map[string]types.AlertTarget
+
+	channelsMu sync.RWMutex
+	notifiers  []notificationChannel
+	breakers   map[string]*CircuitBreaker
+
+	statsMu      sync.Mutex
+	channelStats map[string]*types.NotificationChannelStats
 }
 
-// NewAlertManager creates a new alert manager instance
+// NewAlertManager creates a new alert manager instance with the email, Mailgun and Telegram
+// channels of the configuration
 func NewAlertManager(config *types.AlertingConfig, appName string) *AlertManager {
-	return &AlertManager{
+	am := &AlertManager{
 		config:   config,
 		appName:  appName,
 		lastSent: make(map[string]time.Time),
+		breakers: make(map[string]*CircuitBreaker),
+
+		channelStats: make(map[string]*types.NotificationChannelStats),
 	}
+	am.registerBuiltinNotifiers()
+
+	return am
 }
 
 // SendAlert sends an alert through all configured channels
@@ -38,40 +53,30 @@ func (am *AlertManager) SendAlert(alert types.Alert) error {
 		return nil
 	}
 
-	// Check cooldown period
-	if !am.shouldSendCooldown(alert) {
+	// Check cooldown period, claiming it for this alert
+	if !am.claimCooldown(alert, time.Now()) {
 		return nil
 	}
 
-	var errs []string
-
-	// Send via SMTP email if enabled
-	if am.config.Email.Enabled {
-		if err := am.sendEmail(alert); err != nil {
-			slog.Error("Failed to send email alert", "error", err)
-			errs = append(errs, fmt.Sprintf("email: %v", err))
-		}
-	}
-
-	// Send via Mailgun if enabled
-	if am.config.Mailgun.Enabled {
-		if err := am.sendMailgun(alert); err != nil {
-			slog.Error("Failed to send Mailgun alert", "error", err)
-			errs = append(errs, fmt.Sprintf("mailgun: %v", err))
-		}
+	// Channels are sent to in parallel, so a slow one doesn't delay the others
+	deliveries := am.route(alert)
+	results := make([]error, len(deliveries))
+	var wg sync.WaitGroup
+	for i, d := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = am.sendThrough(d, alert)
+		}()
 	}
+	wg.Wait()
 
-	// Send via Telegram if enabled
-	if am.config.Telegram.Enabled {
-		if err := am.sendTelegram(alert); err != nil {
-			slog.Error("Failed to send Telegram alert", "error", err)
-			errs = append(errs, fmt.Sprintf("telegram: %v", err))
+	var errs []string
+	for i, err := range results {
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", deliveries[i].channel.name(), err))
 		}
 	}
-
-	// Update last sent time
-	am.lastSent[alert.Type] = time.Now()
-
 	if len(errs) > 0 {
 		return fmt.Errorf("failed to send alerts: %s", strings.Join(errs, "; "))
 	}
@@ -80,246 +85,184 @@ func (am *AlertManager) SendAlert(alert types.Alert) error {
 	return nil
 }
 
-// shouldSendLevel checks if the alert level should be sent
-func (am *AlertManager) shouldSendLevel(level string) bool {
-	// If no levels configured, send all
-	return true
-}
-
-// shouldSendCooldown checks if enough time has passed since the last alert of this type
-func (am *AlertManager) shouldSendCooldown(alert types.Alert) bool {
-
-	lastSent, exists := am.lastSent[alert.Type]
-	if !exists {
-		return true // Never sent this type before
-	}
-
-	cooldownDuration := time.Duration(1) * time.Minute
-	return time.Since(lastSent) >= cooldownDuration
-}
-
-// sendEmail sends an alert via SMTP email
-func (am *AlertManager) sendEmail(alert types.Alert) error {
-	emailConfig := am.config.Email
-
-	// Validate email configuration
-	if emailConfig.SMTPHost == "" || emailConfig.SMTPPort == 0 {
-		return fmt.Errorf("SMTP host and port must be configured")
-	}
-	if emailConfig.From == "" || emailConfig.To == "" {
-		return fmt.Errorf("from and to email addresses must be configured")
-	}
+// sendThrough sends an alert through one channel, unless its circuit is open, and updates the
+// circuit with the outcome
+func (am *AlertManager) sendThrough(d delivery, alert types.Alert) error {
+	channel := d.channel
+	now := time.Now()
 
-	// Create email message
-	appName := am.getAppName()
-	subject := fmt.Sprintf("[%s Alert] %s - %s", appName, strings.ToUpper(alert.Level), alert.Type)
-	body := am.buildEmailBody(alert)
-
-	// Build message headers
-	headers := make(map[string]string)
-	headers["From"] = emailConfig.From
-	headers["To"] = emailConfig.To
-	headers["Subject"] = subject
-	headers["Content-Type"] = "text/plain; charset=\"utf-8\""
-
-	// Build message
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
+	// Skip providers with an open circuit, the others continue to work
+	breaker := am.breaker(channel.name())
+	if !breaker.Allow(now) {
+		slog.Debug("Skipping alert channel with open circuit", "channel", channel.name(), "until", breaker.OpenUntil())
+		return nil
 	}
-	message += "\r\n" + body
-
-	// Connect to SMTP server
-	auth := smtp.PlainAuth("", emailConfig.Username, emailConfig.Password, emailConfig.SMTPHost)
-	addr := fmt.Sprintf("%s:%d", emailConfig.SMTPHost, emailConfig.SMTPPort)
-
-	if emailConfig.UseTLS {
-		// Use STARTTLS (required for Gmail)
-		client, err := smtp.Dial(addr)
-		if err != nil {
-			return fmt.Errorf("SMTP dial failed: %w", err)
-		}
-		defer client.Close()
-
-		// Start TLS
-		tlsconfig := &tls.Config{
-			ServerName: emailConfig.SMTPHost,
-		}
-		if err = client.StartTLS(tlsconfig); err != nil {
-			return fmt.Errorf("STARTTLS failed: %w", err)
-		}
-
-		// Auth
-		if err = client.Auth(auth); err != nil {
-			return fmt.Errorf("SMTP auth failed: %w", err)
-		}
 
-		// Send email
-		if err = client.Mail(emailConfig.From); err != nil {
-			return fmt.Errorf("SMTP MAIL failed: %w", err)
-		}
-		if err = client.Rcpt(emailConfig.To); err != nil {
-			return fmt.Errorf("SMTP RCPT failed: %w", err)
-		}
-
-		w, err := client.Data()
-		if err != nil {
-			return fmt.Errorf("SMTP DATA failed: %w", err)
+	if err := am.deliver(channel, alert, d.recipient); err != nil {
+		slog.Error("Failed to send alert", "channel", channel.name(), "recipient", d.recipient, "error", err)
+		if breaker.RecordFailure(now) {
+			am.handleChannelOutage(channel, err)
 		}
-		defer w.Close()
-
-		_, err = w.Write([]byte(message))
-		if err != nil {
-			return fmt.Errorf("SMTP message write failed: %w", err)
-		}
-		slog.Info("Email alert sent (TLS)", "recipient", emailConfig.To)
-	} else {
-		// Use plain SMTP
-		err := smtp.SendMail(addr, auth, emailConfig.From, []string{emailConfig.To}, []byte(message))
-		if err != nil {
-			return fmt.Errorf("SMTP send failed: %w", err)
-		}
-		slog.Info("Email alert sent (plain SMTP)", "recipient", emailConfig.To)
+		return err
 	}
 
+	if breaker.RecordSuccess() {
+		am.handleChannelRecovery(channel)
+	}
 	return nil
 }
 
-// getAppName returns the application name, defaulting to "Monic" if not configured
-func (am *AlertManager) getAppName() string {
-	if am.appName != "" {
-		return am.appName
-	}
-	return "Monic"
+// handleChannelOutage logs a provider outage and notifies the remaining healthy channels
+func (am *AlertManager) handleChannelOutage(channel notificationChannel, err error) {
+	breaker := am.breaker(channel.name())
+	slog.Warn("Alert channel circuit opened",
+		"channel", channel.name(),
+		"failures", breaker.ConsecutiveFailures(),
+		"until", breaker.OpenUntil().Format(time.RFC3339),
+		"error", err)
+
+	am.sendMetaAlert(types.Alert{
+		Type:      "alerting_" + channel.name(),
+		Message:   fmt.Sprintf("%s alert channel is failing (%d consecutive errors: %v), paused until %s", channel.label(), breaker.ConsecutiveFailures(), err, breaker.OpenUntil().Format(time.RFC1123)),
+		Level:     "critical",
+		Timestamp: time.Now(),
+	}, channel.name())
 }
 
-// sendMailgun sends an alert via Mailgun API
-func (am *AlertManager) sendMailgun(alert types.Alert) error {
-	mailgunConfig := am.config.Mailgun
+// handleChannelRecovery logs a provider recovery and notifies the other healthy channels
+func (am *AlertManager) handleChannelRecovery(channel notificationChannel) {
+	slog.Info("Alert channel recovered", "channel", channel.name())
 
-	// Validate Mailgun configuration
-	if mailgunConfig.APIKey == "" {
-		return fmt.Errorf("Mailgun API key must be configured")
-	}
-	if mailgunConfig.Domain == "" {
-		return fmt.Errorf("Mailgun domain must be configured")
-	}
-	if mailgunConfig.From == "" || mailgunConfig.To == "" {
-		return fmt.Errorf("from and to email addresses must be configured")
-	}
+	am.sendMetaAlert(types.Alert{
+		Type:      "alerting_" + channel.name(),
+		Message:   fmt.Sprintf("%s alert channel recovered", channel.label()),
+		Level:     "info",
+		Timestamp: time.Now(),
+	}, channel.name())
+}
 
-	// Set default base URL if not provided
-	baseURL := mailgunConfig.BaseURL
-	if baseURL == "" {
-		baseURL = "https://api.mailgun.net/v3"
+// sendMetaAlert sends an alert about the alerting system itself through all healthy
+// channels except the given one, bypassing cooldown and circuit accounting
+func (am *AlertManager) sendMetaAlert(alert types.Alert, exclude string) {
+	for _, channel := range am.channels() {
+		if !channel.enabled || channel.name() == exclude || am.breaker(channel.name()).State() != circuitClosed {
+			continue
+		}
+		if err := am.deliver(channel, alert, ""); err != nil {
+			slog.Error("Failed to send meta-alert", "channel", channel.name(), "error", err)
+		}
 	}
+}
 
-	// Build request data
-	appName := am.getAppName()
-	subject := fmt.Sprintf("[%s Alert] %s - %s", appName, strings.ToUpper(alert.Level), alert.Type)
-	body := am.buildEmailBody(alert)
+// deliver sends an alert through a channel to a recipient and records delivery metrics
+func (am *AlertManager) deliver(channel notificationChannel, alert types.Alert, recipient string) error {
+	err := channel.send(alert, recipient)
+	now := time.Now()
 
-	formData := map[string]string{
-		"from":    mailgunConfig.From,
-		"to":      mailgunConfig.To,
-		"subject": subject,
-		"text":    body,
-	}
+	am.statsMu.Lock()
+	defer am.statsMu.Unlock()
 
-	// Create request
-	url := fmt.Sprintf("%s/%s/messages", baseURL, mailgunConfig.Domain)
-	formBytes, err := json.Marshal(formData)
+	stats := am.channelStats[channel.name()]
 	if err != nil {
-		return fmt.Errorf("failed to encode form data: %w", err)
+		stats.Failed++
+		stats.LastError = err.Error()
+		stats.LastErrorTime = now
+		return err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(formBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	// Latency is measured from detection (alert timestamp) to successful delivery
+	latency := now.Sub(alert.Timestamp)
+	if alert.Timestamp.IsZero() || latency < 0 {
+		latency = 0
 	}
-
-	req.SetBasicAuth("api", mailgunConfig.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("Mailgun API request failed: %w", err)
+	stats.Sent++
+	stats.LastSuccess = now
+	stats.LastLatency = latency
+	stats.TotalLatency += latency
+	if latency > stats.MaxLatency {
+		stats.MaxLatency = latency
 	}
-	defer resp.Body.Close()
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("mailgun API returned status %s: %s", resp.Status, string(body))
+// GetChannelStats returns delivery metrics and health for each alert channel
+func (am *AlertManager) GetChannelStats() []types.NotificationChannelStats {
+	// Channels and circuit states are read before the stats are locked, never while
+	channels := am.channels()
+	states := make(map[string]string, len(channels))
+	for _, channel := range channels {
+		states[channel.name()] = am.breaker(channel.name()).State()
+	}
+
+	am.statsMu.Lock()
+	defer am.statsMu.Unlock()
+
+	var result []types.NotificationChannelStats
+	for _, channel := range channels {
+		stats := *am.channelStats[channel.name()]
+		stats.Enabled = channel.enabled
+		stats.CircuitState = states[channel.name()]
+		if attempts := stats.Sent + stats.Failed; attempts > 0 {
+			stats.ErrorRate = float64(stats.Failed) / float64(attempts) * 100
+		}
+		if stats.Sent > 0 {
+			stats.AvgLatency = stats.TotalLatency / time.Duration(stats.Sent)
+		}
+		result = append(result, stats)
 	}
-
-	slog.Info("Mailgun alert sent", "recipient", am.config.Mailgun.To)
-	return nil
+	return result
 }
 
-// sendTelegram sends an alert via Telegram Bot API
-func (am *AlertManager) sendTelegram(alert types.Alert) error {
-	telegramConfig := am.config.Telegram
+// LastSent returns a copy of the time the last alert of each type was sent
+func (am *AlertManager) LastSent() map[string]time.Time {
+	am.sentMu.Lock()
+	defer am.sentMu.Unlock()
 
-	// Validate Telegram configuration
-	if telegramConfig.BotToken == "" {
-		return fmt.Errorf("Telegram bot token must be configured")
-	}
-	if telegramConfig.ChatID == "" {
-		return fmt.Errorf("Telegram chat ID must be configured")
+	lastSent := make(map[string]time.Time, len(am.lastSent))
+	for alertType, sent := range am.lastSent {
+		lastSent[alertType] = sent
 	}
+	return lastSent
+}
 
-	// Build message
-	appName := am.getAppName()
-	message := fmt.Sprintf("<b>[%s Alert] %s - %s</b>\n\n", appName, strings.ToUpper(alert.Level), alert.Type)
-	message += fmt.Sprintf("Message: %s\n", alert.Message)
-	message += fmt.Sprintf("Time: %s", alert.Timestamp.Format(time.RFC1123))
-
-	// Create request URL
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", telegramConfig.BotToken)
+// RestoreLastSent restores the send times of a previous run, so alerts still in their cooldown
+// are not sent again after a restart
+func (am *AlertManager) RestoreLastSent(lastSent map[string]time.Time) {
+	am.sentMu.Lock()
+	defer am.sentMu.Unlock()
 
-	// Create request body
-	reqBody := map[string]string{
-		"chat_id":    telegramConfig.ChatID,
-		"text":       message,
-		"parse_mode": "HTML",
+	for alertType, sent := range lastSent {
+		am.lastSent[alertType] = sent
 	}
+}
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal Telegram request: %w", err)
-	}
+// shouldSendLevel checks if the alert level should be sent
+func (am *AlertManager) shouldSendLevel(level string) bool {
+	// If no levels configured, send all
+	return true
+}
 
-	// Send request
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("Telegram API request failed: %w", err)
-	}
-	defer resp.Body.Close()
+// claimCooldown checks if enough time has passed since the last alert of this type and, if so,
+// records the alert as sent at now. Checking and recording at once keeps concurrent alerts of the
+// same type from both passing the cooldown.
+func (am *AlertManager) claimCooldown(alert types.Alert, now time.Time) bool {
+	am.sentMu.Lock()
+	defer am.sentMu.Unlock()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Telegram API returned status %d", resp.StatusCode)
+	cooldownDuration := time.Duration(1) * time.Minute
+	if lastSent, exists := am.lastSent[alert.Type]; exists && now.Sub(lastSent) < cooldownDuration {
+		return false
 	}
-
-	return nil
+	am.lastSent[alert.Type] = now
+	return true
 }
 
-// buildEmailBody creates the email body for an alert
-func (am *AlertManager) buildEmailBody(alert types.Alert) string {
-	var body strings.Builder
-	appName := am.getAppName()
-
-	body.WriteString(fmt.Sprintf("%s MONITORING ALERT\n", strings.ToUpper(appName)))
-	body.WriteString("=====================\n\n")
-	body.WriteString(fmt.Sprintf("Alert Level: %s\n", strings.ToUpper(alert.Level)))
-	body.WriteString(fmt.Sprintf("Alert Type: %s\n", alert.Type))
-	body.WriteString(fmt.Sprintf("Message: %s\n", alert.Message))
-	body.WriteString(fmt.Sprintf("Timestamp: %s\n", alert.Timestamp.Format(time.RFC1123)))
-	body.WriteString(fmt.Sprintf("Server Time: %s\n\n", time.Now().Format(time.RFC1123)))
-	body.WriteString(fmt.Sprintf("This alert was generated by the %s monitoring service.\n", appName))
-
-	return body.String()
+// getAppName returns the application name, defaulting to "Monic" if not configured
+func (am *AlertManager) getAppName() string {
+	if am.appName != "" {
+		return am.appName
+	}
+	return "Monic"
 }
 
 // SendAlerts sends multiple alerts
@@ -339,53 +282,21 @@ func (am *AlertManager) SendAlerts(alerts []types.Alert) error {
 	return nil
 }
 
-// ValidateConfig validates the alerting configuration
+// ValidateConfig validates the alerting configuration of the enabled channels
 func (am *AlertManager) ValidateConfig() error {
-
-	// Validate email configuration if enabled
-	if am.config.Email.Enabled {
-		if am.config.Email.SMTPHost == "" {
-			return fmt.Errorf("SMTP host is required for email alerts")
-		}
-		if am.config.Email.SMTPPort <= 0 {
-			return fmt.Errorf("SMTP port must be positive")
-		}
-		if am.config.Email.From == "" {
-			return fmt.Errorf("from email address is required")
-		}
-		if am.config.Email.To == "" {
-			return fmt.Errorf("to email address is required")
-		}
-	}
-
-	// Validate Mailgun configuration if enabled
-	if am.config.Mailgun.Enabled {
-		if am.config.Mailgun.APIKey == "" {
-			return fmt.Errorf("API key is required for Mailgun alerts")
-		}
-		if am.config.Mailgun.Domain == "" {
-			return fmt.Errorf("domain is required for Mailgun alerts")
-		}
-		if am.config.Mailgun.From == "" {
-			return fmt.Errorf("from email address is required for Mailgun")
-		}
-		if am.config.Mailgun.To == "" {
-			return fmt.Errorf("to email address is required for Mailgun")
-		}
-	}
-
-	// Validate Telegram configuration if enabled
-	if am.config.Telegram.Enabled {
-		if am.config.Telegram.BotToken == "" {
-			return fmt.Errorf("bot token is required for Telegram alerts")
+	enabled := 0
+	for _, channel := range am.channels() {
+		if !channel.enabled {
+			continue
 		}
-		if am.config.Telegram.ChatID == "" {
-			return fmt.Errorf("chat ID is required for Telegram alerts")
+		enabled++
+		if err := channel.notifier.Validate(); err != nil {
+			return err
 		}
 	}
 
 	// Validate that at least one alerting method is configured if enabled
-	if !am.config.Email.Enabled && !am.config.Mailgun.Enabled && !am.config.Telegram.Enabled {
+	if enabled == 0 {
 		return fmt.Errorf("alerting is enabled but no alerting methods are configured")
 	}
 
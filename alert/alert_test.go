@@ -1,8 +1,11 @@
 package alert
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -161,18 +164,19 @@ func TestAlertManager_ShouldSendCooldown(t *testing.T) {
 		Timestamp: time.Now(),
 	}
 
-	// First alert should always be sent
-	if !manager.shouldSendCooldown(alert) {
+	// First alert should always be sent, and is recorded as sent
+	if !manager.claimCooldown(alert, time.Now()) {
 		t.Error("First alert should be sent (no cooldown)")
 	}
 
-	// Mark as sent
-	manager.lastSent[alert.Type] = time.Now()
-
 	// Immediately after sending, should not send again (1 minute cooldown is hardcoded)
-	if manager.shouldSendCooldown(alert) {
+	if manager.claimCooldown(alert, time.Now()) {
 		t.Error("Alert should not be sent immediately after previous one")
 	}
+
+	if !manager.claimCooldown(alert, time.Now().Add(time.Minute)) {
+		t.Error("Alert should be sent again after the cooldown")
+	}
 }
 
 func TestAlertManager_SendAlert_NoMethods(t *testing.T) {
@@ -203,15 +207,22 @@ func TestAlertManager_BuildEmailBody(t *testing.T) {
 		Message:   "CPU usage is 95%",
 		Level:     "critical",
 		Timestamp: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Correlated: []types.CorrelatedChange{
+			{Type: "http_db-ping", State: "critical"},
+			{Type: "http_redis", State: "critical"},
+			{Type: "memory", State: "ok"},
+		},
 	}
 
-	body := manager.buildEmailBody(alert)
+	body := emailBody(manager.getAppName(), alert)
 
 	expectedStrings := []string{
 		"TESTAPP MONITORING ALERT",
 		"Alert Level: CRITICAL",
 		"Alert Type: cpu",
 		"Message: CPU usage is 95%",
+		"Also failing: http_db-ping, http_redis",
+		"Also recovered: memory",
 		"Timestamp: Wed, 01 Jan 2025 12:00:00 UTC",
 		"This alert was generated by the TestApp monitoring service",
 	}
@@ -287,7 +298,7 @@ func TestAlertManager_SendMailgun_MockServer(t *testing.T) {
 		},
 	}
 
-	manager := NewAlertManager(config, "TestApp")
+	notifier := newMailgunNotifier(&config.Mailgun, "TestApp")
 
 	alert := types.Alert{
 		Type:      "test",
@@ -296,7 +307,7 @@ func TestAlertManager_SendMailgun_MockServer(t *testing.T) {
 		Timestamp: time.Now(),
 	}
 
-	err := manager.sendMailgun(alert)
+	err := notifier.Send(context.Background(), alert, "")
 	if err != nil {
 		t.Errorf("Expected no error from mock Mailgun server, got: %v", err)
 	}
@@ -320,7 +331,7 @@ func TestAlertManager_SendMailgun_Error(t *testing.T) {
 		},
 	}
 
-	manager := NewAlertManager(config, "TestApp")
+	notifier := newMailgunNotifier(&config.Mailgun, "TestApp")
 
 	alert := types.Alert{
 		Type:      "test",
@@ -329,8 +340,101 @@ func TestAlertManager_SendMailgun_Error(t *testing.T) {
 		Timestamp: time.Now(),
 	}
 
-	err := manager.sendMailgun(alert)
+	err := notifier.Send(context.Background(), alert, "")
 	if err == nil {
 		t.Error("Expected error from mock Mailgun server, got nil")
 	}
 }
+
+func TestAlertManager_GetChannelStats(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &types.AlertingConfig{
+		Mailgun: types.MailgunConfig{
+			Enabled: true,
+			APIKey:  "test-key",
+			Domain:  "example.com",
+			From:    "monic@example.com",
+			To:      "admin@example.com",
+			BaseURL: server.URL,
+		},
+	}
+	manager := NewAlertManager(config, "TestApp")
+
+	// Alert detected 2 seconds before delivery
+	manager.SendAlert(types.Alert{Type: "cpu", Level: "critical", Timestamp: time.Now().Add(-2 * time.Second)})
+	fail = true
+	manager.SendAlert(types.Alert{Type: "memory", Level: "critical", Timestamp: time.Now()})
+
+	var mailgun *types.NotificationChannelStats
+	for _, stats := range manager.GetChannelStats() {
+		if stats.Channel == "mailgun" {
+			s := stats
+			mailgun = &s
+		}
+	}
+	if mailgun == nil {
+		t.Fatal("Expected Mailgun channel stats")
+	}
+
+	if !mailgun.Enabled || mailgun.Sent != 1 || mailgun.Failed != 1 {
+		t.Errorf("Unexpected Mailgun stats: %+v", mailgun)
+	}
+	if mailgun.ErrorRate != 50 {
+		t.Errorf("Expected 50%% error rate, got %.1f", mailgun.ErrorRate)
+	}
+	if mailgun.LastLatency < 2*time.Second {
+		t.Errorf("Expected latency of at least 2s from detection, got %v", mailgun.LastLatency)
+	}
+	if mailgun.LastError == "" {
+		t.Error("Expected last error to be recorded")
+	}
+}
+
+// TestAlertManager_ConcurrentSendAlert sends alerts of one type from many goroutines at once;
+// run with -race to detect unguarded access
+func TestAlertManager_ConcurrentSendAlert(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &types.AlertingConfig{
+		Mailgun: types.MailgunConfig{
+			Enabled: true,
+			APIKey:  "test-key",
+			Domain:  "example.com",
+			From:    "monic@example.com",
+			To:      "admin@example.com",
+			BaseURL: server.URL,
+		},
+	}
+	manager := NewAlertManager(config, "TestApp")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			manager.SendAlert(types.Alert{Type: "cpu", Level: "critical", Message: "CPU usage high", Timestamp: time.Now()})
+			manager.RestoreLastSent(map[string]time.Time{"memory": time.Now()})
+			manager.LastSent()
+		}()
+	}
+	wg.Wait()
+
+	// The cooldown lets only one of the alerts through
+	if requests != 1 {
+		t.Errorf("Expected one alert sent, got %d", requests)
+	}
+}
@@ -0,0 +1,149 @@
+package alert
+
+import (
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// Circuit breaker states
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+// CircuitBreaker tracks failures of a single notification provider and temporarily
+// disables it after repeated failures, backing off exponentially while it keeps failing
+type CircuitBreaker struct {
+	failureThreshold int
+	openPeriod       time.Duration
+	maxOpenPeriod    time.Duration
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	opens               int // Number of consecutive open periods, drives the backoff
+	openUntil           time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker using the configured settings (or defaults)
+func NewCircuitBreaker(config types.CircuitBreakerConfig) *CircuitBreaker {
+	failureThreshold := config.FailureThreshold
+	if failureThreshold <= 0 {
+		failureThreshold = 3
+	}
+
+	openPeriod := config.OpenPeriod
+	if openPeriod <= 0 {
+		openPeriod = 300 // Default to 5 minutes
+	}
+
+	maxOpenPeriod := config.MaxOpenPeriod
+	if maxOpenPeriod < openPeriod {
+		maxOpenPeriod = 3600 // Default to 1 hour
+		if maxOpenPeriod < openPeriod {
+			maxOpenPeriod = openPeriod
+		}
+	}
+
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openPeriod:       time.Duration(openPeriod) * time.Second,
+		maxOpenPeriod:    time.Duration(maxOpenPeriod) * time.Second,
+		state:            circuitClosed,
+	}
+}
+
+// Allow reports whether a send attempt may be made; once the open period has
+// elapsed a single probe attempt is allowed (half-open)
+func (cb *CircuitBreaker) Allow(now time.Time) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if now.Before(cb.openUntil) {
+			return false
+		}
+		cb.state = circuitHalfOpen
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess closes the circuit and returns true if the provider recovered from an outage
+func (cb *CircuitBreaker) RecordSuccess() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	recovered := cb.state != circuitClosed
+	cb.state = circuitClosed
+	cb.consecutiveFailures = 0
+	cb.opens = 0
+	cb.openUntil = time.Time{}
+	return recovered
+}
+
+// RecordFailure records a failed send and returns true if the circuit has just opened
+// (a new outage); failed half-open probes reopen the circuit with a doubled period
+func (cb *CircuitBreaker) RecordFailure(now time.Time) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures++
+
+	switch cb.state {
+	case circuitHalfOpen:
+		cb.open(now)
+		return false
+	case circuitClosed:
+		if cb.consecutiveFailures >= cb.failureThreshold {
+			cb.open(now)
+			return true
+		}
+	}
+	return false
+}
+
+// open opens the circuit for the next backoff period
+func (cb *CircuitBreaker) open(now time.Time) {
+	cb.opens++
+	cb.state = circuitOpen
+	cb.openUntil = now.Add(cb.currentOpenPeriod())
+}
+
+// currentOpenPeriod returns the open period for the current number of opens
+func (cb *CircuitBreaker) currentOpenPeriod() time.Duration {
+	period := cb.openPeriod
+	for i := 1; i < cb.opens && period < cb.maxOpenPeriod; i++ {
+		period *= 2
+	}
+	if period > cb.maxOpenPeriod {
+		period = cb.maxOpenPeriod
+	}
+	return period
+}
+
+// State returns the current circuit state
+func (cb *CircuitBreaker) State() string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// OpenUntil returns when the open circuit will allow the next probe
+func (cb *CircuitBreaker) OpenUntil() time.Time {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.openUntil
+}
+
+// ConsecutiveFailures returns the number of failures since the last success
+func (cb *CircuitBreaker) ConsecutiveFailures() int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.consecutiveFailures
+}
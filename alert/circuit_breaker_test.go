@@ -0,0 +1,112 @@
+package alert
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	breaker := NewCircuitBreaker(types.CircuitBreakerConfig{FailureThreshold: 2, OpenPeriod: 60})
+	now := time.Now()
+
+	if breaker.RecordFailure(now) {
+		t.Error("Circuit should not open after the first failure")
+	}
+	if !breaker.RecordFailure(now) {
+		t.Error("Circuit should open after reaching the failure threshold")
+	}
+	if breaker.State() != circuitOpen {
+		t.Errorf("Expected state '%s', got '%s'", circuitOpen, breaker.State())
+	}
+	if breaker.Allow(now.Add(30 * time.Second)) {
+		t.Error("Open circuit should not allow sending")
+	}
+	if !breaker.Allow(now.Add(61 * time.Second)) {
+		t.Error("Circuit should allow a probe after the open period")
+	}
+	if breaker.State() != circuitHalfOpen {
+		t.Errorf("Expected state '%s', got '%s'", circuitHalfOpen, breaker.State())
+	}
+}
+
+func TestCircuitBreaker_ExponentialBackoff(t *testing.T) {
+	breaker := NewCircuitBreaker(types.CircuitBreakerConfig{FailureThreshold: 1, OpenPeriod: 60, MaxOpenPeriod: 200})
+	now := time.Now()
+
+	breaker.RecordFailure(now)
+	expected := []time.Duration{60 * time.Second, 120 * time.Second, 200 * time.Second, 200 * time.Second}
+	for i, period := range expected {
+		if got := breaker.OpenUntil().Sub(now); got != period {
+			t.Errorf("Open period %d: expected %v, got %v", i+1, period, got)
+		}
+
+		// Probe after the open period and fail again
+		now = breaker.OpenUntil()
+		if !breaker.Allow(now) {
+			t.Fatal("Expected probe to be allowed after open period")
+		}
+		if breaker.RecordFailure(now) {
+			t.Error("Failed probe should not report a new outage")
+		}
+	}
+}
+
+func TestCircuitBreaker_RecordSuccess(t *testing.T) {
+	breaker := NewCircuitBreaker(types.CircuitBreakerConfig{FailureThreshold: 1})
+
+	if breaker.RecordSuccess() {
+		t.Error("Closed circuit should not report a recovery")
+	}
+
+	breaker.RecordFailure(time.Now())
+	if !breaker.RecordSuccess() {
+		t.Error("Expected recovery to be reported after an outage")
+	}
+	if breaker.State() != circuitClosed || breaker.ConsecutiveFailures() != 0 {
+		t.Error("Expected circuit to be closed and failures reset after success")
+	}
+}
+
+func TestAlertManager_CircuitBreakerSkipsFailingChannel(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := &types.AlertingConfig{
+		Mailgun: types.MailgunConfig{
+			Enabled: true,
+			APIKey:  "test-key",
+			Domain:  "example.com",
+			From:    "monic@example.com",
+			To:      "admin@example.com",
+			BaseURL: server.URL,
+		},
+		CircuitBreaker: types.CircuitBreakerConfig{FailureThreshold: 2, OpenPeriod: 60},
+	}
+
+	manager := NewAlertManager(config, "TestApp")
+
+	for i := 0; i < 4; i++ {
+		manager.SendAlert(types.Alert{
+			Type:      fmt.Sprintf("test_%d", i),
+			Message:   "Test alert",
+			Level:     "critical",
+			Timestamp: time.Now(),
+		})
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected Mailgun to be called 2 times before the circuit opened, got %d", requests)
+	}
+	if manager.breakers["mailgun"].State() != circuitOpen {
+		t.Errorf("Expected Mailgun circuit to be open, got '%s'", manager.breakers["mailgun"].State())
+	}
+}
@@ -0,0 +1,153 @@
+package alert
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// emailNotifier sends alerts through an SMTP server
+type emailNotifier struct {
+	config  *types.EmailConfig
+	appName string
+}
+
+// newEmailNotifier creates an SMTP notifier
+func newEmailNotifier(config *types.EmailConfig, appName string) *emailNotifier {
+	return &emailNotifier{config: config, appName: appName}
+}
+
+// Name returns "email"
+func (n *emailNotifier) Name() string {
+	return "email"
+}
+
+// Validate checks that the SMTP server and addresses are configured
+func (n *emailNotifier) Validate() error {
+	if n.config.SMTPHost == "" {
+		return fmt.Errorf("SMTP host is required for email alerts")
+	}
+	if n.config.SMTPPort <= 0 {
+		return fmt.Errorf("SMTP port must be positive")
+	}
+	if n.config.From == "" {
+		return fmt.Errorf("from email address is required")
+	}
+	if n.config.To == "" {
+		return fmt.Errorf("to email address is required")
+	}
+	return nil
+}
+
+// Send sends an alert via SMTP email to the recipient, by default the configured one
+func (n *emailNotifier) Send(ctx context.Context, alert types.Alert, recipient string) error {
+	emailConfig := *n.config
+	if recipient != "" {
+		emailConfig.To = recipient
+	}
+
+	// Validate email configuration
+	if emailConfig.SMTPHost == "" || emailConfig.SMTPPort == 0 {
+		return fmt.Errorf("SMTP host and port must be configured")
+	}
+	if emailConfig.From == "" || emailConfig.To == "" {
+		return fmt.Errorf("from and to email addresses must be configured")
+	}
+
+	// Build message headers
+	headers := make(map[string]string)
+	headers["From"] = emailConfig.From
+	headers["To"] = emailConfig.To
+	headers["Subject"] = alertSubject(n.appName, alert)
+	headers["Content-Type"] = "text/plain; charset=\"utf-8\""
+
+	// Build message
+	message := ""
+	for k, v := range headers {
+		message += fmt.Sprintf("%s: %s\r\n", k, v)
+	}
+	message += "\r\n" + emailBody(n.appName, alert)
+
+	// Connect to SMTP server; the whole conversation ends with the context
+	addr := fmt.Sprintf("%s:%d", emailConfig.SMTPHost, emailConfig.SMTPPort)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("SMTP dial failed: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, emailConfig.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP dial failed: %w", err)
+	}
+	defer client.Close()
+
+	// STARTTLS is required with UseTLS (e.g. Gmail) and used when offered otherwise
+	tlsconfig := &tls.Config{
+		ServerName: emailConfig.SMTPHost,
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok || emailConfig.UseTLS {
+		if err := client.StartTLS(tlsconfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	auth := smtp.PlainAuth("", emailConfig.Username, emailConfig.Password, emailConfig.SMTPHost)
+	if err := client.Auth(auth); err != nil {
+		return fmt.Errorf("SMTP auth failed: %w", err)
+	}
+
+	// Send email
+	if err := client.Mail(emailConfig.From); err != nil {
+		return fmt.Errorf("SMTP MAIL failed: %w", err)
+	}
+	if err := client.Rcpt(emailConfig.To); err != nil {
+		return fmt.Errorf("SMTP RCPT failed: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write([]byte(message)); err != nil {
+		return fmt.Errorf("SMTP message write failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP message write failed: %w", err)
+	}
+	client.Quit()
+
+	slog.Info("Email alert sent", "recipient", emailConfig.To, "tls", emailConfig.UseTLS)
+	return nil
+}
+
+// emailBody creates the email body for an alert
+func emailBody(appName string, alert types.Alert) string {
+	var body strings.Builder
+
+	body.WriteString(fmt.Sprintf("%s MONITORING ALERT\n", strings.ToUpper(appName)))
+	body.WriteString("=====================\n\n")
+	body.WriteString(fmt.Sprintf("Alert Level: %s\n", strings.ToUpper(alert.Level)))
+	body.WriteString(fmt.Sprintf("Alert Type: %s\n", alert.Type))
+	body.WriteString(fmt.Sprintf("Message: %s\n", alert.Message))
+	if len(alert.Tags) > 0 {
+		body.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(alert.Tags, ", ")))
+	}
+	for _, line := range correlationLines(alert.Correlated) {
+		body.WriteString(line + "\n")
+	}
+	body.WriteString(fmt.Sprintf("Timestamp: %s\n", alert.Timestamp.Format(time.RFC1123)))
+	body.WriteString(fmt.Sprintf("Server Time: %s\n\n", time.Now().Format(time.RFC1123)))
+	body.WriteString(fmt.Sprintf("This alert was generated by the %s monitoring service.\n", appName))
+
+	return body.String()
+}
@@ -0,0 +1,115 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"bconf.com/monic/types"
+)
+
+// mailgunNotifier sends alerts as email through the Mailgun API
+type mailgunNotifier struct {
+	config  *types.MailgunConfig
+	appName string
+	client  *http.Client
+}
+
+// newMailgunNotifier creates a Mailgun notifier
+func newMailgunNotifier(config *types.MailgunConfig, appName string) *mailgunNotifier {
+	return &mailgunNotifier{config: config, appName: appName, client: &http.Client{}}
+}
+
+// Name returns "mailgun"
+func (n *mailgunNotifier) Name() string {
+	return "mailgun"
+}
+
+// Label returns "Mailgun"
+func (n *mailgunNotifier) Label() string {
+	return "Mailgun"
+}
+
+// Validate checks that the API key, domain and addresses are configured
+func (n *mailgunNotifier) Validate() error {
+	if n.config.APIKey == "" {
+		return fmt.Errorf("API key is required for Mailgun alerts")
+	}
+	if n.config.Domain == "" {
+		return fmt.Errorf("domain is required for Mailgun alerts")
+	}
+	if n.config.From == "" {
+		return fmt.Errorf("from email address is required for Mailgun")
+	}
+	if n.config.To == "" {
+		return fmt.Errorf("to email address is required for Mailgun")
+	}
+	return nil
+}
+
+// Send sends an alert via Mailgun API to the recipient, by default the configured one
+func (n *mailgunNotifier) Send(ctx context.Context, alert types.Alert, recipient string) error {
+	mailgunConfig := *n.config
+	if recipient != "" {
+		mailgunConfig.To = recipient
+	}
+
+	// Validate Mailgun configuration
+	if mailgunConfig.APIKey == "" {
+		return fmt.Errorf("Mailgun API key must be configured")
+	}
+	if mailgunConfig.Domain == "" {
+		return fmt.Errorf("Mailgun domain must be configured")
+	}
+	if mailgunConfig.From == "" || mailgunConfig.To == "" {
+		return fmt.Errorf("from and to email addresses must be configured")
+	}
+
+	// Set default base URL if not provided
+	baseURL := mailgunConfig.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.mailgun.net/v3"
+	}
+
+	// Build request data
+	formData := map[string]string{
+		"from":    mailgunConfig.From,
+		"to":      mailgunConfig.To,
+		"subject": alertSubject(n.appName, alert),
+		"text":    emailBody(n.appName, alert),
+	}
+
+	// Create request
+	url := fmt.Sprintf("%s/%s/messages", baseURL, mailgunConfig.Domain)
+	formBytes, err := json.Marshal(formData)
+	if err != nil {
+		return fmt.Errorf("failed to encode form data: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(formBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.SetBasicAuth("api", mailgunConfig.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	// Send request
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Mailgun API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("mailgun API returned status %s: %s", resp.Status, string(body))
+	}
+
+	slog.Info("Mailgun alert sent", "recipient", mailgunConfig.To)
+	return nil
+}
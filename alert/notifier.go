@@ -0,0 +1,158 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// defaultSendTimeout limits a single delivery of a channel without a configured timeout
+const defaultSendTimeout = 30 * time.Second
+
+// Notifier delivers alerts through one channel, e.g. email or Telegram. Channels are registered
+// with the alert manager, which routes alerts to them, sends through all of them in parallel and
+// keeps their circuit breakers and delivery stats.
+type Notifier interface {
+	// Name identifies the channel in routes, stats and meta-alert types, e.g. "telegram"
+	Name() string
+	// Validate checks the configuration of the channel
+	Validate() error
+	// Send delivers an alert to a recipient, the configured one if empty, until ctx is done
+	Send(ctx context.Context, alert types.Alert, recipient string) error
+}
+
+// labeler is implemented by notifiers named differently in messages, e.g. "Mailgun"
+type labeler interface {
+	Label() string
+}
+
+// notificationChannel is a registered notifier
+type notificationChannel struct {
+	notifier Notifier
+	enabled  bool
+	timeout  time.Duration // Limit of a single delivery
+}
+
+// name returns the name of the channel's notifier
+func (c notificationChannel) name() string {
+	return c.notifier.Name()
+}
+
+// label returns the name of the channel in messages
+func (c notificationChannel) label() string {
+	if l, ok := c.notifier.(labeler); ok {
+		return l.Label()
+	}
+	return c.notifier.Name()
+}
+
+// RegisterNotifier adds an alert channel, used when enabled, that may take up to timeout per
+// delivery (default: 30s). A channel registered again under the same name is replaced.
+func (am *AlertManager) RegisterNotifier(notifier Notifier, enabled bool, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultSendTimeout
+	}
+	channel := notificationChannel{notifier: notifier, enabled: enabled, timeout: timeout}
+	name := notifier.Name()
+
+	// The stats exist before the channel, and the two locks are never held together
+	am.statsMu.Lock()
+	am.channelStats[name] = &types.NotificationChannelStats{Channel: name}
+	am.statsMu.Unlock()
+
+	am.channelsMu.Lock()
+	defer am.channelsMu.Unlock()
+
+	replaced := false
+	for i := range am.notifiers {
+		if am.notifiers[i].name() == name {
+			am.notifiers[i] = channel
+			replaced = true
+		}
+	}
+	if !replaced {
+		am.notifiers = append(am.notifiers, channel)
+	}
+
+	// Each channel gets its own circuit so one failing channel doesn't affect the others
+	am.breakers[name] = NewCircuitBreaker(am.config.CircuitBreaker)
+}
+
+// registerBuiltinNotifiers registers the channels configured in the alerting configuration
+func (am *AlertManager) registerBuiltinNotifiers() {
+	config := am.config
+	appName := am.getAppName()
+	am.RegisterNotifier(newEmailNotifier(&config.Email, appName), config.Email.Enabled, secondsTimeout(config.Email.Timeout))
+	am.RegisterNotifier(newMailgunNotifier(&config.Mailgun, appName), config.Mailgun.Enabled, secondsTimeout(config.Mailgun.Timeout))
+	am.RegisterNotifier(newTelegramNotifier(&config.Telegram, appName), config.Telegram.Enabled, secondsTimeout(config.Telegram.Timeout))
+}
+
+// secondsTimeout converts a configured timeout in seconds, 0 for the default
+func secondsTimeout(seconds int) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
+
+// channels returns the registered alert channels in the order they were registered
+func (am *AlertManager) channels() []notificationChannel {
+	am.channelsMu.RLock()
+	defer am.channelsMu.RUnlock()
+
+	channels := make([]notificationChannel, len(am.notifiers))
+	copy(channels, am.notifiers)
+	return channels
+}
+
+// breaker returns the circuit breaker of a channel
+func (am *AlertManager) breaker(name string) *CircuitBreaker {
+	am.channelsMu.RLock()
+	defer am.channelsMu.RUnlock()
+	return am.breakers[name]
+}
+
+// send runs a notifier within the channel's timeout. A notifier that doesn't return once its
+// context is done is left running, so one hanging channel can't hold up the alerting loop.
+func (c notificationChannel) send(alert types.Alert, recipient string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.notifier.Send(ctx, alert, recipient)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", c.timeout)
+	}
+}
+
+// alertSubject returns the subject line of an alert, e.g. "[Monic Alert] CRITICAL - cpu"
+func alertSubject(appName string, alert types.Alert) string {
+	return fmt.Sprintf("[%s Alert] %s - %s", appName, strings.ToUpper(alert.Level), alert.Type)
+}
+
+// correlationLines formats correlated state changes for notifications,
+// e.g. "Also failing: http_db-ping, http_redis"
+func correlationLines(changes []types.CorrelatedChange) []string {
+	var failing, recovered []string
+	for _, change := range changes {
+		if change.State == "ok" {
+			recovered = append(recovered, change.Type)
+		} else {
+			failing = append(failing, change.Type)
+		}
+	}
+
+	var lines []string
+	if len(failing) > 0 {
+		lines = append(lines, "Also failing: "+strings.Join(failing, ", "))
+	}
+	if len(recovered) > 0 {
+		lines = append(lines, "Also recovered: "+strings.Join(recovered, ", "))
+	}
+	return lines
+}
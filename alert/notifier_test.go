@@ -0,0 +1,156 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// fakeNotifier records the alerts it sends, after a delay or once released
+type fakeNotifier struct {
+	name    string
+	delay   time.Duration
+	release chan struct{} // Blocks sends, ignoring the context, until closed
+	invalid bool
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func (n *fakeNotifier) Name() string { return n.name }
+
+func (n *fakeNotifier) Validate() error {
+	if n.invalid {
+		return fmt.Errorf("%s is not configured", n.name)
+	}
+	return nil
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, alert types.Alert, recipient string) error {
+	if n.release != nil {
+		<-n.release
+	}
+	select {
+	case <-time.After(n.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, alert.Type)
+	return nil
+}
+
+func (n *fakeNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.sent)
+}
+
+func TestAlertManager_RegisterNotifier(t *testing.T) {
+	manager := NewAlertManager(&types.AlertingConfig{}, "TestApp")
+	slack := &fakeNotifier{name: "slack"}
+	pager := &fakeNotifier{name: "pager"}
+	manager.RegisterNotifier(slack, true, 0)
+	manager.RegisterNotifier(pager, false, 0)
+
+	if err := manager.ValidateConfig(); err != nil {
+		t.Errorf("Expected the registered channel to count as configured, got %v", err)
+	}
+	manager.RegisterNotifier(&fakeNotifier{name: "pager", invalid: true}, true, 0)
+	if err := manager.ValidateConfig(); err == nil || err.Error() != "pager is not configured" {
+		t.Errorf("Expected the replaced channel to be validated, got %v", err)
+	}
+	manager.RegisterNotifier(pager, false, 0)
+
+	if err := manager.SendAlert(types.Alert{Type: "cpu", Level: "critical", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("SendAlert() error = %v", err)
+	}
+	if slack.count() != 1 || pager.count() != 0 {
+		t.Errorf("Expected the alert on the enabled channel only, got %d and %d", slack.count(), pager.count())
+	}
+
+	var names []string
+	for _, stats := range manager.GetChannelStats() {
+		names = append(names, stats.Channel)
+	}
+	if got := strings.Join(names, ","); got != "email,mailgun,telegram,slack,pager" {
+		t.Errorf("Expected the built-in channels followed by the registered ones, got %s", got)
+	}
+}
+
+func TestAlertManager_SendAlertParallel(t *testing.T) {
+	manager := NewAlertManager(&types.AlertingConfig{}, "TestApp")
+	for _, name := range []string{"slack", "pager", "webhook"} {
+		manager.RegisterNotifier(&fakeNotifier{name: name, delay: 200 * time.Millisecond}, true, 0)
+	}
+
+	start := time.Now()
+	if err := manager.SendAlert(types.Alert{Type: "cpu", Level: "critical", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("SendAlert() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("Expected the channels to be sent to in parallel, took %v", elapsed)
+	}
+}
+
+func TestAlertManager_SendAlertTimeout(t *testing.T) {
+	manager := NewAlertManager(&types.AlertingConfig{}, "TestApp")
+	release := make(chan struct{})
+	defer close(release)
+	hanging := &fakeNotifier{name: "hanging", release: release}
+	working := &fakeNotifier{name: "working"}
+	manager.RegisterNotifier(hanging, true, 50*time.Millisecond)
+	manager.RegisterNotifier(working, true, 0)
+
+	err := manager.SendAlert(types.Alert{Type: "cpu", Level: "critical", Timestamp: time.Now()})
+	if err == nil || !strings.Contains(err.Error(), "hanging: timed out after 50ms") {
+		t.Errorf("Expected a timeout of the hanging channel, got %v", err)
+	}
+	if working.count() != 1 {
+		t.Error("Expected the other channel to deliver the alert")
+	}
+	for _, stats := range manager.GetChannelStats() {
+		if stats.Channel == "hanging" && stats.Failed != 1 {
+			t.Errorf("Expected the timeout as a failed delivery, got %+v", stats)
+		}
+	}
+}
+
+func TestAlertManager_RegisterWhileReadingStats(t *testing.T) {
+	manager := NewAlertManager(&types.AlertingConfig{}, "TestApp")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20000; i++ {
+			manager.RegisterNotifier(&fakeNotifier{name: fmt.Sprintf("channel-%d", i%10)}, true, 0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20000; i++ {
+			manager.GetChannelStats()
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Registering channels while reading their stats deadlocked")
+	}
+	if stats := manager.GetChannelStats(); len(stats) != 13 {
+		t.Errorf("Expected 3 built-in and 10 registered channels, got %d", len(stats))
+	}
+}
@@ -0,0 +1,182 @@
+package alert
+
+import (
+	"strings"
+
+	"bconf.com/monic/types"
+)
+
+// delivery is a channel and the recipient an alert is sent to through it
+type delivery struct {
+	channel   notificationChannel
+	recipient string // Empty for the recipient configured for the channel
+}
+
+// SetCheckTargets sets the alert channels and recipients of the configured checks by alert type.
+// They replace the routes and the global channels for the alerts of these checks.
+func (am *AlertManager) SetCheckTargets(targets map[string]types.AlertTarget) {
+	am.targetsMu.Lock()
+	defer am.targetsMu.Unlock()
+	am.checkTargets = targets
+}
+
+// SetManagedCheckTargets sets the alert targets of the checks managed at runtime, replacing the
+// previous ones whenever the checks change. Targets of configured checks take precedence.
+func (am *AlertManager) SetManagedCheckTargets(targets map[string]types.AlertTarget) {
+	am.targetsMu.Lock()
+	defer am.targetsMu.Unlock()
+	am.managedTargets = targets
+}
+
+// checkTarget returns the alert target of the check an alert type belongs to, if it has one
+func (am *AlertManager) checkTarget(alertType string) (types.AlertTarget, bool) {
+	am.targetsMu.RLock()
+	defer am.targetsMu.RUnlock()
+	if target, ok := am.checkTargets[alertType]; ok {
+		return target, true
+	}
+	target, ok := am.managedTargets[alertType]
+	return target, ok
+}
+
+// CheckTargets returns the alert targets of the configured checks that have one, by alert type
+func CheckTargets(cfg *types.Config) map[string]types.AlertTarget {
+	targets := make(map[string]types.AlertTarget)
+	add := func(alertType string, target types.AlertTarget) {
+		if !target.IsZero() {
+			targets[alertType] = target
+		}
+	}
+	for _, check := range cfg.HTTPChecks {
+		add("http_"+check.Name, check.Alert)
+	}
+	for _, check := range cfg.GRPCChecks {
+		add("grpc_"+check.Name, check.Alert)
+	}
+	for _, check := range cfg.MailChecks {
+		add(strings.ToLower(check.Protocol)+"_"+check.Name, check.Alert)
+	}
+	for _, check := range cfg.NTPChecks {
+		add("ntp_"+check.Name, check.Alert)
+	}
+	for _, check := range cfg.SNMPChecks {
+		add("snmp_"+check.Name, check.Alert)
+	}
+	for _, check := range cfg.MQTTChecks {
+		add("mqtt_"+check.Name, check.Alert)
+	}
+	for _, check := range cfg.QueueChecks {
+		add(check.Type+"_"+check.Name, check.Alert)
+	}
+	for _, check := range cfg.ScriptChecks {
+		add("script_"+check.Name, check.Alert)
+	}
+	for _, check := range cfg.Heartbeats {
+		add("heartbeat_"+check.Name, check.Alert)
+	}
+	return targets
+}
+
+// ManagedCheckTargets returns the alert targets of the managed checks that have one, by alert type
+func ManagedCheckTargets(checks []types.ManagedCheck) map[string]types.AlertTarget {
+	targets := make(map[string]types.AlertTarget)
+	for _, check := range checks {
+		if !check.Alert.IsZero() {
+			targets[check.Type+"_"+check.Name] = check.Alert
+		}
+	}
+	return targets
+}
+
+// route returns the channels and recipients of an alert: the target of its check if it has one,
+// otherwise the targets of all matching routes, and every enabled channel with its configured
+// recipient when no route matches
+func (am *AlertManager) route(alert types.Alert) []delivery {
+	var targets []types.AlertTarget
+	if target, ok := am.checkTarget(alert.Type); ok {
+		targets = append(targets, target)
+	} else {
+		for _, route := range am.config.Routes {
+			if routeMatches(route, alert) {
+				targets = append(targets, route.To)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		targets = append(targets, types.AlertTarget{})
+	}
+
+	var deliveries []delivery
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		for _, channel := range am.channels() {
+			if !channel.enabled {
+				continue
+			}
+			recipient, ok := targetRecipient(target, channel.name())
+			if !ok || seen[channel.name()+"\x00"+recipient] {
+				continue
+			}
+			seen[channel.name()+"\x00"+recipient] = true
+			deliveries = append(deliveries, delivery{channel: channel, recipient: recipient})
+		}
+	}
+	return deliveries
+}
+
+// targetRecipient returns the recipient of a target on a channel and whether the target sends
+// through the channel at all
+func targetRecipient(target types.AlertTarget, channel string) (string, bool) {
+	recipient := map[string]string{
+		"email":    target.Email,
+		"mailgun":  target.Mailgun,
+		"telegram": target.TelegramChat,
+	}[channel]
+
+	if len(target.Channels) > 0 {
+		for _, name := range target.Channels {
+			if name == channel {
+				return recipient, true
+			}
+		}
+		return "", false
+	}
+	return recipient, recipient != "" || target.IsZero()
+}
+
+// routeMatches reports whether an alert matches all conditions of a route: one of its checks,
+// where a trailing * matches a prefix of the alert type, and one of its tags, ignoring case
+func routeMatches(route types.AlertRoute, alert types.Alert) bool {
+	if len(route.Checks) > 0 && !matchesAny(route.Checks, alert.Type) {
+		return false
+	}
+	if len(route.Tags) > 0 && !sharesTag(route.Tags, alert.Tags) {
+		return false
+	}
+	return true
+}
+
+// matchesAny reports whether an alert type matches one of the patterns
+func matchesAny(patterns []string, alertType string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(alertType, prefix) {
+			return true
+		}
+		if pattern == alertType {
+			return true
+		}
+	}
+	return false
+}
+
+// sharesTag reports whether the tags have one of the wanted tags, ignoring case
+func sharesTag(wanted, tags []string) bool {
+	for _, want := range wanted {
+		for _, tag := range tags {
+			if strings.EqualFold(want, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
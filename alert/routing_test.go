@@ -0,0 +1,182 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestAlertManager_Route(t *testing.T) {
+	config := &types.AlertingConfig{
+		Email:    types.EmailConfig{Enabled: true, To: "ops@example.com"},
+		Telegram: types.TelegramConfig{Enabled: true, ChatID: "ops-chat"},
+		Routes: []types.AlertRoute{
+			{Tags: []string{"db"}, To: types.AlertTarget{TelegramChat: "dba-chat"}},
+			{Checks: []string{"docker*"}, Tags: []string{"payments"}, To: types.AlertTarget{Channels: []string{"email"}}},
+			{Checks: []string{"http_shop"}, To: types.AlertTarget{Email: "web@example.com"}},
+		},
+	}
+	manager := NewAlertManager(config, "TestApp")
+	manager.SetCheckTargets(CheckTargets(&types.Config{
+		HTTPChecks: []types.HTTPCheck{
+			{Name: "frontend", Alert: types.AlertTarget{Email: "web@example.com", TelegramChat: "web-chat"}},
+			{Name: "api"},
+		},
+		MailChecks:   []types.MailCheck{{Name: "mx", Protocol: "SMTP", Alert: types.AlertTarget{Email: "mail@example.com"}}},
+		QueueChecks:  []types.QueueCheck{{Name: "orders", Type: "rabbitmq", Alert: types.AlertTarget{TelegramChat: "orders-chat"}}},
+		ScriptChecks: []types.ScriptCheck{{Name: "backup", Alert: types.AlertTarget{Channels: []string{"telegram"}}}},
+	}))
+	manager.SetManagedCheckTargets(ManagedCheckTargets([]types.ManagedCheck{
+		{Name: "db", Type: "tcp", Alert: types.AlertTarget{TelegramChat: "dba-chat"}},
+		{Name: "frontend", Type: "http", Alert: types.AlertTarget{Email: "managed@example.com"}},
+		{Name: "status", Type: "http", Alert: types.AlertTarget{Email: "status@example.com"}},
+	}))
+
+	tests := []struct {
+		name  string
+		alert types.Alert
+		want  []string
+	}{
+		{
+			name:  "no matching route",
+			alert: types.Alert{Type: "cpu"},
+			want:  []string{"email:", "telegram:"},
+		},
+		{
+			name:  "route by tag ignoring case",
+			alert: types.Alert{Type: "http_db-ping", Tags: []string{"DB"}},
+			want:  []string{"telegram:dba-chat"},
+		},
+		{
+			name:  "route by check and tag",
+			alert: types.Alert{Type: "docker", Tags: []string{"payments"}},
+			want:  []string{"email:"},
+		},
+		{
+			name:  "check of the route without its tag",
+			alert: types.Alert{Type: "docker"},
+			want:  []string{"email:", "telegram:"},
+		},
+		{
+			name:  "all matching routes",
+			alert: types.Alert{Type: "http_shop", Tags: []string{"db"}},
+			want:  []string{"telegram:dba-chat", "email:web@example.com"},
+		},
+		{
+			name:  "check target replaces the routes",
+			alert: types.Alert{Type: "http_frontend", Tags: []string{"db"}},
+			want:  []string{"email:web@example.com", "telegram:web-chat"},
+		},
+		{
+			name:  "check without target",
+			alert: types.Alert{Type: "http_api"},
+			want:  []string{"email:", "telegram:"},
+		},
+		{
+			name:  "mail check target",
+			alert: types.Alert{Type: "smtp_mx"},
+			want:  []string{"email:mail@example.com"},
+		},
+		{
+			name:  "queue check target",
+			alert: types.Alert{Type: "rabbitmq_orders", Tags: []string{"db"}},
+			want:  []string{"telegram:orders-chat"},
+		},
+		{
+			name:  "script check channel",
+			alert: types.Alert{Type: "script_backup"},
+			want:  []string{"telegram:"},
+		},
+		{
+			name:  "managed check target",
+			alert: types.Alert{Type: "tcp_db"},
+			want:  []string{"telegram:dba-chat"},
+		},
+		{
+			name:  "managed HTTP check target",
+			alert: types.Alert{Type: "http_status", Tags: []string{"db"}},
+			want:  []string{"email:status@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range manager.route(tt.alert) {
+				got = append(got, d.channel.name()+":"+d.recipient)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("route() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlertManager_SendAlert_Recipient(t *testing.T) {
+	var recipients []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var form map[string]string
+		json.NewDecoder(r.Body).Decode(&form)
+		recipients = append(recipients, form["to"])
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &types.AlertingConfig{
+		Mailgun: types.MailgunConfig{
+			Enabled: true,
+			APIKey:  "test-key",
+			Domain:  "example.com",
+			From:    "monic@example.com",
+			To:      "admin@example.com",
+			BaseURL: server.URL,
+		},
+		Routes: []types.AlertRoute{
+			{Tags: []string{"frontend"}, To: types.AlertTarget{Mailgun: "web@example.com"}},
+		},
+	}
+	manager := NewAlertManager(config, "TestApp")
+
+	alerts := []types.Alert{
+		{Type: "http_shop", Level: "critical", Tags: []string{"frontend"}, Timestamp: time.Now()},
+		{Type: "cpu", Level: "warning", Timestamp: time.Now()},
+	}
+	if err := manager.SendAlerts(alerts); err != nil {
+		t.Fatalf("SendAlerts() error = %v", err)
+	}
+
+	want := []string{"web@example.com", "admin@example.com"}
+	if !reflect.DeepEqual(recipients, want) {
+		t.Errorf("recipients = %v, want %v", recipients, want)
+	}
+}
+
+func TestAlertManager_SetManagedCheckTargetsWhileRouting(t *testing.T) {
+	config := &types.AlertingConfig{Email: types.EmailConfig{Enabled: true, To: "ops@example.com"}}
+	manager := NewAlertManager(config, "TestApp")
+
+	// Managed checks change while alerts are routed by the goroutines sending them (run with -race)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			manager.SetManagedCheckTargets(ManagedCheckTargets([]types.ManagedCheck{
+				{Name: "db", Type: "tcp", Alert: types.AlertTarget{Email: "dba@example.com"}},
+			}))
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		manager.route(types.Alert{Type: "tcp_db"})
+	}
+	<-done
+
+	deliveries := manager.route(types.Alert{Type: "tcp_db"})
+	if len(deliveries) != 1 || deliveries[0].recipient != "dba@example.com" {
+		t.Errorf("Expected the managed check's target, got %+v", deliveries)
+	}
+}
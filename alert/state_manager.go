@@ -1,25 +1,66 @@
 package alert
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"bconf.com/monic/types"
 )
 
+// Correlation hints: state changes of other checks within correlationWindow (before or after)
+// of an alert's own state change are attached to the alert to speed up root-cause triage
+const (
+	correlationWindow     = 2 * time.Minute
+	correlationHistory    = time.Hour // How long state transitions are remembered
+	maxCorrelationHistory = 1000
+)
+
+// stateTransition records a single state change
+type stateTransition struct {
+	alertType string
+	state     string
+	at        time.Time
+}
+
+// restartRecoveryChecks is the number of checks without restarts after which a restart loop is over
+const restartRecoveryChecks = 3
+
+// containerRestarts is the restart count of a container at the previous check
+type containerRestarts struct {
+	containerID string
+	count       int
+}
+
 // StateManager handles alert state tracking and deduplication
 type StateManager struct {
+	// mu guards states and restarts. Checks run concurrently, so every exported method holds it
+	// while it reads or updates them; the unexported helpers expect it to be held.
+	mu     sync.Mutex
 	states map[string]*types.AlertState
+
+	// Restart counts of the previous check per container name
+	restarts map[string]containerRestarts
+
+	transitionsMu sync.Mutex
+	transitions   []stateTransition // Recent state changes, oldest first
 }
 
 // NewStateManager creates a new state manager instance
 func NewStateManager() *StateManager {
 	return &StateManager{
-		states: make(map[string]*types.AlertState),
+		states:   make(map[string]*types.AlertState),
+		restarts: make(map[string]containerRestarts),
 	}
 }
 
 // UpdateSystemState updates the state for system metrics and returns alerts if needed
 func (sm *StateManager) UpdateSystemState(stats *types.SystemStats, thresholds *types.SystemChecksConfig) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
 	var alerts []types.Alert
 	now := time.Now()
 
@@ -37,6 +78,24 @@ func (sm *StateManager) UpdateSystemState(stats *types.SystemStats, thresholds *
 		alerts = append(alerts, *memoryAlert)
 	}
 
+	// Check swap (only when a swap threshold is configured and the host has swap)
+	if thresholds.SwapThreshold > 0 && stats.SwapUsage.Total > 0 {
+		swapState := sm.getOrCreateState("swap")
+		swapAlert := sm.checkSystemMetric(swapState, "swap", stats.SwapUsage.UsedPercent, float64(thresholds.SwapThreshold), now)
+		if swapAlert != nil {
+			alerts = append(alerts, *swapAlert)
+		}
+	}
+
+	// Check load average per core (only when a load threshold is configured)
+	if thresholds.LoadThreshold > 0 && stats.LoadAverage.Cores > 0 {
+		loadState := sm.getOrCreateState("load")
+		loadAlert := sm.checkSystemMetric(loadState, "load", stats.LoadAverage.PerCore(), thresholds.LoadThreshold, now)
+		if loadAlert != nil {
+			alerts = append(alerts, *loadAlert)
+		}
+	}
+
 	// Check Disk for each path
 	for path, diskStats := range stats.DiskUsage {
 		diskState := sm.getOrCreateState("disk_" + path)
@@ -44,6 +103,374 @@ func (sm *StateManager) UpdateSystemState(stats *types.SystemStats, thresholds *
 		if diskAlert != nil {
 			alerts = append(alerts, *diskAlert)
 		}
+
+		// Check inodes (only when an inode threshold is configured and the filesystem reports inodes)
+		if thresholds.InodeThreshold > 0 && diskStats.InodesTotal > 0 {
+			inodeState := sm.getOrCreateState("inode_" + path)
+			inodeAlert := sm.checkSystemMetric(inodeState, "inode_"+path, diskStats.InodesUsedPercent, float64(thresholds.InodeThreshold), now)
+			if inodeAlert != nil {
+				alerts = append(alerts, *inodeAlert)
+			}
+		}
+	}
+
+	// Check disk I/O await per device (only when an await threshold is configured)
+	if thresholds.DiskAwaitThreshold > 0 {
+		for device, ioStats := range stats.DiskIO {
+			ioState := sm.getOrCreateState("diskio_" + device)
+			ioAlert := sm.checkSystemMetric(ioState, "diskio_"+device, ioStats.AwaitMs, thresholds.DiskAwaitThreshold, now)
+			if ioAlert != nil {
+				alerts = append(alerts, *ioAlert)
+			}
+		}
+	}
+
+	// Every system alert carries the tags of the system checks
+	for i := range alerts {
+		alerts[i].Tags = thresholds.Tags
+	}
+	return alerts
+}
+
+// UpdateDockerResourceState checks container CPU and memory usage against their thresholds and returns alerts if needed
+func (sm *StateManager) UpdateDockerResourceState(stats []types.DockerContainerStats, config *types.DockerConfig) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	var alerts []types.Alert
+	now := time.Now()
+
+	for _, container := range stats {
+		if !container.Running {
+			continue
+		}
+		cpuThreshold, memoryThreshold := config.ThresholdsFor(container.Name)
+
+		if cpuThreshold > 0 {
+			cpuState := sm.getOrCreateState("container_cpu_" + container.Name)
+			cpuAlert := sm.checkSystemMetric(cpuState, "container_cpu_"+container.Name, container.CPUPercent, cpuThreshold, now)
+			if cpuAlert != nil {
+				cpuAlert.Tags = container.Tags
+				alerts = append(alerts, *cpuAlert)
+			}
+		}
+
+		// Containers without a memory limit report the host memory as limit
+		if memoryThreshold > 0 && container.MemoryLimit > 0 {
+			memoryState := sm.getOrCreateState("container_memory_" + container.Name)
+			memoryAlert := sm.checkSystemMetric(memoryState, "container_memory_"+container.Name, container.MemoryPercent, memoryThreshold, now)
+			if memoryAlert != nil {
+				memoryAlert.Tags = container.Tags
+				alerts = append(alerts, *memoryAlert)
+			}
+		}
+	}
+
+	return alerts
+}
+
+// UpdateDockerDiskState checks Docker's total disk usage (images, containers, volumes and build
+// cache) against the threshold in GB and returns alerts if needed
+func (sm *StateManager) UpdateDockerDiskState(usage *types.DockerDiskUsage, thresholdGB float64) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if usage == nil || thresholdGB <= 0 {
+		return nil
+	}
+
+	state := sm.getOrCreateState("docker_disk")
+	totalGB := float64(usage.Total()) / (1024 * 1024 * 1024)
+	if alert := sm.checkSystemMetric(state, "docker_disk", totalGB, thresholdGB, usage.Timestamp); alert != nil {
+		return []types.Alert{*alert}
+	}
+	return nil
+}
+
+// UpdateComposeState alerts when the number of running containers of a compose service differs
+// from its expected replicas. Like other checks, a mismatch has to persist for 3 checks, so
+// rolling deployments and restarts do not alert.
+func (sm *StateManager) UpdateComposeState(services []types.ComposeServiceStatus) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	var alerts []types.Alert
+	now := time.Now()
+
+	for _, service := range services {
+		if service.Expected == 0 {
+			continue
+		}
+		alertType := "compose_" + service.Project + "_" + service.Service
+		state := sm.getOrCreateState(alertType)
+
+		currentState := "ok"
+		message := "Compose service " + service.Project + "/" + service.Service + " has " + itoa(service.Running) + " running replicas as expected"
+		if service.Running != service.Expected {
+			currentState = "critical"
+			message = "Compose service " + service.Project + "/" + service.Service + " has " + itoa(service.Running) +
+				" running replicas, expected " + itoa(service.Expected)
+		}
+
+		if alert := sm.updateState(state, alertType, currentState, message, now); alert != nil {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	return alerts
+}
+
+// UpdateDockerRestartState alerts when a container's restart count grows by at least the restart
+// threshold between two checks, i.e. the container is restart-looping. The alert is sent on the
+// first such check; a recovery follows after restartRecoveryChecks checks without restarts.
+func (sm *StateManager) UpdateDockerRestartState(stats []types.DockerContainerStats, config *types.DockerConfig) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	threshold := config.RestartThreshold
+	if threshold < 0 {
+		return nil
+	}
+	if threshold == 0 {
+		threshold = 1
+	}
+
+	var alerts []types.Alert
+	now := time.Now()
+	seen := make(map[string]bool)
+
+	for _, container := range stats {
+		seen[container.Name] = true
+		previous, exists := sm.restarts[container.Name]
+		sm.restarts[container.Name] = containerRestarts{containerID: container.ContainerID, count: container.RestartCount}
+
+		// A recreated container starts counting from zero again
+		if !exists || previous.containerID != container.ContainerID {
+			continue
+		}
+		restarted := container.RestartCount - previous.count
+
+		alertType := "container_restart_" + container.Name
+		state := sm.getOrCreateState(alertType)
+
+		if restarted >= threshold {
+			if state.CurrentState == "critical" {
+				state.ConsecutiveChecks = 0 // Restarting again postpones the recovery
+				continue
+			}
+			state.CurrentState = "critical"
+			state.ConsecutiveChecks = 0
+			state.LastStateChange = now
+			state.LastAlertSent = now
+			sm.recordTransition(alertType, "critical", now)
+			alerts = append(alerts, types.Alert{
+				Type:       alertType,
+				Message:    fmt.Sprintf("Container %s is restart-looping: restarted %d times since the last check (%d restarts in total)", container.Name, restarted, container.RestartCount),
+				Level:      "critical",
+				Timestamp:  now,
+				Correlated: sm.correlatedChanges(alertType, now),
+				Tags:       container.Tags,
+			})
+			continue
+		}
+
+		if state.CurrentState != "critical" {
+			continue
+		}
+		state.ConsecutiveChecks++
+		if state.ConsecutiveChecks >= restartRecoveryChecks {
+			state.CurrentState = "ok"
+			state.ConsecutiveChecks = 1
+			state.LastStateChange = now
+			state.LastAlertSent = now
+			sm.recordTransition(alertType, "ok", now)
+			alerts = append(alerts, types.Alert{
+				Type:      alertType,
+				Message:   fmt.Sprintf("Container %s stopped restarting (%d restarts in total)", container.Name, container.RestartCount),
+				Level:     "warning",
+				Timestamp: now,
+				Tags:      container.Tags,
+			})
+		}
+	}
+
+	// Forget removed containers
+	for name := range sm.restarts {
+		if !seen[name] {
+			delete(sm.restarts, name)
+		}
+	}
+
+	return alerts
+}
+
+// UpdateDockerHealthState alerts when a container's HEALTHCHECK turns unhealthy, even while it is
+// still running, and when it becomes healthy again. Docker already requires several failed probes
+// before reporting unhealthy, so the alert is sent on the transition instead of after 3 checks.
+func (sm *StateManager) UpdateDockerHealthState(stats []types.DockerContainerStats) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	var alerts []types.Alert
+	now := time.Now()
+
+	for _, container := range stats {
+		// Containers without a health check or still in their start period keep their state
+		var currentState string
+		switch container.Health {
+		case "unhealthy":
+			currentState = "critical"
+		case "healthy":
+			currentState = "ok"
+		default:
+			continue
+		}
+
+		alertType := "container_health_" + container.Name
+		state := sm.getOrCreateState(alertType)
+		if state.CurrentState == currentState {
+			state.ConsecutiveChecks++
+			continue
+		}
+
+		// Every transition to unhealthy is alerted, so leaving it always sends a recovery
+		recovered := state.CurrentState == "critical"
+		state.CurrentState = currentState
+		state.ConsecutiveChecks = 1
+		state.LastStateChange = now
+		sm.recordTransition(alertType, currentState, now)
+
+		if currentState == "critical" {
+			message := fmt.Sprintf("Container %s is unhealthy (%d failed health checks)", container.Name, container.HealthFailingStreak)
+			if container.HealthOutput != "" {
+				message += ": " + container.HealthOutput
+			}
+			state.LastAlertSent = now
+			alerts = append(alerts, types.Alert{
+				Type:       alertType,
+				Message:    message,
+				Level:      "critical",
+				Timestamp:  now,
+				Correlated: sm.correlatedChanges(alertType, now),
+				Tags:       container.Tags,
+			})
+		} else if recovered {
+			state.LastAlertSent = now
+			alerts = append(alerts, types.Alert{
+				Type:      alertType,
+				Message:   fmt.Sprintf("Container %s is healthy again", container.Name),
+				Level:     "warning",
+				Timestamp: now,
+				Tags:      container.Tags,
+			})
+		}
+	}
+
+	return alerts
+}
+
+// UpdateSLOState alerts when the error budget of a check or container is exhausted and when its
+// availability is back within the target. The availability already aggregates many samples over
+// the SLO window, so the alert is sent on the transition instead of after 3 checks.
+func (sm *StateManager) UpdateSLOState(statuses []types.SLOStatus) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	var alerts []types.Alert
+	now := time.Now()
+
+	for _, status := range statuses {
+		currentState := "ok"
+		if status.Exhausted() {
+			currentState = "critical"
+		}
+
+		alertType := "slo_" + strings.ReplaceAll(status.Name, "/", "_")
+		state := sm.getOrCreateState(alertType)
+		if state.CurrentState == currentState {
+			state.ConsecutiveChecks++
+			continue
+		}
+
+		// New states start out ok, so a budget that is fine from the start does not alert
+		recovered := state.CurrentState == "critical"
+		state.CurrentState = currentState
+		state.ConsecutiveChecks = 1
+		state.LastStateChange = now
+		sm.recordTransition(alertType, currentState, now)
+
+		if currentState == "critical" {
+			state.LastAlertSent = now
+			alerts = append(alerts, types.Alert{
+				Type: alertType,
+				Message: fmt.Sprintf("SLO error budget of %s is exhausted: %.3f%% available over %dd, target %.3f%%",
+					status.Name, status.Availability, status.WindowDays, status.Target),
+				Level:      "critical",
+				Timestamp:  now,
+				Correlated: sm.correlatedChanges(alertType, now),
+			})
+		} else if recovered {
+			state.LastAlertSent = now
+			alerts = append(alerts, types.Alert{
+				Type: alertType,
+				Message: fmt.Sprintf("SLO of %s is met again: %.3f%% available over %dd, target %.3f%%",
+					status.Name, status.Availability, status.WindowDays, status.Target),
+				Level:     "warning",
+				Timestamp: now,
+			})
+		}
+	}
+
+	return alerts
+}
+
+// UpdateLoopState alerts when one of Monic's own monitoring loops stalls and when it makes
+// progress again. Stalls are already detected over several intervals, so the alert is sent on
+// the transition.
+func (sm *StateManager) UpdateLoopState(statuses []types.LoopStatus) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	var alerts []types.Alert
+	now := time.Now()
+
+	for _, status := range statuses {
+		currentState := "ok"
+		if status.Stalled {
+			currentState = "critical"
+		}
+
+		alertType := "stall_" + status.Subsystem
+		state := sm.getOrCreateState(alertType)
+		if state.CurrentState == currentState {
+			state.ConsecutiveChecks++
+			continue
+		}
+
+		recovered := state.CurrentState == "critical"
+		state.CurrentState = currentState
+		state.ConsecutiveChecks = 1
+		state.LastStateChange = now
+		sm.recordTransition(alertType, currentState, now)
+
+		if currentState == "critical" {
+			state.LastAlertSent = now
+			alerts = append(alerts, types.Alert{
+				Type: alertType,
+				Message: fmt.Sprintf("Monitoring loop %s stalled: no progress for %s (interval %s)",
+					status.Subsystem, now.Sub(status.LastProgress).Round(time.Second), status.Interval),
+				Level:     "critical",
+				Timestamp: now,
+			})
+		} else if recovered {
+			state.LastAlertSent = now
+			alerts = append(alerts, types.Alert{
+				Type:      alertType,
+				Message:   fmt.Sprintf("Monitoring loop %s is making progress again", status.Subsystem),
+				Level:     "warning",
+				Timestamp: now,
+			})
+		}
 	}
 
 	return alerts
@@ -51,11 +478,18 @@ func (sm *StateManager) UpdateSystemState(stats *types.SystemStats, thresholds *
 
 // UpdateHTTPState updates the state for HTTP checks and returns alerts if needed
 func (sm *StateManager) UpdateHTTPState(results []types.HTTPCheckResult) []types.Alert {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
 	var alerts []types.Alert
 	now := time.Now()
 
 	for _, result := range results {
-		stateKey := "http_" + result.Name
+		checkType := result.Type
+		if checkType == "" {
+			checkType = "http"
+		}
+		stateKey := checkType + "_" + result.Name
 		httpState := sm.getOrCreateState(stateKey)
 
 		// Determine current state
@@ -66,6 +500,7 @@ func (sm *StateManager) UpdateHTTPState(results []types.HTTPCheckResult) []types
 
 		alert := sm.updateState(httpState, stateKey, currentState, result.Error, now)
 		if alert != nil {
+			alert.Tags = result.Tags
 			alerts = append(alerts, *alert)
 		}
 	}
@@ -98,6 +533,7 @@ func (sm *StateManager) updateState(state *types.AlertState, alertType, currentS
 		state.CurrentState = currentState
 		state.ConsecutiveChecks = 1
 		state.LastStateChange = now
+		sm.recordTransition(alertType, currentState, now)
 	} else {
 		state.ConsecutiveChecks++
 	}
@@ -111,16 +547,70 @@ func (sm *StateManager) updateState(state *types.AlertState, alertType, currentS
 		}
 
 		return &types.Alert{
-			Type:      alertType,
-			Message:   message,
-			Level:     level,
-			Timestamp: now,
+			Type:       alertType,
+			Message:    message,
+			Level:      level,
+			Timestamp:  now,
+			Correlated: sm.correlatedChanges(alertType, state.LastStateChange),
 		}
 	}
 
 	return nil
 }
 
+// recordTransition adds a state change to the history and drops entries that are too old to correlate
+func (sm *StateManager) recordTransition(alertType, state string, now time.Time) {
+	sm.transitionsMu.Lock()
+	defer sm.transitionsMu.Unlock()
+
+	sm.transitions = append(sm.transitions, stateTransition{alertType: alertType, state: state, at: now})
+
+	cutoff := now.Add(-correlationHistory)
+	drop := 0
+	for drop < len(sm.transitions) && (sm.transitions[drop].at.Before(cutoff) || len(sm.transitions)-drop > maxCorrelationHistory) {
+		drop++
+	}
+	sm.transitions = sm.transitions[drop:]
+}
+
+// correlatedChanges returns the latest state change of every other check within the
+// correlation window around changedAt, oldest first
+func (sm *StateManager) correlatedChanges(alertType string, changedAt time.Time) []types.CorrelatedChange {
+	sm.transitionsMu.Lock()
+	defer sm.transitionsMu.Unlock()
+
+	latest := make(map[string]stateTransition)
+	for _, transition := range sm.transitions {
+		if transition.alertType == alertType {
+			continue
+		}
+		if diff := transition.at.Sub(changedAt); diff < -correlationWindow || diff > correlationWindow {
+			continue
+		}
+		latest[transition.alertType] = transition
+	}
+
+	if len(latest) == 0 {
+		return nil
+	}
+
+	changes := make([]types.CorrelatedChange, 0, len(latest))
+	for _, transition := range latest {
+		changes = append(changes, types.CorrelatedChange{
+			Type:      transition.alertType,
+			State:     transition.state,
+			Timestamp: transition.at,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].Timestamp.Equal(changes[j].Timestamp) {
+			return changes[i].Timestamp.Before(changes[j].Timestamp)
+		}
+		return changes[i].Type < changes[j].Type
+	})
+	return changes
+}
+
 // shouldSendAlert determines if an alert should be sent based on state
 func (sm *StateManager) shouldSendAlert(state *types.AlertState, now time.Time) bool {
 	// Don't send alerts for OK state
@@ -171,11 +661,33 @@ func getSystemAlertMessage(alertType string, currentValue, threshold float64) st
 		return formatSystemMessage("CPU usage", currentValue, threshold, "%")
 	case "memory":
 		return formatSystemMessage("Memory usage", currentValue, threshold, "%")
+	case "swap":
+		return formatSystemMessage("Swap usage", currentValue, threshold, "%")
+	case "load":
+		return formatSystemMessage("Load average per core", currentValue, threshold, "")
+	case "docker_disk":
+		return formatSystemMessage("Docker disk usage", currentValue, threshold, " GB")
 	default:
 		if len(alertType) > 5 && alertType[:5] == "disk_" {
 			path := alertType[5:]
 			return formatSystemMessage("Disk usage on "+path, currentValue, threshold, "%")
 		}
+		if len(alertType) > 6 && alertType[:6] == "inode_" {
+			path := alertType[6:]
+			return formatSystemMessage("Inode usage on "+path, currentValue, threshold, "%")
+		}
+		if len(alertType) > 7 && alertType[:7] == "diskio_" {
+			device := alertType[7:]
+			return formatSystemMessage("Disk I/O await on "+device, currentValue, threshold, " ms")
+		}
+		if len(alertType) > 14 && alertType[:14] == "container_cpu_" {
+			name := alertType[14:]
+			return formatSystemMessage("CPU usage of container "+name, currentValue, threshold, "%")
+		}
+		if len(alertType) > 17 && alertType[:17] == "container_memory_" {
+			name := alertType[17:]
+			return formatSystemMessage("Memory usage of container "+name, currentValue, threshold, "% of limit")
+		}
 		return formatSystemMessage(alertType, currentValue, threshold, "%")
 	}
 }
@@ -187,11 +699,33 @@ func getSystemRecoveryMessage(alertType string, currentValue, threshold float64)
 		return formatRecoveryMessage("CPU usage", currentValue, threshold, "%")
 	case "memory":
 		return formatRecoveryMessage("Memory usage", currentValue, threshold, "%")
+	case "swap":
+		return formatRecoveryMessage("Swap usage", currentValue, threshold, "%")
+	case "load":
+		return formatRecoveryMessage("Load average per core", currentValue, threshold, "")
+	case "docker_disk":
+		return formatRecoveryMessage("Docker disk usage", currentValue, threshold, " GB")
 	default:
 		if len(alertType) > 5 && alertType[:5] == "disk_" {
 			path := alertType[5:]
 			return formatRecoveryMessage("Disk usage on "+path, currentValue, threshold, "%")
 		}
+		if len(alertType) > 6 && alertType[:6] == "inode_" {
+			path := alertType[6:]
+			return formatRecoveryMessage("Inode usage on "+path, currentValue, threshold, "%")
+		}
+		if len(alertType) > 7 && alertType[:7] == "diskio_" {
+			device := alertType[7:]
+			return formatRecoveryMessage("Disk I/O await on "+device, currentValue, threshold, " ms")
+		}
+		if len(alertType) > 14 && alertType[:14] == "container_cpu_" {
+			name := alertType[14:]
+			return formatRecoveryMessage("CPU usage of container "+name, currentValue, threshold, "%")
+		}
+		if len(alertType) > 17 && alertType[:17] == "container_memory_" {
+			name := alertType[17:]
+			return formatRecoveryMessage("Memory usage of container "+name, currentValue, threshold, "% of limit")
+		}
 		return formatRecoveryMessage(alertType, currentValue, threshold, "%")
 	}
 }
@@ -236,6 +770,11 @@ func formatFloatPrecision(value float64, precision int) string {
 			multiplier *= 10
 		}
 		fracInt := int(fracPart*float64(multiplier) + 0.5)
+		// Carry rounding overflow into the integer part (e.g. 0.96 -> 1.0, not 0.10)
+		if fracInt >= multiplier {
+			intPart++
+			fracInt -= multiplier
+		}
 		fracStr = "." + itoa(fracInt)
 	}
 
@@ -260,12 +799,58 @@ func itoa(n int) string {
 	return string(buf[i:])
 }
 
-// GetStates returns all current alert states (for testing and debugging)
+// Snapshot returns copies of all alert states sorted by alert type
+func (sm *StateManager) Snapshot() []types.AlertState {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	states := make([]types.AlertState, 0, len(sm.states))
+	for _, state := range sm.states {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Type < states[j].Type
+	})
+	return states
+}
+
+// RestoreStates replaces all alert states, e.g. with the states of an imported snapshot
+func (sm *StateManager) RestoreStates(states []types.AlertState) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.states = make(map[string]*types.AlertState, len(states))
+	for _, state := range states {
+		sm.states[state.Type] = &state
+	}
+}
+
+// RemoveState forgets the state of an alert type, e.g. of a deleted check, so it no longer shows
+// as failing
+func (sm *StateManager) RemoveState(alertType string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	delete(sm.states, alertType)
+}
+
+// GetStates returns copies of all current alert states (for testing and debugging)
 func (sm *StateManager) GetStates() map[string]*types.AlertState {
-	return sm.states
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	states := make(map[string]*types.AlertState, len(sm.states))
+	for alertType, state := range sm.states {
+		copied := *state
+		states[alertType] = &copied
+	}
+	return states
 }
 
 // ResetState resets a specific alert state (for testing)
 func (sm *StateManager) ResetState(alertType string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
 	delete(sm.states, alertType)
 }
@@ -0,0 +1,444 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestStateManager_LoadThreshold(t *testing.T) {
+	manager := NewStateManager()
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100, LoadThreshold: 1.0}
+	stats := &types.SystemStats{
+		LoadAverage: types.LoadStats{Load5: 7.9, Cores: 4}, // 1.975 per core
+	}
+
+	// Alerts require 3 consecutive checks above the threshold
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateSystemState(stats, thresholds)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "load" {
+		t.Fatalf("Expected a single load alert, got %+v", alerts)
+	}
+	if alerts[0].Message != "Load average per core is 2.0 (threshold: 1.0)" {
+		t.Errorf("Unexpected message: %s", alerts[0].Message)
+	}
+
+	// State returns to ok once the load drops
+	stats.LoadAverage.Load5 = 2
+	manager.UpdateSystemState(stats, thresholds)
+	if state := manager.GetStates()["load"]; state.CurrentState != "ok" {
+		t.Errorf("Expected load state 'ok', got '%s'", state.CurrentState)
+	}
+}
+
+func TestStateManager_LoadThresholdDisabled(t *testing.T) {
+	manager := NewStateManager()
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100}
+	stats := &types.SystemStats{LoadAverage: types.LoadStats{Load5: 100, Cores: 1}}
+
+	for i := 0; i < 3; i++ {
+		manager.UpdateSystemState(stats, thresholds)
+	}
+	if _, exists := manager.GetStates()["load"]; exists {
+		t.Error("Expected no load state when the load threshold is disabled")
+	}
+}
+
+func TestStateManager_SwapThreshold(t *testing.T) {
+	manager := NewStateManager()
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100, SwapThreshold: 60}
+	stats := &types.SystemStats{
+		SwapUsage: types.SwapStats{Total: 2048, Used: 1536, UsedPercent: 75},
+	}
+
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateSystemState(stats, thresholds)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "swap" {
+		t.Fatalf("Expected a single swap alert, got %+v", alerts)
+	}
+	if alerts[0].Message != "Swap usage is 75.0% (threshold: 60.0%)" {
+		t.Errorf("Unexpected message: %s", alerts[0].Message)
+	}
+}
+
+func TestStateManager_InodeThreshold(t *testing.T) {
+	manager := NewStateManager()
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100, InodeThreshold: 90}
+	stats := &types.SystemStats{
+		DiskUsage: map[string]types.DiskStats{"/": {Path: "/", UsedPercent: 10, InodesTotal: 100, InodesUsedPercent: 96}},
+	}
+
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateSystemState(stats, thresholds)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "inode_/" {
+		t.Fatalf("Expected a single inode alert, got %+v", alerts)
+	}
+	if alerts[0].Message != "Inode usage on / is 96.0% (threshold: 90.0%)" {
+		t.Errorf("Unexpected message: %s", alerts[0].Message)
+	}
+}
+
+func TestStateManager_DiskAwaitThreshold(t *testing.T) {
+	manager := NewStateManager()
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100, DiskAwaitThreshold: 25}
+	stats := &types.SystemStats{
+		DiskIO: map[string]types.DiskIOStats{"nvme0n1": {Device: "nvme0n1", AwaitMs: 40}},
+	}
+
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateSystemState(stats, thresholds)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "diskio_nvme0n1" {
+		t.Fatalf("Expected a single disk I/O alert, got %+v", alerts)
+	}
+	if alerts[0].Message != "Disk I/O await on nvme0n1 is 40.0 ms (threshold: 25.0 ms)" {
+		t.Errorf("Unexpected message: %s", alerts[0].Message)
+	}
+}
+
+func TestStateManager_DockerResourceThresholds(t *testing.T) {
+	manager := NewStateManager()
+	config := &types.DockerConfig{
+		CPUThreshold:    80,
+		MemoryThreshold: 90,
+		Thresholds: []types.ContainerThreshold{
+			{Container: "batch", CPU: -1}, // CPU alerts disabled
+			{Container: "db", Memory: 95}, // Higher memory threshold
+		},
+	}
+	stats := []types.DockerContainerStats{
+		{Name: "web", Running: true, CPUPercent: 120, MemoryPercent: 50, MemoryLimit: 1024},
+		{Name: "batch", Running: true, CPUPercent: 400, MemoryPercent: 10, MemoryLimit: 1024},
+		{Name: "db", Running: true, CPUPercent: 10, MemoryPercent: 92, MemoryLimit: 1024},
+		{Name: "cache", Running: true, CPUPercent: 5, MemoryPercent: 93, MemoryLimit: 1024},
+		{Name: "stopped", Running: false, CPUPercent: 100},
+	}
+
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateDockerResourceState(stats, config)
+	}
+
+	types := make(map[string]string)
+	for _, alert := range alerts {
+		types[alert.Type] = alert.Message
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %+v", alerts)
+	}
+	if types["container_cpu_web"] != "CPU usage of container web is 120.0% (threshold: 80.0%)" {
+		t.Errorf("Unexpected CPU alert: %q", types["container_cpu_web"])
+	}
+	if types["container_memory_cache"] != "Memory usage of container cache is 93.0% of limit (threshold: 90.0% of limit)" {
+		t.Errorf("Unexpected memory alert: %q", types["container_memory_cache"])
+	}
+}
+
+func TestStateManager_DockerDiskThreshold(t *testing.T) {
+	manager := NewStateManager()
+	usage := &types.DockerDiskUsage{Images: 30 << 30, Volumes: 15 << 30, BuildCache: 5 << 30, Timestamp: time.Now()}
+
+	if alerts := manager.UpdateDockerDiskState(usage, 0); alerts != nil {
+		t.Errorf("Expected no alerts without a threshold, got %+v", alerts)
+	}
+
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateDockerDiskState(usage, 40)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "docker_disk" {
+		t.Fatalf("Expected a single Docker disk alert, got %+v", alerts)
+	}
+	if alerts[0].Message != "Docker disk usage is 50.0 GB (threshold: 40.0 GB)" {
+		t.Errorf("Unexpected message: %s", alerts[0].Message)
+	}
+}
+
+func TestStateManager_ComposeReplicas(t *testing.T) {
+	manager := NewStateManager()
+	services := []types.ComposeServiceStatus{
+		{Project: "shop", Service: "web", Running: 1, Expected: 3},
+		{Project: "shop", Service: "db", Running: 1, Expected: 1},
+		{Project: "shop", Service: "cron", Running: 0}, // No expectation
+	}
+
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateComposeState(services)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "compose_shop_web" {
+		t.Fatalf("Expected a single compose alert, got %+v", alerts)
+	}
+	if alerts[0].Message != "Compose service shop/web has 1 running replicas, expected 3" {
+		t.Errorf("Unexpected message: %s", alerts[0].Message)
+	}
+	if _, exists := manager.GetStates()["compose_shop_cron"]; exists {
+		t.Error("Expected services without expectation to be ignored")
+	}
+}
+
+func TestStateManager_DockerRestartLoop(t *testing.T) {
+	manager := NewStateManager()
+	config := &types.DockerConfig{}
+	check := func(id string, restarts int) []types.Alert {
+		return manager.UpdateDockerRestartState([]types.DockerContainerStats{{Name: "web", ContainerID: id, RestartCount: restarts}}, config)
+	}
+
+	// A high absolute count alone does not alert
+	if alerts := check("abc", 12); alerts != nil {
+		t.Fatalf("Expected no alert on the first check, got %+v", alerts)
+	}
+	if alerts := check("abc", 12); alerts != nil {
+		t.Fatalf("Expected no alert without new restarts, got %+v", alerts)
+	}
+
+	alerts := check("abc", 14)
+	if len(alerts) != 1 || alerts[0].Type != "container_restart_web" || alerts[0].Level != "critical" {
+		t.Fatalf("Expected a restart loop alert, got %+v", alerts)
+	}
+	if alerts[0].Message != "Container web is restart-looping: restarted 2 times since the last check (14 restarts in total)" {
+		t.Errorf("Unexpected message: %s", alerts[0].Message)
+	}
+	if alerts := check("abc", 15); alerts != nil {
+		t.Errorf("Expected a single alert while the loop continues, got %+v", alerts)
+	}
+
+	// Recovery after 3 checks without restarts
+	for i := 0; i < 2; i++ {
+		if alerts := check("abc", 15); alerts != nil {
+			t.Fatalf("Expected no recovery yet, got %+v", alerts)
+		}
+	}
+	alerts = check("abc", 15)
+	if len(alerts) != 1 || alerts[0].Message != "Container web stopped restarting (15 restarts in total)" {
+		t.Fatalf("Expected a recovery alert, got %+v", alerts)
+	}
+
+	// A recreated container starts over
+	if alerts := check("def", 0); alerts != nil {
+		t.Errorf("Expected no alert for a recreated container, got %+v", alerts)
+	}
+
+	// Negative thresholds disable the alert
+	config.RestartThreshold = -1
+	if alerts := check("def", 5); alerts != nil {
+		t.Errorf("Expected no alert when disabled, got %+v", alerts)
+	}
+}
+
+func TestStateManager_DockerHealthTransitions(t *testing.T) {
+	manager := NewStateManager()
+	container := func(health string) []types.DockerContainerStats {
+		return []types.DockerContainerStats{{Name: "web", Running: true, Health: health, HealthFailingStreak: 3, HealthOutput: "connection refused"}}
+	}
+
+	if alerts := manager.UpdateDockerHealthState(container("starting")); len(alerts) != 0 {
+		t.Errorf("Expected no alert while starting, got %+v", alerts)
+	}
+	if alerts := manager.UpdateDockerHealthState(container("healthy")); len(alerts) != 0 {
+		t.Errorf("Expected no alert for a healthy container, got %+v", alerts)
+	}
+
+	// The transition to unhealthy alerts immediately, even though the container is running
+	alerts := manager.UpdateDockerHealthState(container("unhealthy"))
+	if len(alerts) != 1 || alerts[0].Level != "critical" || alerts[0].Message != "Container web is unhealthy (3 failed health checks): connection refused" {
+		t.Fatalf("Expected an unhealthy alert, got %+v", alerts)
+	}
+	if alerts := manager.UpdateDockerHealthState(container("unhealthy")); len(alerts) != 0 {
+		t.Errorf("Expected a single alert per transition, got %+v", alerts)
+	}
+
+	alerts = manager.UpdateDockerHealthState(container("healthy"))
+	if len(alerts) != 1 || alerts[0].Message != "Container web is healthy again" {
+		t.Errorf("Expected a recovery alert, got %+v", alerts)
+	}
+}
+
+func TestStateManager_SLOTransitions(t *testing.T) {
+	manager := NewStateManager()
+	status := func(availability float64) []types.SLOStatus {
+		return []types.SLOStatus{{Name: "http/api", Availability: availability, Target: 99.9, WindowDays: 30, Samples: 1000}}
+	}
+
+	if alerts := manager.UpdateSLOState(status(99.95)); len(alerts) != 0 {
+		t.Errorf("Expected no alert while the SLO is met, got %+v", alerts)
+	}
+
+	alerts := manager.UpdateSLOState(status(99.5))
+	if len(alerts) != 1 || alerts[0].Type != "slo_http_api" || alerts[0].Level != "critical" ||
+		alerts[0].Message != "SLO error budget of http/api is exhausted: 99.500% available over 30d, target 99.900%" {
+		t.Fatalf("Expected an exhausted budget alert, got %+v", alerts)
+	}
+	if alerts := manager.UpdateSLOState(status(99.4)); len(alerts) != 0 {
+		t.Errorf("Expected a single alert per transition, got %+v", alerts)
+	}
+
+	alerts = manager.UpdateSLOState(status(99.9))
+	if len(alerts) != 1 || alerts[0].Message != "SLO of http/api is met again: 99.900% available over 30d, target 99.900%" {
+		t.Errorf("Expected a recovery alert, got %+v", alerts)
+	}
+}
+
+func TestStateManager_LoopStallTransitions(t *testing.T) {
+	manager := NewStateManager()
+	status := func(stalled bool) []types.LoopStatus {
+		return []types.LoopStatus{{Subsystem: "docker", Stalled: stalled, LastProgress: time.Now().Add(-5 * time.Minute), Interval: time.Minute}}
+	}
+
+	if alerts := manager.UpdateLoopState(status(false)); len(alerts) != 0 {
+		t.Errorf("Expected no alert while the loop makes progress, got %+v", alerts)
+	}
+
+	alerts := manager.UpdateLoopState(status(true))
+	if len(alerts) != 1 || alerts[0].Type != "stall_docker" || alerts[0].Level != "critical" ||
+		alerts[0].Message != "Monitoring loop docker stalled: no progress for 5m0s (interval 1m0s)" {
+		t.Fatalf("Expected a stall alert, got %+v", alerts)
+	}
+	if alerts := manager.UpdateLoopState(status(true)); len(alerts) != 0 {
+		t.Errorf("Expected a single alert per transition, got %+v", alerts)
+	}
+
+	alerts = manager.UpdateLoopState(status(false))
+	if len(alerts) != 1 || alerts[0].Level != "warning" || alerts[0].Message != "Monitoring loop docker is making progress again" {
+		t.Errorf("Expected a recovery alert, got %+v", alerts)
+	}
+}
+
+func TestStateManager_CorrelatedChanges(t *testing.T) {
+	manager := NewStateManager()
+	failing := []types.HTTPCheckResult{
+		{Name: "api", Success: false, Error: "timeout"},
+		{Name: "db-ping", Type: "grpc", Success: false, Error: "unavailable"},
+	}
+
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateHTTPState(failing)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %+v", alerts)
+	}
+	if len(alerts[0].Correlated) != 1 || alerts[0].Correlated[0].Type != "grpc_db-ping" || alerts[0].Correlated[0].State != "critical" {
+		t.Errorf("Expected api alert to be correlated with grpc_db-ping, got %+v", alerts[0].Correlated)
+	}
+	if len(alerts[1].Correlated) != 1 || alerts[1].Correlated[0].Type != "http_api" {
+		t.Errorf("Expected db-ping alert to be correlated with http_api, got %+v", alerts[1].Correlated)
+	}
+}
+
+func TestStateManager_CorrelationWindow(t *testing.T) {
+	manager := NewStateManager()
+	now := time.Now()
+
+	manager.recordTransition("http_old", "critical", now.Add(-10*time.Minute))
+	manager.recordTransition("http_redis", "critical", now.Add(-90*time.Second))
+	manager.recordTransition("cpu", "ok", now.Add(time.Minute))
+	manager.recordTransition("http_redis", "ok", now.Add(2*time.Minute))
+	manager.recordTransition("http_api", "critical", now)
+
+	changes := manager.correlatedChanges("http_api", now)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 correlated changes, got %+v", changes)
+	}
+	// Latest change per check, oldest first
+	if changes[0].Type != "cpu" || changes[1].Type != "http_redis" || changes[1].State != "ok" {
+		t.Errorf("Unexpected correlated changes: %+v", changes)
+	}
+}
+
+func TestStateManager_Snapshot(t *testing.T) {
+	manager := NewStateManager()
+	manager.UpdateLoopState([]types.LoopStatus{{Subsystem: "http", Stalled: true}, {Subsystem: "docker"}})
+
+	states := manager.Snapshot()
+	if len(states) != 2 || states[0].Type != "stall_docker" || states[1].Type != "stall_http" {
+		t.Fatalf("Expected states sorted by type, got %+v", states)
+	}
+
+	// Snapshots are copies, not the live states
+	states[1].CurrentState = "ok"
+	if manager.GetStates()["stall_http"].CurrentState != "critical" {
+		t.Error("Expected changes to a snapshot not to affect the state manager")
+	}
+
+	// A snapshot restores the states, e.g. in another instance
+	restored := NewStateManager()
+	restored.RestoreStates(manager.Snapshot())
+	if state := restored.GetStates()["stall_http"]; state == nil || state.CurrentState != "critical" || len(restored.GetStates()) != 2 {
+		t.Errorf("Expected restored states, got %+v", restored.GetStates())
+	}
+}
+
+func TestStateManager_AlertTags(t *testing.T) {
+	manager := NewStateManager()
+	results := []types.HTTPCheckResult{{Name: "checkout", Success: false, Error: "timeout", Tags: []string{"payment-critical"}}}
+
+	var alerts []types.Alert
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateHTTPState(results)
+	}
+	if len(alerts) != 1 || len(alerts[0].Tags) != 1 || alerts[0].Tags[0] != "payment-critical" {
+		t.Fatalf("Expected an alert with the tags of the check, got %+v", alerts)
+	}
+
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 50, MemoryThreshold: 100, DiskThreshold: 100, Tags: []string{"infra"}}
+	stats := &types.SystemStats{CPUUsage: 90}
+	for i := 0; i < 3; i++ {
+		alerts = manager.UpdateSystemState(stats, thresholds)
+	}
+	if len(alerts) != 1 || len(alerts[0].Tags) != 1 || alerts[0].Tags[0] != "infra" {
+		t.Errorf("Expected a CPU alert with the tags of the system checks, got %+v", alerts)
+	}
+}
+
+// TestStateManager_Concurrent updates and reads states from many goroutines at once, as
+// concurrently running checks do; run with -race to detect unguarded access
+func TestStateManager_Concurrent(t *testing.T) {
+	manager := NewStateManager()
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 50, MemoryThreshold: 100, DiskThreshold: 100}
+
+	var wg sync.WaitGroup
+	alerts := make([][]types.Alert, 8)
+	for i := range alerts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results := []types.HTTPCheckResult{{Name: "shared", Error: "timeout"}, {Name: fmt.Sprintf("own-%d", i), Success: true}}
+			for j := 0; j < 3; j++ {
+				alerts[i] = append(alerts[i], manager.UpdateHTTPState(results)...)
+				manager.UpdateSystemState(&types.SystemStats{CPUUsage: 90}, thresholds)
+				manager.UpdateDockerRestartState([]types.DockerContainerStats{{Name: "web", ContainerID: "abc", RestartCount: j}}, &types.DockerConfig{})
+				manager.Snapshot()
+				manager.GetStates()
+				manager.RemoveState(fmt.Sprintf("http_own-%d", i))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// 24 failed results of the shared check in any order alert once, on the third
+	var shared int
+	for _, list := range alerts {
+		for _, alert := range list {
+			if alert.Type == "http_shared" {
+				shared++
+			}
+		}
+	}
+	if shared != 1 {
+		t.Errorf("Expected one alert of the shared check, got %d", shared)
+	}
+	if state := manager.GetStates()["http_shared"]; state == nil || state.ConsecutiveChecks != 24 {
+		t.Errorf("Expected 24 consecutive failures of the shared check, got %+v", state)
+	}
+}
@@ -0,0 +1,106 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// telegramNotifier sends alerts to a chat through the Telegram Bot API
+type telegramNotifier struct {
+	config  *types.TelegramConfig
+	appName string
+	client  *http.Client
+}
+
+// newTelegramNotifier creates a Telegram notifier
+func newTelegramNotifier(config *types.TelegramConfig, appName string) *telegramNotifier {
+	return &telegramNotifier{config: config, appName: appName, client: &http.Client{}}
+}
+
+// Name returns "telegram"
+func (n *telegramNotifier) Name() string {
+	return "telegram"
+}
+
+// Label returns "Telegram"
+func (n *telegramNotifier) Label() string {
+	return "Telegram"
+}
+
+// Validate checks that the bot token and chat are configured
+func (n *telegramNotifier) Validate() error {
+	if n.config.BotToken == "" {
+		return fmt.Errorf("bot token is required for Telegram alerts")
+	}
+	if n.config.ChatID == "" {
+		return fmt.Errorf("chat ID is required for Telegram alerts")
+	}
+	return nil
+}
+
+// Send sends an alert via Telegram Bot API to the chat, by default the configured one
+func (n *telegramNotifier) Send(ctx context.Context, alert types.Alert, chatID string) error {
+	telegramConfig := *n.config
+	if chatID != "" {
+		telegramConfig.ChatID = chatID
+	}
+
+	// Validate Telegram configuration
+	if telegramConfig.BotToken == "" {
+		return fmt.Errorf("Telegram bot token must be configured")
+	}
+	if telegramConfig.ChatID == "" {
+		return fmt.Errorf("Telegram chat ID must be configured")
+	}
+
+	// Build message
+	message := fmt.Sprintf("<b>%s</b>\n\n", alertSubject(n.appName, alert))
+	message += fmt.Sprintf("Message: %s\n", alert.Message)
+	if len(alert.Tags) > 0 {
+		message += fmt.Sprintf("Tags: %s\n", strings.Join(alert.Tags, ", "))
+	}
+	for _, line := range correlationLines(alert.Correlated) {
+		message += line + "\n"
+	}
+	message += fmt.Sprintf("Time: %s", alert.Timestamp.Format(time.RFC1123))
+
+	// Create request URL
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", telegramConfig.BotToken)
+
+	// Create request body
+	reqBody := map[string]string{
+		"chat_id":    telegramConfig.ChatID,
+		"text":       message,
+		"parse_mode": "HTML",
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Telegram request: %w", err)
+	}
+
+	// Send request
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create Telegram request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Telegram API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Telegram API returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
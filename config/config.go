@@ -1,102 +1,340 @@
 package config
 
 import (
-	"os"
+	"fmt"
+	"strings"
 
 	"bconf.com/monic/types"
-
-	"github.com/joho/godotenv"
-	"github.com/kelseyhightower/envconfig"
 )
 
-// LoadConfig loads configuration from environment variables only
+// LoadConfig loads configuration from environment variables and the optional config file
+// named by MONIC_CONFIG_FILE
 func LoadConfig() (*types.Config, error) {
+	return loadConfig(osEnv, loadRemoteConfig)
+}
+
+// loadConfig loads the configuration from an environment, applying the remote configuration with
+// loadRemote
+func loadConfig(env environment, loadRemote func(env environment, rawURL string) error) (*types.Config, error) {
 	config := &types.Config{}
 
 	// Load .env file (Optional)
 	// It's okay if .env doesn't exist
-	_ = godotenv.Load()
+	loadDotEnv(env)
+
+	// Variables with a former name, e.g. MONIC_HTTPSERVER_PORT for MONIC_HTTP_SERVER_PORT
+	if err := applyEnvAliases(env); err != nil {
+		return nil, err
+	}
+
+	// Secrets mounted as files, e.g. MONIC_ALERTING_EMAIL_PASSWORD_FILE
+	if err := loadSecretFiles(env); err != nil {
+		return nil, err
+	}
+
+	// Values of the config file apply where no environment variable is set
+	if path, _ := env.Lookup(FileEnv); path != "" {
+		if err := loadConfigFile(env, path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Values of the remote configuration apply where neither a variable nor the config file sets them
+	if rawURL, _ := env.Lookup(RemoteEnv); rawURL != "" {
+		if err := loadRemote(env, rawURL); err != nil {
+			return nil, err
+		}
+	}
 
 	// Load from Environment Variables
-	if err := envconfig.Process("MONIC", config); err != nil {
+	warnUnknownEnv(env)
+	if err := processEnv(env, "MONIC", config); err != nil {
+		return nil, err
+	}
+
+	// Load list-style checks from indexed environment variables
+	httpChecks, err := loadHTTPChecks(env)
+	if err != nil {
+		return nil, err
+	}
+	config.HTTPChecks = httpChecks
+
+	grpcChecks, err := loadIndexed[types.GRPCCheck](env, "MONIC_CHECK_GRPC")
+	if err != nil {
+		return nil, err
+	}
+	config.GRPCChecks = grpcChecks
+
+	mailChecks, err := loadIndexed[types.MailCheck](env, "MONIC_CHECK_MAIL")
+	if err != nil {
+		return nil, err
+	}
+	config.MailChecks = mailChecks
+
+	ntpChecks, err := loadIndexed[types.NTPCheck](env, "MONIC_CHECK_NTP")
+	if err != nil {
+		return nil, err
+	}
+	config.NTPChecks = ntpChecks
+
+	snmpChecks, err := loadIndexed[types.SNMPCheck](env, "MONIC_CHECK_SNMP")
+	if err != nil {
+		return nil, err
+	}
+	config.SNMPChecks = snmpChecks
+
+	mqttChecks, err := loadIndexed[types.MQTTCheck](env, "MONIC_CHECK_MQTT")
+	if err != nil {
+		return nil, err
+	}
+	config.MQTTChecks = mqttChecks
+
+	queueChecks, err := loadIndexed[types.QueueCheck](env, "MONIC_CHECK_QUEUE")
+	if err != nil {
+		return nil, err
+	}
+	config.QueueChecks = queueChecks
+
+	scriptChecks, err := loadIndexed[types.ScriptCheck](env, "MONIC_CHECK_SCRIPT")
+	if err != nil {
+		return nil, err
+	}
+	config.ScriptChecks = scriptChecks
+
+	heartbeats, err := loadIndexed[types.HeartbeatCheck](env, "MONIC_HEARTBEAT")
+	if err != nil {
+		return nil, err
+	}
+	config.Heartbeats = heartbeats
+
+	checkCaches, err := loadIndexed[types.CheckCache](env, "MONIC_CHECK_CACHE")
+	if err != nil {
+		return nil, err
+	}
+	config.CheckCaches = checkCaches
+
+	peers, err := loadIndexed[types.PeerConfig](env, "MONIC_PEER")
+	if err != nil {
+		return nil, err
+	}
+	config.Peers = peers
+
+	containerThresholds, err := loadIndexed[types.ContainerThreshold](env, "MONIC_CHECK_DOCKER_THRESHOLD")
+	if err != nil {
+		return nil, err
+	}
+	config.DockerChecks.Thresholds = containerThresholds
+
+	logRules, err := loadIndexed[types.ContainerLogRule](env, "MONIC_CHECK_DOCKER_LOG")
+	if err != nil {
+		return nil, err
+	}
+	config.DockerChecks.LogRules = logRules
+
+	expectations, err := loadIndexed[types.ContainerExpectation](env, "MONIC_CHECK_DOCKER_EXPECT")
+	if err != nil {
+		return nil, err
+	}
+	config.DockerChecks.Expectations = expectations
+
+	composeReplicas, err := loadIndexed[types.ComposeReplicas](env, "MONIC_CHECK_DOCKER_COMPOSE")
+	if err != nil {
+		return nil, err
+	}
+	config.DockerChecks.Compose = composeReplicas
+
+	tagRules, err := loadIndexed[types.ContainerTags](env, "MONIC_CHECK_DOCKER_TAG")
+	if err != nil {
+		return nil, err
+	}
+	config.DockerChecks.TagRules = tagRules
+
+	routes, err := loadIndexed[types.AlertRoute](env, "MONIC_ALERTING_ROUTE")
+	if err != nil {
+		return nil, err
+	}
+	config.Alerting.Routes = routes
+
+	components, err := loadIndexed[types.StatusComponent](env, "MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT")
+	if err != nil {
+		return nil, err
+	}
+	config.HTTPServer.StatusPage.Components = components
+
+	relabel, err := loadIndexed[types.MetricRelabel](env, "MONIC_HTTP_SERVER_METRICS_RELABEL")
+	if err != nil {
 		return nil, err
 	}
+	config.HTTPServer.Metrics.Relabel = relabel
 
 	// Calculate enabled status based on environment variables
-	config = calculateEnabledStatus(config)
+	config = calculateEnabledStatus(env, config)
+
+	// Probe the SMTP server that delivers Monic's own email alerts
+	if config.CheckAlertingSMTP && config.Alerting.Email.Enabled {
+		config.MailChecks = append(config.MailChecks, alertingSMTPCheck(config.Alerting.Email))
+	}
 
 	return config, nil
 }
 
+// loadIndexed loads a list of structs from indexed environment variables,
+// e.g. MONIC_CHECK_GRPC_0_ADDRESS, MONIC_CHECK_GRPC_1_ADDRESS, ...
+// Loading stops at the first index with no variables set.
+func loadIndexed[T any](env environment, prefix string) ([]T, error) {
+	var items []T
+	for i := 0; ; i++ {
+		itemPrefix := fmt.Sprintf("%s_%d", prefix, i)
+		if !hasEnvPrefix(env, itemPrefix+"_") {
+			return items, nil
+		}
+
+		var item T
+		if err := processEnv(env, itemPrefix, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
 
-// calculateEnabledStatus determines which features are enabled based on environment variables
-func calculateEnabledStatus(config *types.Config) *types.Config {
-	
-	// Only set enabled status if not already set by envconfig
-	if !config.Alerting.Email.Enabled {
-		config.Alerting.Email.Enabled = isEmailAlertingEnabled()
+// loadHTTPChecks loads the HTTP checks: the single check of the MONIC_CHECK_HTTP_* variables,
+// if it has a URL, followed by the indexed MONIC_CHECK_HTTP_<N>_* checks
+func loadHTTPChecks(env environment) ([]types.HTTPCheck, error) {
+	var single types.HTTPCheck
+	if err := processEnv(env, "MONIC_CHECK_HTTP", &single); err != nil {
+		return nil, err
 	}
 
-	if !config.Alerting.Mailgun.Enabled {
-		config.Alerting.Mailgun.Enabled = isMailgunAlertingEnabled()
+	checks, err := loadIndexed[types.HTTPCheck](env, "MONIC_CHECK_HTTP")
+	if err != nil {
+		return nil, err
 	}
+	if single.URL != "" {
+		checks = append([]types.HTTPCheck{single}, checks...)
+	}
+	return checks, nil
+}
 
-	if !config.Alerting.Telegram.Enabled {
-		config.Alerting.Telegram.Enabled = isTelegramAlertingEnabled()
+// alertingSMTPCheck builds a mail check for the email alerting SMTP server
+func alertingSMTPCheck(email types.EmailConfig) types.MailCheck {
+	return types.MailCheck{
+		Name:     "alerting_smtp",
+		Protocol: "smtp",
+		Host:     email.SMTPHost,
+		Port:     email.SMTPPort,
+		StartTLS: email.UseTLS,
+		Username: email.Username,
+		Password: email.Password,
 	}
+}
 
-	if !config.DockerChecks.Enabled {
-		config.DockerChecks.Enabled = isDockerChecksEnabled()
+// hasEnvPrefix checks if any environment variable starts with the given prefix
+func hasEnvPrefix(env environment, prefix string) bool {
+	for _, variable := range env.Environ() {
+		if strings.HasPrefix(variable, prefix) {
+			return true
+		}
 	}
+	return false
+}
+
+// calculateEnabledStatus determines which features are enabled: the MONIC_..._ENABLED variable
+// of a feature wins when it is set, so a configured feature can be turned off, otherwise a feature
+// is enabled when any of its variables is set
+func calculateEnabledStatus(env environment, config *types.Config) *types.Config {
+	config.Alerting.Email.Enabled = featureEnabled(env, "MONIC_ALERTING_EMAIL_ENABLED", config.Alerting.Email.Enabled, emailAlertingVars)
+	config.Alerting.Mailgun.Enabled = featureEnabled(env, "MONIC_ALERTING_MAILGUN_ENABLED", config.Alerting.Mailgun.Enabled, mailgunAlertingVars)
+	config.Alerting.Telegram.Enabled = featureEnabled(env, "MONIC_ALERTING_TELEGRAM_ENABLED", config.Alerting.Telegram.Enabled, telegramAlertingVars)
+	config.DockerChecks.Enabled = featureEnabled(env, "MONIC_CHECK_DOCKER_ENABLED", config.DockerChecks.Enabled, dockerChecksVars)
+	config.HTTPServer.Enabled = featureEnabled(env, "MONIC_HTTP_SERVER_ENABLED", config.HTTPServer.Enabled, httpServerVars)
 
-	if !config.HTTPServer.Enabled {
-		config.HTTPServer.Enabled = isHTTPServerEnabled()
+	// Alerting is on unless MONIC_ALERTING_ENABLED=false turns off every channel
+	if _, set := env.Lookup("MONIC_ALERTING_ENABLED"); !set {
+		config.Alerting.Enabled = true
+	}
+	if !config.Alerting.Enabled {
+		config.Alerting.Email.Enabled = false
+		config.Alerting.Mailgun.Enabled = false
+		config.Alerting.Telegram.Enabled = false
 	}
 
 	return config
 }
 
-// isAlertingEnabled checks if any alerting environment variables are set
-func isAlertingEnabled() bool {
-	return isEmailAlertingEnabled() || isMailgunAlertingEnabled() || isTelegramAlertingEnabled() ||
-		os.Getenv("MONIC_ALERTING_LEVELS") != "" || os.Getenv("MONIC_ALERTING_COOLDOWN") != ""
+// featureEnabled returns the value of the enable variable of a feature if it is set, otherwise
+// whether any of the variables of the feature is set
+func featureEnabled(env environment, enableVar string, enabled bool, vars []string) bool {
+	if _, set := env.Lookup(enableVar); set {
+		return enabled
+	}
+	return anyEnvSet(env, vars)
+}
+
+// The variables that enable a feature when any of them is set. They must be settings of the
+// variable scheme (see knownEnvPattern).
+var (
+	emailAlertingVars = []string{
+		"MONIC_ALERTING_EMAIL_SMTP_HOST",
+		"MONIC_ALERTING_EMAIL_SMTP_PORT",
+		"MONIC_ALERTING_EMAIL_USERNAME",
+		"MONIC_ALERTING_EMAIL_PASSWORD",
+		"MONIC_ALERTING_EMAIL_FROM",
+		"MONIC_ALERTING_EMAIL_TO",
+		"MONIC_ALERTING_EMAIL_USE_TLS",
+	}
+	mailgunAlertingVars = []string{
+		"MONIC_ALERTING_MAILGUN_API_KEY",
+		"MONIC_ALERTING_MAILGUN_DOMAIN",
+		"MONIC_ALERTING_MAILGUN_FROM",
+		"MONIC_ALERTING_MAILGUN_TO",
+		"MONIC_ALERTING_MAILGUN_BASE_URL",
+	}
+	telegramAlertingVars = []string{
+		"MONIC_ALERTING_TELEGRAM_BOT_TOKEN",
+		"MONIC_ALERTING_TELEGRAM_CHAT_ID",
+	}
+	dockerChecksVars = []string{
+		"MONIC_CHECK_DOCKER_INTERVAL",
+		"MONIC_CHECK_DOCKER_CONTAINERS",
+	}
+	httpServerVars = []string{
+		"MONIC_HTTP_SERVER_PORT",
+		"MONIC_HTTP_SERVER_USERNAME",
+		"MONIC_HTTP_SERVER_PASSWORD",
+	}
+)
+
+// anyEnvSet checks if any of the variables is set to a non-empty value
+func anyEnvSet(env environment, names []string) bool {
+	for _, name := range names {
+		if value, _ := env.Lookup(name); value != "" {
+			return true
+		}
+	}
+	return false
 }
 
 // isEmailAlertingEnabled checks if email alerting environment variables are set
 func isEmailAlertingEnabled() bool {
-	return os.Getenv("MONIC_ALERTING_EMAIL_SMTP_HOST") != "" ||
-		os.Getenv("MONIC_ALERTING_EMAIL_SMTP_PORT") != "" ||
-		os.Getenv("MONIC_ALERTING_EMAIL_USERNAME") != "" ||
-		os.Getenv("MONIC_ALERTING_EMAIL_PASSWORD") != "" ||
-		os.Getenv("MONIC_ALERTING_EMAIL_FROM") != "" ||
-		os.Getenv("MONIC_ALERTING_EMAIL_TO") != "" ||
-		os.Getenv("MONIC_ALERTING_EMAIL_USE_TLS") != ""
+	return anyEnvSet(osEnv, emailAlertingVars)
 }
 
 // isMailgunAlertingEnabled checks if mailgun alerting environment variables are set
 func isMailgunAlertingEnabled() bool {
-	return os.Getenv("MONIC_ALERTING_MAILGUN_API_KEY") != "" ||
-		os.Getenv("MONIC_ALERTING_MAILGUN_DOMAIN") != "" ||
-		os.Getenv("MONIC_ALERTING_MAILGUN_FROM") != "" ||
-		os.Getenv("MONIC_ALERTING_MAILGUN_TO") != "" ||
-		os.Getenv("MONIC_ALERTING_MAILGUN_BASE_URL") != ""
+	return anyEnvSet(osEnv, mailgunAlertingVars)
 }
 
 // isTelegramAlertingEnabled checks if telegram alerting environment variables are set
 func isTelegramAlertingEnabled() bool {
-	return os.Getenv("MONIC_ALERTING_TELEGRAM_BOT_TOKEN") != "" ||
-		os.Getenv("MONIC_ALERTING_TELEGRAM_CHAT_ID") != ""
+	return anyEnvSet(osEnv, telegramAlertingVars)
 }
 
 // isDockerChecksEnabled checks if docker checks environment variables are set
 func isDockerChecksEnabled() bool {
-	return os.Getenv("MONIC_CHECK_DOCKER_INTERVAL") != "" ||
-		os.Getenv("MONIC_CHECK_DOCKER_CONTAINERS") != ""
+	return anyEnvSet(osEnv, dockerChecksVars)
 }
 
 // isHTTPServerEnabled checks if HTTP server environment variables are set
 func isHTTPServerEnabled() bool {
-	return os.Getenv("MONIC_HTTP_SERVER_PORT") != "" ||
-		os.Getenv("MONIC_HTTP_SERVER_USERNAME") != "" ||
-		os.Getenv("MONIC_HTTP_SERVER_PASSWORD") != ""
+	return anyEnvSet(osEnv, httpServerVars)
 }
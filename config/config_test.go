@@ -1,8 +1,23 @@
 package config
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/spf13/pflag"
 )
 
 func TestLoadConfig_EnvOnly(t *testing.T) {
@@ -56,12 +71,25 @@ func TestLoadConfig_HTTPCheckFromEnv(t *testing.T) {
 	os.Setenv("MONIC_CHECK_HTTP_TIMEOUT", "10")
 	os.Setenv("MONIC_CHECK_HTTP_EXPECTED_STATUS", "200")
 	os.Setenv("MONIC_CHECK_HTTP_INTERVAL", "30")
+	// Indexed checks follow the single one
+	os.Setenv("MONIC_CHECK_HTTP_0_NAME", "api")
+	os.Setenv("MONIC_CHECK_HTTP_0_URL", "https://api.example.com/health")
+	os.Setenv("MONIC_CHECK_HTTP_0_INTERVAL", "10")
+	os.Setenv("MONIC_CHECK_HTTP_1_NAME", "docs")
+	os.Setenv("MONIC_CHECK_HTTP_1_URL", "https://docs.example.com")
+	os.Setenv("MONIC_CHECK_HTTP_1_SCHEDULE", "@hourly")
 	defer func() {
 		os.Unsetenv("MONIC_CHECK_HTTP_URL")
 		os.Unsetenv("MONIC_CHECK_HTTP_METHOD")
 		os.Unsetenv("MONIC_CHECK_HTTP_TIMEOUT")
 		os.Unsetenv("MONIC_CHECK_HTTP_EXPECTED_STATUS")
 		os.Unsetenv("MONIC_CHECK_HTTP_INTERVAL")
+		os.Unsetenv("MONIC_CHECK_HTTP_0_NAME")
+		os.Unsetenv("MONIC_CHECK_HTTP_0_URL")
+		os.Unsetenv("MONIC_CHECK_HTTP_0_INTERVAL")
+		os.Unsetenv("MONIC_CHECK_HTTP_1_NAME")
+		os.Unsetenv("MONIC_CHECK_HTTP_1_URL")
+		os.Unsetenv("MONIC_CHECK_HTTP_1_SCHEDULE")
 	}()
 
 	// Test loading the config
@@ -70,11 +98,17 @@ func TestLoadConfig_HTTPCheckFromEnv(t *testing.T) {
 		t.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Note: HTTP checks are not currently loaded from environment variables
-	// This test verifies that the config loads without errors when HTTP check env vars are present
-	// The actual HTTP check loading would need to be implemented separately
-	if config == nil {
-		t.Error("Expected config to be loaded successfully")
+	if len(config.HTTPChecks) != 3 {
+		t.Fatalf("Expected 3 HTTP checks, got %+v", config.HTTPChecks)
+	}
+	if first := config.HTTPChecks[0]; first.Name != "" || first.URL != "http://localhost:8080/health" || first.Timeout != 10 || first.CheckInterval != 30 {
+		t.Errorf("Unexpected single HTTP check: %+v", first)
+	}
+	if api := config.HTTPChecks[1]; api.Name != "api" || api.URL != "https://api.example.com/health" || api.CheckInterval != 10 || api.Timeout != 0 {
+		t.Errorf("Unexpected first indexed HTTP check: %+v", api)
+	}
+	if docs := config.HTTPChecks[2]; docs.Name != "docs" || docs.Schedule != "@hourly" {
+		t.Errorf("Unexpected second indexed HTTP check: %+v", docs)
 	}
 }
 
@@ -140,10 +174,14 @@ func TestLoadConfig_HTTPServerConfig(t *testing.T) {
 	os.Setenv("MONIC_HTTP_SERVER_PORT", "8080")
 	os.Setenv("MONIC_HTTP_SERVER_USERNAME", "admin")
 	os.Setenv("MONIC_HTTP_SERVER_PASSWORD", "secret123")
+	os.Setenv("MONIC_HTTP_SERVER_BIND_ADDRESS", "127.0.0.1")
+	os.Setenv("MONIC_HTTP_SERVER_BASE_PATH", "/monic")
 	defer func() {
 		os.Unsetenv("MONIC_HTTP_SERVER_PORT")
 		os.Unsetenv("MONIC_HTTP_SERVER_USERNAME")
 		os.Unsetenv("MONIC_HTTP_SERVER_PASSWORD")
+		os.Unsetenv("MONIC_HTTP_SERVER_BIND_ADDRESS")
+		os.Unsetenv("MONIC_HTTP_SERVER_BASE_PATH")
 	}()
 
 	// Test loading the config
@@ -165,6 +203,9 @@ func TestLoadConfig_HTTPServerConfig(t *testing.T) {
 	if config.HTTPServer.Password != "secret123" {
 		t.Errorf("Expected HTTP server password 'secret123', got '%s'", config.HTTPServer.Password)
 	}
+	if config.HTTPServer.BindAddress != "127.0.0.1" || config.HTTPServer.BasePath != "/monic" {
+		t.Errorf("Expected bind address 127.0.0.1 and base path /monic, got '%s' and '%s'", config.HTTPServer.BindAddress, config.HTTPServer.BasePath)
+	}
 }
 
 func TestLoadConfig_HTTPServerEnabledByPort(t *testing.T) {
@@ -204,3 +245,1286 @@ func TestLoadConfig_HTTPServerDisabledByDefault(t *testing.T) {
 		t.Error("Expected HTTP server to be disabled by default when no environment variables are set")
 	}
 }
+
+func TestLoadConfig_GRPCChecksFromEnv(t *testing.T) {
+	// Set indexed gRPC check environment variables
+	os.Setenv("MONIC_CHECK_GRPC_0_NAME", "api")
+	os.Setenv("MONIC_CHECK_GRPC_0_ADDRESS", "localhost:50051")
+	os.Setenv("MONIC_CHECK_GRPC_1_NAME", "payments")
+	os.Setenv("MONIC_CHECK_GRPC_1_ADDRESS", "payments:443")
+	os.Setenv("MONIC_CHECK_GRPC_1_SERVICE", "payments.v1.Payments")
+	os.Setenv("MONIC_CHECK_GRPC_1_TLS", "true")
+	defer func() {
+		os.Unsetenv("MONIC_CHECK_GRPC_0_NAME")
+		os.Unsetenv("MONIC_CHECK_GRPC_0_ADDRESS")
+		os.Unsetenv("MONIC_CHECK_GRPC_1_NAME")
+		os.Unsetenv("MONIC_CHECK_GRPC_1_ADDRESS")
+		os.Unsetenv("MONIC_CHECK_GRPC_1_SERVICE")
+		os.Unsetenv("MONIC_CHECK_GRPC_1_TLS")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.GRPCChecks) != 2 {
+		t.Fatalf("Expected 2 gRPC checks, got %d", len(config.GRPCChecks))
+	}
+	if config.GRPCChecks[0].Name != "api" || config.GRPCChecks[0].Address != "localhost:50051" {
+		t.Errorf("Unexpected first gRPC check: %+v", config.GRPCChecks[0])
+	}
+	if config.GRPCChecks[1].Service != "payments.v1.Payments" || !config.GRPCChecks[1].TLS {
+		t.Errorf("Unexpected second gRPC check: %+v", config.GRPCChecks[1])
+	}
+}
+
+func TestLoadConfig_NTPChecksFromEnv(t *testing.T) {
+	os.Setenv("MONIC_CHECK_NTP_0_NAME", "clock")
+	os.Setenv("MONIC_CHECK_NTP_0_SERVER", "pool.ntp.org")
+	os.Setenv("MONIC_CHECK_NTP_0_MAX_DRIFT", "250")
+	defer func() {
+		os.Unsetenv("MONIC_CHECK_NTP_0_NAME")
+		os.Unsetenv("MONIC_CHECK_NTP_0_SERVER")
+		os.Unsetenv("MONIC_CHECK_NTP_0_MAX_DRIFT")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.NTPChecks) != 1 {
+		t.Fatalf("Expected 1 NTP check, got %d", len(config.NTPChecks))
+	}
+	if config.NTPChecks[0].Server != "pool.ntp.org" || config.NTPChecks[0].MaxDrift != 250 {
+		t.Errorf("Unexpected NTP check: %+v", config.NTPChecks[0])
+	}
+}
+
+func TestLoadConfig_SNMPChecksFromEnv(t *testing.T) {
+	os.Setenv("MONIC_CHECK_SNMP_0_NAME", "ups-battery")
+	os.Setenv("MONIC_CHECK_SNMP_0_TARGET", "10.0.0.20")
+	os.Setenv("MONIC_CHECK_SNMP_0_OID", "1.3.6.1.2.1.33.1.2.4.0")
+	os.Setenv("MONIC_CHECK_SNMP_0_MIN", "50")
+	defer func() {
+		os.Unsetenv("MONIC_CHECK_SNMP_0_NAME")
+		os.Unsetenv("MONIC_CHECK_SNMP_0_TARGET")
+		os.Unsetenv("MONIC_CHECK_SNMP_0_OID")
+		os.Unsetenv("MONIC_CHECK_SNMP_0_MIN")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.SNMPChecks) != 1 {
+		t.Fatalf("Expected 1 SNMP check, got %d", len(config.SNMPChecks))
+	}
+	check := config.SNMPChecks[0]
+	if check.Target != "10.0.0.20" || check.Min == nil || *check.Min != 50 || check.Max != nil {
+		t.Errorf("Unexpected SNMP check: %+v", check)
+	}
+}
+
+func TestLoadConfig_MQTTChecksFromEnv(t *testing.T) {
+	os.Setenv("MONIC_CHECK_MQTT_0_NAME", "sensors")
+	os.Setenv("MONIC_CHECK_MQTT_0_BROKER", "tcp://mqtt:1883")
+	os.Setenv("MONIC_CHECK_MQTT_0_TOPIC", "sensors/#")
+	os.Setenv("MONIC_CHECK_MQTT_0_WINDOW", "300")
+	defer func() {
+		os.Unsetenv("MONIC_CHECK_MQTT_0_NAME")
+		os.Unsetenv("MONIC_CHECK_MQTT_0_BROKER")
+		os.Unsetenv("MONIC_CHECK_MQTT_0_TOPIC")
+		os.Unsetenv("MONIC_CHECK_MQTT_0_WINDOW")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.MQTTChecks) != 1 {
+		t.Fatalf("Expected 1 MQTT check, got %d", len(config.MQTTChecks))
+	}
+	if config.MQTTChecks[0].Broker != "tcp://mqtt:1883" || config.MQTTChecks[0].Window != 300 {
+		t.Errorf("Unexpected MQTT check: %+v", config.MQTTChecks[0])
+	}
+}
+
+func TestLoadConfig_QueueChecksFromEnv(t *testing.T) {
+	os.Setenv("MONIC_CHECK_QUEUE_0_NAME", "billing-lag")
+	os.Setenv("MONIC_CHECK_QUEUE_0_TYPE", "kafka")
+	os.Setenv("MONIC_CHECK_QUEUE_0_BROKERS", "kafka-1:9092,kafka-2:9092")
+	os.Setenv("MONIC_CHECK_QUEUE_0_MAX_BACKLOG", "50000")
+	defer func() {
+		os.Unsetenv("MONIC_CHECK_QUEUE_0_NAME")
+		os.Unsetenv("MONIC_CHECK_QUEUE_0_TYPE")
+		os.Unsetenv("MONIC_CHECK_QUEUE_0_BROKERS")
+		os.Unsetenv("MONIC_CHECK_QUEUE_0_MAX_BACKLOG")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.QueueChecks) != 1 {
+		t.Fatalf("Expected 1 queue check, got %d", len(config.QueueChecks))
+	}
+	check := config.QueueChecks[0]
+	if check.Type != "kafka" || len(check.Brokers) != 2 || check.Brokers[1] != "kafka-2:9092" || check.MaxBacklog != 50000 {
+		t.Errorf("Unexpected queue check: %+v", check)
+	}
+}
+
+func TestLoadConfig_ScriptChecksFromEnv(t *testing.T) {
+	os.Setenv("MONIC_CHECK_SCRIPT_0_NAME", "replication")
+	os.Setenv("MONIC_CHECK_SCRIPT_0_COMMAND", "/usr/lib/nagios/plugins/check_pgsql")
+	os.Setenv("MONIC_CHECK_SCRIPT_0_ARGS", "-H,db,-w,5")
+	os.Setenv("MONIC_CHECK_SCRIPT_0_FORMAT", "nagios")
+	defer func() {
+		os.Unsetenv("MONIC_CHECK_SCRIPT_0_NAME")
+		os.Unsetenv("MONIC_CHECK_SCRIPT_0_COMMAND")
+		os.Unsetenv("MONIC_CHECK_SCRIPT_0_ARGS")
+		os.Unsetenv("MONIC_CHECK_SCRIPT_0_FORMAT")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.ScriptChecks) != 1 {
+		t.Fatalf("Expected 1 script check, got %d", len(config.ScriptChecks))
+	}
+	check := config.ScriptChecks[0]
+	if check.Name != "replication" || check.Command != "/usr/lib/nagios/plugins/check_pgsql" || check.Format != "nagios" {
+		t.Errorf("Unexpected script check: %+v", check)
+	}
+	if len(check.Args) != 4 || check.Args[1] != "db" {
+		t.Errorf("Unexpected script arguments: %v", check.Args)
+	}
+}
+
+func TestLoadConfig_StatusPageComponentsFromEnv(t *testing.T) {
+	os.Setenv("MONIC_HTTP_SERVER_STATUS_PAGE_ENABLED", "true")
+	os.Setenv("MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_0_NAME", "API")
+	os.Setenv("MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_0_CHECKS", "api-eu,grpc/api-us")
+	os.Setenv("MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_1_NAME", "Maintenance")
+	os.Setenv("MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_1_TEXT", "Planned upgrade")
+	defer func() {
+		os.Unsetenv("MONIC_HTTP_SERVER_STATUS_PAGE_ENABLED")
+		os.Unsetenv("MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_0_NAME")
+		os.Unsetenv("MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_0_CHECKS")
+		os.Unsetenv("MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_1_NAME")
+		os.Unsetenv("MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_1_TEXT")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	statusPage := config.HTTPServer.StatusPage
+	if !statusPage.Enabled || len(statusPage.Components) != 2 {
+		t.Fatalf("Unexpected status page config: %+v", statusPage)
+	}
+	if len(statusPage.Components[0].Checks) != 2 || statusPage.Components[1].Text != "Planned upgrade" {
+		t.Errorf("Unexpected components: %+v", statusPage.Components)
+	}
+}
+
+func TestLoadConfig_MetricsFromEnv(t *testing.T) {
+	os.Setenv("MONIC_HTTP_SERVER_METRICS_CHECK_LABELS", "type,url")
+	os.Setenv("MONIC_HTTP_SERVER_METRICS_LABELS", "host:web-01,env:prod")
+	os.Setenv("MONIC_HTTP_SERVER_METRICS_RELABEL_0_ACTION", "drop")
+	os.Setenv("MONIC_HTTP_SERVER_METRICS_RELABEL_0_SOURCE_LABEL", "__name__")
+	os.Setenv("MONIC_HTTP_SERVER_METRICS_RELABEL_0_REGEX", "monic_cycle_.*")
+	defer func() {
+		os.Unsetenv("MONIC_HTTP_SERVER_METRICS_CHECK_LABELS")
+		os.Unsetenv("MONIC_HTTP_SERVER_METRICS_LABELS")
+		os.Unsetenv("MONIC_HTTP_SERVER_METRICS_RELABEL_0_ACTION")
+		os.Unsetenv("MONIC_HTTP_SERVER_METRICS_RELABEL_0_SOURCE_LABEL")
+		os.Unsetenv("MONIC_HTTP_SERVER_METRICS_RELABEL_0_REGEX")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	metrics := config.HTTPServer.Metrics
+	if len(metrics.CheckLabels) != 2 || metrics.Labels["host"] != "web-01" || metrics.Labels["env"] != "prod" {
+		t.Errorf("Unexpected metrics labels: %+v", metrics)
+	}
+	if len(metrics.Relabel) != 1 || metrics.Relabel[0].SourceLabel != "__name__" || metrics.Relabel[0].Regex != "monic_cycle_.*" {
+		t.Errorf("Unexpected relabel rules: %+v", metrics.Relabel)
+	}
+}
+
+func TestLoadConfig_AgentFromEnv(t *testing.T) {
+	os.Setenv("MONIC_AGENT_SERVER_URL", "https://monic.example.com")
+	os.Setenv("MONIC_AGENT_HOST", "web-01")
+	os.Setenv("MONIC_AGENT_SECRET", "agent-secret")
+	os.Setenv("MONIC_HTTP_SERVER_AGENTS_ENABLED", "true")
+	os.Setenv("MONIC_HTTP_SERVER_AGENTS_MISSED_REPORTS", "5")
+	defer func() {
+		os.Unsetenv("MONIC_AGENT_SERVER_URL")
+		os.Unsetenv("MONIC_AGENT_HOST")
+		os.Unsetenv("MONIC_AGENT_SECRET")
+		os.Unsetenv("MONIC_HTTP_SERVER_AGENTS_ENABLED")
+		os.Unsetenv("MONIC_HTTP_SERVER_AGENTS_MISSED_REPORTS")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	agent := config.Agent
+	if agent.ServerURL != "https://monic.example.com" || agent.Host != "web-01" || agent.Secret != "agent-secret" {
+		t.Errorf("Unexpected agent config: %+v", agent)
+	}
+	if !config.HTTPServer.Agents.Enabled || config.HTTPServer.Agents.MissedReports != 5 {
+		t.Errorf("Unexpected agents config: %+v", config.HTTPServer.Agents)
+	}
+}
+
+func TestLoadConfig_PeersFromEnv(t *testing.T) {
+	os.Setenv("MONIC_PEER_0_NAME", "eu-west")
+	os.Setenv("MONIC_PEER_0_URL", "https://monic-eu.example.com")
+	os.Setenv("MONIC_PEER_1_NAME", "us-east")
+	os.Setenv("MONIC_PEER_1_URL", "https://monic-us.example.com")
+	os.Setenv("MONIC_CHECK_HTTP_URL", "https://api.example.com/health")
+	os.Setenv("MONIC_CHECK_HTTP_MIN_LOCATIONS", "2")
+	defer func() {
+		os.Unsetenv("MONIC_CHECK_HTTP_URL")
+		os.Unsetenv("MONIC_PEER_0_NAME")
+		os.Unsetenv("MONIC_PEER_0_URL")
+		os.Unsetenv("MONIC_PEER_1_NAME")
+		os.Unsetenv("MONIC_PEER_1_URL")
+		os.Unsetenv("MONIC_CHECK_HTTP_MIN_LOCATIONS")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.Peers) != 2 || config.Peers[1].Name != "us-east" || config.Peers[1].URL != "https://monic-us.example.com" {
+		t.Errorf("Unexpected peers: %+v", config.Peers)
+	}
+	if len(config.HTTPChecks) != 1 || config.HTTPChecks[0].MinLocations != 2 {
+		t.Errorf("Expected 2 min locations, got %+v", config.HTTPChecks)
+	}
+}
+
+func TestLoadConfig_SLOFromEnv(t *testing.T) {
+	os.Setenv("MONIC_SLO_TARGET", "99.9")
+	os.Setenv("MONIC_SLO_WINDOW_DAYS", "7")
+	os.Setenv("MONIC_SLO_CHECKS", "api,container/web")
+	defer func() {
+		os.Unsetenv("MONIC_SLO_TARGET")
+		os.Unsetenv("MONIC_SLO_WINDOW_DAYS")
+		os.Unsetenv("MONIC_SLO_CHECKS")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if config.SLO.Target != 99.9 || config.SLO.WindowDays != 7 || len(config.SLO.Checks) != 2 || config.SLO.Checks[1] != "container/web" {
+		t.Errorf("Unexpected SLO config: %+v", config.SLO)
+	}
+}
+
+func TestLoadConfig_MailChecksWithAlertingSMTP(t *testing.T) {
+	os.Setenv("MONIC_CHECK_MAIL_0_NAME", "imap")
+	os.Setenv("MONIC_CHECK_MAIL_0_PROTOCOL", "imap")
+	os.Setenv("MONIC_CHECK_MAIL_0_HOST", "imap.example.com")
+	os.Setenv("MONIC_CHECK_MAIL_ALERTING_SMTP", "true")
+	os.Setenv("MONIC_ALERTING_EMAIL_SMTP_HOST", "smtp.example.com")
+	os.Setenv("MONIC_ALERTING_EMAIL_SMTP_PORT", "587")
+	os.Setenv("MONIC_ALERTING_EMAIL_USE_TLS", "true")
+	defer func() {
+		os.Unsetenv("MONIC_CHECK_MAIL_0_NAME")
+		os.Unsetenv("MONIC_CHECK_MAIL_0_PROTOCOL")
+		os.Unsetenv("MONIC_CHECK_MAIL_0_HOST")
+		os.Unsetenv("MONIC_CHECK_MAIL_ALERTING_SMTP")
+		os.Unsetenv("MONIC_ALERTING_EMAIL_SMTP_HOST")
+		os.Unsetenv("MONIC_ALERTING_EMAIL_SMTP_PORT")
+		os.Unsetenv("MONIC_ALERTING_EMAIL_USE_TLS")
+	}()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.MailChecks) != 2 {
+		t.Fatalf("Expected 2 mail checks, got %d", len(config.MailChecks))
+	}
+	if config.MailChecks[0].Host != "imap.example.com" {
+		t.Errorf("Unexpected first mail check: %+v", config.MailChecks[0])
+	}
+
+	smtpCheck := config.MailChecks[1]
+	if smtpCheck.Name != "alerting_smtp" || smtpCheck.Host != "smtp.example.com" || smtpCheck.Port != 587 || !smtpCheck.StartTLS {
+		t.Errorf("Unexpected alerting SMTP check: %+v", smtpCheck)
+	}
+}
+
+// restoreEnv restores the environment at the end of a test, as loading a config file sets variables
+func restoreEnv(t *testing.T) {
+	saved := os.Environ()
+	t.Cleanup(func() {
+		os.Clearenv()
+		for _, env := range saved {
+			name, value, _ := strings.Cut(env, "=")
+			os.Setenv(name, value)
+		}
+	})
+}
+
+// configFiles holds the same configuration in every supported file format
+var configFiles = map[string]string{
+	"monic.yaml": `
+app_name: from-file
+check_system:
+  cpu_threshold: 75
+  disk_paths: [/, /data]
+check_http:
+  url: https://example.com/health
+  timeout: 5
+check_grpc:
+  - name: api
+    address: api:50051
+  - name: payments
+    address: payments:443
+    tls: true
+alerting:
+  telegram:
+    bot_token: token
+    chat_id: "42"
+remote_write:
+  labels:
+    env: prod
+    region: eu
+`,
+	"monic.json": `{
+  "app_name": "from-file",
+  "check_system": {"cpu_threshold": 75, "disk_paths": ["/", "/data"]},
+  "check_http": {"url": "https://example.com/health", "timeout": 5},
+  "check_grpc": [
+    {"name": "api", "address": "api:50051"},
+    {"name": "payments", "address": "payments:443", "tls": true}
+  ],
+  "alerting": {"telegram": {"bot_token": "token", "chat_id": "42"}},
+  "remote_write": {"labels": {"env": "prod", "region": "eu"}}
+}`,
+	"monic.toml": `
+app_name = "from-file"
+
+[check_system]
+cpu_threshold = 75
+disk_paths = ["/", "/data"]
+
+[check_http]
+url = "https://example.com/health"
+timeout = 5
+
+[[check_grpc]]
+name = "api"
+address = "api:50051"
+
+[[check_grpc]]
+name = "payments"
+address = "payments:443"
+tls = true
+
+[alerting.telegram]
+bot_token = "token"
+chat_id = "42"
+
+[remote_write.labels]
+env = "prod"
+region = "eu"
+`,
+}
+
+func TestLoadConfig_File(t *testing.T) {
+	dir := t.TempDir()
+	var loaded []*types.Config
+	for name, content := range configFiles {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			restoreEnv(t)
+			os.Setenv(FileEnv, path)
+
+			config, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if config.AppName != "from-file" || config.SystemChecks.CPUThreshold != 75 || !reflect.DeepEqual(config.SystemChecks.DiskPaths, []string{"/", "/data"}) {
+				t.Errorf("Unexpected system config: %q %+v", config.AppName, config.SystemChecks)
+			}
+			if len(config.HTTPChecks) != 1 || config.HTTPChecks[0].URL != "https://example.com/health" || config.HTTPChecks[0].Timeout != 5 {
+				t.Errorf("Unexpected HTTP check: %+v", config.HTTPChecks)
+			}
+			if len(config.GRPCChecks) != 2 || config.GRPCChecks[1].Address != "payments:443" || !config.GRPCChecks[1].TLS {
+				t.Errorf("Unexpected gRPC checks: %+v", config.GRPCChecks)
+			}
+			// Channels configured in the file are enabled like those configured in the environment
+			if !config.Alerting.Telegram.Enabled || config.Alerting.Telegram.ChatID != "42" {
+				t.Errorf("Expected Telegram alerting enabled, got %+v", config.Alerting.Telegram)
+			}
+			if config.RemoteWrite.Labels["env"] != "prod" || config.RemoteWrite.Labels["region"] != "eu" {
+				t.Errorf("Unexpected remote write labels: %v", config.RemoteWrite.Labels)
+			}
+			loaded = append(loaded, config)
+		})
+	}
+
+	for _, config := range loaded[1:] {
+		if !reflect.DeepEqual(config, loaded[0]) {
+			t.Errorf("Expected all formats to load the same config, got\n%+v\n%+v", config, loaded[0])
+		}
+	}
+}
+
+func TestLoadConfig_FileEnvOverride(t *testing.T) {
+	restoreEnv(t)
+	path := filepath.Join(t.TempDir(), "monic.yaml")
+	if err := os.WriteFile(path, []byte(configFiles["monic.yaml"]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(FileEnv, path)
+	os.Setenv("MONIC_APP_NAME", "from-env")
+	os.Setenv("MONIC_CHECK_GRPC_0_ADDRESS", "payments:8443")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.AppName != "from-env" || config.SystemChecks.CPUThreshold != 75 {
+		t.Errorf("Expected the environment to override the file, got %q with CPU threshold %d", config.AppName, config.SystemChecks.CPUThreshold)
+	}
+	// The environment list replaces the file list instead of merging into its entries
+	if len(config.GRPCChecks) != 1 || config.GRPCChecks[0].Address != "payments:8443" || config.GRPCChecks[0].Name != "" || config.GRPCChecks[0].TLS {
+		t.Errorf("Expected the gRPC checks of the environment only, got %+v", config.GRPCChecks)
+	}
+	if config.HTTPChecks[0].URL != "https://example.com/health" {
+		t.Errorf("Expected the other settings to still come from the file, got %+v", config.HTTPChecks)
+	}
+}
+
+func TestLoadConfig_FileHTTPCheckList(t *testing.T) {
+	restoreEnv(t)
+	path := filepath.Join(t.TempDir(), "monic.yaml")
+	content := `
+check_http:
+  - name: api
+    url: https://api.example.com/health
+    interval: 10
+  - name: docs
+    url: https://docs.example.com
+    schedule: "@hourly"
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(FileEnv, path)
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(config.HTTPChecks) != 2 || config.HTTPChecks[0].CheckInterval != 10 || config.HTTPChecks[1].Name != "docs" || config.HTTPChecks[1].Schedule != "@hourly" {
+		t.Errorf("Unexpected HTTP checks: %+v", config.HTTPChecks)
+	}
+}
+
+func TestLoadConfig_FileErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown.yaml": "check_http:\n  urll: https://example.com\n",
+		"section.yaml": "alerting: true\n",
+		"value.json":   `{"app_name": {"name": "monic"}}`,
+		"invalid.toml": "app_name = ",
+		"monic.ini":    "app_name=monic",
+	} {
+		t.Run(name, func(t *testing.T) {
+			restoreEnv(t)
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			os.Setenv(FileEnv, path)
+			if _, err := LoadConfig(); err == nil {
+				t.Errorf("Expected an error for %s", content)
+			}
+		})
+	}
+
+	restoreEnv(t)
+	os.Setenv(FileEnv, filepath.Join(dir, "missing.yaml"))
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "failed to read config file") {
+		t.Errorf("Expected an error for a missing file, got %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cfg := &types.Config{
+		Alerting: types.AlertingConfig{
+			Email:   types.EmailConfig{Username: "user", Password: "smtp-pass"},
+			Mailgun: types.MailgunConfig{APIKey: "mg-key"},
+		},
+		MailChecks: []types.MailCheck{{Name: "imap", Password: "imap-pass"}},
+	}
+
+	redacted, secrets := Redact(cfg)
+
+	if redacted.Alerting.Email.Password != redactedValue || redacted.Alerting.Mailgun.APIKey != redactedValue {
+		t.Error("Expected alerting secrets to be redacted")
+	}
+	if redacted.MailChecks[0].Password != redactedValue {
+		t.Error("Expected mail check password to be redacted")
+	}
+	if redacted.Alerting.Email.Username != "user" {
+		t.Error("Expected non-secret fields to be kept")
+	}
+	if redacted.Alerting.Telegram.BotToken != "" {
+		t.Error("Expected empty secrets to stay empty")
+	}
+	if len(secrets) != 3 {
+		t.Errorf("Expected 3 secret values, got %d", len(secrets))
+	}
+
+	// The live configuration must not be modified
+	if cfg.Alerting.Email.Password != "smtp-pass" || cfg.MailChecks[0].Password != "imap-pass" {
+		t.Error("Expected original config to be unchanged")
+	}
+}
+
+func TestWriteDump_RoundTrip(t *testing.T) {
+	cfg := &types.Config{
+		AppName:      "web-01",
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 75, DiskPaths: []string{"/", "/data"}},
+		HTTPChecks:   []types.HTTPCheck{{Name: "api", URL: "https://api.example.com", Timeout: 5}},
+		GRPCChecks:   []types.GRPCCheck{{Name: "payments", Address: "payments:443", TLS: true}},
+		Alerting:     types.AlertingConfig{Enabled: true, Telegram: types.TelegramConfig{Enabled: true, BotToken: "secret", ChatID: "42"}},
+		RemoteWrite:  types.RemoteWriteConfig{Labels: map[string]string{"env": "prod"}},
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			restoreEnv(t)
+			os.Clearenv()
+
+			var dump strings.Builder
+			if err := WriteDump(&dump, cfg, format); err != nil {
+				t.Fatalf("Failed to write dump: %v", err)
+			}
+			if strings.Contains(dump.String(), "secret") || strings.Contains(dump.String(), "smtp_host") {
+				t.Errorf("Expected secrets and unset settings to be left out, got\n%s", dump.String())
+			}
+
+			// A dump loads as a config file, giving the same configuration apart from secrets
+			path := filepath.Join(t.TempDir(), "monic."+format)
+			if err := os.WriteFile(path, []byte(dump.String()), 0600); err != nil {
+				t.Fatal(err)
+			}
+			os.Setenv(FileEnv, path)
+			loaded, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("Failed to load dump: %v\n%s", err, dump.String())
+			}
+
+			want, _ := Redact(cfg)
+			if !reflect.DeepEqual(Dump(loaded), Dump(want)) {
+				t.Errorf("Expected the loaded dump to match the configuration, got %+v", Dump(loaded))
+			}
+			if loaded.Alerting.Email.Enabled || loaded.HTTPServer.Enabled {
+				t.Error("Expected channels and features that were not set to stay disabled")
+			}
+		})
+	}
+
+	if err := WriteDump(io.Discard, cfg, "toml"); err == nil {
+		t.Error("Expected error for an unsupported format")
+	}
+}
+
+func TestLoadConfig_SecretFiles(t *testing.T) {
+	restoreEnv(t)
+	dir := t.TempDir()
+	writeSecret := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	os.Setenv("MONIC_ALERTING_EMAIL_SMTP_HOST", "smtp.example.com")
+	os.Setenv("MONIC_ALERTING_EMAIL_PASSWORD_FILE", writeSecret("smtp", "smtp-pass\n"))
+	os.Setenv("MONIC_CHECK_MAIL_0_NAME", "imap")
+	os.Setenv("MONIC_CHECK_MAIL_0_PASSWORD_FILE", writeSecret("imap", "imap-pass"))
+	// Settings named like files are not secrets
+	os.Setenv("MONIC_HTTP_SERVER_API_KEYS_FILE", "/etc/monic/keys.json")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.Alerting.Email.Password != "smtp-pass" || len(config.MailChecks) != 1 || config.MailChecks[0].Password != "imap-pass" {
+		t.Errorf("Expected secrets read from files, got %q and %+v", config.Alerting.Email.Password, config.MailChecks)
+	}
+	if config.HTTPServer.KeysFile != "/etc/monic/keys.json" {
+		t.Errorf("Expected the API keys file setting to be kept, got %q", config.HTTPServer.KeysFile)
+	}
+
+	// Loading again keeps the secrets
+	if config, err := LoadConfig(); err != nil || config.Alerting.Email.Password != "smtp-pass" {
+		t.Fatalf("Expected secrets to load again, got %v", err)
+	}
+
+	os.Setenv("MONIC_ALERTING_TELEGRAM_BOT_TOKEN", "token")
+	os.Setenv("MONIC_ALERTING_TELEGRAM_BOT_TOKEN_FILE", writeSecret("telegram", "other-token"))
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "both") {
+		t.Errorf("Expected error for a secret set both directly and as a file, got %v", err)
+	}
+	os.Unsetenv("MONIC_ALERTING_TELEGRAM_BOT_TOKEN")
+	os.Unsetenv("MONIC_ALERTING_TELEGRAM_BOT_TOKEN_FILE")
+
+	os.Setenv("MONIC_STORAGE_DSN_FILE", filepath.Join(dir, "missing"))
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected error for a missing secret file")
+	}
+}
+
+func TestLoadConfig_EnvAliases(t *testing.T) {
+	restoreEnv(t)
+	os.Setenv("MONIC_HTTPSERVER_HTTP_SERVER_PORT", "9090")
+	os.Setenv("MONIC_TELEGRAM_BOT_TOKEN", "old-token")
+	os.Setenv("MONIC_EMAIL_SMTP_HOST", "old.example.com")
+	os.Setenv("MONIC_ALERTING_EMAIL_SMTP_HOST", "smtp.example.com")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.HTTPServer.Port != 9090 || !config.HTTPServer.Enabled {
+		t.Errorf("Expected HTTP server on port 9090 from the former name, got %+v", config.HTTPServer)
+	}
+	if config.Alerting.Telegram.BotToken != "old-token" || !config.Alerting.Telegram.Enabled {
+		t.Errorf("Expected Telegram alerting from the former name, got %+v", config.Alerting.Telegram)
+	}
+	// The current name wins over a former one
+	if config.Alerting.Email.SMTPHost != "smtp.example.com" {
+		t.Errorf("Expected SMTP host smtp.example.com, got %q", config.Alerting.Email.SMTPHost)
+	}
+}
+
+func TestLoadConfig_DockerEnabledName(t *testing.T) {
+	restoreEnv(t)
+	os.Setenv("MONIC_DOCKER_ENABLED", "true")
+	// Only MONIC_DOCKER_ENABLED is another name, other MONIC_DOCKER_ variables are not Docker check settings
+	os.Setenv("MONIC_DOCKER_CONTAINERS", "web")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !config.DockerChecks.Enabled {
+		t.Error("Expected Docker checks enabled by MONIC_DOCKER_ENABLED")
+	}
+	if len(config.DockerChecks.Containers) != 0 {
+		t.Errorf("Expected MONIC_DOCKER_CONTAINERS to be ignored, got %v", config.DockerChecks.Containers)
+	}
+}
+
+func TestLoadConfig_NoBareVariables(t *testing.T) {
+	restoreEnv(t)
+	// These were read for MONIC_HTTP_SERVER_PORT and MONIC_STORAGE_PATH when unset
+	os.Setenv("PORT", "1234")
+	os.Setenv("PATH", "/usr/bin:/bin")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.HTTPServer.Port != 0 || config.Storage.Path != "" {
+		t.Errorf("Expected variables without the MONIC_ prefix to be ignored, got port %d and path %q", config.HTTPServer.Port, config.Storage.Path)
+	}
+}
+
+func TestUnknownEnv(t *testing.T) {
+	restoreEnv(t)
+	os.Setenv("MONIC_CHECK_SYSTEM_INTERVAL", "30")
+	os.Setenv("MONIC_CHECK_SYSTEM_INTERVALL", "30")
+	os.Setenv("MONIC_CHECK_HTTP_URL", "https://example.com")
+	os.Setenv("MONIC_CHECK_GRPC_2_ADDRESS", "localhost:50051")
+	os.Setenv("MONIC_CHECK_GRPC_ADDRESS", "localhost:50051")
+	os.Setenv("MONIC_ALERTING_EMAIL_PASSWORD_FILE", "/run/secrets/smtp")
+	os.Setenv("MONIC_ALERTING_EMAIL_FROM_FILE", "/run/secrets/from")
+	os.Setenv("MONIC_HTTPSERVER_PORT", "8080")
+	os.Setenv("MONIC_DOCKER_ENABLED", "true")
+	os.Setenv("MONIC_DOCKER_CONTAINERS", "web")
+	os.Setenv(FileEnv, "monic.yaml")
+
+	unknown := unknownEnv(osEnv)
+	want := []string{"MONIC_ALERTING_EMAIL_FROM_FILE", "MONIC_CHECK_GRPC_ADDRESS", "MONIC_CHECK_SYSTEM_INTERVALL", "MONIC_DOCKER_CONTAINERS"}
+	if !reflect.DeepEqual(unknown, want) {
+		t.Errorf("Expected unknown variables %v, got %v", want, unknown)
+	}
+}
+
+func TestEnablementVarsAreKnown(t *testing.T) {
+	known := knownEnvPattern()
+	for _, names := range [][]string{emailAlertingVars, mailgunAlertingVars, telegramAlertingVars, dockerChecksVars, httpServerVars} {
+		for _, name := range names {
+			if !known.MatchString(name) {
+				t.Errorf("Enablement variable %s is not a setting", name)
+			}
+		}
+	}
+}
+
+func TestLoadConfig_EnableFlags(t *testing.T) {
+	restoreEnv(t)
+	os.Setenv("MONIC_ALERTING_TELEGRAM_BOT_TOKEN", "token")
+	os.Setenv("MONIC_ALERTING_TELEGRAM_CHAT_ID", "42")
+	os.Setenv("MONIC_ALERTING_EMAIL_SMTP_HOST", "smtp.example.com")
+	os.Setenv("MONIC_ALERTING_EMAIL_ENABLED", "false")
+	os.Setenv("MONIC_CHECK_DOCKER_CONTAINERS", "web")
+	os.Setenv("MONIC_DOCKER_ENABLED", "false")
+	os.Setenv("MONIC_HTTP_SERVER_PORT", "8080")
+	os.Setenv("MONIC_HTTP_SERVER_ENABLED", "false")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	// An explicit false turns off a configured feature
+	if config.Alerting.Email.Enabled || config.DockerChecks.Enabled || config.HTTPServer.Enabled {
+		t.Errorf("Expected email alerting, Docker checks and HTTP server to be disabled, got %v, %v and %v",
+			config.Alerting.Email.Enabled, config.DockerChecks.Enabled, config.HTTPServer.Enabled)
+	}
+	if !config.Alerting.Enabled || !config.Alerting.Telegram.Enabled {
+		t.Error("Expected Telegram alerting to stay enabled")
+	}
+
+	os.Setenv("MONIC_ALERTING_ENABLED", "false")
+	os.Setenv("MONIC_HTTP_SERVER_ENABLED", "true")
+	os.Unsetenv("MONIC_HTTP_SERVER_PORT")
+	config, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.Alerting.Telegram.Enabled || config.Alerting.Email.Enabled {
+		t.Error("Expected MONIC_ALERTING_ENABLED=false to disable every alert channel")
+	}
+	if !config.HTTPServer.Enabled {
+		t.Error("Expected an explicit true to enable the HTTP server without other settings")
+	}
+}
+
+func TestLoadConfig_Durations(t *testing.T) {
+	restoreEnv(t)
+	os.Setenv("MONIC_CHECK_SYSTEM_INTERVAL", "2m")
+	os.Setenv("MONIC_CHECK_NTP_0_MAX_DRIFT", "1s")
+	os.Setenv("MONIC_CHECK_NTP_0_INTERVAL", "45")
+	os.Setenv("MONIC_HEARTBEAT_0_INTERVAL", "24h")
+	os.Setenv("MONIC_STORAGE_RETENTION_INTERVAL", "2h")
+	path := filepath.Join(t.TempDir(), "monic.yaml")
+	if err := os.WriteFile(path, []byte("check_http:\n  - url: https://example.com\n    interval: 1m30s\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(FileEnv, path)
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	// Durations are converted to the unit of each setting; plain numbers keep it
+	if config.SystemChecks.Interval != 120 || config.Storage.Retention.Interval != 120 {
+		t.Errorf("Expected 120 seconds and 120 minutes, got %d and %d", config.SystemChecks.Interval, config.Storage.Retention.Interval)
+	}
+	if config.NTPChecks[0].MaxDrift != 1000 || config.NTPChecks[0].CheckInterval != 45 {
+		t.Errorf("Expected 1000ms drift and a 45s interval, got %+v", config.NTPChecks[0])
+	}
+	if config.Heartbeats[0].Interval != 86400 || config.HTTPChecks[0].CheckInterval != 90 {
+		t.Errorf("Expected 86400 and 90 seconds, got %d and %d", config.Heartbeats[0].Interval, config.HTTPChecks[0].CheckInterval)
+	}
+
+	for value, message := range map[string]string{"1500ms": "whole number", "soon": "duration like"} {
+		os.Setenv("MONIC_CHECK_SYSTEM_INTERVAL", value)
+		if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Expected %q error for %s, got %v", message, value, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := &types.Config{
+		SystemChecks: types.SystemChecksConfig{Interval: 30, CPUThreshold: 80},
+		HTTPChecks:   []types.HTTPCheck{{URL: "https://example.com", Timeout: 5, ExpectedStatus: 200, CheckInterval: 30}},
+		HTTPServer:   types.HTTPServerConfig{Port: 8080, Username: "admin", Password: "secret"},
+	}
+	if problems := Validate(valid); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+
+	invalid := &types.Config{
+		LogLevel:     "loud",
+		LogFormat:    "yaml",
+		SystemChecks: types.SystemChecksConfig{Interval: -5, CPUThreshold: 120},
+		HTTPChecks: []types.HTTPCheck{
+			{URL: "https://example.com", Timeout: 5, CheckInterval: 30, Jitter: 30000, Retries: 5},
+			{URL: "example.com/health", Timeout: 30, ExpectedStatus: 42, CheckInterval: 30},
+		},
+		MailChecks:   []types.MailCheck{{Port: 70000, TLS: true, StartTLS: true}},
+		HTTPServer:   types.HTTPServerConfig{Username: "admin", TLS: types.TLSConfig{KeyFile: "key.pem"}, Probes: types.ProbesConfig{Enabled: true}},
+		DockerChecks: types.DockerConfig{ExpectedState: "up"},
+		SLO:          types.SLOConfig{Target: 199.9},
+		Watchdog:     types.WatchdogConfig{StallIntervals: -1},
+		Alerting:     types.AlertingConfig{ImmediateLevels: []string{"urgent"}},
+	}
+	var got []string
+	for _, problem := range Validate(invalid) {
+		setting, _, _ := strings.Cut(problem.Error(), ":")
+		got = append(got, setting)
+	}
+	// Every problem is reported, not only the first
+	want := []string{
+		"MONIC_LOG_LEVEL",
+		"MONIC_LOG_FORMAT",
+		"MONIC_CHECK_SYSTEM_INTERVAL",
+		"MONIC_CHECK_SYSTEM_CPU_THRESHOLD",
+		"MONIC_CHECK_HTTP_0_JITTER",
+		"MONIC_CHECK_HTTP_0_RETRY_DELAY",
+		"MONIC_CHECK_HTTP_1_URL",
+		"MONIC_CHECK_HTTP_1_EXPECTED_STATUS",
+		"MONIC_CHECK_HTTP_1_TIMEOUT",
+		"MONIC_CHECK_MAIL_0_PORT",
+		"MONIC_CHECK_MAIL_0_STARTTLS",
+		"MONIC_ALERTING_IMMEDIATE_LEVELS",
+		"MONIC_CHECK_DOCKER_EXPECTED_STATE",
+		"MONIC_HTTP_SERVER_USERNAME",
+		"MONIC_HTTP_SERVER_TLS_CERT_FILE",
+		"MONIC_HTTP_SERVER_PROBES_ENABLED",
+		"MONIC_SLO_TARGET",
+		"MONIC_WATCHDOG_STALL_INTERVALS",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected problems with\n%v\ngot\n%v", want, got)
+	}
+}
+
+func TestApplyFlags(t *testing.T) {
+	restoreEnv(t)
+	os.Setenv("MONIC_HTTP_SERVER_PORT", "8080")
+
+	flags := pflag.NewFlagSet("monic", pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	AddFlags(flags)
+	if flags.Lookup("alerting-email-password") != nil || flags.Lookup("check-grpc") != nil {
+		t.Error("Expected no flags for secrets and lists of checks")
+	}
+
+	args := []string{"--http-server-port", "9000", "--check-http-url=https://example.com", "--check-docker-enabled", "--log-level", "debug", "validate", "--skip-dns"}
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	if err := ApplyFlags(flags); err != nil {
+		t.Fatalf("Failed to apply flags: %v", err)
+	}
+	// The command and its arguments are left for the command
+	if rest := flags.Args(); !reflect.DeepEqual(rest, []string{"validate", "--skip-dns"}) {
+		t.Errorf("Expected the command to remain, got %v", rest)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	// Flags override variables
+	if config.HTTPServer.Port != 9000 || !config.DockerChecks.Enabled || config.LogLevel != "debug" {
+		t.Errorf("Expected settings from flags, got port %d, Docker %v and log level %q", config.HTTPServer.Port, config.DockerChecks.Enabled, config.LogLevel)
+	}
+	if len(config.HTTPChecks) != 1 || config.HTTPChecks[0].URL != "https://example.com" {
+		t.Errorf("Expected the HTTP check from flags, got %+v", config.HTTPChecks)
+	}
+}
+
+func TestLoadConfig_FileProfiles(t *testing.T) {
+	restoreEnv(t)
+	path := filepath.Join(t.TempDir(), "monic.yaml")
+	content := `
+app_name: shop
+check_system:
+  cpu_threshold: 80
+  memory_threshold: 85
+check_http:
+  url: https://staging.example.com
+profiles:
+  production:
+    check_system:
+      cpu_threshold: 90
+    check_http:
+      url: https://example.com
+    alerting:
+      telegram:
+        chat_id: "42"
+  staging:
+    check_system:
+      memory_threshold: 95
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(FileEnv, path)
+
+	// Without a profile only the base values apply
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.SystemChecks.CPUThreshold != 80 || config.Alerting.Telegram.Enabled {
+		t.Errorf("Expected the base configuration, got %+v", config.SystemChecks)
+	}
+
+	// Values of the file loaded before are variables now, so start over
+	os.Clearenv()
+	os.Setenv(FileEnv, path)
+	os.Setenv(ProfileEnv, "production")
+	config, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	// Profile values override the base values, other base values are kept
+	if config.SystemChecks.CPUThreshold != 90 || config.SystemChecks.MemoryThreshold != 85 || config.AppName != "shop" {
+		t.Errorf("Expected production thresholds over the base, got %+v", config.SystemChecks)
+	}
+	if config.HTTPChecks[0].URL != "https://example.com" || config.Alerting.Telegram.ChatID != "42" {
+		t.Errorf("Expected the production URL and chat, got %+v and %+v", config.HTTPChecks, config.Alerting.Telegram)
+	}
+
+	os.Clearenv()
+	os.Setenv(FileEnv, path)
+	os.Setenv(ProfileEnv, "qa")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "production, staging") {
+		t.Errorf("Expected error listing the profiles, got %v", err)
+	}
+}
+
+func TestLoadConfig_RemoteSources(t *testing.T) {
+	restoreEnv(t)
+	os.Clearenv()
+
+	const yamlConfig = "app_name: fleet\ncheck_system:\n  cpu_threshold: 70\n"
+	const jsonConfig = `{"app_name": "fleet", "check_system": {"cpu_threshold": 70}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/monic", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		io.WriteString(w, yamlConfig)
+	})
+	mux.HandleFunc("/v1/kv/monic/config.json", func(w http.ResponseWriter, r *http.Request) {
+		if _, raw := r.URL.Query()["raw"]; !raw || r.Header.Get("X-Consul-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		io.WriteString(w, jsonConfig)
+	})
+	mux.HandleFunc("/v3/kv/range", func(w http.ResponseWriter, r *http.Request) {
+		var request struct{ Key string }
+		json.NewDecoder(r.Body).Decode(&request)
+		key, _ := base64.StdEncoding.DecodeString(request.Key)
+		if string(key) != "monic/config.yaml" || r.Header.Get("Authorization") != "secret" {
+			io.WriteString(w, `{"kvs": []}`)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"kvs": []map[string]string{{"value": base64.StdEncoding.EncodeToString([]byte(yamlConfig))}},
+		})
+	})
+	remote := httptest.NewServer(mux)
+	defer remote.Close()
+	host := strings.TrimPrefix(remote.URL, "http://")
+
+	for _, rawURL := range []string{remote.URL + "/monic", "consul+http://" + host + "/monic/config.json", "etcd+http://" + host + "/monic/config.yaml"} {
+		// Values of the remote configuration loaded before are variables now, so start over
+		os.Clearenv()
+		os.Setenv(RemoteEnv, rawURL)
+		os.Setenv(RemoteTokenEnv, "secret")
+		os.Setenv("MONIC_APP_NAME", "local")
+		config, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("Failed to load config from %s: %v", rawURL, err)
+		}
+		// Variables override the remote configuration
+		if config.AppName != "local" || config.SystemChecks.CPUThreshold != 70 {
+			t.Errorf("Expected the remote threshold and the local name from %s, got %q and %d", rawURL, config.AppName, config.SystemChecks.CPUThreshold)
+		}
+	}
+
+	os.Clearenv()
+	os.Setenv(RemoteEnv, "etcd+http://"+host+"/missing")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected error for a missing etcd key, got %v", err)
+	}
+	os.Clearenv()
+	os.Setenv(RemoteEnv, remote.URL+"/monic")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected error without the token, got %v", err)
+	}
+}
+
+func TestLoadConfig_RemoteSourcesTLS(t *testing.T) {
+	restoreEnv(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/monic/config.yaml", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "app_name: fleet\n")
+	})
+	mux.HandleFunc("/v3/kv/range", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"kvs": []map[string]string{{"value": base64.StdEncoding.EncodeToString([]byte("app_name: fleet\n"))}},
+		})
+	})
+	remote := httptest.NewTLSServer(mux)
+	defer remote.Close()
+	client := http.DefaultClient
+	http.DefaultClient = remote.Client()
+	t.Cleanup(func() { http.DefaultClient = client })
+	host := strings.TrimPrefix(remote.URL, "https://")
+
+	// Consul and etcd default to HTTPS, so the token never goes out in cleartext
+	for _, scheme := range []string{"consul", "consul+https", "etcd", "etcd+https"} {
+		os.Clearenv()
+		os.Setenv(RemoteEnv, scheme+"://"+host+"/monic/config.yaml")
+		config, err := LoadConfig()
+		if err != nil || config.AppName != "fleet" {
+			t.Errorf("Expected the configuration from %s over HTTPS, got %v", scheme, err)
+		}
+	}
+	os.Clearenv()
+	os.Setenv(RemoteEnv, "consul+http://"+host+"/monic/config.yaml")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected plain HTTP to fail against a TLS server")
+	}
+	os.Clearenv()
+	os.Setenv(RemoteEnv, "https+http://"+host+"/monic/config.yaml")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Errorf("Expected error for an unsupported scheme, got %v", err)
+	}
+}
+
+func TestValidateRemoteConfig(t *testing.T) {
+	restoreEnv(t)
+	os.Clearenv()
+	environ := []string{"MONIC_APP_NAME=local", RemoteEnv + "=http://config.example.com/monic.yaml"}
+
+	if err := validateRemoteConfig(environ, "http://config.example.com/monic.yaml", []byte("log_level: loud\n"), ".yaml"); err == nil || !strings.Contains(err.Error(), "MONIC_LOG_LEVEL") {
+		t.Errorf("Expected the invalid log level to be reported, got %v", err)
+	}
+	if err := validateRemoteConfig(environ, "http://config.example.com/monic.yaml", []byte("log_level: debug\n"), ".yaml"); err != nil {
+		t.Errorf("Expected a valid configuration, got %v", err)
+	}
+	// The candidate is loaded from a copy, the process environment stays as it was
+	if environ := os.Environ(); len(environ) != 0 {
+		t.Errorf("Expected no variables set by validation, got %v", environ)
+	}
+}
+
+func TestWatchRemoteConfig(t *testing.T) {
+	restoreEnv(t)
+
+	var mu sync.Mutex
+	content := "app_name: first\n"
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		io.WriteString(w, content)
+	}))
+	defer remote.Close()
+	os.Setenv(RemoteEnv, remote.URL)
+	environ := os.Environ()
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	changed := make(chan struct{})
+	go WatchRemoteConfig(ctx, 10*time.Millisecond, environ, func() { close(changed) })
+
+	select {
+	case <-changed:
+		t.Fatal("Expected no change before the configuration changed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	mu.Lock()
+	content = "app_name: second\n"
+	mu.Unlock()
+	select {
+	case <-changed:
+	case <-ctx.Done():
+		t.Fatal("Expected the change to be detected")
+	}
+}
+
+func TestWatchRemoteConfig_Invalid(t *testing.T) {
+	restoreEnv(t)
+
+	var mu sync.Mutex
+	content := "log_level: info\n"
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		io.WriteString(w, content)
+	}))
+	defer remote.Close()
+	os.Setenv(RemoteEnv, remote.URL)
+	environ := os.Environ()
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	changed := make(chan struct{})
+	go WatchRemoteConfig(ctx, 10*time.Millisecond, environ, func() { close(changed) })
+
+	mu.Lock()
+	content = "log_level: loud\n"
+	mu.Unlock()
+	select {
+	case <-changed:
+		t.Fatal("Expected no restart for an invalid configuration")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if level := os.Getenv("MONIC_LOG_LEVEL"); level != "info" {
+		t.Errorf("Expected the current configuration to be kept, got log level %q", level)
+	}
+
+	mu.Lock()
+	content = "log_level: debug\n"
+	mu.Unlock()
+	select {
+	case <-changed:
+	case <-ctx.Done():
+		t.Fatal("Expected the valid change to be detected")
+	}
+}
+
+func TestRemoteInterval(t *testing.T) {
+	restoreEnv(t)
+
+	for value, expected := range map[string]time.Duration{"": 0, "300": 5 * time.Minute, "5m": 5 * time.Minute} {
+		os.Setenv(RemoteIntervalEnv, value)
+		if interval, err := RemoteInterval(); err != nil || interval != expected {
+			t.Errorf("Expected %v for %q, got %v (%v)", expected, value, interval, err)
+		}
+	}
+	os.Setenv(RemoteIntervalEnv, "soon")
+	if _, err := RemoteInterval(); err == nil {
+		t.Error("Expected error for an invalid interval")
+	}
+}
+
+func TestLoadConfig_Tags(t *testing.T) {
+	restoreEnv(t)
+	os.Setenv("MONIC_CHECK_HTTP_0_URL", "https://shop.example.com/checkout")
+	os.Setenv("MONIC_CHECK_HTTP_0_TAGS", "payment-critical,shop")
+	os.Setenv("MONIC_CHECK_SYSTEM_TAGS", "infra")
+	os.Setenv("MONIC_CHECK_DOCKER_TAG_0_CONTAINER", "payments")
+	os.Setenv("MONIC_CHECK_DOCKER_TAG_0_TAGS", "payment-critical")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !reflect.DeepEqual(config.HTTPChecks[0].Tags, []string{"payment-critical", "shop"}) {
+		t.Errorf("Expected the check tags, got %v", config.HTTPChecks[0].Tags)
+	}
+	if !reflect.DeepEqual(config.SystemChecks.Tags, []string{"infra"}) {
+		t.Errorf("Expected the system check tags, got %v", config.SystemChecks.Tags)
+	}
+	if tags := config.DockerChecks.TagsFor("payments"); !reflect.DeepEqual(tags, []string{"payment-critical"}) {
+		t.Errorf("Expected the container tags, got %v", tags)
+	}
+	if unknown := unknownEnv(osEnv); len(unknown) != 0 {
+		t.Errorf("Expected the tag variables to be known, got %v", unknown)
+	}
+}
+
+func TestLoadConfig_AlertRoutes(t *testing.T) {
+	restoreEnv(t)
+	os.Setenv("MONIC_ALERTING_TELEGRAM_BOT_TOKEN", "token")
+	os.Setenv("MONIC_ALERTING_TELEGRAM_CHAT_ID", "ops-chat")
+	os.Setenv("MONIC_CHECK_HTTP_0_NAME", "frontend")
+	os.Setenv("MONIC_CHECK_HTTP_0_URL", "https://www.example.com")
+	os.Setenv("MONIC_CHECK_HTTP_0_ALERT_CHANNELS", "telegram")
+	os.Setenv("MONIC_CHECK_HTTP_0_ALERT_TELEGRAM_CHAT", "web-chat")
+	os.Setenv("MONIC_ALERTING_ROUTE_0_TAGS", "db")
+	os.Setenv("MONIC_ALERTING_ROUTE_0_TO_TELEGRAM_CHAT", "dba-chat")
+	os.Setenv("MONIC_CHECK_SCRIPT_0_NAME", "backup")
+	os.Setenv("MONIC_CHECK_SCRIPT_0_COMMAND", "check_backup")
+	os.Setenv("MONIC_CHECK_SCRIPT_0_ALERT_TELEGRAM_CHAT", "backup-chat")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	wantCheck := types.AlertTarget{Channels: []string{"telegram"}, TelegramChat: "web-chat"}
+	if !reflect.DeepEqual(config.HTTPChecks[0].Alert, wantCheck) {
+		t.Errorf("Expected the check's alert target %+v, got %+v", wantCheck, config.HTTPChecks[0].Alert)
+	}
+	if config.ScriptChecks[0].Alert.TelegramChat != "backup-chat" {
+		t.Errorf("Expected the script check's alert target, got %+v", config.ScriptChecks[0].Alert)
+	}
+	wantRoutes := []types.AlertRoute{{Tags: []string{"db"}, To: types.AlertTarget{TelegramChat: "dba-chat"}}}
+	if !reflect.DeepEqual(config.Alerting.Routes, wantRoutes) {
+		t.Errorf("Expected routes %+v, got %+v", wantRoutes, config.Alerting.Routes)
+	}
+	if problems := Validate(config); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+	if unknown := unknownEnv(osEnv); len(unknown) != 0 {
+		t.Errorf("Expected the routing variables to be known, got %v", unknown)
+	}
+
+	// Routes need a condition and a target, and only enabled channels can be selected
+	config.Alerting.Routes = append(config.Alerting.Routes, types.AlertRoute{})
+	config.HTTPChecks[0].Alert = types.AlertTarget{Channels: []string{"sms", "email"}, Mailgun: "web@example.com"}
+	config.ScriptChecks[0].Alert = types.AlertTarget{Email: "backup@example.com"}
+	var got []string
+	for _, problem := range Validate(config) {
+		setting, _, _ := strings.Cut(problem.Error(), ":")
+		got = append(got, setting)
+	}
+	want := []string{
+		"MONIC_CHECK_HTTP_0_ALERT_CHANNELS",
+		"MONIC_CHECK_HTTP_0_ALERT_CHANNELS",
+		"MONIC_CHECK_HTTP_0_ALERT_MAILGUN",
+		"MONIC_CHECK_SCRIPT_0_ALERT_EMAIL",
+		"MONIC_ALERTING_ROUTE_1_CHECKS",
+		"MONIC_ALERTING_ROUTE_1_TO_CHANNELS",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected problems with\n%v\ngot\n%v", want, got)
+	}
+}
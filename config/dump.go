@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dump returns the configuration with secrets redacted, keyed like the config file (e.g.
+// check_http[0].url for MONIC_CHECK_HTTP_0_URL), so a dump can be edited and loaded with --config.
+// Settings that are not set (zero values) are left out, as in a config file they would enable
+// features like the environment variables they stand for.
+func Dump(cfg *types.Config) map[string]interface{} {
+	redacted, _ := Redact(cfg)
+	values, ok := dumpValue(reflect.ValueOf(redacted).Elem()).(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return values
+}
+
+// WriteDump writes the configuration with secrets redacted as YAML or JSON
+func WriteDump(w io.Writer, cfg *types.Config, format string) error {
+	values := Dump(cfg)
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(values); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		return encoder.Close()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(values); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q, expected yaml or json", format)
+}
+
+// dumpValue converts a config value to the plain values of a config file: sections become
+// maps keyed by the lower-cased variable name part of their fields. It returns nil for values
+// that are not set, including sections without any set value.
+func dumpValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpValue(v.Elem())
+
+	case reflect.Struct:
+		section := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			// Runtime state such as the last check time is not configuration
+			if !field.IsExported() || field.Type == reflect.TypeOf(time.Time{}) {
+				continue
+			}
+			if value := dumpValue(v.Field(i)); value != nil {
+				section[strings.ToLower(fieldEnvName(field))] = value
+			}
+		}
+		if len(section) == 0 {
+			return nil
+		}
+		return section
+
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = dumpValue(v.Index(i))
+			// List entries keep their position, even when nothing in them is set
+			if items[i] == nil && v.Type().Elem().Kind() == reflect.Struct {
+				items[i] = map[string]interface{}{}
+			} else if items[i] == nil {
+				items[i] = v.Index(i).Interface()
+			}
+		}
+		return items
+
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+		entries := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			entries[fmt.Sprint(key.Interface())] = v.MapIndex(key).Interface()
+		}
+		return entries
+	}
+
+	if v.IsZero() {
+		return nil
+	}
+	return v.Interface()
+}
@@ -0,0 +1,371 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/joho/godotenv"
+)
+
+// Every setting is read from exactly one variable: the MONIC_ prefix followed by the variable
+// name part of each section and field (see fieldEnvName), e.g. MONIC_ALERTING_EMAIL_SMTP_HOST
+// for Alerting.Email.SMTPHost. Config files, secret files and config dumps use the same names.
+
+// environment holds the variables a configuration is loaded from: those of the process, or a copy
+// to load a configuration without changing the process
+type environment interface {
+	Lookup(name string) (string, bool)
+	Set(name, value string) error
+	Environ() []string
+}
+
+// osEnv is the environment of the process
+var osEnv environment = processEnvironment{}
+
+// processEnvironment reads and sets the variables of the process
+type processEnvironment struct{}
+
+func (processEnvironment) Lookup(name string) (string, bool) { return os.LookupEnv(name) }
+func (processEnvironment) Set(name, value string) error      { return os.Setenv(name, value) }
+func (processEnvironment) Environ() []string                 { return os.Environ() }
+
+// envCopy is a copy of an environment in "NAME=value" form, changed without affecting the process
+type envCopy map[string]string
+
+// newEnvCopy copies an environment in "NAME=value" form
+func newEnvCopy(environ []string) envCopy {
+	env := make(envCopy, len(environ))
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		env[name] = value
+	}
+	return env
+}
+
+func (e envCopy) Lookup(name string) (string, bool) {
+	value, ok := e[name]
+	return value, ok
+}
+
+func (e envCopy) Set(name, value string) error {
+	e[name] = value
+	return nil
+}
+
+func (e envCopy) Environ() []string {
+	environ := make([]string, 0, len(e))
+	for name, value := range e {
+		environ = append(environ, name+"="+value)
+	}
+	return environ
+}
+
+// loadDotEnv sets the variables of the optional .env file that are not set yet
+func loadDotEnv(env environment) {
+	values, err := godotenv.Read()
+	if err != nil {
+		return // It's okay if .env doesn't exist
+	}
+	for name, value := range values {
+		if _, exists := env.Lookup(name); !exists {
+			env.Set(name, value)
+		}
+	}
+}
+
+// envAlias maps the variables of a former name of a section to the current one
+type envAlias struct {
+	Old     string // e.g. MONIC_HTTPSERVER_
+	Current string // e.g. MONIC_HTTP_SERVER_
+}
+
+// envAliases are the former variable name prefixes that are still accepted, longest first
+var envAliases = []envAlias{
+	{Old: "MONIC_HTTPSERVER_HTTP_SERVER_", Current: "MONIC_HTTP_SERVER_"},
+	{Old: "MONIC_HTTPSERVER_", Current: "MONIC_HTTP_SERVER_"},
+	{Old: "MONIC_DOCKERCHECKS_", Current: "MONIC_CHECK_DOCKER_"},
+	{Old: "MONIC_SYSTEMCHECKS_", Current: "MONIC_CHECK_SYSTEM_"},
+	{Old: "MONIC_TELEGRAM_", Current: "MONIC_ALERTING_TELEGRAM_"},
+	{Old: "MONIC_MAILGUN_", Current: "MONIC_ALERTING_MAILGUN_"},
+	{Old: "MONIC_EMAIL_", Current: "MONIC_ALERTING_EMAIL_"},
+}
+
+// envNames are other accepted names of single variables, not deprecated
+var envNames = map[string]string{
+	"MONIC_DOCKER_ENABLED": "MONIC_CHECK_DOCKER_ENABLED",
+}
+
+// processEnv sets the fields of the section target points to from the variables below prefix.
+// A field is only read from its full variable name, never from its bare tag as the envconfig
+// package did, so e.g. PATH does not end up in the storage path. Fields tagged ignored:"true" are
+// lists loaded from indexed variables.
+func processEnv(env environment, prefix string, target interface{}) error {
+	return processEnvSection(env, prefix, reflect.ValueOf(target).Elem())
+}
+
+// processEnvSection sets the fields of a section and of its nested sections
+func processEnvSection(env environment, prefix string, section reflect.Value) error {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get("ignored") == "true" || field.Type == timeType {
+			continue
+		}
+
+		name := prefix + "_" + fieldEnvName(field)
+		if field.Type.Kind() == reflect.Struct {
+			if err := processEnvSection(env, name, section.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := env.Lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvField(section.Field(i), field, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+// timeType is the type of runtime state such as the last check time, which is not configuration
+var timeType = reflect.TypeOf(time.Time{})
+
+// setEnvField parses a variable into a field. Durations, fields with a unit tag of s, m or ms,
+// take a plain number in that unit or a duration string like 30s, 5m or 1h30m.
+func setEnvField(field reflect.Value, structField reflect.StructField, value string) error {
+	if unit := structField.Tag.Get("unit"); unit != "" {
+		count, err := durationInUnit(value, unit)
+		if err != nil {
+			return err
+		}
+		value = count
+	}
+	return setEnvValue(field, value)
+}
+
+// durationInUnit converts a duration string to the whole number of units it spans; plain
+// numbers are returned unchanged
+func durationInUnit(value, unit string) (string, error) {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return value, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return "", fmt.Errorf("expected a number of %s or a duration like 30s, 5m or 1h", unit)
+	}
+	size, err := time.ParseDuration("1" + unit)
+	if err != nil {
+		return "", fmt.Errorf("unsupported unit %q", unit)
+	}
+	if duration%size != 0 {
+		return "", fmt.Errorf("expected a whole number of %s", unit)
+	}
+	return strconv.FormatInt(int64(duration/size), 10), nil
+}
+
+// setEnvValue parses a variable into a field: lists are comma-separated values and maps
+// comma-separated key:value pairs
+func setEnvValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.Pointer:
+		item := reflect.New(field.Type().Elem())
+		if err := setEnvValue(item.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(item)
+
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+
+	case reflect.Slice:
+		if strings.TrimSpace(value) == "" {
+			return nil
+		}
+		parts := strings.Split(value, ",")
+		items := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setEnvValue(items.Index(i), part); err != nil {
+				return err
+			}
+		}
+		field.Set(items)
+
+	case reflect.Map:
+		entries := reflect.MakeMap(field.Type())
+		if strings.TrimSpace(value) != "" {
+			for _, pair := range strings.Split(value, ",") {
+				key, entry, found := strings.Cut(pair, ":")
+				if !found {
+					return fmt.Errorf("invalid map entry %q, expected key:value", pair)
+				}
+				k := reflect.New(field.Type().Key()).Elem()
+				if err := setEnvValue(k, key); err != nil {
+					return err
+				}
+				v := reflect.New(field.Type().Elem()).Elem()
+				if err := setEnvValue(v, entry); err != nil {
+					return err
+				}
+				entries.SetMapIndex(k, v)
+			}
+		}
+		field.Set(entries)
+
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// applyEnvAliases sets the current variable of every variable still using a former or other
+// name, unless the current one is set too, and warns about each former name
+func applyEnvAliases(env environment) error {
+	for _, variable := range env.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		current, ok := aliasedEnvName(name)
+		if !ok {
+			continue
+		}
+
+		if existing, exists := env.Lookup(current); exists {
+			if existing != value {
+				slog.Warn("Ignoring deprecated configuration variable, the current one is set too", "variable", name, "current", current)
+			}
+			continue
+		}
+		if _, other := envNames[name]; !other {
+			slog.Warn("Deprecated configuration variable, use the current name", "variable", name, "current", current)
+		}
+		if err := env.Set(current, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", current, err)
+		}
+	}
+	return nil
+}
+
+// aliasedEnvName returns the current name of a variable using a former or other name
+func aliasedEnvName(name string) (string, bool) {
+	if current, ok := envNames[name]; ok {
+		return current, true
+	}
+	for _, alias := range envAliases {
+		if rest, found := strings.CutPrefix(name, alias.Old); found {
+			return alias.Current + rest, true
+		}
+	}
+	return "", false
+}
+
+// warnUnknownEnv warns about every MONIC_ variable that is not a setting, as a misspelled
+// variable is otherwise silently ignored
+func warnUnknownEnv(env environment) {
+	for _, name := range unknownEnv(env) {
+		slog.Warn("Unknown configuration variable, it is ignored", "variable", name)
+	}
+}
+
+// unknownEnv returns the sorted names of the MONIC_ variables that are not settings
+func unknownEnv(env environment) []string {
+	known := knownEnvPattern()
+	var unknown []string
+	for _, variable := range env.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(name, "MONIC_") || known.MatchString(name) {
+			continue
+		}
+		if _, aliased := aliasedEnvName(name); aliased {
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// knownEnvPattern returns a pattern matching the variables of all settings, including the
+// variables of the single HTTP check, secret files, the config file and the remote configuration
+func knownEnvPattern() *regexp.Regexp {
+	all := func(reflect.StructField) bool { return true }
+
+	var names, secrets []string
+	collectEnvVars(&names, "MONIC", reflect.TypeOf(types.Config{}), all)
+	collectEnvVars(&names, "MONIC_CHECK_HTTP", reflect.TypeOf(types.HTTPCheck{}), all)
+	collectEnvVars(&secrets, "MONIC", reflect.TypeOf(types.Config{}), isSecretField)
+	secrets = append(secrets, regexp.QuoteMeta(RemoteTokenEnv))
+	for _, secret := range secrets {
+		names = append(names, secret+secretFileSuffix)
+	}
+	for _, name := range []string{FileEnv, ProfileEnv, RemoteEnv, RemoteIntervalEnv, RemoteTokenEnv} {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	return regexp.MustCompile("^(" + strings.Join(names, "|") + ")$")
+}
+
+// envVarPattern returns a pattern matching the variables of the fields of a section selected by
+// include, with any index in lists of checks (e.g. MONIC_CHECK_MAIL_0_PASSWORD)
+func envVarPattern(prefix string, section reflect.Type, include func(field reflect.StructField) bool) *regexp.Regexp {
+	var names []string
+	collectEnvVars(&names, regexp.QuoteMeta(prefix), section, include)
+	return regexp.MustCompile("^(" + strings.Join(names, "|") + ")$")
+}
+
+// collectEnvVars adds the variable patterns of the fields of a section selected by include,
+// descending into nested sections and lists of checks
+func collectEnvVars(names *[]string, prefix string, section reflect.Type, include func(field reflect.StructField) bool) {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Field(i)
+		if !field.IsExported() || field.Type == timeType {
+			continue
+		}
+
+		name := prefix + "_" + regexp.QuoteMeta(fieldEnvName(field))
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			collectEnvVars(names, name, field.Type, include)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			collectEnvVars(names, name+"_[0-9]+", field.Type.Elem(), include)
+		case include(field):
+			*names = append(*names, name)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bconf.com/monic/types"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv is the environment variable with the path of the config file
+const FileEnv = "MONIC_CONFIG_FILE"
+
+// ProfileEnv is the environment variable selecting a profile of the config file, e.g. production
+const ProfileEnv = "MONIC_ENV"
+
+// profilesKey is the config file section with the overrides of each profile
+const profilesKey = "profiles"
+
+// loadConfigFile reads a YAML, JSON or TOML config file and sets the environment variable of
+// every value it holds, unless that variable is already set. Environment variables therefore
+// override the file, and the file is loaded exactly like the environment.
+//
+// Keys are the variable names without the MONIC_ prefix, nested per section, e.g.
+// check_http.url for MONIC_CHECK_HTTP_URL. Lists of checks are lists of objects, e.g. the
+// first entry of check_grpc becomes the MONIC_CHECK_GRPC_0_* variables.
+func loadConfigFile(env environment, path string) error {
+	values, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if err := setConfigValues(env, values); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// setConfigValues applies the selected profile to decoded config file values and sets the
+// environment variable of every value that is not set yet. A list is taken as a whole: when any
+// of its indexed variables is set, the environment holds the list and its file entries are skipped.
+func setConfigValues(env environment, values map[string]interface{}) error {
+	profile, _ := env.Lookup(ProfileEnv)
+	values, err := applyProfile(values, profile)
+	if err != nil {
+		return err
+	}
+
+	vars := make(map[string]string)
+	lists := make(map[string]bool)
+	if err := flattenConfig(vars, lists, "MONIC", values, reflect.TypeOf(types.Config{})); err != nil {
+		return err
+	}
+	var envLists []string
+	for list := range lists {
+		if hasIndexedEnv(env, list) {
+			envLists = append(envLists, list)
+		}
+	}
+	for name, value := range vars {
+		if _, exists := env.Lookup(name); exists || inIndexedList(name, envLists) {
+			continue
+		}
+		if err := env.Set(name, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// hasIndexedEnv reports whether any indexed variable of a list is set, e.g. MONIC_CHECK_GRPC_1_*
+// for the list MONIC_CHECK_GRPC
+func hasIndexedEnv(env environment, list string) bool {
+	for _, variable := range env.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if inIndexedList(name, []string{list}) {
+			return true
+		}
+	}
+	return false
+}
+
+// inIndexedList reports whether a variable belongs to an entry of one of the lists, i.e. is
+// named <list>_<index>_*
+func inIndexedList(name string, lists []string) bool {
+	for _, list := range lists {
+		rest, ok := strings.CutPrefix(name, list+"_")
+		if !ok {
+			continue
+		}
+		index, _, ok := strings.Cut(rest, "_")
+		if _, err := strconv.Atoi(index); ok && err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// applyProfile merges the overrides of a profile into the base values of a config file and
+// removes the profiles section. Without a profile, the base values apply unchanged.
+func applyProfile(values map[string]interface{}, profile string) (map[string]interface{}, error) {
+	profiles, _ := values[profilesKey].(map[string]interface{})
+	delete(values, profilesKey)
+	if profile == "" {
+		return values, nil
+	}
+
+	overrides, ok := profiles[profile].(map[string]interface{})
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile %q selected by %s (profiles: %s)", profile, ProfileEnv, strings.Join(names, ", "))
+	}
+	return mergeValues(values, overrides), nil
+}
+
+// mergeValues overrides base values: sections are merged key by key, any other value, including
+// a list, replaces the base value
+func mergeValues(base, overrides map[string]interface{}) map[string]interface{} {
+	for key, override := range overrides {
+		baseSection, baseOK := base[key].(map[string]interface{})
+		overrideSection, overrideOK := override.(map[string]interface{})
+		if baseOK && overrideOK {
+			base[key] = mergeValues(baseSection, overrideSection)
+			continue
+		}
+		base[key] = override
+	}
+	return base
+}
+
+// readConfigFile decodes a config file by its extension: .yaml, .yml, .json or .toml
+func readConfigFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	values, err := decodeConfig(data, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// decodeConfig decodes config file contents in the format of a file extension
+func decodeConfig(data []byte, ext string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	var err error
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q, expected .yaml, .yml, .json or .toml", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flattenConfig converts a decoded config value for a field of type target to the environment
+// variables processEnv reads: sections and list entries become name prefixes, lists of values
+// comma-separated values and maps key:value pairs. The names of lists of entries are added to lists.
+func flattenConfig(vars map[string]string, lists map[string]bool, name string, value interface{}, target reflect.Type) error {
+	if value == nil {
+		return nil
+	}
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a section, got %v", name, value)
+		}
+		for key, item := range section {
+			field, ok := configField(target, key)
+			if !ok {
+				return fmt.Errorf("unknown key %q (no setting %s)", key, name+"_"+envKey(key))
+			}
+			if err := flattenConfig(vars, lists, name+"_"+fieldEnvName(field), item, field.Type); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice:
+		if tables, ok := value.([]map[string]interface{}); ok {
+			// TOML arrays of tables
+			items := make([]interface{}, len(tables))
+			for i, table := range tables {
+				items[i] = table
+			}
+			value = items
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			// A single comma-separated value or section, as in the environment (e.g. check_http.url)
+			return flattenConfig(vars, lists, name, value, target.Elem())
+		}
+		if target.Elem().Kind() == reflect.Struct {
+			lists[name] = true
+			for i, item := range items {
+				if err := flattenConfig(vars, lists, name+"_"+strconv.Itoa(i), item, target.Elem()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			part, err := configScalar(name, item)
+			if err != nil {
+				return err
+			}
+			parts = append(parts, part)
+		}
+		vars[name] = strings.Join(parts, ",")
+		return nil
+
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			entry, err := configScalar(name, entries[key])
+			if err != nil {
+				return err
+			}
+			pairs = append(pairs, key+":"+entry)
+		}
+		vars[name] = strings.Join(pairs, ",")
+		return nil
+	}
+
+	scalar, err := configScalar(name, value)
+	if err != nil {
+		return err
+	}
+	vars[name] = scalar
+	return nil
+}
+
+// configScalar formats a single config value as processEnv expects it in a variable
+func configScalar(name string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("%s: expected a value, got %v", name, value)
+}
+
+// configField finds the field of a config section matching a file key, ignoring case and
+// accepting dashes for underscores
+func configField(section reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Field(i)
+		if field.IsExported() && fieldEnvName(field) == envKey(key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// fieldEnvName returns the variable name part of a field: its envconfig tag, or its
+// upper-cased name without a tag
+func fieldEnvName(field reflect.StructField) string {
+	if tag := field.Tag.Get("envconfig"); tag != "" {
+		return tag
+	}
+	return strings.ToUpper(field.Name)
+}
+
+// envKey converts a file key to its variable name part
+func envKey(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
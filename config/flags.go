@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"bconf.com/monic/types"
+
+	"github.com/spf13/pflag"
+)
+
+// AddFlags adds a flag for every setting to a flag set, named after its variable without the
+// MONIC_ prefix: --http-server-port for MONIC_HTTP_SERVER_PORT and --check-http-url for the
+// single HTTP check. Lists of checks and secrets have no flags, as command lines are visible to
+// other users of the host; they are set with variables, _FILE variables or the config file.
+func AddFlags(flags *pflag.FlagSet) {
+	addSectionFlags(flags, "MONIC", reflect.TypeOf(types.Config{}))
+	addSectionFlags(flags, "MONIC_CHECK_HTTP", reflect.TypeOf(types.HTTPCheck{}))
+	flags.Var(&settingFlag{}, flagName(ProfileEnv), "sets "+ProfileEnv+", the profile of the config file to apply")
+	flags.Var(&settingFlag{}, flagName(RemoteEnv), "sets "+RemoteEnv+", the URL of the remote configuration")
+	flags.Var(&settingFlag{}, flagName(RemoteIntervalEnv), "sets "+RemoteIntervalEnv+", the time between fetches of the remote configuration")
+}
+
+// addSectionFlags adds the flags of the settings of a section and its nested sections
+func addSectionFlags(flags *pflag.FlagSet, prefix string, section reflect.Type) {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Field(i)
+		if !field.IsExported() || field.Tag.Get("ignored") == "true" || field.Type == timeType || isSecretField(field) {
+			continue
+		}
+
+		name := prefix + "_" + fieldEnvName(field)
+		if field.Type.Kind() == reflect.Struct {
+			addSectionFlags(flags, name, field.Type)
+			continue
+		}
+
+		usage := "sets " + name
+		if unit := field.Tag.Get("unit"); unit != "" {
+			usage += " (a duration like 30s, or a number of " + unit + ")"
+		}
+		flag := flags.VarPF(&settingFlag{}, flagName(name), "", usage)
+		if field.Type.Kind() == reflect.Bool {
+			// --docker-enabled alone means true, like a boolean flag
+			flag.NoOptDefVal = "true"
+		}
+	}
+}
+
+// ApplyFlags sets the variable of every setting given as a flag. Flags therefore override
+// variables, .env and the config file, and are parsed like variables by LoadConfig.
+func ApplyFlags(flags *pflag.FlagSet) error {
+	var err error
+	flags.Visit(func(flag *pflag.Flag) {
+		setting, ok := flag.Value.(*settingFlag)
+		if !ok || err != nil {
+			return
+		}
+		name := "MONIC_" + strings.ToUpper(strings.ReplaceAll(flag.Name, "-", "_"))
+		if setErr := os.Setenv(name, setting.value); setErr != nil {
+			err = fmt.Errorf("failed to set %s: %w", name, setErr)
+		}
+	})
+	return err
+}
+
+// flagName converts a variable name to its flag name, e.g. http-server-port
+func flagName(envName string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(envName, "MONIC_"), "_", "-"))
+}
+
+// settingFlag holds the value of a setting flag as given; it is parsed with the variables
+type settingFlag struct {
+	value string
+}
+
+// String returns the value of the flag
+func (f *settingFlag) String() string {
+	return f.value
+}
+
+// Set stores the value of the flag
+func (f *settingFlag) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+// Type names the kind of value in the usage
+func (f *settingFlag) Type() string {
+	return "value"
+}
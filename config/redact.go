@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"bconf.com/monic/types"
+)
+
+// redactedValue replaces secret values in redacted output
+const redactedValue = "[REDACTED]"
+
+// sensitiveFieldMarkers are substrings of config field names that hold secrets
+var sensitiveFieldMarkers = []string{"Password", "Token", "APIKey", "Secret", "DSN", "Community"}
+
+// Redact returns a copy of the configuration with all secret fields replaced,
+// together with the original secret values so they can be scrubbed from other output (e.g. logs)
+func Redact(cfg *types.Config) (*types.Config, []string) {
+	redacted := &types.Config{}
+	if cfg == nil {
+		return redacted, nil
+	}
+
+	// Deep copy via JSON so slices of checks are not shared with the live config
+	data, err := json.Marshal(cfg)
+	if err != nil || json.Unmarshal(data, redacted) != nil {
+		return &types.Config{}, nil
+	}
+
+	var secrets []string
+	redactValue(reflect.ValueOf(redacted).Elem(), &secrets)
+	return redacted, secrets
+}
+
+// redactValue walks structs and slices, replacing non-empty sensitive string fields
+func redactValue(v reflect.Value, secrets *[]string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			if field.Kind() == reflect.String && isSensitiveField(v.Type().Field(i).Name) {
+				if field.String() != "" {
+					*secrets = append(*secrets, field.String())
+					field.SetString(redactedValue)
+				}
+				continue
+			}
+			redactValue(field, secrets)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redactValue(v.Index(i), secrets)
+		}
+	}
+}
+
+// isSensitiveField reports whether a field name looks like it holds a secret
+func isSensitiveField(name string) bool {
+	for _, marker := range sensitiveFieldMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteEnv is the environment variable with the URL of a remote configuration: an http:// or
+// https:// URL of a config file, consul://host:8501/<key> or etcd://host:2379/<key>, which are
+// reached over HTTPS, or consul+http:// and etcd+http:// for plain HTTP
+const RemoteEnv = "MONIC_CONFIG_URL"
+
+// RemoteIntervalEnv is the environment variable with the time between fetches of the remote
+// configuration after startup, e.g. 5m (unset or 0 fetches it at startup only)
+const RemoteIntervalEnv = "MONIC_CONFIG_URL_INTERVAL"
+
+// RemoteTokenEnv is the environment variable with the token sent with remote configuration
+// requests: as bearer token over HTTP, as X-Consul-Token to Consul and as etcd auth token
+const RemoteTokenEnv = "MONIC_CONFIG_URL_TOKEN"
+
+// remoteTimeout limits a fetch of the remote configuration
+const remoteTimeout = 10 * time.Second
+
+// maxRemoteConfigSize limits the size of a remote configuration
+const maxRemoteConfigSize = 4 << 20
+
+// remoteLoaded is the URL and digest of the remote configuration applied by LoadConfig, from
+// which WatchRemoteConfig detects changes
+var remoteLoaded struct {
+	sync.Mutex
+	url    string
+	digest [sha256.Size]byte
+}
+
+// loadRemoteConfig fetches the remote configuration and sets the environment variable of every
+// value it holds, unless that variable is already set, exactly like a config file
+func loadRemoteConfig(env environment, rawURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+
+	token, _ := env.Lookup(RemoteTokenEnv)
+	data, ext, err := fetchRemoteConfig(ctx, rawURL, token)
+	if err != nil {
+		return fmt.Errorf("failed to fetch remote config: %w", err)
+	}
+	if err := applyRemoteConfig(env, rawURL, data, ext); err != nil {
+		return err
+	}
+	remoteLoaded.Lock()
+	remoteLoaded.url, remoteLoaded.digest = rawURL, sha256.Sum256(data)
+	remoteLoaded.Unlock()
+	return nil
+}
+
+// applyRemoteConfig sets the environment variables of the values of a fetched remote
+// configuration
+func applyRemoteConfig(env environment, rawURL string, data []byte, ext string) error {
+	values, err := decodeConfig(data, ext)
+	if err != nil {
+		return fmt.Errorf("failed to parse remote config %s: %w", redactURL(rawURL), err)
+	}
+	if err := setConfigValues(env, values); err != nil {
+		return fmt.Errorf("invalid remote config %s: %w", redactURL(rawURL), err)
+	}
+	return nil
+}
+
+// fetchRemoteConfig fetches a remote configuration and returns its contents with the file
+// extension of its format
+func fetchRemoteConfig(ctx context.Context, rawURL, token string) ([]byte, string, error) {
+	source, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	// Consul and etcd are reached over HTTPS, as the token and the configuration must not travel
+	// in cleartext, unless +http explicitly selects plain HTTP
+	kind, transport := source.Scheme, "https"
+	if base, suffix, found := strings.Cut(source.Scheme, "+"); found && (suffix == "http" || suffix == "https") {
+		kind, transport = base, suffix
+	}
+
+	var req *http.Request
+	key := strings.TrimPrefix(source.Path, "/")
+	switch kind {
+	case "http", "https":
+		if kind != source.Scheme {
+			return nil, "", fmt.Errorf("unsupported scheme %q", source.Scheme)
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err == nil && token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	case "consul":
+		endpoint := url.URL{Scheme: transport, Host: source.Host, Path: "/v1/kv/" + key, RawQuery: "raw"}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+		if err == nil && token != "" {
+			req.Header.Set("X-Consul-Token", token)
+		}
+	case "etcd":
+		body, _ := json.Marshal(map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(key))})
+		endpoint := url.URL{Scheme: transport, Host: source.Host, Path: "/v3/kv/range"}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+		if err == nil && token != "" {
+			req.Header.Set("Authorization", token)
+		}
+	default:
+		return nil, "", fmt.Errorf("unsupported scheme %q, expected http, https, consul, consul+http, etcd or etcd+http", source.Scheme)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("request to %s failed: %w", redactURL(rawURL), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s returned %s", redactURL(rawURL), resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", redactURL(rawURL), err)
+	}
+
+	if kind == "etcd" {
+		if data, err = etcdValue(data); err != nil {
+			return nil, "", fmt.Errorf("key %q: %w", key, err)
+		}
+	}
+	return data, remoteFormat(key, resp.Header.Get("Content-Type")), nil
+}
+
+// etcdValue extracts the value of the key from an etcd range response
+func etcdValue(data []byte) ([]byte, error) {
+	var response struct {
+		KVs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid etcd response: %w", err)
+	}
+	if len(response.KVs) == 0 {
+		return nil, fmt.Errorf("not found")
+	}
+	return base64.StdEncoding.DecodeString(response.KVs[0].Value)
+}
+
+// remoteFormat returns the file extension of the format of a remote configuration: the extension
+// of its path or key, otherwise its content type, YAML by default
+func remoteFormat(key, contentType string) string {
+	switch ext := strings.ToLower(path.Ext(key)); ext {
+	case ".yaml", ".yml", ".json", ".toml":
+		return ext
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		return ".json"
+	case strings.HasSuffix(mediaType, "toml"):
+		return ".toml"
+	}
+	return ".yaml"
+}
+
+// redactURL hides the password of a URL for messages
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Redacted()
+}
+
+// RemoteInterval returns the time between fetches of the remote configuration, zero when it is
+// only fetched at startup
+func RemoteInterval() (time.Duration, error) {
+	value := os.Getenv(RemoteIntervalEnv)
+	if value == "" {
+		return 0, nil
+	}
+	seconds, err := durationInUnit(value, "s")
+	if err == nil {
+		var interval time.Duration
+		if interval, err = time.ParseDuration(seconds + "s"); err == nil && interval >= 0 {
+			return interval, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid value %q for %s: expected a duration like 5m", value, RemoteIntervalEnv)
+}
+
+// WatchRemoteConfig fetches the remote configuration every interval until the context is done,
+// and calls changed once when it differs from the one LoadConfig applied and the configuration
+// loaded with it from environ, the environment LoadConfig started from, is valid. Failed fetches
+// and invalid changes are logged and the current configuration is kept.
+func WatchRemoteConfig(ctx context.Context, interval time.Duration, environ []string, changed func()) {
+	rawURL := os.Getenv(RemoteEnv)
+	token := os.Getenv(RemoteTokenEnv)
+
+	var loaded, rejected *[sha256.Size]byte
+	remoteLoaded.Lock()
+	if remoteLoaded.url == rawURL {
+		digest := remoteLoaded.digest
+		loaded = &digest
+	}
+	remoteLoaded.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, remoteTimeout)
+		data, ext, err := fetchRemoteConfig(fetchCtx, rawURL, token)
+		cancel()
+		digest := sha256.Sum256(data)
+
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("Failed to fetch remote configuration", "url", redactURL(rawURL), "error", err)
+		case loaded == nil:
+			loaded = &digest
+		case digest == *loaded || (rejected != nil && digest == *rejected):
+		default:
+			if err := validateRemoteConfig(environ, rawURL, data, ext); err != nil {
+				slog.Error("Remote configuration changed but is invalid, keeping the current configuration",
+					"url", redactURL(rawURL), "error", err)
+				rejected = &digest
+				break
+			}
+			slog.Info("Remote configuration changed", "url", redactURL(rawURL))
+			changed()
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// validateRemoteConfig loads the configuration from a copy of environ with a changed remote
+// configuration, leaving the environment of the process alone, and returns why Monic would not
+// start with it
+func validateRemoteConfig(environ []string, rawURL string, data []byte, ext string) error {
+	load := func(env environment, rawURL string) error { return applyRemoteConfig(env, rawURL, data, ext) }
+	cfg, err := loadConfig(newEnvCopy(environ), load)
+	if err != nil {
+		return err
+	}
+	return errors.Join(Validate(cfg)...)
+}
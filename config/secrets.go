@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"bconf.com/monic/types"
+)
+
+// secretFileSuffix marks a variable holding the path of a file with the value of a secret
+// setting, e.g. MONIC_ALERTING_EMAIL_PASSWORD_FILE
+const secretFileSuffix = "_FILE"
+
+// loadSecretFiles sets every secret setting given as a file, as Docker and Kubernetes mount
+// secrets: MONIC_ALERTING_EMAIL_PASSWORD is read from the file named by
+// MONIC_ALERTING_EMAIL_PASSWORD_FILE. Setting both variables to different values is an error.
+func loadSecretFiles(env environment) error {
+	secretVar := envVarPattern("MONIC", reflect.TypeOf(types.Config{}), isSecretField)
+	isSecret := func(name string) bool { return secretVar.MatchString(name) || name == RemoteTokenEnv }
+	for _, variable := range env.Environ() {
+		name, path, _ := strings.Cut(variable, "=")
+		base, found := strings.CutSuffix(name, secretFileSuffix)
+		if !found || !isSecret(base) {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		// Secret files usually end with a newline that is not part of the secret
+		secret := strings.TrimRight(string(data), "\r\n")
+
+		// The variable holds the secret already when the configuration is loaded again
+		if value, exists := env.Lookup(base); exists {
+			if value != secret {
+				return fmt.Errorf("both %s and %s are set, use only one", base, name)
+			}
+			continue
+		}
+		if err := env.Set(base, secret); err != nil {
+			return fmt.Errorf("failed to set %s: %w", base, err)
+		}
+	}
+	return nil
+}
+
+// isSecretField reports whether a field is a secret string setting, as recognized by Redact
+func isSecretField(field reflect.StructField) bool {
+	return field.Type.Kind() == reflect.String && isSensitiveField(field.Name)
+}
@@ -0,0 +1,346 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// Validate checks the ranges and formats of the settings and the consistency of related
+// settings. It returns every problem, each naming its variable, so all of them can be fixed at
+// once instead of one per restart. Checks of single subsystems, such as required fields of a
+// check, are left to the subsystems.
+func Validate(cfg *types.Config) []error {
+	v := &validator{}
+
+	if cfg.LogLevel != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+			v.addf("MONIC_LOG_LEVEL", "%q is not debug, info, warn or error", cfg.LogLevel)
+		}
+	}
+	switch strings.ToLower(cfg.LogFormat) {
+	case "", "json", "text":
+	default:
+		v.addf("MONIC_LOG_FORMAT", "%q is not json or text", cfg.LogFormat)
+	}
+
+	v.nonNegative("MONIC_CHECK_MAX_CONCURRENT", float64(cfg.CheckMaxConcurrent))
+	v.nonNegative("MONIC_CHECK_MAX_PER_HOST", float64(cfg.CheckMaxPerHost))
+	v.nonNegative("MONIC_CHECK_TRANSPORT_MAX_IDLE_CONNS", float64(cfg.CheckTransport.MaxIdleConns))
+	v.nonNegative("MONIC_CHECK_TRANSPORT_MAX_IDLE_CONNS_PER_HOST", float64(cfg.CheckTransport.MaxIdleConnsPerHost))
+
+	// Durations of every section and list entry
+	v.durations("MONIC", reflect.ValueOf(cfg).Elem())
+
+	system := cfg.SystemChecks
+	v.percent("MONIC_CHECK_SYSTEM_CPU_THRESHOLD", float64(system.CPUThreshold))
+	v.percent("MONIC_CHECK_SYSTEM_MEMORY_THRESHOLD", float64(system.MemoryThreshold))
+	v.percent("MONIC_CHECK_SYSTEM_DISK_THRESHOLD", float64(system.DiskThreshold))
+	v.percent("MONIC_CHECK_SYSTEM_SWAP_THRESHOLD", float64(system.SwapThreshold))
+	v.percent("MONIC_CHECK_SYSTEM_INODE_THRESHOLD", float64(system.InodeThreshold))
+	v.nonNegative("MONIC_CHECK_SYSTEM_LOAD_THRESHOLD", system.LoadThreshold)
+	v.nonNegative("MONIC_CHECK_SYSTEM_DISK_AWAIT_THRESHOLD", system.DiskAwaitThreshold)
+
+	for i, check := range cfg.HTTPChecks {
+		prefix := entryPrefix("MONIC_CHECK_HTTP", i)
+		v.httpURL(prefix+"_URL", check.URL)
+		if check.ExpectedStatus != 0 && (check.ExpectedStatus < 100 || check.ExpectedStatus > 599) {
+			v.addf(prefix+"_EXPECTED_STATUS", "%d is not an HTTP status code", check.ExpectedStatus)
+		}
+		v.timeout(prefix, check.Timeout, check.CheckInterval)
+		// Jitter of a whole interval or more would delay runs into the following ones
+		jitter, setting := check.Jitter, prefix+"_JITTER"
+		if jitter == 0 {
+			jitter, setting = cfg.CheckJitter, "MONIC_CHECK_JITTER"
+		}
+		if check.Schedule == "" && check.CheckInterval > 0 && jitter >= check.CheckInterval*1000 {
+			v.addf(setting, "%dms is not shorter than %s_INTERVAL (%ds)", jitter, prefix, check.CheckInterval)
+		}
+		v.retries(prefix, check)
+		v.alertTarget(prefix+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, check := range cfg.GRPCChecks {
+		prefix := entryPrefix("MONIC_CHECK_GRPC", i)
+		v.timeout(prefix, check.Timeout, check.CheckInterval)
+		v.alertTarget(prefix+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, check := range cfg.MailChecks {
+		prefix := entryPrefix("MONIC_CHECK_MAIL", i)
+		v.port(prefix+"_PORT", check.Port)
+		if check.TLS && check.StartTLS {
+			v.addf(prefix+"_STARTTLS", "cannot be combined with %s_TLS, use one of them", prefix)
+		}
+		v.timeout(prefix, check.Timeout, check.CheckInterval)
+		v.alertTarget(prefix+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, check := range cfg.NTPChecks {
+		prefix := entryPrefix("MONIC_CHECK_NTP", i)
+		v.timeout(prefix, check.Timeout, check.CheckInterval)
+		v.alertTarget(prefix+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, check := range cfg.SNMPChecks {
+		prefix := entryPrefix("MONIC_CHECK_SNMP", i)
+		v.timeout(prefix, check.Timeout, check.CheckInterval)
+		v.alertTarget(prefix+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, check := range cfg.MQTTChecks {
+		prefix := entryPrefix("MONIC_CHECK_MQTT", i)
+		v.timeout(prefix, check.Timeout, check.CheckInterval)
+		v.alertTarget(prefix+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, check := range cfg.QueueChecks {
+		prefix := entryPrefix("MONIC_CHECK_QUEUE", i)
+		v.timeout(prefix, check.Timeout, check.CheckInterval)
+		v.alertTarget(prefix+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, check := range cfg.ScriptChecks {
+		prefix := entryPrefix("MONIC_CHECK_SCRIPT", i)
+		v.timeout(prefix, check.Timeout, check.CheckInterval)
+		v.alertTarget(prefix+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, check := range cfg.Heartbeats {
+		v.alertTarget(entryPrefix("MONIC_HEARTBEAT", i)+"_ALERT", check.Alert, cfg.Alerting)
+	}
+	for i, peer := range cfg.Peers {
+		v.httpURL(entryPrefix("MONIC_PEER", i)+"_URL", peer.URL)
+	}
+
+	if cfg.CheckAlertingSMTP && !cfg.Alerting.Email.Enabled {
+		v.addf("MONIC_CHECK_MAIL_ALERTING_SMTP", "needs email alerting (MONIC_ALERTING_EMAIL_*)")
+	}
+	v.port("MONIC_ALERTING_EMAIL_SMTP_PORT", cfg.Alerting.Email.SMTPPort)
+	v.httpURL("MONIC_ALERTING_MAILGUN_BASE_URL", cfg.Alerting.Mailgun.BaseURL)
+	breaker := cfg.Alerting.CircuitBreaker
+	if breaker.OpenPeriod > 0 && breaker.MaxOpenPeriod > 0 && breaker.MaxOpenPeriod < breaker.OpenPeriod {
+		v.addf("MONIC_ALERTING_CIRCUIT_BREAKER_MAX_OPEN_PERIOD", "%ds is shorter than MONIC_ALERTING_CIRCUIT_BREAKER_OPEN_PERIOD (%ds)", breaker.MaxOpenPeriod, breaker.OpenPeriod)
+	}
+	for _, level := range cfg.Alerting.ImmediateLevels {
+		v.immediateLevel("MONIC_ALERTING_IMMEDIATE_LEVELS", level, len(cfg.Alerting.ImmediateLevels))
+	}
+	for i, route := range cfg.Alerting.Routes {
+		prefix := entryPrefix("MONIC_ALERTING_ROUTE", i)
+		if len(route.Checks) == 0 && len(route.Tags) == 0 {
+			v.addf(prefix+"_CHECKS", "a route needs checks or tags (%s_TAGS) to match", prefix)
+		}
+		if route.To.IsZero() {
+			v.addf(prefix+"_TO_CHANNELS", "a route needs channels or recipients (%s_TO_*)", prefix)
+		}
+		v.alertTarget(prefix+"_TO", route.To, cfg.Alerting)
+	}
+
+	docker := cfg.DockerChecks
+	v.nonNegative("MONIC_CHECK_DOCKER_CPU_THRESHOLD", docker.CPUThreshold)
+	v.percent("MONIC_CHECK_DOCKER_MEMORY_THRESHOLD", docker.MemoryThreshold)
+	v.nonNegative("MONIC_CHECK_DOCKER_DISK_USAGE_THRESHOLD", docker.DiskUsageThreshold)
+	v.nonNegative("MONIC_CHECK_DOCKER_CONCURRENCY", float64(docker.Concurrency))
+	for i, threshold := range docker.Thresholds {
+		if threshold.Memory > 100 {
+			v.addf(entryPrefix("MONIC_CHECK_DOCKER_THRESHOLD", i)+"_MEMORY", "%g is not a percentage between 0 and 100", threshold.Memory)
+		}
+	}
+	v.containerState("MONIC_CHECK_DOCKER_EXPECTED_STATE", docker.ExpectedState)
+	for i, expectation := range docker.Expectations {
+		v.containerState(entryPrefix("MONIC_CHECK_DOCKER_EXPECT", i)+"_STATE", expectation.State)
+	}
+	for i, rule := range docker.TagRules {
+		if rule.Container == "" {
+			v.addf(entryPrefix("MONIC_CHECK_DOCKER_TAG", i)+"_CONTAINER", "is required to tag a container")
+		}
+	}
+
+	server := cfg.HTTPServer
+	v.port("MONIC_HTTP_SERVER_PORT", server.Port)
+	if (server.Username == "") != (server.Password == "") {
+		v.addf("MONIC_HTTP_SERVER_USERNAME", "basic auth needs both MONIC_HTTP_SERVER_USERNAME and MONIC_HTTP_SERVER_PASSWORD")
+	}
+	if (server.TLS.CertFile == "") != (server.TLS.KeyFile == "") {
+		v.addf("MONIC_HTTP_SERVER_TLS_CERT_FILE", "HTTPS needs both MONIC_HTTP_SERVER_TLS_CERT_FILE and MONIC_HTTP_SERVER_TLS_KEY_FILE")
+	}
+	if server.TLS.CertFile != "" && len(server.TLS.ACMEDomains) > 0 {
+		v.addf("MONIC_HTTP_SERVER_TLS_ACME_DOMAINS", "cannot be combined with certificate files, use one of them")
+	}
+	// Probes send requests to any URL, so they must not be open to everyone reaching the server
+	if server.Probes.Enabled && server.Ingest.Secret == "" && (server.Username == "" || server.Password == "") {
+		v.addf("MONIC_HTTP_SERVER_PROBES_ENABLED", "probes need MONIC_HTTP_SERVER_INGEST_SECRET or basic auth credentials")
+	}
+
+	v.percent("MONIC_SLO_TARGET", cfg.SLO.Target)
+	v.nonNegative("MONIC_SLO_WINDOW_DAYS", float64(cfg.SLO.WindowDays))
+	v.nonNegative("MONIC_WATCHDOG_STALL_INTERVALS", float64(cfg.Watchdog.StallIntervals))
+	retention := cfg.Storage.Retention
+	v.nonNegative("MONIC_STORAGE_RETENTION_ALERTS_DAYS", float64(retention.AlertsDays))
+	v.nonNegative("MONIC_STORAGE_RETENTION_STATS_DAYS", float64(retention.StatsDays))
+	v.nonNegative("MONIC_STORAGE_RETENTION_CHECKS_DAYS", float64(retention.ChecksDays))
+	v.nonNegative("MONIC_STORAGE_RETENTION_DOCKER_DAYS", float64(retention.DockerDays))
+	v.nonNegative("MONIC_STORAGE_RETENTION_EVENTS_DAYS", float64(retention.EventsDays))
+
+	v.httpURL("MONIC_AGENT_SERVER_URL", cfg.Agent.ServerURL)
+	v.httpURL("MONIC_INFLUXDB_URL", cfg.InfluxDB.URL)
+	v.httpURL("MONIC_REMOTE_WRITE_URL", cfg.RemoteWrite.URL)
+
+	return v.problems
+}
+
+// validator collects the problems found by Validate
+type validator struct {
+	problems []error
+}
+
+// addf records a problem with a setting
+func (v *validator) addf(setting, format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Errorf("%s: %s", setting, fmt.Sprintf(format, args...)))
+}
+
+// percent checks that a threshold is a percentage (0 disables)
+func (v *validator) percent(setting string, value float64) {
+	if value < 0 || value > 100 {
+		v.addf(setting, "%g is not a percentage between 0 and 100", value)
+	}
+}
+
+// nonNegative checks that a threshold or count is not negative
+func (v *validator) nonNegative(setting string, value float64) {
+	if value < 0 {
+		v.addf(setting, "%g cannot be negative", value)
+	}
+}
+
+// port checks that a port number is valid, if set
+func (v *validator) port(setting string, port int) {
+	if port < 0 || port > 65535 {
+		v.addf(setting, "%d is not a port between 1 and 65535", port)
+	}
+}
+
+// httpURL checks that a URL is an absolute http or https URL, if set
+func (v *validator) httpURL(setting, value string) {
+	if value == "" {
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		v.addf(setting, "%q is not a valid URL: %v", value, err)
+		return
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		v.addf(setting, "%q is not an http:// or https:// URL with a host", value)
+	}
+}
+
+// timeout checks that the timeout of a check is shorter than its interval, so a slow target does
+// not delay the next run
+func (v *validator) timeout(prefix string, timeout, interval int) {
+	if timeout > 0 && interval > 0 && timeout >= interval {
+		v.addf(prefix+"_TIMEOUT", "%ds is not shorter than %s_INTERVAL (%ds)", timeout, prefix, interval)
+	}
+}
+
+// maxRetries is the number of quick retries of a failed HTTP check run allowed
+const maxRetries = 10
+
+// retries checks the retries of an HTTP check: they have to be done before the next run
+func (v *validator) retries(prefix string, check types.HTTPCheck) {
+	if check.Retries < 0 || check.Retries > maxRetries {
+		v.addf(prefix+"_RETRIES", "%d is not between 0 and %d", check.Retries, maxRetries)
+		return
+	}
+	if check.Schedule != "" || check.CheckInterval <= 0 {
+		return
+	}
+	var wait time.Duration
+	for _, delay := range check.RetryDelays() {
+		wait += delay
+	}
+	if interval := time.Duration(check.CheckInterval) * time.Second; wait >= interval {
+		v.addf(prefix+"_RETRY_DELAY", "%d retries wait %s, not shorter than %s_INTERVAL (%ds)", check.Retries, wait, prefix, check.CheckInterval)
+	}
+}
+
+// alertTarget checks that the channels and recipients of an alert target are enabled channels,
+// unless alerting is turned off altogether
+func (v *validator) alertTarget(prefix string, target types.AlertTarget, alerting types.AlertingConfig) {
+	if !alerting.Enabled {
+		return
+	}
+	enabled := map[string]bool{
+		"email":    alerting.Email.Enabled,
+		"mailgun":  alerting.Mailgun.Enabled,
+		"telegram": alerting.Telegram.Enabled,
+	}
+	for _, channel := range target.Channels {
+		if on, known := enabled[channel]; !known {
+			v.addf(prefix+"_CHANNELS", "%q is not email, mailgun or telegram", channel)
+		} else if !on {
+			v.addf(prefix+"_CHANNELS", "the %s channel is not configured", channel)
+		}
+	}
+	recipients := []struct{ setting, channel, value string }{
+		{"_EMAIL", "email", target.Email},
+		{"_MAILGUN", "mailgun", target.Mailgun},
+		{"_TELEGRAM_CHAT", "telegram", target.TelegramChat},
+	}
+	for _, recipient := range recipients {
+		if recipient.value != "" && !enabled[recipient.channel] {
+			v.addf(prefix+recipient.setting, "the %s channel is not configured", recipient.channel)
+		}
+	}
+}
+
+// containerState checks an expected container state
+func (v *validator) containerState(setting, state string) {
+	switch state {
+	case "", "running", "stopped", "any":
+		return
+	}
+	v.addf(setting, "%q is not running, stopped or any", state)
+}
+
+// immediateLevel checks a level of alerts sent right away; none can't be combined with levels
+func (v *validator) immediateLevel(setting, level string, levels int) {
+	switch strings.ToLower(level) {
+	case "info", "warning", "critical":
+		return
+	case "none":
+		if levels > 1 {
+			v.addf(setting, "none can't be combined with other levels")
+		}
+		return
+	}
+	v.addf(setting, "%q is not info, warning, critical or none", level)
+}
+
+// durations checks that no duration (a field with a unit tag) of a section, its nested sections
+// and list entries is negative
+func (v *validator) durations(prefix string, section reflect.Value) {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Type().Field(i)
+		if !field.IsExported() || field.Type == timeType {
+			continue
+		}
+
+		name := prefix + "_" + fieldEnvName(field)
+		value := section.Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			v.durations(name, value)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			for j := 0; j < value.Len(); j++ {
+				v.durations(entryPrefix(name, j), value.Index(j))
+			}
+		case field.Tag.Get("unit") != "" && value.CanInt() && value.Int() < 0:
+			v.addf(name, "%d%s cannot be negative", value.Int(), field.Tag.Get("unit"))
+		}
+	}
+}
+
+// entryPrefix returns the variable prefix of a list entry, e.g. MONIC_CHECK_GRPC_0
+func entryPrefix(prefix string, i int) string {
+	return prefix + "_" + strconv.Itoa(i)
+}
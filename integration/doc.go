@@ -0,0 +1,7 @@
+// Package integration contains opt-in end-to-end tests that run Monic's
+// check → state → alert → delivery flow against real services started in
+// Docker containers (MailHog SMTP, a target web server, test containers),
+// and the storage drivers against real databases (PostgreSQL).
+//
+// Run with: go test -tags integration ./integration/...
+package integration
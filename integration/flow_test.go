@@ -0,0 +1,170 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/alert"
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/server"
+	"bconf.com/monic/types"
+)
+
+const (
+	mailhogImage = "mailhog/mailhog:latest"
+	webImage     = "nginx:alpine"
+	exitImage    = "busybox:latest"
+)
+
+// startMailHog starts a MailHog container and returns an alert manager delivering email to it
+func startMailHog(h *harness) (*testContainer, *alert.AlertManager) {
+	mailhog := h.startContainer(mailhogImage, nil, "1025/tcp", "8025/tcp")
+
+	port, _ := strconv.Atoi(mailhog.Port("1025/tcp"))
+	alertManager := alert.NewAlertManager(&types.AlertingConfig{
+		Email: types.EmailConfig{
+			Enabled:  true,
+			SMTPHost: "127.0.0.1",
+			SMTPPort: port,
+			Username: "monic",
+			Password: "monic",
+			From:     "monic@example.com",
+			To:       "admin@example.com",
+		},
+	}, "Integration")
+
+	return mailhog, alertManager
+}
+
+// waitForEmail polls the MailHog API until a message with the given subject fragment arrives
+func waitForEmail(t *testing.T, mailhog *testContainer, subject string) {
+	t.Helper()
+
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get("http://" + mailhog.Addr("8025/tcp") + "/api/v2/messages")
+		if err == nil {
+			var messages struct {
+				Items []struct {
+					Content struct {
+						Headers map[string][]string
+					}
+				}
+			}
+			json.NewDecoder(resp.Body).Decode(&messages)
+			resp.Body.Close()
+
+			for _, item := range messages.Items {
+				for _, s := range item.Content.Headers["Subject"] {
+					if strings.Contains(s, subject) {
+						return
+					}
+				}
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	t.Fatalf("No email with subject containing %q was delivered", subject)
+}
+
+// runHTTPCycle mirrors one MonitorService HTTP collection cycle
+func runHTTPCycle(httpMonitor *monitor.HTTPMonitor, stateManager *alert.StateManager, storage *server.StorageManager, check types.HTTPCheck) types.HTTPCheckResult {
+	result := httpMonitor.CheckEndpoint(check)
+	storage.AddHTTPCheckResult(result)
+	storage.AddAlerts(stateManager.UpdateHTTPState([]types.HTTPCheckResult{result}))
+	return result
+}
+
+// deliverAlerts mirrors MonitorService alert processing
+func deliverAlerts(t *testing.T, alertManager *alert.AlertManager, storage *server.StorageManager) {
+	t.Helper()
+
+	if err := alertManager.SendAlerts(storage.GetAlerts()); err != nil {
+		t.Fatalf("Failed to deliver alerts: %v", err)
+	}
+	storage.ClearAlerts()
+}
+
+func TestHTTPCheckFailureDeliversEmail(t *testing.T) {
+	h := newHarness(t)
+	mailhog, alertManager := startMailHog(h)
+	web := h.startContainer(webImage, nil, "80/tcp")
+
+	httpMonitor := monitor.NewHTTPMonitor()
+	stateManager := alert.NewStateManager()
+	storage := server.NewStorageManager(100)
+
+	check := types.HTTPCheck{
+		URL:            "http://" + web.Addr("80/tcp"),
+		Method:         "GET",
+		Timeout:        2,
+		ExpectedStatus: 200,
+		CheckInterval:  1,
+	}
+
+	// Healthy target: no alerts
+	if result := runHTTPCycle(httpMonitor, stateManager, storage, check); !result.Success {
+		t.Fatalf("Expected healthy target, got error: %s", result.Error)
+	}
+	if storage.GetAlertsCount() != 0 {
+		t.Fatalf("Expected no alerts for healthy target, got %d", storage.GetAlertsCount())
+	}
+
+	// Target goes down: alert after 3 consecutive failures
+	h.stopContainer(web)
+	for i := 0; i < 3; i++ {
+		if result := runHTTPCycle(httpMonitor, stateManager, storage, check); result.Success {
+			t.Fatal("Expected check to fail after target was stopped")
+		}
+	}
+
+	alerts := storage.GetAlerts()
+	if len(alerts) != 1 || alerts[0].Level != "critical" {
+		t.Fatalf("Expected 1 critical alert after 3 failures, got %+v", alerts)
+	}
+
+	deliverAlerts(t, alertManager, storage)
+	waitForEmail(t, mailhog, "CRITICAL")
+}
+
+func TestDockerExitedContainerDeliversEmail(t *testing.T) {
+	h := newHarness(t)
+	mailhog, alertManager := startMailHog(h)
+	exited := h.startContainer(exitImage, []string{"sh", "-c", "exit 3"})
+	h.waitForExit(exited)
+
+	dockerMonitor := monitor.NewDockerMonitor(&types.DockerConfig{
+		Enabled:    true,
+		Containers: []string{exited.Name},
+	})
+	if err := dockerMonitor.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize Docker monitor: %v", err)
+	}
+	defer dockerMonitor.Close()
+
+	alerts, err := dockerMonitor.CheckContainerStatus()
+	if err != nil {
+		t.Fatalf("Failed to check container status: %v", err)
+	}
+
+	found := false
+	for _, a := range alerts {
+		if a.Level == "critical" && strings.Contains(a.Message, "exited with error code: 3") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Expected critical exit code alert, got %+v", alerts)
+	}
+
+	storage := server.NewStorageManager(100)
+	storage.AddAlerts(alerts)
+	deliverAlerts(t, alertManager, storage)
+	waitForEmail(t, mailhog, "docker")
+}
@@ -0,0 +1,165 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+// harness manages Docker containers started for a single integration test
+type harness struct {
+	t      *testing.T
+	client *client.Client
+}
+
+// testContainer is a container started by the harness
+type testContainer struct {
+	ID    string
+	Name  string
+	ports map[string]string // container port (e.g. "1025/tcp") -> host port
+}
+
+// newHarness connects to the Docker daemon or skips the test if it is not available
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Skipf("Docker client not available: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := cli.Ping(ctx); err != nil {
+		t.Skipf("Docker daemon not reachable: %v", err)
+	}
+
+	t.Cleanup(func() { cli.Close() })
+	return &harness{t: t, client: cli}
+}
+
+// startContainer pulls the image and starts a container publishing the given ports
+// on random host ports; the container is removed when the test finishes
+func (h *harness) startContainer(imageRef string, cmd []string, ports ...string) *testContainer {
+	h.t.Helper()
+	return h.startContainerWithEnv(imageRef, nil, cmd, ports...)
+}
+
+// startContainerWithEnv is like startContainer but sets environment variables (KEY=value)
+func (h *harness) startContainerWithEnv(imageRef string, env []string, cmd []string, ports ...string) *testContainer {
+	h.t.Helper()
+	ctx := context.Background()
+
+	reader, err := h.client.ImagePull(ctx, imageRef, image.PullOptions{})
+	if err != nil {
+		h.t.Fatalf("Failed to pull image %s: %v", imageRef, err)
+	}
+	io.Copy(io.Discard, reader)
+	reader.Close()
+
+	exposed := nat.PortSet{}
+	bindings := nat.PortMap{}
+	for _, port := range ports {
+		p := nat.Port(port)
+		exposed[p] = struct{}{}
+		bindings[p] = []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: ""}}
+	}
+
+	name := fmt.Sprintf("monic-it-%d", time.Now().UnixNano())
+	created, err := h.client.ContainerCreate(ctx,
+		&container.Config{Image: imageRef, Env: env, Cmd: cmd, ExposedPorts: exposed},
+		&container.HostConfig{PortBindings: bindings},
+		nil, nil, name)
+	if err != nil {
+		h.t.Fatalf("Failed to create container from %s: %v", imageRef, err)
+	}
+
+	h.t.Cleanup(func() {
+		h.client.ContainerRemove(context.Background(), created.ID, container.RemoveOptions{Force: true})
+	})
+
+	if err := h.client.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		h.t.Fatalf("Failed to start container %s: %v", name, err)
+	}
+
+	tc := &testContainer{ID: created.ID, Name: name, ports: make(map[string]string)}
+	if len(ports) == 0 {
+		return tc
+	}
+
+	info, err := h.client.ContainerInspect(ctx, created.ID)
+	if err != nil {
+		h.t.Fatalf("Failed to inspect container %s: %v", name, err)
+	}
+	for _, port := range ports {
+		mapped := info.NetworkSettings.Ports[nat.Port(port)]
+		if len(mapped) == 0 {
+			h.t.Fatalf("Port %s of container %s is not published", port, name)
+		}
+		tc.ports[port] = mapped[0].HostPort
+		h.waitForPort(tc.Addr(port))
+	}
+
+	return tc
+}
+
+// stopContainer stops a running container
+func (h *harness) stopContainer(tc *testContainer) {
+	h.t.Helper()
+
+	timeout := 5
+	if err := h.client.ContainerStop(context.Background(), tc.ID, container.StopOptions{Timeout: &timeout}); err != nil {
+		h.t.Fatalf("Failed to stop container %s: %v", tc.Name, err)
+	}
+}
+
+// waitForExit waits until a container has exited
+func (h *harness) waitForExit(tc *testContainer) {
+	h.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	statusCh, errCh := h.client.ContainerWait(ctx, tc.ID, container.WaitConditionNotRunning)
+	select {
+	case <-statusCh:
+	case err := <-errCh:
+		h.t.Fatalf("Failed waiting for container %s: %v", tc.Name, err)
+	}
+}
+
+// waitForPort waits until a TCP port accepts connections
+func (h *harness) waitForPort(addr string) {
+	h.t.Helper()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	h.t.Fatalf("Timed out waiting for %s", addr)
+}
+
+// Addr returns the host address of a published container port
+func (tc *testContainer) Addr(port string) string {
+	return net.JoinHostPort("127.0.0.1", tc.ports[port])
+}
+
+// Port returns the host port of a published container port
+func (tc *testContainer) Port(port string) string {
+	return tc.ports[port]
+}
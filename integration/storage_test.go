@@ -0,0 +1,74 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"bconf.com/monic/server"
+	"bconf.com/monic/types"
+)
+
+// startPostgres starts a PostgreSQL container and returns a connected storage backend
+func startPostgres(h *harness) *server.PostgresStorage {
+	h.t.Helper()
+
+	pg := h.startContainerWithEnv("postgres:16-alpine", []string{"POSTGRES_PASSWORD=monic", "POSTGRES_DB=monic"}, nil, "5432/tcp")
+	dsn := fmt.Sprintf("postgres://postgres:monic@%s/monic?sslmode=disable", pg.Addr("5432/tcp"))
+
+	// The server accepts connections before initialization has finished
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		storage, err := server.NewPostgresStorage(dsn, 3)
+		if err == nil {
+			h.t.Cleanup(func() { storage.Close() })
+			return storage
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("PostgreSQL not ready: %v", err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func TestPostgresStorage(t *testing.T) {
+	h := newHarness(t)
+	storage := startPostgres(h)
+
+	for i := 0; i < 5; i++ {
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", StatusCode: 200 + i, Success: true, Timestamp: time.Now()})
+	}
+	storage.AddSystemStats(types.SystemStats{Timestamp: time.Now(), CPUUsage: 42})
+	storage.AddAlerts([]types.Alert{{Type: "cpu"}, {Type: "memory"}})
+
+	results := storage.GetHTTPCheckResults()
+	if len(results) != 3 || results[0].StatusCode != 202 || results[2].StatusCode != 204 {
+		t.Errorf("Expected the 3 newest results in order, got %+v", results)
+	}
+
+	if latest := storage.GetLatestSystemStats(); latest == nil || latest.CPUUsage != 42 {
+		t.Errorf("Unexpected latest system stats: %+v", latest)
+	}
+
+	if storage.GetAlertsCount() != 2 {
+		t.Errorf("Expected 2 alerts, got %d", storage.GetAlertsCount())
+	}
+	storage.ClearAlerts()
+	if storage.GetAlertsCount() != 0 {
+		t.Error("Expected no alerts after clearing")
+	}
+
+	// Purge by entry timestamp
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "old", Timestamp: time.Now().Add(-48 * time.Hour)})
+	deleted, err := storage.PurgeHistory(server.HistoryChecks, time.Now().Add(-time.Hour))
+	if err != nil || deleted != 1 {
+		t.Errorf("Expected 1 purged check result, got %d (err: %v)", deleted, err)
+	}
+
+	storage.AddAuditEntry(types.PurgeAuditEntry{DataType: server.HistoryChecks, Deleted: deleted, Trigger: "api"})
+	if entries := storage.GetAuditEntries(); len(entries) != 1 || entries[0].Deleted != 1 {
+		t.Errorf("Unexpected audit entries: %+v", entries)
+	}
+}
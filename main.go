@@ -1,46 +1,180 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"bconf.com/monic/alert"
 	"bconf.com/monic/config"
 	"bconf.com/monic/monitor"
 	"bconf.com/monic/server"
+	"bconf.com/monic/types"
+
+	"github.com/spf13/pflag"
 )
 
 // version will be set during build
 var version = "dev"
 
 func main() {
+	// Monic restarts itself with its original arguments and environment when the remote
+	// configuration changes, as loading the configuration sets variables
+	argv := append([]string(nil), os.Args...)
+	environ := os.Environ()
+
+	// A config file given on the command line applies to all commands
+	args, err := configFileFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	// Settings given as flags before the command, e.g. monic --http-server-port 8080 validate
+	flags := pflag.NewFlagSet("monic", pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	showVersion := flags.BoolP("version", "v", false, "print the version and exit")
+	config.AddFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: monic [--config file] [flags] [command]\n\n")
+		fmt.Fprintf(os.Stderr, "Commands: validate, config, export, import, pause, resume, apikey, support-bundle\n")
+		fmt.Fprintf(os.Stderr, "Without a command Monic starts monitoring.\n\nFlags:\n%s", flags.FlagUsages())
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%v\nRun monic --help for the flags.\n", err)
+		os.Exit(2)
+	}
+	if err := config.ApplyFlags(flags); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	os.Args = append(os.Args[:1], flags.Args()...)
+
 	// Handle version flag
-	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
+	if *showVersion {
 		fmt.Printf("Monic v%s\n", version)
 		return
 	}
 
-	// Configure structured logging
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	slog.SetDefault(logger)
+	// Handle support bundle command
+	if len(os.Args) > 1 && os.Args[1] == "support-bundle" {
+		if err := runSupportBundle(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create support bundle: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle API key management commands
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKey(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "API key command failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle snapshot export and import commands
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Import failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle configuration validation for CI pipelines and deploy hooks
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		if err := runValidate(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Validation failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle effective configuration dump
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Config command failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle subsystem pause/resume commands
+	if len(os.Args) > 1 && (os.Args[1] == "pause" || os.Args[1] == "resume") {
+		if err := runToggleSubsystem(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to %s subsystem: %v\n", os.Args[1], err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Configure structured logging (recent lines are kept in memory for support bundles)
+	logBuffer := server.NewLogBuffer(500)
+	logLevel := new(slog.LevelVar)
+	logOutput := io.MultiWriter(os.Stdout, logBuffer)
+	slog.SetDefault(slog.New(server.NewLogHandler("", logOutput, logLevel)))
 
 	// Load configuration from environment variables
+	configEnviron := os.Environ()
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		slog.Error("Failed to load configuration", "error", err)
 		os.Exit(1)
 	}
+	if problems := config.Validate(cfg); len(problems) > 0 {
+		for _, problem := range problems {
+			slog.Error("Invalid configuration", "problem", problem)
+		}
+		os.Exit(1)
+	}
+	if cfg.LogLevel != "" {
+		var level slog.Level
+		_ = level.UnmarshalText([]byte(cfg.LogLevel)) // Checked by Validate
+		logLevel.Set(level)
+	}
+	if cfg.LogFormat != "" {
+		slog.SetDefault(slog.New(server.NewLogHandler(cfg.LogFormat, logOutput, logLevel)))
+	}
+	remoteInterval, err := config.RemoteInterval()
+	if err != nil {
+		slog.Error("Invalid configuration", "problem", err)
+		os.Exit(1)
+	}
 
 	// Create all dependencies
 	systemMonitor := monitor.NewSystemMonitor(&cfg.SystemChecks)
 	httpMonitor := monitor.NewHTTPMonitor()
+	httpMonitor.SetPool(monitor.NewCheckPool(cfg.CheckMaxConcurrent, cfg.CheckMaxPerHost))
+	httpMonitor.SetTransport(cfg.CheckTransport)
+	heartbeatMonitor := monitor.NewHeartbeatMonitor(cfg.Heartbeats)
+	agentMonitor := monitor.NewAgentMonitor(&cfg.HTTPServer.Agents)
 	dockerMonitor := monitor.NewDockerMonitor(&cfg.DockerChecks)
 	alertManager := alert.NewAlertManager(&cfg.Alerting, cfg.AppName)
+	alertManager.SetCheckTargets(alert.CheckTargets(cfg))
 	stateManager := alert.NewStateManager()
-	storage := server.NewStorageManager(100)
+	storage, err := server.NewStorage(&cfg.Storage)
+	if err != nil {
+		slog.Error("Failed to initialize storage", "driver", cfg.Storage.Driver, "error", err)
+		os.Exit(1)
+	}
 	
 	statsServer := server.NewStatsServer(
 		&cfg.HTTPServer,
@@ -48,6 +182,29 @@ func main() {
 		storage,
 		stateManager,
 	)
+	statsServer.SetSupportBundle(server.NewSupportBundle(cfg, version, logBuffer, storage))
+	statsServer.SetHeartbeatMonitor(heartbeatMonitor)
+	statsServer.SetAgentMonitor(agentMonitor)
+	statsServer.SetProbeMonitor(httpMonitor)
+	if cfg.HTTPServer.KeysFile != "" {
+		statsServer.SetAPIKeyStore(server.NewAPIKeyStore(cfg.HTTPServer.KeysFile))
+	}
+
+	checkRunners := newCheckRunners(cfg, heartbeatMonitor, agentMonitor)
+
+	// Checks created at runtime through the API, kept in a file across restarts
+	if cfg.ManagedChecks.File != "" {
+		managedChecks := monitor.NewManagedCheckMonitor(cfg.ManagedChecks.File, httpMonitor)
+		managedChecks.OnChange(func(checks []types.ManagedCheck) {
+			alertManager.SetManagedCheckTargets(alert.ManagedCheckTargets(checks))
+		})
+		if err := managedChecks.Load(); err != nil {
+			slog.Error("Failed to load managed checks", "file", cfg.ManagedChecks.File, "error", err)
+			os.Exit(1)
+		}
+		statsServer.SetManagedChecks(managedChecks)
+		checkRunners = append(checkRunners, managedChecks)
+	}
 
 	// Create and start monitoring service
 	service := server.NewMonitorService(
@@ -59,7 +216,46 @@ func main() {
 		stateManager,
 		storage,
 		statsServer,
+		checkRunners...,
 	)
+
+	host := cfg.AppName
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	// Stream check results and alerts to NATS if configured
+	if cfg.NATS.URL != "" {
+		service.SetPublisher(server.NewNATSPublisher(&cfg.NATS, host))
+	}
+
+	// Forward system stats and check results to InfluxDB if configured
+	if cfg.InfluxDB.URL != "" {
+		service.AddExporter(server.NewInfluxDBExporter(&cfg.InfluxDB, host))
+	}
+
+	// Push system stats and check results to a Prometheus remote write receiver if configured
+	if cfg.RemoteWrite.URL != "" {
+		service.AddExporter(server.NewRemoteWriteExporter(&cfg.RemoteWrite, host))
+	}
+
+	// Send system gauges and check latencies to Graphite and StatsD if configured
+	if cfg.Graphite.Address != "" {
+		service.AddExporter(server.NewGraphiteExporter(&cfg.Graphite, host))
+	}
+	if cfg.StatsD.Address != "" {
+		service.AddExporter(server.NewStatsDExporter(&cfg.StatsD, host))
+	}
+
+	// Run the HTTP check from peer instances in other locations
+	if len(cfg.Peers) > 0 {
+		service.SetPeerProber(server.NewPeerProber(cfg.Peers, host))
+	}
+
+	// Push system and Docker stats to a central instance when running as an agent
+	if cfg.Agent.ServerURL != "" {
+		service.SetAgentReporter(server.NewAgentReporter(&cfg.Agent, host, version, storage))
+	}
 	
 	if err := service.Start(); err != nil {
 		slog.Error("Failed to start monitoring service", "error", err)
@@ -70,7 +266,419 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	<-sigChan
+	// Restart with the new configuration when the remote configuration changes
+	configChanged := make(chan struct{})
+	if os.Getenv(config.RemoteEnv) != "" && remoteInterval > 0 {
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		go config.WatchRemoteConfig(watchCtx, remoteInterval, configEnviron, func() { close(configChanged) })
+	}
+
+	restart := false
+	select {
+	case <-sigChan:
+	case <-configChanged:
+		restart = true
+	}
 	service.Stop()
+	if err := storage.Close(); err != nil {
+		slog.Error("Failed to close storage", "error", err)
+	}
+	if restart {
+		restartProcess(argv, environ)
+	}
 	slog.Info("Monic monitoring service shutdown complete")
 }
+
+// restartProcess replaces the process with a new one of the same executable, arguments and
+// environment, which loads the configuration again
+func restartProcess(argv, environ []string) {
+	executable, err := os.Executable()
+	if err == nil {
+		slog.Info("Restarting with the changed remote configuration")
+		err = syscall.Exec(executable, argv, environ)
+	}
+	slog.Error("Failed to restart with the changed remote configuration", "error", err)
+	os.Exit(1)
+}
+
+// newCheckRunners creates the runners of the gRPC, mail, NTP, SNMP, MQTT, queue and script checks,
+// heartbeats and agents
+func newCheckRunners(cfg *types.Config, heartbeatMonitor *monitor.HeartbeatMonitor, agentMonitor *monitor.AgentMonitor) []server.CheckRunner {
+	return []server.CheckRunner{
+		monitor.NewGRPCMonitor(cfg.GRPCChecks),
+		monitor.NewMailMonitor(cfg.MailChecks),
+		monitor.NewNTPMonitor(cfg.NTPChecks),
+		monitor.NewSNMPMonitor(cfg.SNMPChecks),
+		monitor.NewMQTTMonitor(cfg.MQTTChecks),
+		monitor.NewQueueMonitor(cfg.QueueChecks),
+		monitor.NewScriptMonitor(cfg.ScriptChecks),
+		heartbeatMonitor,
+		agentMonitor,
+	}
+}
+
+// configFileFlag removes --config <path> (or --config=<path>) from the arguments and makes
+// LoadConfig read that file
+func configFileFlag(args []string) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		path, found := strings.CutPrefix(args[i], "--config=")
+		if args[i] == "--config" {
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("--config requires a file path")
+			}
+			i++
+			path, found = args[i], true
+		}
+		if !found {
+			rest = append(rest, args[i])
+			continue
+		}
+		if err := os.Setenv(config.FileEnv, path); err != nil {
+			return nil, fmt.Errorf("failed to set config file: %w", err)
+		}
+	}
+	return rest, nil
+}
+
+// runToggleSubsystem pauses or resumes a subsystem (system, http, docker, alerting, grpc, mail, ntp, snmp, mqtt, queue, script, heartbeat, agents)
+// of the running instance
+func runToggleSubsystem(action string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: monic %s <system|http|docker|alerting|grpc|mail|ntp|snmp|mqtt|queue|script|heartbeat|agents>", action)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if !cfg.HTTPServer.Enabled {
+		return fmt.Errorf("the HTTP stats server must be enabled to control a running instance")
+	}
+
+	if err := server.ToggleSubsystem(&cfg.HTTPServer, args[0], action == "pause"); err != nil {
+		return err
+	}
+
+	fmt.Printf("Subsystem %s %sd\n", args[0], action)
+	return nil
+}
+
+// runConfig prints the configuration loaded from the environment and config file with secrets
+// redacted: dump [--format yaml|json]
+func runConfig(args []string) error {
+	usage := fmt.Errorf("usage: monic config dump [--format yaml|json]")
+	if len(args) == 0 || args[0] != "dump" {
+		return usage
+	}
+
+	format := "yaml"
+	switch {
+	case len(args) == 1:
+	case len(args) == 3 && args[1] == "--format":
+		format = args[2]
+	case len(args) == 2 && strings.HasPrefix(args[1], "--format="):
+		format = strings.TrimPrefix(args[1], "--format=")
+	default:
+		return usage
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return config.WriteDump(os.Stdout, cfg, format)
+}
+
+// runValidate checks the configuration without starting the service: it runs the validation of
+// all checks and alert channels, resolves the host names of all targets (unless --skip-dns is
+// given) and prints a report. It fails when any check fails, for CI pipelines and deploy hooks.
+func runValidate(args []string) error {
+	resolve := true
+	for _, arg := range args {
+		if arg != "--skip-dns" {
+			return fmt.Errorf("usage: monic validate [--skip-dns]")
+		}
+		resolve = false
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Every problem of the settings themselves is a failed check of its own
+	checks := []server.ConfigCheck{{Name: "config"}}
+	if problems := config.Validate(cfg); len(problems) > 0 {
+		checks = checks[:0]
+		for _, problem := range problems {
+			checks = append(checks, server.ConfigCheck{Name: "config", Error: problem})
+		}
+	}
+
+	httpMonitor := monitor.NewHTTPMonitor()
+	httpMonitor.SetPool(monitor.NewCheckPool(cfg.CheckMaxConcurrent, cfg.CheckMaxPerHost))
+	httpMonitor.SetTransport(cfg.CheckTransport)
+	checkRunners := newCheckRunners(cfg, monitor.NewHeartbeatMonitor(cfg.Heartbeats), monitor.NewAgentMonitor(&cfg.HTTPServer.Agents))
+	if cfg.ManagedChecks.File != "" {
+		managedChecks := monitor.NewManagedCheckMonitor(cfg.ManagedChecks.File, httpMonitor)
+		err := managedChecks.Load()
+		if err != nil {
+			err = fmt.Errorf("failed to load managed checks from %s: %w", cfg.ManagedChecks.File, err)
+		} else {
+			checkRunners = append(checkRunners, managedChecks)
+		}
+		checks = append(checks, server.ConfigCheck{Name: "managed_checks", Error: err})
+	}
+
+	service := server.NewMonitorService(
+		cfg,
+		monitor.NewSystemMonitor(&cfg.SystemChecks),
+		httpMonitor,
+		monitor.NewDockerMonitor(&cfg.DockerChecks),
+		alert.NewAlertManager(&cfg.Alerting, cfg.AppName),
+		alert.NewStateManager(),
+		server.NewStorageManager(1),
+		nil,
+		checkRunners...,
+	)
+	if len(cfg.Peers) > 0 {
+		host := cfg.AppName
+		if host == "" {
+			host, _ = os.Hostname()
+		}
+		service.SetPeerProber(server.NewPeerProber(cfg.Peers, host))
+	}
+
+	checks = append(checks, service.Validate()...)
+	if resolve {
+		checks = append(checks, server.ResolveTargets(cfg, 5*time.Second)...)
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if check.Error != nil {
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", check.Name, check.Error)
+			continue
+		}
+		fmt.Printf("ok    %s\n", check.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+
+	fmt.Printf("Configuration is valid (%d checks passed)\n", len(checks))
+	return nil
+}
+
+// runSupportBundle downloads a support bundle from the running instance, falling back
+// to a local bundle (config, goroutines, version) when the stats server is not reachable
+func runSupportBundle(args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	bundle := server.NewSupportBundle(cfg, version, nil, nil)
+	path := bundle.FileName()
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if cfg.HTTPServer.Enabled {
+		err := server.FetchSupportBundle(&cfg.HTTPServer, file)
+		if err == nil {
+			fmt.Printf("Support bundle written to %s\n", path)
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Could not fetch bundle from running instance (%v), creating local bundle\n", err)
+
+		// Discard any partial download
+		if err := file.Truncate(0); err != nil {
+			return fmt.Errorf("failed to reset %s: %w", path, err)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to reset %s: %w", path, err)
+		}
+	}
+
+	if err := bundle.WriteArchive(file); err != nil {
+		return err
+	}
+
+	fmt.Printf("Support bundle written to %s\n", path)
+	return nil
+}
+
+// runAPIKey manages scoped API keys: create <name> <scope,...>, list, revoke <name>
+func runAPIKey(args []string) error {
+	usage := fmt.Errorf("usage: monic apikey create <name> <read:stats|write:silences|admin:config>[,...] | list | revoke <name>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.HTTPServer.KeysFile == "" {
+		return fmt.Errorf("MONIC_HTTP_SERVER_API_KEYS_FILE must be set to manage API keys")
+	}
+	store := server.NewAPIKeyStore(cfg.HTTPServer.KeysFile)
+
+	switch {
+	case args[0] == "create" && len(args) == 3:
+		key, err := store.Create(args[1], strings.Split(args[2], ","))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("API key %s created. Store it now, it cannot be shown again:\n%s\n", args[1], key)
+	case args[0] == "list" && len(args) == 1:
+		keys, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			fmt.Printf("%s\t%s\t%s\n", key.Name, strings.Join(key.Scopes, ","), key.CreatedAt.Format(time.RFC3339))
+		}
+	case args[0] == "revoke" && len(args) == 2:
+		if err := store.Revoke(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("API key %s revoked\n", args[1])
+	default:
+		return usage
+	}
+	return nil
+}
+
+// runExport writes a snapshot of all history to a file (JSON, or CSV files in a .tar.gz archive),
+// taken from the running instance or, when it is not running, from the configured storage
+func runExport(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: monic export [file.json|file.tar.gz]")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	path := server.SnapshotFileName("json")
+	if len(args) == 1 {
+		path = args[0]
+	}
+	format := server.SnapshotFormat(path)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if cfg.HTTPServer.Enabled {
+		err := server.FetchSnapshot(&cfg.HTTPServer, format, file)
+		if err == nil {
+			fmt.Printf("Snapshot written to %s\n", path)
+			return nil
+		}
+		if !errors.Is(err, server.ErrUnreachable) {
+			os.Remove(path)
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Could not reach the running instance (%v), exporting from storage\n", err)
+	}
+
+	if err := exportFromStorage(cfg, file, format); err != nil {
+		os.Remove(path)
+		return err
+	}
+	fmt.Printf("Snapshot written to %s (without alert states)\n", path)
+	return nil
+}
+
+// exportFromStorage writes a snapshot of the configured storage, which only has history while
+// no instance holds it open
+func exportFromStorage(cfg *types.Config, w io.Writer, format string) error {
+	if driver := strings.ToLower(cfg.Storage.Driver); driver == "" || driver == "memory" {
+		return fmt.Errorf("in-memory storage can only be exported from the running instance")
+	}
+
+	storage, err := server.NewStorage(&cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer storage.Close()
+
+	return server.NewSnapshot(storage, nil).Write(w, format)
+}
+
+// runImport replaces the history of the running instance, or of the configured storage when it
+// is not running, with a JSON snapshot
+func runImport(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: monic import <file.json>")
+	}
+	path := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if cfg.HTTPServer.Enabled {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		summary, err := server.PushSnapshot(&cfg.HTTPServer, file)
+		file.Close()
+		if err == nil {
+			fmt.Printf("Snapshot imported: %s\n", summary)
+			return nil
+		}
+		if !errors.Is(err, server.ErrUnreachable) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Could not reach the running instance (%v), importing into storage\n", err)
+	}
+
+	if driver := strings.ToLower(cfg.Storage.Driver); driver == "" || driver == "memory" {
+		return fmt.Errorf("in-memory storage can only be imported into the running instance")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+	snapshot, err := server.ReadSnapshot(file)
+	if err != nil {
+		return err
+	}
+
+	storage, err := server.NewStorage(&cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer storage.Close()
+
+	imported, err := snapshot.Restore(storage, "cli")
+	if err != nil {
+		return err
+	}
+	counts := make([]string, 0, len(server.HistoryTypes))
+	for _, dataType := range server.HistoryTypes {
+		counts = append(counts, fmt.Sprintf("%s=%d", dataType, imported[dataType]))
+	}
+	fmt.Printf("Snapshot imported into storage: %s (alert states are only restored by a running instance)\n", strings.Join(counts, " "))
+	return nil
+}
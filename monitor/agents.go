@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	// agentCheckInterval is how often missing agent reports are evaluated
+	agentCheckInterval = 30 * time.Second
+	// defaultAgentInterval is assumed for agents that do not report their interval
+	defaultAgentInterval = 30
+	// defaultMissedReports is the number of missed reports after which an agent counts as down
+	defaultMissedReports = 3
+)
+
+// AgentStatus is the latest report of a remote agent and whether it is still reporting
+type AgentStatus struct {
+	Report     types.AgentReport
+	ReceivedAt time.Time
+	Stale      bool
+}
+
+// AgentMonitor keeps the reports pushed by remote agents: an agent that stops reporting
+// produces a failed "agent" check result
+type AgentMonitor struct {
+	config  *types.AgentsConfig
+	mu      sync.Mutex
+	reports map[string]AgentStatus
+}
+
+// NewAgentMonitor creates a new agent monitor instance
+func NewAgentMonitor(config *types.AgentsConfig) *AgentMonitor {
+	return &AgentMonitor{
+		config:  config,
+		reports: make(map[string]AgentStatus),
+	}
+}
+
+// Name returns the monitor name used in logs
+func (am *AgentMonitor) Name() string {
+	return "Agents"
+}
+
+// Interval returns how often missing reports are evaluated
+func (am *AgentMonitor) Interval() time.Duration {
+	return agentCheckInterval
+}
+
+// Validate validates the agent configuration
+func (am *AgentMonitor) Validate() error {
+	if am.config.MissedReports < 0 {
+		return fmt.Errorf("missed reports cannot be negative")
+	}
+	return nil
+}
+
+// Record stores the report of an agent
+func (am *AgentMonitor) Record(report types.AgentReport, at time.Time) error {
+	if report.Host == "" {
+		return fmt.Errorf("report has no host")
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.reports[report.Host] = AgentStatus{Report: report, ReceivedAt: at}
+	return nil
+}
+
+// Agents returns the latest report of every agent, sorted by host
+func (am *AgentMonitor) Agents() []AgentStatus {
+	return am.agentsAt(time.Now())
+}
+
+// agentsAt returns the latest reports with their staleness at the given time
+func (am *AgentMonitor) agentsAt(now time.Time) []AgentStatus {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	agents := make([]AgentStatus, 0, len(am.reports))
+	for _, status := range am.reports {
+		status.Stale = now.Sub(status.ReceivedAt) > am.deadline(status.Report)
+		agents = append(agents, status)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Report.Host < agents[j].Report.Host })
+	return agents
+}
+
+// deadline returns how long an agent may stay silent before it counts as down
+func (am *AgentMonitor) deadline(report types.AgentReport) time.Duration {
+	interval := report.Interval
+	if interval <= 0 {
+		interval = defaultAgentInterval
+	}
+	missed := am.config.MissedReports
+	if missed == 0 {
+		missed = defaultMissedReports
+	}
+	return time.Duration(interval*missed) * time.Second
+}
+
+// RunChecks evaluates whether all known agents are still reporting
+func (am *AgentMonitor) RunChecks() []types.HTTPCheckResult {
+	return am.checkAt(time.Now())
+}
+
+// checkAt evaluates the agents at the given time. Agents are known from their first report on,
+// so an agent that never reported after a restart of this instance is not detected.
+func (am *AgentMonitor) checkAt(now time.Time) []types.HTTPCheckResult {
+	agents := am.agentsAt(now)
+	results := make([]types.HTTPCheckResult, 0, len(agents))
+	for _, status := range agents {
+		result := types.HTTPCheckResult{
+			Name:      status.Report.Host,
+			Type:      "agent",
+			URL:       "agent://" + status.Report.Host,
+			Timestamp: now,
+		}
+		if status.Stale {
+			since := now.Sub(status.ReceivedAt).Round(time.Second)
+			result.Error = fmt.Sprintf("agent down: no report for %s (last at %s)", since, status.ReceivedAt.Format(time.RFC3339))
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
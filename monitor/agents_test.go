@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestAgentMonitor_Staleness(t *testing.T) {
+	am := NewAgentMonitor(&types.AgentsConfig{Enabled: true, MissedReports: 2})
+	now := time.Now()
+
+	if err := am.Record(types.AgentReport{}, now); err == nil {
+		t.Error("Expected error for report without host")
+	}
+	am.Record(types.AgentReport{Host: "web-2", Interval: 10}, now.Add(-25*time.Second))
+	am.Record(types.AgentReport{Host: "web-1", Interval: 10}, now.Add(-15*time.Second))
+
+	results := am.checkAt(now)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Name != "web-1" || !results[0].Success || results[0].Type != "agent" {
+		t.Errorf("Expected web-1 to be reporting, got %+v", results[0])
+	}
+	if results[1].Name != "web-2" || results[1].Success || !strings.Contains(results[1].Error, "no report for 25s") {
+		t.Errorf("Expected web-2 to be down, got %+v", results[1])
+	}
+
+	// A new report brings the agent back
+	am.Record(types.AgentReport{Host: "web-2", Interval: 10}, now)
+	for _, status := range am.agentsAt(now) {
+		if status.Stale {
+			t.Errorf("Expected %s to be reporting", status.Report.Host)
+		}
+	}
+}
+
+func TestAgentMonitor_DefaultDeadline(t *testing.T) {
+	am := NewAgentMonitor(&types.AgentsConfig{})
+	now := time.Now()
+	am.Record(types.AgentReport{Host: "web-1"}, now.Add(-80*time.Second))
+
+	if results := am.checkAt(now); !results[0].Success {
+		t.Errorf("Expected agent within 3 missed 30s reports to be up, got %+v", results[0])
+	}
+	if results := am.checkAt(now.Add(20 * time.Second)); results[0].Success {
+		t.Error("Expected agent to be down after 3 missed reports")
+	}
+}
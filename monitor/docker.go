@@ -2,8 +2,14 @@ package monitor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"bconf.com/monic/types"
@@ -16,12 +22,32 @@ import (
 type DockerMonitor struct {
 	config *types.DockerConfig
 	client *client.Client
+
+	// Previous CPU counters per container, used to compute CPU usage between collections
+	cpuMu   sync.Mutex
+	prevCPU map[string]container.CPUStats
+
+	// Cached inspections per container ID
+	inspectMu sync.Mutex
+	inspected map[string]inspectedContainer
+
+	// Log scanning state per container ID
+	logMu       sync.Mutex
+	logPatterns []containerLogPattern
+	logState    map[string]*containerLogState
+
+	// Latest docker system df result
+	diskMu    sync.Mutex
+	diskUsage *types.DockerDiskUsage
 }
 
 // NewDockerMonitor creates a new Docker monitor instance
 func NewDockerMonitor(config *types.DockerConfig) *DockerMonitor {
 	return &DockerMonitor{
-		config: config,
+		config:    config,
+		prevCPU:   make(map[string]container.CPUStats),
+		inspected: make(map[string]inspectedContainer),
+		logState:  make(map[string]*containerLogState),
 	}
 }
 
@@ -55,6 +81,20 @@ func (dm *DockerMonitor) Initialize() error {
 	return nil
 }
 
+// Validate checks the container selection, expected states and compose replicas and compiles the log patterns
+func (dm *DockerMonitor) Validate() error {
+	if _, err := newContainerFilter(dm.config); err != nil {
+		return err
+	}
+	if err := dm.validateExpectations(); err != nil {
+		return err
+	}
+	if err := dm.validateCompose(); err != nil {
+		return err
+	}
+	return dm.validateLogPatterns()
+}
+
 // CheckContainers checks the status of Docker containers
 func (dm *DockerMonitor) CheckContainers() ([]types.DockerContainerStats, error) {
 	if !dm.config.Enabled || dm.client == nil {
@@ -69,27 +109,19 @@ func (dm *DockerMonitor) CheckContainers() ([]types.DockerContainerStats, error)
 		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
 
+	filter, err := newContainerFilter(dm.config)
+	if err != nil {
+		return nil, err
+	}
+
+	var listed []container.Summary
 	var stats []types.DockerContainerStats
 	now := time.Now()
 
 	for _, c := range containers {
-		// Filter containers if specific ones are configured
-		if len(dm.config.Containers) > 0 {
-			found := false
-			for _, targetContainer := range dm.config.Containers {
-				for _, name := range c.Names {
-					if name == targetContainer || name == "/"+targetContainer {
-						found = true
-						break
-					}
-				}
-				if found {
-					break
-				}
-			}
-			if !found {
-				continue
-			}
+		// Filter containers by the configured names and labels
+		if !filter.matchesAny(c.Names, c.Labels) {
+			continue
 		}
 
 		containerStats := types.DockerContainerStats{
@@ -101,32 +133,72 @@ func (dm *DockerMonitor) CheckContainers() ([]types.DockerContainerStats, error)
 			Created:      time.Unix(c.Created, 0),
 			Timestamp:    now,
 		}
+		applyContainerInventory(&containerStats, c)
+		containerStats.Tags = containerTags(dm.config, containerStats.Name, c.Labels)
+
+		listed = append(listed, c)
+		stats = append(stats, containerStats)
+	}
 
-		// Get detailed container info
-		containerInfo, err := dm.client.ContainerInspect(ctx, c.ID)
-		if err == nil {
-			if containerInfo.State != nil {
-				if containerInfo.State.Running {
-					containerStats.StartedAt = containerInfo.State.StartedAt 
-				} else {
-					containerStats.FinishedAt = containerInfo.State.FinishedAt
-					containerStats.ExitCode = containerInfo.State.ExitCode
-					if containerInfo.State.Error != "" {
-						containerStats.Error = containerInfo.State.Error
-					}
-				}
+	// Inspect and sample the containers in parallel, a limited number at once
+	concurrency := dm.config.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDockerConcurrency
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(listed)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				dm.collectContainer(ctx, listed[i], &stats[i], now)
 			}
-		} else {
-			slog.Warn("Warning: failed to inspect container", "id", c.ID[:12], "error", err)
+		}()
+	}
+	for i := range listed {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	sampled := make(map[string]bool)
+	for i, c := range listed {
+		if stats[i].Running {
+			sampled[c.ID] = true
 		}
+	}
+	dm.forgetInspections(containers)
 
-		stats = append(stats, containerStats)
+	// Forget CPU counters of containers that stopped or were removed
+	dm.cpuMu.Lock()
+	for id := range dm.prevCPU {
+		if !sampled[id] {
+			delete(dm.prevCPU, id)
+		}
 	}
+	dm.cpuMu.Unlock()
 
 	return stats, nil
 }
 
-// CheckContainerStatus checks if specific containers are in the desired state
+// collectContainer fills the inspected details and, for running containers, the resource usage
+// of a listed container
+func (dm *DockerMonitor) collectContainer(ctx context.Context, c container.Summary, containerStats *types.DockerContainerStats, now time.Time) {
+	if info, err := dm.inspectContainer(ctx, c, now); err == nil {
+		applyContainerInspect(containerStats, info)
+	} else {
+		slog.Warn("Warning: failed to inspect container", "id", c.ID[:12], "error", err)
+	}
+
+	if containerStats.Running {
+		if err := dm.collectResources(ctx, c.ID, containerStats); err != nil {
+			slog.Warn("Warning: failed to get container stats", "id", c.ID[:12], "error", err)
+		}
+	}
+}
+
+// CheckContainerStatus checks if containers are in their expected state
 func (dm *DockerMonitor) CheckContainerStatus() ([]types.Alert, error) {
 	if !dm.config.Enabled || dm.client == nil {
 		return nil, nil
@@ -137,42 +209,7 @@ func (dm *DockerMonitor) CheckContainerStatus() ([]types.Alert, error) {
 		return nil, err
 	}
 
-	var alerts []types.Alert
-	now := time.Now()
-
-	for _, container := range stats {
-		// Check for stopped containers that should be running
-		if !container.Running {
-			alerts = append(alerts, types.Alert{
-				Type:      "docker",
-				Message:   fmt.Sprintf("Container %s (%s) is stopped", container.Name, container.ContainerID),
-				Level:     "warning",
-				Timestamp: now,
-			})
-		}
-
-		// Check for containers with non-zero exit codes
-		if container.ExitCode != 0 && container.ExitCode != 137 { // 137 is SIGKILL, often intentional
-			alerts = append(alerts, types.Alert{
-				Type:      "docker",
-				Message:   fmt.Sprintf("Container %s (%s) exited with error code: %d", container.Name, container.ContainerID, container.ExitCode),
-				Level:     "critical",
-				Timestamp: now,
-			})
-		}
-
-		// Check for containers with errors
-		if container.Error != "" {
-			alerts = append(alerts, types.Alert{
-				Type:      "docker",
-				Message:   fmt.Sprintf("Container %s (%s) has error: %s", container.Name, container.ContainerID, container.Error),
-				Level:     "critical",
-				Timestamp: now,
-			})
-		}
-	}
-
-	return alerts, nil
+	return containerStatusAlerts(stats, dm.config, time.Now()), nil
 }
 
 // GetContainerSummary returns a summary of container status
@@ -184,6 +221,7 @@ func (dm *DockerMonitor) GetContainerSummary(stats []types.DockerContainerStats)
 	stopped := 0
 	restarted := 0
 	errored := 0
+	unhealthy := 0
 
 	for _, container := range stats {
 		if container.Running {
@@ -194,6 +232,12 @@ func (dm *DockerMonitor) GetContainerSummary(stats []types.DockerContainerStats)
 		if container.ExitCode != 0 || container.Error != "" {
 			errored++
 		}
+		if container.Health == "unhealthy" {
+			unhealthy++
+		}
+		if container.RestartCount > 0 {
+			restarted++
+		}
 	}
 
 	summary["total_containers"] = total
@@ -201,6 +245,7 @@ func (dm *DockerMonitor) GetContainerSummary(stats []types.DockerContainerStats)
 	summary["stopped_containers"] = stopped
 	summary["restarted_containers"] = restarted
 	summary["errored_containers"] = errored
+	summary["unhealthy_containers"] = unhealthy
 
 	if total > 0 {
 		summary["running_percentage"] = float64(running) / float64(total) * 100
@@ -211,6 +256,85 @@ func (dm *DockerMonitor) GetContainerSummary(stats []types.DockerContainerStats)
 	return summary
 }
 
+// collectResources reads a one-shot stats sample of a running container. CPU usage is computed
+// against the previous sample of the same container, so it is 0 on the first collection.
+func (dm *DockerMonitor) collectResources(ctx context.Context, containerID string, containerStats *types.DockerContainerStats) error {
+	reader, err := dm.client.ContainerStatsOneShot(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	defer reader.Body.Close()
+
+	var sample container.StatsResponse
+	if err := json.NewDecoder(reader.Body).Decode(&sample); err != nil {
+		return fmt.Errorf("failed to decode stats: %w", err)
+	}
+
+	dm.cpuMu.Lock()
+	prev, hasPrev := dm.prevCPU[containerID]
+	dm.prevCPU[containerID] = sample.CPUStats
+	dm.cpuMu.Unlock()
+
+	applyContainerResources(containerStats, sample, prev, hasPrev)
+	return nil
+}
+
+// applyContainerResources fills resource usage from a stats sample, the same way "docker stats" computes it
+func applyContainerResources(containerStats *types.DockerContainerStats, sample container.StatsResponse, prev container.CPUStats, hasPrev bool) {
+	if hasPrev {
+		cpuDelta := float64(sample.CPUStats.CPUUsage.TotalUsage) - float64(prev.CPUUsage.TotalUsage)
+		systemDelta := float64(sample.CPUStats.SystemUsage) - float64(prev.SystemUsage)
+		cpus := float64(sample.CPUStats.OnlineCPUs)
+		if cpus == 0 {
+			cpus = float64(len(sample.CPUStats.CPUUsage.PercpuUsage))
+		}
+		// Counter resets (container restarts) produce negative deltas and are skipped
+		if cpuDelta > 0 && systemDelta > 0 {
+			containerStats.CPUPercent = cpuDelta / systemDelta * cpus * 100
+		}
+	}
+
+	// Page cache that can be reclaimed is not counted, like "docker stats" (cgroup v1 and v2 keys)
+	usage := sample.MemoryStats.Usage
+	inactive, ok := sample.MemoryStats.Stats["total_inactive_file"]
+	if !ok {
+		inactive = sample.MemoryStats.Stats["inactive_file"]
+	}
+	if inactive < usage {
+		usage -= inactive
+	}
+	containerStats.MemoryUsage = usage
+	containerStats.MemoryLimit = sample.MemoryStats.Limit
+	if sample.MemoryStats.Limit > 0 {
+		containerStats.MemoryPercent = float64(usage) / float64(sample.MemoryStats.Limit) * 100
+	}
+
+	for _, network := range sample.Networks {
+		containerStats.NetworkRxBytes += network.RxBytes
+		containerStats.NetworkTxBytes += network.TxBytes
+	}
+}
+
+// maxHealthOutput is the maximum length of health probe output kept per container
+const maxHealthOutput = 200
+
+// applyContainerHealth fills the HEALTHCHECK status; containers without a health check keep an empty status
+func applyContainerHealth(containerStats *types.DockerContainerStats, health *container.Health) {
+	if health == nil || health.Status == container.NoHealthcheck {
+		return
+	}
+
+	containerStats.Health = health.Status
+	containerStats.HealthFailingStreak = health.FailingStreak
+	if len(health.Log) > 0 && health.Log[len(health.Log)-1] != nil {
+		output := strings.TrimSpace(health.Log[len(health.Log)-1].Output)
+		if len(output) > maxHealthOutput {
+			output = output[:maxHealthOutput] + "..."
+		}
+		containerStats.HealthOutput = output
+	}
+}
+
 // Close closes the Docker client connection
 func (dm *DockerMonitor) Close() error {
 	if dm.client != nil {
@@ -219,6 +343,69 @@ func (dm *DockerMonitor) Close() error {
 	return nil
 }
 
+// applyContainerInventory fills the image, compose labels, port mappings and networks of a listed container
+func applyContainerInventory(containerStats *types.DockerContainerStats, c container.Summary) {
+	containerStats.Image = c.Image
+	containerStats.ImageID = shortImageID(c.ImageID)
+	containerStats.ComposeProject = c.Labels[composeProjectLabel]
+	containerStats.ComposeService = c.Labels[composeServiceLabel]
+
+	// Docker lists IPv4 and IPv6 bindings of the same port separately
+	seen := make(map[string]bool)
+	containerStats.Ports = nil
+	for _, port := range c.Ports {
+		formatted := formatPort(port)
+		if !seen[formatted] {
+			seen[formatted] = true
+			containerStats.Ports = append(containerStats.Ports, formatted)
+		}
+	}
+	sort.Strings(containerStats.Ports)
+
+	containerStats.Networks = nil
+	if c.NetworkSettings != nil {
+		for name := range c.NetworkSettings.Networks {
+			containerStats.Networks = append(containerStats.Networks, name)
+		}
+		sort.Strings(containerStats.Networks)
+	}
+}
+
+// tagsLabel is the container label with comma-separated tags, e.g. monic.tags=payment-critical,db
+const tagsLabel = "monic.tags"
+
+// containerTags returns the tags of a container: those of its monic.tags label followed by those
+// of the tag rules naming it, without duplicates
+func containerTags(config *types.DockerConfig, name string, labels map[string]string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range append(strings.Split(labels[tagsLabel], ","), config.TagsFor(name)...) {
+		if tag = strings.TrimSpace(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// formatPort formats a port mapping like docker ps, e.g. 0.0.0.0:8080->80/tcp or 5432/tcp
+func formatPort(port container.Port) string {
+	exposed := fmt.Sprintf("%d/%s", port.PrivatePort, port.Type)
+	if port.PublicPort == 0 {
+		return exposed
+	}
+	return fmt.Sprintf("%s->%s", net.JoinHostPort(port.IP, strconv.Itoa(int(port.PublicPort))), exposed)
+}
+
+// shortImageID returns the first 12 characters of an image ID without the digest algorithm
+func shortImageID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
 // getContainerName extracts the container name from the names array
 func getContainerName(names []string) string {
 	if len(names) == 0 {
@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+
+	"bconf.com/monic/types"
+)
+
+// Labels docker compose sets on the containers it creates
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+)
+
+// validateCompose checks the expected replicas of compose services
+func (dm *DockerMonitor) validateCompose() error {
+	seen := make(map[string]bool)
+	for _, expected := range dm.config.Compose {
+		if expected.Project == "" || expected.Service == "" {
+			return fmt.Errorf("compose replicas require a project and a service")
+		}
+		if expected.Replicas < 1 {
+			return fmt.Errorf("compose service %s/%s: replicas must be at least 1", expected.Project, expected.Service)
+		}
+		key := expected.Project + "/" + expected.Service
+		if seen[key] {
+			return fmt.Errorf("compose service %s: replicas configured twice", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// ComposeServices groups containers by compose project and service, sorted by project and service.
+// Services with expected replicas are included even when none of their containers exist.
+func (dm *DockerMonitor) ComposeServices(stats []types.DockerContainerStats) []types.ComposeServiceStatus {
+	return groupComposeServices(stats, dm.config.Compose)
+}
+
+// groupComposeServices groups containers by compose project and service and adds the expected replicas
+func groupComposeServices(stats []types.DockerContainerStats, expectations []types.ComposeReplicas) []types.ComposeServiceStatus {
+	services := make(map[string]*types.ComposeServiceStatus)
+	service := func(project, name string) *types.ComposeServiceStatus {
+		key := project + "/" + name
+		if services[key] == nil {
+			services[key] = &types.ComposeServiceStatus{Project: project, Service: name}
+		}
+		return services[key]
+	}
+
+	for _, c := range stats {
+		if c.ComposeProject == "" || c.ComposeService == "" {
+			continue
+		}
+		status := service(c.ComposeProject, c.ComposeService)
+		status.Total++
+		if c.Running {
+			status.Running++
+		}
+		status.Containers = append(status.Containers, c.Name)
+	}
+	for _, expected := range expectations {
+		service(expected.Project, expected.Service).Expected = expected.Replicas
+	}
+
+	result := make([]types.ComposeServiceStatus, 0, len(services))
+	for _, status := range services {
+		sort.Strings(status.Containers)
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Project != result[j].Project {
+			return result[i].Project < result[j].Project
+		}
+		return result[i].Service < result[j].Service
+	})
+	return result
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+func TestGroupComposeServices(t *testing.T) {
+	stats := []types.DockerContainerStats{
+		{Name: "shop-web-2", Running: true, ComposeProject: "shop", ComposeService: "web"},
+		{Name: "shop-web-1", Running: true, ComposeProject: "shop", ComposeService: "web"},
+		{Name: "shop-web-3", Running: false, ComposeProject: "shop", ComposeService: "web"},
+		{Name: "shop-db-1", Running: true, ComposeProject: "shop", ComposeService: "db"},
+		{Name: "standalone", Running: true},
+	}
+	expectations := []types.ComposeReplicas{
+		{Project: "shop", Service: "web", Replicas: 3},
+		{Project: "shop", Service: "worker", Replicas: 2},
+	}
+
+	services := groupComposeServices(stats, expectations)
+
+	if len(services) != 3 {
+		t.Fatalf("Expected 3 compose services, got %+v", services)
+	}
+	if db := services[0]; db.Service != "db" || db.Running != 1 || db.Expected != 0 {
+		t.Errorf("Unexpected db service: %+v", db)
+	}
+	web := services[1]
+	if web.Service != "web" || web.Running != 2 || web.Total != 3 || web.Expected != 3 {
+		t.Errorf("Unexpected web service: %+v", web)
+	}
+	if len(web.Containers) != 3 || web.Containers[0] != "shop-web-1" {
+		t.Errorf("Expected sorted container names, got %v", web.Containers)
+	}
+	// Expected services are listed even without containers
+	if worker := services[2]; worker.Service != "worker" || worker.Total != 0 || worker.Expected != 2 {
+		t.Errorf("Unexpected worker service: %+v", worker)
+	}
+}
+
+func TestDockerMonitor_ValidateCompose(t *testing.T) {
+	tests := [][]types.ComposeReplicas{
+		{{Service: "web", Replicas: 1}},
+		{{Project: "shop", Service: "web"}},
+		{{Project: "shop", Service: "web", Replicas: 1}, {Project: "shop", Service: "web", Replicas: 2}},
+	}
+	for _, compose := range tests {
+		dm := NewDockerMonitor(&types.DockerConfig{Compose: compose})
+		if err := dm.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", compose)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bconf.com/monic/types"
+
+	dockertypes "github.com/docker/docker/api/types"
+)
+
+// defaultDiskUsageInterval is the default time between disk usage collections; docker system df
+// computes the size of every volume and can take a while on busy hosts
+const defaultDiskUsageInterval = 5 * time.Minute
+
+// diskUsageEnabled reports whether Docker's disk usage is collected
+func (dm *DockerMonitor) diskUsageEnabled() bool {
+	return dm.config.DiskUsage || dm.config.DiskUsageThreshold > 0
+}
+
+// CheckDiskUsage collects Docker's disk usage when the disk usage interval has elapsed since the
+// previous collection. It returns nil if disk usage is disabled or not due yet.
+func (dm *DockerMonitor) CheckDiskUsage() (*types.DockerDiskUsage, error) {
+	if !dm.diskUsageEnabled() || dm.client == nil {
+		return nil, nil
+	}
+
+	interval := time.Duration(dm.config.DiskUsageInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultDiskUsageInterval
+	}
+
+	dm.diskMu.Lock()
+	due := dm.diskUsage == nil || time.Since(dm.diskUsage.Timestamp) >= interval
+	dm.diskMu.Unlock()
+	if !due {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	du, err := dm.client.DiskUsage(ctx, dockertypes.DiskUsageOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Docker disk usage: %w", err)
+	}
+
+	usage := summarizeDiskUsage(du, time.Now())
+	dm.diskMu.Lock()
+	dm.diskUsage = &usage
+	dm.diskMu.Unlock()
+	return &usage, nil
+}
+
+// LatestDiskUsage returns the latest collected disk usage (nil before the first collection)
+func (dm *DockerMonitor) LatestDiskUsage() *types.DockerDiskUsage {
+	dm.diskMu.Lock()
+	defer dm.diskMu.Unlock()
+	if dm.diskUsage == nil {
+		return nil
+	}
+	usage := *dm.diskUsage
+	return &usage
+}
+
+// summarizeDiskUsage sums up a docker system df response like the docker CLI does
+func summarizeDiskUsage(du dockertypes.DiskUsage, now time.Time) types.DockerDiskUsage {
+	usage := types.DockerDiskUsage{
+		Images:         du.LayersSize,
+		ImageCount:     len(du.Images),
+		ContainerCount: len(du.Containers),
+		VolumeCount:    len(du.Volumes),
+		Timestamp:      now,
+	}
+
+	// Layers shared with other images stay in use while any of them is
+	for _, image := range du.Images {
+		if image != nil && image.Containers == 0 {
+			usage.Reclaimable += image.Size - max(image.SharedSize, 0)
+		}
+	}
+	for _, c := range du.Containers {
+		if c == nil {
+			continue
+		}
+		usage.Containers += c.SizeRw
+		if c.State != "running" {
+			usage.Reclaimable += c.SizeRw
+		}
+	}
+	// Volume sizes are -1 when the driver cannot report them
+	for _, volume := range du.Volumes {
+		if volume == nil || volume.UsageData == nil || volume.UsageData.Size < 0 {
+			continue
+		}
+		usage.Volumes += volume.UsageData.Size
+		if volume.UsageData.RefCount == 0 {
+			usage.Reclaimable += volume.UsageData.Size
+		}
+	}
+	for _, record := range du.BuildCache {
+		if record == nil || record.Shared {
+			continue
+		}
+		usage.BuildCache += record.Size
+		if !record.InUse {
+			usage.Reclaimable += record.Size
+		}
+	}
+	return usage
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func TestSummarizeDiskUsage(t *testing.T) {
+	du := dockertypes.DiskUsage{
+		LayersSize: 1000,
+		Images: []*image.Summary{
+			{Size: 600, SharedSize: 100, Containers: 1},
+			{Size: 400, SharedSize: 100, Containers: 0}, // Unused: 300 reclaimable
+			{Size: 50, SharedSize: -1, Containers: 0},   // Unused, shared size unknown
+		},
+		Containers: []*container.Summary{
+			{SizeRw: 20, State: "running"},
+			{SizeRw: 30, State: "exited"},
+		},
+		Volumes: []*volume.Volume{
+			{UsageData: &volume.UsageData{Size: 500, RefCount: 1}},
+			{UsageData: &volume.UsageData{Size: 200, RefCount: 0}},
+			{UsageData: &volume.UsageData{Size: -1, RefCount: 0}},
+		},
+		BuildCache: []*build.CacheRecord{
+			{Size: 70, InUse: true},
+			{Size: 80},
+			{Size: 90, Shared: true},
+		},
+	}
+
+	usage := summarizeDiskUsage(du, time.Now())
+
+	if usage.Images != 1000 || usage.Containers != 50 || usage.Volumes != 700 || usage.BuildCache != 150 {
+		t.Errorf("Unexpected sizes: %+v", usage)
+	}
+	if usage.Total() != 1900 {
+		t.Errorf("Expected a total of 1900 bytes, got %d", usage.Total())
+	}
+	// 300 + 50 (images) + 30 (stopped container) + 200 (volume) + 80 (build cache)
+	if usage.Reclaimable != 660 {
+		t.Errorf("Expected 660 reclaimable bytes, got %d", usage.Reclaimable)
+	}
+	if usage.ImageCount != 3 || usage.ContainerCount != 2 || usage.VolumeCount != 3 {
+		t.Errorf("Unexpected counts: %+v", usage)
+	}
+}
@@ -0,0 +1,142 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"bconf.com/monic/types"
+)
+
+// labelSelector matches a container label: "key" (label is set), "key=value" or "key!=value"
+type labelSelector struct {
+	key    string
+	value  string
+	negate bool // key!=value
+	exists bool // Only the presence of the key is checked
+}
+
+// containerFilter selects the monitored containers by name and label
+type containerFilter struct {
+	names         []string // Exact names
+	includeNames  []*regexp.Regexp
+	includeLabels []labelSelector
+	excludeNames  []*regexp.Regexp
+	excludeLabels []labelSelector
+}
+
+// newContainerFilter compiles the container selection of the Docker config
+func newContainerFilter(config *types.DockerConfig) (*containerFilter, error) {
+	filter := &containerFilter{names: config.Containers}
+
+	var err error
+	if filter.includeNames, err = compileNamePatterns(config.IncludeNames); err != nil {
+		return nil, err
+	}
+	if filter.excludeNames, err = compileNamePatterns(config.ExcludeNames); err != nil {
+		return nil, err
+	}
+	if filter.includeLabels, err = parseLabelSelectors(config.IncludeLabels); err != nil {
+		return nil, err
+	}
+	if filter.excludeLabels, err = parseLabelSelectors(config.ExcludeLabels); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// compileNamePatterns compiles container name regular expressions
+func compileNamePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var exprs []*regexp.Regexp
+	for _, pattern := range patterns {
+		expr, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid container name pattern %q: %w", pattern, err)
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+// parseLabelSelectors parses label selectors ("key", "key=value" or "key!=value")
+func parseLabelSelectors(selectors []string) ([]labelSelector, error) {
+	var parsed []labelSelector
+	for _, selector := range selectors {
+		var s labelSelector
+		if key, value, found := strings.Cut(selector, "!="); found {
+			s = labelSelector{key: key, value: value, negate: true}
+		} else if key, value, found := strings.Cut(selector, "="); found {
+			s = labelSelector{key: key, value: value}
+		} else {
+			s = labelSelector{key: selector, exists: true}
+		}
+		s.key = strings.TrimSpace(s.key)
+		s.value = strings.TrimSpace(s.value)
+		if s.key == "" {
+			return nil, fmt.Errorf("invalid container label selector %q: missing label name", selector)
+		}
+		parsed = append(parsed, s)
+	}
+	return parsed, nil
+}
+
+// matches reports whether the selector matches the labels of a container
+func (s labelSelector) matches(labels map[string]string) bool {
+	value, ok := labels[s.key]
+	switch {
+	case s.exists:
+		return ok
+	case s.negate:
+		return value != s.value
+	default:
+		return ok && value == s.value
+	}
+}
+
+// matches reports whether a container is monitored. Without include rules (names, name patterns
+// or label selectors) all containers are included; otherwise at least one of them has to match.
+// A container matching any exclude rule is skipped.
+func (f *containerFilter) matches(name string, labels map[string]string) bool {
+	name = strings.TrimPrefix(name, "/")
+
+	for _, expr := range f.excludeNames {
+		if expr.MatchString(name) {
+			return false
+		}
+	}
+	for _, selector := range f.excludeLabels {
+		if selector.matches(labels) {
+			return false
+		}
+	}
+
+	if len(f.names) == 0 && len(f.includeNames) == 0 && len(f.includeLabels) == 0 {
+		return true
+	}
+	for _, target := range f.names {
+		if name == strings.TrimPrefix(target, "/") {
+			return true
+		}
+	}
+	for _, expr := range f.includeNames {
+		if expr.MatchString(name) {
+			return true
+		}
+	}
+	for _, selector := range f.includeLabels {
+		if selector.matches(labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether any of the names of a container is monitored
+func (f *containerFilter) matchesAny(names []string, labels map[string]string) bool {
+	for _, name := range names {
+		if f.matches(name, labels) {
+			return true
+		}
+	}
+	return false
+}
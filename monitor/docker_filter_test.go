@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+func TestContainerFilter(t *testing.T) {
+	compose := map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "web"}
+	monitoring := map[string]string{"com.docker.compose.project": "shop", "monic.ignore": "true"}
+
+	tests := []struct {
+		name   string
+		config types.DockerConfig
+		cname  string
+		labels map[string]string
+		want   bool
+	}{
+		{"no rules", types.DockerConfig{}, "/anything", nil, true},
+		{"exact name", types.DockerConfig{Containers: []string{"db"}}, "/db", nil, true},
+		{"exact name mismatch", types.DockerConfig{Containers: []string{"db"}}, "/db-replica", nil, false},
+		{"name pattern", types.DockerConfig{IncludeNames: []string{`^shop[-_]web[-_]\d+$`}}, "/shop-web-3", nil, true},
+		{"label value", types.DockerConfig{IncludeLabels: []string{"com.docker.compose.project=shop"}}, "/shop-web-1", compose, true},
+		{"label value mismatch", types.DockerConfig{IncludeLabels: []string{"com.docker.compose.project=blog"}}, "/shop-web-1", compose, false},
+		{"label exists", types.DockerConfig{IncludeLabels: []string{"com.docker.compose.service"}}, "/shop-web-1", compose, true},
+		{"label not equal", types.DockerConfig{IncludeLabels: []string{"com.docker.compose.service!=web"}}, "/shop-web-1", compose, false},
+		{"any include rule", types.DockerConfig{Containers: []string{"db"}, IncludeLabels: []string{"com.docker.compose.project=shop"}}, "/shop-web-1", compose, true},
+		{"exclude label", types.DockerConfig{IncludeLabels: []string{"com.docker.compose.project=shop"}, ExcludeLabels: []string{"monic.ignore=true"}}, "/shop-cron-1", monitoring, false},
+		{"exclude name only", types.DockerConfig{ExcludeNames: []string{"^buildkit"}}, "/buildkit_builder0", nil, false},
+		{"exclude name keeps others", types.DockerConfig{ExcludeNames: []string{"^buildkit"}}, "/web", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newContainerFilter(&tt.config)
+			if err != nil {
+				t.Fatalf("Failed to create filter: %v", err)
+			}
+			if got := filter.matches(tt.cname, tt.labels); got != tt.want {
+				t.Errorf("Expected %v for %s, got %v", tt.want, tt.cname, got)
+			}
+		})
+	}
+}
+
+func TestNewContainerFilter_Invalid(t *testing.T) {
+	if _, err := newContainerFilter(&types.DockerConfig{IncludeNames: []string{"("}}); err == nil {
+		t.Error("Expected error for an invalid name pattern")
+	}
+	if _, err := newContainerFilter(&types.DockerConfig{ExcludeLabels: []string{"=value"}}); err == nil {
+		t.Error("Expected error for a label selector without name")
+	}
+}
@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// defaultDockerConcurrency is the number of containers inspected and sampled at once by default
+const defaultDockerConcurrency = 8
+
+// inspectedContainer is the inspection of a container, reused while its listing shows no change
+type inspectedContainer struct {
+	key  string // listingKey of the listing it was inspected at
+	info container.InspectResponse
+}
+
+// inspectContainer returns the inspection of a listed container. Inspections are cached and only
+// repeated when the listing shows a change since: another state, health or exit code, a start or
+// exit after the cached one, or a health check that is not healthy, whose streak and output change.
+func (dm *DockerMonitor) inspectContainer(ctx context.Context, c container.Summary, now time.Time) (container.InspectResponse, error) {
+	key := listingKey(c)
+
+	dm.inspectMu.Lock()
+	cached, ok := dm.inspected[c.ID]
+	dm.inspectMu.Unlock()
+	if ok && cached.key == key && !changedSince(c.Status, cached.info, now) {
+		return cached.info, nil
+	}
+
+	info, err := dm.client.ContainerInspect(ctx, c.ID)
+	if err != nil {
+		return info, err
+	}
+	dm.inspectMu.Lock()
+	dm.inspected[c.ID] = inspectedContainer{key: key, info: info}
+	dm.inspectMu.Unlock()
+	return info, nil
+}
+
+// forgetInspections drops the cached inspections of containers that are no longer listed
+func (dm *DockerMonitor) forgetInspections(listed []container.Summary) {
+	ids := make(map[string]bool, len(listed))
+	for _, c := range listed {
+		ids[c.ID] = true
+	}
+
+	dm.inspectMu.Lock()
+	defer dm.inspectMu.Unlock()
+	for id := range dm.inspected {
+		if !ids[id] {
+			delete(dm.inspected, id)
+		}
+	}
+}
+
+// statusDetails matches the parenthesized parts of a container status, e.g. the exit code of
+// "Exited (1) 2 hours ago" or the health of "Up 5 minutes (healthy)"
+var statusDetails = regexp.MustCompile(`\(([^)]*)\)`)
+
+// listingKey returns the parts of a listed container that identify its state: the state itself
+// and the exit code and health of its status, but not the time that passes
+func listingKey(c container.Summary) string {
+	key := c.State
+	for _, detail := range statusDetails.FindAllStringSubmatch(c.Status, -1) {
+		key += "|" + detail[1]
+	}
+	return key
+}
+
+// changedSince reports whether the status shows a change the listing key can't: a container that
+// was restarted, or exited again, since its inspection, and health checks that are not healthy
+func changedSince(status string, info container.InspectResponse, now time.Time) bool {
+	if info.State == nil {
+		return true
+	}
+	if health := info.State.Health; health != nil && health.Status != container.NoHealthcheck && health.Status != container.Healthy {
+		return true
+	}
+
+	// Running containers show the time since their start, others the time since they exited
+	since := info.State.FinishedAt
+	if info.State.Running {
+		since = info.State.StartedAt
+	}
+	at, err := time.Parse(time.RFC3339Nano, since)
+	if err != nil {
+		return false
+	}
+	elapsed, ok := statusDuration(status)
+	return ok && now.Sub(at) > elapsed
+}
+
+// statusDurationPattern matches the human-readable durations of container statuses
+var statusDurationPattern = regexp.MustCompile(`(Less than a second|About a minute|About an hour|(\d+) (second|minute|hour|day|week|month|year)s?)`)
+
+// statusUnits are the units of status durations
+var statusUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// statusDuration returns the upper bound of the rounded-down duration of a container status, e.g.
+// 6 minutes for "Up 5 minutes" or 2 hours for "Exited (0) About an hour ago"
+func statusDuration(status string) (time.Duration, bool) {
+	match := statusDurationPattern.FindStringSubmatch(status)
+	if match == nil {
+		return 0, false
+	}
+	switch match[1] {
+	case "Less than a second":
+		return time.Second, true
+	case "About a minute":
+		return 2 * time.Minute, true
+	case "About an hour":
+		return 2 * time.Hour, true
+	}
+	n, err := strconv.Atoi(match[2])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n+1) * statusUnits[match[3]], true
+}
+
+// applyContainerInspect fills the restart count, health and start or exit details of a container
+func applyContainerInspect(containerStats *types.DockerContainerStats, info container.InspectResponse) {
+	containerStats.RestartCount = info.RestartCount
+	if info.State == nil {
+		return
+	}
+	applyContainerHealth(containerStats, info.State.Health)
+	if info.State.Running {
+		containerStats.StartedAt = info.State.StartedAt
+		return
+	}
+	containerStats.FinishedAt = info.State.FinishedAt
+	containerStats.ExitCode = info.State.ExitCode
+	if info.State.Error != "" {
+		containerStats.Error = info.State.Error
+	}
+}
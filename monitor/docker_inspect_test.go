@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestListingKey(t *testing.T) {
+	running := container.Summary{State: "running", Status: "Up 5 minutes (healthy)"}
+	later := container.Summary{State: "running", Status: "Up 2 hours (healthy)"}
+	if listingKey(running) != listingKey(later) {
+		t.Errorf("Expected the passing time to keep the key, got %q and %q", listingKey(running), listingKey(later))
+	}
+
+	changed := []container.Summary{
+		{State: "running", Status: "Up 2 hours (unhealthy)"},
+		{State: "exited", Status: "Exited (0) 3 seconds ago"},
+	}
+	for _, c := range changed {
+		if listingKey(c) == listingKey(running) {
+			t.Errorf("Expected a different key for %q", c.Status)
+		}
+	}
+	if listingKey(container.Summary{State: "exited", Status: "Exited (0) 1 hour ago"}) == listingKey(container.Summary{State: "exited", Status: "Exited (137) 1 hour ago"}) {
+		t.Error("Expected the exit code in the key")
+	}
+}
+
+func TestStatusDuration(t *testing.T) {
+	tests := []struct {
+		status string
+		want   time.Duration
+		ok     bool
+	}{
+		{"Up Less than a second", time.Second, true},
+		{"Up 5 seconds (health: starting)", 6 * time.Second, true},
+		{"Up About a minute", 2 * time.Minute, true},
+		{"Up 5 minutes (healthy)", 6 * time.Minute, true},
+		{"Exited (1) About an hour ago", 2 * time.Hour, true},
+		{"Up 3 days", 4 * 24 * time.Hour, true},
+		{"Restarting (1) 2 weeks ago", 3 * 7 * 24 * time.Hour, true},
+		{"Created", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := statusDuration(tt.status)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("statusDuration(%q) = %v, %v, want %v, %v", tt.status, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-2*time.Hour - 30*time.Minute).Format(time.RFC3339Nano)
+	info := container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{
+		State: &container.State{Running: true, StartedAt: started},
+	}}
+
+	if changedSince("Up 2 hours", info, now) {
+		t.Error("Expected no change while the uptime matches the start")
+	}
+	if !changedSince("Up 10 seconds", info, now) {
+		t.Error("Expected a restart when the uptime is shorter than the time since the start")
+	}
+
+	info.State.Health = &container.Health{Status: container.Unhealthy}
+	if !changedSince("Up 2 hours (unhealthy)", info, now) {
+		t.Error("Expected unhealthy containers to be inspected again")
+	}
+	info.State.Health.Status = container.Healthy
+	if changedSince("Up 2 hours (healthy)", info, now) {
+		t.Error("Expected no change of a healthy container")
+	}
+
+	stopped := container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{
+		State: &container.State{FinishedAt: now.Add(-3 * 24 * time.Hour).Format(time.RFC3339Nano)},
+	}}
+	if changedSince("Exited (0) 3 days ago", stopped, now) {
+		t.Error("Expected no change of a container that stayed exited")
+	}
+	if !changedSince("Exited (0) 5 minutes ago", stopped, now) {
+		t.Error("Expected a change of a container that ran and exited again")
+	}
+}
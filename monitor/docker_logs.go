@@ -0,0 +1,248 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// defaultLogRateLimit is the default number of log lines scanned per container and minute
+const defaultLogRateLimit = 600
+
+// maxLogAlertLine is the maximum length of a log line quoted in an alert
+const maxLogAlertLine = 200
+
+// defaultLogPatterns match common crash messages when no patterns are configured
+var defaultLogPatterns = []string{
+	`(?i)\bpanic\b`,
+	`(?i)\bout of memory\b|\boom\b`,
+	`(?i)\bfatal\b`,
+}
+
+// containerLogPattern is a compiled log pattern; an empty container applies to all containers
+type containerLogPattern struct {
+	container string
+	expr      *regexp.Regexp
+}
+
+// containerLogState tracks how far the logs of a container have been scanned
+type containerLogState struct {
+	last time.Time // Timestamp of the latest scanned line (or of the first scan)
+	tty  bool      // TTY containers write raw logs instead of multiplexed streams
+}
+
+// validateLogPatterns compiles the configured log patterns
+func (dm *DockerMonitor) validateLogPatterns() error {
+	if !dm.config.LogScan {
+		return nil
+	}
+
+	global := dm.config.LogPatterns
+	if len(global) == 0 {
+		global = defaultLogPatterns
+	}
+
+	var patterns []containerLogPattern
+	for _, pattern := range global {
+		expr, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid log pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, containerLogPattern{expr: expr})
+	}
+	for _, rule := range dm.config.LogRules {
+		if rule.Container == "" {
+			return fmt.Errorf("log rule container cannot be empty")
+		}
+		for _, pattern := range rule.Patterns {
+			expr, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid log pattern %q for container %s: %w", pattern, rule.Container, err)
+			}
+			patterns = append(patterns, containerLogPattern{container: rule.Container, expr: expr})
+		}
+	}
+	if dm.config.LogRateLimit < 0 {
+		return fmt.Errorf("log rate limit cannot be negative")
+	}
+
+	dm.logMu.Lock()
+	dm.logPatterns = patterns
+	dm.logMu.Unlock()
+	return nil
+}
+
+// patternsFor returns the log patterns that apply to a container
+func (dm *DockerMonitor) patternsFor(name string) []*regexp.Regexp {
+	var exprs []*regexp.Regexp
+	for _, pattern := range dm.logPatterns {
+		if pattern.container == "" || pattern.container == name {
+			exprs = append(exprs, pattern.expr)
+		}
+	}
+	return exprs
+}
+
+// ScanLogs reads the log lines running containers wrote since the previous scan and returns an
+// alert per container and matching pattern. Logs written before a container's first scan are skipped.
+func (dm *DockerMonitor) ScanLogs(stats []types.DockerContainerStats) []types.Alert {
+	if !dm.config.LogScan || dm.client == nil {
+		return nil
+	}
+
+	dm.logMu.Lock()
+	defer dm.logMu.Unlock()
+
+	ctx := context.Background()
+	now := time.Now()
+	scanned := make(map[string]bool)
+	var alerts []types.Alert
+
+	for _, c := range stats {
+		if !c.Running {
+			continue
+		}
+		patterns := dm.patternsFor(c.Name)
+		if len(patterns) == 0 {
+			continue
+		}
+		scanned[c.ContainerID] = true
+
+		state, exists := dm.logState[c.ContainerID]
+		if !exists {
+			info, err := dm.client.ContainerInspect(ctx, c.ContainerID)
+			if err != nil {
+				slog.Warn("Warning: failed to inspect container", "id", c.ContainerID, "error", err)
+				continue
+			}
+			state = &containerLogState{last: now, tty: info.Config != nil && info.Config.Tty}
+			dm.logState[c.ContainerID] = state
+			continue
+		}
+
+		lines, err := dm.readLogs(ctx, c.ContainerID, state, now)
+		if err != nil {
+			slog.Warn("Warning: failed to read container logs", "id", c.ContainerID, "error", err)
+			continue
+		}
+		logAlerts := matchLogLines(c.Name, lines, patterns, now)
+		for i := range logAlerts {
+			logAlerts[i].Tags = c.Tags
+		}
+		alerts = append(alerts, logAlerts...)
+	}
+
+	// Forget containers that stopped or were removed
+	for id := range dm.logState {
+		if !scanned[id] {
+			delete(dm.logState, id)
+		}
+	}
+
+	return alerts
+}
+
+// readLogs returns the log lines written since the previous scan, at most the rate limit
+// for the elapsed time (the newest lines are kept)
+func (dm *DockerMonitor) readLogs(ctx context.Context, containerID string, state *containerLogState, now time.Time) ([]string, error) {
+	rate := dm.config.LogRateLimit
+	if rate == 0 {
+		rate = defaultLogRateLimit
+	}
+	limit := int(math.Ceil(float64(rate) * now.Sub(state.last).Minutes()))
+	limit = max(limit, 1)
+
+	reader, err := dm.client.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      fmt.Sprintf("%d.%09d", state.last.Unix(), state.last.Nanosecond()),
+		Timestamps: true,
+		Tail:       strconv.Itoa(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var buf bytes.Buffer
+	if state.tty {
+		_, err = io.Copy(&buf, reader)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
+
+	lines, last := parseLogLines(buf.Bytes(), state.last)
+	state.last = last
+	if len(lines) >= limit {
+		slog.Warn("Container log rate limit reached, older lines were not scanned", "id", containerID, "limit", limit)
+	}
+	return lines, nil
+}
+
+// parseLogLines splits timestamped log output ("<RFC3339Nano> <message>") into messages written
+// after the given time and returns them with the timestamp of the latest line
+func parseLogLines(data []byte, after time.Time) ([]string, time.Time) {
+	var lines []string
+	last := after
+	for _, line := range strings.Split(string(data), "\n") {
+		timestamp, message, found := strings.Cut(strings.TrimRight(line, "\r"), " ")
+		if !found {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil || !at.After(after) {
+			continue
+		}
+		lines = append(lines, message)
+		if at.After(last) {
+			last = at
+		}
+	}
+	return lines, last
+}
+
+// matchLogLines returns an alert for every pattern matched by at least one log line
+func matchLogLines(name string, lines []string, patterns []*regexp.Regexp, now time.Time) []types.Alert {
+	var alerts []types.Alert
+	for _, pattern := range patterns {
+		count := 0
+		first := ""
+		for _, line := range lines {
+			if pattern.MatchString(line) {
+				if count == 0 {
+					first = line
+				}
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		if len(first) > maxLogAlertLine {
+			first = first[:maxLogAlertLine] + "..."
+		}
+		alerts = append(alerts, types.Alert{
+			Type:      "container_log_" + name,
+			Message:   fmt.Sprintf("Container %s logged %d line(s) matching %q: %s", name, count, pattern.String(), first),
+			Level:     "critical",
+			Timestamp: now,
+		})
+	}
+	return alerts
+}
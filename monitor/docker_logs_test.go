@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestParseLogLines(t *testing.T) {
+	after := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	data := []byte("2026-10-16T11:59:59.000000000Z old line\n" +
+		"2026-10-16T12:00:00.000000000Z already scanned\n" +
+		"2026-10-16T12:00:01.500000000Z server started\r\n" +
+		"2026-10-16T12:00:02.000000000Z panic: runtime error\n" +
+		"not a log line\n")
+
+	lines, last := parseLogLines(data, after)
+	if len(lines) != 2 || lines[0] != "server started" || lines[1] != "panic: runtime error" {
+		t.Errorf("Expected the 2 new lines, got %q", lines)
+	}
+	if want := time.Date(2026, 10, 16, 12, 0, 2, 0, time.UTC); !last.Equal(want) {
+		t.Errorf("Expected last timestamp %v, got %v", want, last)
+	}
+
+	if _, last := parseLogLines(nil, after); !last.Equal(after) {
+		t.Errorf("Expected the previous timestamp without new lines, got %v", last)
+	}
+}
+
+func TestMatchLogLines(t *testing.T) {
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bpanic\b`),
+		regexp.MustCompile(`(?i)\bfatal\b`),
+		regexp.MustCompile(`(?i)\bout of memory\b|\boom\b`),
+	}
+	lines := []string{
+		"GET /health 200",
+		"panic: runtime error: index out of range",
+		"goroutine 1 [running]:",
+		"PANIC again",
+		"Out of memory: Killed process 42",
+	}
+
+	alerts := matchLogLines("api", lines, patterns, time.Now())
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %+v", alerts)
+	}
+	if alerts[0].Type != "container_log_api" || !strings.Contains(alerts[0].Message, "logged 2 line(s)") || !strings.HasSuffix(alerts[0].Message, ": panic: runtime error: index out of range") {
+		t.Errorf("Unexpected panic alert: %+v", alerts[0])
+	}
+	if !strings.Contains(alerts[1].Message, "Out of memory: Killed process 42") {
+		t.Errorf("Unexpected OOM alert: %+v", alerts[1])
+	}
+}
+
+func TestDockerMonitor_ValidateLogPatterns(t *testing.T) {
+	config := &types.DockerConfig{
+		LogScan:  true,
+		LogRules: []types.ContainerLogRule{{Container: "db", Patterns: []string{"deadlock detected"}}},
+	}
+	dm := NewDockerMonitor(config)
+	if err := dm.Validate(); err != nil {
+		t.Fatalf("Expected valid patterns, got %v", err)
+	}
+	if got := len(dm.patternsFor("db")); got != len(defaultLogPatterns)+1 {
+		t.Errorf("Expected default and container patterns for db, got %d", got)
+	}
+	if got := len(dm.patternsFor("web")); got != len(defaultLogPatterns) {
+		t.Errorf("Expected only default patterns for web, got %d", got)
+	}
+
+	config.LogPatterns = []string{"error("}
+	if err := dm.Validate(); err == nil {
+		t.Error("Expected error for an invalid pattern")
+	}
+}
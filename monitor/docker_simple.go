@@ -61,6 +61,11 @@ func (dm *SimpleDockerMonitor) CheckContainers() ([]types.DockerContainerStats,
 		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
 
+	filter, err := newContainerFilter(dm.config)
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse JSON output
 	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
 	var stats []types.DockerContainerStats
@@ -86,25 +91,24 @@ func (dm *SimpleDockerMonitor) CheckContainers() ([]types.DockerContainerStats,
 			Created:      now, // Not available in basic docker ps
 			Timestamp:    now,
 		}
+		containerStats.Image = getString(containerData["Image"])
+		containerStats.Ports = splitList(getString(containerData["Ports"]), ", ")
+		containerStats.Networks = splitList(getString(containerData["Networks"]), ",")
 
-		// Filter containers if specific ones are configured
-		if len(dm.config.Containers) > 0 {
-			found := false
-			for _, targetContainer := range dm.config.Containers {
-				if containerStats.Name == targetContainer || containerStats.Name == "/"+targetContainer {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
+		// Filter containers by the configured names and labels
+		labels := parseLabels(getString(containerData["Labels"]))
+		if !filter.matches(containerStats.Name, labels) {
+			continue
 		}
+		containerStats.ComposeProject = labels[composeProjectLabel]
+		containerStats.ComposeService = labels[composeServiceLabel]
+		containerStats.Tags = containerTags(dm.config, containerStats.Name, labels)
 
 		// Get detailed container info for exit code and error
 		if containerInfo, err := dm.getContainerInfo(containerStats.ContainerID); err == nil {
 			containerStats.ExitCode = containerInfo.ExitCode
 			containerStats.Error = containerInfo.Error
+			containerStats.RestartCount = containerInfo.RestartCount
 		}
 
 		stats = append(stats, containerStats)
@@ -113,7 +117,7 @@ func (dm *SimpleDockerMonitor) CheckContainers() ([]types.DockerContainerStats,
 	return stats, nil
 }
 
-// CheckContainerStatus checks if specific containers are in the desired state
+// CheckContainerStatus checks if containers are in their expected state
 func (dm *SimpleDockerMonitor) CheckContainerStatus() ([]types.Alert, error) {
 	if !dm.config.Enabled {
 		return nil, nil
@@ -124,42 +128,7 @@ func (dm *SimpleDockerMonitor) CheckContainerStatus() ([]types.Alert, error) {
 		return nil, err
 	}
 
-	var alerts []types.Alert
-	now := time.Now()
-
-	for _, container := range stats {
-		// Check for stopped containers that should be running
-		if !container.Running {
-			alerts = append(alerts, types.Alert{
-				Type:      "docker",
-				Message:   fmt.Sprintf("Container %s (%s) is stopped", container.Name, container.ContainerID),
-				Level:     "warning",
-				Timestamp: now,
-			})
-		}
-
-		// Check for containers with non-zero exit codes
-		if container.ExitCode != 0 && container.ExitCode != 137 { // 137 is SIGKILL, often intentional
-			alerts = append(alerts, types.Alert{
-				Type:      "docker",
-				Message:   fmt.Sprintf("Container %s (%s) exited with error code: %d", container.Name, container.ContainerID, container.ExitCode),
-				Level:     "critical",
-				Timestamp: now,
-			})
-		}
-
-		// Check for containers with errors
-		if container.Error != "" {
-			alerts = append(alerts, types.Alert{
-				Type:      "docker",
-				Message:   fmt.Sprintf("Container %s (%s) has error: %s", container.Name, container.ContainerID, container.Error),
-				Level:     "critical",
-				Timestamp: now,
-			})
-		}
-	}
-
-	return alerts, nil
+	return containerStatusAlerts(stats, dm.config, time.Now()), nil
 }
 
 // GetContainerSummary returns a summary of container status
@@ -225,6 +194,9 @@ func (dm *SimpleDockerMonitor) getContainerInfo(containerID string) (*types.Dock
 	if errorMsg, ok := state["Error"].(string); ok && errorMsg != "" {
 		stats.Error = errorMsg
 	}
+	if restarts, ok := info["RestartCount"].(float64); ok {
+		stats.RestartCount = int(restarts)
+	}
 
 	return stats, nil
 }
@@ -236,3 +208,27 @@ func getString(value interface{}) string {
 	}
 	return value.(string)
 }
+
+// parseLabels parses the comma-separated "key=value" labels of docker ps output
+func parseLabels(value string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if pair == "" {
+			continue
+		}
+		key, val, _ := strings.Cut(pair, "=")
+		labels[key] = val
+	}
+	return labels
+}
+
+// splitList splits a docker ps list column, dropping empty entries
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// validContainerStates are the states a container can be expected to be in
+var validContainerStates = map[string]bool{"running": true, "stopped": true, "any": true}
+
+// validateExpectations checks the expected container states
+func (dm *DockerMonitor) validateExpectations() error {
+	if dm.config.ExpectedState != "" && !validContainerStates[dm.config.ExpectedState] {
+		return fmt.Errorf("invalid expected container state %q (supported: running, stopped, any)", dm.config.ExpectedState)
+	}
+	for _, expectation := range dm.config.Expectations {
+		if expectation.Container == "" {
+			return fmt.Errorf("container expectation requires a container name")
+		}
+		if !validContainerStates[expectation.State] {
+			return fmt.Errorf("invalid expected state %q for container %s (supported: running, stopped, any)", expectation.State, expectation.Container)
+		}
+	}
+	return nil
+}
+
+// containerStatusAlerts returns alerts for containers that deviate from their expected state, and
+// for containers that exited with an error code or failed to start
+func containerStatusAlerts(stats []types.DockerContainerStats, config *types.DockerConfig, now time.Time) []types.Alert {
+	var alerts []types.Alert
+
+	for _, container := range stats {
+		switch config.ExpectedStateFor(container.Name) {
+		case "running":
+			if !container.Running {
+				alerts = append(alerts, types.Alert{
+					Type:      "docker",
+					Message:   fmt.Sprintf("Container %s (%s) is stopped", container.Name, container.ContainerID),
+					Level:     "warning",
+					Timestamp: now,
+					Tags:      container.Tags,
+				})
+			}
+		case "stopped":
+			if container.Running {
+				alerts = append(alerts, types.Alert{
+					Type:      "docker",
+					Message:   fmt.Sprintf("Container %s (%s) is running but expected to be stopped", container.Name, container.ContainerID),
+					Level:     "warning",
+					Timestamp: now,
+					Tags:      container.Tags,
+				})
+			}
+		}
+
+		// Check for containers with non-zero exit codes
+		if container.ExitCode != 0 && container.ExitCode != 137 { // 137 is SIGKILL, often intentional
+			alerts = append(alerts, types.Alert{
+				Type:      "docker",
+				Message:   fmt.Sprintf("Container %s (%s) exited with error code: %d", container.Name, container.ContainerID, container.ExitCode),
+				Level:     "critical",
+				Timestamp: now,
+				Tags:      container.Tags,
+			})
+		}
+
+		// Check for containers with errors
+		if container.Error != "" {
+			alerts = append(alerts, types.Alert{
+				Type:      "docker",
+				Message:   fmt.Sprintf("Container %s (%s) has error: %s", container.Name, container.ContainerID, container.Error),
+				Level:     "critical",
+				Timestamp: now,
+				Tags:      container.Tags,
+			})
+		}
+	}
+
+	return alerts
+}
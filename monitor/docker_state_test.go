@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestContainerStatusAlerts(t *testing.T) {
+	config := &types.DockerConfig{
+		ExpectedState: "running",
+		Expectations: []types.ContainerExpectation{
+			{Container: "migrate", State: "stopped"},
+			{Container: "debug", State: "any"},
+			{Container: "backup", State: "stopped"},
+		},
+	}
+	stats := []types.DockerContainerStats{
+		{Name: "web", Running: true},
+		{Name: "db", Running: false, ExitCode: 137},
+		{Name: "migrate", Running: false}, // Intentionally stopped
+		{Name: "debug", Running: false},   // Any state
+		{Name: "backup", Running: true},   // Should have finished
+		{Name: "worker", Running: false, ExitCode: 2},
+	}
+
+	alerts := containerStatusAlerts(stats, config, time.Now())
+
+	var messages []string
+	for _, alert := range alerts {
+		messages = append(messages, alert.Message)
+	}
+	want := []string{
+		"Container db () is stopped",
+		"Container backup () is running but expected to be stopped",
+		"Container worker () is stopped",
+		"Container worker () exited with error code: 2",
+	}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected alerts:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(messages, "\n"))
+	}
+
+	// Without a default expectation, only declared containers are checked
+	config.ExpectedState = "any"
+	if alerts := containerStatusAlerts(stats[:2], config, time.Now()); len(alerts) != 0 {
+		t.Errorf("Expected no alerts for undeclared containers, got %+v", alerts)
+	}
+}
+
+func TestDockerMonitor_ValidateExpectations(t *testing.T) {
+	for _, config := range []types.DockerConfig{
+		{ExpectedState: "paused"},
+		{Expectations: []types.ContainerExpectation{{State: "running"}}},
+		{Expectations: []types.ContainerExpectation{{Container: "web", State: "up"}}},
+	} {
+		if err := NewDockerMonitor(&config).Validate(); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
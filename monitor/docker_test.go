@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestApplyContainerResources(t *testing.T) {
+	prev := container.CPUStats{
+		CPUUsage:    container.CPUUsage{TotalUsage: 1_000_000_000},
+		SystemUsage: 100_000_000_000,
+	}
+	sample := container.StatsResponse{
+		CPUStats: container.CPUStats{
+			CPUUsage:    container.CPUUsage{TotalUsage: 3_000_000_000},
+			SystemUsage: 108_000_000_000,
+			OnlineCPUs:  4,
+		},
+		MemoryStats: container.MemoryStats{
+			Usage: 600 * 1024 * 1024,
+			Limit: 1024 * 1024 * 1024,
+			Stats: map[string]uint64{"inactive_file": 88 * 1024 * 1024},
+		},
+		Networks: map[string]container.NetworkStats{
+			"eth0": {RxBytes: 1000, TxBytes: 200},
+			"eth1": {RxBytes: 500, TxBytes: 50},
+		},
+	}
+
+	var stats types.DockerContainerStats
+	applyContainerResources(&stats, sample, prev, true)
+
+	// 2s of CPU time over 8s of system time on 4 CPUs = 100% (one core)
+	if math.Abs(stats.CPUPercent-100) > 0.001 {
+		t.Errorf("Expected 100%% CPU, got %.3f", stats.CPUPercent)
+	}
+	if stats.MemoryUsage != 512*1024*1024 || stats.MemoryPercent != 50 {
+		t.Errorf("Expected 512 MiB (50%%) memory excluding page cache, got %d (%.1f%%)", stats.MemoryUsage, stats.MemoryPercent)
+	}
+	if stats.NetworkRxBytes != 1500 || stats.NetworkTxBytes != 250 {
+		t.Errorf("Expected network totals across interfaces, got rx=%d tx=%d", stats.NetworkRxBytes, stats.NetworkTxBytes)
+	}
+
+	// Without a previous sample, or after a counter reset, CPU usage is not computed
+	stats = types.DockerContainerStats{}
+	applyContainerResources(&stats, sample, prev, false)
+	if stats.CPUPercent != 0 {
+		t.Errorf("Expected 0%% CPU without a previous sample, got %.1f", stats.CPUPercent)
+	}
+	stats = types.DockerContainerStats{}
+	applyContainerResources(&stats, sample, sample.CPUStats, true)
+	if stats.CPUPercent != 0 {
+		t.Errorf("Expected 0%% CPU without a delta, got %.1f", stats.CPUPercent)
+	}
+}
+
+func TestApplyContainerHealth(t *testing.T) {
+	var stats types.DockerContainerStats
+	applyContainerHealth(&stats, &container.Health{
+		Status:        container.Unhealthy,
+		FailingStreak: 3,
+		Log: []*container.HealthcheckResult{
+			{ExitCode: 0, Output: "ok"},
+			{ExitCode: 1, Output: "curl: (7) Failed to connect to localhost port 8080\n"},
+		},
+	})
+	if stats.Health != "unhealthy" || stats.HealthFailingStreak != 3 {
+		t.Errorf("Expected unhealthy with 3 failures, got %q / %d", stats.Health, stats.HealthFailingStreak)
+	}
+	if stats.HealthOutput != "curl: (7) Failed to connect to localhost port 8080" {
+		t.Errorf("Expected the latest probe output, got %q", stats.HealthOutput)
+	}
+
+	// Containers without a health check keep an empty status
+	stats = types.DockerContainerStats{}
+	applyContainerHealth(&stats, nil)
+	applyContainerHealth(&stats, &container.Health{Status: container.NoHealthcheck})
+	if stats.Health != "" {
+		t.Errorf("Expected no health status, got %q", stats.Health)
+	}
+}
+
+func TestApplyContainerInventory(t *testing.T) {
+	summary := container.Summary{
+		Image:   "nginx:1.27",
+		ImageID: "sha256:0123456789abcdef0123",
+		Ports: []container.Port{
+			{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+			{IP: "::", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+			{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+			{PrivatePort: 443, Type: "tcp"},
+		},
+		NetworkSettings: &container.NetworkSettingsSummary{
+			Networks: map[string]*network.EndpointSettings{"shop_front": {}, "bridge": {}},
+		},
+	}
+
+	var stats types.DockerContainerStats
+	applyContainerInventory(&stats, summary)
+
+	if stats.Image != "nginx:1.27" || stats.ImageID != "0123456789ab" {
+		t.Errorf("Unexpected image: %s (%s)", stats.Image, stats.ImageID)
+	}
+	wantPorts := []string{"0.0.0.0:8080->80/tcp", "443/tcp", "[::]:8080->80/tcp"}
+	if strings.Join(stats.Ports, " ") != strings.Join(wantPorts, " ") {
+		t.Errorf("Expected ports %v, got %v", wantPorts, stats.Ports)
+	}
+	if strings.Join(stats.Networks, " ") != "bridge shop_front" {
+		t.Errorf("Expected sorted networks, got %v", stats.Networks)
+	}
+}
+
+func TestContainerTags(t *testing.T) {
+	config := &types.DockerConfig{TagRules: []types.ContainerTags{
+		{Container: "payments", Tags: []string{"db", "payment-critical"}},
+		{Container: "web", Tags: []string{"frontend"}},
+	}}
+	labels := map[string]string{tagsLabel: "payment-critical, backend"}
+
+	tags := containerTags(config, "payments", labels)
+	if strings.Join(tags, ",") != "payment-critical,backend,db" {
+		t.Errorf("Expected label tags followed by rule tags without duplicates, got %v", tags)
+	}
+	if tags := containerTags(config, "worker", nil); tags != nil {
+		t.Errorf("Expected no tags for an untagged container, got %v", tags)
+	}
+}
@@ -0,0 +1,329 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// gRPC health check serving statuses (grpc.health.v1.HealthCheckResponse.ServingStatus)
+const (
+	grpcHealthUnknown        = 0
+	grpcHealthServing        = 1
+	grpcHealthNotServing     = 2
+	grpcHealthServiceUnknown = 3
+)
+
+// grpcHealthCheckPath is the HTTP/2 path of the grpc.health.v1 Health/Check method
+const grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+// GRPCMonitor handles gRPC endpoint monitoring using the standard health checking protocol
+type GRPCMonitor struct {
+	checks []types.GRPCCheck
+}
+
+// NewGRPCMonitor creates a new gRPC monitor instance
+func NewGRPCMonitor(checks []types.GRPCCheck) *GRPCMonitor {
+	return &GRPCMonitor{
+		checks: checks,
+	}
+}
+
+// Name returns the monitor name used in logs
+func (gm *GRPCMonitor) Name() string {
+	return "gRPC"
+}
+
+// Interval returns how often the configured checks should run (shortest check interval, default 30s)
+func (gm *GRPCMonitor) Interval() time.Duration {
+	intervals := make([]int, 0, len(gm.checks))
+	for _, check := range gm.checks {
+		intervals = append(intervals, check.CheckInterval)
+	}
+	return shortestInterval(intervals)
+}
+
+// shortestInterval returns the shortest positive check interval in seconds as a duration,
+// defaulting to 30 seconds when none is configured
+func shortestInterval(intervals []int) time.Duration {
+	interval := 0
+	for _, i := range intervals {
+		if i > 0 && (interval == 0 || i < interval) {
+			interval = i
+		}
+	}
+	if interval == 0 {
+		interval = 30
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// RunChecks performs all configured gRPC health checks
+func (gm *GRPCMonitor) RunChecks() []types.HTTPCheckResult {
+	results := make([]types.HTTPCheckResult, 0, len(gm.checks))
+	for _, check := range gm.checks {
+		results = append(results, gm.CheckHealth(check))
+	}
+	return results
+}
+
+// HasCheck reports whether a gRPC check of the given name is configured
+func (gm *GRPCMonitor) HasCheck(name string) bool {
+	for _, check := range gm.checks {
+		if check.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunCheck performs the named gRPC check on its own
+func (gm *GRPCMonitor) RunCheck(name string) (types.HTTPCheckResult, bool) {
+	for _, check := range gm.checks {
+		if check.Name == name {
+			return gm.CheckHealth(check), true
+		}
+	}
+	return types.HTTPCheckResult{}, false
+}
+
+// Validate validates all configured gRPC checks
+func (gm *GRPCMonitor) Validate() error {
+	for _, check := range gm.checks {
+		if err := gm.ValidateGRPCCheck(check); err != nil {
+			return fmt.Errorf("gRPC check %s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateGRPCCheck validates if a gRPC check configuration is valid
+func (gm *GRPCMonitor) ValidateGRPCCheck(check types.GRPCCheck) error {
+	if check.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	if check.Address == "" {
+		return fmt.Errorf("address cannot be empty")
+	}
+
+	if strings.Contains(check.Address, "://") {
+		return fmt.Errorf("address must be host:port without scheme")
+	}
+
+	if check.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	if check.CheckInterval < 0 {
+		return fmt.Errorf("check interval cannot be negative")
+	}
+
+	return nil
+}
+
+// CheckHealth performs a single grpc.health.v1 Health/Check call
+func (gm *GRPCMonitor) CheckHealth(check types.GRPCCheck) types.HTTPCheckResult {
+	result := gm.checkHealth(check)
+	if check.Invert {
+		invertResult(&result)
+	}
+	return result
+}
+
+// checkHealth performs the health check call and evaluates the response
+func (gm *GRPCMonitor) checkHealth(check types.GRPCCheck) types.HTTPCheckResult {
+	scheme := "http"
+	if check.TLS {
+		scheme = "https"
+	}
+
+	result := types.HTTPCheckResult{
+		Name:      check.Name,
+		Type:      "grpc",
+		URL:       fmt.Sprintf("grpc://%s/%s", check.Address, check.Service),
+		Timestamp: time.Now(),
+	}
+
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 5 // Default to 5 seconds
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	url := fmt.Sprintf("%s://%s%s", scheme, check.Address, grpcHealthCheckPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encodeGRPCHealthRequest(check.Service)))
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to create request: %v", err)
+		return result
+	}
+
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("User-Agent", "Monic-Monitor/1.0")
+
+	transport := newGRPCTransport(check)
+	defer transport.CloseIdleConnections()
+
+	startTime := time.Now()
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		result.ResponseTime = time.Since(startTime)
+		result.Error = fmt.Sprintf("request failed: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	result.ResponseTime = time.Since(startTime)
+	result.StatusCode = resp.StatusCode
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read response body: %v", err)
+		return result
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		result.Error = fmt.Sprintf("unexpected HTTP status code: %d", resp.StatusCode)
+		return result
+	}
+
+	// grpc-status is sent in trailers, or in headers for trailers-only responses
+	grpcStatus := resp.Header.Get("Grpc-Status")
+	grpcMessage := resp.Header.Get("Grpc-Message")
+	if grpcStatus == "" {
+		grpcStatus = resp.Trailer.Get("Grpc-Status")
+		grpcMessage = resp.Trailer.Get("Grpc-Message")
+	}
+	if grpcStatus != "" && grpcStatus != "0" {
+		code, _ := strconv.Atoi(grpcStatus)
+		result.Error = fmt.Sprintf("gRPC error %d: %s", code, grpcMessage)
+		return result
+	}
+
+	status, err := decodeGRPCHealthResponse(body)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid health response: %v", err)
+		return result
+	}
+
+	if status != grpcHealthServing {
+		result.Error = fmt.Sprintf("service is %s", grpcHealthStatusName(status))
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// newGRPCTransport creates an HTTP/2-only transport for a gRPC check
+func newGRPCTransport(check types.GRPCCheck) *http.Transport {
+	transport := &http.Transport{
+		TLSHandshakeTimeout: 10 * time.Second,
+		ForceAttemptHTTP2:   true,
+	}
+
+	protocols := new(http.Protocols)
+	if check.TLS {
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: check.InsecureSkipVerify,
+			ServerName:         check.ServerName,
+		}
+		protocols.SetHTTP2(true)
+	} else {
+		// Plaintext gRPC uses HTTP/2 with prior knowledge (h2c)
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	transport.Protocols = protocols
+
+	return transport
+}
+
+// encodeGRPCHealthRequest encodes a length-prefixed HealthCheckRequest message
+func encodeGRPCHealthRequest(service string) []byte {
+	// HealthCheckRequest { string service = 1; }
+	var message []byte
+	if service != "" {
+		message = append(message, 0x0a)
+		message = binary.AppendUvarint(message, uint64(len(service)))
+		message = append(message, service...)
+	}
+
+	frame := make([]byte, 5, 5+len(message))
+	frame[0] = 0 // Not compressed
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// decodeGRPCHealthResponse decodes a length-prefixed HealthCheckResponse message and returns its status
+func decodeGRPCHealthResponse(data []byte) (int, error) {
+	if len(data) < 5 {
+		return 0, fmt.Errorf("response too short")
+	}
+	if data[0] != 0 {
+		return 0, fmt.Errorf("compressed responses are not supported")
+	}
+
+	length := binary.BigEndian.Uint32(data[1:5])
+	if int(length) > len(data)-5 {
+		return 0, fmt.Errorf("truncated response message")
+	}
+	message := data[5 : 5+length]
+
+	// HealthCheckResponse { ServingStatus status = 1; }
+	status := grpcHealthUnknown
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, fmt.Errorf("invalid field tag")
+		}
+		message = message[n:]
+
+		fieldNumber, wireType := tag>>3, tag&0x7
+		switch wireType {
+		case 0: // varint
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return 0, fmt.Errorf("invalid varint field")
+			}
+			message = message[n:]
+			if fieldNumber == 1 {
+				status = int(value)
+			}
+		case 2: // length-delimited
+			length, n := binary.Uvarint(message)
+			if n <= 0 || int(length) > len(message)-n {
+				return 0, fmt.Errorf("invalid length-delimited field")
+			}
+			message = message[n+int(length):]
+		default:
+			return 0, fmt.Errorf("unsupported wire type %d", wireType)
+		}
+	}
+
+	return status, nil
+}
+
+// grpcHealthStatusName returns a human-readable name for a serving status
+func grpcHealthStatusName(status int) string {
+	switch status {
+	case grpcHealthServing:
+		return "SERVING"
+	case grpcHealthNotServing:
+		return "NOT_SERVING"
+	case grpcHealthServiceUnknown:
+		return "SERVICE_UNKNOWN"
+	default:
+		return "UNKNOWN"
+	}
+}
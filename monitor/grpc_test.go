@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+// newGRPCHealthServer creates a fake gRPC health server replying with the given serving status
+func newGRPCHealthServer(t *testing.T, status int, useTLS bool) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != grpcHealthCheckPath || r.Header.Get("Content-Type") != "application/grpc" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+
+		// HealthCheckResponse { status = <status> }
+		message := []byte{0x08, byte(status)}
+		frame := make([]byte, 5)
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.WriteHeader(http.StatusOK)
+		w.Write(append(frame, message...))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+
+	if useTLS {
+		server.EnableHTTP2 = true
+		server.StartTLS()
+	} else {
+		server.Config.Protocols = new(http.Protocols)
+		server.Config.Protocols.SetUnencryptedHTTP2(true)
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGRPCMonitor_CheckHealth_Serving(t *testing.T) {
+	server := newGRPCHealthServer(t, grpcHealthServing, false)
+	monitor := NewGRPCMonitor(nil)
+
+	result := monitor.CheckHealth(types.GRPCCheck{
+		Name:    "api",
+		Address: strings.TrimPrefix(server.URL, "http://"),
+		Timeout: 5,
+	})
+
+	if !result.Success {
+		t.Errorf("Expected successful check, got error: %s", result.Error)
+	}
+	if result.Type != "grpc" {
+		t.Errorf("Expected type 'grpc', got '%s'", result.Type)
+	}
+	if result.Name != "api" {
+		t.Errorf("Expected name 'api', got '%s'", result.Name)
+	}
+}
+
+func TestGRPCMonitor_CheckHealth_TLS(t *testing.T) {
+	server := newGRPCHealthServer(t, grpcHealthServing, true)
+	monitor := NewGRPCMonitor(nil)
+
+	result := monitor.CheckHealth(types.GRPCCheck{
+		Name:               "api-tls",
+		Address:            strings.TrimPrefix(server.URL, "https://"),
+		Service:            "payments.v1.Payments",
+		TLS:                true,
+		InsecureSkipVerify: true,
+		Timeout:            5,
+	})
+
+	if !result.Success {
+		t.Errorf("Expected successful TLS check, got error: %s", result.Error)
+	}
+}
+
+func TestGRPCMonitor_CheckHealth_NotServing(t *testing.T) {
+	server := newGRPCHealthServer(t, grpcHealthNotServing, false)
+	monitor := NewGRPCMonitor(nil)
+
+	result := monitor.CheckHealth(types.GRPCCheck{
+		Name:    "api",
+		Address: strings.TrimPrefix(server.URL, "http://"),
+		Timeout: 5,
+	})
+
+	if result.Success {
+		t.Error("Expected failed check for NOT_SERVING status")
+	}
+	if result.Error != "service is NOT_SERVING" {
+		t.Errorf("Expected NOT_SERVING error, got '%s'", result.Error)
+	}
+}
+
+func TestGRPCMonitor_ValidateGRPCCheck(t *testing.T) {
+	monitor := NewGRPCMonitor(nil)
+
+	tests := []struct {
+		name     string
+		check    types.GRPCCheck
+		expected string
+	}{
+		{
+			name:     "valid check",
+			check:    types.GRPCCheck{Name: "api", Address: "localhost:50051"},
+			expected: "",
+		},
+		{
+			name:     "empty name",
+			check:    types.GRPCCheck{Address: "localhost:50051"},
+			expected: "name cannot be empty",
+		},
+		{
+			name:     "empty address",
+			check:    types.GRPCCheck{Name: "api"},
+			expected: "address cannot be empty",
+		},
+		{
+			name:     "address with scheme",
+			check:    types.GRPCCheck{Name: "api", Address: "http://localhost:50051"},
+			expected: "address must be host:port without scheme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := monitor.ValidateGRPCCheck(tt.check)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+			} else if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected error '%s', got '%v'", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeGRPCHealth(t *testing.T) {
+	frame := encodeGRPCHealthRequest("svc")
+	if len(frame) != 10 || frame[5] != 0x0a || string(frame[7:]) != "svc" {
+		t.Errorf("Unexpected request frame: %v", frame)
+	}
+
+	status, err := decodeGRPCHealthResponse([]byte{0, 0, 0, 0, 2, 0x08, 0x01})
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if status != grpcHealthServing {
+		t.Errorf("Expected SERVING status, got %d", status)
+	}
+
+	if _, err := decodeGRPCHealthResponse([]byte{0, 0, 0, 0, 5, 0x08}); err == nil {
+		t.Error("Expected error for truncated response")
+	}
+}
@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// heartbeatCheckInterval is how often missed heartbeats are evaluated
+const heartbeatCheckInterval = 30 * time.Second
+
+// HeartbeatMonitor tracks heartbeats of external jobs (dead man's switch): a job that does
+// not report in within its interval produces a failed "heartbeat" check result
+type HeartbeatMonitor struct {
+	checks    []types.HeartbeatCheck
+	mu        sync.Mutex
+	lastPing  map[string]time.Time
+	startTime time.Time
+}
+
+// NewHeartbeatMonitor creates a new heartbeat monitor instance
+func NewHeartbeatMonitor(checks []types.HeartbeatCheck) *HeartbeatMonitor {
+	return &HeartbeatMonitor{
+		checks:    checks,
+		lastPing:  make(map[string]time.Time),
+		startTime: time.Now(),
+	}
+}
+
+// Name returns the monitor name used in logs
+func (hm *HeartbeatMonitor) Name() string {
+	return "Heartbeat"
+}
+
+// Interval returns how often missed heartbeats are evaluated
+func (hm *HeartbeatMonitor) Interval() time.Duration {
+	return heartbeatCheckInterval
+}
+
+// Validate validates all configured heartbeats
+func (hm *HeartbeatMonitor) Validate() error {
+	seen := make(map[string]bool)
+	for _, check := range hm.checks {
+		if check.Name == "" {
+			return fmt.Errorf("heartbeat name cannot be empty")
+		}
+		if seen[check.Name] {
+			return fmt.Errorf("heartbeat %s: duplicate name", check.Name)
+		}
+		seen[check.Name] = true
+
+		if check.Interval <= 0 {
+			return fmt.Errorf("heartbeat %s: interval must be positive", check.Name)
+		}
+		if check.Grace < 0 {
+			return fmt.Errorf("heartbeat %s: grace cannot be negative", check.Name)
+		}
+	}
+	return nil
+}
+
+// Ping records a heartbeat of the named job
+func (hm *HeartbeatMonitor) Ping(name string, at time.Time) error {
+	if _, exists := hm.find(name); !exists {
+		return fmt.Errorf("unknown heartbeat: %s", name)
+	}
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	hm.lastPing[name] = at
+	return nil
+}
+
+// find returns the configuration of the named heartbeat
+func (hm *HeartbeatMonitor) find(name string) (types.HeartbeatCheck, bool) {
+	for _, check := range hm.checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+	return types.HeartbeatCheck{}, false
+}
+
+// RunChecks evaluates all heartbeats
+func (hm *HeartbeatMonitor) RunChecks() []types.HTTPCheckResult {
+	return hm.checkAt(time.Now())
+}
+
+// checkAt evaluates all heartbeats at the given time. Jobs that have not reported since startup
+// are measured from the start time, so a restart does not immediately alert.
+func (hm *HeartbeatMonitor) checkAt(now time.Time) []types.HTTPCheckResult {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	results := make([]types.HTTPCheckResult, 0, len(hm.checks))
+	for _, check := range hm.checks {
+		result := types.HTTPCheckResult{
+			Name:      check.Name,
+			Type:      "heartbeat",
+			URL:       "heartbeat://" + check.Name,
+			Timestamp: now,
+		}
+
+		deadline := time.Duration(check.Interval+check.Grace) * time.Second
+		last, received := hm.lastPing[check.Name]
+		if !received {
+			last = hm.startTime
+		}
+
+		if since := now.Sub(last); since > deadline {
+			if received {
+				result.Error = fmt.Sprintf("missed job: no heartbeat for %s (last at %s)", since.Round(time.Second), last.Format(time.RFC3339))
+			} else {
+				result.Error = fmt.Sprintf("missed job: no heartbeat received since startup %s ago", since.Round(time.Second))
+			}
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestHeartbeatMonitor_CheckAt(t *testing.T) {
+	monitor := NewHeartbeatMonitor([]types.HeartbeatCheck{
+		{Name: "backup", Interval: 3600, Grace: 300},
+		{Name: "report", Interval: 60},
+	})
+	start := monitor.startTime
+
+	// Jobs that never reported are measured from startup
+	results := monitor.checkAt(start.Add(30 * time.Second))
+	if !results[0].Success || !results[1].Success {
+		t.Fatalf("Expected all heartbeats to be within their interval after startup, got %+v", results)
+	}
+
+	results = monitor.checkAt(start.Add(2 * time.Minute))
+	if !results[0].Success {
+		t.Errorf("Expected backup heartbeat to be within its interval, got %s", results[0].Error)
+	}
+	if results[1].Success || !strings.Contains(results[1].Error, "since startup") {
+		t.Errorf("Expected missed report heartbeat, got %+v", results[1])
+	}
+
+	if err := monitor.Ping("report", start.Add(2*time.Minute)); err != nil {
+		t.Fatalf("Unexpected ping error: %v", err)
+	}
+	results = monitor.checkAt(start.Add(150 * time.Second))
+	if !results[1].Success {
+		t.Errorf("Expected report heartbeat to recover after a ping, got %s", results[1].Error)
+	}
+
+	// The grace period extends the interval
+	if err := monitor.Ping("backup", start); err != nil {
+		t.Fatalf("Unexpected ping error: %v", err)
+	}
+	if results := monitor.checkAt(start.Add(62 * time.Minute)); !results[0].Success {
+		t.Errorf("Expected backup heartbeat to be within its grace period, got %s", results[0].Error)
+	}
+	results = monitor.checkAt(start.Add(66 * time.Minute))
+	if results[0].Success || !strings.Contains(results[0].Error, "missed job") || results[0].Type != "heartbeat" {
+		t.Errorf("Expected missed backup heartbeat, got %+v", results[0])
+	}
+
+	if err := monitor.Ping("unknown", start); err == nil {
+		t.Error("Expected error for unknown heartbeat")
+	}
+}
+
+func TestHeartbeatMonitor_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		checks  []types.HeartbeatCheck
+		wantErr bool
+	}{
+		{"valid", []types.HeartbeatCheck{{Name: "backup", Interval: 3600}}, false},
+		{"missing name", []types.HeartbeatCheck{{Interval: 60}}, true},
+		{"missing interval", []types.HeartbeatCheck{{Name: "backup"}}, true},
+		{"negative grace", []types.HeartbeatCheck{{Name: "backup", Interval: 60, Grace: -1}}, true},
+		{"duplicate", []types.HeartbeatCheck{{Name: "backup", Interval: 60}, {Name: "backup", Interval: 60}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewHeartbeatMonitor(tt.checks).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
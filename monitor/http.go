@@ -2,11 +2,11 @@ package monitor
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"bconf.com/monic/types"
@@ -14,35 +14,78 @@ import (
 
 // HTTPMonitor handles HTTP/HTTPS endpoint monitoring
 type HTTPMonitor struct {
-	client *http.Client
+	client        *http.Client
+	closingClient *http.Client // Client of checks with keep-alives disabled
+	pool          *CheckPool   // Limits the checks running at once; nil for no limit
 }
 
-// NewHTTPMonitor creates a new HTTP monitor instance
+// NewHTTPMonitor creates a new HTTP monitor instance with the default transport settings
 func NewHTTPMonitor() *HTTPMonitor {
-	// Create a custom HTTP client with timeouts and TLS configuration
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: false, // Verify SSL certificates
-		},
-		MaxIdleConns:        10,
-		IdleConnTimeout:     30 * time.Second,
-		TLSHandshakeTimeout: 10 * time.Second,
-	}
-
-	client := &http.Client{
-		Transport: transport,
+	hm := &HTTPMonitor{}
+	hm.SetTransport(types.HTTPTransportConfig{})
+	return hm
+}
+
+// SetTransport replaces the clients of the monitor with ones using the connection reuse and DNS
+// cache settings. It is meant to be called before the first check.
+func (hm *HTTPMonitor) SetTransport(config types.HTTPTransportConfig) {
+	dns := newDNSCache(time.Duration(config.DNSCacheTTL) * time.Second)
+	hm.client = &http.Client{
+		Transport: newCheckTransport(config, dns, true),
 		Timeout:   30 * time.Second, // Default timeout
 	}
-
-	return &HTTPMonitor{
-		client: client,
+	hm.closingClient = &http.Client{
+		Transport: newCheckTransport(config, dns, false),
+		Timeout:   30 * time.Second,
 	}
 }
 
-// CheckEndpoint performs a single HTTP/HTTPS check
+// SetPool limits the checks running at once, shared by every caller of the monitor
+func (hm *HTTPMonitor) SetPool(pool *CheckPool) {
+	hm.pool = pool
+}
+
+// CheckEndpoint performs a single HTTP/HTTPS check. A failed run is retried after the check's
+// retry delays, so a single dropped request doesn't count as a failure; the result is the one of
+// the last attempt.
 func (hm *HTTPMonitor) CheckEndpoint(check types.HTTPCheck) types.HTTPCheckResult {
+	result := hm.attemptEndpoint(check)
+	attempts := 1
+	for _, delay := range check.RetryDelays() {
+		if result.Success {
+			break
+		}
+		time.Sleep(delay)
+		result = hm.attemptEndpoint(check)
+		attempts++
+	}
+
+	result.Attempts = attempts
+	if !result.Success && attempts > 1 {
+		result.Error = fmt.Sprintf("%s (%d attempts)", result.Error, attempts)
+	}
+	return result
+}
+
+// attemptEndpoint requests the endpoint once the pool has a free slot for it. Retries wait
+// outside the pool, so they don't hold slots other checks could use.
+func (hm *HTTPMonitor) attemptEndpoint(check types.HTTPCheck) types.HTTPCheckResult {
+	var result types.HTTPCheckResult
+	hm.pool.Run(urlHost(check.URL), func() {
+		result = hm.checkEndpoint(check)
+	})
+	if check.Invert {
+		invertResult(&result)
+	}
+	return result
+}
+
+// checkEndpoint performs the HTTP request and evaluates the response
+func (hm *HTTPMonitor) checkEndpoint(check types.HTTPCheck) types.HTTPCheckResult {
 	result := types.HTTPCheckResult{
+		Name:      check.Name,
 		URL:       check.URL,
+		Tags:      check.Tags,
 		Timestamp: time.Now(),
 	}
 
@@ -50,7 +93,8 @@ func (hm *HTTPMonitor) CheckEndpoint(check types.HTTPCheck) types.HTTPCheckResul
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(check.Timeout)*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(check.Method), check.URL, nil)
+	timing := &requestTiming{}
+	req, err := http.NewRequestWithContext(timing.withTiming(ctx), strings.ToUpper(check.Method), check.URL, nil)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to create request: %v", err)
 		result.Success = false
@@ -62,7 +106,11 @@ func (hm *HTTPMonitor) CheckEndpoint(check types.HTTPCheck) types.HTTPCheckResul
 	req.Header.Set("Accept", "*/*")
 
 	startTime := time.Now()
-	resp, err := hm.client.Do(req)
+	client := hm.client
+	if check.DisableKeepAlives {
+		client = hm.closingClient
+	}
+	resp, err := client.Do(req)
 	responseTime := time.Since(startTime)
 
 	result.ResponseTime = responseTime
@@ -70,12 +118,15 @@ func (hm *HTTPMonitor) CheckEndpoint(check types.HTTPCheck) types.HTTPCheckResul
 	if err != nil {
 		result.Error = fmt.Sprintf("request failed: %v", err)
 		result.Success = false
+		result.Timing = timing.timing() // The phases up to the failure
 		return result
 	}
 	defer resp.Body.Close()
 
 	// Read a small portion of the response body to ensure connection is working
 	_, err = io.CopyN(io.Discard, resp.Body, 1024) // Read up to 1KB
+	timing.readBody()
+	result.Timing = timing.timing()
 	if err != nil && err != io.EOF {
 		result.Error = fmt.Sprintf("failed to read response body: %v", err)
 		result.Success = false
@@ -95,6 +146,23 @@ func (hm *HTTPMonitor) CheckEndpoint(check types.HTTPCheck) types.HTTPCheckResul
 	return result
 }
 
+// invertResult turns a check result into a canary result: reaching the target is a failure,
+// while any failure to reach it is the expected (successful) outcome
+func invertResult(result *types.HTTPCheckResult) {
+	result.Inverted = true
+	if result.Success {
+		result.Success = false
+		if result.StatusCode != 0 {
+			result.Error = fmt.Sprintf("canary check succeeded: %s is reachable (status %d) but must not be", result.URL, result.StatusCode)
+		} else {
+			result.Error = fmt.Sprintf("canary check succeeded: %s is reachable but must not be", result.URL)
+		}
+		return
+	}
+	result.Success = true
+	result.Error = ""
+}
+
 // CheckEndpoints performs checks on multiple HTTP endpoints
 func (hm *HTTPMonitor) CheckEndpoints(checks []types.HTTPCheck) []types.HTTPCheckResult {
 	var results []types.HTTPCheckResult
@@ -115,24 +183,31 @@ func (hm *HTTPMonitor) CheckEndpoints(checks []types.HTTPCheck) []types.HTTPChec
 	return results
 }
 
-// CheckEndpointsConcurrent performs HTTP checks concurrently for better performance
+// CheckEndpointsConcurrent performs HTTP checks concurrently for better performance, with no
+// more workers than the pool runs checks at once. Results are in the order of the checks.
 func (hm *HTTPMonitor) CheckEndpointsConcurrent(checks []types.HTTPCheck) []types.HTTPCheckResult {
-	results := make([]types.HTTPCheckResult, 0, len(checks))
-	resultChan := make(chan types.HTTPCheckResult, len(checks))
-
-	// Launch goroutines for each check
-	for _, check := range checks {
-		go func(c types.HTTPCheck) {
-			result := hm.CheckEndpoint(c)
-			resultChan <- result
-		}(check)
+	results := make([]types.HTTPCheckResult, len(checks))
+	workers := len(checks)
+	if size := hm.pool.Size(); size > 0 && size < workers {
+		workers = size
 	}
 
-	// Collect results
-	for i := 0; i < len(checks); i++ {
-		result := <-resultChan
-		results = append(results, result)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = hm.CheckEndpoint(checks[i])
+			}
+		}()
+	}
+	for i := range checks {
+		indexes <- i
 	}
+	close(indexes)
+	wg.Wait()
 
 	return results
 }
@@ -3,6 +3,8 @@ package monitor
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -109,6 +111,7 @@ func TestHTTPMonitor_CheckEndpoint_Success(t *testing.T) {
 	defer server.Close()
 
 	check := types.HTTPCheck{
+		Name:           "api",
 		URL:            server.URL,
 		Method:         "GET",
 		Timeout:        5,
@@ -124,7 +127,9 @@ func TestHTTPMonitor_CheckEndpoint_Success(t *testing.T) {
 	if result.StatusCode != 200 {
 		t.Errorf("Expected status code 200, got %d", result.StatusCode)
 	}
-	// Note: HTTPCheck doesn't have a Name field, so result.Name will be empty
+	if result.Name != "api" {
+		t.Errorf("Expected name 'api', got '%s'", result.Name)
+	}
 	if result.URL != server.URL {
 		t.Errorf("Expected URL '%s', got '%s'", server.URL, result.URL)
 	}
@@ -188,6 +193,45 @@ func TestHTTPMonitor_CheckEndpoint_ConnectionError(t *testing.T) {
 	}
 }
 
+func TestHTTPMonitor_CheckEndpoint_Retries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first two requests fail, e.g. dropped by an overloaded load balancer
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	monitor := NewHTTPMonitor()
+	check := types.HTTPCheck{URL: server.URL, Method: "GET", Timeout: 5, ExpectedStatus: 200, RetryDelay: 10}
+
+	check.Retries = 1
+	result := monitor.CheckEndpoint(check)
+	if result.Success || result.Attempts != 2 || !strings.HasSuffix(result.Error, "(2 attempts)") {
+		t.Errorf("Expected a failure after 2 attempts, got %+v", result)
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	check.Retries = 2
+	start := time.Now()
+	result = monitor.CheckEndpoint(check)
+	if !result.Success || result.Attempts != 3 {
+		t.Errorf("Expected a success on the third attempt, got %+v", result)
+	}
+	// 10ms before the first retry, doubled to 20ms before the second
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expected the retries to wait 30ms, took %v", elapsed)
+	}
+
+	result = monitor.CheckEndpoint(check)
+	if !result.Success || result.Attempts != 1 || requests != 4 {
+		t.Errorf("Expected a successful run without retries, got %+v after %d requests", result, requests)
+	}
+}
+
 func TestHTTPMonitor_CheckEndpoints(t *testing.T) {
 	monitor := NewHTTPMonitor()
 
@@ -323,7 +367,10 @@ func TestHTTPMonitor_CheckEndpointConcurrent(t *testing.T) {
 	if result.StatusCode != 200 {
 		t.Errorf("Expected status code 200, got %d", result.StatusCode)
 	}
-	// Note: HTTPCheck doesn't have a Name field, so result.Name will be empty
+	// The check has no name, so neither has the result
+	if result.Name != "" {
+		t.Errorf("Expected no name, got '%s'", result.Name)
+	}
 	if result.URL != server.URL {
 		t.Errorf("Expected URL '%s', got '%s'", server.URL, result.URL)
 	}
@@ -334,3 +381,43 @@ func TestHTTPMonitor_CheckEndpointConcurrent(t *testing.T) {
 		t.Error("Expected timestamp to be set")
 	}
 }
+
+func TestHTTPMonitor_CheckEndpoint_Inverted(t *testing.T) {
+	monitor := NewHTTPMonitor()
+
+	// An exposed admin panel responding with 200 must fail the canary check
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	check := types.HTTPCheck{
+		URL:            server.URL,
+		Method:         "GET",
+		Timeout:        5,
+		ExpectedStatus: 200,
+		CheckInterval:  30,
+		Invert:         true,
+	}
+
+	result := monitor.CheckEndpoint(check)
+	if result.Success {
+		t.Error("Expected canary check to fail when the endpoint is reachable")
+	}
+	if !result.Inverted {
+		t.Error("Expected result to be marked as inverted")
+	}
+	if result.Error == "" {
+		t.Error("Expected error message for reachable canary endpoint")
+	}
+
+	// An unreachable endpoint is the expected outcome
+	server.Close()
+	result = monitor.CheckEndpoint(check)
+	if !result.Success {
+		t.Errorf("Expected canary check to succeed when the endpoint is unreachable, got error: %s", result.Error)
+	}
+	if result.Error != "" {
+		t.Errorf("Expected no error for unreachable canary endpoint, got '%s'", result.Error)
+	}
+}
@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// requestTiming records the phases of an HTTP request through httptrace. Dialing may run in
+// another goroutine than the request, so the hooks lock.
+type requestTiming struct {
+	mu                  sync.Mutex
+	dnsStart, dnsDone   time.Time
+	connectStart        time.Time
+	connectDone         time.Time // The phase spans all addresses tried
+	tlsStart, tlsDone   time.Time
+	wroteRequest        time.Time
+	firstByte, bodyDone time.Time
+	reused              bool
+}
+
+// withTiming returns a context recording the phases of the request made with it
+func (rt *requestTiming) withTiming(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { rt.mark(&rt.dnsStart, false) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { rt.mark(&rt.dnsDone, true) },
+		ConnectStart:         func(string, string) { rt.mark(&rt.connectStart, false) },
+		ConnectDone:          func(string, string, error) { rt.mark(&rt.connectDone, true) },
+		TLSHandshakeStart:    func() { rt.mark(&rt.tlsStart, false) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { rt.mark(&rt.tlsDone, true) },
+		GotConn:              func(info httptrace.GotConnInfo) { rt.setReused(info.Reused) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { rt.mark(&rt.wroteRequest, true) },
+		GotFirstResponseByte: func() { rt.mark(&rt.firstByte, false) },
+	})
+}
+
+// mark records the time of an event: the first occurrence for starts, the last one for ends
+func (rt *requestTiming) mark(at *time.Time, last bool) {
+	now := time.Now()
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if at.IsZero() || last {
+		*at = now
+	}
+}
+
+// setReused records whether the request got a kept-alive connection
+func (rt *requestTiming) setReused(reused bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.reused = reused
+}
+
+// readBody records that the response body was read
+func (rt *requestTiming) readBody() {
+	rt.mark(&rt.bodyDone, true)
+}
+
+// timing returns the durations of the recorded phases
+func (rt *requestTiming) timing() *types.HTTPTiming {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return &types.HTTPTiming{
+		DNS:        span(rt.dnsStart, rt.dnsDone),
+		Connect:    span(rt.connectStart, rt.connectDone),
+		TLS:        span(rt.tlsStart, rt.tlsDone),
+		FirstByte:  span(rt.wroteRequest, rt.firstByte),
+		Transfer:   span(rt.firstByte, rt.bodyDone),
+		ReusedConn: rt.reused,
+	}
+}
+
+// span returns the time between two events, zero unless both happened
+func span(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,51 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestHTTPMonitor_CheckEndpoint_Timing(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond) // Server processing
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	monitor := &HTTPMonitor{client: server.Client()}
+	check := types.HTTPCheck{URL: server.URL, Method: "GET", Timeout: 5, ExpectedStatus: 200}
+
+	first := monitor.CheckEndpoint(check).Timing
+	if first == nil {
+		t.Fatal("Expected the timing of the request")
+	}
+	if first.Connect <= 0 || first.TLS <= 0 || first.ReusedConn {
+		t.Errorf("Expected a new connection and TLS handshake, got %+v", first)
+	}
+	if first.DNS != 0 {
+		t.Errorf("Expected no DNS lookup of an IP address, got %v", first.DNS)
+	}
+	if first.FirstByte < 20*time.Millisecond {
+		t.Errorf("Expected the server processing in the time to first byte, got %v", first.FirstByte)
+	}
+
+	second := monitor.CheckEndpoint(check).Timing
+	if second == nil || !second.ReusedConn || second.Connect != 0 || second.TLS != 0 {
+		t.Errorf("Expected a reused connection without connect and handshake, got %+v", second)
+	}
+}
+
+func TestHTTPMonitor_CheckEndpoint_TimingOfFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	result := NewHTTPMonitor().CheckEndpoint(types.HTTPCheck{URL: url, Method: "GET", Timeout: 5, ExpectedStatus: 200})
+	if result.Success || result.Timing == nil || result.Timing.FirstByte != 0 {
+		t.Errorf("Expected the timing up to the failed connection, got %+v", result)
+	}
+}
@@ -0,0 +1,287 @@
+package monitor
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// MailMonitor handles SMTP/IMAP server monitoring with handshake and login probes
+type MailMonitor struct {
+	checks []types.MailCheck
+}
+
+// NewMailMonitor creates a new mail monitor instance
+func NewMailMonitor(checks []types.MailCheck) *MailMonitor {
+	return &MailMonitor{
+		checks: checks,
+	}
+}
+
+// Name returns the monitor name used in logs
+func (mm *MailMonitor) Name() string {
+	return "Mail"
+}
+
+// Interval returns how often the configured checks should run
+func (mm *MailMonitor) Interval() time.Duration {
+	intervals := make([]int, 0, len(mm.checks))
+	for _, check := range mm.checks {
+		intervals = append(intervals, check.CheckInterval)
+	}
+	return shortestInterval(intervals)
+}
+
+// RunChecks performs all configured mail server checks
+func (mm *MailMonitor) RunChecks() []types.HTTPCheckResult {
+	results := make([]types.HTTPCheckResult, 0, len(mm.checks))
+	for _, check := range mm.checks {
+		results = append(results, mm.CheckServer(check))
+	}
+	return results
+}
+
+// HasCheck reports whether a mail check of the given name is configured
+func (mm *MailMonitor) HasCheck(name string) bool {
+	for _, check := range mm.checks {
+		if check.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunCheck performs the named mail check on its own
+func (mm *MailMonitor) RunCheck(name string) (types.HTTPCheckResult, bool) {
+	for _, check := range mm.checks {
+		if check.Name == name {
+			return mm.CheckServer(check), true
+		}
+	}
+	return types.HTTPCheckResult{}, false
+}
+
+// Validate validates all configured mail checks
+func (mm *MailMonitor) Validate() error {
+	for _, check := range mm.checks {
+		if err := mm.ValidateMailCheck(check); err != nil {
+			return fmt.Errorf("mail check %s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateMailCheck validates if a mail check configuration is valid
+func (mm *MailMonitor) ValidateMailCheck(check types.MailCheck) error {
+	if check.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	protocol := strings.ToLower(check.Protocol)
+	if protocol != "smtp" && protocol != "imap" {
+		return fmt.Errorf("protocol must be smtp or imap")
+	}
+
+	if check.Host == "" {
+		return fmt.Errorf("host cannot be empty")
+	}
+
+	if check.Port < 0 || check.Port > 65535 {
+		return fmt.Errorf("port must be between 0 and 65535")
+	}
+
+	if check.TLS && check.StartTLS {
+		return fmt.Errorf("TLS and STARTTLS cannot both be enabled")
+	}
+
+	if check.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	return nil
+}
+
+// CheckServer performs a single SMTP or IMAP probe
+func (mm *MailMonitor) CheckServer(check types.MailCheck) types.HTTPCheckResult {
+	protocol := strings.ToLower(check.Protocol)
+	port := check.Port
+	if port == 0 {
+		port = defaultMailPort(protocol, check.TLS)
+	}
+	addr := net.JoinHostPort(check.Host, strconv.Itoa(port))
+
+	result := types.HTTPCheckResult{
+		Name:      check.Name,
+		Type:      protocol,
+		URL:       fmt.Sprintf("%s://%s", protocol, addr),
+		Timestamp: time.Now(),
+	}
+
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 10 // Default to 10 seconds
+	}
+
+	startTime := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, time.Duration(timeout)*time.Second)
+	if err != nil {
+		result.ResponseTime = time.Since(startTime)
+		result.Error = fmt.Sprintf("connection failed: %v", err)
+		return result
+	}
+	defer conn.Close()
+
+	// The whole probe must complete within the timeout
+	conn.SetDeadline(startTime.Add(time.Duration(timeout) * time.Second))
+
+	tlsConfig := &tls.Config{
+		ServerName:         check.Host,
+		InsecureSkipVerify: check.InsecureSkipVerify,
+	}
+	if check.TLS {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			result.ResponseTime = time.Since(startTime)
+			result.Error = fmt.Sprintf("TLS handshake failed: %v", err)
+			return result
+		}
+		conn = tlsConn
+	}
+
+	if protocol == "imap" {
+		err = probeIMAP(conn, check, tlsConfig)
+	} else {
+		err = probeSMTP(conn, check, tlsConfig)
+	}
+
+	result.ResponseTime = time.Since(startTime)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// probeSMTP performs an EHLO handshake with optional STARTTLS and AUTH
+func probeSMTP(conn net.Conn, check types.MailCheck, tlsConfig *tls.Config) error {
+	client, err := smtp.NewClient(conn, check.Host)
+	if err != nil {
+		return fmt.Errorf("SMTP greeting failed: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Hello("monic"); err != nil {
+		return fmt.Errorf("SMTP EHLO failed: %w", err)
+	}
+
+	if check.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+
+	if check.Username != "" {
+		auth := smtp.PlainAuth("", check.Username, check.Password, check.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP auth failed: %w", err)
+		}
+	}
+
+	if err := client.Quit(); err != nil {
+		return fmt.Errorf("SMTP QUIT failed: %w", err)
+	}
+	return nil
+}
+
+// probeIMAP reads the server greeting and performs optional STARTTLS and LOGIN
+func probeIMAP(conn net.Conn, check types.MailCheck, tlsConfig *tls.Config) error {
+	reader := bufio.NewReader(conn)
+
+	greeting, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("IMAP greeting failed: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		return fmt.Errorf("unexpected IMAP greeting: %s", strings.TrimSpace(greeting))
+	}
+
+	if check.StartTLS {
+		if err := imapCommand(conn, reader, "a1", "STARTTLS"); err != nil {
+			return fmt.Errorf("IMAP STARTTLS failed: %w", err)
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return fmt.Errorf("IMAP TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	if check.Username != "" {
+		login := fmt.Sprintf("LOGIN %s %s", imapQuote(check.Username), imapQuote(check.Password))
+		if err := imapCommand(conn, reader, "a2", login); err != nil {
+			return fmt.Errorf("IMAP login failed: %w", err)
+		}
+	}
+
+	if err := imapCommand(conn, reader, "a3", "LOGOUT"); err != nil {
+		return fmt.Errorf("IMAP LOGOUT failed: %w", err)
+	}
+	return nil
+}
+
+// imapCommand sends a tagged IMAP command and waits for its tagged OK response
+func imapCommand(conn net.Conn, reader *bufio.Reader, tag, command string) error {
+	if _, err := fmt.Fprintf(conn, "%s %s\r\n", tag, command); err != nil {
+		return err
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, tag+" ") {
+			continue // Untagged response
+		}
+
+		status := strings.TrimSpace(strings.TrimPrefix(line, tag+" "))
+		if strings.HasPrefix(status, "OK") {
+			return nil
+		}
+		return fmt.Errorf("%s", status)
+	}
+}
+
+// imapQuote quotes a string for use in an IMAP command
+func imapQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// defaultMailPort returns the default port for a mail protocol
+func defaultMailPort(protocol string, useTLS bool) int {
+	switch {
+	case protocol == "imap" && useTLS:
+		return 993
+	case protocol == "imap":
+		return 143
+	case useTLS:
+		return 465
+	default:
+		return 25
+	}
+}
@@ -0,0 +1,192 @@
+package monitor
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+// startFakeMailServer starts a line-based fake server answering commands via the respond function
+func startFakeMailServer(t *testing.T, greeting string, respond func(line string) string) (string, int) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.Write([]byte(greeting))
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					conn.Write([]byte(respond(strings.TrimSpace(line))))
+				}
+			}(conn)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return host, portNum
+}
+
+func TestMailMonitor_CheckServer_SMTP(t *testing.T) {
+	host, port := startFakeMailServer(t, "220 test ESMTP\r\n", func(line string) string {
+		switch {
+		case strings.HasPrefix(line, "EHLO"):
+			return "250-test\r\n250 AUTH PLAIN\r\n"
+		case strings.HasPrefix(line, "AUTH"):
+			return "235 2.7.0 Authentication successful\r\n"
+		case strings.HasPrefix(line, "QUIT"):
+			return "221 bye\r\n"
+		default:
+			return "500 unknown\r\n"
+		}
+	})
+
+	monitor := NewMailMonitor(nil)
+	result := monitor.CheckServer(types.MailCheck{
+		Name:     "smtp",
+		Protocol: "smtp",
+		Host:     host,
+		Port:     port,
+		Username: "monic",
+		Password: "secret",
+		Timeout:  5,
+	})
+
+	if !result.Success {
+		t.Errorf("Expected successful SMTP check, got error: %s", result.Error)
+	}
+	if result.Type != "smtp" {
+		t.Errorf("Expected type 'smtp', got '%s'", result.Type)
+	}
+}
+
+func TestMailMonitor_CheckServer_SMTPNoStartTLS(t *testing.T) {
+	host, port := startFakeMailServer(t, "220 test ESMTP\r\n", func(line string) string {
+		if strings.HasPrefix(line, "EHLO") {
+			return "250 test\r\n"
+		}
+		return "221 bye\r\n"
+	})
+
+	monitor := NewMailMonitor(nil)
+	result := monitor.CheckServer(types.MailCheck{
+		Name:     "smtp",
+		Protocol: "smtp",
+		Host:     host,
+		Port:     port,
+		StartTLS: true,
+		Timeout:  5,
+	})
+
+	if result.Success {
+		t.Error("Expected SMTP check to fail when STARTTLS is not offered")
+	}
+	if result.Error != "SMTP server does not support STARTTLS" {
+		t.Errorf("Unexpected error: %s", result.Error)
+	}
+}
+
+func TestMailMonitor_CheckServer_IMAP(t *testing.T) {
+	respond := func(line string) string {
+		tag, command, _ := strings.Cut(line, " ")
+		switch {
+		case strings.HasPrefix(command, "LOGIN") && strings.Contains(command, `"secret"`):
+			return tag + " OK LOGIN completed\r\n"
+		case strings.HasPrefix(command, "LOGIN"):
+			return tag + " NO [AUTHENTICATIONFAILED] Invalid credentials\r\n"
+		case command == "LOGOUT":
+			return "* BYE logging out\r\n" + tag + " OK LOGOUT completed\r\n"
+		default:
+			return tag + " BAD unknown command\r\n"
+		}
+	}
+	host, port := startFakeMailServer(t, "* OK IMAP4rev1 ready\r\n", respond)
+
+	monitor := NewMailMonitor(nil)
+	check := types.MailCheck{
+		Name:     "imap",
+		Protocol: "imap",
+		Host:     host,
+		Port:     port,
+		Username: "monic",
+		Password: "secret",
+		Timeout:  5,
+	}
+
+	result := monitor.CheckServer(check)
+	if !result.Success {
+		t.Errorf("Expected successful IMAP check, got error: %s", result.Error)
+	}
+
+	check.Password = "wrong"
+	result = monitor.CheckServer(check)
+	if result.Success {
+		t.Error("Expected IMAP check to fail with invalid credentials")
+	}
+	if !strings.Contains(result.Error, "IMAP login failed") {
+		t.Errorf("Unexpected error: %s", result.Error)
+	}
+}
+
+func TestMailMonitor_ValidateMailCheck(t *testing.T) {
+	monitor := NewMailMonitor(nil)
+
+	tests := []struct {
+		name     string
+		check    types.MailCheck
+		expected string
+	}{
+		{
+			name:     "valid check",
+			check:    types.MailCheck{Name: "smtp", Protocol: "SMTP", Host: "mail.example.com"},
+			expected: "",
+		},
+		{
+			name:     "invalid protocol",
+			check:    types.MailCheck{Name: "pop", Protocol: "pop3", Host: "mail.example.com"},
+			expected: "protocol must be smtp or imap",
+		},
+		{
+			name:     "empty host",
+			check:    types.MailCheck{Name: "smtp", Protocol: "smtp"},
+			expected: "host cannot be empty",
+		},
+		{
+			name:     "TLS and STARTTLS",
+			check:    types.MailCheck{Name: "smtp", Protocol: "smtp", Host: "mail.example.com", TLS: true, StartTLS: true},
+			expected: "TLS and STARTTLS cannot both be enabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := monitor.ValidateMailCheck(tt.check)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+			} else if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected error '%s', got '%v'", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// managedCheckTick is how often due managed checks are run; each check runs on its own interval
+const managedCheckTick = 10 * time.Second
+
+// Defaults of managed checks
+const (
+	defaultManagedTimeout  = 10
+	defaultManagedInterval = 60
+)
+
+// Errors of managed check changes, so callers can tell a missing check from an invalid one
+var (
+	ErrManagedCheckNotFound = errors.New("check not found")
+	ErrManagedCheckExists   = errors.New("check already exists")
+	ErrInvalidManagedCheck  = errors.New("invalid check")
+)
+
+// ManagedCheckMonitor runs HTTP, TCP and DNS checks that are created, changed, paused and deleted
+// at runtime. Every change is written to a JSON file, so the checks survive restarts.
+type ManagedCheckMonitor struct {
+	path        string
+	httpMonitor *HTTPMonitor
+	mu          sync.Mutex
+	checks      []types.ManagedCheck
+	lastRun     map[string]time.Time
+	onChange    func([]types.ManagedCheck)
+}
+
+// NewManagedCheckMonitor creates a managed check monitor backed by the given file
+func NewManagedCheckMonitor(path string, httpMonitor *HTTPMonitor) *ManagedCheckMonitor {
+	return &ManagedCheckMonitor{
+		path:        path,
+		httpMonitor: httpMonitor,
+		lastRun:     make(map[string]time.Time),
+	}
+}
+
+// Name returns the monitor name used in logs
+func (mm *ManagedCheckMonitor) Name() string {
+	return "Managed"
+}
+
+// Interval returns how often due checks are run
+func (mm *ManagedCheckMonitor) Interval() time.Duration {
+	return managedCheckTick
+}
+
+// OnChange sets a function called with all checks after they are loaded and after every change,
+// e.g. to update the alert targets. It is called with the checks locked and must not call back
+// into the monitor.
+func (mm *ManagedCheckMonitor) OnChange(fn func([]types.ManagedCheck)) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.onChange = fn
+}
+
+// changed calls the change function, if any, with a copy of the checks; mm.mu must be held
+func (mm *ManagedCheckMonitor) changed() {
+	if mm.onChange != nil {
+		mm.onChange(append([]types.ManagedCheck(nil), mm.checks...))
+	}
+}
+
+// Load reads the checks from the file; a missing file means no checks yet
+func (mm *ManagedCheckMonitor) Load() error {
+	data, err := os.ReadFile(mm.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read managed checks file: %w", err)
+	}
+
+	checks := []types.ManagedCheck{}
+	if err := json.Unmarshal(data, &checks); err != nil {
+		return fmt.Errorf("failed to parse managed checks file: %w", err)
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.checks = checks
+	mm.changed()
+	return nil
+}
+
+// save writes all checks to the file, readable only by the owner (URLs may contain credentials)
+func (mm *ManagedCheckMonitor) save() error {
+	data, err := json.MarshalIndent(mm.checks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode managed checks: %w", err)
+	}
+	if err := os.WriteFile(mm.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write managed checks file: %w", err)
+	}
+	return nil
+}
+
+// Validate validates all loaded checks
+func (mm *ManagedCheckMonitor) Validate() error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, check := range mm.checks {
+		if err := ValidateManagedCheck(check); err != nil {
+			return fmt.Errorf("check %s: %w", check.Name, err)
+		}
+		if seen[check.Name] {
+			return fmt.Errorf("check %s: duplicate name", check.Name)
+		}
+		seen[check.Name] = true
+	}
+	return nil
+}
+
+// ValidateManagedCheck validates a managed check with its defaults applied
+func ValidateManagedCheck(check types.ManagedCheck) error {
+	if check.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if strings.ContainsAny(check.Name, "/ ") {
+		return fmt.Errorf("name cannot contain slashes or spaces")
+	}
+	if check.Target == "" {
+		return fmt.Errorf("target cannot be empty")
+	}
+	if check.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	if check.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative")
+	}
+	for _, channel := range check.Alert.Channels {
+		if channel != "email" && channel != "mailgun" && channel != "telegram" {
+			return fmt.Errorf("unknown alert channel: %s (valid: email, mailgun, telegram)", channel)
+		}
+	}
+
+	switch check.Type {
+	case "http":
+		return (&HTTPMonitor{}).ValidateHTTPCheck(managedHTTPCheck(check))
+	case "tcp":
+		host, port, err := net.SplitHostPort(check.Target)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("target must be host:port")
+		}
+	case "dns":
+		if strings.ContainsAny(check.Target, ":/ ") {
+			return fmt.Errorf("target must be a host name")
+		}
+		if check.Expected != "" && net.ParseIP(check.Expected) == nil {
+			return fmt.Errorf("expected address is not an IP address: %s", check.Expected)
+		}
+	default:
+		return fmt.Errorf("unknown check type: %s (valid: http, tcp, dns)", check.Type)
+	}
+	return nil
+}
+
+// withDefaults fills in the defaults of unset optional fields
+func withDefaults(check types.ManagedCheck) types.ManagedCheck {
+	check.Type = strings.ToLower(check.Type)
+	if check.Timeout == 0 {
+		check.Timeout = defaultManagedTimeout
+	}
+	if check.Interval == 0 {
+		check.Interval = defaultManagedInterval
+	}
+	if check.Type == "http" {
+		if check.Method == "" {
+			check.Method = "GET"
+		}
+		check.Method = strings.ToUpper(check.Method)
+		if check.ExpectedStatus == 0 {
+			check.ExpectedStatus = 200
+		}
+	}
+	return check
+}
+
+// List returns all checks sorted by name
+func (mm *ManagedCheckMonitor) List() []types.ManagedCheck {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	checks := append([]types.ManagedCheck(nil), mm.checks...)
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Name < checks[j].Name
+	})
+	return checks
+}
+
+// Get returns the named check
+func (mm *ManagedCheckMonitor) Get(name string) (types.ManagedCheck, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if i := mm.index(name); i >= 0 {
+		return mm.checks[i], nil
+	}
+	return types.ManagedCheck{}, fmt.Errorf("%w: %s", ErrManagedCheckNotFound, name)
+}
+
+// Create adds a check and returns it with its defaults applied
+func (mm *ManagedCheckMonitor) Create(check types.ManagedCheck) (types.ManagedCheck, error) {
+	check = withDefaults(check)
+	if err := ValidateManagedCheck(check); err != nil {
+		return types.ManagedCheck{}, fmt.Errorf("%w: %v", ErrInvalidManagedCheck, err)
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if mm.index(check.Name) >= 0 {
+		return types.ManagedCheck{}, fmt.Errorf("%w: %s", ErrManagedCheckExists, check.Name)
+	}
+	check.CreatedAt = time.Now().UTC()
+	check.UpdatedAt = check.CreatedAt
+	mm.checks = append(mm.checks, check)
+	if err := mm.save(); err != nil {
+		mm.checks = mm.checks[:len(mm.checks)-1]
+		return types.ManagedCheck{}, err
+	}
+	mm.changed()
+	return check, nil
+}
+
+// Update replaces the definition of the named check; its paused state and creation time are kept
+func (mm *ManagedCheckMonitor) Update(name string, check types.ManagedCheck) (types.ManagedCheck, error) {
+	check.Name = name
+	check = withDefaults(check)
+	if err := ValidateManagedCheck(check); err != nil {
+		return types.ManagedCheck{}, fmt.Errorf("%w: %v", ErrInvalidManagedCheck, err)
+	}
+
+	return mm.change(name, func(existing *types.ManagedCheck) {
+		check.Paused = existing.Paused
+		check.CreatedAt = existing.CreatedAt
+		*existing = check
+	})
+}
+
+// SetPaused pauses or resumes the named check
+func (mm *ManagedCheckMonitor) SetPaused(name string, paused bool) (types.ManagedCheck, error) {
+	return mm.change(name, func(existing *types.ManagedCheck) {
+		existing.Paused = paused
+	})
+}
+
+// change applies a change to the named check and saves it, restoring the previous version if the
+// file cannot be written
+func (mm *ManagedCheckMonitor) change(name string, apply func(*types.ManagedCheck)) (types.ManagedCheck, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	i := mm.index(name)
+	if i < 0 {
+		return types.ManagedCheck{}, fmt.Errorf("%w: %s", ErrManagedCheckNotFound, name)
+	}
+	previous := mm.checks[i]
+	apply(&mm.checks[i])
+	mm.checks[i].UpdatedAt = time.Now().UTC()
+	if err := mm.save(); err != nil {
+		mm.checks[i] = previous
+		return types.ManagedCheck{}, err
+	}
+	// Changed checks run on the next tick
+	delete(mm.lastRun, name)
+	mm.changed()
+	return mm.checks[i], nil
+}
+
+// Delete removes the named check
+func (mm *ManagedCheckMonitor) Delete(name string) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	i := mm.index(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrManagedCheckNotFound, name)
+	}
+	previous := append([]types.ManagedCheck(nil), mm.checks...)
+	mm.checks = append(mm.checks[:i], mm.checks[i+1:]...)
+	if err := mm.save(); err != nil {
+		mm.checks = previous
+		return err
+	}
+	delete(mm.lastRun, name)
+	mm.changed()
+	return nil
+}
+
+// index returns the position of the named check, or -1
+func (mm *ManagedCheckMonitor) index(name string) int {
+	for i, check := range mm.checks {
+		if check.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// RunChecks runs the checks that are not paused and whose interval has elapsed, concurrently
+func (mm *ManagedCheckMonitor) RunChecks() []types.HTTPCheckResult {
+	now := time.Now()
+
+	mm.mu.Lock()
+	var due []types.ManagedCheck
+	for _, check := range mm.checks {
+		if check.Paused {
+			continue
+		}
+		if last, ran := mm.lastRun[check.Name]; ran && now.Sub(last) < time.Duration(check.Interval)*time.Second {
+			continue
+		}
+		mm.lastRun[check.Name] = now
+		due = append(due, check)
+	}
+	mm.mu.Unlock()
+
+	results := make([]types.HTTPCheckResult, len(due))
+	var wg sync.WaitGroup
+	for i, check := range due {
+		wg.Add(1)
+		go func(i int, check types.ManagedCheck) {
+			defer wg.Done()
+			results[i] = mm.runCheck(check)
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// HasCheck reports whether a check of the given name exists
+func (mm *ManagedCheckMonitor) HasCheck(name string) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.index(name) >= 0
+}
+
+// RunCheck performs the named check on its own, also when it is paused
+func (mm *ManagedCheckMonitor) RunCheck(name string) (types.HTTPCheckResult, bool) {
+	check, err := mm.Get(name)
+	if err != nil {
+		return types.HTTPCheckResult{}, false
+	}
+	return mm.runCheck(check), true
+}
+
+// runCheck performs a single managed check
+func (mm *ManagedCheckMonitor) runCheck(check types.ManagedCheck) types.HTTPCheckResult {
+	var result types.HTTPCheckResult
+	switch check.Type {
+	case "http":
+		result = mm.httpMonitor.CheckEndpoint(managedHTTPCheck(check))
+	case "tcp":
+		// HTTP checks take their slot in CheckEndpoint, the others share the HTTP monitor's pool
+		host, _, _ := net.SplitHostPort(check.Target)
+		mm.httpMonitor.pool.Run(host, func() { result = checkTCP(check) })
+	case "dns":
+		// The target is the name to resolve, the load is on the resolver
+		mm.httpMonitor.pool.Run("", func() { result = checkDNS(check) })
+	}
+	result.Name = check.Name
+	result.Type = check.Type
+	return result
+}
+
+// managedHTTPCheck converts a managed HTTP check to the HTTP monitor's check definition
+func managedHTTPCheck(check types.ManagedCheck) types.HTTPCheck {
+	return types.HTTPCheck{
+		URL:            check.Target,
+		Method:         check.Method,
+		Timeout:        check.Timeout,
+		ExpectedStatus: check.ExpectedStatus,
+		CheckInterval:  check.Interval,
+	}
+}
+
+// checkTCP checks that a TCP connection to host:port can be opened
+func checkTCP(check types.ManagedCheck) types.HTTPCheckResult {
+	result := types.HTTPCheckResult{
+		URL:       "tcp://" + check.Target,
+		Timestamp: time.Now(),
+	}
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", check.Target, time.Duration(check.Timeout)*time.Second)
+	result.ResponseTime = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Sprintf("connection failed: %v", err)
+		return result
+	}
+	conn.Close()
+	result.Success = true
+	return result
+}
+
+// checkDNS checks that a host name resolves, and to the expected address if one is set. The
+// resolved addresses are reported as the check value.
+func checkDNS(check types.ManagedCheck) types.HTTPCheckResult {
+	result := types.HTTPCheckResult{
+		URL:       (&url.URL{Scheme: "dns", Host: check.Target}).String(),
+		Timestamp: time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(check.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, check.Target)
+	result.ResponseTime = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Sprintf("lookup failed: %v", err)
+		return result
+	}
+	sort.Strings(addresses)
+	result.Value = strings.Join(addresses, ",")
+
+	if check.Expected != "" && !containsAddress(addresses, check.Expected) {
+		result.Error = fmt.Sprintf("%s resolves to %s, expected %s", check.Target, result.Value, check.Expected)
+		return result
+	}
+	result.Success = true
+	return result
+}
+
+// containsAddress reports whether an IP address is in a list, comparing parsed addresses so
+// different notations of the same IPv6 address match
+func containsAddress(addresses []string, expected string) bool {
+	want := net.ParseIP(expected)
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip != nil && ip.Equal(want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package monitor
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+func TestManagedCheckMonitor_Lifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checks.json")
+	monitor := NewManagedCheckMonitor(path, NewHTTPMonitor())
+
+	created, err := monitor.Create(types.ManagedCheck{Name: "api", Type: "HTTP", Target: "https://example.com/health"})
+	if err != nil {
+		t.Fatalf("Failed to create check: %v", err)
+	}
+	if created.Type != "http" || created.Method != "GET" || created.ExpectedStatus != 200 || created.Interval != defaultManagedInterval {
+		t.Errorf("Expected defaults to be applied, got %+v", created)
+	}
+	if _, err := monitor.Create(types.ManagedCheck{Name: "api", Type: "tcp", Target: "db:5432"}); !errors.Is(err, ErrManagedCheckExists) {
+		t.Errorf("Expected duplicate name error, got %v", err)
+	}
+	if _, err := monitor.Create(types.ManagedCheck{Name: "db", Type: "tcp", Target: "db"}); !errors.Is(err, ErrInvalidManagedCheck) {
+		t.Errorf("Expected invalid check error for a TCP target without port, got %v", err)
+	}
+
+	if _, err := monitor.SetPaused("api", true); err != nil {
+		t.Fatalf("Failed to pause check: %v", err)
+	}
+	updated, err := monitor.Update("api", types.ManagedCheck{Type: "http", Target: "https://example.com/ready", Interval: 30})
+	if err != nil {
+		t.Fatalf("Failed to update check: %v", err)
+	}
+	if !updated.Paused || updated.Target != "https://example.com/ready" || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Expected update to keep the paused state and creation time, got %+v", updated)
+	}
+
+	// Checks are persisted and survive a restart
+	reloaded := NewManagedCheckMonitor(path, NewHTTPMonitor())
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to load checks: %v", err)
+	}
+	if checks := reloaded.List(); len(checks) != 1 || checks[0].Target != "https://example.com/ready" || !checks[0].Paused {
+		t.Errorf("Expected the updated check to be loaded, got %+v", checks)
+	}
+	if err := reloaded.Validate(); err != nil {
+		t.Errorf("Expected loaded checks to be valid, got %v", err)
+	}
+
+	if err := monitor.Delete("api"); err != nil {
+		t.Fatalf("Failed to delete check: %v", err)
+	}
+	if err := monitor.Delete("api"); !errors.Is(err, ErrManagedCheckNotFound) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestManagedCheckMonitor_RunChecks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	monitor := NewManagedCheckMonitor(filepath.Join(t.TempDir(), "checks.json"), NewHTTPMonitor())
+	for _, check := range []types.ManagedCheck{
+		{Name: "web", Type: "http", Target: server.URL},
+		{Name: "port", Type: "tcp", Target: listener.Addr().String()},
+		{Name: "local", Type: "dns", Target: "localhost", Expected: "127.0.0.1"},
+		{Name: "paused", Type: "tcp", Target: listener.Addr().String()},
+	} {
+		if _, err := monitor.Create(check); err != nil {
+			t.Fatalf("Failed to create check %s: %v", check.Name, err)
+		}
+	}
+	if _, err := monitor.SetPaused("paused", true); err != nil {
+		t.Fatalf("Failed to pause check: %v", err)
+	}
+
+	results := monitor.RunChecks()
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results (paused check skipped), got %+v", results)
+	}
+	for _, result := range results {
+		if !result.Success {
+			t.Errorf("Expected %s check %s to succeed, got %s", result.Type, result.Name, result.Error)
+		}
+	}
+
+	// Checks only run again once their interval has elapsed
+	if results := monitor.RunChecks(); len(results) != 0 {
+		t.Errorf("Expected no checks to be due, got %+v", results)
+	}
+}
+
+func TestCheckTCP_Refused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	result := checkTCP(types.ManagedCheck{Name: "closed", Type: "tcp", Target: address, Timeout: 1})
+	if result.Success || result.Error == "" {
+		t.Errorf("Expected refused connection to fail, got %+v", result)
+	}
+}
+
+func TestValidateManagedCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		check types.ManagedCheck
+		valid bool
+	}{
+		{"valid dns", types.ManagedCheck{Name: "dns", Type: "dns", Target: "example.com", Expected: "93.184.216.34"}, true},
+		{"dns with scheme", types.ManagedCheck{Name: "dns", Type: "dns", Target: "https://example.com"}, false},
+		{"dns with invalid expected address", types.ManagedCheck{Name: "dns", Type: "dns", Target: "example.com", Expected: "example"}, false},
+		{"http without scheme", withDefaults(types.ManagedCheck{Name: "web", Type: "http", Target: "example.com"}), false},
+		{"name with slash", types.ManagedCheck{Name: "a/b", Type: "tcp", Target: "db:5432"}, false},
+		{"unknown type", types.ManagedCheck{Name: "x", Type: "icmp", Target: "example.com"}, false},
+		{"alert channel", types.ManagedCheck{Name: "db", Type: "tcp", Target: "db:5432", Alert: types.AlertTarget{Channels: []string{"telegram"}}}, true},
+		{"unknown alert channel", types.ManagedCheck{Name: "db", Type: "tcp", Target: "db:5432", Alert: types.AlertTarget{Channels: []string{"sms"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateManagedCheck(tt.check)
+			if (err == nil) != tt.valid {
+				t.Errorf("Expected valid=%v, got error %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestManagedCheckMonitor_OnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checks.json")
+	monitor := NewManagedCheckMonitor(path, NewHTTPMonitor())
+	var got [][]types.ManagedCheck
+	monitor.OnChange(func(checks []types.ManagedCheck) {
+		got = append(got, checks)
+	})
+
+	alert := types.AlertTarget{TelegramChat: "dba-chat"}
+	if _, err := monitor.Create(types.ManagedCheck{Name: "db", Type: "tcp", Target: "db:5432", Alert: alert}); err != nil {
+		t.Fatalf("Failed to create check: %v", err)
+	}
+	if _, err := monitor.Create(types.ManagedCheck{Name: "db", Type: "tcp", Target: "db:5432"}); err == nil {
+		t.Fatal("Expected duplicate name error")
+	}
+	if _, err := monitor.Update("db", types.ManagedCheck{Type: "tcp", Target: "db:5433"}); err != nil {
+		t.Fatalf("Failed to update check: %v", err)
+	}
+	if err := monitor.Delete("db"); err != nil {
+		t.Fatalf("Failed to delete check: %v", err)
+	}
+
+	// Failed changes are not reported
+	if len(got) != 3 || len(got[0]) != 1 || got[0][0].Alert.TelegramChat != "dba-chat" || !got[1][0].Alert.IsZero() || len(got[2]) != 0 {
+		t.Errorf("Expected the checks after each change, got %+v", got)
+	}
+
+	reloaded := NewManagedCheckMonitor(path, NewHTTPMonitor())
+	loaded := false
+	reloaded.OnChange(func([]types.ManagedCheck) { loaded = true })
+	if err := reloaded.Load(); err != nil || !loaded {
+		t.Errorf("Expected Load to report the checks, got loaded=%v, err=%v", loaded, err)
+	}
+}
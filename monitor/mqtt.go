@@ -0,0 +1,325 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttSchemes are the supported broker URL schemes
+var mqttSchemes = map[string]bool{"tcp": true, "mqtt": true, "ssl": true, "tls": true, "mqtts": true, "ws": true, "wss": true}
+
+// mqttSubscription is a long-lived subscription of a check waiting for messages on its topic
+type mqttSubscription struct {
+	client      mqtt.Client
+	since       time.Time // Start of the subscription
+	lastMessage time.Time
+	lastError   error // Why the connection was lost
+}
+
+// MQTTMonitor checks MQTT brokers: connectivity, a canary round trip through a topic, or
+// messages published by other clients (e.g. IoT devices) arriving within a window
+type MQTTMonitor struct {
+	checks        []types.MQTTCheck
+	mu            sync.Mutex
+	subscriptions map[string]*mqttSubscription
+}
+
+// NewMQTTMonitor creates a new MQTT monitor instance
+func NewMQTTMonitor(checks []types.MQTTCheck) *MQTTMonitor {
+	return &MQTTMonitor{
+		checks:        checks,
+		subscriptions: make(map[string]*mqttSubscription),
+	}
+}
+
+// Name returns the monitor name used in logs
+func (mm *MQTTMonitor) Name() string {
+	return "MQTT"
+}
+
+// Interval returns how often the configured checks should run
+func (mm *MQTTMonitor) Interval() time.Duration {
+	intervals := make([]int, 0, len(mm.checks))
+	for _, check := range mm.checks {
+		intervals = append(intervals, check.CheckInterval)
+	}
+	return shortestInterval(intervals)
+}
+
+// RunChecks checks all configured brokers
+func (mm *MQTTMonitor) RunChecks() []types.HTTPCheckResult {
+	results := make([]types.HTTPCheckResult, 0, len(mm.checks))
+	for _, check := range mm.checks {
+		results = append(results, mm.CheckBroker(check))
+	}
+	return results
+}
+
+// HasCheck reports whether a MQTT check of the given name is configured
+func (mm *MQTTMonitor) HasCheck(name string) bool {
+	for _, check := range mm.checks {
+		if check.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunCheck performs the named MQTT check on its own
+func (mm *MQTTMonitor) RunCheck(name string) (types.HTTPCheckResult, bool) {
+	for _, check := range mm.checks {
+		if check.Name == name {
+			return mm.CheckBroker(check), true
+		}
+	}
+	return types.HTTPCheckResult{}, false
+}
+
+// Validate validates all configured MQTT checks
+func (mm *MQTTMonitor) Validate() error {
+	for _, check := range mm.checks {
+		if err := mm.ValidateMQTTCheck(check); err != nil {
+			return fmt.Errorf("MQTT check %s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateMQTTCheck validates if an MQTT check configuration is valid
+func (mm *MQTTMonitor) ValidateMQTTCheck(check types.MQTTCheck) error {
+	if check.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	if check.Broker == "" {
+		return fmt.Errorf("broker cannot be empty")
+	}
+	broker, err := url.Parse(check.Broker)
+	if err != nil || broker.Host == "" {
+		return fmt.Errorf("invalid broker URL %q (expected e.g. tcp://host:1883)", check.Broker)
+	}
+	if !mqttSchemes[broker.Scheme] {
+		return fmt.Errorf("unsupported broker scheme %q (supported: tcp, ssl, ws, wss)", broker.Scheme)
+	}
+
+	if check.QoS < 0 || check.QoS > 2 {
+		return fmt.Errorf("QoS must be 0, 1 or 2")
+	}
+
+	if check.Topic == "" && (check.Publish || check.Window != 0) {
+		return fmt.Errorf("publish and window require a topic")
+	}
+	if check.Publish && strings.ContainsAny(check.Topic, "+#") {
+		return fmt.Errorf("canary topic cannot contain wildcards")
+	}
+	if check.Topic != "" && !check.Publish && check.Window <= 0 {
+		return fmt.Errorf("window must be positive when waiting for messages")
+	}
+
+	if check.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	if check.CheckInterval < 0 {
+		return fmt.Errorf("check interval cannot be negative")
+	}
+
+	return nil
+}
+
+// CheckBroker runs an MQTT check: without a topic only the connection is checked, with Publish a
+// canary message has to make the round trip, otherwise a message must have arrived within the window
+func (mm *MQTTMonitor) CheckBroker(check types.MQTTCheck) types.HTTPCheckResult {
+	result := types.HTTPCheckResult{
+		Name:      check.Name,
+		Type:      "mqtt",
+		URL:       check.Broker,
+		Timestamp: time.Now(),
+	}
+	if check.Topic != "" {
+		result.URL = strings.TrimSuffix(check.Broker, "/") + "/" + check.Topic
+	}
+
+	if check.Topic != "" && !check.Publish {
+		mm.checkMessages(check, &result)
+		return result
+	}
+
+	timeout := mqttTimeout(check)
+	start := time.Now()
+	client := mqtt.NewClient(mqttClientOptions(check, timeout))
+	if err := waitToken(client.Connect(), timeout); err != nil {
+		result.Error = fmt.Sprintf("failed to connect: %v", err)
+		return result
+	}
+	defer client.Disconnect(250)
+
+	if check.Publish {
+		if err := publishCanary(client, check, timeout); err != nil {
+			result.Error = err.Error()
+			result.ResponseTime = time.Since(start)
+			return result
+		}
+	}
+
+	result.ResponseTime = time.Since(start)
+	result.Success = true
+	return result
+}
+
+// publishCanary publishes a unique message to the topic of a check and waits until it is received back
+func publishCanary(client mqtt.Client, check types.MQTTCheck, timeout time.Duration) error {
+	payload := "monic canary " + strconv.FormatInt(time.Now().UnixNano(), 10)
+	received := make(chan struct{}, 1)
+	handler := func(_ mqtt.Client, message mqtt.Message) {
+		if string(message.Payload()) == payload {
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	if err := waitToken(client.Subscribe(check.Topic, byte(check.QoS), handler), timeout); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", check.Topic, err)
+	}
+	defer client.Unsubscribe(check.Topic)
+
+	if err := waitToken(client.Publish(check.Topic, byte(check.QoS), false, payload), timeout); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", check.Topic, err)
+	}
+
+	select {
+	case <-received:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("canary message not received on %s within %s", check.Topic, timeout)
+	}
+}
+
+// checkMessages fails the result when the topic of a check had no message within its window.
+// The subscription is kept between checks, and the window of a new subscription starts when it is made.
+func (mm *MQTTMonitor) checkMessages(check types.MQTTCheck, result *types.HTTPCheckResult) {
+	sub, err := mm.subscribe(check)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if !sub.client.IsConnectionOpen() {
+		if sub.lastError != nil {
+			result.Error = fmt.Sprintf("connection to broker lost: %v", sub.lastError)
+		} else {
+			result.Error = "not connected to broker"
+		}
+		return
+	}
+
+	window := time.Duration(check.Window) * time.Second
+	last, received := sub.lastMessage, !sub.lastMessage.IsZero()
+	if !received {
+		last = sub.since
+	}
+	if since := result.Timestamp.Sub(last); since > window {
+		if received {
+			result.Error = fmt.Sprintf("missing messages: nothing on %s for %s (last at %s)", check.Topic, since.Round(time.Second), last.Format(time.RFC3339))
+		} else {
+			result.Error = fmt.Sprintf("missing messages: nothing on %s since subscribing %s ago", check.Topic, since.Round(time.Second))
+		}
+		return
+	}
+	result.Success = true
+}
+
+// subscribe returns the subscription of a check, connecting and subscribing on first use.
+// The client reconnects (and subscribes again) on its own when the connection is lost.
+func (mm *MQTTMonitor) subscribe(check types.MQTTCheck) (*mqttSubscription, error) {
+	mm.mu.Lock()
+	sub, exists := mm.subscriptions[check.Name]
+	mm.mu.Unlock()
+	if exists {
+		return sub, nil
+	}
+
+	timeout := mqttTimeout(check)
+	sub = &mqttSubscription{}
+	handler := func(_ mqtt.Client, _ mqtt.Message) {
+		mm.mu.Lock()
+		sub.lastMessage = time.Now()
+		mm.mu.Unlock()
+	}
+
+	options := mqttClientOptions(check, timeout).
+		SetAutoReconnect(true).
+		SetOnConnectHandler(func(client mqtt.Client) {
+			// Subscriptions do not survive a clean session
+			client.Subscribe(check.Topic, byte(check.QoS), handler)
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			mm.mu.Lock()
+			sub.lastError = err
+			mm.mu.Unlock()
+		})
+	sub.client = mqtt.NewClient(options)
+	if err := waitToken(sub.client.Connect(), timeout); err != nil {
+		sub.client.Disconnect(0)
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	sub.since = time.Now()
+
+	mm.mu.Lock()
+	mm.subscriptions[check.Name] = sub
+	mm.mu.Unlock()
+	return sub, nil
+}
+
+// mqttTimeout returns the timeout of a check
+func mqttTimeout(check types.MQTTCheck) time.Duration {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 10 // Default to 10 seconds
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// mqttClientOptions creates the client options of a check
+func mqttClientOptions(check types.MQTTCheck, timeout time.Duration) *mqtt.ClientOptions {
+	clientID := check.ClientID
+	if clientID == "" {
+		// Client IDs must be unique per broker, so include the host
+		host, _ := os.Hostname()
+		clientID = "monic-" + host + "-" + check.Name
+	}
+
+	return mqtt.NewClientOptions().
+		AddBroker(check.Broker).
+		SetClientID(clientID).
+		SetUsername(check.Username).
+		SetPassword(check.Password).
+		SetTLSConfig(&tls.Config{InsecureSkipVerify: check.InsecureSkipVerify}).
+		SetConnectTimeout(timeout).
+		SetWriteTimeout(timeout).
+		SetAutoReconnect(false).
+		SetCleanSession(true)
+}
+
+// waitToken waits for an MQTT operation to complete
+func waitToken(token mqtt.Token, timeout time.Duration) error {
+	if !token.WaitTimeout(timeout) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return token.Error()
+}
@@ -0,0 +1,237 @@
+package monitor
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+)
+
+// fakeMQTTBroker is a minimal MQTT 3.1.1 broker delivering messages to exact topic subscriptions
+type fakeMQTTBroker struct {
+	addr     string
+	password string // Required password, if set
+	mu       sync.Mutex
+	subs     map[net.Conn][]string
+	writeMu  map[net.Conn]*sync.Mutex
+}
+
+// startFakeMQTTBroker starts a TCP broker on a random local port
+func startFakeMQTTBroker(t *testing.T, password string) *fakeMQTTBroker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	broker := &fakeMQTTBroker{
+		addr:     "tcp://" + listener.Addr().String(),
+		password: password,
+		subs:     make(map[net.Conn][]string),
+		writeMu:  make(map[net.Conn]*sync.Mutex),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go broker.serve(conn)
+		}
+	}()
+	return broker
+}
+
+// serve handles the packets of a client connection
+func (b *fakeMQTTBroker) serve(conn net.Conn) {
+	b.mu.Lock()
+	b.writeMu[conn] = &sync.Mutex{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs, conn)
+		b.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		switch p := packet.(type) {
+		case *packets.ConnectPacket:
+			ack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket)
+			if b.password != "" && string(p.Password) != b.password {
+				ack.ReturnCode = packets.ErrRefusedBadUsernameOrPassword
+			}
+			b.write(conn, ack)
+		case *packets.SubscribePacket:
+			b.mu.Lock()
+			b.subs[conn] = append(b.subs[conn], p.Topics...)
+			b.mu.Unlock()
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = p.Qoss
+			b.write(conn, ack)
+		case *packets.UnsubscribePacket:
+			ack := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket)
+			ack.MessageID = p.MessageID
+			b.write(conn, ack)
+		case *packets.PublishPacket:
+			if p.Qos == 1 {
+				ack := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				ack.MessageID = p.MessageID
+				b.write(conn, ack)
+			}
+			b.publish(p.TopicName, string(p.Payload))
+		case *packets.PingreqPacket:
+			b.write(conn, packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+// publish delivers a message (QoS 0) to all clients subscribed to the topic
+func (b *fakeMQTTBroker) publish(topic, payload string) {
+	b.mu.Lock()
+	var targets []net.Conn
+	for conn, topics := range b.subs {
+		for _, subscribed := range topics {
+			if subscribed == topic {
+				targets = append(targets, conn)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for _, conn := range targets {
+		message := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+		message.TopicName = topic
+		message.Payload = []byte(payload)
+		b.write(conn, message)
+	}
+}
+
+// write sends a packet, serialized per connection
+func (b *fakeMQTTBroker) write(conn net.Conn, packet packets.ControlPacket) {
+	b.mu.Lock()
+	mu := b.writeMu[conn]
+	b.mu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+	packet.Write(conn)
+}
+
+func TestMQTTMonitor_CheckBroker_Connect(t *testing.T) {
+	broker := startFakeMQTTBroker(t, "secret")
+	monitor := NewMQTTMonitor(nil)
+
+	result := monitor.CheckBroker(types.MQTTCheck{Name: "broker", Broker: broker.addr, Username: "monic", Password: "secret", Timeout: 2})
+	if !result.Success {
+		t.Fatalf("Expected success, got error: %s", result.Error)
+	}
+	if result.Type != "mqtt" || result.URL != broker.addr {
+		t.Errorf("Unexpected result identity: %+v", result)
+	}
+
+	result = monitor.CheckBroker(types.MQTTCheck{Name: "broker", Broker: broker.addr, Username: "monic", Password: "wrong", Timeout: 2})
+	if result.Success || !strings.HasPrefix(result.Error, "failed to connect") {
+		t.Errorf("Expected a connection failure, got %+v", result)
+	}
+}
+
+func TestMQTTMonitor_CheckBroker_Canary(t *testing.T) {
+	broker := startFakeMQTTBroker(t, "")
+	monitor := NewMQTTMonitor(nil)
+
+	result := monitor.CheckBroker(types.MQTTCheck{Name: "canary", Broker: broker.addr, Topic: "monic/canary", Publish: true, QoS: 1, Timeout: 2})
+	if !result.Success {
+		t.Fatalf("Expected the canary to make the round trip, got error: %s", result.Error)
+	}
+	if result.URL != broker.addr+"/monic/canary" {
+		t.Errorf("Unexpected URL: %s", result.URL)
+	}
+}
+
+func TestMQTTMonitor_CheckMessages(t *testing.T) {
+	broker := startFakeMQTTBroker(t, "")
+	monitor := NewMQTTMonitor(nil)
+	check := types.MQTTCheck{Name: "sensors", Broker: broker.addr, Topic: "sensors/temperature", Window: 60, Timeout: 2}
+
+	// A new subscription is within its window
+	result := monitor.CheckBroker(check)
+	if !result.Success {
+		t.Fatalf("Expected success right after subscribing, got error: %s", result.Error)
+	}
+
+	sub := monitor.subscriptions["sensors"]
+	monitor.mu.Lock()
+	sub.since = time.Now().Add(-2 * time.Minute)
+	monitor.mu.Unlock()
+
+	result = monitor.CheckBroker(check)
+	if result.Success || !strings.Contains(result.Error, "nothing on sensors/temperature since subscribing") {
+		t.Errorf("Expected missing messages, got %+v", result)
+	}
+
+	// Wait for the subscription to reach the broker before publishing
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		broker.publish("sensors/temperature", "21.5")
+		monitor.mu.Lock()
+		received := !sub.lastMessage.IsZero()
+		monitor.mu.Unlock()
+		if received {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the message")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	result = monitor.CheckBroker(check)
+	if !result.Success {
+		t.Errorf("Expected success after a message, got error: %s", result.Error)
+	}
+	sub.client.Disconnect(0)
+}
+
+func TestMQTTMonitor_Validate(t *testing.T) {
+	monitor := NewMQTTMonitor(nil)
+
+	tests := []struct {
+		name    string
+		check   types.MQTTCheck
+		wantErr bool
+	}{
+		{"valid connect", types.MQTTCheck{Name: "broker", Broker: "tcp://mqtt:1883"}, false},
+		{"valid canary", types.MQTTCheck{Name: "broker", Broker: "ssl://mqtt:8883", Topic: "monic/canary", Publish: true}, false},
+		{"valid messages", types.MQTTCheck{Name: "broker", Broker: "tcp://mqtt:1883", Topic: "sensors/+/temperature", Window: 300}, false},
+		{"missing broker", types.MQTTCheck{Name: "broker"}, true},
+		{"unknown scheme", types.MQTTCheck{Name: "broker", Broker: "http://mqtt:1883"}, true},
+		{"invalid QoS", types.MQTTCheck{Name: "broker", Broker: "tcp://mqtt:1883", QoS: 3}, true},
+		{"publish without topic", types.MQTTCheck{Name: "broker", Broker: "tcp://mqtt:1883", Publish: true}, true},
+		{"canary wildcard", types.MQTTCheck{Name: "broker", Broker: "tcp://mqtt:1883", Topic: "monic/#", Publish: true}, true},
+		{"messages without window", types.MQTTCheck{Name: "broker", Broker: "tcp://mqtt:1883", Topic: "sensors"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := monitor.ValidateMQTTCheck(tt.check)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMQTTCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	ntpPacketSize = 48
+	// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and the Unix epoch (1970)
+	ntpEpochOffset = 2208988800
+	// defaultNTPMaxDrift is the default allowed clock offset in milliseconds
+	defaultNTPMaxDrift = 500
+)
+
+// NTPMonitor queries NTP servers and alerts when the host clock drifts too far
+type NTPMonitor struct {
+	checks []types.NTPCheck
+}
+
+// NewNTPMonitor creates a new NTP monitor instance
+func NewNTPMonitor(checks []types.NTPCheck) *NTPMonitor {
+	return &NTPMonitor{
+		checks: checks,
+	}
+}
+
+// Name returns the monitor name used in logs
+func (nm *NTPMonitor) Name() string {
+	return "NTP"
+}
+
+// Interval returns how often the configured checks should run
+func (nm *NTPMonitor) Interval() time.Duration {
+	intervals := make([]int, 0, len(nm.checks))
+	for _, check := range nm.checks {
+		intervals = append(intervals, check.CheckInterval)
+	}
+	return shortestInterval(intervals)
+}
+
+// RunChecks performs all configured NTP drift checks
+func (nm *NTPMonitor) RunChecks() []types.HTTPCheckResult {
+	results := make([]types.HTTPCheckResult, 0, len(nm.checks))
+	for _, check := range nm.checks {
+		results = append(results, nm.CheckDrift(check))
+	}
+	return results
+}
+
+// HasCheck reports whether a NTP check of the given name is configured
+func (nm *NTPMonitor) HasCheck(name string) bool {
+	for _, check := range nm.checks {
+		if check.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunCheck performs the named NTP check on its own
+func (nm *NTPMonitor) RunCheck(name string) (types.HTTPCheckResult, bool) {
+	for _, check := range nm.checks {
+		if check.Name == name {
+			return nm.CheckDrift(check), true
+		}
+	}
+	return types.HTTPCheckResult{}, false
+}
+
+// Validate validates all configured NTP checks
+func (nm *NTPMonitor) Validate() error {
+	for _, check := range nm.checks {
+		if err := nm.ValidateNTPCheck(check); err != nil {
+			return fmt.Errorf("NTP check %s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateNTPCheck validates if an NTP check configuration is valid
+func (nm *NTPMonitor) ValidateNTPCheck(check types.NTPCheck) error {
+	if check.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	if check.Server == "" {
+		return fmt.Errorf("server cannot be empty")
+	}
+
+	if check.MaxDrift < 0 {
+		return fmt.Errorf("max drift cannot be negative")
+	}
+
+	if check.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	if check.CheckInterval < 0 {
+		return fmt.Errorf("check interval cannot be negative")
+	}
+
+	return nil
+}
+
+// CheckDrift queries the NTP server and fails when the clock offset exceeds the allowed drift
+func (nm *NTPMonitor) CheckDrift(check types.NTPCheck) types.HTTPCheckResult {
+	addr := check.Server
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "123")
+	}
+
+	result := types.HTTPCheckResult{
+		Name:      check.Name,
+		Type:      "ntp",
+		URL:       "ntp://" + addr,
+		Timestamp: time.Now(),
+	}
+
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 5 // Default to 5 seconds
+	}
+
+	maxDrift := check.MaxDrift
+	if maxDrift <= 0 {
+		maxDrift = defaultNTPMaxDrift
+	}
+
+	offset, delay, err := queryNTP(addr, time.Duration(timeout)*time.Second)
+	result.ResponseTime = delay
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	drift := math.Abs(float64(offset) / float64(time.Millisecond))
+	if drift > float64(maxDrift) {
+		result.Error = fmt.Sprintf("clock drift of %.0fms exceeds %dms (offset %s)", drift, maxDrift, offset.Round(time.Millisecond))
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// queryNTP sends a single SNTP request and returns the clock offset (positive when the
+// local clock is behind the server) and the round-trip delay
+func queryNTP(addr string, timeout time.Duration) (time.Duration, time.Duration, error) {
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		return 0, 0, fmt.Errorf("connection failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, 0, fmt.Errorf("failed to set deadline: %v", err)
+	}
+
+	// LI = 0 (no warning), VN = 4, Mode = 3 (client)
+	request := make([]byte, ntpPacketSize)
+	request[0] = 0x23
+
+	// The transmit timestamp is echoed back as the origin timestamp, which ties the reply to this request
+	sent := time.Now()
+	binary.BigEndian.PutUint64(request[40:], toNTPTime(sent))
+	if _, err := conn.Write(request); err != nil {
+		return 0, 0, fmt.Errorf("failed to send request: %v", err)
+	}
+
+	response := make([]byte, ntpPacketSize)
+	n, err := conn.Read(response)
+	received := time.Now()
+	if err != nil {
+		return 0, received.Sub(sent), fmt.Errorf("no response: %v", err)
+	}
+	if n < ntpPacketSize {
+		return 0, received.Sub(sent), fmt.Errorf("short response: %d bytes", n)
+	}
+
+	if mode := response[0] & 0x07; mode != 4 {
+		return 0, received.Sub(sent), fmt.Errorf("unexpected NTP mode: %d", mode)
+	}
+	if stratum := response[1]; stratum == 0 {
+		return 0, received.Sub(sent), fmt.Errorf("server sent kiss-of-death: %s", string(response[12:16]))
+	}
+	if binary.BigEndian.Uint64(response[24:]) != binary.BigEndian.Uint64(request[40:]) {
+		return 0, received.Sub(sent), fmt.Errorf("response does not match request")
+	}
+
+	serverReceive := fromNTPTime(binary.BigEndian.Uint64(response[32:]))
+	serverTransmit := fromNTPTime(binary.BigEndian.Uint64(response[40:]))
+
+	offset := (serverReceive.Sub(sent) + serverTransmit.Sub(received)) / 2
+	delay := received.Sub(sent) - serverTransmit.Sub(serverReceive)
+	if delay < 0 {
+		delay = 0
+	}
+	return offset, delay, nil
+}
+
+// toNTPTime converts a time to a 64-bit NTP timestamp (32-bit seconds, 32-bit fraction)
+func toNTPTime(t time.Time) uint64 {
+	nanos := uint64(t.UnixNano()) + ntpEpochOffset*uint64(time.Second)
+	seconds := nanos / uint64(time.Second)
+	fraction := (nanos % uint64(time.Second)) << 32 / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+// fromNTPTime converts a 64-bit NTP timestamp to a time
+func fromNTPTime(ts uint64) time.Time {
+	seconds := int64(ts>>32) - ntpEpochOffset
+	nanos := (ts & 0xffffffff) * uint64(time.Second) >> 32
+	return time.Unix(seconds, int64(nanos))
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// startFakeNTPServer starts a UDP server answering SNTP requests with a clock shifted by skew
+func startFakeNTPServer(t *testing.T, skew time.Duration, stratum byte) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, ntpPacketSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < ntpPacketSize {
+				continue
+			}
+
+			response := make([]byte, ntpPacketSize)
+			response[0] = 0x24 // VN = 4, Mode = 4 (server)
+			response[1] = stratum
+			copy(response[12:16], "RATE")
+			copy(response[24:32], buf[40:48])
+			now := toNTPTime(time.Now().Add(skew))
+			binary.BigEndian.PutUint64(response[32:], now)
+			binary.BigEndian.PutUint64(response[40:], now)
+			conn.WriteTo(response, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestNTPTimeConversion(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC)
+	converted := fromNTPTime(toNTPTime(now))
+	if diff := converted.Sub(now); diff < -time.Microsecond || diff > time.Microsecond {
+		t.Errorf("Expected round-trip within 1µs, got %s", diff)
+	}
+}
+
+func TestNTPMonitor_CheckDrift_InSync(t *testing.T) {
+	addr := startFakeNTPServer(t, 0, 2)
+	monitor := NewNTPMonitor(nil)
+
+	result := monitor.CheckDrift(types.NTPCheck{Name: "pool", Server: addr, MaxDrift: 100, Timeout: 2})
+	if !result.Success {
+		t.Fatalf("Expected success, got error: %s", result.Error)
+	}
+	if result.Type != "ntp" || result.URL != "ntp://"+addr {
+		t.Errorf("Unexpected result identity: %+v", result)
+	}
+}
+
+func TestNTPMonitor_CheckDrift_Skewed(t *testing.T) {
+	addr := startFakeNTPServer(t, 2*time.Second, 2)
+	monitor := NewNTPMonitor(nil)
+
+	result := monitor.CheckDrift(types.NTPCheck{Name: "pool", Server: addr, MaxDrift: 500, Timeout: 2})
+	if result.Success {
+		t.Fatal("Expected failure for a 2s clock drift")
+	}
+	if !strings.Contains(result.Error, "exceeds 500ms") {
+		t.Errorf("Unexpected error: %s", result.Error)
+	}
+}
+
+func TestNTPMonitor_CheckDrift_KissOfDeath(t *testing.T) {
+	addr := startFakeNTPServer(t, 0, 0)
+	monitor := NewNTPMonitor(nil)
+
+	result := monitor.CheckDrift(types.NTPCheck{Name: "pool", Server: addr, Timeout: 2})
+	if result.Success || !strings.Contains(result.Error, "kiss-of-death: RATE") {
+		t.Errorf("Expected kiss-of-death error, got %+v", result)
+	}
+}
+
+func TestNTPMonitor_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   types.NTPCheck
+		wantErr bool
+	}{
+		{"valid", types.NTPCheck{Name: "pool", Server: "pool.ntp.org"}, false},
+		{"missing name", types.NTPCheck{Server: "pool.ntp.org"}, true},
+		{"missing server", types.NTPCheck{Name: "pool"}, true},
+		{"negative drift", types.NTPCheck{Name: "pool", Server: "pool.ntp.org", MaxDrift: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewNTPMonitor([]types.NTPCheck{tt.check}).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
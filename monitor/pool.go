@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxConcurrentChecks is the number of checks that run at once when no limit is configured
+const DefaultMaxConcurrentChecks = 50
+
+// CheckPool limits how many checks run at once, overall and against a single host, so hundreds
+// of checks don't overwhelm this host or their targets. Checks beyond the limits wait for a
+// free slot. A nil pool runs every check right away.
+type CheckPool struct {
+	slots   chan struct{}
+	perHost int // Checks against one host at once, 0 for no limit
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+// NewCheckPool creates a pool running up to maxConcurrent checks at once (default:
+// DefaultMaxConcurrentChecks) and up to maxPerHost of them against the same host (0 for no
+// per-host limit)
+func NewCheckPool(maxConcurrent, maxPerHost int) *CheckPool {
+	if maxConcurrent <= 0 {
+		maxConcurrent = DefaultMaxConcurrentChecks
+	}
+	if maxPerHost < 0 {
+		maxPerHost = 0
+	}
+	return &CheckPool{
+		slots:   make(chan struct{}, maxConcurrent),
+		perHost: maxPerHost,
+		hosts:   make(map[string]chan struct{}),
+	}
+}
+
+// Size returns the number of checks that run at once, 0 for a nil pool
+func (p *CheckPool) Size() int {
+	if p == nil {
+		return 0
+	}
+	return cap(p.slots)
+}
+
+// Run runs a check against a host once the pool has a free slot for it. The host slot is
+// taken first, so checks waiting for a busy host don't hold slots other hosts could use.
+func (p *CheckPool) Run(host string, check func()) {
+	if p == nil {
+		check()
+		return
+	}
+
+	if hostSlots := p.hostSlots(host); hostSlots != nil {
+		hostSlots <- struct{}{}
+		defer func() { <-hostSlots }()
+	}
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	check()
+}
+
+// hostSlots returns the slots of a host, nil without a per-host limit
+func (p *CheckPool) hostSlots(host string) chan struct{} {
+	if p.perHost == 0 || host == "" {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	host = strings.ToLower(host)
+	if p.hosts[host] == nil {
+		p.hosts[host] = make(chan struct{}, p.perHost)
+	}
+	return p.hosts[host]
+}
+
+// urlHost returns the host name of a URL, empty if it cannot be parsed
+func urlHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Hostname()
+}
@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// runConcurrently runs checks against the hosts through the pool and returns the highest number
+// of checks that ran at once, overall and per host
+func runConcurrently(pool *CheckPool, hosts []string) (int32, map[string]int32) {
+	var running, peak int32
+	var mu sync.Mutex
+	hostRunning := make(map[string]int32)
+	hostPeak := make(map[string]int32)
+
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			pool.Run(host, func() {
+				mu.Lock()
+				hostRunning[host]++
+				hostPeak[host] = max(hostPeak[host], hostRunning[host])
+				mu.Unlock()
+				n := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+
+				time.Sleep(10 * time.Millisecond)
+
+				atomic.AddInt32(&running, -1)
+				mu.Lock()
+				hostRunning[host]--
+				mu.Unlock()
+			})
+		}(host)
+	}
+	wg.Wait()
+	return peak, hostPeak
+}
+
+func TestCheckPool_Limits(t *testing.T) {
+	var hosts []string
+	for i := 0; i < 20; i++ {
+		hosts = append(hosts, fmt.Sprintf("host-%d", i%4))
+	}
+
+	peak, hostPeak := runConcurrently(NewCheckPool(5, 0), hosts)
+	if peak > 5 || peak < 2 {
+		t.Errorf("Expected up to 5 checks at once, got %d", peak)
+	}
+
+	peak, hostPeak = runConcurrently(NewCheckPool(10, 1), hosts)
+	if peak > 4 {
+		t.Errorf("Expected at most one check per host at once, got %d overall", peak)
+	}
+	for host, n := range hostPeak {
+		if n != 1 {
+			t.Errorf("Expected one check against %s at once, got %d", host, n)
+		}
+	}
+
+	// A nil pool runs every check right away
+	peak, _ = runConcurrently(nil, hosts)
+	if peak < 10 {
+		t.Errorf("Expected checks without a pool to run at once, got %d", peak)
+	}
+}
+
+func TestNewCheckPool_Defaults(t *testing.T) {
+	if size := NewCheckPool(0, 0).Size(); size != DefaultMaxConcurrentChecks {
+		t.Errorf("Expected the default pool size, got %d", size)
+	}
+	if size := (*CheckPool)(nil).Size(); size != 0 {
+		t.Errorf("Expected no size of a nil pool, got %d", size)
+	}
+}
+
+func TestHTTPMonitor_CheckEndpointsConcurrent_Pool(t *testing.T) {
+	var running, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	monitor := NewHTTPMonitor()
+	monitor.SetPool(NewCheckPool(3, 0))
+
+	var checks []types.HTTPCheck
+	for i := 0; i < 12; i++ {
+		checks = append(checks, types.HTTPCheck{
+			Name:           fmt.Sprintf("check-%d", i),
+			URL:            server.URL,
+			Method:         "GET",
+			Timeout:        5,
+			ExpectedStatus: 200,
+		})
+	}
+
+	results := monitor.CheckEndpointsConcurrent(checks)
+	if len(results) != len(checks) {
+		t.Fatalf("Expected %d results, got %d", len(checks), len(results))
+	}
+	for i, result := range results {
+		if result.Name != checks[i].Name || !result.Success {
+			t.Errorf("Expected a successful result of %s, got %+v", checks[i].Name, result)
+		}
+	}
+	if peak > 3 {
+		t.Errorf("Expected at most 3 requests at once, got %d", peak)
+	}
+}
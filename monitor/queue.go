@@ -0,0 +1,237 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// rabbitMQQueue is the part of a queue of the RabbitMQ management API used by the checks
+type rabbitMQQueue struct {
+	Messages               int64 `json:"messages"`
+	MessagesReady          int64 `json:"messages_ready"`
+	MessagesUnacknowledged int64 `json:"messages_unacknowledged"`
+	Consumers              int   `json:"consumers"`
+}
+
+// QueueMonitor checks the backlog of message queues: RabbitMQ queue depths and Kafka consumer lag
+type QueueMonitor struct {
+	checks []types.QueueCheck
+}
+
+// NewQueueMonitor creates a new queue monitor instance
+func NewQueueMonitor(checks []types.QueueCheck) *QueueMonitor {
+	return &QueueMonitor{
+		checks: checks,
+	}
+}
+
+// Name returns the monitor name used in logs
+func (qm *QueueMonitor) Name() string {
+	return "Queue"
+}
+
+// Interval returns how often the configured checks should run
+func (qm *QueueMonitor) Interval() time.Duration {
+	intervals := make([]int, 0, len(qm.checks))
+	for _, check := range qm.checks {
+		intervals = append(intervals, check.CheckInterval)
+	}
+	return shortestInterval(intervals)
+}
+
+// RunChecks checks all configured queues
+func (qm *QueueMonitor) RunChecks() []types.HTTPCheckResult {
+	results := make([]types.HTTPCheckResult, 0, len(qm.checks))
+	for _, check := range qm.checks {
+		results = append(results, qm.CheckBacklog(check))
+	}
+	return results
+}
+
+// HasCheck reports whether a queue check of the given name is configured
+func (qm *QueueMonitor) HasCheck(name string) bool {
+	for _, check := range qm.checks {
+		if check.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunCheck performs the named queue check on its own
+func (qm *QueueMonitor) RunCheck(name string) (types.HTTPCheckResult, bool) {
+	for _, check := range qm.checks {
+		if check.Name == name {
+			return qm.CheckBacklog(check), true
+		}
+	}
+	return types.HTTPCheckResult{}, false
+}
+
+// Validate validates all configured queue checks
+func (qm *QueueMonitor) Validate() error {
+	for _, check := range qm.checks {
+		if err := qm.ValidateQueueCheck(check); err != nil {
+			return fmt.Errorf("queue check %s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateQueueCheck validates if a queue check configuration is valid
+func (qm *QueueMonitor) ValidateQueueCheck(check types.QueueCheck) error {
+	if check.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	switch check.Type {
+	case "rabbitmq":
+		if check.URL == "" {
+			return fmt.Errorf("RabbitMQ management URL cannot be empty")
+		}
+		if parsed, err := url.Parse(check.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid RabbitMQ management URL %q", check.URL)
+		}
+		if check.Queue == "" {
+			return fmt.Errorf("queue cannot be empty")
+		}
+	case "kafka":
+		if len(check.Brokers) == 0 {
+			return fmt.Errorf("Kafka brokers cannot be empty")
+		}
+		for _, broker := range check.Brokers {
+			if _, _, err := net.SplitHostPort(broker); err != nil {
+				return fmt.Errorf("invalid Kafka broker %q (expected host:port)", broker)
+			}
+		}
+		if check.Group == "" {
+			return fmt.Errorf("consumer group cannot be empty")
+		}
+	default:
+		return fmt.Errorf("unsupported queue type %q (supported: rabbitmq, kafka)", check.Type)
+	}
+
+	if check.MaxBacklog <= 0 {
+		return fmt.Errorf("max backlog must be positive")
+	}
+
+	if check.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	if check.CheckInterval < 0 {
+		return fmt.Errorf("check interval cannot be negative")
+	}
+
+	return nil
+}
+
+// CheckBacklog measures the backlog of a queue and fails when it exceeds the limit
+func (qm *QueueMonitor) CheckBacklog(check types.QueueCheck) types.HTTPCheckResult {
+	result := types.HTTPCheckResult{
+		Name:      check.Name,
+		Type:      check.Type,
+		Timestamp: time.Now(),
+	}
+
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 10 // Default to 10 seconds
+	}
+
+	start := time.Now()
+	switch check.Type {
+	case "rabbitmq":
+		result.URL = strings.TrimSuffix(check.URL, "/") + "/#/queues/" + url.PathEscape(rabbitMQVHost(check)) + "/" + url.PathEscape(check.Queue)
+		queue, err := fetchRabbitMQQueue(check, time.Duration(timeout)*time.Second)
+		result.ResponseTime = time.Since(start)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Value = strconv.FormatInt(queue.Messages, 10)
+		if queue.Messages > check.MaxBacklog {
+			result.Error = fmt.Sprintf("backlog of %d messages exceeds limit %d (%d ready, %d unacknowledged, %d consumers)",
+				queue.Messages, check.MaxBacklog, queue.MessagesReady, queue.MessagesUnacknowledged, queue.Consumers)
+			return result
+		}
+	case "kafka":
+		result.URL = "kafka://" + strings.Join(check.Brokers, ",") + "/" + check.Group
+		if check.Topic != "" {
+			result.URL += "/" + check.Topic
+		}
+		lag, err := fetchKafkaLag(check, time.Duration(timeout)*time.Second)
+		result.ResponseTime = time.Since(start)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Value = strconv.FormatInt(lag.total, 10)
+		if lag.total > check.MaxBacklog {
+			result.Error = fmt.Sprintf("consumer lag of %d messages exceeds limit %d (most behind: %s partition %d with %d)",
+				lag.total, check.MaxBacklog, lag.topic, lag.partition, lag.max)
+			return result
+		}
+	}
+
+	result.Success = true
+	return result
+}
+
+// rabbitMQVHost returns the virtual host of a RabbitMQ check
+func rabbitMQVHost(check types.QueueCheck) string {
+	if check.VHost == "" {
+		return "/"
+	}
+	return check.VHost
+}
+
+// fetchRabbitMQQueue reads a queue from the RabbitMQ management API
+func fetchRabbitMQQueue(check types.QueueCheck, timeout time.Duration) (*rabbitMQQueue, error) {
+	// The default virtual host "/" has to be escaped as %2F
+	endpoint := strings.TrimSuffix(check.URL, "/") + "/api/queues/" + url.PathEscape(rabbitMQVHost(check)) + "/" + url.PathEscape(check.Queue)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if check.Username != "" {
+		req.SetBasicAuth(check.Username, check.Password)
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: check.InsecureSkipVerify},
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("management API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("queue %s not found in virtual host %s", check.Queue, rabbitMQVHost(check))
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("management API denied access (status %d)", resp.StatusCode)
+	default:
+		return nil, fmt.Errorf("management API returned status %d", resp.StatusCode)
+	}
+
+	var queue rabbitMQQueue
+	if err := json.NewDecoder(resp.Body).Decode(&queue); err != nil {
+		return nil, fmt.Errorf("failed to decode queue: %w", err)
+	}
+	return &queue, nil
+}
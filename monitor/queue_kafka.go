@@ -0,0 +1,383 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Kafka protocol versions used by the lag check. They are the newest non-flexible versions
+// supported from Kafka 0.11 up to 4.x, so responses have no tagged header fields.
+const (
+	kafkaMetadataVersion        = 4
+	kafkaFindCoordinatorVersion = 1
+	kafkaOffsetFetchVersion     = 3
+	kafkaListOffsetsVersion     = 2
+	kafkaSASLHandshakeVersion   = 1
+	kafkaSASLAuthVersion        = 0
+)
+
+// kafkaMaxResponseSize protects against huge allocations when the peer is not a Kafka broker
+const kafkaMaxResponseSize = 64 << 20
+
+// kafkaErrors names the error codes most likely returned to the lag check
+var kafkaErrors = map[int16]string{
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	6:  "NOT_LEADER_OR_FOLLOWER",
+	14: "COORDINATOR_LOAD_IN_PROGRESS",
+	15: "COORDINATOR_NOT_AVAILABLE",
+	16: "NOT_COORDINATOR",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+	30: "GROUP_AUTHORIZATION_FAILED",
+	31: "CLUSTER_AUTHORIZATION_FAILED",
+	33: "UNSUPPORTED_SASL_MECHANISM",
+	35: "UNSUPPORTED_VERSION",
+	58: "SASL_AUTHENTICATION_FAILED",
+}
+
+// kafkaError formats a Kafka error code
+func kafkaError(code int16) error {
+	if name, ok := kafkaErrors[code]; ok {
+		return fmt.Errorf("%s", name)
+	}
+	return fmt.Errorf("error code %d", code)
+}
+
+// kafkaConn is a connection to a Kafka broker issuing one request at a time
+type kafkaConn struct {
+	conn          net.Conn
+	formatter     *kmsg.RequestFormatter
+	correlationID int32
+}
+
+// kafkaLag is the lag of a consumer group and its most lagging partition
+type kafkaLag struct {
+	total     int64
+	topic     string
+	partition int32
+	max       int64
+}
+
+// dialKafka connects (and authenticates) to a broker; the whole check has to finish before the deadline
+func dialKafka(addr string, check types.QueueCheck, deadline time.Time) (*kafkaConn, error) {
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	var err error
+	if check.TLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host, InsecureSkipVerify: check.InsecureSkipVerify})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(deadline)
+
+	c := &kafkaConn{conn: conn, formatter: kmsg.NewRequestFormatter(kmsg.FormatterClientID("monic"))}
+	if check.Username != "" {
+		if err := c.authenticate(check.Username, check.Password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("SASL authentication failed: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// authenticate performs a SASL/PLAIN authentication
+func (c *kafkaConn) authenticate(username, password string) error {
+	handshake := kmsg.NewPtrSASLHandshakeRequest()
+	handshake.SetVersion(kafkaSASLHandshakeVersion)
+	handshake.Mechanism = "PLAIN"
+	resp, err := c.request(handshake)
+	if err != nil {
+		return err
+	}
+	if code := resp.(*kmsg.SASLHandshakeResponse).ErrorCode; code != 0 {
+		return kafkaError(code)
+	}
+
+	auth := kmsg.NewPtrSASLAuthenticateRequest()
+	auth.SetVersion(kafkaSASLAuthVersion)
+	auth.SASLAuthBytes = []byte("\x00" + username + "\x00" + password)
+	resp, err = c.request(auth)
+	if err != nil {
+		return err
+	}
+	if authResp := resp.(*kmsg.SASLAuthenticateResponse); authResp.ErrorCode != 0 {
+		if authResp.ErrorMessage != nil {
+			return fmt.Errorf("%s", *authResp.ErrorMessage)
+		}
+		return kafkaError(authResp.ErrorCode)
+	}
+	return nil
+}
+
+// request sends a request and reads its response
+func (c *kafkaConn) request(req kmsg.Request) (kmsg.Response, error) {
+	c.correlationID++
+	if _, err := c.conn.Write(c.formatter.AppendRequest(nil, req, c.correlationID)); err != nil {
+		return nil, err
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+		return nil, err
+	}
+	size := int32(binary.BigEndian.Uint32(header[:]))
+	if size < 4 || size > kafkaMaxResponseSize {
+		return nil, fmt.Errorf("invalid response size %d (not a Kafka broker?)", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, body); err != nil {
+		return nil, err
+	}
+	if id := int32(binary.BigEndian.Uint32(body)); id != c.correlationID {
+		return nil, fmt.Errorf("unexpected correlation ID %d", id)
+	}
+
+	resp := req.ResponseKind()
+	resp.SetVersion(req.GetVersion())
+	if err := resp.ReadFrom(body[4:]); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp, nil
+}
+
+// Close closes the connection
+func (c *kafkaConn) Close() error {
+	return c.conn.Close()
+}
+
+// kafkaBrokers keeps one connection per broker during a lag check
+type kafkaBrokers struct {
+	check    types.QueueCheck
+	deadline time.Time
+	conns    map[string]*kafkaConn
+}
+
+// get returns the connection to a broker, connecting on first use
+func (b *kafkaBrokers) get(addr string) (*kafkaConn, error) {
+	if conn, ok := b.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := dialKafka(addr, b.check, b.deadline)
+	if err != nil {
+		return nil, err
+	}
+	b.conns[addr] = conn
+	return conn, nil
+}
+
+// bootstrap returns the connection to the first reachable bootstrap broker
+func (b *kafkaBrokers) bootstrap() (*kafkaConn, error) {
+	var lastErr error
+	for _, addr := range b.check.Brokers {
+		conn, err := b.get(addr)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("no Kafka broker reachable: %w", lastErr)
+}
+
+// close closes all connections
+func (b *kafkaBrokers) close() {
+	for _, conn := range b.conns {
+		conn.Close()
+	}
+}
+
+// fetchKafkaLag measures the lag of the consumer group of a check: the committed offsets of the
+// group are compared to the end offsets of their partitions
+func fetchKafkaLag(check types.QueueCheck, timeout time.Duration) (*kafkaLag, error) {
+	brokers := &kafkaBrokers{check: check, deadline: time.Now().Add(timeout), conns: make(map[string]*kafkaConn)}
+	defer brokers.close()
+
+	bootstrap, err := brokers.bootstrap()
+	if err != nil {
+		return nil, err
+	}
+
+	// Committed offsets are stored by the group coordinator
+	findCoordinator := kmsg.NewPtrFindCoordinatorRequest()
+	findCoordinator.SetVersion(kafkaFindCoordinatorVersion)
+	findCoordinator.CoordinatorKey = check.Group
+	resp, err := bootstrap.request(findCoordinator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find group coordinator: %w", err)
+	}
+	coordinatorResp := resp.(*kmsg.FindCoordinatorResponse)
+	if coordinatorResp.ErrorCode != 0 {
+		return nil, fmt.Errorf("failed to find group coordinator: %w", kafkaError(coordinatorResp.ErrorCode))
+	}
+	coordinator, err := brokers.get(net.JoinHostPort(coordinatorResp.Host, strconv.Itoa(int(coordinatorResp.Port))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to group coordinator: %w", err)
+	}
+
+	// A nil topic list fetches the offsets of all topics of the group
+	offsetFetch := kmsg.NewPtrOffsetFetchRequest()
+	offsetFetch.SetVersion(kafkaOffsetFetchVersion)
+	offsetFetch.Group = check.Group
+	resp, err = coordinator.request(offsetFetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+	offsetResp := resp.(*kmsg.OffsetFetchResponse)
+	if offsetResp.ErrorCode != 0 {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", kafkaError(offsetResp.ErrorCode))
+	}
+	committed := make(map[string]map[int32]int64)
+	for _, topic := range offsetResp.Topics {
+		if check.Topic != "" && topic.Topic != check.Topic {
+			continue
+		}
+		for _, partition := range topic.Partitions {
+			if partition.ErrorCode != 0 || partition.Offset < 0 {
+				continue
+			}
+			if committed[topic.Topic] == nil {
+				committed[topic.Topic] = make(map[int32]int64)
+			}
+			committed[topic.Topic][partition.Partition] = partition.Offset
+		}
+	}
+	if len(committed) == 0 {
+		if check.Topic != "" {
+			return nil, fmt.Errorf("consumer group %s has no committed offsets for topic %s", check.Group, check.Topic)
+		}
+		return nil, fmt.Errorf("consumer group %s has no committed offsets", check.Group)
+	}
+
+	end, err := fetchKafkaEndOffsets(brokers, bootstrap, committed)
+	if err != nil {
+		return nil, err
+	}
+	return computeKafkaLag(committed, end), nil
+}
+
+// fetchKafkaEndOffsets returns the latest offsets of the partitions, asking the leader of each partition
+func fetchKafkaEndOffsets(brokers *kafkaBrokers, bootstrap *kafkaConn, partitions map[string]map[int32]int64) (map[string]map[int32]int64, error) {
+	metadata := kmsg.NewPtrMetadataRequest()
+	metadata.SetVersion(kafkaMetadataVersion)
+	for topic := range partitions {
+		requestTopic := kmsg.NewMetadataRequestTopic()
+		requestTopic.Topic = kmsg.StringPtr(topic)
+		metadata.Topics = append(metadata.Topics, requestTopic)
+	}
+	resp, err := bootstrap.request(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metadata: %w", err)
+	}
+	metadataResp := resp.(*kmsg.MetadataResponse)
+
+	addrs := make(map[int32]string)
+	for _, broker := range metadataResp.Brokers {
+		addrs[broker.NodeID] = net.JoinHostPort(broker.Host, strconv.Itoa(int(broker.Port)))
+	}
+
+	// Group the partitions by leader
+	requests := make(map[string]*kmsg.ListOffsetsRequest)
+	for _, topic := range metadataResp.Topics {
+		if topic.Topic == nil {
+			continue
+		}
+		if topic.ErrorCode != 0 {
+			return nil, fmt.Errorf("failed to fetch metadata of topic %s: %w", *topic.Topic, kafkaError(topic.ErrorCode))
+		}
+		for _, partition := range topic.Partitions {
+			if _, ok := partitions[*topic.Topic][partition.Partition]; !ok {
+				continue
+			}
+			addr, ok := addrs[partition.Leader]
+			if !ok {
+				return nil, fmt.Errorf("topic %s partition %d has no leader", *topic.Topic, partition.Partition)
+			}
+
+			req, ok := requests[addr]
+			if !ok {
+				req = kmsg.NewPtrListOffsetsRequest()
+				req.SetVersion(kafkaListOffsetsVersion)
+				requests[addr] = req
+			}
+			requestPartition := kmsg.NewListOffsetsRequestTopicPartition()
+			requestPartition.Partition = partition.Partition
+			requestPartition.Timestamp = -1 // Latest offset
+			addListOffsetsPartition(req, *topic.Topic, requestPartition)
+		}
+	}
+
+	end := make(map[string]map[int32]int64)
+	for addr, req := range requests {
+		conn, err := brokers.get(addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to partition leader %s: %w", addr, err)
+		}
+		resp, err := conn.request(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list offsets: %w", err)
+		}
+		for _, topic := range resp.(*kmsg.ListOffsetsResponse).Topics {
+			for _, partition := range topic.Partitions {
+				if partition.ErrorCode != 0 {
+					return nil, fmt.Errorf("failed to list offsets of topic %s partition %d: %w", topic.Topic, partition.Partition, kafkaError(partition.ErrorCode))
+				}
+				if end[topic.Topic] == nil {
+					end[topic.Topic] = make(map[int32]int64)
+				}
+				end[topic.Topic][partition.Partition] = partition.Offset
+			}
+		}
+	}
+	return end, nil
+}
+
+// addListOffsetsPartition adds a partition to the topic of a ListOffsets request
+func addListOffsetsPartition(req *kmsg.ListOffsetsRequest, topic string, partition kmsg.ListOffsetsRequestTopicPartition) {
+	for i := range req.Topics {
+		if req.Topics[i].Topic == topic {
+			req.Topics[i].Partitions = append(req.Topics[i].Partitions, partition)
+			return
+		}
+	}
+	requestTopic := kmsg.NewListOffsetsRequestTopic()
+	requestTopic.Topic = topic
+	requestTopic.Partitions = []kmsg.ListOffsetsRequestTopicPartition{partition}
+	req.Topics = append(req.Topics, requestTopic)
+}
+
+// computeKafkaLag sums the lag of all partitions with a committed offset and an end offset
+func computeKafkaLag(committed, end map[string]map[int32]int64) *kafkaLag {
+	topics := make([]string, 0, len(committed))
+	for topic := range committed {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics) // Stable choice of the most lagging partition
+
+	lag := &kafkaLag{}
+	for _, topic := range topics {
+		for partition, offset := range committed[topic] {
+			latest, ok := end[topic][partition]
+			if !ok {
+				continue
+			}
+			behind := max(latest-offset, 0)
+			lag.total += behind
+			if lag.topic == "" || behind > lag.max || (behind == lag.max && topic == lag.topic && partition < lag.partition) {
+				lag.topic, lag.partition, lag.max = topic, partition, behind
+			}
+		}
+	}
+	return lag
+}
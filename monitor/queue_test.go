@@ -0,0 +1,245 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestQueueMonitor_CheckBacklog_RabbitMQ(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "guest" || pass != "guest" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.EscapedPath() != "/api/queues/%2F/orders" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"name":"orders","messages":1500,"messages_ready":1400,"messages_unacknowledged":100,"consumers":2}`)
+	}))
+	defer server.Close()
+	monitor := NewQueueMonitor(nil)
+
+	check := types.QueueCheck{Name: "orders", Type: "rabbitmq", URL: server.URL, Queue: "orders", Username: "guest", Password: "guest", MaxBacklog: 2000}
+	result := monitor.CheckBacklog(check)
+	if !result.Success || result.Value != "1500" {
+		t.Fatalf("Expected success with a backlog of 1500, got %+v", result)
+	}
+
+	check.MaxBacklog = 1000
+	result = monitor.CheckBacklog(check)
+	expected := "backlog of 1500 messages exceeds limit 1000 (1400 ready, 100 unacknowledged, 2 consumers)"
+	if result.Success || result.Error != expected {
+		t.Errorf("Expected error %q, got %+v", expected, result)
+	}
+
+	check.Queue = "missing"
+	result = monitor.CheckBacklog(check)
+	if result.Success || result.Error != "queue missing not found in virtual host /" {
+		t.Errorf("Expected a missing queue error, got %+v", result)
+	}
+}
+
+// startFakeKafkaBroker starts a single Kafka broker (node 1) answering the requests of the lag
+// check with the given committed and end offsets of the group "billing"
+func startFakeKafkaBroker(t *testing.T, committed, end map[string]map[int32]int64) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	host, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	respond := func(req kmsg.Request) kmsg.Response {
+		switch req := req.(type) {
+		case *kmsg.FindCoordinatorRequest:
+			resp := kmsg.NewPtrFindCoordinatorResponse()
+			if req.CoordinatorKey != "billing" {
+				resp.ErrorCode = 15
+			}
+			resp.NodeID, resp.Host, resp.Port = 1, host, int32(port)
+			return resp
+		case *kmsg.OffsetFetchRequest:
+			resp := kmsg.NewPtrOffsetFetchResponse()
+			for topic, partitions := range committed {
+				responseTopic := kmsg.NewOffsetFetchResponseTopic()
+				responseTopic.Topic = topic
+				for partition, offset := range partitions {
+					responsePartition := kmsg.NewOffsetFetchResponseTopicPartition()
+					responsePartition.Partition, responsePartition.Offset = partition, offset
+					responseTopic.Partitions = append(responseTopic.Partitions, responsePartition)
+				}
+				resp.Topics = append(resp.Topics, responseTopic)
+			}
+			return resp
+		case *kmsg.MetadataRequest:
+			resp := kmsg.NewPtrMetadataResponse()
+			broker := kmsg.NewMetadataResponseBroker()
+			broker.NodeID, broker.Host, broker.Port = 1, host, int32(port)
+			resp.Brokers = append(resp.Brokers, broker)
+			for _, requested := range req.Topics {
+				responseTopic := kmsg.NewMetadataResponseTopic()
+				responseTopic.Topic = requested.Topic
+				for partition := range end[*requested.Topic] {
+					responsePartition := kmsg.NewMetadataResponseTopicPartition()
+					responsePartition.Partition, responsePartition.Leader = partition, 1
+					responseTopic.Partitions = append(responseTopic.Partitions, responsePartition)
+				}
+				resp.Topics = append(resp.Topics, responseTopic)
+			}
+			return resp
+		case *kmsg.ListOffsetsRequest:
+			resp := kmsg.NewPtrListOffsetsResponse()
+			for _, requested := range req.Topics {
+				responseTopic := kmsg.NewListOffsetsResponseTopic()
+				responseTopic.Topic = requested.Topic
+				for _, partition := range requested.Partitions {
+					responsePartition := kmsg.NewListOffsetsResponseTopicPartition()
+					responsePartition.Partition = partition.Partition
+					responsePartition.Offset = end[requested.Topic][partition.Partition]
+					responseTopic.Partitions = append(responseTopic.Partitions, responsePartition)
+				}
+				resp.Topics = append(resp.Topics, responseTopic)
+			}
+			return resp
+		}
+		return nil
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					var size [4]byte
+					if _, err := io.ReadFull(conn, size[:]); err != nil {
+						return
+					}
+					frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+					if _, err := io.ReadFull(conn, frame); err != nil {
+						return
+					}
+
+					// Request header v1: key, version, correlation ID and client ID
+					key := int16(binary.BigEndian.Uint16(frame[0:]))
+					version := int16(binary.BigEndian.Uint16(frame[2:]))
+					correlationID := frame[4:8]
+					clientIDLength := int(int16(binary.BigEndian.Uint16(frame[8:])))
+					body := frame[10+max(clientIDLength, 0):]
+
+					req := kmsg.RequestForKey(key)
+					req.SetVersion(version)
+					if err := req.ReadFrom(body); err != nil {
+						return
+					}
+					resp := respond(req)
+					if resp == nil {
+						return
+					}
+					resp.SetVersion(version)
+
+					payload := resp.AppendTo(append([]byte(nil), correlationID...))
+					out := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+					conn.Write(append(out, payload...))
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestQueueMonitor_CheckBacklog_Kafka(t *testing.T) {
+	addr := startFakeKafkaBroker(t,
+		map[string]map[int32]int64{"invoices": {0: 90, 1: 50}, "refunds": {0: 10}},
+		map[string]map[int32]int64{"invoices": {0: 100, 1: 80}, "refunds": {0: 10}},
+	)
+	monitor := NewQueueMonitor(nil)
+
+	check := types.QueueCheck{Name: "billing", Type: "kafka", Brokers: []string{addr}, Group: "billing", MaxBacklog: 100, Timeout: 2}
+	result := monitor.CheckBacklog(check)
+	if !result.Success || result.Value != "40" {
+		t.Fatalf("Expected success with a lag of 40, got %+v", result)
+	}
+	if result.URL != "kafka://"+addr+"/billing" {
+		t.Errorf("Unexpected URL: %s", result.URL)
+	}
+
+	check.MaxBacklog = 25
+	check.Topic = "invoices"
+	result = monitor.CheckBacklog(check)
+	expected := "consumer lag of 40 messages exceeds limit 25 (most behind: invoices partition 1 with 30)"
+	if result.Success || result.Error != expected {
+		t.Errorf("Expected error %q, got %+v", expected, result)
+	}
+
+	check.Topic = "payments"
+	result = monitor.CheckBacklog(check)
+	if result.Success || !strings.Contains(result.Error, "no committed offsets for topic payments") {
+		t.Errorf("Expected a missing offsets error, got %+v", result)
+	}
+
+	check.Group = "shipping"
+	check.Topic = ""
+	result = monitor.CheckBacklog(check)
+	if result.Success || result.Error != "failed to find group coordinator: COORDINATOR_NOT_AVAILABLE" {
+		t.Errorf("Expected a coordinator error, got %+v", result)
+	}
+}
+
+func TestComputeKafkaLag(t *testing.T) {
+	lag := computeKafkaLag(
+		map[string]map[int32]int64{"a": {0: 5, 1: 20}, "b": {0: 0}},
+		map[string]map[int32]int64{"a": {0: 10, 1: 15}, "b": {0: 5}},
+	)
+	// A committed offset past the end (e.g. after a topic was recreated) does not count
+	if lag.total != 10 || lag.topic != "a" || lag.partition != 0 || lag.max != 5 {
+		t.Errorf("Unexpected lag: %+v", lag)
+	}
+}
+
+func TestQueueMonitor_Validate(t *testing.T) {
+	monitor := NewQueueMonitor(nil)
+
+	tests := []struct {
+		name    string
+		check   types.QueueCheck
+		wantErr bool
+	}{
+		{"valid rabbitmq", types.QueueCheck{Name: "orders", Type: "rabbitmq", URL: "http://rabbitmq:15672", Queue: "orders", MaxBacklog: 1000}, false},
+		{"valid kafka", types.QueueCheck{Name: "billing", Type: "kafka", Brokers: []string{"kafka:9092"}, Group: "billing", MaxBacklog: 1000}, false},
+		{"unknown type", types.QueueCheck{Name: "orders", Type: "sqs", MaxBacklog: 1000}, true},
+		{"rabbitmq without queue", types.QueueCheck{Name: "orders", Type: "rabbitmq", URL: "http://rabbitmq:15672", MaxBacklog: 1000}, true},
+		{"rabbitmq amqp url", types.QueueCheck{Name: "orders", Type: "rabbitmq", URL: "amqp://rabbitmq:5672", Queue: "orders", MaxBacklog: 1000}, true},
+		{"kafka without port", types.QueueCheck{Name: "billing", Type: "kafka", Brokers: []string{"kafka"}, Group: "billing", MaxBacklog: 1000}, true},
+		{"kafka without group", types.QueueCheck{Name: "billing", Type: "kafka", Brokers: []string{"kafka:9092"}, MaxBacklog: 1000}, true},
+		{"missing limit", types.QueueCheck{Name: "orders", Type: "rabbitmq", URL: "http://rabbitmq:15672", Queue: "orders"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := monitor.ValidateQueueCheck(tt.check)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueueCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// maxScriptOutput limits the output kept from a script; the rest is discarded
+const maxScriptOutput = 64 * 1024
+
+// Nagios plugin exit codes (https://nagios-plugins.org/doc/guidelines.html#AEN78)
+const (
+	nagiosOK       = 0
+	nagiosWarning  = 1
+	nagiosCritical = 2
+)
+
+// ScriptMonitor runs external plugin executables and turns their output into check results
+type ScriptMonitor struct {
+	checks []types.ScriptCheck
+}
+
+// scriptOutput is the JSON object a script may print instead of Nagios plugin output
+type scriptOutput struct {
+	Success *bool       `json:"success"` // Default: exit code 0
+	Error   string      `json:"error"`
+	Value   interface{} `json:"value"` // Number or string, shown on the dashboard
+}
+
+// NewScriptMonitor creates a new script monitor instance
+func NewScriptMonitor(checks []types.ScriptCheck) *ScriptMonitor {
+	return &ScriptMonitor{
+		checks: checks,
+	}
+}
+
+// Name returns the monitor name used in logs
+func (sm *ScriptMonitor) Name() string {
+	return "Script"
+}
+
+// Interval returns how often the configured checks should run
+func (sm *ScriptMonitor) Interval() time.Duration {
+	intervals := make([]int, 0, len(sm.checks))
+	for _, check := range sm.checks {
+		intervals = append(intervals, check.CheckInterval)
+	}
+	return shortestInterval(intervals)
+}
+
+// RunChecks runs all configured scripts
+func (sm *ScriptMonitor) RunChecks() []types.HTTPCheckResult {
+	results := make([]types.HTTPCheckResult, 0, len(sm.checks))
+	for _, check := range sm.checks {
+		results = append(results, sm.RunScript(check))
+	}
+	return results
+}
+
+// HasCheck reports whether a script check of the given name is configured
+func (sm *ScriptMonitor) HasCheck(name string) bool {
+	for _, check := range sm.checks {
+		if check.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunCheck performs the named script check on its own
+func (sm *ScriptMonitor) RunCheck(name string) (types.HTTPCheckResult, bool) {
+	for _, check := range sm.checks {
+		if check.Name == name {
+			return sm.RunScript(check), true
+		}
+	}
+	return types.HTTPCheckResult{}, false
+}
+
+// Validate validates all configured script checks
+func (sm *ScriptMonitor) Validate() error {
+	for _, check := range sm.checks {
+		if err := sm.ValidateScriptCheck(check); err != nil {
+			return fmt.Errorf("script check %s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateScriptCheck validates if a script check configuration is valid
+func (sm *ScriptMonitor) ValidateScriptCheck(check types.ScriptCheck) error {
+	if check.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	if check.Command == "" {
+		return fmt.Errorf("command cannot be empty")
+	}
+	if _, err := exec.LookPath(check.Command); err != nil {
+		return fmt.Errorf("command not found or not executable: %s", check.Command)
+	}
+
+	switch strings.ToLower(check.Format) {
+	case "", "auto", "json", "nagios":
+	default:
+		return fmt.Errorf("invalid format %q (must be json, nagios or auto)", check.Format)
+	}
+
+	if check.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	if check.CheckInterval < 0 {
+		return fmt.Errorf("check interval cannot be negative")
+	}
+
+	return nil
+}
+
+// RunScript runs the script of a check and parses its exit code and output
+func (sm *ScriptMonitor) RunScript(check types.ScriptCheck) types.HTTPCheckResult {
+	result := types.HTTPCheckResult{
+		Name:      check.Name,
+		Type:      "script",
+		URL:       "script://" + check.Command,
+		Timestamp: time.Now(),
+	}
+
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 10 // Default to 10 seconds
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, check.Command, check.Args...)
+	cmd.Env = scriptEnv(check.Name)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Child processes that keep the output open must not block the check past its timeout
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result.ResponseTime = time.Since(start)
+
+	exitCode := 0
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Error = fmt.Sprintf("timed out after %ds", timeout)
+		return result
+	case errors.As(err, &exitErr):
+		exitCode = exitErr.ExitCode()
+	case err != nil:
+		result.Error = fmt.Sprintf("failed to run: %v", err)
+		return result
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	format := strings.ToLower(check.Format)
+	if format == "json" || ((format == "" || format == "auto") && strings.HasPrefix(output, "{")) {
+		parseScriptJSON(&result, output, exitCode)
+	} else {
+		parseNagiosOutput(&result, output, strings.TrimSpace(stderr.String()), exitCode)
+	}
+	return result
+}
+
+// parseScriptJSON fills a result from JSON script output; without "success" the exit code decides
+func parseScriptJSON(result *types.HTTPCheckResult, output string, exitCode int) {
+	var parsed scriptOutput
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	if err := decoder.Decode(&parsed); err != nil {
+		result.Error = fmt.Sprintf("invalid JSON output: %v", err)
+		return
+	}
+
+	if parsed.Value != nil {
+		result.Value = fmt.Sprint(parsed.Value)
+	}
+	result.Success = exitCode == 0
+	if parsed.Success != nil {
+		result.Success = *parsed.Success
+	}
+	if !result.Success {
+		result.Error = parsed.Error
+		if result.Error == "" {
+			result.Error = fmt.Sprintf("script reported failure (exit code %d)", exitCode)
+		}
+	}
+}
+
+// scriptEnv returns the environment of a check script: Monic's environment without its MONIC_
+// settings, which hold credentials such as alerting tokens and passwords, plus MONIC_CHECK_NAME
+func scriptEnv(name string) []string {
+	var env []string
+	for _, variable := range os.Environ() {
+		if !strings.HasPrefix(variable, "MONIC_") {
+			env = append(env, variable)
+		}
+	}
+	return append(env, "MONIC_CHECK_NAME="+name)
+}
+
+// parseNagiosOutput fills a result from Nagios plugin output ("TEXT | label=value;warn;crit"):
+// exit code 0 is OK, anything else fails with the status and text of the first line. The value of
+// the first performance data entry becomes the result value.
+func parseNagiosOutput(result *types.HTTPCheckResult, output, stderr string, exitCode int) {
+	firstLine, _, _ := strings.Cut(output, "\n")
+	text, perfData, _ := strings.Cut(firstLine, "|")
+	text = strings.TrimSpace(text)
+	result.Value = perfDataValue(perfData)
+
+	if exitCode == nagiosOK {
+		result.Success = true
+		return
+	}
+
+	if text == "" {
+		text, _, _ = strings.Cut(stderr, "\n")
+	}
+
+	var status string
+	switch exitCode {
+	case nagiosWarning:
+		status = "WARNING"
+	case nagiosCritical:
+		status = "CRITICAL"
+	default:
+		status = "UNKNOWN"
+	}
+
+	if text == "" {
+		result.Error = fmt.Sprintf("%s (exit code %d)", status, exitCode)
+	} else if strings.HasPrefix(strings.ToUpper(text), status) {
+		result.Error = text // Plugins usually start their output with the status already
+	} else {
+		result.Error = status + ": " + text
+	}
+}
+
+// perfDataValue returns the numeric value of the first performance data entry without its unit,
+// e.g. "95.5" for "disk=95.5%;90;95"
+func perfDataValue(perfData string) string {
+	fields := strings.Fields(perfData)
+	if len(fields) == 0 {
+		return ""
+	}
+	_, value, found := strings.Cut(fields[0], "=")
+	if !found {
+		return ""
+	}
+	value, _, _ = strings.Cut(value, ";")
+	number := strings.TrimRightFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if _, err := strconv.ParseFloat(number, 64); err != nil {
+		return ""
+	}
+	return number
+}
+
+// limitedBuffer keeps the first maxScriptOutput bytes written to it and discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+// Write implements io.Writer; it never fails so the script is not killed by a broken pipe
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := maxScriptOutput - b.Len(); remaining > 0 {
+		b.Buffer.Write(p[:min(len(p), remaining)])
+	}
+	return len(p), nil
+}
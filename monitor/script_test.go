@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+// writeScript creates an executable shell script with the given body
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "check.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestScriptMonitor_RunScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		format  string
+		success bool
+		value   string
+		err     string
+	}{
+		{"nagios ok", `echo "OK - load 0.52 | load1=0.52;4;8"`, "", true, "0.52", ""},
+		{"nagios warning", `echo "disk usage 91% | disk=91%;90;95"; exit 1`, "", false, "91", "WARNING: disk usage 91%"},
+		{"nagios critical with status", `echo "CRITICAL - replication stopped"; exit 2`, "", false, "", "CRITICAL - replication stopped"},
+		{"nagios unknown from stderr", `echo "cannot connect" >&2; exit 3`, "", false, "", "UNKNOWN: cannot connect"},
+		{"nagios no output", `exit 2`, "nagios", false, "", "CRITICAL (exit code 2)"},
+		{"json success", `echo '{"success": true, "value": 42}'`, "", true, "42", ""},
+		{"json failure", `echo '{"success": false, "error": "3 jobs stuck", "value": "stuck"}'`, "", false, "stuck", "3 jobs stuck"},
+		{"json exit code", `echo '{"value": 1.5}'; exit 1`, "json", false, "1.5", "script reported failure (exit code 1)"},
+		{"json invalid", `echo 'not json'`, "json", false, "", "invalid JSON output"},
+		{"environment", `echo "{\"success\": true, \"value\": \"$MONIC_CHECK_NAME\"}"`, "", true, "backup", ""},
+	}
+
+	sm := NewScriptMonitor(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := types.ScriptCheck{Name: "backup", Command: writeScript(t, tt.script), Format: tt.format}
+			result := sm.RunScript(check)
+			if result.Type != "script" || result.Success != tt.success || result.Value != tt.value || !strings.Contains(result.Error, tt.err) {
+				t.Errorf("Expected success=%v value=%q error %q, got %+v", tt.success, tt.value, tt.err, result)
+			}
+		})
+	}
+}
+
+func TestScriptMonitor_Environment(t *testing.T) {
+	t.Setenv("MONIC_ALERTING_TELEGRAM_BOT_TOKEN", "secret-token")
+	t.Setenv("MONIC_HTTP_SERVER_PASSWORD", "secret-password")
+
+	sm := NewScriptMonitor(nil)
+	result := sm.RunScript(types.ScriptCheck{Name: "env", Command: writeScript(t, "env | tr '\\n' ' '; exit 2")})
+	if strings.Contains(result.Error, "secret-") {
+		t.Errorf("Expected no Monic settings in the script environment, got %s", result.Error)
+	}
+	if !strings.Contains(result.Error, "MONIC_CHECK_NAME=env") || !strings.Contains(result.Error, "PATH=") {
+		t.Errorf("Expected the check name and the rest of the environment, got %s", result.Error)
+	}
+}
+
+func TestScriptMonitor_Timeout(t *testing.T) {
+	sm := NewScriptMonitor(nil)
+	result := sm.RunScript(types.ScriptCheck{Name: "slow", Command: writeScript(t, "sleep 5"), Timeout: 1})
+	if result.Success || result.Error != "timed out after 1s" {
+		t.Errorf("Expected timeout, got %+v", result)
+	}
+}
+
+func TestScriptMonitor_Validate(t *testing.T) {
+	script := writeScript(t, "exit 0")
+	tests := []struct {
+		name  string
+		check types.ScriptCheck
+		valid bool
+	}{
+		{"valid", types.ScriptCheck{Name: "ok", Command: script}, true},
+		{"in PATH", types.ScriptCheck{Name: "ok", Command: "sh", Args: []string{"-c", "true"}, Format: "nagios"}, true},
+		{"missing name", types.ScriptCheck{Command: script}, false},
+		{"missing command", types.ScriptCheck{Name: "x"}, false},
+		{"unknown command", types.ScriptCheck{Name: "x", Command: "/nonexistent/check"}, false},
+		{"invalid format", types.ScriptCheck{Name: "x", Command: script, Format: "xml"}, false},
+		{"negative timeout", types.ScriptCheck{Name: "x", Command: script, Timeout: -1}, false},
+	}
+
+	sm := NewScriptMonitor(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sm.ValidateScriptCheck(tt.check)
+			if (err == nil) != tt.valid {
+				t.Errorf("Expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestPerfDataValue(t *testing.T) {
+	tests := map[string]string{
+		"":                    "",
+		"load1=0.52;4;8":      "0.52",
+		" disk=95.5%;90;95":   "95.5",
+		"time=120ms size=2KB": "120",
+		"temp=-3C":            "-3",
+		"state=U":             "",
+		"garbage":             "",
+	}
+	for perfData, want := range tests {
+		if got := perfDataValue(perfData); got != want {
+			t.Errorf("perfDataValue(%q) = %q, want %q", perfData, got, want)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package monitor
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// snmpAuthProtocols maps configured SNMPv3 authentication protocols to gosnmp
+var snmpAuthProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"MD5":    gosnmp.MD5,
+	"SHA":    gosnmp.SHA,
+	"SHA224": gosnmp.SHA224,
+	"SHA256": gosnmp.SHA256,
+	"SHA384": gosnmp.SHA384,
+	"SHA512": gosnmp.SHA512,
+}
+
+// snmpPrivProtocols maps configured SNMPv3 privacy protocols to gosnmp
+var snmpPrivProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"DES":    gosnmp.DES,
+	"AES":    gosnmp.AES,
+	"AES192": gosnmp.AES192,
+	"AES256": gosnmp.AES256,
+}
+
+// SNMPMonitor polls OIDs on network devices and checks their values against thresholds
+type SNMPMonitor struct {
+	checks []types.SNMPCheck
+}
+
+// NewSNMPMonitor creates a new SNMP monitor instance
+func NewSNMPMonitor(checks []types.SNMPCheck) *SNMPMonitor {
+	return &SNMPMonitor{
+		checks: checks,
+	}
+}
+
+// Name returns the monitor name used in logs
+func (sm *SNMPMonitor) Name() string {
+	return "SNMP"
+}
+
+// Interval returns how often the configured checks should run
+func (sm *SNMPMonitor) Interval() time.Duration {
+	intervals := make([]int, 0, len(sm.checks))
+	for _, check := range sm.checks {
+		intervals = append(intervals, check.CheckInterval)
+	}
+	return shortestInterval(intervals)
+}
+
+// RunChecks polls all configured OIDs
+func (sm *SNMPMonitor) RunChecks() []types.HTTPCheckResult {
+	results := make([]types.HTTPCheckResult, 0, len(sm.checks))
+	for _, check := range sm.checks {
+		results = append(results, sm.CheckOID(check))
+	}
+	return results
+}
+
+// HasCheck reports whether a SNMP check of the given name is configured
+func (sm *SNMPMonitor) HasCheck(name string) bool {
+	for _, check := range sm.checks {
+		if check.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunCheck performs the named SNMP check on its own
+func (sm *SNMPMonitor) RunCheck(name string) (types.HTTPCheckResult, bool) {
+	for _, check := range sm.checks {
+		if check.Name == name {
+			return sm.CheckOID(check), true
+		}
+	}
+	return types.HTTPCheckResult{}, false
+}
+
+// Validate validates all configured SNMP checks
+func (sm *SNMPMonitor) Validate() error {
+	for _, check := range sm.checks {
+		if err := sm.ValidateSNMPCheck(check); err != nil {
+			return fmt.Errorf("SNMP check %s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateSNMPCheck validates if an SNMP check configuration is valid
+func (sm *SNMPMonitor) ValidateSNMPCheck(check types.SNMPCheck) error {
+	if check.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	if check.Target == "" {
+		return fmt.Errorf("target cannot be empty")
+	}
+
+	if check.OID == "" {
+		return fmt.Errorf("OID cannot be empty")
+	}
+
+	switch check.Version {
+	case "", "1", "2c":
+	case "3":
+		if check.Username == "" {
+			return fmt.Errorf("SNMPv3 requires a username")
+		}
+		if _, ok := snmpAuthProtocols[check.AuthProtocol]; check.AuthProtocol != "" && !ok {
+			return fmt.Errorf("unsupported auth protocol %q (supported: MD5, SHA, SHA224, SHA256, SHA384, SHA512)", check.AuthProtocol)
+		}
+		if _, ok := snmpPrivProtocols[check.PrivProtocol]; check.PrivProtocol != "" && !ok {
+			return fmt.Errorf("unsupported privacy protocol %q (supported: DES, AES, AES192, AES256)", check.PrivProtocol)
+		}
+		if check.PrivProtocol != "" && check.AuthProtocol == "" {
+			return fmt.Errorf("privacy requires an auth protocol")
+		}
+	default:
+		return fmt.Errorf("unsupported SNMP version %q (supported: 1, 2c, 3)", check.Version)
+	}
+
+	if check.Min != nil && check.Max != nil && *check.Min > *check.Max {
+		return fmt.Errorf("min cannot be greater than max")
+	}
+
+	if check.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	if check.CheckInterval < 0 {
+		return fmt.Errorf("check interval cannot be negative")
+	}
+
+	return nil
+}
+
+// CheckOID reads the OID of a check and fails when the value is outside of its bounds
+func (sm *SNMPMonitor) CheckOID(check types.SNMPCheck) types.HTTPCheckResult {
+	host, port := check.Target, uint16(161)
+	if h, p, err := net.SplitHostPort(check.Target); err == nil {
+		parsed, err := strconv.ParseUint(p, 10, 16)
+		if err == nil {
+			host, port = h, uint16(parsed)
+		}
+	}
+
+	result := types.HTTPCheckResult{
+		Name:      check.Name,
+		Type:      "snmp",
+		URL:       "snmp://" + net.JoinHostPort(host, strconv.Itoa(int(port))) + "/" + strings.TrimPrefix(check.OID, "."),
+		Timestamp: time.Now(),
+	}
+
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 5 // Default to 5 seconds
+	}
+
+	client := newSNMPClient(check, host, port, time.Duration(timeout)*time.Second)
+	start := time.Now()
+	if err := client.Connect(); err != nil {
+		result.Error = fmt.Sprintf("failed to connect: %v", err)
+		return result
+	}
+	defer client.Conn.Close()
+
+	packet, err := client.Get([]string{check.OID})
+	result.ResponseTime = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Sprintf("SNMP get failed: %v", err)
+		return result
+	}
+	if packet.Error != gosnmp.NoError {
+		result.Error = fmt.Sprintf("SNMP error: %s", packet.Error)
+		return result
+	}
+	if len(packet.Variables) != 1 {
+		result.Error = fmt.Sprintf("expected 1 variable, got %d", len(packet.Variables))
+		return result
+	}
+
+	value, numeric, err := snmpValue(packet.Variables[0])
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Value = value
+	if err := checkSNMPValue(check, value, numeric); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// newSNMPClient creates a client for the version and credentials of a check
+func newSNMPClient(check types.SNMPCheck, host string, port uint16, timeout time.Duration) *gosnmp.GoSNMP {
+	client := &gosnmp.GoSNMP{
+		Target:    host,
+		Port:      port,
+		Transport: "udp",
+		Community: check.Community,
+		Version:   gosnmp.Version2c,
+		Timeout:   timeout,
+		Retries:   1,
+		MaxOids:   gosnmp.MaxOids,
+	}
+	if client.Community == "" {
+		client.Community = "public"
+	}
+
+	switch check.Version {
+	case "1":
+		client.Version = gosnmp.Version1
+	case "3":
+		client.Version = gosnmp.Version3
+		client.SecurityModel = gosnmp.UserSecurityModel
+		params := &gosnmp.UsmSecurityParameters{
+			UserName:               check.Username,
+			AuthenticationProtocol: gosnmp.NoAuth,
+			PrivacyProtocol:        gosnmp.NoPriv,
+		}
+		client.MsgFlags = gosnmp.NoAuthNoPriv
+		if check.AuthProtocol != "" {
+			params.AuthenticationProtocol = snmpAuthProtocols[check.AuthProtocol]
+			params.AuthenticationPassphrase = check.AuthPassword
+			client.MsgFlags = gosnmp.AuthNoPriv
+		}
+		if check.PrivProtocol != "" {
+			params.PrivacyProtocol = snmpPrivProtocols[check.PrivProtocol]
+			params.PrivacyPassphrase = check.PrivPassword
+			client.MsgFlags = gosnmp.AuthPriv
+		}
+		client.SecurityParameters = params
+	}
+	return client
+}
+
+// snmpValue formats a polled variable and returns its numeric value when it has one
+func snmpValue(variable gosnmp.SnmpPDU) (string, *float64, error) {
+	switch variable.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return "", nil, fmt.Errorf("OID %s not found on the device", variable.Name)
+	case gosnmp.Null:
+		return "", nil, fmt.Errorf("OID %s has no value", variable.Name)
+	case gosnmp.OctetString:
+		text := string(variable.Value.([]byte))
+		// Some devices report numbers as strings (e.g. UPS temperatures)
+		if number, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			return text, &number, nil
+		}
+		return text, nil, nil
+	case gosnmp.OpaqueFloat:
+		number := float64(variable.Value.(float32))
+		return strconv.FormatFloat(number, 'g', -1, 64), &number, nil
+	case gosnmp.OpaqueDouble:
+		number := variable.Value.(float64)
+		return strconv.FormatFloat(number, 'g', -1, 64), &number, nil
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		integer := gosnmp.ToBigInt(variable.Value)
+		number, _ := new(big.Float).SetInt(integer).Float64()
+		return integer.String(), &number, nil
+	default:
+		return fmt.Sprint(variable.Value), nil, nil
+	}
+}
+
+// checkSNMPValue checks a polled value against the bounds and expected value of a check
+func checkSNMPValue(check types.SNMPCheck, value string, numeric *float64) error {
+	if check.Expect != "" && value != check.Expect {
+		return fmt.Errorf("value %q, expected %q", value, check.Expect)
+	}
+	if check.Min == nil && check.Max == nil {
+		return nil
+	}
+
+	if numeric == nil {
+		return fmt.Errorf("value %q is not numeric", value)
+	}
+	if check.Min != nil && *numeric < *check.Min {
+		return fmt.Errorf("value %s below minimum %s", value, strconv.FormatFloat(*check.Min, 'g', -1, 64))
+	}
+	if check.Max != nil && *numeric > *check.Max {
+		return fmt.Errorf("value %s above maximum %s", value, strconv.FormatFloat(*check.Max, 'g', -1, 64))
+	}
+	return nil
+}
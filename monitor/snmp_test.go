@@ -0,0 +1,139 @@
+package monitor
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// startFakeSNMPAgent starts a UDP SNMPv2c agent answering GET requests from a fixed set of OIDs
+func startFakeSNMPAgent(t *testing.T, values map[string]gosnmp.SnmpPDU) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		decoder := &gosnmp.GoSNMP{}
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			request, err := decoder.SnmpDecodePacket(buf[:n])
+			if err != nil || request.Community != "public" {
+				continue
+			}
+
+			response := &gosnmp.SnmpPacket{
+				Version:   request.Version,
+				Community: request.Community,
+				PDUType:   gosnmp.GetResponse,
+				RequestID: request.RequestID,
+			}
+			for _, variable := range request.Variables {
+				value, ok := values[variable.Name]
+				if !ok {
+					value = gosnmp.SnmpPDU{Type: gosnmp.NoSuchObject}
+				}
+				value.Name = variable.Name
+				response.Variables = append(response.Variables, value)
+			}
+			data, err := response.MarshalMsg()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(data, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestSNMPMonitor_CheckOID(t *testing.T) {
+	addr := startFakeSNMPAgent(t, map[string]gosnmp.SnmpPDU{
+		".1.3.6.1.2.1.33.1.2.4.0": {Type: gosnmp.Integer, Value: 87},
+		".1.3.6.1.2.1.1.5.0":      {Type: gosnmp.OctetString, Value: "core-switch"},
+	})
+	monitor := NewSNMPMonitor(nil)
+	low, high := 50.0, 80.0
+
+	result := monitor.CheckOID(types.SNMPCheck{Name: "ups", Target: addr, OID: ".1.3.6.1.2.1.33.1.2.4.0", Min: &low, Timeout: 2})
+	if !result.Success {
+		t.Fatalf("Expected success, got error: %s", result.Error)
+	}
+	if result.Type != "snmp" || result.Value != "87" || result.URL != "snmp://"+addr+"/1.3.6.1.2.1.33.1.2.4.0" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	result = monitor.CheckOID(types.SNMPCheck{Name: "ups", Target: addr, OID: ".1.3.6.1.2.1.33.1.2.4.0", Max: &high, Timeout: 2})
+	if result.Success || result.Error != "value 87 above maximum 80" {
+		t.Errorf("Expected a threshold failure, got %+v", result)
+	}
+
+	result = monitor.CheckOID(types.SNMPCheck{Name: "sysname", Target: addr, OID: ".1.3.6.1.2.1.1.5.0", Expect: "core-switch", Timeout: 2})
+	if !result.Success || result.Value != "core-switch" {
+		t.Errorf("Expected the string value to match, got %+v", result)
+	}
+
+	result = monitor.CheckOID(types.SNMPCheck{Name: "missing", Target: addr, OID: ".1.3.6.1.2.1.1.9.0", Timeout: 2})
+	if result.Success || !strings.Contains(result.Error, "not found") {
+		t.Errorf("Expected a missing OID failure, got %+v", result)
+	}
+}
+
+func TestCheckSNMPValue(t *testing.T) {
+	low, high := 10.0, 20.0
+	number := 15.0
+
+	if err := checkSNMPValue(types.SNMPCheck{Min: &low, Max: &high}, "15", &number); err != nil {
+		t.Errorf("Expected value within bounds, got %v", err)
+	}
+	if err := checkSNMPValue(types.SNMPCheck{Min: &high}, "15", &number); err == nil || err.Error() != "value 15 below minimum 20" {
+		t.Errorf("Expected a minimum failure, got %v", err)
+	}
+	if err := checkSNMPValue(types.SNMPCheck{Max: &high}, "up", nil); err == nil || !strings.Contains(err.Error(), "not numeric") {
+		t.Errorf("Expected a non-numeric failure, got %v", err)
+	}
+	if err := checkSNMPValue(types.SNMPCheck{Expect: "1"}, "2", nil); err == nil {
+		t.Error("Expected a mismatch failure")
+	}
+}
+
+func TestSNMPMonitor_Validate(t *testing.T) {
+	monitor := NewSNMPMonitor(nil)
+	low, high := 5.0, 1.0
+
+	tests := []struct {
+		name    string
+		check   types.SNMPCheck
+		wantErr bool
+	}{
+		{"valid v2c", types.SNMPCheck{Name: "ups", Target: "10.0.0.5", OID: "1.3.6.1.2.1.1.3.0"}, false},
+		{"valid v3", types.SNMPCheck{Name: "ups", Target: "10.0.0.5", OID: "1.3.6.1.2.1.1.3.0", Version: "3", Username: "monic", AuthProtocol: "SHA", PrivProtocol: "AES"}, false},
+		{"missing target", types.SNMPCheck{Name: "ups", OID: "1.3.6.1.2.1.1.3.0"}, true},
+		{"missing OID", types.SNMPCheck{Name: "ups", Target: "10.0.0.5"}, true},
+		{"unknown version", types.SNMPCheck{Name: "ups", Target: "10.0.0.5", OID: "1.3.6.1.2.1.1.3.0", Version: "4"}, true},
+		{"v3 without user", types.SNMPCheck{Name: "ups", Target: "10.0.0.5", OID: "1.3.6.1.2.1.1.3.0", Version: "3"}, true},
+		{"privacy without auth", types.SNMPCheck{Name: "ups", Target: "10.0.0.5", OID: "1.3.6.1.2.1.1.3.0", Version: "3", Username: "monic", PrivProtocol: "AES"}, true},
+		{"unknown auth", types.SNMPCheck{Name: "ups", Target: "10.0.0.5", OID: "1.3.6.1.2.1.1.3.0", Version: "3", Username: "monic", AuthProtocol: "SHA1"}, true},
+		{"min above max", types.SNMPCheck{Name: "ups", Target: "10.0.0.5", OID: "1.3.6.1.2.1.1.3.0", Min: &low, Max: &high}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := monitor.ValidateSNMPCheck(tt.check)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSNMPCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
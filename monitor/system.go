@@ -4,18 +4,26 @@ import (
 	"fmt"
 	"log/slog"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"bconf.com/monic/types"
 
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/load"
 	"github.com/shirou/gopsutil/v4/mem"
 )
 
 // SystemMonitor handles system resource monitoring
 type SystemMonitor struct {
 	config *types.SystemChecksConfig
+
+	// Disk I/O counters are cumulative, so rates are computed against the previous collection
+	ioMu       sync.Mutex
+	prevIO     map[string]disk.IOCountersStat
+	prevIOTime time.Time
 }
 
 // NewSystemMonitor creates a new system monitor instance
@@ -32,12 +40,22 @@ func (sm *SystemMonitor) CollectStats() (*types.SystemStats, error) {
 		DiskUsage: make(map[string]types.DiskStats),
 	}
 
-	// Collect CPU usage
-	cpuUsage, err := sm.getCPUUsage()
+	// Collect CPU usage (overall and per core)
+	cpuUsage, perCore, err := sm.getCPUUsage()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CPU usage: %w", err)
 	}
 	stats.CPUUsage = cpuUsage
+	stats.PerCoreUsage = perCore
+
+	// Collect load averages
+	loadStats, err := sm.getLoadAverage()
+	if err != nil {
+		// Log error but continue with other stats (e.g. not supported on this platform)
+		slog.Warn("Failed to get load average", "error", err)
+	} else {
+		stats.LoadAverage = loadStats
+	}
 
 	// Collect memory usage
 	memStats, err := sm.getMemoryUsage()
@@ -46,6 +64,15 @@ func (sm *SystemMonitor) CollectStats() (*types.SystemStats, error) {
 	}
 	stats.MemoryUsage = memStats
 
+	// Collect swap usage
+	swapStats, err := sm.getSwapUsage()
+	if err != nil {
+		// Log error but continue with other stats
+		slog.Warn("Failed to get swap usage", "error", err)
+	} else {
+		stats.SwapUsage = swapStats
+	}
+
 	// Collect disk usage only for root path "/"
 	diskStats, err := sm.getDiskUsage("/")
 	if err != nil {
@@ -55,22 +82,133 @@ func (sm *SystemMonitor) CollectStats() (*types.SystemStats, error) {
 		stats.DiskUsage["/"] = diskStats
 	}
 
+	// Collect disk I/O rates
+	diskIO, err := sm.getDiskIO(stats.Timestamp)
+	if err != nil {
+		// Log error but continue with other stats
+		slog.Warn("Failed to get disk I/O stats", "error", err)
+	} else {
+		stats.DiskIO = diskIO
+	}
+
 	return stats, nil
 }
 
-// getCPUUsage returns the current CPU usage percentage
-func (sm *SystemMonitor) getCPUUsage() (float64, error) {
-	// Get CPU usage for a short interval to get current usage
-	percentages, err := cpu.Percent(1*time.Second, false)
+// getDiskIO returns per-device I/O rates since the previous collection (empty on the first call)
+func (sm *SystemMonitor) getDiskIO(now time.Time) (map[string]types.DiskIOStats, error) {
+	var devices []string
+	if sm.config != nil {
+		devices = sm.config.DiskIODevices
+	}
+
+	counters, err := disk.IOCounters(devices...)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		for name := range counters {
+			if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+				delete(counters, name)
+			}
+		}
+	}
+
+	sm.ioMu.Lock()
+	defer sm.ioMu.Unlock()
+
+	result := make(map[string]types.DiskIOStats)
+	if sm.prevIO != nil {
+		result = computeDiskIO(sm.prevIO, counters, now.Sub(sm.prevIOTime))
+	}
+	sm.prevIO = counters
+	sm.prevIOTime = now
+
+	return result, nil
+}
+
+// computeDiskIO derives I/O rates from two snapshots of cumulative counters taken elapsed apart
+func computeDiskIO(prev, curr map[string]disk.IOCountersStat, elapsed time.Duration) map[string]types.DiskIOStats {
+	result := make(map[string]types.DiskIOStats)
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return result
+	}
+
+	for name, c := range curr {
+		p, exists := prev[name]
+		// Skip new devices and counters that were reset (e.g. device re-attached)
+		if !exists || c.ReadCount < p.ReadCount || c.WriteCount < p.WriteCount || c.ReadTime < p.ReadTime || c.WriteTime < p.WriteTime || c.IoTime < p.IoTime {
+			continue
+		}
+
+		reads := float64(c.ReadCount - p.ReadCount)
+		writes := float64(c.WriteCount - p.WriteCount)
+		readTime := float64(c.ReadTime - p.ReadTime) // Milliseconds
+		writeTime := float64(c.WriteTime - p.WriteTime)
+
+		stats := types.DiskIOStats{
+			Device:           name,
+			ReadIOPS:         reads / seconds,
+			WriteIOPS:        writes / seconds,
+			ReadBytesPerSec:  float64(c.ReadBytes-p.ReadBytes) / seconds,
+			WriteBytesPerSec: float64(c.WriteBytes-p.WriteBytes) / seconds,
+			UtilPercent:      float64(c.IoTime-p.IoTime) / (seconds * 1000) * 100,
+		}
+		if reads > 0 {
+			stats.ReadAwaitMs = readTime / reads
+		}
+		if writes > 0 {
+			stats.WriteAwaitMs = writeTime / writes
+		}
+		if reads+writes > 0 {
+			stats.AwaitMs = (readTime + writeTime) / (reads + writes)
+		}
+		if stats.UtilPercent > 100 {
+			stats.UtilPercent = 100
+		}
+
+		result[name] = stats
+	}
+
+	return result
+}
+
+// getCPUUsage returns the current overall CPU usage percentage and the usage of each core
+func (sm *SystemMonitor) getCPUUsage() (float64, []float64, error) {
+	// Get per-core CPU usage for a short interval to get current usage
+	percentages, err := cpu.Percent(1*time.Second, true)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	if len(percentages) == 0 {
-		return 0, fmt.Errorf("no CPU usage data available")
+		return 0, nil, fmt.Errorf("no CPU usage data available")
 	}
 
-	return percentages[0], nil
+	// Overall usage is the average across all cores
+	total := 0.0
+	for _, p := range percentages {
+		total += p
+	}
+
+	return total / float64(len(percentages)), percentages, nil
+}
+
+// getLoadAverage returns the 1/5/15-minute load averages
+func (sm *SystemMonitor) getLoadAverage() (types.LoadStats, error) {
+	var stats types.LoadStats
+
+	avg, err := load.Avg()
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Load1 = avg.Load1
+	stats.Load5 = avg.Load5
+	stats.Load15 = avg.Load15
+	stats.Cores = runtime.NumCPU()
+
+	return stats, nil
 }
 
 // getMemoryUsage returns current memory usage statistics
@@ -90,6 +228,23 @@ func (sm *SystemMonitor) getMemoryUsage() (types.MemoryStats, error) {
 	return stats, nil
 }
 
+// getSwapUsage returns current swap usage statistics
+func (sm *SystemMonitor) getSwapUsage() (types.SwapStats, error) {
+	var stats types.SwapStats
+
+	swap, err := mem.SwapMemory()
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Total = swap.Total
+	stats.Used = swap.Used
+	stats.Free = swap.Free
+	stats.UsedPercent = swap.UsedPercent
+
+	return stats, nil
+}
+
 // getDiskUsage returns disk usage statistics for a specific path
 func (sm *SystemMonitor) getDiskUsage(path string) (types.DiskStats, error) {
 	var stats types.DiskStats
@@ -104,6 +259,10 @@ func (sm *SystemMonitor) getDiskUsage(path string) (types.DiskStats, error) {
 	stats.Used = usage.Used
 	stats.Free = usage.Free
 	stats.UsedPercent = usage.UsedPercent
+	stats.InodesTotal = usage.InodesTotal
+	stats.InodesUsed = usage.InodesUsed
+	stats.InodesFree = usage.InodesFree
+	stats.InodesUsedPercent = usage.InodesUsedPercent
 
 	return stats, nil
 }
@@ -132,6 +291,26 @@ func (sm *SystemMonitor) CheckThresholds(stats *types.SystemStats, thresholds *t
 		})
 	}
 
+	// Check swap threshold (disabled when 0 or when the host has no swap)
+	if thresholds.SwapThreshold > 0 && stats.SwapUsage.Total > 0 && stats.SwapUsage.UsedPercent > float64(thresholds.SwapThreshold) {
+		alerts = append(alerts, types.Alert{
+			Type:      "swap",
+			Message:   fmt.Sprintf("Swap usage is %.2f%% (threshold: %d%%)", stats.SwapUsage.UsedPercent, thresholds.SwapThreshold),
+			Level:     "warning",
+			Timestamp: time.Now(),
+		})
+	}
+
+	// Check load threshold (per core, disabled when 0)
+	if thresholds.LoadThreshold > 0 && stats.LoadAverage.PerCore() > thresholds.LoadThreshold {
+		alerts = append(alerts, types.Alert{
+			Type:      "load",
+			Message:   fmt.Sprintf("Load average per core is %.2f (threshold: %.2f)", stats.LoadAverage.PerCore(), thresholds.LoadThreshold),
+			Level:     "warning",
+			Timestamp: time.Now(),
+		})
+	}
+
 	// Check disk thresholds
 	for path, diskStats := range stats.DiskUsage {
 		if diskStats.UsedPercent > float64(thresholds.DiskThreshold) {
@@ -142,6 +321,30 @@ func (sm *SystemMonitor) CheckThresholds(stats *types.SystemStats, thresholds *t
 				Timestamp: time.Now(),
 			})
 		}
+
+		// Inode exhaustion makes a disk "full" even with plenty of bytes free
+		if thresholds.InodeThreshold > 0 && diskStats.InodesTotal > 0 && diskStats.InodesUsedPercent > float64(thresholds.InodeThreshold) {
+			alerts = append(alerts, types.Alert{
+				Type:      "inode",
+				Message:   fmt.Sprintf("Inode usage on %s is %.2f%% (threshold: %d%%)", path, diskStats.InodesUsedPercent, thresholds.InodeThreshold),
+				Level:     "warning",
+				Timestamp: time.Now(),
+			})
+		}
+	}
+
+	// Check disk I/O await (disabled when 0)
+	if thresholds.DiskAwaitThreshold > 0 {
+		for device, ioStats := range stats.DiskIO {
+			if ioStats.AwaitMs > thresholds.DiskAwaitThreshold {
+				alerts = append(alerts, types.Alert{
+					Type:      "diskio",
+					Message:   fmt.Sprintf("Disk I/O await on %s is %.2f ms (threshold: %.2f ms)", device, ioStats.AwaitMs, thresholds.DiskAwaitThreshold),
+					Level:     "warning",
+					Timestamp: time.Now(),
+				})
+			}
+		}
 	}
 
 	return alerts
@@ -170,16 +373,24 @@ func (sm *SystemMonitor) GetSystemInfo() map[string]interface{} {
 func (sm *SystemMonitor) GetThresholds() map[string]interface{} {
 	if sm.config == nil {
 		return map[string]interface{}{
-			"cpu_threshold":    80,
-			"memory_threshold": 85,
-			"disk_threshold":   90,
+			"cpu_threshold":        80,
+			"memory_threshold":     85,
+			"disk_threshold":       90,
+			"load_threshold":       0.0,
+			"swap_threshold":       0,
+			"inode_threshold":      0,
+			"disk_await_threshold": 0.0,
 		}
 	}
 
 	return map[string]interface{}{
-		"cpu_threshold":    sm.config.CPUThreshold,
-		"memory_threshold": sm.config.MemoryThreshold,
-		"disk_threshold":   sm.config.DiskThreshold,
+		"cpu_threshold":        sm.config.CPUThreshold,
+		"memory_threshold":     sm.config.MemoryThreshold,
+		"disk_threshold":       sm.config.DiskThreshold,
+		"load_threshold":       sm.config.LoadThreshold,
+		"swap_threshold":       sm.config.SwapThreshold,
+		"inode_threshold":      sm.config.InodeThreshold,
+		"disk_await_threshold": sm.config.DiskAwaitThreshold,
 	}
 }
 
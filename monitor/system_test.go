@@ -1,10 +1,14 @@
 package monitor
 
 import (
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"bconf.com/monic/types"
+
+	"github.com/shirou/gopsutil/v4/disk"
 )
 
 func TestNewSystemMonitor(t *testing.T) {
@@ -47,6 +51,24 @@ func TestSystemMonitor_CollectStats(t *testing.T) {
 		t.Errorf("CPU usage out of expected range: %f", stats.CPUUsage)
 	}
 
+	// Per-core usage should have one entry per logical core
+	if len(stats.PerCoreUsage) == 0 {
+		t.Error("Expected per-core CPU usage to be collected")
+	}
+
+	// Load average is normalized by the number of logical cores
+	if stats.LoadAverage.Cores != runtime.NumCPU() {
+		t.Errorf("Expected %d cores for load average, got %d", runtime.NumCPU(), stats.LoadAverage.Cores)
+	}
+	if stats.LoadAverage.Load1 < 0 || stats.LoadAverage.Load5 < 0 || stats.LoadAverage.Load15 < 0 {
+		t.Errorf("Load average out of range: %+v", stats.LoadAverage)
+	}
+
+	// Swap may be absent, but usage must be consistent
+	if stats.SwapUsage.UsedPercent < 0 || stats.SwapUsage.UsedPercent > 100 || stats.SwapUsage.Used > stats.SwapUsage.Total {
+		t.Errorf("Swap usage out of range: %+v", stats.SwapUsage)
+	}
+
 	// Memory usage validation
 	if stats.MemoryUsage.Total == 0 {
 		t.Error("Expected total memory to be non-zero")
@@ -136,6 +158,128 @@ func TestSystemMonitor_CheckThresholds(t *testing.T) {
 	}
 }
 
+func TestSystemMonitor_CheckThresholds_Load(t *testing.T) {
+	monitor := NewSystemMonitor(nil)
+
+	stats := &types.SystemStats{
+		Timestamp:   time.Now(),
+		LoadAverage: types.LoadStats{Load1: 9, Load5: 6, Load15: 3, Cores: 4}, // 1.5 per core
+	}
+
+	// Load threshold disabled by default
+	alerts := monitor.CheckThresholds(stats, &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100})
+	if len(alerts) != 0 {
+		t.Errorf("Expected no alerts with load threshold disabled, got %d", len(alerts))
+	}
+
+	alerts = monitor.CheckThresholds(stats, &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100, LoadThreshold: 1.2})
+	if len(alerts) != 1 || alerts[0].Type != "load" {
+		t.Fatalf("Expected a single load alert, got %+v", alerts)
+	}
+	if alerts[0].Message != "Load average per core is 1.50 (threshold: 1.20)" {
+		t.Errorf("Unexpected message: %s", alerts[0].Message)
+	}
+}
+
+func TestSystemMonitor_CheckThresholds_Swap(t *testing.T) {
+	monitor := NewSystemMonitor(nil)
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100, SwapThreshold: 50}
+
+	stats := &types.SystemStats{
+		Timestamp: time.Now(),
+		SwapUsage: types.SwapStats{Total: 1024, Used: 768, UsedPercent: 75},
+	}
+
+	alerts := monitor.CheckThresholds(stats, thresholds)
+	if len(alerts) != 1 || alerts[0].Type != "swap" {
+		t.Fatalf("Expected a single swap alert, got %+v", alerts)
+	}
+
+	// Hosts without swap never alert
+	stats.SwapUsage = types.SwapStats{}
+	if alerts := monitor.CheckThresholds(stats, thresholds); len(alerts) != 0 {
+		t.Errorf("Expected no alerts without swap, got %d", len(alerts))
+	}
+}
+
+func TestSystemMonitor_CheckThresholds_Inodes(t *testing.T) {
+	monitor := NewSystemMonitor(nil)
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 90, InodeThreshold: 80}
+
+	// Plenty of bytes free, but inodes are nearly exhausted
+	stats := &types.SystemStats{
+		Timestamp: time.Now(),
+		DiskUsage: map[string]types.DiskStats{
+			"/": {Path: "/", UsedPercent: 30, InodesTotal: 1000, InodesUsed: 950, InodesUsedPercent: 95},
+		},
+	}
+
+	alerts := monitor.CheckThresholds(stats, thresholds)
+	if len(alerts) != 1 || alerts[0].Type != "inode" {
+		t.Fatalf("Expected a single inode alert, got %+v", alerts)
+	}
+
+	// Filesystems without inode accounting never alert
+	stats.DiskUsage["/"] = types.DiskStats{Path: "/", UsedPercent: 30}
+	if alerts := monitor.CheckThresholds(stats, thresholds); len(alerts) != 0 {
+		t.Errorf("Expected no alerts without inode stats, got %d", len(alerts))
+	}
+}
+
+func TestComputeDiskIO(t *testing.T) {
+	prev := map[string]disk.IOCountersStat{
+		"sda":  {Name: "sda", ReadCount: 100, WriteCount: 200, ReadBytes: 1 << 20, WriteBytes: 2 << 20, ReadTime: 500, WriteTime: 1000, IoTime: 1000},
+		"sdb":  {Name: "sdb", ReadCount: 500, WriteCount: 500},
+		"gone": {Name: "gone"},
+	}
+	curr := map[string]disk.IOCountersStat{
+		"sda": {Name: "sda", ReadCount: 300, WriteCount: 400, ReadBytes: 11 << 20, WriteBytes: 2 << 20, ReadTime: 2500, WriteTime: 1000, IoTime: 6000},
+		"sdb": {Name: "sdb", ReadCount: 10, WriteCount: 10}, // Counters reset
+		"new": {Name: "new", ReadCount: 10},
+	}
+
+	result := computeDiskIO(prev, curr, 10*time.Second)
+	if len(result) != 1 {
+		t.Fatalf("Expected stats only for sda, got %+v", result)
+	}
+
+	sda := result["sda"]
+	if sda.ReadIOPS != 20 || sda.WriteIOPS != 20 {
+		t.Errorf("Expected 20 read and write IOPS, got %.1f / %.1f", sda.ReadIOPS, sda.WriteIOPS)
+	}
+	if sda.ReadBytesPerSec != 1<<20 || sda.WriteBytesPerSec != 0 {
+		t.Errorf("Expected 1 MiB/s reads and no writes, got %.0f / %.0f", sda.ReadBytesPerSec, sda.WriteBytesPerSec)
+	}
+	if sda.ReadAwaitMs != 10 || sda.WriteAwaitMs != 0 || sda.AwaitMs != 5 {
+		t.Errorf("Unexpected await times: read %.1f, write %.1f, total %.1f", sda.ReadAwaitMs, sda.WriteAwaitMs, sda.AwaitMs)
+	}
+	if sda.UtilPercent != 50 {
+		t.Errorf("Expected 50%% utilization, got %.1f", sda.UtilPercent)
+	}
+
+	if result := computeDiskIO(prev, curr, 0); len(result) != 0 {
+		t.Errorf("Expected no stats for zero elapsed time, got %+v", result)
+	}
+}
+
+func TestSystemMonitor_CheckThresholds_DiskAwait(t *testing.T) {
+	monitor := NewSystemMonitor(nil)
+	thresholds := &types.SystemChecksConfig{CPUThreshold: 100, MemoryThreshold: 100, DiskThreshold: 100, DiskAwaitThreshold: 20}
+
+	stats := &types.SystemStats{
+		Timestamp: time.Now(),
+		DiskIO: map[string]types.DiskIOStats{
+			"sda": {Device: "sda", AwaitMs: 45},
+			"sdb": {Device: "sdb", AwaitMs: 2},
+		},
+	}
+
+	alerts := monitor.CheckThresholds(stats, thresholds)
+	if len(alerts) != 1 || alerts[0].Type != "diskio" || !strings.Contains(alerts[0].Message, "sda") {
+		t.Fatalf("Expected a single disk I/O alert for sda, got %+v", alerts)
+	}
+}
+
 func TestSystemMonitor_GetSystemInfo(t *testing.T) {
 	config := &types.SystemChecksConfig{
 		DiskPaths: []string{"/"},
@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// Connection reuse of the HTTP checks when no transport settings are configured
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 2
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// newCheckTransport creates the transport of the HTTP checks. Without keep-alives every request
+// connects and handshakes anew, e.g. to measure the full time to first byte on every run.
+func newCheckTransport(config types.HTTPTransportConfig, dns *dnsCache, keepAlives bool) *http.Transport {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: false, // Verify SSL certificates
+		},
+		MaxIdleConns:        defaultMaxIdleConns,
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+		TLSHandshakeTimeout: 10 * time.Second,
+		DisableKeepAlives:   !keepAlives,
+	}
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = time.Duration(config.IdleConnTimeout) * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	if dns != nil {
+		transport.DialContext = dns.dialContext(dialer)
+	}
+	return transport
+}
+
+// dnsCache keeps the addresses of host names for a fixed time, so checks running every few
+// seconds don't resolve their host on every new connection. Failed lookups are not cached.
+type dnsCache struct {
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+// dnsEntry is a cached lookup
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// newDNSCache creates a cache keeping lookups of the system resolver for ttl, nil for no TTL
+func newDNSCache(ttl time.Duration) *dnsCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &dnsCache{
+		ttl:     ttl,
+		lookup:  net.DefaultResolver.LookupHost,
+		entries: make(map[string]dnsEntry),
+	}
+}
+
+// resolve returns the addresses of a host, from the cache while they are fresh
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// dialContext returns a dial function connecting to the cached addresses of the host, one after
+// the other until a connection succeeds. IP addresses are dialed directly.
+func (c *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, address)
+		}
+
+		// Cached lookups are traced like lookups of the dialer, taking no time
+		trace := httptrace.ContextClientTrace(ctx)
+		if trace != nil && trace.DNSStart != nil {
+			trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+		}
+		addrs, err := c.resolve(ctx, host)
+		if trace != nil && trace.DNSDone != nil {
+			trace.DNSDone(httptrace.DNSDoneInfo{Err: err})
+		}
+		if err != nil {
+			return nil, err
+		}
+		var errs []error
+		for _, addr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+		}
+		return nil, errors.Join(errs...)
+	}
+}
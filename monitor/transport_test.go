@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// countingServer starts a server counting the connections it accepts
+func countingServer(t *testing.T, conns *int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(conns, 1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPMonitor_KeepAlives(t *testing.T) {
+	var conns int32
+	server := countingServer(t, &conns)
+
+	monitor := NewHTTPMonitor()
+	check := types.HTTPCheck{URL: server.URL, Method: "GET", Timeout: 5, ExpectedStatus: 200}
+	for i := 0; i < 3; i++ {
+		if result := monitor.CheckEndpoint(check); !result.Success {
+			t.Fatalf("Expected a successful check, got %+v", result)
+		}
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("Expected the runs to reuse one connection, got %d", n)
+	}
+
+	atomic.StoreInt32(&conns, 0)
+	check.DisableKeepAlives = true
+	for i := 0; i < 3; i++ {
+		monitor.CheckEndpoint(check)
+	}
+	if n := atomic.LoadInt32(&conns); n != 3 {
+		t.Errorf("Expected a connection per run without keep-alives, got %d", n)
+	}
+}
+
+func TestDNSCache(t *testing.T) {
+	var conns, lookups int32
+	server := countingServer(t, &conns)
+	serverURL, _ := url.Parse(server.URL)
+
+	cache := newDNSCache(time.Minute)
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&lookups, 1)
+		if host != "shop.test" {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		// The server doesn't listen on the first address, the next one is tried
+		return []string{"::1", serverURL.Hostname()}, nil
+	}
+
+	monitor := &HTTPMonitor{}
+	monitor.client = &http.Client{Transport: newCheckTransport(types.HTTPTransportConfig{}, cache, false)}
+	check := types.HTTPCheck{URL: "http://shop.test:" + serverURL.Port(), Method: "GET", Timeout: 5, ExpectedStatus: 200}
+	for i := 0; i < 3; i++ {
+		if result := monitor.CheckEndpoint(check); !result.Success {
+			t.Fatalf("Expected a successful check through the cache, got %+v", result)
+		}
+	}
+	if n := atomic.LoadInt32(&conns); lookups != 1 || n != 3 {
+		t.Errorf("Expected 3 connections after one lookup, got %d connections and %d lookups", n, lookups)
+	}
+
+	// Failed lookups are not cached
+	check.URL = "http://missing.test"
+	monitor.CheckEndpoint(check)
+	monitor.CheckEndpoint(check)
+	if lookups != 3 {
+		t.Errorf("Expected failed lookups to be repeated, got %d lookups", lookups)
+	}
+
+	// Expired entries are resolved again
+	cache.mu.Lock()
+	entry := cache.entries["shop.test"]
+	entry.expires = time.Now().Add(-time.Second)
+	cache.entries["shop.test"] = entry
+	cache.mu.Unlock()
+	if _, err := cache.resolve(context.Background(), "shop.test"); err != nil || lookups != 4 {
+		t.Errorf("Expected an expired entry to be resolved again, got %d lookups, error %v", lookups, err)
+	}
+}
+
+func TestNewDNSCache_Disabled(t *testing.T) {
+	if newDNSCache(0) != nil {
+		t.Error("Expected no cache without a TTL")
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// accessLogWriter records the status and size of a response for the access log
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (w *accessLogWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush passes flushes through, as live event streams depend on them
+func (w *accessLogWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes the connection through for WebSocket upgrades
+func (w *accessLogWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not support hijacking")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// accessLogExcluded reports whether requests to a path are left out of the access log. Patterns
+// are paths below the base path, optionally ending with "*" to match a prefix.
+func accessLogExcluded(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if pattern != "" && path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// accessLog logs every request with its method, path, status, size, duration and client, so use
+// of the API can be audited. It wraps all other handlers, so rejected requests (429, 401) are
+// logged as well.
+func accessLog(config types.AccessLogConfig, prefix string, next http.Handler) http.Handler {
+	if !config.Enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, prefix)
+		if accessLogExcluded(path, config.Exclude) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		recorder := &accessLogWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK // Nothing written
+		}
+
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"duration", time.Since(start),
+			"client", clientIP(r),
+		}
+		if username, _, ok := r.BasicAuth(); ok {
+			attrs = append(attrs, "user", username)
+		}
+		if userAgent := r.UserAgent(); userAgent != "" {
+			attrs = append(attrs, "user_agent", userAgent)
+		}
+		slog.Info("HTTP request", attrs...)
+	})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+func TestAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/monic/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/monic/api/v1/checks", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad check", http.StatusBadRequest)
+	})
+	mux.HandleFunc("/monic/api/heartbeat/{name}", func(w http.ResponseWriter, r *http.Request) {})
+	config := types.AccessLogConfig{Enabled: true, Exclude: []string{"/metrics", "/api/heartbeat/*"}}
+	handler := accessLog(config, "/monic", mux)
+
+	req := httptest.NewRequest("POST", "/monic/api/v1/checks", nil)
+	req.RemoteAddr = "192.0.2.1:4321"
+	req.SetBasicAuth("admin", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/monic/stats", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/monic/api/heartbeat/backup", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/monic/metrics", nil))
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 logged requests, got %d: %s", len(lines), logs.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Failed to parse log line: %v", err)
+	}
+	expected := map[string]interface{}{
+		"method": "POST",
+		"path":   "/monic/api/v1/checks",
+		"status": float64(http.StatusBadRequest),
+		"client": "192.0.2.1",
+		"user":   "admin",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry["duration"]; !ok {
+		t.Error("Expected the duration to be logged")
+	}
+	if strings.Contains(lines[0], "secret") {
+		t.Error("Expected the password not to be logged")
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry["status"] != float64(http.StatusOK) || entry["bytes"] != float64(2) {
+		t.Errorf("Expected a 200 response of 2 bytes, got %s", lines[1])
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	defaultAgentReportInterval = 30
+	defaultAgentReportTimeout  = 10
+	agentReportPath            = "/api/v1/agents/report"
+)
+
+// AgentReporter pushes the latest system and Docker stats of this host to a central Monic instance
+type AgentReporter struct {
+	config  *types.AgentConfig
+	host    string
+	version string
+	storage Storage
+	client  *http.Client
+	stop    chan struct{}
+	done    chan struct{}
+	running bool
+}
+
+// NewAgentReporter creates an agent reporter; host identifies this instance on the central one
+func NewAgentReporter(config *types.AgentConfig, host, version string, storage Storage) *AgentReporter {
+	if config.Host != "" {
+		host = config.Host
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultAgentReportTimeout
+	}
+
+	return &AgentReporter{
+		config:  config,
+		host:    host,
+		version: version,
+		storage: storage,
+		client:  &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start validates the configuration and starts reporting. An unreachable central instance does
+// not fail startup: failed reports are logged and the next report is sent on schedule.
+func (a *AgentReporter) Start() error {
+	server, err := url.Parse(a.config.ServerURL)
+	if err != nil || (server.Scheme != "http" && server.Scheme != "https") || server.Host == "" {
+		return fmt.Errorf("invalid agent server URL %q", a.config.ServerURL)
+	}
+	if a.host == "" {
+		return fmt.Errorf("agent host cannot be empty")
+	}
+	if a.config.Interval < 0 {
+		return fmt.Errorf("agent interval cannot be negative")
+	}
+
+	a.running = true
+	go a.reportLoop()
+	slog.Info("Agent reporting started", "server", a.config.ServerURL, "host", a.host, "interval", a.interval().String())
+	return nil
+}
+
+// Close stops reporting
+func (a *AgentReporter) Close() {
+	if !a.running {
+		return
+	}
+	close(a.stop)
+	<-a.done
+}
+
+// interval returns the time between reports
+func (a *AgentReporter) interval() time.Duration {
+	interval := a.config.Interval
+	if interval <= 0 {
+		interval = defaultAgentReportInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// reportLoop sends a report on every interval
+func (a *AgentReporter) reportLoop() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			if err := a.report(); err != nil {
+				slog.Warn("Failed to send agent report", "server", a.config.ServerURL, "error", err)
+			}
+		}
+	}
+}
+
+// buildReport returns the latest stats of this host
+func (a *AgentReporter) buildReport() types.AgentReport {
+	return types.AgentReport{
+		Host:       a.host,
+		Version:    a.version,
+		Interval:   int(a.interval().Seconds()),
+		System:     a.storage.GetLatestSystemStats(),
+		Containers: a.storage.GetLatestDockerContainerStats(),
+		Timestamp:  time.Now(),
+	}
+}
+
+// report sends the latest stats to the central instance
+func (a *AgentReporter) report() error {
+	body, err := json.Marshal(a.buildReport())
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(a.config.ServerURL, "/")+agentReportPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if a.config.Secret != "" {
+		if err := signRequest(req, a.config.Secret, body); err != nil {
+			return err
+		}
+	} else if a.config.Username != "" {
+		req.SetBasicAuth(a.config.Username, a.config.Password)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("central instance returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
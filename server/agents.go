@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+// maxAgentReportSize limits agent reports, which carry the stats of all containers of a host
+const maxAgentReportSize = 4 * 1024 * 1024
+
+// SetAgentMonitor enables the endpoints remote agents report to
+func (s *StatsServer) SetAgentMonitor(agents *monitor.AgentMonitor) {
+	s.agents = agents
+}
+
+// handleAgentReport handles POST /api/v1/agents/report, called by remote agents with the
+// latest system and Docker stats of their host
+func (s *StatsServer) handleAgentReport(w http.ResponseWriter, r *http.Request) {
+	if s.agents == nil || !s.config.Agents.Enabled {
+		http.Error(w, "Agents are not enabled", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAgentReportSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxAgentReportSize {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var report types.AgentReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		http.Error(w, "Invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if err := s.agents.Record(report, now); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	slog.Debug("Agent report received", "host", report.Host, "containers", len(report.Containers), "remote", r.RemoteAddr)
+	writeJSON(w, map[string]interface{}{
+		"host":        report.Host,
+		"received_at": now.Format(time.RFC3339),
+	})
+}
+
+// handleAgents handles GET /api/v1/agents: the latest report of every remote agent
+func (s *StatsServer) handleAgents(w http.ResponseWriter, r *http.Request) {
+	if s.agents == nil || !s.config.Agents.Enabled {
+		http.Error(w, "Agents are not enabled", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, agentSections(s.agents.Agents()))
+}
+
+// agentSections returns the per-host sections of the stats page and API
+func agentSections(agents []monitor.AgentStatus) []map[string]interface{} {
+	sections := make([]map[string]interface{}, 0, len(agents))
+	for _, agent := range agents {
+		containers := agent.Report.Containers
+		if containers == nil {
+			containers = []types.DockerContainerStats{}
+		}
+		sections = append(sections, map[string]interface{}{
+			"host":        agent.Report.Host,
+			"version":     agent.Report.Version,
+			"last_report": agent.ReceivedAt.Format(time.RFC3339),
+			"stale":       agent.Stale,
+			"system":      agent.Report.System,
+			"containers":  containers,
+		})
+	}
+	return sections
+}
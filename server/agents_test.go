@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+func TestAgentReporter_SignedReport(t *testing.T) {
+	config := &types.HTTPServerConfig{
+		Ingest: types.IngestConfig{Secret: "agent-secret"},
+		Agents: types.AgentsConfig{Enabled: true},
+	}
+	central := NewStatsServer(config, nil, NewStorageManager(100), nil)
+	agents := monitor.NewAgentMonitor(&config.Agents)
+	central.SetAgentMonitor(agents)
+	server := httptest.NewServer(central.routes())
+	defer server.Close()
+
+	// The agent reports the latest stats of its own storage
+	storage := NewStorageManager(100)
+	storage.AddSystemStats(types.SystemStats{CPUUsage: 42.5, Timestamp: time.Now()})
+	storage.AddDockerContainerStats([]types.DockerContainerStats{{Name: "web", Running: true}})
+
+	reporter := NewAgentReporter(&types.AgentConfig{ServerURL: server.URL + "/", Secret: "agent-secret", Interval: 15}, "web-1", "1.2.3", storage)
+	if err := reporter.report(); err != nil {
+		t.Fatalf("Expected report to be accepted, got %v", err)
+	}
+
+	statuses := agents.Agents()
+	if len(statuses) != 1 {
+		t.Fatalf("Expected 1 agent, got %d", len(statuses))
+	}
+	report := statuses[0].Report
+	if report.Host != "web-1" || report.Version != "1.2.3" || report.Interval != 15 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if report.System == nil || report.System.CPUUsage != 42.5 {
+		t.Errorf("Expected system stats in report, got %+v", report.System)
+	}
+	if len(report.Containers) != 1 || report.Containers[0].Name != "web" {
+		t.Errorf("Expected container stats in report, got %+v", report.Containers)
+	}
+
+	// A reporter with the wrong secret is rejected
+	wrong := NewAgentReporter(&types.AgentConfig{ServerURL: server.URL, Secret: "other"}, "web-2", "1.2.3", storage)
+	if err := wrong.report(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected 401 for wrong secret, got %v", err)
+	}
+
+	// The stats API lists the agent
+	req, _ := http.NewRequest("GET", server.URL+"/api/v1/agents", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var sections []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&sections); err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 1 || sections[0]["host"] != "web-1" || sections[0]["stale"] != false {
+		t.Errorf("Unexpected agents response: %+v", sections)
+	}
+}
+
+func TestStatsServer_AgentsDisabled(t *testing.T) {
+	config := &types.HTTPServerConfig{}
+	server := NewStatsServer(config, nil, NewStorageManager(100), nil)
+	server.SetAgentMonitor(monitor.NewAgentMonitor(&config.Agents))
+	mux := server.routes()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/api/v1/agents/report", strings.NewReader(`{"Host":"web-1"}`)),
+		httptest.NewRequest("GET", "/api/v1/agents", nil),
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s %s without agents enabled, got %d", req.Method, req.URL.Path, w.Code)
+		}
+	}
+}
+
+func TestAgentReporter_Start(t *testing.T) {
+	for _, serverURL := range []string{"", "central:8080", "ftp://central"} {
+		reporter := NewAgentReporter(&types.AgentConfig{ServerURL: serverURL}, "web-1", "dev", NewStorageManager(10))
+		if err := reporter.Start(); err == nil {
+			t.Errorf("Expected error for server URL %q", serverURL)
+		}
+		reporter.Close()
+	}
+}
+
+func TestStatsServer_AgentSections(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPServer:   types.HTTPServerConfig{Agents: types.AgentsConfig{Enabled: true}},
+	}
+	service := createTestMonitorService(t, config)
+	agents := monitor.NewAgentMonitor(&config.HTTPServer.Agents)
+	service.statsServer.SetAgentMonitor(agents)
+	agents.Record(types.AgentReport{
+		Host:       "db-1",
+		System:     &types.SystemStats{CPUUsage: 12.5, DiskUsage: map[string]types.DiskStats{"/": {UsedPercent: 40}}},
+		Containers: []types.DockerContainerStats{{Name: "postgres", Running: true, Status: "running"}},
+	}, time.Now())
+	agents.Record(types.AgentReport{Host: "db-2"}, time.Now().Add(-time.Hour))
+
+	w := httptest.NewRecorder()
+	service.statsServer.routes().ServeHTTP(w, httptest.NewRequest("GET", "/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Host db-1", "12.5%", "postgres", "Host db-2", "Not reporting"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected stats page to contain %q", want)
+		}
+	}
+}
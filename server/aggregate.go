@@ -0,0 +1,128 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// aggregate summarizes the samples of a metric over a time range
+type aggregate struct {
+	Count int     `json:"count"` // Zero (and all values zero) without samples
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	P95   float64 `json:"p95"`
+}
+
+// checkAggregate summarizes the response times of a check over a time range
+type checkAggregate struct {
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Failures       int       `json:"failures"`
+	ResponseTimeMs aggregate `json:"response_time_ms"`
+}
+
+// newAggregate computes the summary of a set of values; the 95th percentile uses the nearest-rank method
+func newAggregate(values []float64) aggregate {
+	if len(values) == 0 {
+		return aggregate{}
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+	return aggregate{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Avg:   sum / float64(len(sorted)),
+		P95:   sorted[rank],
+	}
+}
+
+// aggregateSystemStats summarizes the CPU and memory usage (percent) of system stats
+func aggregateSystemStats(stats []types.SystemStats) (cpu, memory aggregate) {
+	cpuValues := make([]float64, len(stats))
+	memoryValues := make([]float64, len(stats))
+	for i, s := range stats {
+		cpuValues[i] = s.CPUUsage
+		memoryValues[i] = s.MemoryUsage.UsedPercent
+	}
+	return newAggregate(cpuValues), newAggregate(memoryValues)
+}
+
+// aggregateChecks summarizes the response times of the selected checks (all when none are
+// selected), sorted by name and type
+func aggregateChecks(results []types.HTTPCheckResult, checks []string) []checkAggregate {
+	type accumulator struct {
+		aggregate checkAggregate
+		values    []float64
+	}
+	byCheck := make(map[string]*accumulator)
+
+	for _, result := range results {
+		if len(checks) > 0 && !matchesCheck(result, checks) {
+			continue
+		}
+
+		key := checkKey(result)
+		acc, exists := byCheck[key]
+		if !exists {
+			acc = &accumulator{aggregate: checkAggregate{Name: result.Name, Type: strings.SplitN(key, "/", 2)[0]}}
+			byCheck[key] = acc
+		}
+		acc.values = append(acc.values, float64(result.ResponseTime)/float64(time.Millisecond))
+		if !result.Success {
+			acc.aggregate.Failures++
+		}
+	}
+
+	aggregates := make([]checkAggregate, 0, len(byCheck))
+	for _, acc := range byCheck {
+		acc.aggregate.ResponseTimeMs = newAggregate(acc.values)
+		aggregates = append(aggregates, acc.aggregate)
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		if aggregates[i].Name != aggregates[j].Name {
+			return aggregates[i].Name < aggregates[j].Name
+		}
+		return aggregates[i].Type < aggregates[j].Type
+	})
+	return aggregates
+}
+
+// handleAggregate handles GET /api/v1/aggregate: min, max, average and 95th percentile of CPU and
+// memory usage and of check response times over a time range ("from"/"to" as in the history API,
+// or the last "window"), optionally limited to some checks with "checks"
+func (s *StatsServer) handleAggregate(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	values := r.URL.Query()
+	if err := q.applyWindow(values); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cpu, memory := aggregateSystemStats(s.storage.GetSystemStatsRange(q.From, q.To))
+	writeJSON(w, map[string]interface{}{
+		"from":           q.From.Format(time.RFC3339),
+		"to":             q.To.Format(time.RFC3339),
+		"cpu_percent":    cpu,
+		"memory_percent": memory,
+		"checks":         aggregateChecks(s.storage.GetHTTPCheckResultsRange(q.From, q.To), parseChecksParam(values)),
+	})
+}
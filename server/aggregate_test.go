@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestNewAggregate(t *testing.T) {
+	if got := newAggregate(nil); got != (aggregate{}) {
+		t.Errorf("Expected an empty aggregate without values, got %+v", got)
+	}
+
+	values := make([]float64, 0, 20)
+	for i := 20; i >= 1; i-- {
+		values = append(values, float64(i))
+	}
+	want := aggregate{Count: 20, Min: 1, Max: 20, Avg: 10.5, P95: 19}
+	if got := newAggregate(values); got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if values[0] != 20 {
+		t.Error("Expected the values to be left unsorted")
+	}
+
+	if got := newAggregate([]float64{42}); got.P95 != 42 || got.Avg != 42 {
+		t.Errorf("Expected a single value to be every statistic, got %+v", got)
+	}
+}
+
+func TestStatsServer_Aggregate(t *testing.T) {
+	storage := NewStorageManager(100)
+	server := NewStatsServer(&types.HTTPServerConfig{}, nil, storage, nil)
+	mux := server.routes()
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		ts := now.Add(-time.Duration(10-i) * time.Minute)
+		storage.AddSystemStats(types.SystemStats{Timestamp: ts, CPUUsage: float64(i), MemoryUsage: types.MemoryStats{UsedPercent: 50}})
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Timestamp: ts, ResponseTime: time.Duration(i+1) * time.Millisecond, Success: i%2 == 0})
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "db", Type: "grpc", Timestamp: ts, Success: true})
+	}
+	// Outside of every requested window
+	storage.AddSystemStats(types.SystemStats{Timestamp: now.Add(-2 * time.Hour), CPUUsage: 100})
+
+	get := func(url string) map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	response := get("/api/v1/aggregate")
+	cpu := response["cpu_percent"].(map[string]interface{})
+	if cpu["count"] != 10.0 || cpu["min"] != 0.0 || cpu["max"] != 9.0 || cpu["avg"] != 4.5 || cpu["p95"] != 9.0 {
+		t.Errorf("Unexpected CPU aggregate over the last hour: %v", cpu)
+	}
+	if memory := response["memory_percent"].(map[string]interface{}); memory["avg"] != 50.0 {
+		t.Errorf("Unexpected memory aggregate: %v", memory)
+	}
+	checks := response["checks"].([]interface{})
+	if len(checks) != 2 {
+		t.Fatalf("Expected aggregates of 2 checks, got %v", checks)
+	}
+	api := checks[0].(map[string]interface{})
+	latency := api["response_time_ms"].(map[string]interface{})
+	if api["name"] != "api" || api["type"] != "http" || api["failures"] != 5.0 || latency["max"] != 10.0 || latency["avg"] != 5.5 {
+		t.Errorf("Unexpected api aggregate: %v", api)
+	}
+
+	// The last samples of one check
+	response = get("/api/v1/aggregate?window=5m30s&checks=grpc/db")
+	if cpu := response["cpu_percent"].(map[string]interface{}); cpu["count"] != 5.0 || cpu["min"] != 5.0 {
+		t.Errorf("Expected the 5 samples of the window, got %v", cpu)
+	}
+	if checks := response["checks"].([]interface{}); len(checks) != 1 || checks[0].(map[string]interface{})["name"] != "db" {
+		t.Errorf("Expected only the db check, got %v", checks)
+	}
+
+	for _, invalid := range []string{"window=-5m", "window=1h&from=2025-01-01T00:00:00Z", "from=yesterday"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/aggregate?"+invalid, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", invalid, w.Code)
+		}
+	}
+}
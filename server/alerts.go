@@ -0,0 +1,492 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// alertHistorySize is the number of processed alerts kept for the alerts page and API
+const alertHistorySize = 5000
+
+// Page sizes of the alert history
+const (
+	defaultAlertLimit = 100
+	maxAlertLimit     = 1000
+)
+
+// Reasons an alert was not sent to the alert channels
+const (
+	suppressedPaused       = "paused"
+	suppressedAcknowledged = "acknowledged"
+	suppressedSilenced     = "silenced"
+)
+
+// alertFormProtection rejects cross-site form posts to the alert actions. Browsers resend basic
+// auth credentials on their own, so without it any site could silence alerts of a logged-in user.
+var alertFormProtection = http.NewCrossOriginProtection()
+
+// alertTracker keeps the history of processed alerts and the acknowledgements and silences set
+// from the alerts page or API. The history is written to the storage backend by the service, the
+// acknowledgements and silences are saved with the alert state; both are restored on startup. Acknowledging an alert mutes its notifications until its check
+// recovers; a silence mutes them for a fixed time, whatever the state.
+type alertTracker struct {
+	mu       sync.Mutex
+	history  []types.AlertRecord // Oldest first
+	nextID   int64
+	acks     map[string]types.AlertAck
+	silences map[string]types.AlertSilence
+}
+
+// newAlertTracker creates an empty alert tracker
+func newAlertTracker() *alertTracker {
+	return &alertTracker{
+		nextID:   1,
+		acks:     make(map[string]types.AlertAck),
+		silences: make(map[string]types.AlertSilence),
+	}
+}
+
+// restore replaces the history with the one of a previous run, oldest first; new alerts get the
+// following IDs
+func (t *alertTracker) restore(records []types.AlertRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(records) > alertHistorySize {
+		records = records[len(records)-alertHistorySize:]
+	}
+	t.history = records
+	for _, record := range records {
+		t.nextID = max(t.nextID, record.ID+1)
+	}
+}
+
+// process records alerts and returns the ones to send and the new history records. Acknowledgements
+// of checks that recovered and expired silences are dropped first, so recovery notifications are
+// always sent.
+func (t *alertTracker) process(alerts []types.Alert, states []types.AlertState, paused bool, now time.Time) ([]types.Alert, []types.AlertRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(states, now)
+
+	var send []types.Alert
+	records := make([]types.AlertRecord, 0, len(alerts))
+	for _, alert := range alerts {
+		record := types.AlertRecord{
+			ID:        t.nextID,
+			Type:      alert.Type,
+			Message:   alert.Message,
+			Level:     alert.Level,
+			Timestamp: alert.Timestamp,
+			Tags:      alert.Tags,
+		}
+		t.nextID++
+
+		switch {
+		case paused:
+			record.Suppressed = suppressedPaused
+		case t.silenced(alert.Type, now):
+			record.Suppressed = suppressedSilenced
+		case t.acknowledged(alert.Type):
+			record.Suppressed = suppressedAcknowledged
+		default:
+			send = append(send, alert)
+		}
+
+		records = append(records, record)
+	}
+	t.history = append(t.history, records...)
+	if len(t.history) > alertHistorySize {
+		t.history = t.history[len(t.history)-alertHistorySize:]
+	}
+	return send, records
+}
+
+// expire drops acknowledgements of alert types that are ok again and silences that ended
+func (t *alertTracker) expire(states []types.AlertState, now time.Time) {
+	active := activeAlertTypes(states)
+	for alertType := range t.acks {
+		if !active[alertType] {
+			delete(t.acks, alertType)
+		}
+	}
+	for alertType, silence := range t.silences {
+		if !now.Before(silence.Until) {
+			delete(t.silences, alertType)
+		}
+	}
+}
+
+// silenced reports whether an alert type is silenced
+func (t *alertTracker) silenced(alertType string, now time.Time) bool {
+	silence, exists := t.silences[alertType]
+	return exists && now.Before(silence.Until)
+}
+
+// acknowledged reports whether an alert type is acknowledged
+func (t *alertTracker) acknowledged(alertType string) bool {
+	_, exists := t.acks[alertType]
+	return exists
+}
+
+// acknowledge mutes an active alert until its check recovers
+func (t *alertTracker) acknowledge(alertType string, states []types.AlertState, by string, now time.Time) error {
+	if !activeAlertTypes(states)[alertType] {
+		return fmt.Errorf("alert %s is not active", alertType)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.acks[alertType] = types.AlertAck{By: by, At: now}
+	return nil
+}
+
+// silence mutes an alert type until the given time
+func (t *alertTracker) silence(alertType, by string, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.silences[alertType] = types.AlertSilence{By: by, Until: until}
+}
+
+// mutes returns copies of the acknowledgements and silences, to be saved with the alert state
+func (t *alertTracker) mutes() (map[string]types.AlertAck, map[string]types.AlertSilence) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return maps.Clone(t.acks), maps.Clone(t.silences)
+}
+
+// restoreMutes replaces the acknowledgements and silences with saved ones. Those of checks that
+// recovered and silences that ended meanwhile are dropped when the next alerts are processed.
+func (t *alertTracker) restoreMutes(acks map[string]types.AlertAck, silences map[string]types.AlertSilence) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.acks = make(map[string]types.AlertAck, len(acks))
+	maps.Copy(t.acks, acks)
+	t.silences = make(map[string]types.AlertSilence, len(silences))
+	maps.Copy(t.silences, silences)
+}
+
+// unsilence removes the silence and acknowledgement of an alert type
+func (t *alertTracker) unsilence(alertType string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.silences, alertType)
+	delete(t.acks, alertType)
+}
+
+// alertQuery filters and pages the alert history
+type alertQuery struct {
+	Levels []string  // Any of these levels; empty matches all
+	Types  []string  // Any of these alert types, "http_*" matches a prefix; empty matches all
+	Tags   []string  // Alerts with any of these tags; empty matches all
+	Since  time.Time // Alerts at or after this time; zero matches all
+	Limit  int
+	Offset int // Alerts to skip, counted from the newest matching one
+}
+
+// parseAlertQuery reads "level", "type" and "tag" (comma-separated), "since" (RFC3339 time, Unix seconds
+// or a duration such as 24h), "limit" (default 100, at most 1000) and "offset" from a request
+func parseAlertQuery(r *http.Request, now time.Time) (alertQuery, error) {
+	query := r.URL.Query()
+	q := alertQuery{
+		Levels: splitList(query.Get("level")),
+		Types:  splitList(query.Get("type")),
+		Tags:   splitList(query.Get("tag")),
+		Limit:  defaultAlertLimit,
+	}
+
+	if since := query.Get("since"); since != "" {
+		if d, err := time.ParseDuration(since); err == nil && d > 0 {
+			q.Since = now.Add(-d)
+		} else if t, err := parseHistoryTime(since); err == nil {
+			q.Since = t
+		} else {
+			return q, fmt.Errorf("invalid since parameter, expected RFC3339 time, Unix seconds or duration: %s", since)
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return q, fmt.Errorf("invalid limit parameter, expected positive number: %s", limit)
+		}
+		q.Limit = min(n, maxAlertLimit)
+	}
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid offset parameter, expected non-negative number: %s", offset)
+		}
+		q.Offset = n
+	}
+	return q, nil
+}
+
+// splitList splits a comma-separated parameter, skipping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// matches reports whether an alert record passes the filters
+func (q alertQuery) matches(record types.AlertRecord) bool {
+	if !q.Since.IsZero() && record.Timestamp.Before(q.Since) {
+		return false
+	}
+	if len(q.Levels) > 0 && !containsFold(q.Levels, record.Level) {
+		return false
+	}
+	if !hasAnyTag(record.Tags, q.Tags) {
+		return false
+	}
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, alertType := range q.Types {
+		if prefix, ok := strings.CutSuffix(alertType, "*"); ok && strings.HasPrefix(record.Type, prefix) {
+			return true
+		}
+		if alertType == record.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether a list contains a value, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// query returns one page of the matching alerts, newest first, and the number of matching alerts
+func (t *alertTracker) query(q alertQuery) ([]types.AlertRecord, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	page := make([]types.AlertRecord, 0, min(q.Limit, len(t.history)))
+	total := 0
+	for i := len(t.history) - 1; i >= 0; i-- {
+		if !q.matches(t.history[i]) {
+			continue
+		}
+		if total >= q.Offset && len(page) < q.Limit {
+			page = append(page, t.history[i])
+		}
+		total++
+	}
+	return page, total
+}
+
+// alertStateEntry is the state of an alert type with its acknowledgement and silence
+type alertStateEntry struct {
+	Type              string              `json:"type"`
+	State             string              `json:"state"`
+	ConsecutiveChecks int                 `json:"consecutive_checks"`
+	LastStateChange   time.Time           `json:"last_state_change"`
+	LastAlertSent     time.Time           `json:"last_alert_sent"`
+	Acknowledged      *types.AlertAck     `json:"acknowledged,omitempty"`
+	Silenced          *types.AlertSilence `json:"silenced,omitempty"`
+}
+
+// stateEntries combines alert states with their acknowledgements and silences. Silences of alert
+// types without a state yet (e.g. silenced ahead of maintenance) are listed as well.
+func (t *alertTracker) stateEntries(states []types.AlertState, now time.Time) []alertStateEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]alertStateEntry, 0, len(states))
+	known := make(map[string]bool, len(states))
+	for _, state := range states {
+		known[state.Type] = true
+		entry := alertStateEntry{
+			Type:              state.Type,
+			State:             state.CurrentState,
+			ConsecutiveChecks: state.ConsecutiveChecks,
+			LastStateChange:   state.LastStateChange,
+			LastAlertSent:     state.LastAlertSent,
+		}
+		if ack, exists := t.acks[state.Type]; exists {
+			entry.Acknowledged = &ack
+		}
+		if t.silenced(state.Type, now) {
+			silence := t.silences[state.Type]
+			entry.Silenced = &silence
+		}
+		entries = append(entries, entry)
+	}
+	for alertType, silence := range t.silences {
+		if !known[alertType] && now.Before(silence.Until) {
+			silence := silence
+			entries = append(entries, alertStateEntry{Type: alertType, State: "ok", Silenced: &silence})
+		}
+	}
+	return entries
+}
+
+// activeAlertTypes returns the alert types whose check is not ok
+func activeAlertTypes(states []types.AlertState) map[string]bool {
+	active := make(map[string]bool)
+	for _, state := range states {
+		if state.CurrentState != "ok" {
+			active[state.Type] = true
+		}
+	}
+	return active
+}
+
+// handleAlertsAPI handles GET /api/v1/alerts: one page of the history of processed alerts,
+// newest first, filtered by level, type, tag and time
+func (s *StatsServer) handleAlertsAPI(w http.ResponseWriter, r *http.Request) {
+	if s.service == nil {
+		http.Error(w, "Alerts are not available", http.StatusServiceUnavailable)
+		return
+	}
+	q, err := parseAlertQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	alerts, total := s.service.alerts.query(q)
+	writeJSON(w, map[string]interface{}{
+		"alerts": alerts,
+		"total":  total,
+		"limit":  q.Limit,
+		"offset": q.Offset,
+	})
+}
+
+// handleAlertStates handles GET /api/v1/alerts/states: the state of every alert type with its
+// consecutive checks, last change, acknowledgement and silence
+func (s *StatsServer) handleAlertStates(w http.ResponseWriter, r *http.Request) {
+	if s.service == nil {
+		http.Error(w, "Alerts are not available", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"states": s.service.alerts.stateEntries(s.service.stateManager.Snapshot(), time.Now()),
+	})
+}
+
+// handleAlertAction handles the POST endpoints acknowledging, silencing and unsilencing an alert
+// type. Forms of the alerts page are redirected back to it; API clients get the new state.
+func (s *StatsServer) handleAlertAction(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.service == nil {
+			http.Error(w, "Alerts are not available", http.StatusServiceUnavailable)
+			return
+		}
+
+		alertType := r.PathValue("type")
+		actor := requestActor(r)
+		now := time.Now()
+		states := s.service.stateManager.Snapshot()
+
+		switch action {
+		case "acknowledge":
+			if err := s.service.alerts.acknowledge(alertType, states, actor, now); err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+		case "silence":
+			duration, err := time.ParseDuration(r.FormValue("duration"))
+			if err != nil || duration <= 0 {
+				http.Error(w, "Invalid duration: use a positive duration such as 30m or 2h", http.StatusBadRequest)
+				return
+			}
+			s.service.alerts.silence(alertType, actor, now.Add(duration))
+		case "unsilence":
+			s.service.alerts.unsilence(alertType)
+		}
+		slog.Info("Alert "+action+"d", "type", alertType, "actor", actor)
+		// Saved right away, so a restart before the next alert cycle keeps the change
+		s.service.saveAlertState()
+
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			// Relative, so the redirect also works under a base path
+			w.Header().Set("Location", "../../../../alerts")
+			w.WriteHeader(http.StatusSeeOther)
+			return
+		}
+		for _, entry := range s.service.alerts.stateEntries(states, now) {
+			if entry.Type == alertType {
+				writeJSON(w, entry)
+				return
+			}
+		}
+		writeJSON(w, alertStateEntry{Type: alertType, State: "ok"})
+	}
+}
+
+// handleAlertsPage handles GET /alerts: alert states with acknowledge and silence controls, and
+// the alert history, paged and filtered with the parameters of the alerts API
+func (s *StatsServer) handleAlertsPage(w http.ResponseWriter, r *http.Request) {
+	if s.service == nil {
+		http.Error(w, "Alerts are not available", http.StatusServiceUnavailable)
+		return
+	}
+	now := time.Now()
+	q, err := parseAlertQuery(r, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries := s.service.alerts.stateEntries(s.service.stateManager.Snapshot(), now)
+	// Active and muted alerts first, then the rest by type
+	var active, other []alertStateEntry
+	for _, entry := range entries {
+		if entry.State != "ok" || entry.Silenced != nil {
+			active = append(active, entry)
+		} else {
+			other = append(other, entry)
+		}
+	}
+
+	history, total := s.service.alerts.query(q)
+	data := map[string]interface{}{
+		"active":  active,
+		"states":  other,
+		"history": history,
+		"total":   total,
+		"first":   q.Offset + 1,
+		"last":    q.Offset + len(history),
+		"level":   r.URL.Query().Get("level"),
+		"type":    r.URL.Query().Get("type"),
+		"tag":     r.URL.Query().Get("tag"),
+		"since":   r.URL.Query().Get("since"),
+	}
+	if q.Offset > 0 {
+		data["newer"] = alertPageLink(r, max(q.Offset-q.Limit, 0))
+	}
+	if q.Offset+len(history) < total {
+		data["older"] = alertPageLink(r, q.Offset+q.Limit)
+	}
+	s.renderHTML(w, "templates/alerts.html", data)
+}
+
+// alertPageLink returns the relative link to another page of the alert history with the same filters
+func alertPageLink(r *http.Request, offset int) string {
+	query := r.URL.Query()
+	query.Set("offset", strconv.Itoa(offset))
+	return "alerts?" + query.Encode()
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestAlertTracker_Process(t *testing.T) {
+	tracker := newAlertTracker()
+	now := time.Now()
+	critical := []types.AlertState{{Type: "cpu", CurrentState: "critical"}, {Type: "disk", CurrentState: "critical"}}
+
+	if err := tracker.acknowledge("cpu", critical, "admin", now); err != nil {
+		t.Fatalf("Failed to acknowledge cpu: %v", err)
+	}
+	if err := tracker.acknowledge("memory", critical, "admin", now); err == nil {
+		t.Error("Expected error when acknowledging an alert that is not active")
+	}
+	tracker.silence("disk", "admin", now.Add(time.Hour))
+
+	alerts := []types.Alert{
+		{Type: "cpu", Level: "critical", Timestamp: now},
+		{Type: "disk", Level: "critical", Timestamp: now},
+		{Type: "memory", Level: "critical", Timestamp: now},
+	}
+	send, added := tracker.process(alerts, critical, false, now)
+	if len(send) != 1 || send[0].Type != "memory" {
+		t.Fatalf("Expected only the memory alert to be sent, got %+v", send)
+	}
+	if len(added) != 3 || added[0].ID != 1 || added[2].Type != "memory" {
+		t.Errorf("Expected the new records in order, got %+v", added)
+	}
+
+	records, _ := tracker.query(alertQuery{Limit: alertHistorySize})
+	if len(records) != 3 || records[0].Type != "memory" || records[0].Suppressed != "" {
+		t.Fatalf("Expected newest record first and sent, got %+v", records)
+	}
+	if records[1].Suppressed != suppressedSilenced || records[2].Suppressed != suppressedAcknowledged {
+		t.Errorf("Expected disk silenced and cpu acknowledged, got %+v", records)
+	}
+
+	// Recovery clears the acknowledgement, so the recovery and later failures are sent again
+	recovered := []types.AlertState{{Type: "cpu", CurrentState: "ok"}, {Type: "disk", CurrentState: "critical"}}
+	send, _ = tracker.process([]types.Alert{{Type: "cpu", Level: "warning", Timestamp: now}}, recovered, false, now)
+	if len(send) != 1 {
+		t.Errorf("Expected the cpu recovery to be sent, got %+v", send)
+	}
+
+	// Expired silences no longer mute alerts
+	send, _ = tracker.process([]types.Alert{{Type: "disk", Level: "critical"}}, recovered, false, now.Add(2*time.Hour))
+	if len(send) != 1 {
+		t.Errorf("Expected the disk alert to be sent after the silence expired, got %+v", send)
+	}
+
+	// Paused alerting records alerts without sending them
+	send, _ = tracker.process([]types.Alert{{Type: "disk", Level: "critical"}}, recovered, true, now)
+	records, _ = tracker.query(alertQuery{Limit: 1})
+	if len(send) != 0 || records[0].Suppressed != suppressedPaused {
+		t.Errorf("Expected alerts not to be sent while paused, got %+v", send)
+	}
+}
+
+func TestAlertTracker_HistoryIsBounded(t *testing.T) {
+	tracker := newAlertTracker()
+	for i := 0; i < alertHistorySize+10; i++ {
+		tracker.process([]types.Alert{{Type: "cpu"}}, nil, false, time.Now())
+	}
+
+	records, _ := tracker.query(alertQuery{Limit: alertHistorySize})
+	if len(records) != alertHistorySize {
+		t.Fatalf("Expected %d records, got %d", alertHistorySize, len(records))
+	}
+	if records[0].ID != alertHistorySize+10 {
+		t.Errorf("Expected the newest record to be kept, got ID %d", records[0].ID)
+	}
+}
+
+func TestStatsServer_AlertActions(t *testing.T) {
+	config := &types.Config{
+		HTTPServer: types.HTTPServerConfig{Username: "admin", Password: "secret"},
+	}
+	service := createTestMonitorService(t, config)
+	service.storage.AddAlerts(service.stateManager.UpdateLoopState([]types.LoopStatus{
+		{Subsystem: "docker", Stalled: true, LastProgress: time.Now().Add(-time.Hour), Interval: time.Minute},
+	}))
+	service.processAlerts()
+	mux := service.statsServer.routes()
+
+	post := func(path string, form url.Values, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		req.SetBasicAuth("admin", "secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/api/v1/alerts/stall_docker/acknowledge", nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var entry alertStateEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil || entry.Acknowledged == nil || entry.Acknowledged.By != "admin" {
+		t.Errorf("Expected stall_docker acknowledged by admin, got %+v (err: %v)", entry, err)
+	}
+
+	if w := post("/api/v1/alerts/cpu/acknowledge", nil, ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 when acknowledging an alert that is not active, got %d", w.Code)
+	}
+	if w := post("/api/v1/alerts/cpu/silence", url.Values{"duration": {"forever"}}, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid duration, got %d", w.Code)
+	}
+
+	// Forms of the alerts page are redirected back to it
+	w = post("/api/v1/alerts/cpu/silence", url.Values{"duration": {"2h"}}, "text/html")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "../../../../alerts" {
+		t.Errorf("Expected redirect to the alerts page, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/alerts/states", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var states struct {
+		States []alertStateEntry `json:"states"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &states); err != nil {
+		t.Fatalf("Failed to decode states: %v", err)
+	}
+	found := make(map[string]alertStateEntry)
+	for _, entry := range states.States {
+		found[entry.Type] = entry
+	}
+	if found["stall_docker"].State != "critical" || found["stall_docker"].ConsecutiveChecks != 1 {
+		t.Errorf("Expected stall_docker critical, got %+v", found["stall_docker"])
+	}
+	if found["cpu"].Silenced == nil {
+		t.Errorf("Expected cpu to be listed as silenced, got %+v", states.States)
+	}
+
+	if w := post("/api/v1/alerts/cpu/unsilence", nil, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 when unsilencing, got %d", w.Code)
+	}
+	if entries := service.alerts.stateEntries(service.stateManager.Snapshot(), time.Now()); len(entries) != 1 {
+		t.Errorf("Expected the cpu silence to be removed, got %+v", entries)
+	}
+}
+
+func TestStatsServer_AlertActionsSurviveRestart(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	service.storage.AddAlerts(service.stateManager.UpdateLoopState([]types.LoopStatus{
+		{Subsystem: "docker", Stalled: true, LastProgress: time.Now().Add(-time.Hour), Interval: time.Minute},
+	}))
+	service.processAlerts()
+	mux := service.statsServer.routes()
+
+	// The actions save the state themselves, without waiting for the next alert cycle
+	for _, path := range []string{"/api/v1/alerts/stall_docker/acknowledge", "/api/v1/alerts/cpu/silence?duration=2h"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for %s, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	restarted := createTestMonitorService(t, &types.Config{})
+	restarted.storage = service.storage
+	restarted.restoreAlertState()
+	found := make(map[string]alertStateEntry)
+	for _, entry := range restarted.alerts.stateEntries(restarted.stateManager.Snapshot(), time.Now()) {
+		found[entry.Type] = entry
+	}
+	if found["stall_docker"].Acknowledged == nil {
+		t.Errorf("Expected stall_docker to stay acknowledged after a restart, got %+v", found["stall_docker"])
+	}
+	if found["cpu"].Silenced == nil {
+		t.Errorf("Expected cpu to stay silenced after a restart, got %+v", found["cpu"])
+	}
+}
+
+func TestStatsServer_AlertActionsRejectCrossOriginForms(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	mux := service.statsServer.routes()
+
+	req := httptest.NewRequest("POST", "/api/v1/alerts/cpu/silence", strings.NewReader("duration=1h"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a cross-site form post, got %d", w.Code)
+	}
+}
+
+func TestStatsServer_AlertsPage(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	service.storage.AddAlerts(service.stateManager.UpdateLoopState([]types.LoopStatus{
+		{Subsystem: "http", Stalled: true, LastProgress: time.Now().Add(-time.Hour), Interval: time.Minute},
+	}))
+	service.processAlerts()
+	mux := service.statsServer.routes()
+
+	req := httptest.NewRequest("GET", "/alerts", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{"stall_http", "Monitoring loop http stalled", `action="api/v1/alerts/stall_http/acknowledge"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected alerts page to contain %q", want)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/alerts", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var history struct {
+		Alerts []types.AlertRecord `json:"alerts"`
+		Total  int           `json:"total"`
+		Limit  int           `json:"limit"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil || len(history.Alerts) != 1 || history.Total != 1 || history.Limit != defaultAlertLimit {
+		t.Errorf("Expected one alert in the history, got %+v (err: %v)", history, err)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/alerts?level=info", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil || len(history.Alerts) != 0 || history.Total != 0 {
+		t.Errorf("Expected no info alerts, got %+v (err: %v)", history, err)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/alerts?limit=-1", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", w.Code)
+	}
+}
+
+func TestAlertTracker_Query(t *testing.T) {
+	tracker := newAlertTracker()
+	now := time.Now()
+	tracker.process([]types.Alert{
+		{Type: "cpu", Level: "critical", Timestamp: now.Add(-2 * time.Hour)},
+		{Type: "http_api", Level: "critical", Timestamp: now.Add(-30 * time.Minute)},
+		{Type: "http_db", Level: "warning", Timestamp: now.Add(-20 * time.Minute), Tags: []string{"db"}},
+		{Type: "http_api", Level: "warning", Timestamp: now.Add(-10 * time.Minute), Tags: []string{"payment-critical"}},
+	}, nil, false, now)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // Types of the returned page, newest first
+		total int
+	}{
+		{"all", "", []string{"http_api", "http_db", "http_api", "cpu"}, 4},
+		{"level", "level=critical", []string{"http_api", "cpu"}, 2},
+		{"type prefix", "type=http_*", []string{"http_api", "http_db", "http_api"}, 3},
+		{"types", "type=cpu,http_db", []string{"http_db", "cpu"}, 2},
+		{"tag", "tag=Payment-Critical", []string{"http_api"}, 1},
+		{"tags", "tag=db,payment-critical", []string{"http_api", "http_db"}, 2},
+		{"since duration", "since=1h", []string{"http_api", "http_db", "http_api"}, 3},
+		{"limit and offset", "limit=2&offset=1", []string{"http_db", "http_api"}, 4},
+		{"offset past the end", "offset=10", []string{}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseAlertQuery(httptest.NewRequest("GET", "/api/v1/alerts?"+tt.query, nil), now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			page, total := tracker.query(q)
+			if total != tt.total || len(page) != len(tt.want) {
+				t.Fatalf("Expected %d of %d alerts, got %d of %d", len(tt.want), tt.total, len(page), total)
+			}
+			for i, record := range page {
+				if record.Type != tt.want[i] {
+					t.Errorf("Expected %s at position %d, got %s", tt.want[i], i, record.Type)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAlertQuery_Invalid(t *testing.T) {
+	for _, query := range []string{"limit=0", "limit=abc", "offset=-1", "since=yesterday"} {
+		if _, err := parseAlertQuery(httptest.NewRequest("GET", "/api/v1/alerts?"+query, nil), time.Now()); err == nil {
+			t.Errorf("Expected error for %s", query)
+		}
+	}
+
+	q, err := parseAlertQuery(httptest.NewRequest("GET", "/api/v1/alerts?limit=100000", nil), time.Now())
+	if err != nil || q.Limit != maxAlertLimit {
+		t.Errorf("Expected the limit to be capped at %d, got %d (err: %v)", maxAlertLimit, q.Limit, err)
+	}
+}
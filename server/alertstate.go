@@ -0,0 +1,60 @@
+package server
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// instanceName identifies this instance's saved state in a storage backend shared by several
+// instances: the app name, or the hostname if none is configured
+func (ms *MonitorService) instanceName() string {
+	if ms.config.AppName != "" {
+		return ms.config.AppName
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// restoreAlertState loads the alert history, alert states, alert send times, acknowledgements and
+// silences saved by the previous run, so a restart keeps the alerts page and muted alerts,
+// continues consecutive failure counts and keeps cooldowns instead of alerting again
+func (ms *MonitorService) restoreAlertState() {
+	ms.alerts.restore(ms.storage.GetAlertRecords(alertHistorySize))
+
+	saved, err := ms.storage.LoadAlertState(ms.instanceName())
+	if err != nil {
+		slog.Error("Failed to load saved alert state, starting with a clean state", "error", err)
+		return
+	}
+	if saved == nil {
+		return
+	}
+
+	ms.stateManager.RestoreStates(saved.States)
+	ms.alertManager.RestoreLastSent(saved.LastSent)
+	ms.alerts.restoreMutes(saved.Acks, saved.Silences)
+	slog.Info("Restored alert state", "states", len(saved.States), "acknowledged", len(saved.Acks), "silenced", len(saved.Silences), "saved_at", saved.SavedAt)
+}
+
+// saveAlertState saves the alert states, alert send times, acknowledgements and silences for the
+// next run
+func (ms *MonitorService) saveAlertState() {
+	// Taken before the snapshot, so an older snapshot can't overwrite a newer one
+	ms.saveStateMu.Lock()
+	defer ms.saveStateMu.Unlock()
+
+	acks, silences := ms.alerts.mutes()
+	state := types.SavedAlertState{
+		States:   ms.stateManager.Snapshot(),
+		LastSent: ms.alertManager.LastSent(),
+		Acks:     acks,
+		Silences: silences,
+		SavedAt:  time.Now(),
+	}
+	if err := ms.storage.SaveAlertState(ms.instanceName(), state); err != nil {
+		slog.Error("Failed to save alert state", "error", err)
+	}
+}
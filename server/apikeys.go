@@ -0,0 +1,256 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API key scopes
+const (
+	ScopeReadStats     = "read:stats"     // Dashboards, metrics, status and history reads
+	ScopeWriteSilences = "write:silences" // Pausing and resuming subsystems (e.g. muting alerting)
+	ScopeAdminConfig   = "admin:config"   // History purge, support bundles (includes all other scopes)
+)
+
+// apiKeyPrefix marks Monic API keys so they are recognizable in secret scanners
+const apiKeyPrefix = "monic_"
+
+// validScopes lists all known API key scopes
+var validScopes = []string{ScopeReadStats, ScopeWriteSilences, ScopeAdminConfig}
+
+// APIKey is a stored API key. Only the SHA-256 hash of the key is kept.
+type APIKey struct {
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HasScope reports whether the key grants a scope; admin:config grants every scope
+func (k APIKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == ScopeAdminConfig {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyStore manages API keys in a JSON file. The file is re-read when it changes,
+// so keys created or revoked via the CLI apply to a running instance.
+type APIKeyStore struct {
+	path    string
+	mu      sync.Mutex
+	keys    []APIKey
+	modTime time.Time
+}
+
+// NewAPIKeyStore creates an API key store backed by the given file
+func NewAPIKeyStore(path string) *APIKeyStore {
+	return &APIKeyStore{path: path}
+}
+
+// reload re-reads the key file if it changed since it was last loaded
+func (ks *APIKeyStore) reload() error {
+	info, err := os.Stat(ks.path)
+	if errors.Is(err, os.ErrNotExist) {
+		ks.keys = nil
+		ks.modTime = time.Time{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat API key file: %w", err)
+	}
+	if info.ModTime().Equal(ks.modTime) && ks.keys != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(ks.path)
+	if err != nil {
+		return fmt.Errorf("failed to read API key file: %w", err)
+	}
+	keys := []APIKey{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("failed to parse API key file: %w", err)
+	}
+	ks.keys = keys
+	ks.modTime = info.ModTime()
+	return nil
+}
+
+// save writes all keys to the key file, readable only by the owner
+func (ks *APIKeyStore) save() error {
+	data, err := json.MarshalIndent(ks.keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode API keys: %w", err)
+	}
+	if err := os.WriteFile(ks.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write API key file: %w", err)
+	}
+	return nil
+}
+
+// List returns all keys sorted by name
+func (ks *APIKeyStore) List() ([]APIKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+	keys := append([]APIKey(nil), ks.keys...)
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name < keys[j].Name
+	})
+	return keys, nil
+}
+
+// Create adds a key with the given scopes and returns the plaintext key, which is not stored
+func (ks *APIKeyStore) Create(name string, scopes []string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("name cannot be empty")
+	}
+	if len(scopes) == 0 {
+		return "", fmt.Errorf("at least one scope is required (%s)", strings.Join(validScopes, ", "))
+	}
+	for _, scope := range scopes {
+		if !isValidScope(scope) {
+			return "", fmt.Errorf("unknown scope: %s (valid: %s)", scope, strings.Join(validScopes, ", "))
+		}
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if err := ks.reload(); err != nil {
+		return "", err
+	}
+	for _, key := range ks.keys {
+		if key.Name == name {
+			return "", fmt.Errorf("API key %s already exists", name)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	token := apiKeyPrefix + hex.EncodeToString(secret)
+
+	ks.keys = append(ks.keys, APIKey{
+		Name:      name,
+		Hash:      hashAPIKey(token),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	})
+	return token, ks.save()
+}
+
+// Revoke removes the named key
+func (ks *APIKeyStore) Revoke(name string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if err := ks.reload(); err != nil {
+		return err
+	}
+	for i, key := range ks.keys {
+		if key.Name == name {
+			ks.keys = append(ks.keys[:i], ks.keys[i+1:]...)
+			return ks.save()
+		}
+	}
+	return fmt.Errorf("API key %s not found", name)
+}
+
+// Lookup returns the key matching a plaintext token
+func (ks *APIKeyStore) Lookup(token string) (APIKey, bool) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if err := ks.reload(); err != nil {
+		return APIKey{}, false
+	}
+	hash := hashAPIKey(token)
+	for _, key := range ks.keys {
+		if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) == 1 {
+			return key, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// hashAPIKey returns the hex SHA-256 hash of a plaintext key
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// isValidScope reports whether a scope is known
+func isValidScope(scope string) bool {
+	for _, valid := range validScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKeyContextKey stores the authenticated API key name in the request context
+type apiKeyContextKey struct{}
+
+// requireScope protects an endpoint: requests with an "Authorization: Bearer <key>" header need an
+// API key with the scope, all other requests use the regular basic auth (which grants full access).
+// With API keys but no basic auth credentials configured, requests without a key are rejected
+// instead of being let through by basicAuth
+func (s *StatsServer) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			if s.apiKeys != nil && (s.config.Username == "" || s.config.Password == "") {
+				http.Error(w, "Unauthorized: API key required", http.StatusUnauthorized)
+				return
+			}
+			s.basicAuth(next)(w, r)
+			return
+		}
+
+		if s.apiKeys == nil {
+			http.Error(w, "Unauthorized: API keys are not configured", http.StatusUnauthorized)
+			return
+		}
+		if s.authLocked(w, r) {
+			return
+		}
+		key, found := s.apiKeys.Lookup(strings.TrimSpace(token))
+		if !found {
+			s.authFailed(r)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.authSucceeded(r)
+		if !key.HasScope(scope) {
+			http.Error(w, fmt.Sprintf("Forbidden: API key %s lacks scope %s", key.Name, scope), http.StatusForbidden)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key.Name)))
+	}
+}
+
+// SetAPIKeyStore enables API key authentication
+func (s *StatsServer) SetAPIKeyStore(store *APIKeyStore) {
+	s.apiKeys = store
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apikeys.json")
+	cli := NewAPIKeyStore(path)
+	running := NewAPIKeyStore(path)
+
+	if _, found := running.Lookup("monic_missing"); found {
+		t.Fatal("Expected no keys before the file exists")
+	}
+
+	token, err := cli.Create("ci", []string{ScopeReadStats})
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		t.Errorf("Expected key to start with %s, got %s", apiKeyPrefix, token)
+	}
+
+	// Keys are stored hashed
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), token) {
+		t.Error("Expected the plaintext key not to be stored")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// A running instance picks up keys created by the CLI
+	key, found := running.Lookup(token)
+	if !found || key.Name != "ci" || !key.HasScope(ScopeReadStats) || key.HasScope(ScopeAdminConfig) {
+		t.Fatalf("Unexpected lookup result: %+v (found: %v)", key, found)
+	}
+
+	if _, err := cli.Create("ci", []string{ScopeReadStats}); err == nil {
+		t.Error("Expected error for duplicate key name")
+	}
+	if _, err := cli.Create("bad", []string{"write:everything"}); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+	if _, err := cli.Create("none", nil); err == nil {
+		t.Error("Expected error for missing scopes")
+	}
+
+	if err := cli.Revoke("ci"); err != nil {
+		t.Fatalf("Failed to revoke key: %v", err)
+	}
+	if keys, _ := cli.List(); len(keys) != 0 {
+		t.Errorf("Expected no keys after revoke, got %+v", keys)
+	}
+	if err := cli.Revoke("ci"); err == nil {
+		t.Error("Expected error when revoking an unknown key")
+	}
+}
+
+func TestAPIKey_AdminGrantsAllScopes(t *testing.T) {
+	key := APIKey{Scopes: []string{ScopeAdminConfig}}
+	for _, scope := range validScopes {
+		if !key.HasScope(scope) {
+			t.Errorf("Expected admin key to have scope %s", scope)
+		}
+	}
+}
+
+func TestStatsServer_APIKeyScopes(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPServer:   types.HTTPServerConfig{Username: "admin", Password: "secret"},
+	}
+	service := createTestMonitorService(t, config)
+	service.startup.set("alerting", subsystemRunning, nil)
+
+	store := NewAPIKeyStore(filepath.Join(t.TempDir(), "apikeys.json"))
+	readKey, _ := store.Create("dashboards", []string{ScopeReadStats})
+	silenceKey, _ := store.Create("oncall", []string{ScopeWriteSilences})
+	service.statsServer.SetAPIKeyStore(store)
+	mux := service.statsServer.routes()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		code   int
+	}{
+		{"read key reads status", "GET", "/api/v1/status", readKey, http.StatusOK},
+		{"read key cannot pause", "POST", "/api/v1/subsystems/alerting/pause", readKey, http.StatusForbidden},
+		{"read key cannot purge", "DELETE", "/api/v1/history", readKey, http.StatusForbidden},
+		{"silence key pauses", "POST", "/api/v1/subsystems/alerting/pause", silenceKey, http.StatusOK},
+		{"silence key cannot read", "GET", "/api/v1/status", silenceKey, http.StatusForbidden},
+		{"unknown key", "GET", "/api/v1/status", "monic_unknown", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("Expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Basic auth keeps full access
+	req := httptest.NewRequest("DELETE", "/api/v1/history", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 with basic auth, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestStatsServer_APIKeysWithoutBasicAuth(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+	}
+	service := createTestMonitorService(t, config)
+
+	store := NewAPIKeyStore(filepath.Join(t.TempDir(), "apikeys.json"))
+	readKey, _ := store.Create("dashboards", []string{ScopeReadStats})
+	service.statsServer.SetAPIKeyStore(store)
+	mux := service.statsServer.routes()
+
+	// Without basic auth credentials, a request without a key must not get full access
+	for _, tt := range []struct{ method, path string }{{"GET", "/api/v1/status"}, {"DELETE", "/api/v1/history"}} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for %s %s without a key, got %d", tt.method, tt.path, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/status", nil)
+	req.Header.Set("Authorization", "Bearer "+readKey)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 with a key, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bconf.com/monic/types"
+)
+
+// Badge colors (shields.io palette)
+const (
+	badgeLabelColor   = "#555"
+	badgeUpColor      = "#4c1"
+	badgeDownColor    = "#e05d44"
+	badgeUnknownColor = "#9f9f9f"
+)
+
+// badgeCharWidth approximates the width of a character in 11px Verdana; badgePadding is added
+// on both sides of each half
+const (
+	badgeCharWidth = 7
+	badgePadding   = 6
+)
+
+// badgeSVG is the flat shields.io badge layout: label width, total width, message width, color,
+// then the text positions and texts
+const badgeSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="%[2]d" height="20" role="img" aria-label="%[6]s: %[7]s">
+<title>%[6]s: %[7]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[2]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[1]d" height="20" fill="` + badgeLabelColor + `"/><rect x="%[1]d" width="%[3]d" height="20" fill="%[4]s"/><rect width="%[2]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[5]d" y="15" fill="#010101" fill-opacity=".3">%[6]s</text><text x="%[5]d" y="14">%[6]s</text>
+<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[7]s</text><text x="%[8]d" y="14">%[7]s</text>
+</g>
+</svg>
+`
+
+// renderBadge renders a flat badge with a gray label and a colored message
+func renderBadge(label, message, color string) string {
+	labelWidth := utf8.RuneCountInString(label)*badgeCharWidth + 2*badgePadding
+	messageWidth := utf8.RuneCountInString(message)*badgeCharWidth + 2*badgePadding
+	return fmt.Sprintf(badgeSVG, labelWidth, labelWidth+messageWidth, messageWidth, color,
+		labelWidth/2, html.EscapeString(label), html.EscapeString(message), labelWidth+messageWidth/2)
+}
+
+// badgeMessage returns the badge message and color of a check result, e.g. "up 123ms"
+func badgeMessage(result types.HTTPCheckResult) (string, string) {
+	switch {
+	case result.Inverted && result.Success:
+		return "unreachable", badgeUpColor
+	case result.Inverted:
+		return "exposed", badgeDownColor
+	case !result.Success:
+		return "down", badgeDownColor
+	}
+
+	message := "up"
+	if result.ResponseTime > 0 {
+		message += " " + formatBadgeDuration(result.ResponseTime)
+	}
+	return message, badgeUpColor
+}
+
+// formatBadgeDuration formats a response time compactly: milliseconds below a second, else seconds
+func formatBadgeDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// badgeResult returns the latest result of a check allowed on badges
+func (s *StatsServer) badgeResult(check string) (types.HTTPCheckResult, bool) {
+	for _, result := range latestCheckResults(s.storage) {
+		if !matchesCheck(result, []string{check}) {
+			continue
+		}
+		if allowed := s.config.Badges.Checks; len(allowed) > 0 && !matchesCheck(result, allowed) {
+			continue
+		}
+		return result, true
+	}
+	return types.HTTPCheckResult{}, false
+}
+
+// handleBadge handles GET /badge/{check} (public, no authentication): an SVG badge with the state
+// and response time of a check, selected by "type/name" or plain name. An optional ".svg" suffix
+// is ignored and "label" replaces the check name on the badge.
+func (s *StatsServer) handleBadge(w http.ResponseWriter, r *http.Request) {
+	check := strings.TrimSuffix(r.PathValue("check"), ".svg")
+
+	label := r.URL.Query().Get("label")
+	if label == "" {
+		label = check
+	}
+
+	// Badges are embedded in READMEs and wikis; image proxies must not cache a stale state
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	result, found := s.badgeResult(check)
+	if !found {
+		// Unknown and non-public checks look the same, so badges do not reveal check names
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, renderBadge(label, "unknown", badgeUnknownColor))
+		return
+	}
+
+	message, color := badgeMessage(result)
+	fmt.Fprint(w, renderBadge(label, message, color))
+}
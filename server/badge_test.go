@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestBadgeMessage(t *testing.T) {
+	tests := []struct {
+		result  types.HTTPCheckResult
+		message string
+		color   string
+	}{
+		{types.HTTPCheckResult{Success: true, ResponseTime: 123 * time.Millisecond}, "up 123ms", badgeUpColor},
+		{types.HTTPCheckResult{Success: true, ResponseTime: 2500 * time.Millisecond}, "up 2.5s", badgeUpColor},
+		{types.HTTPCheckResult{Success: true}, "up", badgeUpColor},
+		{types.HTTPCheckResult{Success: false, ResponseTime: time.Second}, "down", badgeDownColor},
+		{types.HTTPCheckResult{Success: true, Inverted: true}, "unreachable", badgeUpColor},
+		{types.HTTPCheckResult{Success: false, Inverted: true}, "exposed", badgeDownColor},
+	}
+
+	for _, tt := range tests {
+		message, color := badgeMessage(tt.result)
+		if message != tt.message || color != tt.color {
+			t.Errorf("badgeMessage(%+v) = %q, %q, want %q, %q", tt.result, message, color, tt.message, tt.color)
+		}
+	}
+}
+
+func TestStatsServer_HandleBadge(t *testing.T) {
+	storage := NewStorageManager(10)
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Type: "http", Success: true, ResponseTime: 42 * time.Millisecond, Timestamp: time.Now()})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "db", Type: "grpc", Success: false, Timestamp: time.Now()})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "internal", Type: "http", Success: true, Timestamp: time.Now()})
+
+	config := &types.HTTPServerConfig{
+		Enabled:  true,
+		Username: "admin",
+		Password: "secret",
+		Badges:   types.BadgesConfig{Enabled: true, Checks: []string{"api", "grpc/db"}},
+	}
+	handler := NewStatsServer(config, nil, storage, nil).routes()
+
+	tests := []struct {
+		path     string
+		status   int
+		contains string
+	}{
+		{"/badge/api", http.StatusOK, "api: up 42ms"},
+		{"/badge/api.svg?label=API%20%3CEU%3E", http.StatusOK, "API &lt;EU&gt;: up 42ms"},
+		{"/badge/grpc/db", http.StatusOK, "grpc/db: down"},
+		{"/badge/internal", http.StatusNotFound, "internal: unknown"}, // Not in the allowed checks
+		{"/badge/missing", http.StatusNotFound, "missing: unknown"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d without authentication, got %d", tt.path, tt.status, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+			t.Errorf("%s: expected SVG content type, got %q", tt.path, ct)
+		}
+		if body := w.Body.String(); !strings.Contains(body, "<title>"+tt.contains+"</title>") {
+			t.Errorf("%s: expected badge %q, got %s", tt.path, tt.contains, body)
+		}
+	}
+}
+
+func TestStatsServer_HandleBadge_Disabled(t *testing.T) {
+	handler := NewStatsServer(&types.HTTPServerConfig{Enabled: true}, nil, NewStorageManager(10), nil).routes()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/badge/api", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when badges are disabled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"bconf.com/monic/types"
+)
+
+// defaultDashboardTitle is the dashboard title when no branding title is configured
+const defaultDashboardTitle = "Monic Status"
+
+// logoPath serves the branding logo; the pages reference it relatively
+const logoPath = "/branding/logo"
+
+// cssColorPattern accepts hex colors, color names and rgb()/hsl() functions, nothing that could
+// end the CSS declaration it is inserted into
+var cssColorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|(rgb|rgba|hsl|hsla)\([0-9.,%/ ]+\))$`)
+
+// brandingColors maps the color settings to the CSS variables of the templates
+func brandingColors(config types.BrandingConfig) [][2]string {
+	return [][2]string{
+		{"--accent", config.AccentColor},
+		{"--bg-color", config.BackgroundColor},
+		{"--card-bg", config.CardColor},
+		{"--text-color", config.TextColor},
+	}
+}
+
+// brandingData is the branding passed to every template as .branding
+func brandingData(config types.BrandingConfig) map[string]interface{} {
+	title := config.Title
+	if title == "" {
+		title = defaultDashboardTitle
+	}
+
+	// Overrides the template's default colors; invalid colors are rejected at startup and skipped here
+	var style strings.Builder
+	for _, color := range brandingColors(config) {
+		if color[1] != "" && cssColorPattern.MatchString(color[1]) {
+			fmt.Fprintf(&style, "%s: %s; ", color[0], color[1])
+		}
+	}
+	css := ""
+	if style.Len() > 0 {
+		css = ":root { " + style.String() + "}"
+	}
+
+	return map[string]interface{}{
+		"title": title,
+		"logo":  config.LogoFile != "",
+		"style": template.CSS(css),
+	}
+}
+
+// validateBranding checks the colors, the logo and the custom templates
+func validateBranding(config types.BrandingConfig) error {
+	for _, color := range brandingColors(config) {
+		if color[1] != "" && !cssColorPattern.MatchString(color[1]) {
+			return fmt.Errorf("invalid color %q for %s", color[1], color[0])
+		}
+	}
+	if config.LogoFile != "" {
+		if _, err := os.Stat(config.LogoFile); err != nil {
+			return fmt.Errorf("logo file is not readable: %w", err)
+		}
+	}
+	return validateTemplates(config.TemplateDir)
+}
+
+// handleLogo handles GET /branding/logo (public, shown on the status page as well)
+func (s *StatsServer) handleLogo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	http.ServeFile(w, r, s.config.Branding.LogoFile)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+func TestValidateBranding(t *testing.T) {
+	dir := t.TempDir()
+	logo := filepath.Join(dir, "logo.svg")
+	os.WriteFile(logo, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644)
+
+	valid := types.BrandingConfig{LogoFile: logo, AccentColor: "#ff6600", BackgroundColor: "white", CardColor: "rgb(10, 20, 30)", TemplateDir: dir}
+	if err := validateBranding(valid); err != nil {
+		t.Errorf("Expected valid branding, got %v", err)
+	}
+
+	invalid := []types.BrandingConfig{
+		{AccentColor: "red; } body { display: none"},
+		{TextColor: "url(http://example.com/x.png)"},
+		{LogoFile: filepath.Join(dir, "missing.png")},
+		{TemplateDir: filepath.Join(dir, "missing")},
+	}
+	for _, config := range invalid {
+		if err := validateBranding(config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+
+	os.WriteFile(filepath.Join(dir, "status.html"), []byte("{{if}}"), 0644)
+	if err := validateBranding(types.BrandingConfig{TemplateDir: dir}); err == nil || !strings.Contains(err.Error(), "status.html") {
+		t.Errorf("Expected error for the broken status template, got %v", err)
+	}
+}
+
+func TestStatsServer_BrandedPages(t *testing.T) {
+	dir := t.TempDir()
+	logo := filepath.Join(dir, "logo.png")
+	os.WriteFile(logo, []byte("\x89PNG\r\n\x1a\n"), 0644)
+
+	config := &types.HTTPServerConfig{
+		Enabled:    true,
+		StatusPage: types.StatusPageConfig{Enabled: true, Title: "Acme Status"},
+		Branding:   types.BrandingConfig{Title: "Acme Monitoring", LogoFile: logo, AccentColor: "#ff6600", TemplateDir: dir},
+	}
+	server := NewStatsServer(config, nil, NewStorageManager(10), nil)
+	mux := server.routes()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/latency", nil))
+	body := w.Body.String()
+	for _, want := range []string{"Latency Comparison - Acme Monitoring", `src="branding/logo"`, "--accent: #ff6600;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected latency page to contain %q", want)
+		}
+	}
+
+	// The logo is public, like the status page
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/branding/logo", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected the logo, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// A custom template replaces the embedded one
+	os.WriteFile(filepath.Join(dir, "status.html"), []byte(`<h1>{{.branding.title}}: {{.status}}</h1>`), 0644)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	if body := w.Body.String(); body != "<h1>Acme Monitoring: operational</h1>" {
+		t.Errorf("Expected the custom status template, got %q", body)
+	}
+
+	// A broken custom template falls back to the embedded one
+	os.WriteFile(filepath.Join(dir, "status.html"), []byte(`{{template "missing"}}`), 0644)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<title>Acme Status</title>") {
+		t.Errorf("Expected the embedded status page, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"bconf.com/monic/config"
+	"bconf.com/monic/types"
+)
+
+// supportBundlePath is the HTTP path serving support bundles
+const supportBundlePath = "/api/v1/debug/bundle"
+
+// SupportBundle collects diagnostic data (redacted config, recent logs, storage counters,
+// goroutine dump and version) into a tar.gz archive that can be attached to bug reports
+type SupportBundle struct {
+	config  *types.Config
+	version string
+	logs    *LogBuffer // Optional: nil when logs are not captured (e.g. offline CLI bundle)
+	storage Storage    // Optional: nil when no monitoring data is available
+}
+
+// NewSupportBundle creates a new support bundle builder
+func NewSupportBundle(config *types.Config, version string, logs *LogBuffer, storage Storage) *SupportBundle {
+	return &SupportBundle{
+		config:  config,
+		version: version,
+		logs:    logs,
+		storage: storage,
+	}
+}
+
+// FileName returns a timestamped file name for a bundle archive
+func (b *SupportBundle) FileName() string {
+	return fmt.Sprintf("monic-support-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+}
+
+// WriteArchive writes the bundle as a gzip-compressed tar archive
+func (b *SupportBundle) WriteArchive(w io.Writer) error {
+	redactedConfig, secrets := config.Redact(b.config)
+
+	configJSON, err := json.MarshalIndent(redactedConfig, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	var goroutines bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		return fmt.Errorf("failed to dump goroutines: %w", err)
+	}
+
+	storageJSON := []byte("{}")
+	if b.storage != nil {
+		if storageJSON, err = json.MarshalIndent(b.storage.GetStatus(), "", "  "); err != nil {
+			return fmt.Errorf("failed to encode storage counters: %w", err)
+		}
+	}
+
+	var logs string
+	if b.logs != nil {
+		logs = scrubSecrets(strings.Join(b.logs.Lines(), "\n"), secrets)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"version.txt", []byte(b.versionInfo())},
+		{"config.json", configJSON},
+		{"logs.txt", []byte(logs)},
+		{"storage.json", storageJSON},
+		{"goroutines.txt", goroutines.Bytes()},
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    "monic-support/" + file.name,
+			Mode:    0644,
+			Size:    int64(len(file.data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s header: %w", file.name, err)
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// versionInfo returns version and runtime details
+func (b *SupportBundle) versionInfo() string {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return fmt.Sprintf("Monic v%s\nGo: %s\nOS/Arch: %s/%s\nCPUs: %d\nGoroutines: %d\nHeap Alloc: %d bytes\nGenerated: %s\n",
+		b.version,
+		runtime.Version(),
+		runtime.GOOS, runtime.GOARCH,
+		runtime.NumCPU(),
+		runtime.NumGoroutine(),
+		mem.HeapAlloc,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+}
+
+// scrubSecrets replaces any occurrence of the given secret values in text
+func scrubSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, "[REDACTED]")
+	}
+	return text
+}
+
+// SetSupportBundle enables the support bundle endpoint
+func (s *StatsServer) SetSupportBundle(bundle *SupportBundle) {
+	s.bundle = bundle
+}
+
+// handleSupportBundle handles the /api/v1/debug/bundle endpoint
+func (s *StatsServer) handleSupportBundle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.bundle == nil {
+		http.Error(w, "Support bundle not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Build the archive in memory so errors can still be reported with a proper status code
+	var archive bytes.Buffer
+	if err := s.bundle.WriteArchive(&archive); err != nil {
+		slog.Error("Error creating support bundle", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", s.bundle.FileName()))
+	if _, err := w.Write(archive.Bytes()); err != nil {
+		slog.Error("Error writing support bundle response", "error", err)
+	}
+}
+
+// FetchSupportBundle downloads a support bundle from a running Monic instance
+func FetchSupportBundle(config *types.HTTPServerConfig, w io.Writer) error {
+	url := localURL(config, supportBundlePath)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if config.Username != "" && config.Password != "" {
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	client, err := localClient(config, 30*time.Second)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+// readBundle extracts the files of a support bundle archive
+func readBundle(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("Failed to open gzip stream: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", header.Name, err)
+		}
+		files[strings.TrimPrefix(header.Name, "monic-support/")] = string(data)
+	}
+	return files
+}
+
+func TestSupportBundle_WriteArchive(t *testing.T) {
+	config := &types.Config{
+		AppName: "TestApp",
+		Alerting: types.AlertingConfig{
+			Telegram: types.TelegramConfig{Enabled: true, BotToken: "super-secret-token", ChatID: "chat"},
+		},
+		HTTPServer: types.HTTPServerConfig{Port: 8080, Username: "admin", Password: "hunter2"},
+	}
+
+	logs := NewLogBuffer(10)
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+	logger.Info("Sending alert", "token", "super-secret-token")
+
+	storage := NewStorageManager(10)
+	storage.AddAlert(types.Alert{Type: "cpu"})
+
+	bundle := NewSupportBundle(config, "1.2.3", logs, storage)
+
+	var archive strings.Builder
+	if err := bundle.WriteArchive(&archive); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	files := readBundle(t, strings.NewReader(archive.String()))
+
+	for _, name := range []string{"version.txt", "config.json", "logs.txt", "storage.json", "goroutines.txt"} {
+		if _, exists := files[name]; !exists {
+			t.Errorf("Expected bundle to contain %s", name)
+		}
+	}
+
+	if !strings.Contains(files["version.txt"], "Monic v1.2.3") {
+		t.Errorf("Expected version in bundle, got: %s", files["version.txt"])
+	}
+	if !strings.Contains(files["config.json"], `"AppName": "TestApp"`) {
+		t.Error("Expected effective config in bundle")
+	}
+	if !strings.Contains(files["storage.json"], `"alerts_count": 1`) {
+		t.Errorf("Expected storage counters in bundle, got: %s", files["storage.json"])
+	}
+	if !strings.Contains(files["logs.txt"], "Sending alert") {
+		t.Error("Expected recent logs in bundle")
+	}
+	if !strings.Contains(files["goroutines.txt"], "goroutine") {
+		t.Error("Expected goroutine dump in bundle")
+	}
+
+	// Secrets must not leak through config or logs
+	for name, content := range files {
+		if strings.Contains(content, "super-secret-token") || strings.Contains(content, "hunter2") {
+			t.Errorf("Secret leaked in %s", name)
+		}
+	}
+}
+
+func TestStatsServer_HandleSupportBundle(t *testing.T) {
+	config := &types.HTTPServerConfig{Enabled: true, Port: 8080}
+	server := NewStatsServer(config, nil, NewStorageManager(10), nil)
+
+	req := httptest.NewRequest("GET", supportBundlePath, nil)
+	w := httptest.NewRecorder()
+	server.handleSupportBundle(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d without bundle, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	server.SetSupportBundle(NewSupportBundle(&types.Config{}, "dev", nil, nil))
+	w = httptest.NewRecorder()
+	server.handleSupportBundle(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/gzip" {
+		t.Errorf("Expected gzip content type, got '%s'", w.Header().Get("Content-Type"))
+	}
+
+	files := readBundle(t, w.Body)
+	if _, exists := files["config.json"]; !exists {
+		t.Error("Expected bundle to contain config.json")
+	}
+}
+
+func TestLogBuffer_KeepsRecentLines(t *testing.T) {
+	buffer := NewLogBuffer(2)
+	buffer.Write([]byte("one\n"))
+	buffer.Write([]byte("two\nthree\n"))
+
+	lines := buffer.Lines()
+	if len(lines) != 2 || lines[0] != "two" || lines[1] != "three" {
+		t.Errorf("Expected [two three], got %v", lines)
+	}
+}
+
+func TestNewLogHandler(t *testing.T) {
+	var out strings.Builder
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+
+	slog.New(NewLogHandler("text", &out, level)).Info("hidden")
+	slog.New(NewLogHandler("text", &out, level)).Warn("Check failed", "check", "api")
+	slog.New(NewLogHandler("", &out, level)).Error("Check failed", "check", "db")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected entries at or above the level, got %q", out.String())
+	}
+	if !strings.Contains(lines[0], `level=WARN msg="Check failed" check=api`) {
+		t.Errorf("Expected a text entry, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `"level":"ERROR","msg":"Check failed","check":"db"`) {
+		t.Errorf("Expected a JSON entry by default, got %q", lines[1])
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Sparkline layout of the dashboard charts
+const (
+	sparklineWidth  = 300
+	sparklineHeight = 40
+	// dashboardChartWindow is the history shown by the dashboard charts
+	dashboardChartWindow = time.Hour
+)
+
+// chartPoint is a single sample of a chart
+type chartPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// sparkline is a small trend chart rendered as an inline SVG polyline
+type sparkline struct {
+	Points string  // SVG polyline points attribute
+	Max    float64 // Value at the top of the chart
+}
+
+// buildSparkline scales points into the sparkline box, spreading the window over its width. With
+// maxValue 0 the chart is scaled to the largest value. Returns nil for less than two points.
+func buildSparkline(points []chartPoint, since time.Time, window time.Duration, maxValue float64) *sparkline {
+	if len(points) < 2 {
+		return nil
+	}
+
+	if maxValue <= 0 {
+		for _, p := range points {
+			if p.Value > maxValue {
+				maxValue = p.Value
+			}
+		}
+		if maxValue == 0 {
+			maxValue = 1
+		}
+	}
+
+	coords := make([]string, 0, len(points))
+	for _, p := range points {
+		x := sparklineWidth * float64(p.Time.Sub(since)) / float64(window)
+		// Keep a pixel of room so lines at 0 or the maximum stay visible
+		y := 1 + (sparklineHeight-2)*(1-min(p.Value, maxValue)/maxValue)
+		coords = append(coords, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	return &sparkline{Points: strings.Join(coords, " "), Max: maxValue}
+}
+
+// dashboardCharts builds the CPU, memory and per-check latency sparklines of the dashboard from
+// the stored history of the last hour
+func (s *StatsServer) dashboardCharts(now time.Time) map[string]interface{} {
+	since := now.Add(-dashboardChartWindow)
+
+	var cpu, memory []chartPoint
+	for _, stats := range s.storage.GetSystemStatsRange(since, now) {
+		cpu = append(cpu, chartPoint{stats.Timestamp, stats.CPUUsage})
+		memory = append(memory, chartPoint{stats.Timestamp, stats.MemoryUsage.UsedPercent})
+	}
+
+	// Average latency per minute, keyed by check name like the dashboard's check table
+	query := latencyQuery{Window: dashboardChartWindow, Bucket: dashboardChartWindow / defaultLatencyPoints}
+	latency := make(map[string]*sparkline)
+	for _, series := range buildLatencySeries(s.storage.GetHTTPCheckResultsRange(since, now), query, now) {
+		if _, exists := latency[series.Name]; exists {
+			continue
+		}
+		var points []chartPoint
+		for _, point := range series.Points {
+			if point.Count > 0 {
+				points = append(points, chartPoint{point.Time.Add(query.Bucket / 2), point.AvgMs})
+			}
+		}
+		if chart := buildSparkline(points, since, dashboardChartWindow, 0); chart != nil {
+			latency[series.Name] = chart
+		}
+	}
+
+	return map[string]interface{}{
+		"cpu":     buildSparkline(cpu, since, dashboardChartWindow, 100),
+		"memory":  buildSparkline(memory, since, dashboardChartWindow, 100),
+		"latency": latency,
+		"width":   sparklineWidth,
+		"height":  sparklineHeight,
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+func TestBuildSparkline(t *testing.T) {
+	since := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if chart := buildSparkline([]chartPoint{{since, 50}}, since, time.Hour, 100); chart != nil {
+		t.Errorf("Expected no chart for a single point, got %+v", chart)
+	}
+
+	chart := buildSparkline([]chartPoint{{since, 0}, {since.Add(30 * time.Minute), 50}, {since.Add(time.Hour), 100}}, since, time.Hour, 100)
+	if chart == nil || chart.Points != "0.0,39.0 150.0,20.0 300.0,1.0" || chart.Max != 100 {
+		t.Errorf("Unexpected chart %+v", chart)
+	}
+
+	// Without a maximum the chart scales to the largest value
+	chart = buildSparkline([]chartPoint{{since, 10}, {since.Add(time.Hour), 20}}, since, time.Hour, 0)
+	if chart == nil || chart.Max != 20 || !strings.HasSuffix(chart.Points, "300.0,1.0") {
+		t.Errorf("Expected chart scaled to 20, got %+v", chart)
+	}
+}
+
+func TestStatsServer_DashboardCharts(t *testing.T) {
+	now := time.Now()
+	storage := NewStorageManager(100)
+	for i := 0; i < 5; i++ {
+		ts := now.Add(time.Duration(i-5) * 10 * time.Minute)
+		storage.AddSystemStats(types.SystemStats{Timestamp: ts, CPUUsage: float64(10 * i), MemoryUsage: types.MemoryStats{UsedPercent: 50}})
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", URL: "http://api", Success: true, ResponseTime: time.Duration(i+1) * 100 * time.Millisecond, Timestamp: ts})
+	}
+	// Samples older than the chart window are left out
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "old", Timestamp: now.Add(-2 * time.Hour)})
+
+	server := NewStatsServer(&types.HTTPServerConfig{Enabled: true}, nil, storage, nil)
+	charts := server.dashboardCharts(now)
+
+	if charts["cpu"].(*sparkline) == nil || charts["memory"].(*sparkline) == nil {
+		t.Fatal("Expected CPU and memory charts")
+	}
+	latency := charts["latency"].(map[string]*sparkline)
+	if latency["api"] == nil || latency["api"].Max != 500 {
+		t.Errorf("Expected latency chart of api scaled to 500 ms, got %+v", latency["api"])
+	}
+	if _, exists := latency["old"]; exists {
+		t.Error("Expected no chart for a check without recent results")
+	}
+
+	// The HTML dashboard renders the charts
+	server.systemMonitor = monitor.NewSystemMonitor(&types.SystemChecksConfig{DiskPaths: []string{"/"}})
+	w := httptest.NewRecorder()
+	server.handleStats(w, httptest.NewRequest("GET", "/stats", nil))
+	body := w.Body.String()
+	if strings.Count(body, "<polyline") != 3 || !strings.Contains(body, "max 500 ms") {
+		t.Errorf("Expected CPU, memory and latency sparklines in HTML, got %d", strings.Count(body, "<polyline"))
+	}
+}
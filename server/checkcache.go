@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// cachedRunner wraps an expensive check runner (e.g. slow synthetic or hardware checks). Its checks
+// run in the background and every cycle only reports results that completed since the previous
+// cycle, so a collection that takes longer than the interval is not a failure. Once the latest
+// results are older than maxAge, the checks are reported as failed.
+type cachedRunner struct {
+	CheckRunner
+	maxAge time.Duration
+	now    func() time.Time // Replaced in tests
+
+	mu          sync.Mutex
+	results     []types.HTTPCheckResult // Latest collected results
+	collectedAt time.Time
+	reported    bool // Latest results were returned by RunChecks
+	collecting  bool
+	startedAt   time.Time // Start of the running (or last) collection
+}
+
+// checkCacheStatus describes the cached results of a check runner in the API
+type checkCacheStatus struct {
+	Subsystem     string    `json:"subsystem"`
+	CollectedAt   time.Time `json:"collected_at"`
+	AgeSeconds    float64   `json:"age_seconds"`
+	MaxAgeSeconds float64   `json:"max_age_seconds"`
+	Stale         bool      `json:"stale"`
+	Collecting    bool      `json:"collecting"`
+}
+
+// newCachedRunner wraps a runner; a maxAge of 0 defaults to three intervals
+func newCachedRunner(runner CheckRunner, maxAge time.Duration) *cachedRunner {
+	if maxAge <= 0 {
+		maxAge = 3 * runner.Interval()
+	}
+	return &cachedRunner{
+		CheckRunner: runner,
+		maxAge:      maxAge,
+		now:         time.Now,
+	}
+}
+
+// cacheRunners wraps the runners configured as expensive
+func cacheRunners(runners []CheckRunner, caches []types.CheckCache) []CheckRunner {
+	wrapped := make([]CheckRunner, 0, len(runners))
+	for _, runner := range runners {
+		for _, cache := range caches {
+			if cache.Subsystem == runnerSubsystem(runner) {
+				runner = newCachedRunner(runner, time.Duration(cache.MaxAge)*time.Second)
+				break
+			}
+		}
+		wrapped = append(wrapped, runner)
+	}
+	return wrapped
+}
+
+// validateCheckCaches ensures every cache refers to a check runner
+func validateCheckCaches(runners []CheckRunner, caches []types.CheckCache) error {
+	for _, cache := range caches {
+		if cache.MaxAge < 0 {
+			return fmt.Errorf("check cache %s: max age cannot be negative", cache.Subsystem)
+		}
+		found := false
+		for _, runner := range runners {
+			if runnerSubsystem(runner) == cache.Subsystem {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("check cache %s: unknown check subsystem", cache.Subsystem)
+		}
+	}
+	return nil
+}
+
+// RunChecks starts a background collection if none is running and returns results that are new
+// since the previous call. While no new results are available nothing is reported, until the
+// latest results exceed the maximum age and are reported as failed.
+func (c *cachedRunner) RunChecks() []types.HTTPCheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if !c.collecting {
+		c.collecting = true
+		c.startedAt = now
+		go c.collect()
+	}
+
+	if c.results != nil && !c.reported {
+		c.reported = true
+		return c.results
+	}
+
+	// Before the first results, age is measured from the start of the first collection
+	since := c.collectedAt
+	if since.IsZero() {
+		since = c.startedAt
+	}
+	age := now.Sub(since)
+	if age <= c.maxAge || len(c.results) == 0 {
+		return nil
+	}
+
+	stale := make([]types.HTTPCheckResult, 0, len(c.results))
+	for _, result := range c.results {
+		stale = append(stale, types.HTTPCheckResult{
+			Name:      result.Name,
+			Type:      result.Type,
+			URL:       result.URL,
+			Error:     fmt.Sprintf("stale result: last collected %s ago (max age %s)", age.Round(time.Second), c.maxAge),
+			Timestamp: now,
+		})
+	}
+	return stale
+}
+
+// collect runs the wrapped checks and stores their results
+func (c *cachedRunner) collect() {
+	results := c.CheckRunner.RunChecks()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	duration := c.now().Sub(c.startedAt)
+	if duration > c.Interval() {
+		slog.Info("Expensive checks took longer than their interval, cached results were used",
+			"subsystem", runnerSubsystem(c), "duration", duration.Round(time.Millisecond).String())
+	}
+
+	c.collecting = false
+	if len(results) == 0 {
+		return
+	}
+	c.results = results
+	c.collectedAt = c.now()
+	c.reported = false
+}
+
+// status returns the age of the cached results
+func (c *cachedRunner) status() checkCacheStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := checkCacheStatus{
+		Subsystem:     runnerSubsystem(c),
+		CollectedAt:   c.collectedAt,
+		MaxAgeSeconds: c.maxAge.Seconds(),
+		Collecting:    c.collecting,
+	}
+	if !c.collectedAt.IsZero() {
+		age := c.now().Sub(c.collectedAt)
+		status.AgeSeconds = age.Seconds()
+		status.Stale = age > c.maxAge
+	}
+	return status
+}
+
+// checkCacheStatuses returns the cache status of every expensive check runner
+func (ms *MonitorService) checkCacheStatuses() []checkCacheStatus {
+	statuses := []checkCacheStatus{}
+	for _, runner := range ms.checkRunners {
+		if cached, ok := runner.(*cachedRunner); ok {
+			statuses = append(statuses, cached.status())
+		}
+	}
+	return statuses
+}
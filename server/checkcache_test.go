@@ -0,0 +1,105 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// slowRunner is a check runner whose checks block until released
+type slowRunner struct {
+	release chan struct{}
+	mu      sync.Mutex
+	runs    int
+}
+
+func (r *slowRunner) Name() string            { return "Speedtest" }
+func (r *slowRunner) Interval() time.Duration { return time.Minute }
+func (r *slowRunner) Validate() error         { return nil }
+func (r *slowRunner) RunChecks() []types.HTTPCheckResult {
+	<-r.release
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs++
+	return []types.HTTPCheckResult{{Name: "download", Type: "speedtest", Success: true}}
+}
+
+// waitForCollection waits until the background collection of a cached runner finished
+func waitForCollection(t *testing.T, runner *cachedRunner) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runner.status().Collecting {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the collection")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCachedRunner(t *testing.T) {
+	slow := &slowRunner{release: make(chan struct{})}
+	runner := newCachedRunner(slow, 5*time.Minute)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	runner.now = func() time.Time { return now }
+
+	// A running collection is not a failure
+	if results := runner.RunChecks(); results != nil {
+		t.Fatalf("Expected no results while collecting, got %+v", results)
+	}
+	if results := runner.RunChecks(); results != nil {
+		t.Fatalf("Expected no results while still collecting, got %+v", results)
+	}
+
+	slow.release <- struct{}{}
+	waitForCollection(t, runner)
+
+	// New results are reported once
+	now = now.Add(time.Minute)
+	results := runner.RunChecks()
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("Expected the collected result, got %+v", results)
+	}
+	if results := runner.RunChecks(); results != nil {
+		t.Errorf("Expected cached results not to be reported twice, got %+v", results)
+	}
+
+	status := runner.status()
+	if !status.Collecting || status.AgeSeconds != 60 || status.Stale {
+		t.Errorf("Unexpected cache status: %+v", status)
+	}
+
+	// Results older than the maximum age count as failed
+	now = now.Add(5 * time.Minute)
+	results = runner.RunChecks()
+	if len(results) != 1 || results[0].Success || !strings.HasPrefix(results[0].Error, "stale result: last collected 6m0s ago") {
+		t.Errorf("Expected a stale failure, got %+v", results)
+	}
+	if !runner.status().Stale {
+		t.Error("Expected the cache status to be stale")
+	}
+
+	close(slow.release)
+}
+
+func TestCacheRunners(t *testing.T) {
+	slow := &slowRunner{release: make(chan struct{})}
+	runners := cacheRunners([]CheckRunner{slow}, []types.CheckCache{{Subsystem: "speedtest"}})
+
+	cached, ok := runners[0].(*cachedRunner)
+	if !ok {
+		t.Fatalf("Expected the runner to be cached, got %T", runners[0])
+	}
+	if cached.maxAge != 3*time.Minute {
+		t.Errorf("Expected a default max age of 3 intervals, got %s", cached.maxAge)
+	}
+
+	if err := validateCheckCaches(runners, []types.CheckCache{{Subsystem: "smart"}}); err == nil {
+		t.Error("Expected error for a cache of an unknown subsystem")
+	}
+	if err := validateCheckCaches(runners, []types.CheckCache{{Subsystem: "speedtest", MaxAge: -1}}); err == nil {
+		t.Error("Expected error for a negative max age")
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// CheckStatus is the latest result of a check and the time of its last failure
+type CheckStatus struct {
+	Latest      types.HTTPCheckResult
+	LastFailure time.Time // Zero if the check has not failed
+}
+
+// checkIndex keeps the latest result and last failure of each check (by type and name), so the
+// stats page, badges and metrics don't scan the whole check history. Storage backends update it
+// as results are added and rebuild it from history when opened and after purges; checks stay
+// indexed when older results are trimmed to the history size.
+type checkIndex struct {
+	checksMu sync.RWMutex
+	checks   map[string]*CheckStatus
+}
+
+// indexResult records a check result; results older than the indexed ones (e.g. imported
+// history) only update what they are newer than
+func (idx *checkIndex) indexResult(result types.HTTPCheckResult) {
+	idx.checksMu.Lock()
+	defer idx.checksMu.Unlock()
+
+	if idx.checks == nil {
+		idx.checks = make(map[string]*CheckStatus)
+	}
+	idx.addLocked(result)
+}
+
+// indexResults records a batch of check results
+func (idx *checkIndex) indexResults(results []types.HTTPCheckResult) {
+	idx.checksMu.Lock()
+	defer idx.checksMu.Unlock()
+
+	if idx.checks == nil {
+		idx.checks = make(map[string]*CheckStatus)
+	}
+	for _, result := range results {
+		idx.addLocked(result)
+	}
+}
+
+// addLocked records a check result with checksMu held
+func (idx *checkIndex) addLocked(result types.HTTPCheckResult) {
+	status, exists := idx.checks[checkKey(result)]
+	if !exists {
+		status = &CheckStatus{Latest: result}
+		idx.checks[checkKey(result)] = status
+	} else if !result.Timestamp.Before(status.Latest.Timestamp) {
+		status.Latest = result
+	}
+	if !result.Success && result.Timestamp.After(status.LastFailure) {
+		status.LastFailure = result.Timestamp
+	}
+}
+
+// rebuildIndex replaces the index with the checks of a history
+func (idx *checkIndex) rebuildIndex(history []types.HTTPCheckResult) {
+	idx.checksMu.Lock()
+	defer idx.checksMu.Unlock()
+
+	idx.checks = make(map[string]*CheckStatus)
+	for _, result := range history {
+		idx.addLocked(result)
+	}
+}
+
+// GetCheckStatuses returns the status of every indexed check, sorted by name and type
+func (idx *checkIndex) GetCheckStatuses() []CheckStatus {
+	idx.checksMu.RLock()
+	statuses := make([]CheckStatus, 0, len(idx.checks))
+	for _, status := range idx.checks {
+		statuses = append(statuses, *status)
+	}
+	idx.checksMu.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Latest.Name != statuses[j].Latest.Name {
+			return statuses[i].Latest.Name < statuses[j].Latest.Name
+		}
+		return statuses[i].Latest.Type < statuses[j].Latest.Type
+	})
+	return statuses
+}
+
+// latestCheckResults returns the latest result of every check, sorted by name and type
+func latestCheckResults(storage Storage) []types.HTTPCheckResult {
+	statuses := storage.GetCheckStatuses()
+	results := make([]types.HTTPCheckResult, len(statuses))
+	for i, status := range statuses {
+		results[i] = status.Latest
+	}
+	return results
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"bconf.com/monic/types"
+)
+
+// Errors of on-demand check runs
+var (
+	errCheckNotFound  = errors.New("check not found")
+	errCheckAmbiguous = errors.New("check name is ambiguous")
+)
+
+// singleCheckRunner is implemented by check runners that can run one of their checks on its own
+type singleCheckRunner interface {
+	HasCheck(name string) bool
+	RunCheck(name string) (types.HTTPCheckResult, bool) // False if the check disappeared meanwhile
+}
+
+// runCheckNow runs the named check out of band and records its result like a scheduled run, so
+// a passing run counts towards recovering its alert. The subsystem (e.g. "grpc") is only needed
+// when several check runners have a check of that name. Cached runners are bypassed: the point is
+// a fresh result.
+func (ms *MonitorService) runCheckNow(name, subsystem string) (types.HTTPCheckResult, error) {
+	var matches []CheckRunner
+	for _, runner := range ms.checkRunners {
+		if cached, ok := runner.(*cachedRunner); ok {
+			runner = cached.CheckRunner
+		}
+		if subsystem != "" && runnerSubsystem(runner) != strings.ToLower(subsystem) {
+			continue
+		}
+		if single, ok := runner.(singleCheckRunner); ok && single.HasCheck(name) {
+			matches = append(matches, runner)
+		}
+	}
+
+	if len(matches) == 0 {
+		return types.HTTPCheckResult{}, fmt.Errorf("%w: %s", errCheckNotFound, name)
+	}
+	if len(matches) > 1 {
+		subsystems := make([]string, 0, len(matches))
+		for _, runner := range matches {
+			subsystems = append(subsystems, runnerSubsystem(runner))
+		}
+		return types.HTTPCheckResult{}, fmt.Errorf("%w: %s exists in %s, select one with ?subsystem=",
+			errCheckAmbiguous, name, strings.Join(subsystems, ", "))
+	}
+
+	runner := matches[0]
+	result, found := runner.(singleCheckRunner).RunCheck(name)
+	if !found {
+		return types.HTTPCheckResult{}, fmt.Errorf("%w: %s", errCheckNotFound, name)
+	}
+	ms.recordCheckResults(runner.Name(), []types.HTTPCheckResult{result})
+	return result, nil
+}
+
+// handleRunCheck handles POST /api/v1/checks/{name}/run: runs a check immediately and returns its
+// result, e.g. to verify a fix without waiting for the next interval
+func (s *StatsServer) handleRunCheck(w http.ResponseWriter, r *http.Request) {
+	if s.service == nil {
+		http.Error(w, "Checks are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := r.PathValue("name")
+	result, err := s.service.runCheckNow(name, r.URL.Query().Get("subsystem"))
+	switch {
+	case errors.Is(err, errCheckNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, errCheckAmbiguous):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	slog.Info("Check run on demand", "name", name, "type", result.Type, "success", result.Success, "actor", requestActor(r))
+	writeJSON(w, result)
+}
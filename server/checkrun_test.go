@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+func TestStatsServer_RunCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	service := createTestMonitorService(t, &types.Config{})
+	managed := monitor.NewManagedCheckMonitor(filepath.Join(t.TempDir(), "checks.json"), service.httpMonitor)
+	for _, check := range []types.ManagedCheck{
+		{Name: "db", Type: "tcp", Target: listener.Addr().String()},
+		{Name: "cache", Type: "tcp", Target: listener.Addr().String()},
+	} {
+		if _, err := managed.Create(check); err != nil {
+			t.Fatalf("Failed to create check: %v", err)
+		}
+	}
+	if _, err := managed.SetPaused("cache", true); err != nil {
+		t.Fatalf("Failed to pause check: %v", err)
+	}
+	scripts := monitor.NewScriptMonitor([]types.ScriptCheck{{Name: "db", Command: "true", Timeout: 5}})
+	service.checkRunners = []CheckRunner{managed, newCachedRunner(scripts, 0)}
+	mux := service.statsServer.routes()
+
+	run := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := run("/api/v1/checks/db/run"); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a name used by two subsystems, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := run("/api/v1/checks/missing/run"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown check, got %d", w.Code)
+	}
+
+	w := run("/api/v1/checks/db/run?subsystem=managed")
+	var result types.HTTPCheckResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); w.Code != http.StatusOK || err != nil || !result.Success || result.Type != "tcp" {
+		t.Fatalf("Expected a successful TCP result, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Cached runners are bypassed
+	w = run("/api/v1/checks/db/run?subsystem=script")
+	if err := json.Unmarshal(w.Body.Bytes(), &result); w.Code != http.StatusOK || err != nil || result.Type != "script" {
+		t.Errorf("Expected a script result, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Paused checks can be run by hand
+	if w := run("/api/v1/checks/cache/run"); w.Code != http.StatusOK {
+		t.Errorf("Expected a paused check to run on demand, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Results are recorded like scheduled runs
+	if results := service.storage.GetHTTPCheckResults(); len(results) != 3 {
+		t.Errorf("Expected 3 recorded results, got %d", len(results))
+	}
+	if _, exists := service.stateManager.GetStates()["tcp_db"]; !exists {
+		t.Error("Expected the result to update the alert state")
+	}
+}
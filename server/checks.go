@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+// SetManagedChecks enables the check management API
+func (s *StatsServer) SetManagedChecks(checks *monitor.ManagedCheckMonitor) {
+	s.managedChecks = checks
+}
+
+// managedChecksAvailable answers 404 when the check management API is not configured
+func (s *StatsServer) managedChecksAvailable(w http.ResponseWriter) bool {
+	if s.managedChecks == nil {
+		http.Error(w, "Check management is not configured", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// handleListChecks handles GET /api/v1/checks: all managed checks
+func (s *StatsServer) handleListChecks(w http.ResponseWriter, r *http.Request) {
+	if !s.managedChecksAvailable(w) {
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"checks": s.managedChecks.List(),
+	})
+}
+
+// handleGetCheck handles GET /api/v1/checks/{name}
+func (s *StatsServer) handleGetCheck(w http.ResponseWriter, r *http.Request) {
+	if !s.managedChecksAvailable(w) {
+		return
+	}
+	check, err := s.managedChecks.Get(r.PathValue("name"))
+	if err != nil {
+		writeCheckError(w, err)
+		return
+	}
+	writeJSON(w, check)
+}
+
+// handleCreateCheck handles POST /api/v1/checks: adds a check, which runs within seconds
+func (s *StatsServer) handleCreateCheck(w http.ResponseWriter, r *http.Request) {
+	if !s.managedChecksAvailable(w) {
+		return
+	}
+	check, ok := decodeCheck(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := s.managedChecks.Create(check)
+	if err != nil {
+		writeCheckError(w, err)
+		return
+	}
+	slog.Info("Check created", "name", created.Name, "type", created.Type, "target", created.Target, "actor", requestActor(r))
+	s.recordEvent(EventCheckAdded, created.Name, fmt.Sprintf("%s check of %s added", created.Type, created.Target), r)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(created); err != nil {
+		slog.Error("Error encoding JSON response", "error", err)
+	}
+}
+
+// handleUpdateCheck handles PUT /api/v1/checks/{name}: replaces the definition of a check
+func (s *StatsServer) handleUpdateCheck(w http.ResponseWriter, r *http.Request) {
+	if !s.managedChecksAvailable(w) {
+		return
+	}
+	check, ok := decodeCheck(w, r)
+	if !ok {
+		return
+	}
+
+	name := r.PathValue("name")
+	if check.Name != "" && check.Name != name {
+		http.Error(w, "Checks cannot be renamed: delete and create it instead", http.StatusBadRequest)
+		return
+	}
+	previous, err := s.managedChecks.Get(name)
+	if err != nil {
+		writeCheckError(w, err)
+		return
+	}
+	updated, err := s.managedChecks.Update(name, check)
+	if err != nil {
+		writeCheckError(w, err)
+		return
+	}
+	// A changed type is a different check; the old one must not stay failing
+	if updated.Type != previous.Type {
+		s.forgetCheckState(previous)
+	}
+	slog.Info("Check updated", "name", updated.Name, "type", updated.Type, "target", updated.Target, "actor", requestActor(r))
+	s.recordEvent(EventCheckUpdated, updated.Name, fmt.Sprintf("%s check of %s updated", updated.Type, updated.Target), r)
+	writeJSON(w, updated)
+}
+
+// handleDeleteCheck handles DELETE /api/v1/checks/{name}
+func (s *StatsServer) handleDeleteCheck(w http.ResponseWriter, r *http.Request) {
+	if !s.managedChecksAvailable(w) {
+		return
+	}
+
+	name := r.PathValue("name")
+	check, err := s.managedChecks.Get(name)
+	if err != nil {
+		writeCheckError(w, err)
+		return
+	}
+	if err := s.managedChecks.Delete(name); err != nil {
+		writeCheckError(w, err)
+		return
+	}
+	s.forgetCheckState(check)
+	slog.Info("Check deleted", "name", name, "actor", requestActor(r))
+	s.recordEvent(EventCheckRemoved, name, fmt.Sprintf("%s check of %s removed", check.Type, check.Target), r)
+	writeJSON(w, map[string]interface{}{
+		"deleted": name,
+	})
+}
+
+// handleCheckToggle returns a handler for POST /api/v1/checks/{name}/pause and /resume. Paused
+// checks keep their definition but are not run.
+func (s *StatsServer) handleCheckToggle(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.managedChecksAvailable(w) {
+			return
+		}
+
+		check, err := s.managedChecks.SetPaused(r.PathValue("name"), paused)
+		if err != nil {
+			writeCheckError(w, err)
+			return
+		}
+		slog.Info("Check paused state changed", "name", check.Name, "paused", paused, "actor", requestActor(r))
+		if paused {
+			s.recordEvent(EventCheckPaused, check.Name, "Check paused", r)
+		} else {
+			s.recordEvent(EventCheckResumed, check.Name, "Check resumed", r)
+		}
+		writeJSON(w, check)
+	}
+}
+
+// forgetCheckState drops the alert state of a removed check, so it no longer shows as failing
+func (s *StatsServer) forgetCheckState(check types.ManagedCheck) {
+	if s.service != nil {
+		s.service.stateManager.RemoveState(check.Type + "_" + check.Name)
+	}
+}
+
+// decodeCheck reads a check definition from the request body
+func decodeCheck(w http.ResponseWriter, r *http.Request) (types.ManagedCheck, bool) {
+	var check types.ManagedCheck
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxIngestBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&check); err != nil {
+		http.Error(w, "Invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return types.ManagedCheck{}, false
+	}
+	return check, true
+}
+
+// writeCheckError maps check management errors to HTTP status codes
+func writeCheckError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, monitor.ErrManagedCheckNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, monitor.ErrManagedCheckExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, monitor.ErrInvalidManagedCheck):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error("Failed to save managed checks", "error", err)
+		http.Error(w, "Failed to save checks", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+func TestStatsServer_CheckManagementAPI(t *testing.T) {
+	config := &types.Config{
+		HTTPServer: types.HTTPServerConfig{Username: "admin", Password: "secret"},
+	}
+	service := createTestMonitorService(t, config)
+	service.statsServer.SetManagedChecks(monitor.NewManagedCheckMonitor(filepath.Join(t.TempDir(), "checks.json"), service.httpMonitor))
+	mux := service.statsServer.routes()
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.SetBasicAuth("admin", "secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("POST", "/api/v1/checks", `{"name": "db", "type": "tcp", "target": "db:5432"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var check types.ManagedCheck
+	if err := json.Unmarshal(w.Body.Bytes(), &check); err != nil || check.Timeout != 10 || check.Interval != 60 {
+		t.Errorf("Expected the created check with defaults, got %+v (err: %v)", check, err)
+	}
+
+	if w := request("POST", "/api/v1/checks", `{"name": "db", "type": "tcp", "target": "db:5432"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a duplicate check, got %d", w.Code)
+	}
+	if w := request("POST", "/api/v1/checks", `{"name": "web", "type": "http", "target": "ftp://example.com"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid check, got %d", w.Code)
+	}
+	if w := request("POST", "/api/v1/checks", `{"name": "web", "type": "http", "url": "https://example.com"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown fields, got %d", w.Code)
+	}
+
+	w = request("PUT", "/api/v1/checks/db", `{"type": "tcp", "target": "db:6432", "interval": 30}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &check); w.Code != http.StatusOK || err != nil || check.Target != "db:6432" {
+		t.Errorf("Expected the updated check, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request("PUT", "/api/v1/checks/db", `{"name": "cache", "type": "tcp", "target": "db:6432"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 when renaming a check, got %d", w.Code)
+	}
+
+	w = request("POST", "/api/v1/checks/db/pause", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &check); w.Code != http.StatusOK || err != nil || !check.Paused {
+		t.Errorf("Expected the check to be paused, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = request("GET", "/api/v1/checks", "")
+	var list struct {
+		Checks []types.ManagedCheck `json:"checks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Checks) != 1 || !list.Checks[0].Paused {
+		t.Errorf("Expected one paused check, got %s", w.Body.String())
+	}
+
+	// Deleting a check also forgets its alert state
+	service.stateManager.UpdateHTTPState([]types.HTTPCheckResult{{Name: "db", Type: "tcp"}})
+	if w := request("DELETE", "/api/v1/checks/db", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, exists := service.stateManager.GetStates()["tcp_db"]; exists {
+		t.Error("Expected the alert state of the deleted check to be removed")
+	}
+	if w := request("GET", "/api/v1/checks/db", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted check, got %d", w.Code)
+	}
+}
+
+func TestStatsServer_CheckManagementDisabled(t *testing.T) {
+	server := NewStatsServer(&types.HTTPServerConfig{}, nil, NewStorageManager(10), nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/checks", nil)
+	w := httptest.NewRecorder()
+	server.routes().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when check management is not configured, got %d", w.Code)
+	}
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// minGzipSize is the smallest response worth compressing; below it the gzip overhead outweighs
+// the savings
+const minGzipSize = 1024
+
+// gzipWriters reuses gzip writers, which allocate large buffers
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// bufferedResponse captures a response so its ETag can be computed before it is sent
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header implements http.ResponseWriter
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader implements http.ResponseWriter
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// Write implements http.ResponseWriter
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// cacheable middleware adds an ETag to successful responses, answers matching If-None-Match
+// requests with 304 Not Modified, and gzip-compresses responses for clients that accept it.
+// Dashboards and scripts polling unchanged data then only transfer headers. It buffers the whole
+// response, so it must not wrap streaming endpoints (/events, /ws).
+func cacheable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := &bufferedResponse{header: make(http.Header)}
+		next(response, r)
+
+		status := response.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		body := response.body.Bytes()
+
+		header := w.Header()
+		for key, values := range response.header {
+			header[key] = values
+		}
+		if header.Get("Content-Type") == "" && len(body) > 0 {
+			header.Set("Content-Type", http.DetectContentType(body))
+		}
+		header.Add("Vary", "Accept-Encoding")
+
+		if status == http.StatusOK {
+			etag := responseETag(body)
+			header.Set("ETag", etag)
+			if header.Get("Cache-Control") == "" {
+				// Browsers may keep the response but have to revalidate it on every request
+				header.Set("Cache-Control", "no-cache")
+			}
+			if etagMatches(r.Header.Get("If-None-Match"), etag) {
+				header.Del("Content-Type")
+				header.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		if len(body) < minGzipSize || !acceptsGzip(r) || !compressibleType(header.Get("Content-Type")) {
+			w.WriteHeader(status)
+			w.Write(body)
+			return
+		}
+
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.WriteHeader(status)
+
+		gz := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(gz)
+		gz.Reset(w)
+		if _, err := gz.Write(body); err != nil {
+			slog.Debug("Failed to write compressed response", "path", r.URL.Path, "error", err)
+			return
+		}
+		if err := gz.Close(); err != nil {
+			slog.Debug("Failed to write compressed response", "path", r.URL.Path, "error", err)
+		}
+	}
+}
+
+// responseETag returns a strong ETag of the uncompressed body, so it stays the same whether or
+// not the response is compressed
+func responseETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag (or is "*"). Weak
+// validators match as well, as If-None-Match uses weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip reports whether the client accepts gzip-encoded responses
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) == "gzip" {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// compressibleType reports whether a content type is text-based and worth compressing
+func compressibleType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		mediaType == "image/svg+xml"
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestCacheable(t *testing.T) {
+	body := strings.Repeat(`{"cpu":42}`, 200)
+	handler := cacheable(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	})
+
+	// Compressed for clients accepting gzip
+	req := httptest.NewRequest("GET", "/api/v1/uptime", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("Expected a gzip response, got headers %v", w.Header())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Invalid gzip body: %v", err)
+	}
+	if decoded, _ := io.ReadAll(reader); string(decoded) != body {
+		t.Error("Expected the decompressed body to match")
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("Expected an ETag that must be revalidated, got headers %v", w.Header())
+	}
+
+	// The ETag does not depend on the encoding
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/uptime", nil))
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != body || w.Header().Get("ETag") != etag {
+		t.Errorf("Expected an uncompressed body with the same ETag, got headers %v", w.Header())
+	}
+
+	// Unchanged responses are not sent again
+	req = httptest.NewRequest("GET", "/api/v1/uptime", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected 304 without body, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+}
+
+func TestCacheable_SkipsSmallAndFailedResponses(t *testing.T) {
+	handler := cacheable(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, strings.Repeat("error ", 500), http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, "ok")
+	})
+
+	req := httptest.NewRequest("GET", "/stats", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "ok" {
+		t.Errorf("Expected small responses to stay uncompressed, got headers %v", w.Header())
+	}
+
+	req = httptest.NewRequest("GET", "/stats?fail=1", nil)
+	req.Header.Set("If-None-Match", "*")
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusInternalServerError || w.Header().Get("ETag") != "" {
+		t.Errorf("Expected errors to pass through without ETag, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestStatsServer_StatsNotModified(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	service.storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", URL: "https://api.example.com", Success: true, Timestamp: time.Now()})
+	routes := service.statsServer.routes()
+
+	req := httptest.NewRequest("GET", "/stats", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected stats with an ETag, got %d %v", w.Code, w.Header())
+	}
+
+	// Time passing without a new collection does not change the stats
+	time.Sleep(20 * time.Millisecond)
+	req = httptest.NewRequest("GET", "/stats", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	routes.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for unchanged stats, got %d", w.Code)
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{"": false, "gzip": true, "deflate, gzip": true, "gzip;q=0": false, "gzip; q=0.5": true, "br": false}
+	for header, want := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", header)
+		if got := acceptsGzip(req); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
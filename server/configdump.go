@@ -0,0 +1,50 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+
+	"bconf.com/monic/config"
+)
+
+// Formats of the effective configuration, keyed like the config file
+var configOffers = []mediaOffer{
+	{formatJSON, "application/json"},
+	{formatYAML, "application/yaml"},
+}
+
+// handleConfig handles GET /api/v1/config: the configuration the running instance loaded from
+// the environment and config file, with secrets redacted, as JSON or (format=yaml) YAML
+func (s *StatsServer) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if s.service == nil {
+		http.Error(w, "Configuration is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	format, err := negotiateFormat(r, configOffers)
+	if err != nil {
+		status := http.StatusNotAcceptable
+		if r.URL.Query().Has("format") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := config.WriteDump(&body, s.service.config, format); err != nil {
+		slog.Error("Error writing config dump", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "application/json"
+	if format == formatYAML {
+		contentType = "application/yaml"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(body.Bytes()); err != nil {
+		slog.Error("Error writing config response", "error", err)
+	}
+}
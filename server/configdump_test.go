@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+func TestStatsServer_ConfigAPI(t *testing.T) {
+	config := &types.Config{
+		AppName:    "web-01",
+		HTTPChecks: []types.HTTPCheck{{Name: "api", URL: "https://api.example.com"}},
+		HTTPServer: types.HTTPServerConfig{Username: "admin", Password: "secret"},
+	}
+	service := createTestMonitorService(t, config)
+	mux := service.statsServer.routes()
+
+	request := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.SetBasicAuth("admin", "secret")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/api/v1/config", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected JSON, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"app_name": "web-01"`) || !strings.Contains(body, `"url": "https://api.example.com"`) {
+		t.Errorf("Expected the effective configuration keyed like the config file, got %s", body)
+	}
+	if strings.Contains(body, "secret") {
+		t.Errorf("Expected secrets to be redacted, got %s", body)
+	}
+
+	w = request("/api/v1/config", "application/yaml")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "app_name: web-01") {
+		t.Errorf("Expected YAML, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request("/api/v1/config?format=toml", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported format, got %d", w.Code)
+	}
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	// cycleWarnRatio is the share of the interval a cycle may take before a warning is logged
+	cycleWarnRatio = 0.8
+	// defaultStallIntervals is the number of intervals without progress after which a loop is
+	// stalled, unless configured otherwise
+	defaultStallIntervals = 3
+	// minStallTime keeps loops with very short intervals from being reported on a single hiccup
+	minStallTime = time.Minute
+)
+
+// cycleStats contains execution statistics of a subsystem's check/collection cycles
+type cycleStats struct {
+	Subsystem    string        `json:"subsystem"`
+	Interval     time.Duration `json:"-"`
+	LastDuration time.Duration `json:"-"`
+	MaxDuration  time.Duration `json:"-"`
+	Runs         int           `json:"runs"`
+	Overruns     int           `json:"overruns"` // Cycles that took at least as long as the interval
+	LastRun      time.Time     `json:"last_run"`
+	Schedule     string        `json:"schedule,omitempty"` // Cron expression or "every <interval>"
+	NextRun      time.Time     `json:"next_run"`
+	Running      bool          `json:"running"`  // A cycle is in progress
+	Restarts     int           `json:"restarts"` // Times the stalled loop was replaced by a new one
+	CycleStart   time.Time     `json:"-"`
+
+	// Millisecond values for JSON consumers
+	IntervalMs     float64 `json:"interval_ms"`
+	LastDurationMs float64 `json:"last_duration_ms"`
+	MaxDurationMs  float64 `json:"max_duration_ms"`
+}
+
+// cycleTracker records cycle durations per subsystem
+type cycleTracker struct {
+	mu             sync.Mutex
+	stats          map[string]*cycleStats
+	stallIntervals int // Intervals without progress after which a loop is stalled
+}
+
+// newCycleTracker creates an empty cycle tracker judging loops stalled after stallIntervals
+// intervals without progress (default: defaultStallIntervals)
+func newCycleTracker(stallIntervals int) *cycleTracker {
+	if stallIntervals <= 0 {
+		stallIntervals = defaultStallIntervals
+	}
+	return &cycleTracker{stats: make(map[string]*cycleStats), stallIntervals: stallIntervals}
+}
+
+// begin marks the start of a cycle, so a cycle that never finishes can be detected
+func (t *cycleTracker) begin(subsystem string, interval time.Duration, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, exists := t.stats[subsystem]
+	if !exists {
+		stats = &cycleStats{Subsystem: subsystem}
+		t.stats[subsystem] = stats
+	}
+	stats.Interval = interval
+	stats.IntervalMs = float64(interval) / float64(time.Millisecond)
+	stats.Running = true
+	stats.CycleStart = now
+}
+
+// record stores the duration of a finished cycle and reports whether it overran the interval
+func (t *cycleTracker) record(subsystem string, interval, duration time.Duration, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, exists := t.stats[subsystem]
+	if !exists {
+		stats = &cycleStats{Subsystem: subsystem}
+		t.stats[subsystem] = stats
+	}
+
+	overrun := interval > 0 && duration >= interval
+	stats.Interval = interval
+	stats.LastDuration = duration
+	if duration > stats.MaxDuration {
+		stats.MaxDuration = duration
+	}
+	stats.Runs++
+	stats.Running = false
+	if overrun {
+		stats.Overruns++
+	}
+	stats.LastRun = now
+
+	stats.IntervalMs = float64(interval) / float64(time.Millisecond)
+	stats.LastDurationMs = float64(duration) / float64(time.Millisecond)
+	stats.MaxDurationMs = float64(stats.MaxDuration) / float64(time.Millisecond)
+
+	return overrun
+}
+
+// restarted marks the hung cycle of a restarted loop as abandoned, so the new loop is judged by
+// its own progress from now on
+func (t *cycleTracker) restarted(subsystem string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, exists := t.stats[subsystem]
+	if !exists {
+		return
+	}
+	stats.Running = false
+	stats.LastRun = now
+	stats.LastDuration = 0
+	stats.Restarts++
+}
+
+// scheduled stores the schedule and next run time of a subsystem
+func (t *cycleTracker) scheduled(subsystem, schedule string, next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, exists := t.stats[subsystem]
+	if !exists {
+		stats = &cycleStats{Subsystem: subsystem}
+		t.stats[subsystem] = stats
+	}
+	stats.Schedule = schedule
+	stats.NextRun = next
+}
+
+// snapshot returns a copy of all cycle statistics sorted by subsystem
+func (t *cycleTracker) snapshot() []cycleStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]cycleStats, 0, len(t.stats))
+	for _, stats := range t.stats {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Subsystem < result[j].Subsystem
+	})
+	return result
+}
+
+// loopStatuses reports which loops stalled: a cycle hangs, or no cycle finished, for several
+// intervals. Loops that never finished a cycle are only judged by a hanging cycle.
+func (t *cycleTracker) loopStatuses(now time.Time) []types.LoopStatus {
+	var statuses []types.LoopStatus
+	for _, stats := range t.snapshot() {
+		if stats.Interval <= 0 {
+			continue
+		}
+
+		progress := stats.CycleStart
+		if !stats.Running {
+			if stats.LastRun.IsZero() {
+				continue
+			}
+			progress = stats.LastRun.Add(stats.LastDuration)
+		}
+
+		limit := stats.Interval * time.Duration(t.stallIntervals)
+		if limit < minStallTime {
+			limit = minStallTime
+		}
+		statuses = append(statuses, types.LoopStatus{
+			Subsystem:    stats.Subsystem,
+			Stalled:      now.Sub(progress) > limit,
+			LastProgress: progress,
+			Interval:     stats.Interval,
+		})
+	}
+	return statuses
+}
+
+// runCycle runs one check/collection cycle and records how long it took. A cycle that takes
+// most of its interval (e.g. slow disk stats or 1s CPU sampling on a short interval) is logged
+// as a warning, since the next tick is delayed or skipped once a cycle overruns.
+func (ms *MonitorService) runCycle(subsystem string, interval time.Duration, collect func()) {
+	start := time.Now()
+	ms.cycles.begin(subsystem, interval, start)
+	collect()
+	duration := time.Since(start)
+
+	overrun := ms.cycles.record(subsystem, interval, duration, start)
+	if overrun {
+		slog.Warn("Check cycle overran its interval",
+			"subsystem", subsystem,
+			"duration", duration.String(),
+			"interval", interval.String(),
+			"overrun", true)
+	} else if interval > 0 && float64(duration) >= float64(interval)*cycleWarnRatio {
+		slog.Warn("Check cycle is close to its interval",
+			"subsystem", subsystem,
+			"duration", duration.String(),
+			"interval", interval.String(),
+			"overrun", false)
+	}
+}
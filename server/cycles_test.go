@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestCycleTracker_Record(t *testing.T) {
+	tracker := newCycleTracker(0)
+	now := time.Now()
+
+	if tracker.record("system", 10*time.Second, 2*time.Second, now) {
+		t.Error("Expected a 2s cycle not to overrun a 10s interval")
+	}
+	if !tracker.record("system", 10*time.Second, 12*time.Second, now) {
+		t.Error("Expected a 12s cycle to overrun a 10s interval")
+	}
+	tracker.record("http", 30*time.Second, time.Second, now)
+
+	cycles := tracker.snapshot()
+	if len(cycles) != 2 || cycles[0].Subsystem != "http" || cycles[1].Subsystem != "system" {
+		t.Fatalf("Expected cycles sorted by subsystem, got %+v", cycles)
+	}
+
+	system := cycles[1]
+	if system.Runs != 2 || system.Overruns != 1 {
+		t.Errorf("Expected 2 runs with 1 overrun, got %d / %d", system.Runs, system.Overruns)
+	}
+	if system.LastDuration != 12*time.Second || system.MaxDuration != 12*time.Second || system.MaxDurationMs != 12000 {
+		t.Errorf("Unexpected durations: %+v", system)
+	}
+}
+
+func TestMonitorService_RunCycle(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+	}
+	service := createTestMonitorService(t, config)
+
+	ran := false
+	service.runCycle("slow", 5*time.Millisecond, func() {
+		ran = true
+		time.Sleep(10 * time.Millisecond)
+	})
+	if !ran {
+		t.Fatal("Expected the cycle to run")
+	}
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	service.statsServer.handleMetrics(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`monic_cycle_overruns_total{subsystem="slow"} 1`,
+		`monic_cycle_interval_seconds{subsystem="slow"} 0.005`,
+		`monic_cycle_duration_seconds{subsystem="slow"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain '%s'", want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types streamed to live clients
+const (
+	EventSystem = "system" // types.SystemStats of a collection cycle
+	EventCheck  = "check"  // types.HTTPCheckResult of any check (HTTP, gRPC, ...)
+	EventDocker = "docker" // []types.DockerContainerStats of a collection cycle
+	EventAlert  = "alert"  // types.Alert when it is processed
+	// types.LifecycleEvent when it is recorded
+	EventLifecycle = "lifecycle"
+)
+
+// eventTypes lists all event types in the order they are documented
+var eventTypes = []string{EventSystem, EventCheck, EventDocker, EventAlert, EventLifecycle}
+
+const (
+	// eventBuffer is the number of events queued per subscriber; a client that falls further
+	// behind misses events instead of blocking the collectors
+	eventBuffer = 64
+	// eventKeepalive is how often an idle stream sends a comment so proxies keep it open
+	eventKeepalive = 15 * time.Second
+	// eventRetry is the reconnect delay suggested to EventSource clients, in milliseconds
+	eventRetry = 5000
+)
+
+// Event is a live update pushed to subscribers
+type Event struct {
+	Type string
+	Data interface{}
+}
+
+// EventHub fans out live updates to all connected stream clients
+type EventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewEventHub creates an event hub without subscribers
+func NewEventHub() *EventHub {
+	return &EventHub{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Subscribe registers a new subscriber; the returned function unsubscribes it
+func (h *EventHub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers, ch)
+		h.mu.Unlock()
+	}
+}
+
+// Publish sends an event to all subscribers without waiting for slow ones
+func (h *EventHub) Publish(eventType string, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- Event{Type: eventType, Data: data}:
+		default:
+			slog.Debug("Dropping live event for slow subscriber", "type", eventType)
+		}
+	}
+}
+
+// parseEventTypes parses a comma-separated list of event types; an empty list selects all of them
+func parseEventTypes(value string) (map[string]bool, error) {
+	selected := make(map[string]bool)
+	if strings.TrimSpace(value) == "" {
+		for _, eventType := range eventTypes {
+			selected[eventType] = true
+		}
+		return selected, nil
+	}
+
+	for _, eventType := range strings.Split(value, ",") {
+		eventType = strings.TrimSpace(eventType)
+		if !isEventType(eventType) {
+			return nil, fmt.Errorf("unknown event type %q (must be one of %s)", eventType, strings.Join(eventTypes, ", "))
+		}
+		selected[eventType] = true
+	}
+	return selected, nil
+}
+
+// isEventType reports whether eventType is a known event type
+func isEventType(eventType string) bool {
+	for _, known := range eventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// handleEvents handles GET /events: a Server-Sent Events stream of new system stats, check
+// results, Docker stats and alerts as they are collected, optionally limited with "types"
+func (s *StatsServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	selected, err := parseEventTypes(r.URL.Query().Get("types"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable response buffering in nginx
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(eventKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-events:
+			if !selected[event.Type] {
+				continue
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				slog.Error("Failed to encode live event", "type", event.Type, "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
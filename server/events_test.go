@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestEventHub_PublishSubscribe(t *testing.T) {
+	hub := NewEventHub()
+	events, unsubscribe := hub.Subscribe()
+
+	hub.Publish(EventSystem, types.SystemStats{CPUUsage: 42})
+	select {
+	case event := <-events:
+		if event.Type != EventSystem || event.Data.(types.SystemStats).CPUUsage != 42 {
+			t.Errorf("Unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("Expected a published event")
+	}
+
+	// A subscriber that does not read must not block publishing
+	for i := 0; i < eventBuffer*2; i++ {
+		hub.Publish(EventCheck, i)
+	}
+	if len(events) != eventBuffer {
+		t.Errorf("Expected %d queued events, got %d", eventBuffer, len(events))
+	}
+
+	unsubscribe()
+	if len(hub.subscribers) != 0 {
+		t.Errorf("Expected no subscribers after unsubscribe, got %d", len(hub.subscribers))
+	}
+}
+
+func TestStatsServer_HandleEvents(t *testing.T) {
+	config := &types.HTTPServerConfig{Enabled: true}
+	server := NewStatsServer(config, nil, NewStorageManager(10), nil)
+	ts := httptest.NewServer(server.routes())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatalf("Failed to connect to event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readLine := func() string {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event stream: %v", err)
+		}
+		return strings.TrimSuffix(line, "\n")
+	}
+
+	// The retry hint is sent once the client is subscribed
+	if line := readLine(); line != "retry: 5000" {
+		t.Fatalf("Expected retry hint, got %q", line)
+	}
+	readLine()
+
+	server.events.Publish(EventCheck, types.HTTPCheckResult{Name: "api", Type: "http", Success: true})
+
+	if line := readLine(); line != "event: check" {
+		t.Fatalf("Expected check event, got %q", line)
+	}
+	line := readLine()
+	var result types.HTTPCheckResult
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &result); err != nil {
+		t.Fatalf("Invalid event data %q: %v", line, err)
+	}
+	if result.Name != "api" || !result.Success {
+		t.Errorf("Unexpected check result %+v", result)
+	}
+}
+
+func TestParseEventTypes(t *testing.T) {
+	all, err := parseEventTypes("")
+	if err != nil || len(all) != len(eventTypes) {
+		t.Errorf("Expected all event types by default, got %v (%v)", all, err)
+	}
+
+	selected, err := parseEventTypes("docker, alert")
+	if err != nil || len(selected) != 2 || !selected[EventDocker] || !selected[EventAlert] {
+		t.Errorf("Expected docker and alert, got %v (%v)", selected, err)
+	}
+
+	if _, err := parseEventTypes("docker,logs"); err == nil {
+		t.Error("Expected error for an unknown event type")
+	}
+}
+
+func TestStatsServer_HandleEvents_RequiresAuth(t *testing.T) {
+	config := &types.HTTPServerConfig{Enabled: true, Username: "admin", Password: "secret"}
+	server := NewStatsServer(config, nil, NewStorageManager(10), nil)
+
+	w := httptest.NewRecorder()
+	server.routes().ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", w.Code)
+	}
+}
+
+func TestMonitorService_PublishesEvents(t *testing.T) {
+	ms := createTestMonitorService(t, &types.Config{})
+	events, unsubscribe := ms.statsServer.events.Subscribe()
+	defer unsubscribe()
+
+	ms.recordCheckResults("Test", []types.HTTPCheckResult{{Name: "db", Type: "grpc", Success: true, Timestamp: time.Now()}})
+
+	select {
+	case event := <-events:
+		if event.Type != EventCheck || event.Data.(types.HTTPCheckResult).Name != "db" {
+			t.Errorf("Unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("Expected a check event for the recorded result")
+	}
+}
@@ -0,0 +1,434 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	// exportPath serves snapshots of all history, importPath loads them back
+	exportPath = "/api/v1/export"
+	importPath = "/api/v1/import"
+	// maxImportSize limits the size of an uploaded snapshot
+	maxImportSize = 512 << 20
+	// snapshotVersion is the version of the snapshot format written by this release
+	snapshotVersion = 1
+	// formatCSV selects a tar.gz archive with one CSV file per history type
+	formatCSV = "csv"
+)
+
+// Formats of snapshots: JSON can be imported again, CSV is meant for spreadsheets
+var exportOffers = []mediaOffer{
+	{formatJSON, "application/json"},
+	{formatCSV, "application/gzip"},
+}
+
+// Time range covering all stored history. The end is close to the latest time Unix nanoseconds
+// can hold, which the SQLite driver stores.
+var (
+	snapshotFrom = time.Unix(0, 0)
+	snapshotTo   = time.Date(2262, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// ErrUnreachable is returned when the running instance can't be reached, as opposed to a
+// request it rejected
+var ErrUnreachable = errors.New("running instance not reachable")
+
+// Snapshot is a copy of all stored history and alert states, for migrations and offline analysis
+type Snapshot struct {
+	Version     int                          `json:"version"`
+	ExportedAt  time.Time                    `json:"exported_at"`
+	Alerts      []types.Alert                `json:"alerts"`
+	SystemStats []types.SystemStats          `json:"system_stats"`
+	Checks      []types.HTTPCheckResult      `json:"checks"` // HTTP, gRPC, mail and other check results
+	Docker      []types.DockerContainerStats `json:"docker"`
+	Events      []types.LifecycleEvent       `json:"events"`
+	// Only included when exported from a running instance
+	AlertStates []types.AlertState `json:"alert_states,omitempty"`
+}
+
+// NewSnapshot copies the stored history and the given alert states
+func NewSnapshot(storage Storage, states []types.AlertState) *Snapshot {
+	return &Snapshot{
+		Version:     snapshotVersion,
+		ExportedAt:  time.Now().UTC(),
+		Alerts:      storage.GetAlerts(),
+		SystemStats: storage.GetSystemStatsRange(snapshotFrom, snapshotTo),
+		Checks:      storage.GetHTTPCheckResultsRange(snapshotFrom, snapshotTo),
+		Docker:      storage.GetDockerContainerStatsRange(snapshotFrom, snapshotTo),
+		Events:      storage.GetEventsRange(snapshotFrom, snapshotTo),
+		AlertStates: states,
+	}
+}
+
+// ReadSnapshot decodes a JSON snapshot
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if snapshot.Version < 1 || snapshot.Version > snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d (supported: 1 to %d)", snapshot.Version, snapshotVersion)
+	}
+	return &snapshot, nil
+}
+
+// SnapshotFileName returns a timestamped file name for a snapshot in the given format
+func SnapshotFileName(format string) string {
+	extension := ".json"
+	if format == formatCSV {
+		extension = ".tar.gz"
+	}
+	return "monic-export-" + time.Now().UTC().Format("20060102-150405") + extension
+}
+
+// SnapshotFormat returns the snapshot format matching a file name: CSV for .tar.gz and .tgz, JSON otherwise
+func SnapshotFormat(path string) string {
+	if strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz") {
+		return formatCSV
+	}
+	return formatJSON
+}
+
+// Write writes the snapshot in the given format ("json" or "csv")
+func (snap *Snapshot) Write(w io.Writer, format string) error {
+	if format == formatCSV {
+		return snap.writeCSVArchive(w)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snap)
+}
+
+// writeCSVArchive writes a tar.gz archive with one CSV file per history type. Nested data such
+// as per-disk usage is left out; the JSON format has everything.
+func (snap *Snapshot) writeCSVArchive(w io.Writer) error {
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"alerts.csv", snap.alertRows()},
+		{"system_stats.csv", snap.systemStatsRows()},
+		{"checks.csv", snap.checkRows()},
+		{"docker.csv", snap.dockerRows()},
+		{"events.csv", snap.eventRows()},
+		{"alert_states.csv", snap.alertStateRows()},
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		var data bytes.Buffer
+		cw := csv.NewWriter(&data)
+		if err := cw.WriteAll(file.rows); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+
+		header := &tar.Header{
+			Name:    "monic-export/" + file.name,
+			Mode:    0644,
+			Size:    int64(data.Len()),
+			ModTime: snap.ExportedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s header: %w", file.name, err)
+		}
+		if _, err := tw.Write(data.Bytes()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// csvTime formats a timestamp for CSV files, empty for the zero time
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// csvFloat formats a number for CSV files
+func csvFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// csvText escapes a text cell that spreadsheet applications would run as a formula, e.g. an error
+// message of a remote server starting with "=", by prefixing it with a quote
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// alertRows returns the CSV rows of the alert history
+func (snap *Snapshot) alertRows() [][]string {
+	rows := [][]string{{"timestamp", "type", "level", "message"}}
+	for _, alert := range snap.Alerts {
+		rows = append(rows, []string{csvTime(alert.Timestamp), csvText(alert.Type), alert.Level, csvText(alert.Message)})
+	}
+	return rows
+}
+
+// systemStatsRows returns the CSV rows of the system stats history
+func (snap *Snapshot) systemStatsRows() [][]string {
+	rows := [][]string{{"timestamp", "cpu_percent", "memory_percent", "swap_percent", "load1", "load5", "load15"}}
+	for _, stats := range snap.SystemStats {
+		rows = append(rows, []string{
+			csvTime(stats.Timestamp),
+			csvFloat(stats.CPUUsage),
+			csvFloat(stats.MemoryUsage.UsedPercent),
+			csvFloat(stats.SwapUsage.UsedPercent),
+			csvFloat(stats.LoadAverage.Load1),
+			csvFloat(stats.LoadAverage.Load5),
+			csvFloat(stats.LoadAverage.Load15),
+		})
+	}
+	return rows
+}
+
+// checkRows returns the CSV rows of the check result history
+func (snap *Snapshot) checkRows() [][]string {
+	return checkCSVRows(snap.Checks, csvTime)
+}
+
+// checkCSVRows returns the CSV rows of check results, with timestamps formatted by formatTime
+func checkCSVRows(results []types.HTTPCheckResult, formatTime func(time.Time) string) [][]string {
+	rows := [][]string{{"timestamp", "type", "name", "url", "success", "status_code", "response_time_ms", "error"}}
+	for _, result := range results {
+		checkType := result.Type
+		if checkType == "" {
+			checkType = "http"
+		}
+		rows = append(rows, []string{
+			formatTime(result.Timestamp),
+			checkType,
+			csvText(result.Name),
+			csvText(result.URL),
+			strconv.FormatBool(result.Success),
+			strconv.Itoa(result.StatusCode),
+			csvFloat(float64(result.ResponseTime) / float64(time.Millisecond)),
+			csvText(result.Error),
+		})
+	}
+	return rows
+}
+
+// dockerRows returns the CSV rows of the container stats history
+func (snap *Snapshot) dockerRows() [][]string {
+	rows := [][]string{{"timestamp", "name", "state", "health", "restart_count", "cpu_percent", "memory_usage", "memory_limit", "network_rx_bytes", "network_tx_bytes"}}
+	for _, stats := range snap.Docker {
+		rows = append(rows, []string{
+			csvTime(stats.Timestamp),
+			csvText(stats.Name),
+			csvText(stats.State),
+			csvText(stats.Health),
+			strconv.Itoa(stats.RestartCount),
+			csvFloat(stats.CPUPercent),
+			strconv.FormatUint(stats.MemoryUsage, 10),
+			strconv.FormatUint(stats.MemoryLimit, 10),
+			strconv.FormatUint(stats.NetworkRxBytes, 10),
+			strconv.FormatUint(stats.NetworkTxBytes, 10),
+		})
+	}
+	return rows
+}
+
+// eventRows returns the CSV rows of the lifecycle event log
+func (snap *Snapshot) eventRows() [][]string {
+	rows := [][]string{{"timestamp", "kind", "subject", "message", "actor"}}
+	for _, event := range snap.Events {
+		rows = append(rows, []string{csvTime(event.Timestamp), event.Kind, csvText(event.Subject), csvText(event.Message), csvText(event.Actor)})
+	}
+	return rows
+}
+
+// alertStateRows returns the CSV rows of the alert states
+func (snap *Snapshot) alertStateRows() [][]string {
+	rows := [][]string{{"type", "state", "consecutive_checks", "last_state_change", "last_alert_sent"}}
+	for _, state := range snap.AlertStates {
+		rows = append(rows, []string{
+			csvText(state.Type),
+			state.CurrentState,
+			strconv.Itoa(state.ConsecutiveChecks),
+			csvTime(state.LastStateChange),
+			csvTime(state.LastAlertSent),
+		})
+	}
+	return rows
+}
+
+// Restore replaces the stored history with the history of the snapshot and returns the number of
+// imported entries per history type. The purge of the previous history is audited like API purges.
+func (snap *Snapshot) Restore(storage Storage, actor string) (map[string]int, error) {
+	for _, dataType := range HistoryTypes {
+		entry, err := purgeHistory(storage, dataType, time.Time{}, "import", actor)
+		if err != nil {
+			return nil, err
+		}
+		recordPurge(storage, entry)
+	}
+
+	// Entries are added oldest first, as durable drivers expect them in time order
+	storage.AddAlerts(snap.Alerts)
+	for _, stats := range snap.SystemStats {
+		storage.AddSystemStats(stats)
+	}
+	storage.AddHTTPCheckResults(snap.Checks)
+	storage.AddDockerContainerStats(snap.Docker)
+	for _, event := range snap.Events {
+		storage.AddEvent(event)
+	}
+
+	return map[string]int{
+		HistoryAlerts:      len(snap.Alerts),
+		HistorySystemStats: len(snap.SystemStats),
+		HistoryChecks:      len(snap.Checks),
+		HistoryDocker:      len(snap.Docker),
+		HistoryEvents:      len(snap.Events),
+	}, nil
+}
+
+// handleExport handles GET /api/v1/export: a snapshot of all history and alert states, as JSON
+// or, with format=csv, as a tar.gz archive of CSV files
+func (s *StatsServer) handleExport(w http.ResponseWriter, r *http.Request) {
+	format, err := negotiateFormat(r, exportOffers)
+	if err != nil {
+		status := http.StatusNotAcceptable
+		if r.URL.Query().Has("format") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var states []types.AlertState
+	if s.service != nil {
+		states = s.service.stateManager.Snapshot()
+	}
+	snapshot := NewSnapshot(s.storage, states)
+
+	// Build the snapshot in memory so errors can still be reported with a proper status code
+	var body bytes.Buffer
+	if err := snapshot.Write(&body, format); err != nil {
+		slog.Error("Error writing snapshot", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "application/json"
+	if format == formatCSV {
+		contentType = "application/gzip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", SnapshotFileName(format)))
+	if _, err := w.Write(body.Bytes()); err != nil {
+		slog.Error("Error writing snapshot response", "error", err)
+	}
+}
+
+// handleImport handles POST /api/v1/import: replaces the stored history and alert states with
+// those of a JSON snapshot
+func (s *StatsServer) handleImport(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := ReadSnapshot(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actor := requestActor(r)
+	imported, err := snapshot.Restore(s.storage, actor)
+	if err != nil {
+		slog.Error("Error importing snapshot", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	states := 0
+	if s.service != nil && len(snapshot.AlertStates) > 0 {
+		s.service.stateManager.RestoreStates(snapshot.AlertStates)
+		states = len(snapshot.AlertStates)
+	}
+
+	slog.Info("Snapshot imported", "audit", true, "exported_at", snapshot.ExportedAt, "entries", imported, "alert_states", states, "actor", actor)
+	writeJSON(w, map[string]interface{}{
+		"imported":     imported,
+		"alert_states": states,
+	})
+}
+
+// FetchSnapshot downloads a snapshot in the given format from the running instance
+func FetchSnapshot(config *types.HTTPServerConfig, format string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, localURL(config, exportPath+"?format="+format), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if config.Username != "" && config.Password != "" {
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	client, err := localClient(config, 5*time.Minute)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return nil
+}
+
+// PushSnapshot uploads a JSON snapshot to the running instance and returns its summary of the import
+func PushSnapshot(config *types.HTTPServerConfig, r io.Reader) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, localURL(config, importPath), r)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if config.Username != "" && config.Password != "" {
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	client, err := localClient(config, 5*time.Minute)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
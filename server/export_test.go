@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestStatsServer_ExportImport(t *testing.T) {
+	source := createTestMonitorService(t, &types.Config{})
+	now := time.Now().Add(-time.Hour)
+	source.storage.AddSystemStats(types.SystemStats{Timestamp: now, CPUUsage: 12.5})
+	source.storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Success: true, ResponseTime: 150 * time.Millisecond, Timestamp: now})
+	source.storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Error: "timeout, retrying", Timestamp: now.Add(time.Minute)})
+	source.storage.AddDockerContainerStats([]types.DockerContainerStats{{Name: "web", State: "running", Timestamp: now}})
+	source.storage.AddAlert(types.Alert{Type: "http_api", Level: "critical", Message: "API down", Timestamp: now})
+	source.stateManager.UpdateLoopState([]types.LoopStatus{{Subsystem: "http", Stalled: true}})
+
+	w := httptest.NewRecorder()
+	source.statsServer.routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/export", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON snapshot, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "monic-export-") {
+		t.Errorf("Expected a download, got %q", w.Header().Get("Content-Disposition"))
+	}
+	snapshot := w.Body.Bytes()
+
+	// Importing replaces the history of the target
+	target := createTestMonitorService(t, &types.Config{})
+	target.storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "old", Timestamp: time.Now()})
+	w = httptest.NewRecorder()
+	target.statsServer.routes().ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/import", bytes.NewReader(snapshot)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected import to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Imported    map[string]int `json:"imported"`
+		AlertStates int            `json:"alert_states"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Imported[HistoryChecks] != 2 || response.AlertStates != 1 {
+		t.Errorf("Unexpected import summary: %s", w.Body.String())
+	}
+
+	results := target.storage.GetHTTPCheckResults()
+	if len(results) != 2 || results[0].Name != "api" || results[0].ResponseTime != 150*time.Millisecond {
+		t.Errorf("Expected the imported check results, got %+v", results)
+	}
+	if stats := target.storage.GetLatestSystemStats(); stats == nil || stats.CPUUsage != 12.5 {
+		t.Errorf("Expected the imported system stats, got %+v", stats)
+	}
+	if alerts := target.storage.GetAlerts(); len(alerts) != 1 || alerts[0].Message != "API down" {
+		t.Errorf("Expected the imported alert, got %+v", alerts)
+	}
+	if state := target.stateManager.GetStates()["stall_http"]; state == nil || state.CurrentState != "critical" {
+		t.Errorf("Expected the imported alert state, got %+v", target.stateManager.GetStates())
+	}
+	if audit := target.storage.GetAuditEntries(); len(audit) != len(HistoryTypes) || audit[0].Trigger != "import" {
+		t.Errorf("Expected the replaced history to be audited, got %+v", audit)
+	}
+
+	// Invalid snapshots are rejected without touching the history
+	for _, body := range []string{"not json", `{"version": 99}`} {
+		w = httptest.NewRecorder()
+		target.statsServer.routes().ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/import", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q, got %d", body, w.Code)
+		}
+	}
+	if len(target.storage.GetHTTPCheckResults()) != 2 {
+		t.Error("Expected a rejected import to keep the history")
+	}
+}
+
+func TestStatsServer_ExportCSV(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	service.storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Error: "timeout, retrying", ResponseTime: 1500 * time.Microsecond, Timestamp: time.Now()})
+
+	w := httptest.NewRecorder()
+	service.statsServer.routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/export?format=csv", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/gzip" {
+		t.Fatalf("Expected a CSV archive, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	files := readBundle(t, w.Body)
+	for _, name := range []string{"alerts.csv", "system_stats.csv", "checks.csv", "docker.csv", "alert_states.csv"} {
+		if _, exists := files["monic-export/"+name]; !exists {
+			t.Errorf("Expected %s in the archive", name)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(files["monic-export/checks.csv"]), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "timestamp,type,name") || !strings.Contains(lines[1], `http,api,,false,0,1.5,"timeout, retrying"`) {
+		t.Errorf("Unexpected checks.csv: %q", files["monic-export/checks.csv"])
+	}
+
+	w = httptest.NewRecorder()
+	service.statsServer.routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/export?format=xml", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", w.Code)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// MetricsExporter forwards collected system stats and check results to an external time series
+// database for long-term storage
+type MetricsExporter interface {
+	Name() string // Subsystem name in the service status, e.g. influxdb
+	Start() error
+	ExportSystemStats(stats types.SystemStats)
+	ExportCheckResult(result types.HTTPCheckResult)
+	Close()
+}
+
+// errRejected marks a write the receiver refused (e.g. invalid data); retrying it cannot succeed
+var errRejected = errors.New("rejected")
+
+// batchWriter buffers samples of an exporter and writes them in batches on every flush
+// interval, or as soon as a batch is full. Failed writes are retried on the next flush; when the
+// buffer is full the oldest samples are dropped.
+type batchWriter[T any] struct {
+	name       string
+	write      func(batch []T) error
+	interval   time.Duration
+	batchSize  int
+	bufferSize int
+
+	mu      sync.Mutex
+	queue   []T
+	removed int // Samples removed from the front of the queue so far (written or dropped)
+	dropped int
+	notify  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	running bool
+}
+
+// newBatchWriter creates a batch writer; name identifies the exporter in logs
+func newBatchWriter[T any](name string, write func(batch []T) error, interval time.Duration, batchSize, bufferSize int) *batchWriter[T] {
+	return &batchWriter[T]{
+		name:       name,
+		write:      write,
+		interval:   interval,
+		batchSize:  batchSize,
+		bufferSize: bufferSize,
+		notify:     make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// start starts the flush loop
+func (b *batchWriter[T]) start() {
+	b.running = true
+	go b.flushLoop()
+}
+
+// add buffers samples, dropping the oldest ones when the buffer is full
+func (b *batchWriter[T]) add(samples ...T) {
+	b.mu.Lock()
+	b.queue = append(b.queue, samples...)
+	if excess := len(b.queue) - b.bufferSize; excess > 0 {
+		b.queue = b.queue[excess:]
+		b.removed += excess
+		before := b.dropped
+		b.dropped += excess
+		if before == 0 || before/1000 != b.dropped/1000 {
+			slog.Warn("Export buffer full, dropping oldest samples", "exporter", b.name, "dropped", b.dropped, "buffer_size", b.bufferSize)
+		}
+	}
+	full := len(b.queue) >= b.batchSize
+	b.mu.Unlock()
+
+	if full {
+		b.wake()
+	}
+}
+
+// wake makes the flush loop write the buffered samples without waiting for the interval
+func (b *batchWriter[T]) wake() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// flushLoop writes buffered samples on every interval and whenever a batch is full. After a
+// failed write it waits for the next interval.
+func (b *batchWriter[T]) flushLoop() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	ok := true
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			ok = b.flush()
+		case <-b.notify:
+			if ok {
+				ok = b.flush()
+			}
+		}
+	}
+}
+
+// flush writes all buffered samples in batches, stopping at the first failed write. It reports
+// whether the buffer could be emptied.
+func (b *batchWriter[T]) flush() bool {
+	for {
+		b.mu.Lock()
+		batch := b.queue[:min(len(b.queue), b.batchSize)]
+		start := b.removed
+		b.mu.Unlock()
+		if len(batch) == 0 {
+			return true
+		}
+
+		err := b.write(batch)
+		if err != nil && !errors.Is(err, errRejected) {
+			slog.Warn("Failed to export samples, retrying on the next flush", "exporter", b.name, "pending", b.pending(), "error", err)
+			return false
+		}
+		if err != nil {
+			slog.Error("Export rejected, dropping samples", "exporter", b.name, "samples", len(batch), "error", err)
+		}
+
+		// Samples may have been dropped from the front meanwhile; only remove what is still there
+		b.mu.Lock()
+		if written := start + len(batch) - b.removed; written > 0 {
+			b.queue = b.queue[written:]
+			b.removed += written
+		}
+		b.mu.Unlock()
+	}
+}
+
+// pending returns the number of buffered samples
+func (b *batchWriter[T]) pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queue)
+}
+
+// close stops the flush loop and makes a last attempt to write buffered samples
+func (b *batchWriter[T]) close() {
+	if !b.running {
+		return
+	}
+	close(b.stop)
+	<-b.done
+
+	b.flush()
+	if pending := b.pending(); pending > 0 {
+		slog.Warn("Exporter stopped with unwritten samples", "exporter", b.name, "pending", pending)
+	}
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	defaultGraphitePrefix  = "monic"
+	defaultGraphiteTimeout = 5
+	graphiteRetryInterval  = 10 * time.Second
+	graphiteBatchSize      = 1000
+	graphiteBufferSize     = 10000
+	statsDMaxPacketSize    = 1432 // Fits a packet into the Ethernet MTU
+)
+
+// graphiteUnsafeChars matches characters that are not allowed in a Graphite path node or StatsD
+// metric name
+var graphiteUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// graphiteMetric is a single value sent to Graphite or StatsD
+type graphiteMetric struct {
+	path      string
+	value     float64
+	timing    bool  // Sent as a StatsD timer (|ms) instead of a gauge (|g)
+	timestamp int64 // Unix seconds
+}
+
+// graphiteNode makes a string usable as a single node of a metric path; "/" becomes root, other
+// paths have their slashes replaced
+func graphiteNode(s string) string {
+	if s == "/" {
+		return "root"
+	}
+	node := strings.Trim(graphiteUnsafeChars.ReplaceAllString(s, "_"), "_")
+	if node == "" {
+		return "_"
+	}
+	return node
+}
+
+// graphitePrefix returns the configured metric prefix, or monic.<host>
+func graphitePrefix(prefix, host string) string {
+	if prefix != "" {
+		return strings.TrimSuffix(prefix, ".")
+	}
+	return defaultGraphitePrefix + "." + graphiteNode(host)
+}
+
+// graphiteSystemMetrics converts system stats to gauges under <prefix>.system
+func graphiteSystemMetrics(prefix string, stats types.SystemStats) []graphiteMetric {
+	ts := stats.Timestamp.Unix()
+	metrics := []graphiteMetric{
+		{path: prefix + ".system.cpu_usage_percent", value: stats.CPUUsage, timestamp: ts},
+		{path: prefix + ".system.memory_usage_percent", value: stats.MemoryUsage.UsedPercent, timestamp: ts},
+		{path: prefix + ".system.swap_usage_percent", value: stats.SwapUsage.UsedPercent, timestamp: ts},
+	}
+	if stats.LoadAverage.Cores > 0 {
+		metrics = append(metrics,
+			graphiteMetric{path: prefix + ".system.load.1m", value: stats.LoadAverage.Load1, timestamp: ts},
+			graphiteMetric{path: prefix + ".system.load.5m", value: stats.LoadAverage.Load5, timestamp: ts},
+			graphiteMetric{path: prefix + ".system.load.15m", value: stats.LoadAverage.Load15, timestamp: ts})
+	}
+	for _, path := range sortedKeys(stats.DiskUsage) {
+		metrics = append(metrics, graphiteMetric{path: prefix + ".system.disk." + graphiteNode(path) + ".usage_percent", value: stats.DiskUsage[path].UsedPercent, timestamp: ts})
+	}
+	return metrics
+}
+
+// graphiteCheckMetrics converts a check result to metrics under <prefix>.checks.<type>.<name>:
+// up, the response_time_ms timer and numeric polled values as value
+func graphiteCheckMetrics(prefix string, result types.HTTPCheckResult) []graphiteMetric {
+	ts := result.Timestamp.Unix()
+	checkType := strings.SplitN(checkKey(result), "/", 2)[0]
+	base := prefix + ".checks." + graphiteNode(checkType) + "." + graphiteNode(result.Name)
+	metrics := []graphiteMetric{
+		{path: base + ".up", value: boolToFloat(result.Success), timestamp: ts},
+		{path: base + ".response_time_ms", value: float64(result.ResponseTime) / float64(time.Millisecond), timing: true, timestamp: ts},
+	}
+	if value, err := strconv.ParseFloat(strings.TrimSpace(result.Value), 64); err == nil {
+		metrics = append(metrics, graphiteMetric{path: base + ".value", value: value, timestamp: ts})
+	}
+	return metrics
+}
+
+// graphiteValue formats a metric value; NaN and infinite values cannot be sent
+func graphiteValue(value float64) (string, bool) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", false
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), true
+}
+
+// validateAddress checks that address is a host:port pair
+func validateAddress(kind, address string) error {
+	if host, port, err := net.SplitHostPort(address); err != nil || host == "" || port == "" {
+		return fmt.Errorf("invalid %s address %q, expected host:port", kind, address)
+	}
+	return nil
+}
+
+// GraphiteExporter sends system gauges and check latencies to Carbon with the plaintext protocol
+// after every collection. The TCP connection is kept open; when Carbon is unreachable the metrics
+// are buffered and sent again once it is back.
+type GraphiteExporter struct {
+	config  *types.GraphiteConfig
+	prefix  string
+	timeout time.Duration
+	conn    net.Conn // Only used by the flush loop
+	writer  *batchWriter[graphiteMetric]
+}
+
+// NewGraphiteExporter creates a Graphite exporter; host is part of the default metric prefix
+func NewGraphiteExporter(config *types.GraphiteConfig, host string) *GraphiteExporter {
+	e := &GraphiteExporter{
+		config:  config,
+		prefix:  graphitePrefix(config.Prefix, host),
+		timeout: time.Duration(positiveOr(config.Timeout, defaultGraphiteTimeout)) * time.Second,
+	}
+	e.writer = newBatchWriter("graphite", e.write, graphiteRetryInterval, graphiteBatchSize, graphiteBufferSize)
+	return e
+}
+
+// Name returns the subsystem name of the exporter
+func (e *GraphiteExporter) Name() string {
+	return "graphite"
+}
+
+// Start validates the configuration and starts sending; Carbon is connected to on the first send
+func (e *GraphiteExporter) Start() error {
+	if err := validateAddress("Graphite", e.config.Address); err != nil {
+		return err
+	}
+
+	e.writer.start()
+	slog.Info("Graphite exporter started", "address", e.config.Address, "prefix", e.prefix)
+	return nil
+}
+
+// Close sends the buffered metrics and closes the connection
+func (e *GraphiteExporter) Close() {
+	e.writer.close()
+	if e.conn != nil {
+		e.conn.Close()
+		e.conn = nil
+	}
+}
+
+// ExportSystemStats sends the system gauges of a collection
+func (e *GraphiteExporter) ExportSystemStats(stats types.SystemStats) {
+	e.writer.add(graphiteSystemMetrics(e.prefix, stats)...)
+	e.writer.wake()
+}
+
+// ExportCheckResult sends the metrics of a check result
+func (e *GraphiteExporter) ExportCheckResult(result types.HTTPCheckResult) {
+	e.writer.add(graphiteCheckMetrics(e.prefix, result)...)
+	e.writer.wake()
+}
+
+// write sends a batch as "path value timestamp" lines, reconnecting if the connection was lost
+func (e *GraphiteExporter) write(metrics []graphiteMetric) error {
+	var b strings.Builder
+	for _, m := range metrics {
+		if value, ok := graphiteValue(m.value); ok {
+			fmt.Fprintf(&b, "%s %s %d\n", m.path, value, m.timestamp)
+		}
+	}
+
+	if e.conn == nil {
+		conn, err := net.DialTimeout("tcp", e.config.Address, e.timeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to Graphite: %w", err)
+		}
+		e.conn = conn
+	}
+	e.conn.SetWriteDeadline(time.Now().Add(e.timeout))
+	if _, err := e.conn.Write([]byte(b.String())); err != nil {
+		// Part of the batch may have been sent; Carbon keeps the last value of a duplicate point
+		e.conn.Close()
+		e.conn = nil
+		return fmt.Errorf("failed to send to Graphite: %w", err)
+	}
+	return nil
+}
+
+// StatsDExporter sends system gauges and check latencies to StatsD over UDP after every
+// collection. Response times are sent as timers so StatsD computes percentiles per flush.
+type StatsDExporter struct {
+	config *types.StatsDConfig
+	prefix string
+	conn   net.Conn // Only used by the flush loop
+	writer *batchWriter[graphiteMetric]
+}
+
+// NewStatsDExporter creates a StatsD exporter; host is part of the default metric prefix
+func NewStatsDExporter(config *types.StatsDConfig, host string) *StatsDExporter {
+	e := &StatsDExporter{
+		config: config,
+		prefix: graphitePrefix(config.Prefix, host),
+	}
+	e.writer = newBatchWriter("statsd", e.write, graphiteRetryInterval, graphiteBatchSize, graphiteBufferSize)
+	return e
+}
+
+// Name returns the subsystem name of the exporter
+func (e *StatsDExporter) Name() string {
+	return "statsd"
+}
+
+// Start validates the configuration and starts sending
+func (e *StatsDExporter) Start() error {
+	if err := validateAddress("StatsD", e.config.Address); err != nil {
+		return err
+	}
+
+	e.writer.start()
+	slog.Info("StatsD exporter started", "address", e.config.Address, "prefix", e.prefix)
+	return nil
+}
+
+// Close sends the buffered metrics and closes the socket
+func (e *StatsDExporter) Close() {
+	e.writer.close()
+	if e.conn != nil {
+		e.conn.Close()
+		e.conn = nil
+	}
+}
+
+// ExportSystemStats sends the system gauges of a collection
+func (e *StatsDExporter) ExportSystemStats(stats types.SystemStats) {
+	e.writer.add(graphiteSystemMetrics(e.prefix, stats)...)
+	e.writer.wake()
+}
+
+// ExportCheckResult sends the metrics of a check result
+func (e *StatsDExporter) ExportCheckResult(result types.HTTPCheckResult) {
+	e.writer.add(graphiteCheckMetrics(e.prefix, result)...)
+	e.writer.wake()
+}
+
+// write sends a batch as newline-separated "name:value|type" metrics, packed into packets that
+// fit the MTU. Gauges and timers are only meaningful when current, so a failed send is dropped
+// instead of retried.
+func (e *StatsDExporter) write(metrics []graphiteMetric) error {
+	if e.conn == nil {
+		conn, err := net.Dial("udp", e.config.Address)
+		if err != nil {
+			return fmt.Errorf("%w: failed to resolve StatsD address: %w", errRejected, err)
+		}
+		e.conn = conn
+	}
+
+	for _, packet := range statsDPackets(metrics) {
+		if _, err := e.conn.Write(packet); err != nil {
+			return fmt.Errorf("%w: failed to send to StatsD: %w", errRejected, err)
+		}
+	}
+	return nil
+}
+
+// statsDPackets formats metrics in the StatsD protocol, packing as many as fit into each packet
+func statsDPackets(metrics []graphiteMetric) [][]byte {
+	var packets [][]byte
+	var packet []byte
+	for _, m := range metrics {
+		value, ok := graphiteValue(m.value)
+		if !ok {
+			continue
+		}
+		kind := "g"
+		if m.timing {
+			kind = "ms"
+		}
+		line := m.path + ":" + value + "|" + kind
+
+		if len(packet) > 0 && len(packet)+1+len(line) > statsDMaxPacketSize {
+			packets = append(packets, packet)
+			packet = nil
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) > 0 {
+		packets = append(packets, packet)
+	}
+	return packets
+}
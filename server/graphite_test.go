@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestGraphiteMetrics(t *testing.T) {
+	if prefix := graphitePrefix("", "web-01.example.com"); prefix != "monic.web-01_example_com" {
+		t.Errorf("Unexpected default prefix %q", prefix)
+	}
+	if prefix := graphitePrefix("servers.web.", "web-01"); prefix != "servers.web" {
+		t.Errorf("Unexpected configured prefix %q", prefix)
+	}
+
+	ts := time.Unix(1700000000, 0)
+	stats := types.SystemStats{
+		Timestamp:   ts,
+		CPUUsage:    12.5,
+		LoadAverage: types.LoadStats{Load1: 0.5, Load5: 1, Load15: 2, Cores: 4},
+		DiskUsage:   map[string]types.DiskStats{"/": {UsedPercent: 80}, "/var/lib/docker": {UsedPercent: 30}},
+	}
+	var paths []string
+	for _, m := range graphiteSystemMetrics("monic.web", stats) {
+		paths = append(paths, m.path)
+	}
+	want := "monic.web.system.cpu_usage_percent,monic.web.system.memory_usage_percent,monic.web.system.swap_usage_percent," +
+		"monic.web.system.load.1m,monic.web.system.load.5m,monic.web.system.load.15m," +
+		"monic.web.system.disk.root.usage_percent,monic.web.system.disk.var_lib_docker.usage_percent"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("Expected paths\n%s\ngot\n%s", want, got)
+	}
+
+	metrics := graphiteCheckMetrics("monic.web", types.HTTPCheckResult{Name: "api.example.com", Success: true, ResponseTime: 1500 * time.Microsecond, Value: "42", Timestamp: ts})
+	if len(metrics) != 3 || metrics[1].path != "monic.web.checks.http.api_example_com.response_time_ms" || metrics[1].value != 1.5 || !metrics[1].timing || metrics[2].value != 42 {
+		t.Errorf("Unexpected check metrics: %+v", metrics)
+	}
+}
+
+func TestStatsDPackets(t *testing.T) {
+	metrics := []graphiteMetric{
+		{path: "monic.web.checks.http.api.up", value: 1},
+		{path: "monic.web.checks.http.api.response_time_ms", value: 250.5, timing: true},
+	}
+	packets := statsDPackets(metrics)
+	if len(packets) != 1 || string(packets[0]) != "monic.web.checks.http.api.up:1|g\nmonic.web.checks.http.api.response_time_ms:250.5|ms" {
+		t.Errorf("Unexpected packets: %q", packets)
+	}
+
+	var many []graphiteMetric
+	for i := 0; i < 100; i++ {
+		many = append(many, graphiteMetric{path: "monic.web.system.cpu_usage_percent", value: 12.5})
+	}
+	packets = statsDPackets(many)
+	lines := 0
+	for _, packet := range packets {
+		if len(packet) > statsDMaxPacketSize {
+			t.Errorf("Packet of %d bytes exceeds the limit", len(packet))
+		}
+		lines += strings.Count(string(packet), "\n") + 1
+	}
+	if len(packets) < 2 || lines != 100 {
+		t.Errorf("Expected 100 metrics split over several packets, got %d in %d", lines, len(packets))
+	}
+}
+
+func TestGraphiteExporter_Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	exporter := NewGraphiteExporter(&types.GraphiteConfig{Address: listener.Addr().String()}, "web")
+	if err := exporter.Start(); err != nil {
+		t.Fatalf("Failed to start exporter: %v", err)
+	}
+	defer exporter.Close()
+	exporter.ExportCheckResult(types.HTTPCheckResult{Name: "api", Success: true, ResponseTime: 20 * time.Millisecond, Timestamp: time.Unix(1700000000, 0)})
+
+	// Sent right away, without waiting for the retry interval
+	for _, want := range []string{"monic.web.checks.http.api.up 1 1700000000", "monic.web.checks.http.api.response_time_ms 20 1700000000"} {
+		select {
+		case line := <-lines:
+			if line != want {
+				t.Errorf("Expected %q, got %q", want, line)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %q", want)
+		}
+	}
+}
+
+func TestStatsDExporter_Send(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	exporter := NewStatsDExporter(&types.StatsDConfig{Address: conn.LocalAddr().String(), Prefix: "ops"}, "web")
+	if err := exporter.Start(); err != nil {
+		t.Fatalf("Failed to start exporter: %v", err)
+	}
+	defer exporter.Close()
+	exporter.ExportSystemStats(types.SystemStats{CPUUsage: 12.5, DiskUsage: map[string]types.DiskStats{"/": {UsedPercent: 80}}})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, statsDMaxPacketSize)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to receive packet: %v", err)
+	}
+	want := "ops.system.cpu_usage_percent:12.5|g\nops.system.memory_usage_percent:0|g\nops.system.swap_usage_percent:0|g\nops.system.disk.root.usage_percent:80|g"
+	if string(buf[:n]) != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf[:n])
+	}
+}
+
+func TestGraphiteExporter_StartValidation(t *testing.T) {
+	for _, address := range []string{"graphite", ":2003", "graphite:"} {
+		if err := NewGraphiteExporter(&types.GraphiteConfig{Address: address}, "web").Start(); err == nil {
+			t.Errorf("Expected error for %q", address)
+		}
+		if err := NewStatsDExporter(&types.StatsDConfig{Address: address}, "web").Start(); err == nil {
+			t.Errorf("Expected error for %q", address)
+		}
+	}
+}
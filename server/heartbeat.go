@@ -0,0 +1,35 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"bconf.com/monic/monitor"
+)
+
+// SetHeartbeatMonitor enables the heartbeat endpoint
+func (s *StatsServer) SetHeartbeatMonitor(heartbeats *monitor.HeartbeatMonitor) {
+	s.heartbeats = heartbeats
+}
+
+// handleHeartbeat handles POST /api/heartbeat/{name}, called by external jobs when they run
+func (s *StatsServer) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if s.heartbeats == nil {
+		http.Error(w, "Heartbeats are not configured", http.StatusNotFound)
+		return
+	}
+
+	name := r.PathValue("name")
+	now := time.Now()
+	if err := s.heartbeats.Ping(name, now); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	slog.Debug("Heartbeat received", "name", name, "remote", r.RemoteAddr)
+	writeJSON(w, map[string]interface{}{
+		"name":        name,
+		"received_at": now.Format(time.RFC3339),
+	})
+}
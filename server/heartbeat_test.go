@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+func TestStatsServer_Heartbeat(t *testing.T) {
+	config := &types.HTTPServerConfig{Ingest: types.IngestConfig{Secret: "hb-secret"}}
+	server := NewStatsServer(config, nil, NewStorageManager(100), nil)
+	mux := server.routes()
+
+	// Not configured
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, signedRequest("/api/heartbeat/backup", "", "hb-secret", time.Now(), "n1"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without heartbeats, got %d", w.Code)
+	}
+
+	heartbeats := monitor.NewHeartbeatMonitor([]types.HeartbeatCheck{{Name: "backup", Interval: 60}})
+	server.SetHeartbeatMonitor(heartbeats)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, signedRequest("/api/heartbeat/backup", "", "hb-secret", time.Now(), "n2"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, signedRequest("/api/heartbeat/unknown", "", "hb-secret", time.Now(), "n3"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown heartbeat, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/heartbeat/backup", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for unsigned heartbeat, got %d", w.Code)
+	}
+
+	if results := heartbeats.RunChecks(); len(results) != 1 || !results[0].Success {
+		t.Errorf("Expected a healthy heartbeat, got %+v", results)
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// defaultHistoryRange is the time range returned when "from" is not given
+const defaultHistoryRange = time.Hour
+
+// historyQuery is the time range and resolution of a history request
+type historyQuery struct {
+	From time.Time
+	To   time.Time
+	Step time.Duration // Zero returns every stored sample
+}
+
+// parseHistoryQuery reads "from" and "to" (RFC3339 or Unix seconds, default: the last hour)
+// and "step" (duration, e.g. 5m) from a history request
+func parseHistoryQuery(r *http.Request, now time.Time) (historyQuery, error) {
+	query := r.URL.Query()
+	q := historyQuery{To: now}
+
+	if to := query.Get("to"); to != "" {
+		t, err := parseHistoryTime(to)
+		if err != nil {
+			return q, fmt.Errorf("invalid to parameter, expected RFC3339 time or Unix seconds: %s", to)
+		}
+		q.To = t
+	}
+
+	q.From = q.To.Add(-defaultHistoryRange)
+	if from := query.Get("from"); from != "" {
+		t, err := parseHistoryTime(from)
+		if err != nil {
+			return q, fmt.Errorf("invalid from parameter, expected RFC3339 time or Unix seconds: %s", from)
+		}
+		q.From = t
+	}
+	if q.From.After(q.To) {
+		return q, fmt.Errorf("from must not be after to")
+	}
+
+	if step := query.Get("step"); step != "" {
+		d, err := time.ParseDuration(step)
+		if err != nil || d <= 0 {
+			return q, fmt.Errorf("invalid step parameter, expected positive duration: %s", step)
+		}
+		q.Step = d
+	}
+
+	return q, nil
+}
+
+// applyWindow sets the start of the range to "window" (a duration) before its end, as an
+// alternative to "from"
+func (q *historyQuery) applyWindow(values url.Values) error {
+	window := values.Get("window")
+	if window == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid window parameter, expected positive duration: %s", window)
+	}
+	if values.Get("from") != "" {
+		return fmt.Errorf("window and from are mutually exclusive")
+	}
+	q.From = q.To.Add(-d)
+	return nil
+}
+
+// parseHistoryTime parses an RFC3339 time or Unix seconds
+func parseHistoryTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// downsample keeps the latest sample of every series in each step-sized window, counted from
+// the start of the range. Without a step all samples are returned.
+func downsample[T any](samples []T, q historyQuery, timestamp func(T) time.Time, series func(T) string) []T {
+	if q.Step <= 0 {
+		return samples
+	}
+
+	type window struct {
+		series string
+		index  int64
+	}
+	positions := make(map[window]int)
+	result := make([]T, 0)
+	for _, sample := range samples {
+		key := window{series(sample), int64(timestamp(sample).Sub(q.From) / q.Step)}
+		if i, exists := positions[key]; exists {
+			result[i] = sample
+			continue
+		}
+		positions[key] = len(result)
+		result = append(result, sample)
+	}
+	return result
+}
+
+// writeHistory writes the samples of a history request
+func writeHistory[T any](w http.ResponseWriter, q historyQuery, samples []T) {
+	writeJSON(w, map[string]interface{}{
+		"from":         q.From.Format(time.RFC3339),
+		"to":           q.To.Format(time.RFC3339),
+		"step_seconds": q.Step.Seconds(),
+		"count":        len(samples),
+		"samples":      samples,
+	})
+}
+
+// handleSystemHistory handles GET /api/v1/system/history
+func (s *StatsServer) handleSystemHistory(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	samples := downsample(s.storage.GetSystemStatsRange(q.From, q.To), q,
+		func(stats types.SystemStats) time.Time { return stats.Timestamp },
+		func(types.SystemStats) string { return "" })
+	writeHistory(w, q, samples)
+}
+
+// handleHTTPHistory handles GET /api/v1/http/history: results of all checks (HTTP, gRPC, ...),
+// optionally limited to one check with "name" and "type"
+func (s *StatsServer) handleHTTPHistory(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	checkType := r.URL.Query().Get("type")
+	results := make([]types.HTTPCheckResult, 0)
+	for _, result := range s.storage.GetHTTPCheckResultsRange(q.From, q.To) {
+		if result.Type == "" {
+			result.Type = "http"
+		}
+		if (name == "" || result.Name == name) && (checkType == "" || result.Type == checkType) {
+			results = append(results, result)
+		}
+	}
+
+	samples := downsample(results, q,
+		func(result types.HTTPCheckResult) time.Time { return result.Timestamp },
+		func(result types.HTTPCheckResult) string { return result.Type + "/" + result.Name + "/" + result.URL })
+	writeHistory(w, q, samples)
+}
+
+// handleDockerHistory handles GET /api/v1/docker/history, optionally limited to one container with "name"
+func (s *StatsServer) handleDockerHistory(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	containers := make([]types.DockerContainerStats, 0)
+	for _, stats := range s.storage.GetDockerContainerStatsRange(q.From, q.To) {
+		if name == "" || stats.Name == name {
+			containers = append(containers, stats)
+		}
+	}
+
+	samples := downsample(containers, q,
+		func(stats types.DockerContainerStats) time.Time { return stats.Timestamp },
+		func(stats types.DockerContainerStats) string { return stats.Name })
+	writeHistory(w, q, samples)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestStorage_HistoryRange(t *testing.T) {
+	bolt, err := NewBoltStorage(filepath.Join(t.TempDir(), "monic.db"), 100)
+	if err != nil {
+		t.Fatalf("Failed to open bolt storage: %v", err)
+	}
+	defer bolt.Close()
+
+	backends := map[string]Storage{
+		"memory": NewStorageManager(100),
+		"bolt":   bolt,
+	}
+
+	for name, storage := range backends {
+		t.Run(name, func(t *testing.T) {
+			base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			for i := 0; i < 5; i++ {
+				ts := base.Add(time.Duration(i) * time.Minute)
+				storage.AddSystemStats(types.SystemStats{Timestamp: ts, CPUUsage: float64(i)})
+				storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Timestamp: ts, StatusCode: 200 + i})
+				storage.AddDockerContainerStats([]types.DockerContainerStats{{Name: "web", Timestamp: ts}, {Name: "db", Timestamp: ts}})
+			}
+
+			from, to := base.Add(time.Minute), base.Add(3*time.Minute)
+			stats := storage.GetSystemStatsRange(from, to)
+			if len(stats) != 3 || stats[0].CPUUsage != 1 || stats[2].CPUUsage != 3 {
+				t.Errorf("Expected system stats 1-3 in order, got %+v", stats)
+			}
+			results := storage.GetHTTPCheckResultsRange(from, to)
+			if len(results) != 3 || results[0].StatusCode != 201 {
+				t.Errorf("Expected 3 check results from 201, got %+v", results)
+			}
+			if containers := storage.GetDockerContainerStatsRange(from, to); len(containers) != 6 {
+				t.Errorf("Expected 6 container samples, got %d", len(containers))
+			}
+			if empty := storage.GetSystemStatsRange(base.Add(time.Hour), base.Add(2*time.Hour)); len(empty) != 0 {
+				t.Errorf("Expected no samples after the last one, got %d", len(empty))
+			}
+		})
+	}
+}
+
+func TestParseHistoryQuery(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	q, err := parseHistoryQuery(httptest.NewRequest("GET", "/api/v1/system/history", nil), now)
+	if err != nil || !q.To.Equal(now) || !q.From.Equal(now.Add(-time.Hour)) || q.Step != 0 {
+		t.Errorf("Expected the last hour by default, got %+v (err: %v)", q, err)
+	}
+
+	url := "/api/v1/system/history?from=2025-01-01T10:00:00Z&to=" + strconv.FormatInt(now.Unix(), 10) + "&step=5m"
+	q, err = parseHistoryQuery(httptest.NewRequest("GET", url, nil), now)
+	if err != nil || q.From.Hour() != 10 || !q.To.Equal(now) || q.Step != 5*time.Minute {
+		t.Errorf("Unexpected query %+v (err: %v)", q, err)
+	}
+
+	for _, invalid := range []string{"from=yesterday", "to=soon", "step=-1m", "step=often", "from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z"} {
+		if _, err := parseHistoryQuery(httptest.NewRequest("GET", "/api/v1/system/history?"+invalid, nil), now); err == nil {
+			t.Errorf("Expected error for %s", invalid)
+		}
+	}
+}
+
+func TestStatsServer_HistoryAPI(t *testing.T) {
+	storage := NewStorageManager(100)
+	server := NewStatsServer(&types.HTTPServerConfig{}, nil, storage, nil)
+	mux := server.routes()
+
+	base := time.Now().Add(-30 * time.Minute).Truncate(time.Minute)
+	for i := 0; i < 10; i++ {
+		ts := base.Add(time.Duration(i) * 30 * time.Second)
+		storage.AddSystemStats(types.SystemStats{Timestamp: ts, CPUUsage: float64(i)})
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{URL: "https://example.com", Timestamp: ts, Success: true})
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "db", Type: "grpc", Timestamp: ts})
+		storage.AddDockerContainerStats([]types.DockerContainerStats{{Name: "web", Timestamp: ts}, {Name: "db", Timestamp: ts}})
+	}
+
+	get := func(url string) map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+	from := "&from=" + strconv.FormatInt(base.Unix(), 10)
+
+	if response := get("/api/v1/system/history?step=1m" + from); response["count"] != 5.0 {
+		t.Errorf("Expected 5 system samples at 1m steps, got %v", response["count"])
+	}
+	// The latest sample of each window is kept
+	response := get("/api/v1/system/history?step=1m" + from)
+	if first := response["samples"].([]interface{})[0].(map[string]interface{}); first["CPUUsage"] != 1.0 {
+		t.Errorf("Expected the latest sample of the first window, got %v", first["CPUUsage"])
+	}
+
+	if response := get("/api/v1/http/history?type=http" + from); response["count"] != 10.0 {
+		t.Errorf("Expected 10 HTTP results, got %v", response["count"])
+	}
+	if response := get("/api/v1/http/history?step=5m&name=db" + from); response["count"] != 1.0 {
+		t.Errorf("Expected 1 downsampled gRPC result, got %v", response["count"])
+	}
+	if response := get("/api/v1/docker/history?step=1m" + from); response["count"] != 10.0 {
+		t.Errorf("Expected 5 samples per container, got %v", response["count"])
+	}
+	if response := get("/api/v1/docker/history?name=web" + from); response["count"] != 10.0 {
+		t.Errorf("Expected 10 samples of web, got %v", response["count"])
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/system/history?step=0s", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid step, got %d", w.Code)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// excelTimeLayout is a timestamp format spreadsheet applications recognize as a date and time
+const excelTimeLayout = "2006-01-02 15:04:05"
+
+// utf8BOM makes Excel read a CSV file as UTF-8 instead of the system code page
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// fileNameUnsafeChars matches characters replaced in download file names
+var fileNameUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// handleCheckHistoryCSV handles GET /api/v1/http-checks/{name}/history.csv: the results of a check
+// (of any type, or only of "type") as CSV for spreadsheets. The range is given with "from"/"to"
+// or "window" as in the history API, with "step" to keep one result per interval. With "excel"
+// the file starts with a UTF-8 byte order mark and has timestamps Excel parses as dates (UTC).
+func (s *StatsServer) handleCheckHistoryCSV(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	q, err := parseHistoryQuery(r, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	values := r.URL.Query()
+	if err := q.applyWindow(values); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	excel := false
+	if value := values.Get("excel"); value != "" {
+		if excel, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "invalid excel parameter, expected true or false: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+
+	name := r.PathValue("name")
+	checkType := values.Get("type")
+	if !s.hasCheck(name, checkType) {
+		http.Error(w, fmt.Sprintf("check %q not found", name), http.StatusNotFound)
+		return
+	}
+
+	results := make([]types.HTTPCheckResult, 0)
+	for _, result := range s.storage.GetHTTPCheckResultsRange(q.From, q.To) {
+		if result.Type == "" {
+			result.Type = "http"
+		}
+		if result.Name == name && (checkType == "" || result.Type == checkType) {
+			results = append(results, result)
+		}
+	}
+	results = downsample(results, q,
+		func(result types.HTTPCheckResult) time.Time { return result.Timestamp },
+		func(result types.HTTPCheckResult) string { return result.Type + "/" + result.URL })
+
+	formatTime := csvTime
+	var body bytes.Buffer
+	if excel {
+		body.Write(utf8BOM)
+		formatTime = func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.UTC().Format(excelTimeLayout)
+		}
+	}
+	if err := csv.NewWriter(&body).WriteAll(checkCSVRows(results, formatTime)); err != nil {
+		http.Error(w, "Failed to encode CSV: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-history-%s.csv", fileNameUnsafeChars.ReplaceAllString(name, "_"), now.UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	if _, err := w.Write(body.Bytes()); err != nil {
+		slog.Error("Error writing check history CSV", "error", err)
+	}
+}
+
+// hasCheck reports whether results of a check (optionally of a given type) were recorded
+func (s *StatsServer) hasCheck(name, checkType string) bool {
+	for _, status := range s.storage.GetCheckStatuses() {
+		if status.Latest.Name == name && (checkType == "" || strings.SplitN(checkKey(status.Latest), "/", 2)[0] == checkType) {
+			return true
+		}
+	}
+	return false
+}
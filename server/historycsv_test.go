@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestStatsServer_CheckHistoryCSV(t *testing.T) {
+	storage := NewStorageManager(100)
+	server := NewStatsServer(&types.HTTPServerConfig{}, nil, storage, nil)
+	mux := server.routes()
+
+	ts := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", URL: "https://api.example.com", Timestamp: ts, ResponseTime: 1500 * time.Microsecond, StatusCode: 200, Success: true})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", URL: "https://api.example.com", Timestamp: ts.Add(time.Minute), StatusCode: 502, Error: "bad gateway, retrying"})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Type: "grpc", Timestamp: ts, Success: true})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "db", Timestamp: ts, Success: true})
+
+	get := func(url string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w
+	}
+
+	w := get("/api/v1/http-checks/api/history.csv?from=2025-06-01T11:00:00Z&to=2025-06-01T13:00:00Z&type=http")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/csv; charset=utf-8" || !strings.HasPrefix(w.Header().Get("Content-Disposition"), `attachment; filename="api-history-`) {
+		t.Errorf("Unexpected headers: %v", w.Header())
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "timestamp" {
+		t.Fatalf("Expected a header and 2 HTTP results, got %q", rows)
+	}
+	if strings.Join(rows[1], "|") != "2025-06-01T12:00:00Z|http|api|https://api.example.com|true|200|1.5|" || rows[2][7] != "bad gateway, retrying" {
+		t.Errorf("Unexpected rows: %q", rows[1:])
+	}
+
+	// Excel: byte order mark and spreadsheet timestamps; all types of the check without "type"
+	w = get("/api/v1/http-checks/api/history.csv?from=2025-06-01T11:00:00Z&to=2025-06-01T13:00:00Z&excel=true")
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "\ufefftimestamp,") || !strings.Contains(body, "\n2025-06-01 12:00:00,grpc,api,") || strings.Count(body, "\n") != 4 {
+		t.Errorf("Unexpected Excel CSV:\n%s", body)
+	}
+
+	for url, code := range map[string]int{
+		"/api/v1/http-checks/missing/history.csv":              http.StatusNotFound,
+		"/api/v1/http-checks/db/history.csv?type=grpc":         http.StatusNotFound,
+		"/api/v1/http-checks/api/history.csv?excel=maybe":      http.StatusBadRequest,
+		"/api/v1/http-checks/api/history.csv?window=-1h":       http.StatusBadRequest,
+		"/api/v1/http-checks/api/history.csv?window=24h":       http.StatusOK,
+		"/api/v1/http-checks/api/history.csv?from=1&window=1h": http.StatusBadRequest,
+	} {
+		if w := get(url); w.Code != code {
+			t.Errorf("Expected %d for %s, got %d", code, url, w.Code)
+		}
+	}
+}
+
+func TestStatsServer_CheckHistoryCSVFormulas(t *testing.T) {
+	storage := NewStorageManager(100)
+	server := NewStatsServer(&types.HTTPServerConfig{}, nil, storage, nil)
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{
+		Name:      "api",
+		URL:       "+https://api.example.com",
+		Timestamp: time.Now(),
+		Error:     `=HYPERLINK("https://evil.example.com","details")`,
+	})
+
+	w := httptest.NewRecorder()
+	server.routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/http-checks/api/history.csv?window=1h&excel=true", nil))
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(w.Body.String(), "\ufeff"))).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("Expected a header and one result, got %q (%v)", rows, err)
+	}
+	if rows[1][3] != "'+https://api.example.com" || rows[1][7] != `'=HYPERLINK("https://evil.example.com","details")` {
+		t.Errorf("Expected cells starting with a formula character to be quoted, got %q", rows[1])
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	tests := map[string]string{
+		"":                "",
+		"connection reset": "connection reset",
+		"=1+1":            "'=1+1",
+		"+1":              "'+1",
+		"-1":              "'-1",
+		"@SUM(A1)":        "'@SUM(A1)",
+		"\tcmd":           "'\tcmd",
+		"\rcmd":           "'\rcmd",
+		"a=b":             "a=b",
+	}
+	for value, want := range tests {
+		if got := csvText(value); got != want {
+			t.Errorf("csvText(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	defaultInfluxDBPrefix        = "monic"
+	defaultInfluxDBFlushInterval = 10
+	defaultInfluxDBBatchSize     = 1000
+	defaultInfluxDBBufferSize    = 10000
+	defaultInfluxDBTimeout       = 10
+)
+
+// InfluxDBExporter writes system stats and check results to InfluxDB in line protocol. Points
+// are buffered and written in batches, so an unreachable InfluxDB doesn't slow down monitoring.
+type InfluxDBExporter struct {
+	config *types.InfluxDBConfig
+	host   string
+	prefix string
+	client *http.Client
+	writer *batchWriter[string]
+}
+
+// NewInfluxDBExporter creates an InfluxDB exporter; host is added as a tag to every point
+func NewInfluxDBExporter(config *types.InfluxDBConfig, host string) *InfluxDBExporter {
+	prefix := config.MeasurementPrefix
+	if prefix == "" {
+		prefix = defaultInfluxDBPrefix
+	}
+
+	e := &InfluxDBExporter{
+		config: config,
+		host:   host,
+		prefix: prefix,
+		client: &http.Client{Timeout: time.Duration(positiveOr(config.Timeout, defaultInfluxDBTimeout)) * time.Second},
+	}
+	e.writer = newBatchWriter("influxdb", e.write,
+		time.Duration(positiveOr(config.FlushInterval, defaultInfluxDBFlushInterval))*time.Second,
+		positiveOr(config.BatchSize, defaultInfluxDBBatchSize),
+		positiveOr(config.BufferSize, defaultInfluxDBBufferSize))
+	return e
+}
+
+// positiveOr returns value, or def if value is not positive
+func positiveOr(value, def int) int {
+	if value > 0 {
+		return value
+	}
+	return def
+}
+
+// Name returns the subsystem name of the exporter
+func (e *InfluxDBExporter) Name() string {
+	return "influxdb"
+}
+
+// Start validates the configuration and starts writing. An unreachable InfluxDB does not fail
+// startup: points are buffered and the write is retried on the next flush.
+func (e *InfluxDBExporter) Start() error {
+	u, err := url.Parse(e.config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid InfluxDB URL %q", e.config.URL)
+	}
+	if (e.config.Bucket == "") == (e.config.Database == "") {
+		return fmt.Errorf("either an InfluxDB bucket (2.x) or database (1.x) is required")
+	}
+
+	e.writer.start()
+	slog.Info("InfluxDB exporter started", "url", e.config.URL, "bucket", e.config.Bucket, "database", e.config.Database)
+	return nil
+}
+
+// Close writes the buffered points and stops the exporter
+func (e *InfluxDBExporter) Close() {
+	e.writer.close()
+}
+
+// ExportSystemStats queues the points of a system stats collection
+func (e *InfluxDBExporter) ExportSystemStats(stats types.SystemStats) {
+	e.writer.add(influxSystemLines(e.prefix, e.host, stats)...)
+}
+
+// ExportCheckResult queues the point of a check result
+func (e *InfluxDBExporter) ExportCheckResult(result types.HTTPCheckResult) {
+	e.writer.add(influxCheckLine(e.prefix, e.host, result))
+}
+
+// write sends a batch of points; a 4xx response other than 429 means InfluxDB refused the data
+func (e *InfluxDBExporter) write(lines []string) error {
+	req, err := http.NewRequest(http.MethodPost, e.writeURL(), strings.NewReader(strings.Join(lines, "\n")+"\n"))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if e.config.Token != "" {
+		req.Header.Set("Authorization", "Token "+e.config.Token)
+	} else if e.config.Username != "" {
+		req.SetBasicAuth(e.config.Username, e.config.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("InfluxDB returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", errRejected, err)
+	}
+	return err
+}
+
+// writeURL returns the write endpoint of InfluxDB 2.x (bucket) or 1.x (database)
+func (e *InfluxDBExporter) writeURL() string {
+	base := strings.TrimSuffix(e.config.URL, "/")
+	query := url.Values{"precision": {"ns"}}
+	if e.config.Bucket != "" {
+		query.Set("bucket", e.config.Bucket)
+		query.Set("org", e.config.Org)
+		return base + "/api/v2/write?" + query.Encode()
+	}
+	query.Set("db", e.config.Database)
+	return base + "/write?" + query.Encode()
+}
+
+// influxSystemLines converts system stats to a <prefix>_system point and a <prefix>_disk point per path
+func influxSystemLines(prefix, host string, stats types.SystemStats) []string {
+	tags := map[string]string{"host": host}
+	lines := []string{influxLine(prefix+"_system", tags, map[string]interface{}{
+		"cpu_usage":           stats.CPUUsage,
+		"load1":               stats.LoadAverage.Load1,
+		"load5":               stats.LoadAverage.Load5,
+		"load15":              stats.LoadAverage.Load15,
+		"memory_total":        int64(stats.MemoryUsage.Total),
+		"memory_used":         int64(stats.MemoryUsage.Used),
+		"memory_used_percent": stats.MemoryUsage.UsedPercent,
+		"swap_used":           int64(stats.SwapUsage.Used),
+		"swap_used_percent":   stats.SwapUsage.UsedPercent,
+	}, stats.Timestamp)}
+
+	paths := make([]string, 0, len(stats.DiskUsage))
+	for path := range stats.DiskUsage {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		disk := stats.DiskUsage[path]
+		lines = append(lines, influxLine(prefix+"_disk", map[string]string{"host": host, "path": path}, map[string]interface{}{
+			"total":        int64(disk.Total),
+			"used":         int64(disk.Used),
+			"used_percent": disk.UsedPercent,
+		}, stats.Timestamp))
+	}
+	return lines
+}
+
+// influxCheckLine converts a check result to a <prefix>_check point tagged with the check type
+// and name; numeric polled values are written as the value field
+func influxCheckLine(prefix, host string, result types.HTTPCheckResult) string {
+	key := checkKey(result)
+	fields := map[string]interface{}{
+		"success":          result.Success,
+		"response_time_ms": float64(result.ResponseTime) / float64(time.Millisecond),
+		"status_code":      int64(result.StatusCode),
+	}
+	if result.URL != "" {
+		fields["url"] = result.URL
+	}
+	if result.Error != "" {
+		fields["error"] = result.Error
+	}
+	if value, err := strconv.ParseFloat(strings.TrimSpace(result.Value), 64); err == nil {
+		fields["value"] = value
+	}
+
+	tags := map[string]string{"host": host, "type": strings.SplitN(key, "/", 2)[0], "name": result.Name}
+	return influxLine(prefix+"_check", tags, fields, result.Timestamp)
+}
+
+// Line protocol escaping of measurements, tag keys and values, and string field values
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	influxStringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// influxLine formats a point in line protocol with sorted tags and fields; empty tag values are
+// left out, as line protocol does not allow them
+func influxLine(measurement string, tags map[string]string, fields map[string]interface{}, timestamp time.Time) string {
+	var b strings.Builder
+	b.WriteString(influxMeasurementEscaper.Replace(measurement))
+
+	for _, key := range sortedKeys(tags) {
+		if tags[key] == "" {
+			continue
+		}
+		b.WriteString("," + influxTagEscaper.Replace(key) + "=" + influxTagEscaper.Replace(tags[key]))
+	}
+
+	encoded := make([]string, 0, len(fields))
+	for _, key := range sortedKeys(fields) {
+		var value string
+		switch v := fields[key].(type) {
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue // Not representable in line protocol
+			}
+			value = strconv.FormatFloat(v, 'g', -1, 64)
+		case int64:
+			value = strconv.FormatInt(v, 10) + "i"
+		case bool:
+			value = strconv.FormatBool(v)
+		case string:
+			value = `"` + influxStringEscaper.Replace(v) + `"`
+		}
+		encoded = append(encoded, influxTagEscaper.Replace(key)+"="+value)
+	}
+	b.WriteString(" " + strings.Join(encoded, ","))
+
+	b.WriteString(" " + strconv.FormatInt(timestamp.UnixNano(), 10))
+	return b.String()
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestInfluxLine(t *testing.T) {
+	ts := time.Unix(0, 1700000000000000000)
+
+	line := influxLine("monic_check", map[string]string{"name": "api, eu=1", "host": "web 01", "empty": ""}, map[string]interface{}{
+		"success":     true,
+		"error":       `bad "gateway" \ retry`,
+		"status_code": int64(502),
+		"value":       1.5,
+		"nan":         math.NaN(),
+	}, ts)
+	want := `monic_check,host=web\ 01,name=api\,\ eu\=1 error="bad \"gateway\" \\ retry",status_code=502i,success=true,value=1.5 1700000000000000000`
+	if line != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, line)
+	}
+
+	stats := types.SystemStats{
+		Timestamp:   ts,
+		CPUUsage:    12.5,
+		MemoryUsage: types.MemoryStats{Total: 1024, Used: 512, UsedPercent: 50},
+		DiskUsage:   map[string]types.DiskStats{"/data": {Total: 100, Used: 25, UsedPercent: 25}, "/": {UsedPercent: 80}},
+	}
+	lines := influxSystemLines("monic", "web", stats)
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "monic_system,host=web cpu_usage=12.5,") || !strings.Contains(lines[0], "memory_used=512i") {
+		t.Errorf("Unexpected system lines: %v", lines)
+	}
+	if !strings.HasPrefix(lines[1], "monic_disk,host=web,path=/ ") || lines[2] != "monic_disk,host=web,path=/data total=100i,used=25i,used_percent=25 1700000000000000000" {
+		t.Errorf("Expected disk lines sorted by path, got %v", lines[1:])
+	}
+
+	check := influxCheckLine("monic", "web", types.HTTPCheckResult{Name: "db", Type: "snmp", Value: " 42 ", ResponseTime: 1500 * time.Microsecond, Timestamp: ts})
+	if check != "monic_check,host=web,name=db,type=snmp response_time_ms=1.5,status_code=0i,success=false,value=42 1700000000000000000" {
+		t.Errorf("Unexpected check line: %s", check)
+	}
+}
+
+// influxServer records the write requests of an InfluxDB exporter, answering with the given statuses in turn
+type influxServer struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+// ServeHTTP records a write request
+func (s *influxServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	s.bodies = append(s.bodies, string(body))
+	status := http.StatusNoContent
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestInfluxDBExporter_Write(t *testing.T) {
+	recorder := &influxServer{}
+	ts := httptest.NewServer(recorder)
+	defer ts.Close()
+
+	exporter := NewInfluxDBExporter(&types.InfluxDBConfig{URL: ts.URL, Org: "acme", Bucket: "monitoring", Token: "secret", FlushInterval: 3600}, "web")
+	if err := exporter.Start(); err != nil {
+		t.Fatalf("Failed to start exporter: %v", err)
+	}
+	exporter.ExportSystemStats(types.SystemStats{CPUUsage: 10})
+	exporter.ExportCheckResult(types.HTTPCheckResult{Name: "api", Success: true})
+	exporter.Close() // Writes the buffered points
+
+	if len(recorder.requests) != 1 {
+		t.Fatalf("Expected 1 write request, got %d", len(recorder.requests))
+	}
+	req := recorder.requests[0]
+	if req.URL.Path != "/api/v2/write" || req.URL.Query().Get("bucket") != "monitoring" || req.URL.Query().Get("org") != "acme" || req.URL.Query().Get("precision") != "ns" {
+		t.Errorf("Unexpected write URL: %s", req.URL)
+	}
+	if req.Header.Get("Authorization") != "Token secret" {
+		t.Errorf("Expected token authorization, got %q", req.Header.Get("Authorization"))
+	}
+	if lines := strings.Split(strings.TrimSpace(recorder.bodies[0]), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "monic_check,host=web,name=api,type=http ") {
+		t.Errorf("Unexpected points: %q", recorder.bodies[0])
+	}
+
+	// InfluxDB 1.x
+	exporter = NewInfluxDBExporter(&types.InfluxDBConfig{URL: ts.URL, Database: "monic", Username: "user", Password: "pass"}, "web")
+	if err := exporter.write([]string{"m f=1 1"}); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	req = recorder.requests[1]
+	if user, pass, _ := req.BasicAuth(); req.URL.Path != "/write" || req.URL.Query().Get("db") != "monic" || user != "user" || pass != "pass" {
+		t.Errorf("Unexpected 1.x write request: %s (user %q)", req.URL, user)
+	}
+}
+
+func TestInfluxDBExporter_Retry(t *testing.T) {
+	recorder := &influxServer{statuses: []int{http.StatusServiceUnavailable, http.StatusNoContent, http.StatusBadRequest}}
+	ts := httptest.NewServer(recorder)
+	defer ts.Close()
+
+	exporter := NewInfluxDBExporter(&types.InfluxDBConfig{URL: ts.URL, Database: "monic", BatchSize: 2, BufferSize: 3}, "web")
+	for i := 0; i < 4; i++ {
+		exporter.ExportCheckResult(types.HTTPCheckResult{Name: "api", StatusCode: i})
+	}
+	if pending := exporter.writer.pending(); pending != 3 {
+		t.Fatalf("Expected the oldest point dropped from a full buffer, got %d pending", pending)
+	}
+
+	// Unavailable: the points are kept for the next flush
+	if exporter.writer.flush() || exporter.writer.pending() != 3 {
+		t.Fatalf("Expected a failed flush to keep 3 points, got %d", exporter.writer.pending())
+	}
+	// The first batch is written, the second rejected and dropped
+	if !exporter.writer.flush() || exporter.writer.pending() != 0 {
+		t.Errorf("Expected an empty buffer, got %d points", exporter.writer.pending())
+	}
+	if len(recorder.bodies) != 3 || strings.Count(recorder.bodies[1], "\n") != 2 || !strings.Contains(recorder.bodies[1], "status_code=1i") {
+		t.Errorf("Expected the retried batch to start at the oldest kept point, got %q", recorder.bodies)
+	}
+}
+
+func TestInfluxDBExporter_StartValidation(t *testing.T) {
+	for _, config := range []types.InfluxDBConfig{
+		{URL: "localhost:8086", Bucket: "monic"},
+		{URL: "http://localhost:8086"},
+		{URL: "http://localhost:8086", Bucket: "monic", Database: "monic"},
+	} {
+		if err := NewInfluxDBExporter(&config, "web").Start(); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// Headers of signed ingestion requests
+const (
+	signatureHeader = "X-Monic-Signature" // "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + nonce + "." + method + " " + path + "\n" + body))
+	timestampHeader = "X-Monic-Timestamp" // Unix seconds
+	nonceHeader     = "X-Monic-Nonce"     // Unique per request
+)
+
+const (
+	defaultIngestTolerance = 5 * time.Minute
+	maxIngestBodySize      = 64 * 1024
+	maxNonceLength         = 128
+)
+
+// nonceCache remembers nonces of accepted requests for as long as their timestamp is valid
+type nonceCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// newNonceCache creates an empty nonce cache
+func newNonceCache() *nonceCache {
+	return &nonceCache{seen: make(map[string]time.Time)}
+}
+
+// use records a nonce and reports false if it was already used; nonces older than ttl are forgotten
+func (c *nonceCache) use(nonce string, now time.Time, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for seenNonce, seenAt := range c.seen {
+		if now.Sub(seenAt) > ttl {
+			delete(c.seen, seenNonce)
+		}
+	}
+
+	if _, exists := c.seen[nonce]; exists {
+		return false
+	}
+	c.seen[nonce] = now
+	return true
+}
+
+// signPayload computes the signature header value for a request. The method and path are signed
+// as well, so a signed request cannot be replayed against another endpoint or check; the newline
+// after the path cannot occur in it, so path and body cannot be split differently
+func signPayload(secret, timestamp, nonce, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "." + method + " " + path + "\n"))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// signRequest adds the signature headers for body to an outgoing ingestion request
+func signRequest(req *http.Request, secret string, body []byte) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(nonceHeader, hex.EncodeToString(nonce))
+	req.Header.Set(signatureHeader, signPayload(secret, timestamp, hex.EncodeToString(nonce), req.Method, req.URL.EscapedPath(), body))
+	return nil
+}
+
+// signedPath returns the path a request was signed with: the one the client sent, including the
+// base path that http.StripPrefix removes from r.URL
+func signedPath(r *http.Request) string {
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		return u.EscapedPath()
+	}
+	return r.URL.EscapedPath()
+}
+
+// verifySignature checks the signature, timestamp tolerance and nonce of a request body
+func (s *StatsServer) verifySignature(r *http.Request, body []byte, now time.Time) error {
+	config := s.config.Ingest
+	tolerance := defaultIngestTolerance
+	if config.Tolerance > 0 {
+		tolerance = time.Duration(config.Tolerance) * time.Second
+	}
+
+	timestamp := r.Header.Get(timestampHeader)
+	nonce := r.Header.Get(nonceHeader)
+	signature := r.Header.Get(signatureHeader)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return fmt.Errorf("missing signature headers")
+	}
+	if len(nonce) > maxNonceLength {
+		return fmt.Errorf("nonce too long")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp")
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("timestamp outside of tolerance")
+	}
+
+	expected := signPayload(config.Secret, timestamp, nonce, r.Method, signedPath(r), body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	// Checked last so that invalid requests cannot burn nonces; kept for twice the tolerance
+	// since timestamps are accepted up to the tolerance in both directions
+	if !s.nonces.use(nonce, now, 2*tolerance) {
+		return fmt.Errorf("nonce already used")
+	}
+	return nil
+}
+
+// ingestAuth protects inbound push endpoints: with an ingest secret configured requests must be
+// signed (see verifySignature), otherwise the regular basic auth applies
+func (s *StatsServer) ingestAuth(next http.HandlerFunc) http.HandlerFunc {
+	return s.ingestAuthLimit(maxIngestBodySize, next)
+}
+
+// ingestAuthLimit is ingestAuth for endpoints accepting bodies of up to limit bytes
+func (s *StatsServer) ingestAuthLimit(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.Ingest.Secret == "" {
+			s.basicAuth(next)(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > limit {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := s.verifySignature(r, body, time.Now()); err != nil {
+			slog.Warn("Rejected unsigned or invalid ingestion request", "path", r.URL.Path, "remote", r.RemoteAddr, "error", err)
+			http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next(w, r)
+	}
+}
+
+// pushRequest is the optional JSON body of a push check result
+type pushRequest struct {
+	Success        *bool   `json:"success"` // Default: true
+	Error          string  `json:"error"`
+	ResponseTimeMs float64 `json:"response_time_ms"`
+}
+
+// handlePush handles POST /api/v1/push/{name}: external systems report a check result, which is
+// stored and alerted on like any other check (type "push")
+func (s *StatsServer) handlePush(w http.ResponseWriter, r *http.Request) {
+	if s.service == nil {
+		http.Error(w, "Push checks are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var push pushRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxIngestBodySize))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &push); err != nil {
+			http.Error(w, "Invalid JSON body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result := types.HTTPCheckResult{
+		Name:         r.PathValue("name"),
+		Type:         "push",
+		URL:          "push://" + r.PathValue("name"),
+		Success:      push.Success == nil || *push.Success,
+		Error:        push.Error,
+		ResponseTime: time.Duration(push.ResponseTimeMs * float64(time.Millisecond)),
+		Timestamp:    time.Now(),
+	}
+	if !result.Success && result.Error == "" {
+		result.Error = "reported failure"
+	}
+
+	s.service.recordCheckResults("Push", []types.HTTPCheckResult{result})
+	writeJSON(w, result)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// signedRequest builds a push request signed with the given secret, timestamp and nonce
+func signedRequest(path, body, secret string, timestamp time.Time, nonce string) *http.Request {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set(timestampHeader, ts)
+	req.Header.Set(nonceHeader, nonce)
+	req.Header.Set(signatureHeader, signPayload(secret, ts, nonce, "POST", path, []byte(body)))
+	return req
+}
+
+func TestNonceCache(t *testing.T) {
+	cache := newNonceCache()
+	now := time.Now()
+
+	if !cache.use("a", now, time.Minute) {
+		t.Fatal("Expected first use of a nonce to be accepted")
+	}
+	if cache.use("a", now.Add(30*time.Second), time.Minute) {
+		t.Error("Expected a reused nonce to be rejected")
+	}
+	if !cache.use("a", now.Add(2*time.Minute), time.Minute) {
+		t.Error("Expected an expired nonce to be forgotten")
+	}
+}
+
+func TestStatsServer_SignedPush(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPServer: types.HTTPServerConfig{
+			Username: "admin",
+			Password: "secret",
+			Ingest:   types.IngestConfig{Secret: "push-secret", Tolerance: 60},
+		},
+	}
+	service := createTestMonitorService(t, config)
+	mux := service.statsServer.routes()
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+	}{
+		{"valid", signedRequest("/api/v1/push/backup", `{"success":false,"error":"disk full"}`, "push-secret", now, "n1"), http.StatusOK},
+		{"replayed nonce", signedRequest("/api/v1/push/backup", `{"success":false,"error":"disk full"}`, "push-secret", now, "n1"), http.StatusUnauthorized},
+		{"wrong secret", signedRequest("/api/v1/push/backup", `{}`, "other", now, "n2"), http.StatusUnauthorized},
+		{"stale timestamp", signedRequest("/api/v1/push/backup", `{}`, "push-secret", now.Add(-2*time.Minute), "n3"), http.StatusUnauthorized},
+		{"future timestamp", signedRequest("/api/v1/push/backup", `{}`, "push-secret", now.Add(2*time.Minute), "n4"), http.StatusUnauthorized},
+		{"unsigned", httptest.NewRequest("POST", "/api/v1/push/backup", nil), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, tt.req)
+			if w.Code != tt.code {
+				t.Errorf("Expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// A tampered body invalidates the signature
+	req := signedRequest("/api/v1/push/backup", `{"success":true}`, "push-secret", now, "n5")
+	req.Body = httptest.NewRequest("POST", "/", strings.NewReader(`{"success":false}`)).Body
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a tampered body, got %d", w.Code)
+	}
+
+	// The signature covers method and path, so it cannot be replayed against another check
+	req = signedRequest("/api/v1/push/backup", `{"success":true}`, "push-secret", now, "n6")
+	req.URL.Path, req.RequestURI = "/api/v1/push/database", "/api/v1/push/database"
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a request signed for another path, got %d", w.Code)
+	}
+
+	results := service.storage.GetHTTPCheckResults()
+	if len(results) != 1 || results[0].Type != "push" || results[0].Name != "backup" || results[0].Success || results[0].Error != "disk full" {
+		t.Errorf("Expected exactly one stored push result, got %+v", results)
+	}
+}
+
+func TestStatsServer_PushWithBasicAuth(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPServer:   types.HTTPServerConfig{Username: "admin", Password: "secret"},
+	}
+	service := createTestMonitorService(t, config)
+	mux := service.statsServer.routes()
+
+	req := httptest.NewRequest("POST", "/api/v1/push/backup", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/push/backup", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if results := service.storage.GetHTTPCheckResults(); len(results) != 1 || !results[0].Success {
+		t.Errorf("Expected a successful push result by default, got %+v", results)
+	}
+}
+
+func TestStatsServer_SignedPushWithBasePath(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPServer: types.HTTPServerConfig{
+			BasePath: "/monic",
+			Ingest:   types.IngestConfig{Secret: "push-secret"},
+		},
+	}
+	service := createTestMonitorService(t, config)
+	handler := service.statsServer.handler()
+
+	// Clients sign the full path, including the base path the server strips before routing
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, signedRequest("/monic/api/v1/push/backup", `{}`, "push-secret", time.Now(), "n1"))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a request signed with the base path, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := signedRequest("/api/v1/push/backup", `{}`, "push-secret", time.Now(), "n2")
+	req.URL.Path, req.RequestURI = "/monic/api/v1/push/backup", "/monic/api/v1/push/backup"
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a request signed without the base path, got %d", w.Code)
+	}
+}
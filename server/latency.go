@@ -0,0 +1,350 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// Latency comparison defaults and limits
+const (
+	defaultLatencyWindow = time.Hour
+	defaultLatencyPoints = 60 // Buckets per window when no bucket size is given
+	maxLatencyPoints     = 1000
+)
+
+// latencyWindows are the windows offered by the comparison view
+var latencyWindows = []string{"15m0s", "1h0m0s", "6h0m0s", "24h0m0s"}
+
+// latencyPoint is one time bucket of a check's latency series
+type latencyPoint struct {
+	Time     time.Time `json:"time"`
+	AvgMs    float64   `json:"avg_ms"`
+	MaxMs    float64   `json:"max_ms"`
+	Count    int       `json:"count"`
+	Failures int       `json:"failures"`
+}
+
+// latencySeries is the bucketed latency trend of a single check
+type latencySeries struct {
+	Name     string         `json:"name"`
+	Type     string         `json:"type"`
+	AvgMs    float64        `json:"avg_ms"` // Average over the whole window
+	MaxMs    float64        `json:"max_ms"`
+	Failures int            `json:"failures"`
+	Phases   *timingPhases  `json:"phases_ms,omitempty"` // Average request phases of HTTP checks over the window
+	Points   []latencyPoint `json:"points"`
+}
+
+// timingPhases is the time an HTTP check request spent in each phase, in milliseconds
+type timingPhases struct {
+	DNSMs       float64 `json:"dns_ms"`
+	ConnectMs   float64 `json:"connect_ms"`
+	TLSMs       float64 `json:"tls_ms"`
+	FirstByteMs float64 `json:"first_byte_ms"` // Server processing, from the request to the first response byte
+	TransferMs  float64 `json:"transfer_ms"`
+}
+
+// newTimingPhases converts the timing of a check result to milliseconds
+func newTimingPhases(timing *types.HTTPTiming) timingPhases {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return timingPhases{
+		DNSMs:       ms(timing.DNS),
+		ConnectMs:   ms(timing.Connect),
+		TLSMs:       ms(timing.TLS),
+		FirstByteMs: ms(timing.FirstByte),
+		TransferMs:  ms(timing.Transfer),
+	}
+}
+
+// add adds the phases of another request, scaled by a factor
+func (p *timingPhases) add(other timingPhases, factor float64) {
+	p.DNSMs += other.DNSMs * factor
+	p.ConnectMs += other.ConnectMs * factor
+	p.TLSMs += other.TLSMs * factor
+	p.FirstByteMs += other.FirstByteMs * factor
+	p.TransferMs += other.TransferMs * factor
+}
+
+// latencyQuery holds the parsed parameters of a latency comparison request
+type latencyQuery struct {
+	Checks []string // Selected check keys ("type/name" or plain name); empty selects all
+	Window time.Duration
+	Bucket time.Duration
+}
+
+// checkKey returns the identifier of a check across check types
+func checkKey(result types.HTTPCheckResult) string {
+	checkType := result.Type
+	if checkType == "" {
+		checkType = "http"
+	}
+	return checkType + "/" + result.Name
+}
+
+// parseLatencyQuery parses "checks" (comma-separated or repeated), "window" and "bucket" parameters
+func parseLatencyQuery(r *http.Request) (latencyQuery, error) {
+	values := r.URL.Query()
+	query := latencyQuery{Checks: parseChecksParam(values), Window: defaultLatencyWindow}
+
+	if window := values.Get("window"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return query, fmt.Errorf("invalid window parameter, expected positive duration: %s", window)
+		}
+		query.Window = d
+	}
+
+	query.Bucket = query.Window / defaultLatencyPoints
+	if bucket := values.Get("bucket"); bucket != "" {
+		d, err := time.ParseDuration(bucket)
+		if err != nil || d <= 0 {
+			return query, fmt.Errorf("invalid bucket parameter, expected positive duration: %s", bucket)
+		}
+		query.Bucket = d
+	}
+
+	if query.Window/query.Bucket > maxLatencyPoints {
+		return query, fmt.Errorf("too many buckets, use a bucket of at least %s", query.Window/maxLatencyPoints)
+	}
+
+	return query, nil
+}
+
+// parseChecksParam returns the checks selected by "checks" parameters (comma-separated or repeated)
+func parseChecksParam(values url.Values) []string {
+	var checks []string
+	for _, value := range values["checks"] {
+		for _, check := range strings.Split(value, ",") {
+			if check = strings.TrimSpace(check); check != "" {
+				checks = append(checks, check)
+			}
+		}
+	}
+	return checks
+}
+
+// selectsCheck reports whether a check is part of the selection (all checks when none are selected)
+func (q latencyQuery) selectsCheck(result types.HTTPCheckResult) bool {
+	return len(q.Checks) == 0 || matchesCheck(result, q.Checks)
+}
+
+// matchesCheck reports whether a check is listed by "type/name" or plain name
+func matchesCheck(result types.HTTPCheckResult, checks []string) bool {
+	key := checkKey(result)
+	for _, check := range checks {
+		if check == key || check == result.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// buildLatencySeries groups check results into per-check latency series with fixed-size time buckets
+func buildLatencySeries(history []types.HTTPCheckResult, query latencyQuery, now time.Time) []latencySeries {
+	since := now.Add(-query.Window)
+	numBuckets := int((query.Window + query.Bucket - 1) / query.Bucket)
+
+	type accumulator struct {
+		series  latencySeries
+		sums    []float64
+		totalMs float64
+		count   int
+		phases  timingPhases
+		timed   int // Results with a timing
+	}
+	byCheck := make(map[string]*accumulator)
+
+	for _, result := range history {
+		if result.Timestamp.Before(since) || result.Timestamp.After(now) || !query.selectsCheck(result) {
+			continue
+		}
+
+		key := checkKey(result)
+		acc, exists := byCheck[key]
+		if !exists {
+			acc = &accumulator{
+				series: latencySeries{Name: result.Name, Type: strings.SplitN(key, "/", 2)[0]},
+				sums:   make([]float64, numBuckets),
+			}
+			acc.series.Points = make([]latencyPoint, numBuckets)
+			for i := range acc.series.Points {
+				acc.series.Points[i].Time = since.Add(time.Duration(i) * query.Bucket)
+			}
+			byCheck[key] = acc
+		}
+
+		i := int(result.Timestamp.Sub(since) / query.Bucket)
+		if i >= numBuckets {
+			i = numBuckets - 1
+		}
+		ms := float64(result.ResponseTime) / float64(time.Millisecond)
+
+		point := &acc.series.Points[i]
+		point.Count++
+		acc.sums[i] += ms
+		if ms > point.MaxMs {
+			point.MaxMs = ms
+		}
+		if ms > acc.series.MaxMs {
+			acc.series.MaxMs = ms
+		}
+		if !result.Success {
+			point.Failures++
+			acc.series.Failures++
+		}
+		acc.totalMs += ms
+		acc.count++
+		if result.Timing != nil {
+			acc.phases.add(newTimingPhases(result.Timing), 1)
+			acc.timed++
+		}
+	}
+
+	series := make([]latencySeries, 0, len(byCheck))
+	for _, acc := range byCheck {
+		for i := range acc.series.Points {
+			if acc.series.Points[i].Count > 0 {
+				acc.series.Points[i].AvgMs = acc.sums[i] / float64(acc.series.Points[i].Count)
+			}
+		}
+		acc.series.AvgMs = acc.totalMs / float64(acc.count)
+		if acc.timed > 0 {
+			acc.series.Phases = &timingPhases{}
+			acc.series.Phases.add(acc.phases, 1/float64(acc.timed))
+		}
+		series = append(series, acc.series)
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Name != series[j].Name {
+			return series[i].Name < series[j].Name
+		}
+		return series[i].Type < series[j].Type
+	})
+	return series
+}
+
+// availableChecks returns the keys of all checks present in the history, sorted
+func availableChecks(history []types.HTTPCheckResult) []string {
+	seen := make(map[string]bool)
+	var checks []string
+	for _, result := range history {
+		key := checkKey(result)
+		if !seen[key] {
+			seen[key] = true
+			checks = append(checks, key)
+		}
+	}
+	sort.Strings(checks)
+	return checks
+}
+
+// handleLatencyAPI handles GET /api/v1/latency
+func (s *StatsServer) handleLatencyAPI(w http.ResponseWriter, r *http.Request) {
+	query, err := parseLatencyQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"window": query.Window.String(),
+		"bucket": query.Bucket.String(),
+		"series": buildLatencySeries(s.storage.GetHTTPCheckResults(), query, time.Now()),
+	})
+}
+
+// Chart layout of the latency comparison view
+const (
+	chartWidth   = 900
+	chartHeight  = 320
+	chartPadding = 40
+)
+
+// chartColors is the palette used for chart lines (matches the dashboard theme)
+var chartColors = []string{"#7aa2f7", "#9ece6a", "#e0af68", "#f7768e", "#bb9af7", "#7dcfff", "#ff9e64", "#c0caf5"}
+
+// chartLine is a single series rendered as an SVG polyline
+type chartLine struct {
+	Series latencySeries
+	Color  string
+	Points string // SVG polyline points attribute
+}
+
+// buildChartLines converts latency series to SVG polylines and returns the y-axis maximum in ms
+func buildChartLines(series []latencySeries) ([]chartLine, float64) {
+	maxMs := 0.0
+	for _, s := range series {
+		for _, p := range s.Points {
+			if p.Count > 0 && p.AvgMs > maxMs {
+				maxMs = p.AvgMs
+			}
+		}
+	}
+	if maxMs == 0 {
+		maxMs = 1
+	}
+
+	plotWidth := float64(chartWidth - 2*chartPadding)
+	plotHeight := float64(chartHeight - 2*chartPadding)
+
+	lines := make([]chartLine, 0, len(series))
+	for i, s := range series {
+		var points []string
+		for j, p := range s.Points {
+			if p.Count == 0 {
+				continue // Gaps are bridged by the polyline
+			}
+			x := float64(chartPadding)
+			if len(s.Points) > 1 {
+				x += plotWidth * float64(j) / float64(len(s.Points)-1)
+			}
+			y := float64(chartPadding) + plotHeight*(1-p.AvgMs/maxMs)
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		lines = append(lines, chartLine{
+			Series: s,
+			Color:  chartColors[i%len(chartColors)],
+			Points: strings.Join(points, " "),
+		})
+	}
+	return lines, maxMs
+}
+
+// handleLatencyView handles GET /latency (HTML comparison chart)
+func (s *StatsServer) handleLatencyView(w http.ResponseWriter, r *http.Request) {
+	query, err := parseLatencyQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history := s.storage.GetHTTPCheckResults()
+	series := buildLatencySeries(history, query, time.Now())
+	lines, maxMs := buildChartLines(series)
+
+	selected := make(map[string]bool)
+	for _, check := range query.Checks {
+		selected[check] = true
+	}
+
+	s.renderHTML(w, "templates/latency.html", map[string]interface{}{
+		"checks":   availableChecks(history),
+		"selected": selected,
+		"window":   query.Window.String(),
+		"windows":  latencyWindows,
+		"lines":    lines,
+		"max_ms":   maxMs,
+		"width":    chartWidth,
+		"height":   chartHeight,
+		"padding":  chartPadding,
+		"right":    chartWidth - chartPadding,
+		"bottom":   chartHeight - chartPadding,
+	})
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestBuildLatencySeries(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := []types.HTTPCheckResult{
+		{Name: "api-eu", ResponseTime: 100 * time.Millisecond, Success: true, Timestamp: now.Add(-55 * time.Minute),
+			Timing: &types.HTTPTiming{DNS: 10 * time.Millisecond, Connect: 20 * time.Millisecond, FirstByte: 70 * time.Millisecond}},
+		{Name: "api-eu", ResponseTime: 300 * time.Millisecond, Success: false, Timestamp: now.Add(-51 * time.Minute),
+			Timing: &types.HTTPTiming{FirstByte: 290 * time.Millisecond, Transfer: 4 * time.Millisecond, ReusedConn: true}},
+		{Name: "api-us", ResponseTime: 50 * time.Millisecond, Success: true, Timestamp: now.Add(-5 * time.Minute)},
+		{Name: "api-us", Type: "grpc", ResponseTime: 10 * time.Millisecond, Success: true, Timestamp: now.Add(-5 * time.Minute)},
+		{Name: "api-eu", ResponseTime: time.Second, Success: true, Timestamp: now.Add(-2 * time.Hour)}, // Outside window
+	}
+
+	query := latencyQuery{Window: time.Hour, Bucket: 10 * time.Minute}
+	series := buildLatencySeries(history, query, now)
+	if len(series) != 3 {
+		t.Fatalf("Expected 3 series, got %d", len(series))
+	}
+
+	eu := series[0]
+	if eu.Name != "api-eu" || eu.Type != "http" {
+		t.Fatalf("Expected api-eu http series first, got %s/%s", eu.Type, eu.Name)
+	}
+	if len(eu.Points) != 6 {
+		t.Fatalf("Expected 6 buckets, got %d", len(eu.Points))
+	}
+	if eu.Points[0].Count != 2 || eu.Points[0].AvgMs != 200 || eu.Points[0].MaxMs != 300 || eu.Points[0].Failures != 1 {
+		t.Errorf("Unexpected first bucket: %+v", eu.Points[0])
+	}
+	if eu.AvgMs != 200 || eu.MaxMs != 300 || eu.Failures != 1 {
+		t.Errorf("Unexpected series summary: %+v", eu)
+	}
+	want := timingPhases{DNSMs: 5, ConnectMs: 10, FirstByteMs: 180, TransferMs: 2}
+	if eu.Phases == nil || *eu.Phases != want {
+		t.Errorf("Expected average phases %+v, got %+v", want, eu.Phases)
+	}
+	if series[1].Phases != nil {
+		t.Errorf("Expected no phases of a check without timing, got %+v", series[1].Phases)
+	}
+	if series[1].Type != "grpc" || series[2].Type != "http" {
+		t.Errorf("Expected api-us series ordered by type, got %s and %s", series[1].Type, series[2].Type)
+	}
+
+	// Select by plain name and by type/name
+	query.Checks = []string{"api-eu", "grpc/api-us"}
+	series = buildLatencySeries(history, query, now)
+	if len(series) != 2 || series[0].Name != "api-eu" || series[1].Type != "grpc" {
+		t.Errorf("Unexpected selection result: %+v", series)
+	}
+}
+
+func TestParseLatencyQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/latency?checks=a,b&checks=c&window=30m", nil)
+	query, err := parseLatencyQuery(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(query.Checks, ",") != "a,b,c" {
+		t.Errorf("Expected checks a,b,c, got %v", query.Checks)
+	}
+	if query.Window != 30*time.Minute || query.Bucket != 30*time.Second {
+		t.Errorf("Expected 30m window with 30s buckets, got %s / %s", query.Window, query.Bucket)
+	}
+
+	for _, params := range []string{"window=abc", "window=-1h", "bucket=0s", "window=24h&bucket=1s"} {
+		req := httptest.NewRequest("GET", "/api/v1/latency?"+params, nil)
+		if _, err := parseLatencyQuery(req); err == nil {
+			t.Errorf("Expected error for %s", params)
+		}
+	}
+}
+
+func TestStatsServer_Latency(t *testing.T) {
+	storage := NewStorageManager(100)
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api-eu", ResponseTime: 120 * time.Millisecond, Success: true, Timestamp: time.Now()})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api-us", ResponseTime: 80 * time.Millisecond, Success: true, Timestamp: time.Now()})
+
+	server := NewStatsServer(&types.HTTPServerConfig{}, nil, storage, nil)
+	mux := server.routes()
+
+	req := httptest.NewRequest("GET", "/api/v1/latency?checks=api-eu", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Series []latencySeries `json:"series"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Series) != 1 || response.Series[0].Name != "api-eu" || response.Series[0].AvgMs != 120 {
+		t.Errorf("Unexpected series: %+v", response.Series)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/latency?window=bad", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid window, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/latency?checks=api-eu&checks=api-us", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<polyline", `value="http/api-eu"`, "api-us", "120.0 ms"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected latency page to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// Kinds of lifecycle events
+const (
+	EventServiceStarted   = "service_started"
+	EventServiceStopped   = "service_stopped"
+	EventCheckAdded       = "check_added"
+	EventCheckUpdated     = "check_updated"
+	EventCheckRemoved     = "check_removed"
+	EventCheckPaused      = "check_paused"
+	EventCheckResumed     = "check_resumed"
+	EventSubsystemPaused  = "subsystem_paused"
+	EventSubsystemResumed = "subsystem_resumed"
+	EventContainerStarted = "container_started"
+	EventContainerStopped = "container_stopped"
+)
+
+// systemActor is the actor of lifecycle events not caused by an API request
+const systemActor = "system"
+
+const (
+	// defaultEventsRange is the time range of the events API when neither "from" nor "window" is given
+	defaultEventsRange = 24 * time.Hour
+	// dashboardEventCount is the number of recent events shown on the dashboard timeline
+	dashboardEventCount = 20
+)
+
+// newLifecycleEvent creates a lifecycle event that happened now
+func newLifecycleEvent(kind, subject, message, actor string) types.LifecycleEvent {
+	return types.LifecycleEvent{
+		Timestamp: time.Now(),
+		Kind:      kind,
+		Subject:   subject,
+		Message:   message,
+		Actor:     actor,
+	}
+}
+
+// recordEvent stores a lifecycle event caused by the service itself and streams it to live clients
+func (ms *MonitorService) recordEvent(kind, subject, message string) {
+	event := newLifecycleEvent(kind, subject, message, systemActor)
+	ms.storage.AddEvent(event)
+	ms.publishEvent(EventLifecycle, event)
+}
+
+// recordEvent stores a lifecycle event caused by an API request and streams it to live clients
+func (s *StatsServer) recordEvent(kind, subject, message string, r *http.Request) {
+	event := newLifecycleEvent(kind, subject, message, requestActor(r))
+	s.storage.AddEvent(event)
+	s.events.Publish(EventLifecycle, event)
+}
+
+// containerTracker detects containers that started or stopped between two Docker collections
+type containerTracker struct {
+	running map[string]string // Start time of the running containers by name; nil before the first collection
+}
+
+// update compares a collection with the previous one and returns an event per started or
+// stopped container. The first collection only records the baseline.
+func (t *containerTracker) update(stats []types.DockerContainerStats) []types.LifecycleEvent {
+	running := make(map[string]string)
+	current := make(map[string]types.DockerContainerStats, len(stats))
+	for _, container := range stats {
+		current[container.Name] = container
+		if container.Running {
+			running[container.Name] = container.StartedAt
+		}
+	}
+	previous := t.running
+	t.running = running
+	if previous == nil {
+		return nil
+	}
+
+	var events []types.LifecycleEvent
+	for _, name := range sortedKeys(running) {
+		// A changed start time means the container restarted between two collections
+		if startedAt, exists := previous[name]; !exists || startedAt != running[name] {
+			events = append(events, newLifecycleEvent(EventContainerStarted, name, "Container started", systemActor))
+		}
+	}
+	for _, name := range sortedKeys(previous) {
+		if _, exists := running[name]; exists {
+			continue
+		}
+		message := "Container removed"
+		if container, exists := current[name]; exists {
+			message = fmt.Sprintf("Container stopped with exit code %d", container.ExitCode)
+		}
+		events = append(events, newLifecycleEvent(EventContainerStopped, name, message, systemActor))
+	}
+	return events
+}
+
+// recordContainerEvents records the containers that started or stopped since the last collection
+func (ms *MonitorService) recordContainerEvents(stats []types.DockerContainerStats) {
+	for _, event := range ms.containers.update(stats) {
+		ms.storage.AddEvent(event)
+		ms.publishEvent(EventLifecycle, event)
+	}
+}
+
+// handleLifecycleEvents handles GET /api/v1/events: lifecycle events between "from" (or
+// "window" before "to") and "to", newest first, optionally limited to some kinds with "kind"
+func (s *StatsServer) handleLifecycleEvents(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r, time.Now())
+	if err == nil {
+		err = q.applyWindow(r.URL.Query())
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("from") == "" && r.URL.Query().Get("window") == "" {
+		q.From = q.To.Add(-defaultEventsRange)
+	}
+
+	var kinds []string
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		kinds = strings.Split(kind, ",")
+	}
+
+	events := make([]types.LifecycleEvent, 0)
+	for _, event := range s.storage.GetEventsRange(q.From, q.To) {
+		if len(kinds) == 0 || slices.Contains(kinds, event.Kind) {
+			events = append(events, event)
+		}
+	}
+	slices.Reverse(events)
+
+	writeJSON(w, map[string]interface{}{
+		"from":   q.From.Format(time.RFC3339),
+		"to":     q.To.Format(time.RFC3339),
+		"count":  len(events),
+		"events": events,
+	})
+}
+
+// recentEvents returns the newest lifecycle events of the last day for the dashboard timeline
+func recentEvents(storage Storage, now time.Time) []types.LifecycleEvent {
+	events := storage.GetEventsRange(now.Add(-defaultEventsRange), now)
+	events = events[max(0, len(events)-dashboardEventCount):]
+	slices.Reverse(events)
+	return events
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+func TestContainerTracker(t *testing.T) {
+	var tracker containerTracker
+	running := func(name, startedAt string) types.DockerContainerStats {
+		return types.DockerContainerStats{Name: name, Running: true, StartedAt: startedAt}
+	}
+
+	// The first collection is the baseline
+	if events := tracker.update([]types.DockerContainerStats{running("web", "t1"), running("db", "t1")}); len(events) != 0 {
+		t.Fatalf("Expected no events for the first collection, got %+v", events)
+	}
+
+	events := tracker.update([]types.DockerContainerStats{
+		running("web", "t2"), // Restarted between collections
+		{Name: "db", ExitCode: 137},
+		running("cache", "t2"),
+	})
+	var got []string
+	for _, event := range events {
+		got = append(got, event.Kind+" "+event.Subject+": "+event.Message)
+	}
+	want := []string{
+		"container_started cache: Container started",
+		"container_started web: Container started",
+		"container_stopped db: Container stopped with exit code 137",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected events\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	if events := tracker.update([]types.DockerContainerStats{running("web", "t2")}); len(events) != 1 || events[0].Subject != "cache" || events[0].Message != "Container removed" {
+		t.Errorf("Expected the removed cache container, got %+v", events)
+	}
+}
+
+func TestStatsServer_LifecycleEventsAPI(t *testing.T) {
+	config := &types.Config{
+		HTTPServer: types.HTTPServerConfig{Username: "admin", Password: "secret"},
+	}
+	service := createTestMonitorService(t, config)
+	service.statsServer.SetManagedChecks(monitor.NewManagedCheckMonitor(filepath.Join(t.TempDir(), "checks.json"), service.httpMonitor))
+	service.storage.AddEvent(types.LifecycleEvent{Timestamp: time.Now().Add(-48 * time.Hour), Kind: EventServiceStarted})
+	service.recordEvent(EventServiceStarted, "monic", "Monitoring service started")
+	mux := service.statsServer.routes()
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"name": "db", "type": "tcp", "target": "db:5432"}`))
+		req.SetBasicAuth("admin", "secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	if w := request("POST", "/api/v1/checks"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Count  int                    `json:"count"`
+		Events []types.LifecycleEvent `json:"events"`
+	}
+	w := request("GET", "/api/v1/events")
+	if err := json.Unmarshal(w.Body.Bytes(), &response); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("Expected events, got %d: %s", w.Code, w.Body.String())
+	}
+	if response.Count != 2 || response.Events[0].Kind != EventCheckAdded || response.Events[0].Actor != "admin" || response.Events[1].Actor != systemActor {
+		t.Errorf("Expected the last day's events newest first, got %+v", response.Events)
+	}
+
+	w = request("GET", "/api/v1/events?window=72h&kind=service_started")
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Count != 2 || response.Events[0].Subject != "monic" {
+		t.Errorf("Expected both service starts, got %s", w.Body.String())
+	}
+	if alerts := service.storage.GetAlertsCount(); alerts != 0 {
+		t.Errorf("Expected events not to be stored as alerts, got %d alerts", alerts)
+	}
+
+	if w := request("GET", "/api/v1/events?window=1d"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid window, got %d", w.Code)
+	}
+}
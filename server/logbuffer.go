@@ -0,0 +1,66 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// NewLogHandler creates the handler of the application logs writing to w: one JSON object per
+// entry by default, or key=value text for format "text"
+func NewLogHandler(format string, w io.Writer, level slog.Leveler) slog.Handler {
+	options := &slog.HandlerOptions{Level: level}
+	if strings.EqualFold(format, "text") {
+		return slog.NewTextHandler(w, options)
+	}
+	return slog.NewJSONHandler(w, options)
+}
+
+// LogBuffer keeps the most recent log lines in memory so they can be included in support bundles.
+// It implements io.Writer and is meant to be combined with os.Stdout via io.MultiWriter.
+type LogBuffer struct {
+	mu       sync.Mutex
+	lines    []string
+	maxLines int
+}
+
+// NewLogBuffer creates a log buffer holding up to maxLines lines
+func NewLogBuffer(maxLines int) *LogBuffer {
+	if maxLines <= 0 {
+		maxLines = 500 // Default to 500 lines
+	}
+
+	return &LogBuffer{
+		lines:    make([]string, 0),
+		maxLines: maxLines,
+	}
+}
+
+// Write appends log output, one entry per line
+func (lb *LogBuffer) Write(p []byte) (int, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		lb.lines = append(lb.lines, line)
+	}
+	if len(lb.lines) > lb.maxLines {
+		lb.lines = lb.lines[len(lb.lines)-lb.maxLines:]
+	}
+
+	return len(p), nil
+}
+
+// Lines returns a copy of the buffered log lines, oldest first
+func (lb *LogBuffer) Lines() []string {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	result := make([]string, len(lb.lines))
+	copy(result, lb.lines)
+	return result
+}
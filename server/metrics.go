@@ -0,0 +1,339 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// metricsWriter writes metrics in the Prometheus text exposition format
+type metricsWriter struct {
+	w       io.Writer
+	written map[string]bool
+	labels  []string     // Added to every sample (unless the sample sets the label itself)
+	rules   []metricRule // Applied to every sample before it is written
+}
+
+// newMetricsWriter creates a new Prometheus text format writer
+func newMetricsWriter(w io.Writer) *metricsWriter {
+	return &metricsWriter{w: w, written: make(map[string]bool)}
+}
+
+// sample adds the constant labels to a sample and applies the relabel rules
+func (mw *metricsWriter) sample(name string, labels []string) ([]string, bool) {
+	if len(mw.labels) > 0 {
+		labels = append([]string(nil), labels...)
+		for i := 0; i+1 < len(mw.labels); i += 2 {
+			if labelValue(name, labels, mw.labels[i]) == "" {
+				labels = append(labels, mw.labels[i], mw.labels[i+1])
+			}
+		}
+	}
+	return relabel(name, labels, mw.rules)
+}
+
+// metric writes a single sample, emitting HELP/TYPE headers the first time a metric name is seen.
+// Samples dropped by relabel rules are skipped (with their headers if no sample is left).
+func (mw *metricsWriter) metric(name, metricType, help string, value float64, labels ...string) {
+	labels, keep := mw.sample(name, labels)
+	if !keep {
+		return
+	}
+	if !mw.written[name] {
+		fmt.Fprintf(mw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+		mw.written[name] = true
+	}
+	fmt.Fprintf(mw.w, "%s%s %g\n", name, formatLabels(labels), value)
+}
+
+// summary writes the _sum and _count samples of a summary metric without quantiles
+func (mw *metricsWriter) summary(name, help string, sum, count float64, labels ...string) {
+	labels, keep := mw.sample(name, labels)
+	if !keep {
+		return
+	}
+	if !mw.written[name] {
+		fmt.Fprintf(mw.w, "# HELP %s %s\n# TYPE %s summary\n", name, help, name)
+		mw.written[name] = true
+	}
+	fmt.Fprintf(mw.w, "%s_sum%s %g\n", name, formatLabels(labels), sum)
+	fmt.Fprintf(mw.w, "%s_count%s %g\n", name, formatLabels(labels), count)
+}
+
+// formatLabels formats key/value label pairs as {k="v",...}
+func formatLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+
+	var parts []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// boolToFloat converts a boolean into a 0/1 metric value
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// handleMetrics handles the /metrics endpoint (Prometheus text format)
+func (s *StatsServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var b strings.Builder
+	mw := newMetricsWriter(&b)
+	mw.labels = constLabels(s.config.Metrics.Labels)
+	mw.rules = s.metricRules
+	s.writeMetrics(mw)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		slog.Error("Error writing metrics response", "error", err)
+	}
+}
+
+// writeMetrics writes all exported metrics
+func (s *StatsServer) writeMetrics(mw *metricsWriter) {
+	mw.metric("monic_up", "gauge", "Whether the Monic service is running.", 1)
+	mw.metric("monic_uptime_seconds", "gauge", "Time since the Monic service started.", time.Since(s.startTime).Seconds())
+
+	// Resource usage of Monic itself
+	self := s.self.collect()
+	mw.metric("monic_self_goroutines", "gauge", "Number of goroutines of the Monic process.", float64(self.Goroutines))
+	mw.metric("monic_self_cpu_seconds_total", "counter", "User and system CPU time of the Monic process.", self.CPUSeconds)
+	mw.metric("monic_self_resident_memory_bytes", "gauge", "Resident memory of the Monic process.", float64(self.ResidentMemoryBytes))
+	mw.metric("monic_self_heap_alloc_bytes", "gauge", "Allocated heap objects of the Monic process.", float64(self.HeapAllocBytes))
+	mw.metric("monic_self_sys_bytes", "gauge", "Memory obtained from the OS by the Go runtime.", float64(self.SysBytes))
+	mw.metric("monic_self_gc_runs_total", "counter", "Completed garbage collection cycles.", float64(self.GCRuns))
+	mw.metric("monic_self_gc_pause_seconds_total", "counter", "Total stop-the-world garbage collection pause time.", self.GCPauseSeconds)
+	mw.metric("monic_self_open_fds", "gauge", "Open file descriptors of the Monic process.", float64(self.OpenFDs))
+
+	// System stats
+	if stats := s.storage.GetLatestSystemStats(); stats != nil {
+		mw.metric("monic_cpu_usage_percent", "gauge", "Host CPU usage.", stats.CPUUsage)
+		for core, usage := range stats.PerCoreUsage {
+			mw.metric("monic_cpu_core_usage_percent", "gauge", "CPU usage per logical core.", usage, "core", strconv.Itoa(core))
+		}
+		if stats.LoadAverage.Cores > 0 {
+			mw.metric("monic_load_average", "gauge", "System load average.", stats.LoadAverage.Load1, "period", "1m")
+			mw.metric("monic_load_average", "gauge", "System load average.", stats.LoadAverage.Load5, "period", "5m")
+			mw.metric("monic_load_average", "gauge", "System load average.", stats.LoadAverage.Load15, "period", "15m")
+			mw.metric("monic_load_average_per_core", "gauge", "5-minute load average divided by the number of cores.", stats.LoadAverage.PerCore())
+		}
+		mw.metric("monic_memory_usage_percent", "gauge", "Host memory usage.", stats.MemoryUsage.UsedPercent)
+		mw.metric("monic_swap_usage_percent", "gauge", "Host swap usage (0 without swap).", stats.SwapUsage.UsedPercent)
+		mw.metric("monic_swap_total_bytes", "gauge", "Total swap space.", float64(stats.SwapUsage.Total))
+
+		paths := make([]string, 0, len(stats.DiskUsage))
+		for path := range stats.DiskUsage {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			mw.metric("monic_disk_usage_percent", "gauge", "Disk usage per path.", stats.DiskUsage[path].UsedPercent, "path", path)
+		}
+		for _, path := range paths {
+			if disk := stats.DiskUsage[path]; disk.InodesTotal > 0 {
+				mw.metric("monic_disk_inode_usage_percent", "gauge", "Inode usage per path.", disk.InodesUsedPercent, "path", path)
+			}
+		}
+
+		devices := make([]string, 0, len(stats.DiskIO))
+		for device := range stats.DiskIO {
+			devices = append(devices, device)
+		}
+		sort.Strings(devices)
+		for _, device := range devices {
+			mw.metric("monic_disk_io_ops_per_second", "gauge", "Disk I/O operations per second.", stats.DiskIO[device].ReadIOPS, "device", device, "op", "read")
+			mw.metric("monic_disk_io_ops_per_second", "gauge", "Disk I/O operations per second.", stats.DiskIO[device].WriteIOPS, "device", device, "op", "write")
+		}
+		for _, device := range devices {
+			mw.metric("monic_disk_io_bytes_per_second", "gauge", "Disk I/O throughput.", stats.DiskIO[device].ReadBytesPerSec, "device", device, "op", "read")
+			mw.metric("monic_disk_io_bytes_per_second", "gauge", "Disk I/O throughput.", stats.DiskIO[device].WriteBytesPerSec, "device", device, "op", "write")
+		}
+		for _, device := range devices {
+			mw.metric("monic_disk_io_await_milliseconds", "gauge", "Average time per disk I/O request.", stats.DiskIO[device].ReadAwaitMs, "device", device, "op", "read")
+			mw.metric("monic_disk_io_await_milliseconds", "gauge", "Average time per disk I/O request.", stats.DiskIO[device].WriteAwaitMs, "device", device, "op", "write")
+		}
+		for _, device := range devices {
+			mw.metric("monic_disk_io_utilization_percent", "gauge", "Share of time the disk was busy.", stats.DiskIO[device].UtilPercent, "device", device)
+		}
+	}
+
+	// Container resource usage of running containers
+	var containers []types.DockerContainerStats
+	for _, container := range s.storage.GetLatestDockerContainerStats() {
+		if container.Running {
+			containers = append(containers, container)
+		}
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+	for _, container := range containers {
+		mw.metric("monic_container_cpu_usage_percent", "gauge", "Container CPU usage (100 = one core).", container.CPUPercent, "name", container.Name)
+	}
+	for _, container := range containers {
+		mw.metric("monic_container_memory_usage_bytes", "gauge", "Container memory usage excluding inactive page cache.", float64(container.MemoryUsage), "name", container.Name)
+	}
+	for _, container := range containers {
+		mw.metric("monic_container_memory_limit_bytes", "gauge", "Container memory limit.", float64(container.MemoryLimit), "name", container.Name)
+	}
+	for _, container := range containers {
+		mw.metric("monic_container_network_receive_bytes_total", "counter", "Bytes received by the container.", float64(container.NetworkRxBytes), "name", container.Name)
+	}
+	for _, container := range containers {
+		mw.metric("monic_container_network_transmit_bytes_total", "counter", "Bytes sent by the container.", float64(container.NetworkTxBytes), "name", container.Name)
+	}
+	for _, container := range containers {
+		mw.metric("monic_container_restarts_total", "counter", "Times Docker restarted the container.", float64(container.RestartCount), "name", container.Name)
+	}
+	for _, container := range containers {
+		if container.Health != "" {
+			mw.metric("monic_container_healthy", "gauge", "Whether the container health check reports healthy (1) or not (0).", boolToFloat(container.Health == "healthy"), "name", container.Name)
+		}
+	}
+
+	// Running and expected replicas of compose services
+	if s.service != nil {
+		services := s.service.dockerMonitor.ComposeServices(s.storage.GetLatestDockerContainerStats())
+		for _, service := range services {
+			mw.metric("monic_compose_running_replicas", "gauge", "Running containers of a compose service.", float64(service.Running), "project", service.Project, "service", service.Service)
+		}
+		for _, service := range services {
+			if service.Expected > 0 {
+				mw.metric("monic_compose_expected_replicas", "gauge", "Expected running containers of a compose service.", float64(service.Expected), "project", service.Project, "service", service.Service)
+			}
+		}
+	}
+
+	// Docker's disk usage per object type
+	if s.service != nil {
+		if usage := s.service.dockerMonitor.LatestDiskUsage(); usage != nil {
+			mw.metric("monic_docker_disk_usage_bytes", "gauge", "Disk space used by Docker objects.", float64(usage.Images), "type", "images")
+			mw.metric("monic_docker_disk_usage_bytes", "gauge", "Disk space used by Docker objects.", float64(usage.Containers), "type", "containers")
+			mw.metric("monic_docker_disk_usage_bytes", "gauge", "Disk space used by Docker objects.", float64(usage.Volumes), "type", "volumes")
+			mw.metric("monic_docker_disk_usage_bytes", "gauge", "Disk space used by Docker objects.", float64(usage.BuildCache), "type", "build_cache")
+			mw.metric("monic_docker_disk_reclaimable_bytes", "gauge", "Disk space used by unused Docker objects.", float64(usage.Reclaimable))
+		}
+	}
+
+	// Latest check results (samples of a metric family must be grouped together)
+	results := latestCheckResults(s.storage)
+	for _, result := range results {
+		mw.metric("monic_check_up", "gauge", "Whether the latest check succeeded.", boolToFloat(result.Success), s.checkLabels(result)...)
+	}
+	for _, result := range results {
+		mw.metric("monic_check_response_time_seconds", "gauge", "Response time of the latest check.", result.ResponseTime.Seconds(), s.checkLabels(result)...)
+	}
+	for _, result := range results {
+		// Only numeric polled values (e.g. SNMP gauges) are exported
+		value, err := strconv.ParseFloat(strings.TrimSpace(result.Value), 64)
+		if err != nil {
+			continue
+		}
+		mw.metric("monic_check_value", "gauge", "Latest polled value of the check.", value, s.checkLabels(result)...)
+	}
+
+	mw.metric("monic_active_alerts", "gauge", "Number of alerts waiting to be processed.", float64(s.storage.GetAlertsCount()))
+
+	if s.service == nil {
+		return
+	}
+
+	// Check/collection cycle durations and overruns
+	cycles := s.service.cycles.snapshot()
+	for _, stats := range cycles {
+		mw.metric("monic_cycle_duration_seconds", "gauge", "Duration of the latest check cycle.", stats.LastDuration.Seconds(), "subsystem", stats.Subsystem)
+	}
+	for _, stats := range cycles {
+		mw.metric("monic_cycle_max_duration_seconds", "gauge", "Longest check cycle since startup.", stats.MaxDuration.Seconds(), "subsystem", stats.Subsystem)
+	}
+	for _, stats := range cycles {
+		mw.metric("monic_cycle_interval_seconds", "gauge", "Configured check cycle interval.", stats.Interval.Seconds(), "subsystem", stats.Subsystem)
+	}
+	for _, stats := range cycles {
+		mw.metric("monic_cycle_overruns_total", "counter", "Check cycles that took at least as long as their interval.", float64(stats.Overruns), "subsystem", stats.Subsystem)
+	}
+	for _, stats := range cycles {
+		if !stats.NextRun.IsZero() {
+			mw.metric("monic_cycle_next_run_timestamp_seconds", "gauge", "Unix time of the next scheduled check cycle.", float64(stats.NextRun.Unix()), "subsystem", stats.Subsystem)
+		}
+	}
+
+	for _, status := range s.service.loopStatuses(time.Now()) {
+		mw.metric("monic_cycle_stalled", "gauge", "Whether the monitoring loop made no progress for several intervals.", boolToFloat(status.Stalled), "subsystem", status.Subsystem)
+	}
+
+	// Alert channel delivery metrics
+
+	var channels []types.NotificationChannelStats
+	for _, stats := range s.service.alertManager.GetChannelStats() {
+		if stats.Enabled {
+			channels = append(channels, stats)
+		}
+	}
+
+	for _, stats := range channels {
+		mw.metric("monic_alert_channel_up", "gauge", "Whether the alert channel circuit is closed (healthy).", boolToFloat(stats.CircuitState == "closed"), "channel", stats.Channel)
+	}
+	for _, stats := range channels {
+		mw.metric("monic_alert_deliveries_total", "counter", "Successful alert deliveries.", float64(stats.Sent), "channel", stats.Channel)
+	}
+	for _, stats := range channels {
+		mw.metric("monic_alert_delivery_failures_total", "counter", "Failed alert deliveries.", float64(stats.Failed), "channel", stats.Channel)
+	}
+	for _, stats := range channels {
+		mw.metric("monic_alert_delivery_error_ratio", "gauge", "Ratio of failed alert deliveries.", stats.ErrorRate/100, "channel", stats.Channel)
+	}
+	for _, stats := range channels {
+		mw.summary("monic_alert_delivery_latency_seconds", "Detection-to-delivery latency of successful alert deliveries.", stats.TotalLatency.Seconds(), float64(stats.Sent), "channel", stats.Channel)
+	}
+	for _, stats := range channels {
+		mw.metric("monic_alert_delivery_last_latency_seconds", "gauge", "Detection-to-delivery latency of the last successful delivery.", stats.LastLatency.Seconds(), "channel", stats.Channel)
+	}
+	for _, stats := range channels {
+		mw.metric("monic_alert_delivery_max_latency_seconds", "gauge", "Maximum detection-to-delivery latency.", stats.MaxLatency.Seconds(), "channel", stats.Channel)
+	}
+}
+
+// checkLabels returns the metric labels identifying a check: its name and the configured
+// check labels (type by default)
+func (s *StatsServer) checkLabels(result types.HTTPCheckResult) []string {
+	names := s.config.Metrics.CheckLabels
+	if len(names) == 0 {
+		names = []string{"type"}
+	}
+
+	labels := []string{"name", result.Name}
+	for _, name := range names {
+		switch name {
+		case "type":
+			checkType := result.Type
+			if checkType == "" {
+				checkType = "http"
+			}
+			labels = append(labels, "type", checkType)
+		case "url":
+			if result.URL != "" {
+				labels = append(labels, "url", result.URL)
+			}
+		}
+	}
+	return labels
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestStatsServer_HandleMetrics(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		Alerting: types.AlertingConfig{
+			Telegram: types.TelegramConfig{Enabled: true, BotToken: "token", ChatID: "chat"},
+		},
+		DockerChecks: types.DockerConfig{
+			Compose: []types.ComposeReplicas{{Project: "shop", Service: "web", Replicas: 2}},
+		},
+	}
+	service := createTestMonitorService(t, config)
+
+	service.storage.AddSystemStats(types.SystemStats{
+		Timestamp:    time.Now(),
+		CPUUsage:     12.5,
+		PerCoreUsage: []float64{10, 15},
+		LoadAverage:  types.LoadStats{Load1: 1, Load5: 3, Load15: 2, Cores: 2},
+		MemoryUsage:  types.MemoryStats{UsedPercent: 40},
+		SwapUsage:    types.SwapStats{Total: 1024, Used: 256, UsedPercent: 25},
+		DiskUsage:    map[string]types.DiskStats{"/": {Path: "/", UsedPercent: 55, InodesTotal: 100, InodesUsedPercent: 12}},
+		DiskIO:       map[string]types.DiskIOStats{"sda": {Device: "sda", ReadIOPS: 42, WriteAwaitMs: 7.5, UtilPercent: 30}},
+	})
+	collectedAt := time.Now()
+	service.storage.AddDockerContainerStats([]types.DockerContainerStats{
+		{Name: "old", Running: true, CPUPercent: 99, Timestamp: collectedAt.Add(-time.Minute)},
+		{Name: "web", Running: true, CPUPercent: 150, MemoryUsage: 1048576, MemoryLimit: 4194304, NetworkRxBytes: 2048, Timestamp: collectedAt,
+			ComposeProject: "shop", ComposeService: "web"},
+		{Name: "worker", Running: false, Timestamp: collectedAt},
+	})
+	service.storage.AddHTTPCheckResult(types.HTTPCheckResult{
+		Name:         "api",
+		Type:         "grpc",
+		ResponseTime: 250 * time.Millisecond,
+		Success:      true,
+		Timestamp:    time.Now(),
+	})
+	service.storage.AddHTTPCheckResult(types.HTTPCheckResult{
+		Name:      "ups-load",
+		Type:      "snmp",
+		Value:     "42",
+		Success:   true,
+		Timestamp: time.Now(),
+	})
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	service.statsServer.handleMetrics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected text/plain content type, got '%s'", w.Header().Get("Content-Type"))
+	}
+
+	body := w.Body.String()
+	expected := []string{
+		"# TYPE monic_up gauge",
+		"monic_cpu_usage_percent 12.5",
+		`monic_cpu_core_usage_percent{core="1"} 15`,
+		`monic_load_average{period="5m"} 3`,
+		"monic_load_average_per_core 1.5",
+		"monic_swap_usage_percent 25",
+		`monic_disk_usage_percent{path="/"} 55`,
+		`monic_disk_inode_usage_percent{path="/"} 12`,
+		`monic_disk_io_ops_per_second{device="sda",op="read"} 42`,
+		`monic_disk_io_await_milliseconds{device="sda",op="write"} 7.5`,
+		`monic_disk_io_utilization_percent{device="sda"} 30`,
+		`monic_container_cpu_usage_percent{name="web"} 150`,
+		`monic_container_memory_usage_bytes{name="web"} 1.048576e+06`,
+		`monic_container_memory_limit_bytes{name="web"} 4.194304e+06`,
+		`monic_container_network_receive_bytes_total{name="web"} 2048`,
+		`monic_compose_running_replicas{project="shop",service="web"} 1`,
+		`monic_compose_expected_replicas{project="shop",service="web"} 2`,
+		`monic_check_up{name="api",type="grpc"} 1`,
+		`monic_check_response_time_seconds{name="api",type="grpc"} 0.25`,
+		`monic_check_value{name="ups-load",type="snmp"} 42`,
+		`monic_alert_channel_up{channel="telegram"} 1`,
+		`monic_alert_deliveries_total{channel="telegram"} 0`,
+		"# TYPE monic_alert_delivery_latency_seconds summary",
+		`monic_alert_delivery_latency_seconds_count{channel="telegram"} 0`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics to contain '%s'", line)
+		}
+	}
+
+	// Only running containers of the latest collection are exported
+	if strings.Contains(body, `name="worker"`) || strings.Contains(body, `name="old"`) {
+		t.Error("Expected stopped and outdated containers to be omitted")
+	}
+
+	// Disabled channels are not exported
+	if strings.Contains(body, `channel="mailgun"`) {
+		t.Error("Expected disabled Mailgun channel to be omitted")
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	if got := formatLabels(nil); got != "" {
+		t.Errorf("Expected empty labels, got '%s'", got)
+	}
+	if got := formatLabels([]string{"name", `a"b`, "type", "http"}); got != `{name="a\"b",type="http"}` {
+		t.Errorf("Unexpected labels: %s", got)
+	}
+}
+
+func TestStatsServer_HandleMetricsRelabel(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPServer: types.HTTPServerConfig{
+			Metrics: types.MetricsConfig{
+				CheckLabels: []string{"url"},
+				Labels:      map[string]string{"host": "web-01", "env": "prod"},
+				Relabel: []types.MetricRelabel{
+					{Action: "drop", SourceLabel: "__name__", Regex: "monic_check_response_time_seconds|monic_cycle_.*"},
+					{Action: "replace", SourceLabel: "url", Regex: "https?://([^/]+)/.*", TargetLabel: "url"},
+					{Action: "labeldrop", Regex: "env"},
+				},
+			},
+		},
+	}
+	service := createTestMonitorService(t, config)
+	rules, err := compileMetricRules(config.HTTPServer.Metrics)
+	if err != nil {
+		t.Fatalf("Failed to compile rules: %v", err)
+	}
+	service.statsServer.metricRules = rules
+
+	service.storage.AddHTTPCheckResult(types.HTTPCheckResult{
+		Name:      "api",
+		URL:       "https://api.example.com/health?token=1",
+		Success:   true,
+		Timestamp: time.Now(),
+	})
+
+	w := httptest.NewRecorder()
+	service.statsServer.handleMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	if !strings.Contains(body, `monic_check_up{name="api",url="api.example.com",host="web-01"} 1`) {
+		t.Errorf("Expected the relabeled check sample, got:\n%s", body)
+	}
+	if !strings.Contains(body, `monic_up{host="web-01"} 1`) {
+		t.Error("Expected the host label on every sample")
+	}
+	// Dropped metrics are removed with their headers
+	if strings.Contains(body, "monic_check_response_time_seconds") || strings.Contains(body, "monic_cycle_") {
+		t.Error("Expected dropped metrics to be omitted")
+	}
+	if strings.Contains(body, `env="prod"`) || strings.Contains(body, `type="http"`) {
+		t.Error("Expected the env and type labels to be omitted")
+	}
+}
+
+func TestCompileMetricRules_Invalid(t *testing.T) {
+	tests := []types.MetricsConfig{
+		{CheckLabels: []string{"status"}},
+		{Relabel: []types.MetricRelabel{{Action: "hashmod", SourceLabel: "name"}}},
+		{Relabel: []types.MetricRelabel{{Action: "drop"}}},
+		{Relabel: []types.MetricRelabel{{Action: "replace", SourceLabel: "name"}}},
+		{Relabel: []types.MetricRelabel{{Action: "replace", SourceLabel: "name", TargetLabel: "__name__"}}},
+		{Relabel: []types.MetricRelabel{{Action: "keep", SourceLabel: "name", Regex: "("}}},
+	}
+	for _, config := range tests {
+		if _, err := compileMetricRules(config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nuid"
+)
+
+const (
+	defaultNATSSubjectPrefix = "monic"
+	defaultNATSBufferSize    = 10000
+	natsPublishTimeout       = 5 * time.Second
+	natsMaxRetryDelay        = 30 * time.Second
+)
+
+// ResultPublisher streams check results and alerts to an external system
+type ResultPublisher interface {
+	Start() error
+	PublishCheckResult(result types.HTTPCheckResult)
+	PublishAlert(alert types.Alert)
+	Close()
+}
+
+// NATSPublisher publishes check results and alerts to NATS JetStream. Messages are buffered
+// and retried until JetStream acknowledges them (at-least-once); each message carries a
+// Nats-Msg-Id header so the stream drops duplicates of retried messages.
+type NATSPublisher struct {
+	config *types.NATSConfig
+	host   string
+	prefix string
+
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	publish func(ctx context.Context, msg *nats.Msg) error // Replaced in tests
+
+	mu      sync.Mutex
+	queue   []*nats.Msg
+	dropped int
+	notify  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewNATSPublisher creates a NATS publisher; host identifies this instance in subjects
+func NewNATSPublisher(config *types.NATSConfig, host string) *NATSPublisher {
+	prefix := config.SubjectPrefix
+	if prefix == "" {
+		prefix = defaultNATSSubjectPrefix
+	}
+
+	p := &NATSPublisher{
+		config: config,
+		host:   subjectToken(host),
+		prefix: prefix,
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	p.publish = p.publishToJetStream
+	return p
+}
+
+// Start connects to NATS and starts the publishing loop. An unreachable server does not fail
+// startup: the client keeps reconnecting and messages are buffered meanwhile.
+func (p *NATSPublisher) Start() error {
+	options := []nats.Option{
+		nats.Name("monic"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				slog.Warn("Disconnected from NATS", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			slog.Info("Reconnected to NATS", "url", conn.ConnectedUrlRedacted())
+		}),
+	}
+	if p.config.Username != "" {
+		options = append(options, nats.UserInfo(p.config.Username, p.config.Password))
+	}
+	if p.config.Token != "" {
+		options = append(options, nats.Token(p.config.Token))
+	}
+
+	conn, err := nats.Connect(p.config.URL, options...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	p.conn = conn
+	p.js = js
+
+	go p.publishLoop()
+	slog.Info("NATS publisher started", "subjects", p.prefix+".>", "stream", p.config.Stream)
+	return nil
+}
+
+// PublishCheckResult queues a check result for <prefix>.check.<host>.<type>.<name>
+func (p *NATSPublisher) PublishCheckResult(result types.HTTPCheckResult) {
+	checkType := result.Type
+	if checkType == "" {
+		checkType = "http"
+	}
+	subject := strings.Join([]string{p.prefix, "check", p.host, subjectToken(checkType), subjectToken(result.Name)}, ".")
+	p.enqueue(subject, result)
+}
+
+// PublishAlert queues an alert for <prefix>.alert.<host>.<type>
+func (p *NATSPublisher) PublishAlert(alert types.Alert) {
+	subject := strings.Join([]string{p.prefix, "alert", p.host, subjectToken(alert.Type)}, ".")
+	p.enqueue(subject, alert)
+}
+
+// enqueue encodes a message and adds it to the buffer, dropping the oldest message when full
+func (p *NATSPublisher) enqueue(subject string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("Failed to encode NATS message", "subject", subject, "error", err)
+		return
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	msg.Header.Set(jetstream.MsgIDHeader, nuid.Next())
+	msg.Header.Set("Monic-Host", p.host)
+
+	bufferSize := p.config.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultNATSBufferSize
+	}
+
+	p.mu.Lock()
+	if len(p.queue) >= bufferSize {
+		p.queue = p.queue[1:]
+		p.dropped++
+		if p.dropped == 1 || p.dropped%1000 == 0 {
+			slog.Warn("NATS buffer full, dropping oldest messages", "dropped", p.dropped, "buffer_size", bufferSize)
+		}
+	}
+	p.queue = append(p.queue, msg)
+	p.mu.Unlock()
+
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+// publishLoop publishes buffered messages in order, retrying with backoff until each is acknowledged
+func (p *NATSPublisher) publishLoop() {
+	defer close(p.done)
+
+	delay := time.Second
+	for {
+		p.mu.Lock()
+		var msg *nats.Msg
+		if len(p.queue) > 0 {
+			msg = p.queue[0]
+		}
+		p.mu.Unlock()
+
+		if msg == nil {
+			select {
+			case <-p.stop:
+				return
+			case <-p.notify:
+				continue
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), natsPublishTimeout)
+		err := p.publish(ctx, msg)
+		cancel()
+		if err != nil {
+			slog.Warn("Failed to publish to NATS, retrying", "subject", msg.Subject, "retry_in", delay.String(), "error", err)
+			select {
+			case <-p.stop:
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, natsMaxRetryDelay)
+			continue
+		}
+		delay = time.Second
+
+		p.mu.Lock()
+		if len(p.queue) > 0 && p.queue[0] == msg {
+			p.queue = p.queue[1:]
+		}
+		p.mu.Unlock()
+	}
+}
+
+// publishToJetStream publishes a message and waits for the stream acknowledgement,
+// creating the configured stream first if needed
+func (p *NATSPublisher) publishToJetStream(ctx context.Context, msg *nats.Msg) error {
+	if p.config.Stream != "" {
+		if _, err := p.js.Stream(ctx, p.config.Stream); err != nil {
+			_, err := p.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+				Name:     p.config.Stream,
+				Subjects: []string{p.prefix + ".>"},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create stream %s: %w", p.config.Stream, err)
+			}
+		}
+	}
+
+	_, err := p.js.PublishMsg(ctx, msg)
+	return err
+}
+
+// pending returns the number of buffered messages
+func (p *NATSPublisher) pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue)
+}
+
+// Close stops publishing and closes the connection; unacknowledged messages are logged as lost
+func (p *NATSPublisher) Close() {
+	close(p.stop)
+	if p.conn == nil {
+		return
+	}
+	<-p.done
+
+	if pending := p.pending(); pending > 0 {
+		slog.Warn("NATS publisher stopped with unpublished messages", "pending", pending)
+	}
+	p.conn.Close()
+}
+
+// subjectToken makes a value safe to use as a single NATS subject token
+func subjectToken(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\n', '\r':
+			return '_'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestSubjectToken(t *testing.T) {
+	tests := map[string]string{
+		"api":             "api",
+		"api.example.com": "api_example_com",
+		"nightly backup":  "nightly_backup",
+		"a*b>c":           "a_b_c",
+		"":                "unknown",
+	}
+	for input, want := range tests {
+		if got := subjectToken(input); got != want {
+			t.Errorf("subjectToken(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestNATSPublisher_Subjects(t *testing.T) {
+	publisher := NewNATSPublisher(&types.NATSConfig{}, "web.01")
+
+	publisher.PublishCheckResult(types.HTTPCheckResult{Name: "api.example.com", URL: "https://api.example.com"})
+	publisher.PublishCheckResult(types.HTTPCheckResult{Name: "pool", Type: "ntp"})
+	publisher.PublishAlert(types.Alert{Type: "cpu", Level: "critical", Message: "CPU usage is high"})
+
+	want := []string{"monic.check.web_01.http.api_example_com", "monic.check.web_01.ntp.pool", "monic.alert.web_01.cpu"}
+	if len(publisher.queue) != len(want) {
+		t.Fatalf("Expected %d queued messages, got %d", len(want), len(publisher.queue))
+	}
+	for i, msg := range publisher.queue {
+		if msg.Subject != want[i] {
+			t.Errorf("Expected subject %s, got %s", want[i], msg.Subject)
+		}
+		if msg.Header.Get("Nats-Msg-Id") == "" || msg.Header.Get("Monic-Host") != "web_01" {
+			t.Errorf("Unexpected headers: %v", msg.Header)
+		}
+	}
+
+	var alert types.Alert
+	if err := json.Unmarshal(publisher.queue[2].Data, &alert); err != nil || alert.Message != "CPU usage is high" {
+		t.Errorf("Expected the alert as JSON payload, got %s (%v)", publisher.queue[2].Data, err)
+	}
+}
+
+func TestNATSPublisher_BufferDropsOldest(t *testing.T) {
+	publisher := NewNATSPublisher(&types.NATSConfig{SubjectPrefix: "ops", BufferSize: 2}, "host")
+
+	for _, name := range []string{"first", "second", "third"} {
+		publisher.PublishCheckResult(types.HTTPCheckResult{Name: name})
+	}
+
+	if publisher.pending() != 2 || publisher.dropped != 1 {
+		t.Fatalf("Expected 2 pending and 1 dropped message, got %d and %d", publisher.pending(), publisher.dropped)
+	}
+	if publisher.queue[0].Subject != "ops.check.host.http.second" {
+		t.Errorf("Expected the oldest message to be dropped, got %s first", publisher.queue[0].Subject)
+	}
+}
+
+func TestNATSPublisher_RetriesUntilAcknowledged(t *testing.T) {
+	publisher := NewNATSPublisher(&types.NATSConfig{}, "host")
+
+	var mu sync.Mutex
+	var attempts int
+	var published []string
+	publisher.publish = func(ctx context.Context, msg *nats.Msg) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			return errors.New("no responders available for request")
+		}
+		published = append(published, msg.Subject)
+		return nil
+	}
+
+	go publisher.publishLoop()
+	publisher.PublishCheckResult(types.HTTPCheckResult{Name: "a"})
+	publisher.PublishCheckResult(types.HTTPCheckResult{Name: "b"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for publisher.pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(publisher.stop)
+	<-publisher.done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(published) != 2 || published[0] != "monic.check.host.http.a" || published[1] != "monic.check.host.http.b" {
+		t.Errorf("Expected both messages published in order after a retry, got %v (%d attempts)", published, attempts)
+	}
+}
+
+// recordingPublisher records everything the service publishes
+type recordingPublisher struct {
+	results []types.HTTPCheckResult
+	alerts  []types.Alert
+}
+
+func (p *recordingPublisher) Start() error { return nil }
+func (p *recordingPublisher) Close()       {}
+func (p *recordingPublisher) PublishCheckResult(result types.HTTPCheckResult) {
+	p.results = append(p.results, result)
+}
+func (p *recordingPublisher) PublishAlert(alert types.Alert) {
+	p.alerts = append(p.alerts, alert)
+}
+
+func TestMonitorService_PublishesResultsAndAlerts(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+	}
+	service := createTestMonitorService(t, config)
+	publisher := &recordingPublisher{}
+	service.SetPublisher(publisher)
+
+	failed := types.HTTPCheckResult{Name: "backup", Type: "push", Error: "disk full"}
+	for i := 0; i < 3; i++ {
+		service.recordCheckResults("Push", []types.HTTPCheckResult{failed})
+	}
+	service.processAlerts()
+
+	if len(publisher.results) != 3 {
+		t.Errorf("Expected 3 published results, got %d", len(publisher.results))
+	}
+	if len(publisher.alerts) != 1 {
+		t.Errorf("Expected 1 published alert, got %+v", publisher.alerts)
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Response formats selectable with the format parameter
+const (
+	formatHTML       = "html"
+	formatJSON       = "json"
+	formatPrometheus = "prometheus"
+	formatYAML       = "yaml"
+)
+
+// mediaOffer is a response format and the media type it is served as
+type mediaOffer struct {
+	Format    string
+	MediaType string
+}
+
+// Formats of /stats, in order of preference for clients without one (browsers, curl)
+var statsOffers = []mediaOffer{
+	{formatHTML, "text/html"},
+	{formatJSON, "application/json"},
+	{formatPrometheus, "text/plain"},
+}
+
+// acceptRange is a media range of an Accept header with its quality
+type acceptRange struct {
+	mediaType string // "type/subtype", "type/*" or "*/*"
+	quality   float64
+}
+
+// negotiateFormat picks the response format: the "format" parameter if given, otherwise the offer
+// the Accept header prefers (RFC 9110: the most specific matching range decides the quality of an
+// offer, q=0 excludes it). Ties go to the earlier offer, as does a missing Accept header. An error
+// means no offer is acceptable.
+func negotiateFormat(r *http.Request, offers []mediaOffer) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		for _, offer := range offers {
+			if strings.EqualFold(format, offer.Format) {
+				return offer.Format, nil
+			}
+		}
+		return "", fmt.Errorf("unsupported format %q (supported: %s)", format, offerFormats(offers))
+	}
+
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return offers[0].Format, nil
+	}
+	ranges := parseAccept(strings.Join(accept, ","))
+
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		if quality := offerQuality(offer.MediaType, ranges); quality > bestQuality {
+			best, bestQuality = offer.Format, quality
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("none of the accepted media types is available (supported formats: %s)", offerFormats(offers))
+	}
+	return best, nil
+}
+
+// parseAccept parses the media ranges of an Accept header; invalid qualities count as 1
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+		if mediaType == "*" {
+			mediaType = "*/*" // Sent by some old clients
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+					quality = q
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	return ranges
+}
+
+// offerQuality returns the quality of the most specific range matching a media type, 0 if none
+func offerQuality(mediaType string, ranges []acceptRange) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch r.mediaType {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+	return quality
+}
+
+// offerFormats lists the formats of the offers for error messages
+func offerFormats(offers []mediaOffer) string {
+	formats := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		formats = append(formats, offer.Format)
+	}
+	return strings.Join(formats, ", ")
+}
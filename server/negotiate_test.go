@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/types"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   string
+		err    bool
+	}{
+		{"no header", "/stats", "", formatHTML, false},
+		{"exact json", "/stats", "application/json", formatJSON, false},
+		{"json with charset", "/stats", "application/json; charset=utf-8", formatJSON, false},
+		{"case insensitive", "/stats", "Application/JSON", formatJSON, false},
+		{"browser", "/stats", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatHTML, false},
+		{"quality", "/stats", "text/html;q=0.5, application/json", formatJSON, false},
+		{"wildcard", "/stats", "*/*", formatHTML, false},
+		{"subtype wildcard", "/stats", "application/*", formatJSON, false},
+		{"specific range wins", "/stats", "text/*;q=0.9, text/html;q=0.1", formatPrometheus, false},
+		{"excluded", "/stats", "text/html;q=0, */*;q=0.5", formatJSON, false},
+		{"plain text", "/stats", "text/plain", formatPrometheus, false},
+		{"not acceptable", "/stats", "image/png", "", true},
+		{"format parameter", "/stats?format=prometheus", "application/json", formatPrometheus, false},
+		{"format case", "/stats?format=JSON", "", formatJSON, false},
+		{"unknown format", "/stats?format=xml", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			got, err := negotiateFormat(req, statsOffers)
+			if (err != nil) != tt.err {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStatsServer_StatsFormats(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	mux := service.statsServer.routes()
+
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		target      string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"/stats", "", http.StatusOK, "text/html"},
+		{"/stats", "application/json, text/plain;q=0.5", http.StatusOK, "application/json"},
+		{"/stats?format=json", "text/html", http.StatusOK, "application/json"},
+		{"/stats?format=prometheus", "", http.StatusOK, "text/plain"},
+		{"/stats?format=yaml", "", http.StatusBadRequest, ""},
+		{"/stats", "image/png", http.StatusNotAcceptable, ""},
+	}
+	for _, tt := range tests {
+		w := get(tt.target, tt.accept)
+		if w.Code != tt.status {
+			t.Errorf("%s (Accept %q): expected %d, got %d", tt.target, tt.accept, tt.status, w.Code)
+			continue
+		}
+		if tt.contentType != "" && !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+			t.Errorf("%s (Accept %q): expected %s, got %s", tt.target, tt.accept, tt.contentType, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Header().Get("Vary"), "Accept") {
+			t.Errorf("%s: expected Vary: Accept, got %q", tt.target, w.Header().Get("Vary"))
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+const (
+	probePath = "/api/v1/probe"
+	// maxProbeTimeout caps the timeout of checks requested by peers
+	maxProbeTimeout = 60
+	// probeOverhead is added to the check timeout for the round trip to a peer
+	probeOverhead = 5 * time.Second
+)
+
+// probeRequest is the HTTP check a peer runs on behalf of another instance
+type probeRequest struct {
+	URL            string `json:"url"`
+	Method         string `json:"method"`
+	Timeout        int    `json:"timeout"`
+	ExpectedStatus int    `json:"expected_status"`
+	Invert         bool   `json:"invert"`
+}
+
+// locationResult is the result of a check at one location
+type locationResult struct {
+	Location string
+	Result   types.HTTPCheckResult
+}
+
+// PeerProber runs the HTTP check from peer Monic instances in other locations, so that an
+// endpoint only counts as down when it fails from enough vantage points
+type PeerProber struct {
+	peers    []types.PeerConfig
+	location string
+	client   *http.Client
+}
+
+// NewPeerProber creates a peer prober; location names this instance in check errors
+func NewPeerProber(peers []types.PeerConfig, location string) *PeerProber {
+	return &PeerProber{
+		peers:    peers,
+		location: location,
+		client:   &http.Client{},
+	}
+}
+
+// Validate validates the peer configuration
+func (p *PeerProber) Validate() error {
+	names := map[string]bool{p.location: true}
+	for _, peer := range p.peers {
+		if peer.Name == "" {
+			return fmt.Errorf("peer name cannot be empty")
+		}
+		if names[peer.Name] {
+			return fmt.Errorf("duplicate location name %q", peer.Name)
+		}
+		names[peer.Name] = true
+
+		parsed, err := url.Parse(peer.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("peer %s: invalid URL %q", peer.Name, peer.URL)
+		}
+	}
+	return nil
+}
+
+// Probe runs the check on all peers and combines their results with the local one. Peers that
+// cannot be asked are left out, so an unreachable peer does not count as a failed location.
+func (p *PeerProber) Probe(check types.HTTPCheck, local types.HTTPCheckResult) types.HTTPCheckResult {
+	peerResults := make([]*types.HTTPCheckResult, len(p.peers))
+	var wg sync.WaitGroup
+	for i, peer := range p.peers {
+		wg.Add(1)
+		go func(i int, peer types.PeerConfig) {
+			defer wg.Done()
+			result, err := p.probePeer(peer, check)
+			if err != nil {
+				slog.Warn("Failed to run HTTP check from peer", "peer", peer.Name, "url", check.URL, "error", err)
+				return
+			}
+			peerResults[i] = &result
+		}(i, peer)
+	}
+	wg.Wait()
+
+	// Keep the configured order so errors list the locations consistently
+	locations := []locationResult{{Location: p.location, Result: local}}
+	for i, result := range peerResults {
+		if result != nil {
+			locations = append(locations, locationResult{Location: p.peers[i].Name, Result: *result})
+		}
+	}
+
+	return aggregateLocations(locations, check.MinLocations)
+}
+
+// probePeer asks a peer to run the check
+func (p *PeerProber) probePeer(peer types.PeerConfig, check types.HTTPCheck) (types.HTTPCheckResult, error) {
+	body, err := json.Marshal(probeRequest{
+		URL:            check.URL,
+		Method:         check.Method,
+		Timeout:        check.Timeout,
+		ExpectedStatus: check.ExpectedStatus,
+		Invert:         check.Invert,
+	})
+	if err != nil {
+		return types.HTTPCheckResult{}, fmt.Errorf("failed to encode probe: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(check.Timeout)*time.Second+probeOverhead)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(peer.URL, "/")+probePath, bytes.NewReader(body))
+	if err != nil {
+		return types.HTTPCheckResult{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if peer.Secret != "" {
+		if err := signRequest(req, peer.Secret, body); err != nil {
+			return types.HTTPCheckResult{}, err
+		}
+	} else if peer.Username != "" {
+		req.SetBasicAuth(peer.Username, peer.Password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return types.HTTPCheckResult{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return types.HTTPCheckResult{}, fmt.Errorf("peer returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var result types.HTTPCheckResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return types.HTTPCheckResult{}, fmt.Errorf("invalid probe response: %w", err)
+	}
+	return result, nil
+}
+
+// aggregateLocations combines the results of all locations, the local one first: the check
+// succeeds when it succeeded from at least minLocations locations (default: half of them).
+// Timing and status code are those of the local result.
+func aggregateLocations(locations []locationResult, minLocations int) types.HTTPCheckResult {
+	result := locations[0].Result
+
+	succeeded := 0
+	var failures []string
+	for _, location := range locations {
+		if location.Result.Success {
+			succeeded++
+		} else {
+			failures = append(failures, location.Location+": "+location.Result.Error)
+		}
+	}
+
+	required := minLocations
+	if required <= 0 {
+		required = (len(locations) + 1) / 2
+	}
+	if required > len(locations) {
+		required = len(locations)
+	}
+
+	result.Value = fmt.Sprintf("%d/%d locations", succeeded, len(locations))
+	result.Success = succeeded >= required
+	switch {
+	case result.Success:
+		result.Error = ""
+	case result.Inverted:
+		result.Error = fmt.Sprintf("unreachable from %d of %d locations, %d required (%s)", succeeded, len(locations), required, strings.Join(failures, "; "))
+	default:
+		result.Error = fmt.Sprintf("reachable from %d of %d locations, %d required (%s)", succeeded, len(locations), required, strings.Join(failures, "; "))
+	}
+	return result
+}
+
+// SetProbeMonitor enables the endpoint peers use to run their HTTP check from this instance
+func (s *StatsServer) SetProbeMonitor(probes *monitor.HTTPMonitor) {
+	s.probes = probes
+}
+
+// handleProbe handles POST /api/v1/probe: runs an HTTP check for a peer and returns its result
+func (s *StatsServer) handleProbe(w http.ResponseWriter, r *http.Request) {
+	if s.probes == nil || !s.config.Probes.Enabled {
+		http.Error(w, "Probes are not enabled", http.StatusNotFound)
+		return
+	}
+
+	var probe probeRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxIngestBodySize)).Decode(&probe); err != nil {
+		http.Error(w, "Invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	check := types.HTTPCheck{
+		URL:            probe.URL,
+		Method:         probe.Method,
+		Timeout:        min(probe.Timeout, maxProbeTimeout),
+		ExpectedStatus: probe.ExpectedStatus,
+		Invert:         probe.Invert,
+		CheckInterval:  1, // Not used for single probes
+	}
+	if err := s.probes.ValidateHTTPCheck(check); err != nil {
+		http.Error(w, "Invalid check: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	slog.Debug("Running HTTP check for peer", "url", check.URL, "remote", r.RemoteAddr)
+	writeJSON(w, s.probes.CheckEndpoint(check))
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+// newProbePeer starts a peer instance that runs probes, signed with the given secret
+func newProbePeer(t *testing.T, secret string) *httptest.Server {
+	config := &types.HTTPServerConfig{
+		Ingest: types.IngestConfig{Secret: secret},
+		Probes: types.ProbesConfig{Enabled: true},
+	}
+	peer := NewStatsServer(config, nil, NewStorageManager(10), nil)
+	peer.SetProbeMonitor(monitor.NewHTTPMonitor())
+	server := httptest.NewServer(peer.routes())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPeerProber_Probe(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	peerA := newProbePeer(t, "peer-secret")
+	peerB := newProbePeer(t, "peer-secret")
+	prober := NewPeerProber([]types.PeerConfig{
+		{Name: "eu-west", URL: peerA.URL, Secret: "peer-secret"},
+		{Name: "us-east", URL: peerB.URL, Secret: "peer-secret"},
+	}, "home")
+	if err := prober.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	check := types.HTTPCheck{URL: target.URL, Method: "GET", Timeout: 5, ExpectedStatus: 200, CheckInterval: 30}
+
+	// The peers reach the target although the local check failed: 2 of 3 is enough
+	local := types.HTTPCheckResult{URL: target.URL, Error: "request failed: connection reset"}
+	result := prober.Probe(check, local)
+	if !result.Success || result.Value != "2/3 locations" {
+		t.Errorf("Expected success from 2 of 3 locations, got %+v", result)
+	}
+
+	// Requiring all locations fails and names the failing one
+	check.MinLocations = 3
+	result = prober.Probe(check, local)
+	if result.Success || !strings.Contains(result.Error, "reachable from 2 of 3 locations, 3 required") || !strings.Contains(result.Error, "home: request failed") {
+		t.Errorf("Expected failure with 3 required locations, got %+v", result)
+	}
+}
+
+func TestPeerProber_UnavailablePeer(t *testing.T) {
+	peer := newProbePeer(t, "peer-secret")
+	prober := NewPeerProber([]types.PeerConfig{
+		{Name: "eu-west", URL: peer.URL, Secret: "wrong-secret"},
+	}, "home")
+
+	// A peer rejecting the probe is left out instead of counting as a failed location
+	check := types.HTTPCheck{URL: "http://127.0.0.1:1", Method: "GET", Timeout: 1, ExpectedStatus: 200}
+	result := prober.Probe(check, types.HTTPCheckResult{Success: true})
+	if !result.Success || result.Value != "1/1 locations" {
+		t.Errorf("Expected only the local location to count, got %+v", result)
+	}
+}
+
+func TestAggregateLocations(t *testing.T) {
+	ok := types.HTTPCheckResult{Success: true}
+	down := types.HTTPCheckResult{Error: "timeout"}
+	canary := types.HTTPCheckResult{Inverted: true, Error: "canary check succeeded"}
+
+	tests := []struct {
+		name      string
+		locations []locationResult
+		min       int
+		success   bool
+		err       string
+	}{
+		{"half of two", []locationResult{{"a", down}, {"b", ok}}, 0, true, ""},
+		{"none of two", []locationResult{{"a", down}, {"b", down}}, 0, false, "reachable from 0 of 2 locations, 1 required (a: timeout; b: timeout)"},
+		{"one of three", []locationResult{{"a", ok}, {"b", down}, {"c", down}}, 0, false, "reachable from 1 of 3 locations, 2 required"},
+		{"minimum above locations", []locationResult{{"a", ok}}, 3, true, ""},
+		{"canary", []locationResult{{"a", canary}, {"b", canary}}, 0, false, "unreachable from 0 of 2 locations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := aggregateLocations(tt.locations, tt.min)
+			if result.Success != tt.success || !strings.Contains(result.Error, tt.err) {
+				t.Errorf("Expected success=%v and error %q, got %+v", tt.success, tt.err, result)
+			}
+		})
+	}
+}
+
+func TestPeerProber_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		peers []types.PeerConfig
+	}{
+		{"missing name", []types.PeerConfig{{URL: "http://peer:8080"}}},
+		{"local name", []types.PeerConfig{{Name: "home", URL: "http://peer:8080"}}},
+		{"duplicate name", []types.PeerConfig{{Name: "eu", URL: "http://a:8080"}, {Name: "eu", URL: "http://b:8080"}}},
+		{"invalid URL", []types.PeerConfig{{Name: "eu", URL: "peer:8080"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewPeerProber(tt.peers, "home").Validate(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestStatsServer_ProbeDisabled(t *testing.T) {
+	server := NewStatsServer(&types.HTTPServerConfig{}, nil, NewStorageManager(10), nil)
+	server.SetProbeMonitor(monitor.NewHTTPMonitor())
+
+	w := httptest.NewRecorder()
+	server.routes().ServeHTTP(w, httptest.NewRequest("POST", probePath, strings.NewReader(`{"url":"http://example.com"}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without probes enabled, got %d", w.Code)
+	}
+}
+
+func TestStatsServer_ProbeUnsigned(t *testing.T) {
+	peer := newProbePeer(t, "peer-secret")
+
+	resp, err := http.Post(peer.URL+probePath, "application/json", strings.NewReader(`{"url":"http://169.254.169.254/"}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unsigned probe, got %d", resp.StatusCode)
+	}
+}
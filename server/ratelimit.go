@@ -0,0 +1,260 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	// defaultMaxAuthFailures is the number of failed logins after which a client is locked out
+	defaultMaxAuthFailures = 5
+	// defaultAuthLockout is how long a client stays locked out, in seconds
+	defaultAuthLockout = 300
+	// minRateBurst is the smallest default burst, so pages loading a few resources are not limited
+	minRateBurst = 10
+	// clientPruneInterval is how often idle clients are forgotten
+	clientPruneInterval = time.Minute
+)
+
+// clientIP returns the IP address of the client of a request. Forwarded headers are ignored as
+// they can be set by anyone.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// tokenBucket holds the request allowance of a client
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter limits the request rate of each client with a token bucket
+type rateLimiter struct {
+	rate  float64 // Tokens added per second
+	burst float64 // Bucket size
+
+	mu        sync.Mutex
+	clients   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+// newRateLimiter creates a rate limiter, or returns nil when rate limiting is disabled
+func newRateLimiter(config types.RateLimitConfig) *rateLimiter {
+	if config.Requests <= 0 {
+		return nil
+	}
+
+	burst := float64(config.Burst)
+	if burst <= 0 {
+		burst = math.Max(math.Ceil(config.Requests*10), minRateBurst)
+	}
+	return &rateLimiter{
+		rate:    config.Requests,
+		burst:   burst,
+		clients: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the client's bucket; when it is empty, it returns how long the client
+// has to wait for the next one
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+
+	bucket, exists := l.clients[client]
+	if !exists {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.clients[client] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// prune forgets clients whose bucket has refilled completely, as they start with a full one anyway
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < clientPruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for client, bucket := range l.clients {
+		if now.Sub(bucket.updated) >= refill {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// authFailures tracks the failed logins of a client
+type authFailures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// authGuard locks out clients after repeated failed logins. Failures are forgotten after a
+// lockout period without failures, or with a successful login.
+type authGuard struct {
+	maxFailures int
+	lockout     time.Duration
+
+	mu        sync.Mutex
+	clients   map[string]*authFailures
+	lastPrune time.Time
+}
+
+// newAuthGuard creates an auth guard with the configured settings (or defaults), or returns nil
+// when lockouts are disabled
+func newAuthGuard(config types.AuthConfig) *authGuard {
+	maxFailures := config.MaxFailures
+	if maxFailures < 0 {
+		return nil
+	}
+	if maxFailures == 0 {
+		maxFailures = defaultMaxAuthFailures
+	}
+
+	lockout := config.Lockout
+	if lockout <= 0 {
+		lockout = defaultAuthLockout
+	}
+
+	return &authGuard{
+		maxFailures: maxFailures,
+		lockout:     time.Duration(lockout) * time.Second,
+		clients:     make(map[string]*authFailures),
+	}
+}
+
+// locked returns the remaining lockout of a client
+func (g *authGuard) locked(client string, now time.Time) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if failures, exists := g.clients[client]; exists && now.Before(failures.lockedUntil) {
+		return failures.lockedUntil.Sub(now), true
+	}
+	return 0, false
+}
+
+// fail records a failed login and reports whether it locked the client out
+func (g *authGuard) fail(client string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.prune(now)
+
+	failures, exists := g.clients[client]
+	if !exists || now.Sub(failures.last) >= g.lockout {
+		failures = &authFailures{}
+		g.clients[client] = failures
+	}
+	failures.count++
+	failures.last = now
+
+	if failures.count >= g.maxFailures {
+		failures.count = 0
+		failures.lockedUntil = now.Add(g.lockout)
+		return true
+	}
+	return false
+}
+
+// succeed forgets the failed logins of a client
+func (g *authGuard) succeed(client string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.clients, client)
+}
+
+// prune forgets clients that are not locked out and had no failures within the lockout period
+func (g *authGuard) prune(now time.Time) {
+	if now.Sub(g.lastPrune) < clientPruneInterval {
+		return
+	}
+	g.lastPrune = now
+
+	for client, failures := range g.clients {
+		if now.Sub(failures.last) >= g.lockout && !now.Before(failures.lockedUntil) {
+			delete(g.clients, client)
+		}
+	}
+}
+
+// rateLimit middleware rejects requests of clients exceeding the configured request rate
+func (s *StatsServer) rateLimit(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := s.limiter.allow(clientIP(r), time.Now()); !ok {
+			tooManyRequests(w, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authLocked rejects the request if its client is locked out after failed logins
+func (s *StatsServer) authLocked(w http.ResponseWriter, r *http.Request) bool {
+	if s.guard == nil {
+		return false
+	}
+	remaining, locked := s.guard.locked(clientIP(r), time.Now())
+	if locked {
+		tooManyRequests(w, remaining)
+	}
+	return locked
+}
+
+// authFailed records a failed login of the request's client
+func (s *StatsServer) authFailed(r *http.Request) {
+	if s.guard == nil {
+		return
+	}
+	client := clientIP(r)
+	if s.guard.fail(client, time.Now()) {
+		slog.Warn("Locked out client after repeated failed logins", "client", client, "lockout", s.guard.lockout.String())
+	}
+}
+
+// authSucceeded forgets earlier failed logins of the request's client
+func (s *StatsServer) authSucceeded(r *http.Request) {
+	if s.guard != nil {
+		s.guard.succeed(clientIP(r))
+	}
+}
+
+// tooManyRequests rejects a request, telling the client when to try again
+func tooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(wait.Seconds()))))
+	http.Error(w, "Too many requests", http.StatusTooManyRequests)
+}
+
+// credentialsEqual compares credentials in constant time. Hashing first also hides their length.
+func credentialsEqual(given, expected string) bool {
+	givenHash := sha256.Sum256([]byte(given))
+	expectedHash := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(givenHash[:], expectedHash[:]) == 1
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestRateLimiter(t *testing.T) {
+	if newRateLimiter(types.RateLimitConfig{}) != nil {
+		t.Fatal("Expected no rate limiter without a request rate")
+	}
+	if limiter := newRateLimiter(types.RateLimitConfig{Requests: 5}); limiter.burst != 50 {
+		t.Errorf("Expected a default burst of 10 seconds worth of requests, got %v", limiter.burst)
+	}
+
+	now := time.Now()
+	limiter := newRateLimiter(types.RateLimitConfig{Requests: 2, Burst: 3})
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.allow("10.0.0.1", now); !ok {
+			t.Fatalf("Expected request %d within the burst to be allowed", i+1)
+		}
+	}
+	ok, wait := limiter.allow("10.0.0.1", now)
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("Expected request beyond the burst to wait 500ms, got %v %v", ok, wait)
+	}
+	// Other clients have their own bucket
+	if ok, _ := limiter.allow("10.0.0.2", now); !ok {
+		t.Error("Expected another client to be allowed")
+	}
+	// Tokens are refilled at the configured rate
+	if ok, _ := limiter.allow("10.0.0.1", now.Add(500*time.Millisecond)); !ok {
+		t.Error("Expected request to be allowed after a refill")
+	}
+
+	// Clients with a full bucket are forgotten
+	limiter.allow("10.0.0.3", now.Add(time.Hour))
+	if len(limiter.clients) != 1 {
+		t.Errorf("Expected idle clients to be pruned, got %d clients", len(limiter.clients))
+	}
+}
+
+func TestAuthGuard(t *testing.T) {
+	if newAuthGuard(types.AuthConfig{MaxFailures: -1}) != nil {
+		t.Fatal("Expected no auth guard with lockouts disabled")
+	}
+	if guard := newAuthGuard(types.AuthConfig{}); guard.maxFailures != defaultMaxAuthFailures || guard.lockout != 5*time.Minute {
+		t.Errorf("Expected default settings, got %d %v", guard.maxFailures, guard.lockout)
+	}
+
+	now := time.Now()
+	guard := newAuthGuard(types.AuthConfig{MaxFailures: 3, Lockout: 60})
+	guard.fail("10.0.0.1", now)
+	guard.fail("10.0.0.1", now)
+	if _, locked := guard.locked("10.0.0.1", now); locked {
+		t.Fatal("Expected no lockout before the maximum failures")
+	}
+	if !guard.fail("10.0.0.1", now.Add(time.Second)) {
+		t.Fatal("Expected lockout after the maximum failures")
+	}
+	if remaining, locked := guard.locked("10.0.0.1", now.Add(31*time.Second)); !locked || remaining != 30*time.Second {
+		t.Errorf("Expected 30s of lockout remaining, got %v %v", locked, remaining)
+	}
+	if _, locked := guard.locked("10.0.0.2", now); locked {
+		t.Error("Expected other clients not to be locked out")
+	}
+	if _, locked := guard.locked("10.0.0.1", now.Add(61*time.Second)); locked {
+		t.Error("Expected the lockout to expire")
+	}
+
+	// A successful login forgets earlier failures
+	guard.fail("10.0.0.2", now)
+	guard.fail("10.0.0.2", now)
+	guard.succeed("10.0.0.2")
+	if guard.fail("10.0.0.2", now) {
+		t.Error("Expected failures to be reset by a successful login")
+	}
+
+	// Failures older than the lockout period are forgotten
+	guard.fail("10.0.0.3", now)
+	guard.fail("10.0.0.3", now)
+	if guard.fail("10.0.0.3", now.Add(2*time.Minute)) {
+		t.Error("Expected old failures to be forgotten")
+	}
+}
+
+func TestStatsServer_AuthLockout(t *testing.T) {
+	config := &types.HTTPServerConfig{
+		Enabled:  true,
+		Username: "admin",
+		Password: "monic123",
+		Auth:     types.AuthConfig{MaxFailures: 2, Lockout: 60},
+	}
+	server := NewStatsServer(config, nil, NewStorageManager(10), nil)
+	handler := server.basicAuth(func(w http.ResponseWriter, r *http.Request) {})
+
+	request := func(username, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/stats", nil)
+		req.RemoteAddr = "192.0.2.1:4321"
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	// Requests without credentials (the browser prompt) do not count as failures
+	for i := 0; i < 3; i++ {
+		if w := request("", ""); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status %d without credentials, got %d", http.StatusUnauthorized, w.Code)
+		}
+	}
+	request("admin", "wrong")
+	request("wrong", "monic123")
+
+	// Locked out clients are rejected even with the right credentials
+	w := request("admin", "monic123")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected status %d with Retry-After 60, got %d %q", http.StatusTooManyRequests, w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestStatsServer_RateLimit(t *testing.T) {
+	config := &types.HTTPServerConfig{Enabled: true, RateLimit: types.RateLimitConfig{Requests: 1, Burst: 2}}
+	server := NewStatsServer(config, nil, NewStorageManager(10), nil)
+	handler := server.rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/health", nil)
+		req.RemoteAddr = "192.0.2.1:4321"
+		req.Header.Set("X-Forwarded-For", "192.0.2.100")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("Expected the third request to be limited, got %v", codes)
+	}
+}
+
+func TestCredentialsEqual(t *testing.T) {
+	if !credentialsEqual("monic123", "monic123") {
+		t.Error("Expected equal credentials to match")
+	}
+	if credentialsEqual("monic12", "monic123") || credentialsEqual("", "monic123") {
+		t.Error("Expected different credentials not to match")
+	}
+}
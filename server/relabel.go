@@ -0,0 +1,157 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"bconf.com/monic/types"
+)
+
+// metricNameLabel refers to the metric name in relabel rules, like in Prometheus
+const metricNameLabel = "__name__"
+
+// checkLabelNames are the check labels that can be exported besides the name
+var checkLabelNames = map[string]bool{"type": true, "url": true}
+
+// metricRule is a compiled relabel rule of the /metrics endpoint
+type metricRule struct {
+	action      string
+	source      string
+	regex       *regexp.Regexp
+	target      string
+	replacement string
+}
+
+// compileMetricRules validates the metrics configuration and compiles its relabel rules
+func compileMetricRules(config types.MetricsConfig) ([]metricRule, error) {
+	for _, label := range config.CheckLabels {
+		if !checkLabelNames[label] {
+			return nil, fmt.Errorf("unknown check label %q (supported: type, url)", label)
+		}
+	}
+
+	rules := make([]metricRule, 0, len(config.Relabel))
+	for i, entry := range config.Relabel {
+		pattern := entry.Regex
+		if pattern == "" {
+			pattern = ".*"
+		}
+		// Anchor the expression so it has to match the whole value
+		regex, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("metrics relabel rule %d: invalid regex %q: %w", i, entry.Regex, err)
+		}
+
+		rule := metricRule{
+			action:      entry.Action,
+			source:      entry.SourceLabel,
+			regex:       regex,
+			target:      entry.TargetLabel,
+			replacement: entry.Replacement,
+		}
+		switch rule.action {
+		case "drop", "keep":
+			if rule.source == "" {
+				return nil, fmt.Errorf("metrics relabel rule %d: %s requires a source label", i, rule.action)
+			}
+		case "replace":
+			if rule.source == "" || rule.target == "" {
+				return nil, fmt.Errorf("metrics relabel rule %d: replace requires a source and a target label", i)
+			}
+			if rule.target == metricNameLabel {
+				return nil, fmt.Errorf("metrics relabel rule %d: the metric name cannot be replaced", i)
+			}
+			if rule.replacement == "" {
+				rule.replacement = "$1"
+			}
+		case "labeldrop":
+		default:
+			return nil, fmt.Errorf("metrics relabel rule %d: unknown action %q (supported: drop, keep, replace, labeldrop)", i, rule.action)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// constLabels returns the configured labels added to every sample, sorted by name
+func constLabels(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, name, labels[name])
+	}
+	return pairs
+}
+
+// relabel applies the rules to the labels of a sample and reports whether the sample is kept
+func relabel(name string, labels []string, rules []metricRule) ([]string, bool) {
+	for _, rule := range rules {
+		switch rule.action {
+		case "drop":
+			if rule.regex.MatchString(labelValue(name, labels, rule.source)) {
+				return nil, false
+			}
+		case "keep":
+			if !rule.regex.MatchString(labelValue(name, labels, rule.source)) {
+				return nil, false
+			}
+		case "replace":
+			value := labelValue(name, labels, rule.source)
+			match := rule.regex.FindStringSubmatchIndex(value)
+			if match == nil {
+				continue
+			}
+			replaced := string(rule.regex.ExpandString(nil, rule.replacement, value, match))
+			labels = setLabel(labels, rule.target, replaced)
+		case "labeldrop":
+			kept := make([]string, 0, len(labels))
+			for i := 0; i+1 < len(labels); i += 2 {
+				if !rule.regex.MatchString(labels[i]) {
+					kept = append(kept, labels[i], labels[i+1])
+				}
+			}
+			labels = kept
+		}
+	}
+	return labels, true
+}
+
+// labelValue returns the value of a label (empty when missing)
+func labelValue(name string, labels []string, label string) string {
+	if label == metricNameLabel {
+		return name
+	}
+	for i := 0; i+1 < len(labels); i += 2 {
+		if labels[i] == label {
+			return labels[i+1]
+		}
+	}
+	return ""
+}
+
+// setLabel sets a label on a copy of the labels, keeping its position when it already exists;
+// an empty value removes the label
+func setLabel(labels []string, label, value string) []string {
+	updated := make([]string, 0, len(labels)+2)
+	found := false
+	for i := 0; i+1 < len(labels); i += 2 {
+		if labels[i] != label {
+			updated = append(updated, labels[i], labels[i+1])
+			continue
+		}
+		found = true
+		if value != "" {
+			updated = append(updated, label, value)
+		}
+	}
+	if !found && value != "" {
+		updated = append(updated, label, value)
+	}
+	return updated
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+const (
+	defaultRemoteWriteFlushInterval = 10
+	defaultRemoteWriteBatchSize     = 1000
+	defaultRemoteWriteBufferSize    = 10000
+	defaultRemoteWriteTimeout       = 10
+)
+
+// labelNamePattern matches valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// remoteSample is a single sample of a series pushed with remote write
+type remoteSample struct {
+	labels    []string // Name/value pairs sorted by name, including __name__
+	value     float64
+	timestamp int64 // Unix milliseconds
+}
+
+// RemoteWriteExporter pushes system stats and check results to a Prometheus remote write
+// receiver (Mimir, VictoriaMetrics, Thanos, Prometheus with --web.enable-remote-write-receiver).
+// Series have the same names as on /metrics. Samples are buffered and pushed in batches.
+type RemoteWriteExporter struct {
+	config *types.RemoteWriteConfig
+	labels []string // Added to every series unless the series sets the label itself
+	client *http.Client
+	writer *batchWriter[remoteSample]
+}
+
+// NewRemoteWriteExporter creates a remote write exporter; host is the default instance label
+func NewRemoteWriteExporter(config *types.RemoteWriteConfig, host string) *RemoteWriteExporter {
+	labels := map[string]string{"instance": host}
+	for name, value := range config.Labels {
+		labels[name] = value
+	}
+
+	e := &RemoteWriteExporter{
+		config: config,
+		labels: constLabels(labels),
+		client: &http.Client{Timeout: time.Duration(positiveOr(config.Timeout, defaultRemoteWriteTimeout)) * time.Second},
+	}
+	e.writer = newBatchWriter("remote_write", e.write,
+		time.Duration(positiveOr(config.FlushInterval, defaultRemoteWriteFlushInterval))*time.Second,
+		positiveOr(config.BatchSize, defaultRemoteWriteBatchSize),
+		positiveOr(config.BufferSize, defaultRemoteWriteBufferSize))
+	return e
+}
+
+// Name returns the subsystem name of the exporter
+func (e *RemoteWriteExporter) Name() string {
+	return "remote_write"
+}
+
+// Start validates the configuration and starts pushing. An unreachable receiver does not fail
+// startup: samples are buffered and the push is retried on the next flush.
+func (e *RemoteWriteExporter) Start() error {
+	u, err := url.Parse(e.config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid remote write URL %q", e.config.URL)
+	}
+	for i := 0; i < len(e.labels); i += 2 {
+		if !labelNamePattern.MatchString(e.labels[i]) {
+			return fmt.Errorf("invalid remote write label name %q", e.labels[i])
+		}
+	}
+
+	e.writer.start()
+	slog.Info("Remote write exporter started", "url", e.config.URL)
+	return nil
+}
+
+// Close pushes the buffered samples and stops the exporter
+func (e *RemoteWriteExporter) Close() {
+	e.writer.close()
+}
+
+// ExportSystemStats queues the CPU, load, memory, swap and disk usage samples of a collection
+func (e *RemoteWriteExporter) ExportSystemStats(stats types.SystemStats) {
+	ts := stats.Timestamp.UnixMilli()
+	samples := []remoteSample{
+		e.sample("monic_cpu_usage_percent", stats.CPUUsage, ts),
+		e.sample("monic_memory_usage_percent", stats.MemoryUsage.UsedPercent, ts),
+		e.sample("monic_swap_usage_percent", stats.SwapUsage.UsedPercent, ts),
+	}
+	if stats.LoadAverage.Cores > 0 {
+		samples = append(samples,
+			e.sample("monic_load_average", stats.LoadAverage.Load1, ts, "period", "1m"),
+			e.sample("monic_load_average", stats.LoadAverage.Load5, ts, "period", "5m"),
+			e.sample("monic_load_average", stats.LoadAverage.Load15, ts, "period", "15m"))
+	}
+	for path, disk := range stats.DiskUsage {
+		samples = append(samples, e.sample("monic_disk_usage_percent", disk.UsedPercent, ts, "path", path))
+	}
+	e.writer.add(samples...)
+}
+
+// ExportCheckResult queues the up, response time and numeric value samples of a check result
+func (e *RemoteWriteExporter) ExportCheckResult(result types.HTTPCheckResult) {
+	ts := result.Timestamp.UnixMilli()
+	checkType := strings.SplitN(checkKey(result), "/", 2)[0]
+	samples := []remoteSample{
+		e.sample("monic_check_up", boolToFloat(result.Success), ts, "name", result.Name, "type", checkType),
+		e.sample("monic_check_response_time_seconds", result.ResponseTime.Seconds(), ts, "name", result.Name, "type", checkType),
+	}
+	if value, err := strconv.ParseFloat(strings.TrimSpace(result.Value), 64); err == nil {
+		samples = append(samples, e.sample("monic_check_value", value, ts, "name", result.Name, "type", checkType))
+	}
+	e.writer.add(samples...)
+}
+
+// sample creates a sample with the exporter's labels and sorted label pairs
+func (e *RemoteWriteExporter) sample(name string, value float64, timestamp int64, labels ...string) remoteSample {
+	all := append([]string{metricNameLabel, name}, labels...)
+	for i := 0; i+1 < len(e.labels); i += 2 {
+		if labelValue(name, all, e.labels[i]) == "" {
+			all = append(all, e.labels[i], e.labels[i+1])
+		}
+	}
+
+	pairs := make([][2]string, 0, len(all)/2)
+	for i := 0; i+1 < len(all); i += 2 {
+		if all[i+1] != "" {
+			pairs = append(pairs, [2]string{all[i], all[i+1]})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+
+	sorted := make([]string, 0, 2*len(pairs))
+	for _, pair := range pairs {
+		sorted = append(sorted, pair[0], pair[1])
+	}
+	return remoteSample{labels: sorted, value: value, timestamp: timestamp}
+}
+
+// write pushes a batch of samples; a 4xx response other than 429 means the receiver refused them
+func (e *RemoteWriteExporter) write(samples []remoteSample) error {
+	body := snappy.Encode(nil, encodeWriteRequest(samples))
+	req, err := http.NewRequest(http.MethodPost, e.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for name, value := range e.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if e.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+e.config.BearerToken)
+	} else if e.config.Username != "" {
+		req.SetBasicAuth(e.config.Username, e.config.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push samples: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("remote write receiver returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", errRejected, err)
+	}
+	return err
+}
+
+// encodeWriteRequest encodes samples as a remote write 1.0 WriteRequest protobuf message with
+// one time series per sample:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(samples []remoteSample) []byte {
+	var request, series, message []byte
+	for _, s := range samples {
+		series = series[:0]
+		for i := 0; i+1 < len(s.labels); i += 2 {
+			message = protoAppendBytes(message[:0], 1, []byte(s.labels[i]))
+			message = protoAppendBytes(message, 2, []byte(s.labels[i+1]))
+			series = protoAppendBytes(series, 1, message)
+		}
+
+		message = binary.AppendUvarint(message[:0], 1<<3|1) // Fixed 64-bit
+		message = binary.LittleEndian.AppendUint64(message, math.Float64bits(s.value))
+		message = binary.AppendUvarint(message, 2<<3|0) // Varint
+		message = binary.AppendUvarint(message, uint64(s.timestamp))
+		series = protoAppendBytes(series, 2, message)
+
+		request = protoAppendBytes(request, 1, series)
+	}
+	return request
+}
+
+// protoAppendBytes appends a length-delimited protobuf field
+func protoAppendBytes(b []byte, field int, value []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
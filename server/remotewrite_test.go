@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+// protoFields splits a protobuf message into its length-delimited fields (by field number) and
+// the 64-bit and varint values of its other fields
+func protoFields(t *testing.T, b []byte) (map[int][][]byte, map[int]uint64) {
+	t.Helper()
+	messages, values := make(map[int][][]byte), make(map[int]uint64)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		b = b[n:]
+		field := int(tag >> 3)
+		switch tag & 7 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			values[field], b = v, b[n:]
+		case 1:
+			values[field], b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			messages[field] = append(messages[field], b[n:n+int(size)])
+			b = b[n+int(size):]
+		default:
+			t.Fatalf("Unexpected wire type %d", tag&7)
+		}
+	}
+	return messages, values
+}
+
+// decodedSeries is a time series decoded from a WriteRequest
+type decodedSeries struct {
+	labels    string // name=value pairs joined with commas
+	value     float64
+	timestamp int64
+}
+
+// decodeWriteRequest decodes the series of a snappy-compressed WriteRequest
+func decodeWriteRequest(t *testing.T, body []byte) []decodedSeries {
+	t.Helper()
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatalf("Failed to decompress request: %v", err)
+	}
+
+	var result []decodedSeries
+	request, _ := protoFields(t, data)
+	for _, series := range request[1] {
+		fields, _ := protoFields(t, series)
+		var labels []string
+		for _, label := range fields[1] {
+			pair, _ := protoFields(t, label)
+			labels = append(labels, string(pair[1][0])+"="+string(pair[2][0]))
+		}
+		_, sample := protoFields(t, fields[2][0])
+		result = append(result, decodedSeries{
+			labels:    strings.Join(labels, ","),
+			value:     math.Float64frombits(sample[1]),
+			timestamp: int64(sample[2]),
+		})
+	}
+	return result
+}
+
+func TestRemoteWriteExporter_Write(t *testing.T) {
+	var requests []*http.Request
+	var bodies [][]byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	config := &types.RemoteWriteConfig{
+		URL:           ts.URL + "/api/v1/push",
+		BearerToken:   "secret",
+		Headers:       map[string]string{"X-Scope-OrgID": "tenant-1"},
+		Labels:        map[string]string{"env": "prod"},
+		FlushInterval: 3600,
+	}
+	exporter := NewRemoteWriteExporter(config, "web-01")
+	if err := exporter.Start(); err != nil {
+		t.Fatalf("Failed to start exporter: %v", err)
+	}
+	now := time.UnixMilli(1700000000123)
+	exporter.ExportSystemStats(types.SystemStats{Timestamp: now, CPUUsage: 12.5, DiskUsage: map[string]types.DiskStats{"/": {UsedPercent: 80}}})
+	exporter.ExportCheckResult(types.HTTPCheckResult{Name: "db", Type: "snmp", Success: true, ResponseTime: 250 * time.Millisecond, Value: "42", Timestamp: now})
+	exporter.Close()
+
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 push, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.URL.Path != "/api/v1/push" || req.Header.Get("Content-Encoding") != "snappy" || req.Header.Get("Content-Type") != "application/x-protobuf" || req.Header.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" {
+		t.Errorf("Unexpected request %s with headers %v", req.URL, req.Header)
+	}
+	if req.Header.Get("Authorization") != "Bearer secret" || req.Header.Get("X-Scope-OrgID") != "tenant-1" {
+		t.Errorf("Expected bearer token and tenant headers, got %v", req.Header)
+	}
+
+	series := decodeWriteRequest(t, bodies[0])
+	want := map[string]float64{
+		"__name__=monic_cpu_usage_percent,env=prod,instance=web-01":                             12.5,
+		"__name__=monic_disk_usage_percent,env=prod,instance=web-01,path=/":                     80,
+		"__name__=monic_check_up,env=prod,instance=web-01,name=db,type=snmp":                    1,
+		"__name__=monic_check_response_time_seconds,env=prod,instance=web-01,name=db,type=snmp": 0.25,
+		"__name__=monic_check_value,env=prod,instance=web-01,name=db,type=snmp":                 42,
+	}
+	found := 0
+	for _, s := range series {
+		if s.timestamp != now.UnixMilli() {
+			t.Errorf("Expected timestamp %d, got %d for %s", now.UnixMilli(), s.timestamp, s.labels)
+		}
+		if value, exists := want[s.labels]; exists {
+			found++
+			if s.value != value {
+				t.Errorf("Expected %g for %s, got %g", value, s.labels, s.value)
+			}
+		}
+	}
+	if found != len(want) || len(series) != 7 {
+		t.Errorf("Expected the wanted series among 7, got %+v", series)
+	}
+}
+
+func TestRemoteWriteExporter_Rejected(t *testing.T) {
+	status := http.StatusTooManyRequests
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", status)
+	}))
+	defer ts.Close()
+
+	exporter := NewRemoteWriteExporter(&types.RemoteWriteConfig{URL: ts.URL}, "web-01")
+	exporter.ExportCheckResult(types.HTTPCheckResult{Name: "api"})
+
+	// Rate limited: kept for a retry
+	if exporter.writer.flush() || exporter.writer.pending() != 2 {
+		t.Fatalf("Expected 2 samples kept after 429, got %d", exporter.writer.pending())
+	}
+	status = http.StatusBadRequest
+	if !exporter.writer.flush() || exporter.writer.pending() != 0 {
+		t.Errorf("Expected rejected samples to be dropped, got %d", exporter.writer.pending())
+	}
+}
+
+func TestRemoteWriteExporter_StartValidation(t *testing.T) {
+	for _, config := range []types.RemoteWriteConfig{
+		{URL: "mimir:9009/api/v1/push"},
+		{URL: "http://mimir:9009/api/v1/push", Labels: map[string]string{"data-center": "eu"}},
+	} {
+		if err := NewRemoteWriteExporter(&config, "web-01").Start(); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// maxPendingResults is the number of buffered check results that are written right away instead
+// of at the next flush
+const maxPendingResults = 1000
+
+// resultBuffer collects the check results of all check loops and writes them to storage in one
+// batch, so many checks finishing at once take the storage lock, or open a transaction, once
+// instead of once per result
+type resultBuffer struct {
+	storage Storage
+	full    chan struct{} // Signaled when maxPendingResults are waiting
+
+	mu      sync.Mutex
+	pending []types.HTTPCheckResult
+	flushMu sync.Mutex // Keeps batches in the order they were taken
+}
+
+// newResultBuffer creates an empty buffer writing to storage
+func newResultBuffer(storage Storage) *resultBuffer {
+	return &resultBuffer{storage: storage, full: make(chan struct{}, 1)}
+}
+
+// add queues results for the next flush
+func (b *resultBuffer) add(results []types.HTTPCheckResult) {
+	b.mu.Lock()
+	b.pending = append(b.pending, results...)
+	full := len(b.pending) >= maxPendingResults
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush writes the queued results in one batch and returns how many there were
+func (b *resultBuffer) flush() int {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	b.storage.AddHTTPCheckResults(batch)
+	return len(batch)
+}
+
+// storeResults adds check results to history, through the result buffer when writes are batched
+func (ms *MonitorService) storeResults(results []types.HTTPCheckResult) {
+	if ms.results != nil {
+		ms.results.add(results)
+		return
+	}
+	ms.storage.AddHTTPCheckResults(results)
+}
+
+// resultFlushLoop writes the buffered check results on every interval, and early when the buffer
+// fills up. Results still buffered when the service stops are written by Stop.
+func (ms *MonitorService) resultFlushLoop(interval time.Duration) {
+	defer ms.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ms.stopChan:
+			return
+		case <-ticker.C:
+		case <-ms.results.full:
+		}
+		if n := ms.results.flush(); n > 0 {
+			slog.Debug("Check results written", "count", n)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestMonitorService_StoreResultsBuffered(t *testing.T) {
+	config := &types.Config{Storage: types.StorageConfig{FlushInterval: 60000}}
+	service := createTestMonitorService(t, config)
+
+	service.recordCheckResults("Test", []types.HTTPCheckResult{
+		{Name: "api", Success: true, Timestamp: time.Now()},
+		{Name: "db", Type: "grpc", Success: true, Timestamp: time.Now()},
+	})
+	if n := len(service.storage.GetHTTPCheckResults()); n != 0 {
+		t.Fatalf("Expected results to wait for the flush, got %d stored", n)
+	}
+
+	// Stopping writes what is still buffered
+	service.Stop()
+	if results := service.storage.GetHTTPCheckResults(); len(results) != 2 || results[0].Name != "api" {
+		t.Errorf("Expected both results after stopping, got %+v", results)
+	}
+}
+
+func TestResultBuffer_FullSignal(t *testing.T) {
+	storage := NewStorageManager(2 * maxPendingResults)
+	buffer := newResultBuffer(storage)
+
+	buffer.add(make([]types.HTTPCheckResult, maxPendingResults-1))
+	select {
+	case <-buffer.full:
+		t.Fatal("Expected no signal below the limit")
+	default:
+	}
+	buffer.add(make([]types.HTTPCheckResult, 1))
+	select {
+	case <-buffer.full:
+	default:
+		t.Fatal("Expected a signal once the buffer is full")
+	}
+
+	if n := buffer.flush(); n != maxPendingResults || len(storage.GetHTTPCheckResults()) != maxPendingResults {
+		t.Errorf("Expected %d results written in one flush, got %d", maxPendingResults, n)
+	}
+	if n := buffer.flush(); n != 0 {
+		t.Errorf("Expected an empty buffer after the flush, got %d", n)
+	}
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// purgeHistory purges a history type and returns the audit entry describing the purge
+func purgeHistory(storage Storage, dataType string, before time.Time, trigger, actor string) (types.PurgeAuditEntry, error) {
+	deleted, err := storage.PurgeHistory(dataType, before)
+	if err != nil {
+		return types.PurgeAuditEntry{}, err
+	}
+
+	return types.PurgeAuditEntry{
+		Timestamp: time.Now(),
+		DataType:  dataType,
+		Before:    before,
+		Deleted:   deleted,
+		Trigger:   trigger,
+		Actor:     actor,
+	}, nil
+}
+
+// recordPurge stores and logs an audit entry for a purge
+func recordPurge(storage Storage, entry types.PurgeAuditEntry) {
+	storage.AddAuditEntry(entry)
+	slog.Info("History purged",
+		"audit", true,
+		"type", entry.DataType,
+		"deleted", entry.Deleted,
+		"before", entry.Before,
+		"trigger", entry.Trigger,
+		"actor", entry.Actor)
+}
+
+// compactStorage reclaims the space of purged entries in storage backends that need it
+func compactStorage(storage Storage) {
+	c, ok := storage.(compacter)
+	if !ok {
+		return
+	}
+	before, after, err := c.Compact(false)
+	if err != nil {
+		slog.Error("Failed to compact storage", "error", err)
+		return
+	}
+	if after < before {
+		slog.Info("Storage compacted", "size_before", before, "size_after", after)
+	}
+}
+
+// retentionPolicies returns the configured retention period per history type (only enabled ones)
+func retentionPolicies(config *types.RetentionConfig) map[string]time.Duration {
+	policies := make(map[string]time.Duration)
+	days := map[string]int{
+		HistoryAlerts:      config.AlertsDays,
+		HistorySystemStats: config.StatsDays,
+		HistoryChecks:      config.ChecksDays,
+		HistoryDocker:      config.DockerDays,
+		HistoryEvents:      config.EventsDays,
+	}
+	for dataType, d := range days {
+		if d > 0 {
+			policies[dataType] = time.Duration(d) * 24 * time.Hour
+		}
+	}
+	return policies
+}
+
+// retentionLoop periodically purges history older than the configured retention periods
+func (ms *MonitorService) retentionLoop() {
+	defer ms.wg.Done()
+
+	interval := ms.config.Storage.Retention.Interval
+	if interval <= 0 {
+		interval = 60 // Default to 60 minutes
+	}
+
+	// Apply retention right away so a restart doesn't delay purging
+	ms.applyRetention()
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ms.stopChan:
+			return
+		case <-ticker.C:
+			ms.runCycle("retention", time.Duration(interval)*time.Minute, ms.applyRetention)
+		}
+	}
+}
+
+// applyRetention purges expired history; an audit entry is recorded whenever entries were removed
+func (ms *MonitorService) applyRetention() {
+	if len(pruneHistory(ms.storage, &ms.config.Storage.Retention, "retention", "system")) > 0 {
+		compactStorage(ms.storage)
+	}
+}
+
+// pruneHistory purges history older than the retention policies and returns the audit entries of
+// the purges that removed entries
+func pruneHistory(storage Storage, config *types.RetentionConfig, trigger, actor string) []types.PurgeAuditEntry {
+	now := time.Now()
+	pruned := make([]types.PurgeAuditEntry, 0)
+	for dataType, period := range retentionPolicies(config) {
+		entry, err := purgeHistory(storage, dataType, now.Add(-period), trigger, actor)
+		if err != nil {
+			slog.Error("Failed to apply retention policy", "type", dataType, "error", err)
+			continue
+		}
+		if entry.Deleted > 0 {
+			recordPurge(storage, entry)
+			pruned = append(pruned, entry)
+		}
+	}
+	return pruned
+}
+
+// handlePurgeHistory handles DELETE /api/v1/history and DELETE /api/v1/history/{type}.
+// Without parameters all entries are purged; "before" (RFC3339) or "older_than" (e.g. 720h)
+// limit the purge to older entries.
+func (s *StatsServer) handlePurgeHistory(w http.ResponseWriter, r *http.Request) {
+	dataTypes := HistoryTypes
+	if dataType := r.PathValue("type"); dataType != "" {
+		if !isHistoryType(dataType) {
+			http.Error(w, fmt.Sprintf("Unknown history type: %s", dataType), http.StatusNotFound)
+			return
+		}
+		dataTypes = []string{dataType}
+	}
+
+	before, err := parsePurgeBefore(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actor := requestActor(r)
+
+	purged := make([]types.PurgeAuditEntry, 0, len(dataTypes))
+	total := 0
+	for _, dataType := range dataTypes {
+		entry, err := purgeHistory(s.storage, dataType, before, "api", actor)
+		if err != nil {
+			slog.Error("Error purging history", "type", dataType, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		recordPurge(s.storage, entry)
+		purged = append(purged, entry)
+		total += entry.Deleted
+	}
+
+	if total > 0 {
+		compactStorage(s.storage)
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"purged":        purged,
+		"total_deleted": total,
+	})
+}
+
+// handleStorageStatus handles GET /api/v1/storage: the driver, entry counts and sizes per history type
+func (s *StatsServer) handleStorageStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.storage.GetStatus())
+}
+
+// handleCompactStorage handles POST /api/v1/storage/compact: reclaims the free space of the
+// storage file right away instead of waiting for a purge to leave enough of it. With
+// "prune=true" history older than the retention policies is purged first.
+func (s *StatsServer) handleCompactStorage(w http.ResponseWriter, r *http.Request) {
+	prune := false
+	if value := r.URL.Query().Get("prune"); value != "" {
+		var err error
+		if prune, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "invalid prune parameter, expected true or false: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+
+	pruned := make([]types.PurgeAuditEntry, 0)
+	if prune && s.service != nil {
+		pruned = pruneHistory(s.storage, &s.service.config.Storage.Retention, "api", requestActor(r))
+	}
+
+	response := map[string]interface{}{
+		"pruned":    pruned,
+		"compacted": false,
+	}
+	if c, ok := s.storage.(compacter); ok {
+		before, after, err := c.Compact(true)
+		if err != nil {
+			slog.Error("Error compacting storage", "error", err)
+			http.Error(w, "Failed to compact storage: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		slog.Info("Storage compacted", "audit", true, "size_before", before, "size_after", after, "actor", requestActor(r))
+		response["compacted"] = true
+		response["size_before_bytes"] = before
+		response["size_after_bytes"] = after
+	}
+	response["storage"] = s.storage.GetStatus()
+	writeJSON(w, response)
+}
+
+// handlePurgeAudit handles GET /api/v1/history/audit
+func (s *StatsServer) handlePurgeAudit(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"entries": s.storage.GetAuditEntries(),
+	})
+}
+
+// parsePurgeBefore reads the purge cutoff from the "before" or "older_than" query parameter
+func parsePurgeBefore(r *http.Request) (time.Time, error) {
+	query := r.URL.Query()
+
+	if before := query.Get("before"); before != "" {
+		t, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid before parameter, expected RFC3339 time: %s", before)
+		}
+		return t, nil
+	}
+
+	if olderThan := query.Get("older_than"); olderThan != "" {
+		d, err := time.ParseDuration(olderThan)
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid older_than parameter, expected positive duration: %s", olderThan)
+		}
+		return time.Now().Add(-d), nil
+	}
+
+	return time.Time{}, nil
+}
+
+// isHistoryType checks if a data type is a known history type
+func isHistoryType(dataType string) bool {
+	for _, t := range HistoryTypes {
+		if t == dataType {
+			return true
+		}
+	}
+	return false
+}
+
+// requestActor identifies who made an API request: the API key, basic auth user or remote address
+func requestActor(r *http.Request) string {
+	if keyName, ok := r.Context().Value(apiKeyContextKey{}).(string); ok {
+		return "apikey:" + keyName
+	}
+	if username, _, ok := r.BasicAuth(); ok {
+		return username
+	}
+	return r.RemoteAddr
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Error encoding JSON response", "error", err)
+	}
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestStorage_PurgeHistory(t *testing.T) {
+	bolt, err := NewBoltStorage(filepath.Join(t.TempDir(), "monic.db"), 100)
+	if err != nil {
+		t.Fatalf("Failed to open bolt storage: %v", err)
+	}
+	defer bolt.Close()
+
+	backends := map[string]Storage{
+		"memory": NewStorageManager(100),
+		"bolt":   bolt,
+	}
+
+	for name, storage := range backends {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "old", Timestamp: now.Add(-48 * time.Hour)})
+			storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "new", Timestamp: now})
+			storage.AddAlerts([]types.Alert{{Type: "cpu", Timestamp: now}, {Type: "disk", Timestamp: now}})
+
+			deleted, err := storage.PurgeHistory(HistoryChecks, now.Add(-24*time.Hour))
+			if err != nil || deleted != 1 {
+				t.Fatalf("Expected 1 deleted check result, got %d (err: %v)", deleted, err)
+			}
+			results := storage.GetHTTPCheckResults()
+			if len(results) != 1 || results[0].Name != "new" {
+				t.Errorf("Expected only the new result to remain, got %+v", results)
+			}
+
+			// Zero time purges everything
+			deleted, err = storage.PurgeHistory(HistoryAlerts, time.Time{})
+			if err != nil || deleted != 2 || storage.GetAlertsCount() != 0 {
+				t.Errorf("Expected all alerts to be purged, deleted %d (err: %v)", deleted, err)
+			}
+
+			if _, err := storage.PurgeHistory("unknown", time.Time{}); err == nil {
+				t.Error("Expected error for unknown history type")
+			}
+		})
+	}
+}
+
+func TestMonitorService_ApplyRetention(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		Storage:      types.StorageConfig{Retention: types.RetentionConfig{StatsDays: 7, ChecksDays: 30}},
+	}
+	service := createTestMonitorService(t, config)
+
+	now := time.Now()
+	service.storage.AddSystemStats(types.SystemStats{Timestamp: now.AddDate(0, 0, -8)})
+	service.storage.AddSystemStats(types.SystemStats{Timestamp: now})
+	service.storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Timestamp: now.AddDate(0, 0, -8)})
+
+	service.applyRetention()
+
+	status := service.storage.GetStatus()
+	if status["stats_history_count"] != 1 {
+		t.Errorf("Expected expired system stats to be purged, got %v", status["stats_history_count"])
+	}
+	if status["http_history_count"] != 1 {
+		t.Errorf("Expected check results within retention to be kept, got %v", status["http_history_count"])
+	}
+
+	entries := service.storage.GetAuditEntries()
+	if len(entries) != 1 || entries[0].DataType != HistorySystemStats || entries[0].Deleted != 1 || entries[0].Trigger != "retention" {
+		t.Errorf("Expected a single retention audit entry for system stats, got %+v", entries)
+	}
+}
+
+func TestStatsServer_PurgeHistoryAPI(t *testing.T) {
+	config := &types.HTTPServerConfig{Enabled: true, Port: 8080, Username: "admin", Password: "secret"}
+	storage := NewStorageManager(100)
+	server := NewStatsServer(config, nil, storage, nil)
+	mux := server.routes()
+
+	now := time.Now()
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "old", Timestamp: now.Add(-2 * time.Hour)})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "new", Timestamp: now})
+	storage.AddSystemStats(types.SystemStats{Timestamp: now})
+
+	// Purge check results older than one hour
+	req := httptest.NewRequest("DELETE", "/api/v1/history/checks?older_than=1h", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Purged       []types.PurgeAuditEntry `json:"purged"`
+		TotalDeleted int                     `json:"total_deleted"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.TotalDeleted != 1 || len(response.Purged) != 1 || response.Purged[0].Actor != "admin" {
+		t.Errorf("Unexpected purge response: %+v", response)
+	}
+
+	// Purge everything
+	req = httptest.NewRequest("DELETE", "/api/v1/history", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if storage.GetLatestSystemStats() != nil || len(storage.GetHTTPCheckResults()) != 0 {
+		t.Error("Expected all history to be purged")
+	}
+
+	// Audit trail lists every purged type
+	req = httptest.NewRequest("GET", "/api/v1/history/audit", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var audit struct {
+		Entries []types.PurgeAuditEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&audit); err != nil {
+		t.Fatalf("Failed to decode audit response: %v", err)
+	}
+	if len(audit.Entries) != 1+len(HistoryTypes) {
+		t.Errorf("Expected %d audit entries, got %d", 1+len(HistoryTypes), len(audit.Entries))
+	}
+
+	// Invalid requests
+	for path, expected := range map[string]int{
+		"/api/v1/history/unknown":                 http.StatusNotFound,
+		"/api/v1/history/checks?before=yesterday": http.StatusBadRequest,
+	} {
+		req = httptest.NewRequest("DELETE", path, nil)
+		req.SetBasicAuth("admin", "secret")
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("%s: expected status code %d, got %d", path, expected, w.Code)
+		}
+	}
+
+	// Authentication is required
+	req = httptest.NewRequest("DELETE", "/api/v1/history", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d without credentials, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestStatsServer_CompactStorageAPI(t *testing.T) {
+	config := &types.Config{
+		HTTPServer: types.HTTPServerConfig{Enabled: true, Port: 8080, Username: "admin", Password: "secret"},
+		Storage:    types.StorageConfig{Retention: types.RetentionConfig{ChecksDays: 1}},
+	}
+	service := createTestMonitorService(t, config)
+	storage, err := NewBoltStorage(filepath.Join(t.TempDir(), "monic.db"), 100)
+	if err != nil {
+		t.Fatalf("Failed to open bolt storage: %v", err)
+	}
+	defer storage.Close()
+	service.storage = storage
+	service.statsServer.storage = storage
+	mux := service.statsServer.routes()
+
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "old", Timestamp: time.Now().AddDate(0, 0, -2)})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "new", Timestamp: time.Now()})
+
+	req := httptest.NewRequest("POST", "/api/v1/storage/compact?prune=true", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Pruned    []types.PurgeAuditEntry `json:"pruned"`
+		Compacted bool                    `json:"compacted"`
+		After     int64                   `json:"size_after_bytes"`
+		Storage   map[string]interface{}  `json:"storage"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Pruned) != 1 || response.Pruned[0].Deleted != 1 || response.Pruned[0].Trigger != "api" || response.Pruned[0].Actor != "admin" {
+		t.Errorf("Expected the expired check result to be pruned, got %+v", response.Pruned)
+	}
+	if !response.Compacted || response.After <= 0 || response.Storage["http_history_count"] != float64(1) {
+		t.Errorf("Expected a compacted database with one check result, got %+v", response)
+	}
+
+	// Storage status
+	req = httptest.NewRequest("GET", "/api/v1/storage", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var status map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil || status["driver"] != "bolt" || status["size_bytes"] == nil {
+		t.Errorf("Unexpected storage status: %v (%v)", status, err)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/storage/compact?prune=maybe", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid prune parameter, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule decides when a subsystem's next check cycle runs
+type schedule interface {
+	// Next returns the first run time after the given time
+	Next(after time.Time) time.Time
+	String() string
+}
+
+// newSchedule returns a cron schedule for a non-empty spec and a fixed interval otherwise
+func newSchedule(spec string, interval time.Duration) (schedule, error) {
+	if strings.TrimSpace(spec) == "" {
+		return intervalSchedule(interval), nil
+	}
+	return parseCron(spec)
+}
+
+// withJitter delays every run of a schedule by a random time up to max, if max is positive
+func withJitter(sched schedule, max time.Duration) schedule {
+	if max <= 0 {
+		return sched
+	}
+	return jitterSchedule{schedule: sched, max: max}
+}
+
+// jitterSchedule spreads the runs of checks on the same schedule, so they don't all hit their
+// targets at the same moment. Next returns the run times of the underlying schedule, which the
+// random delay is added to when a run is due, so the delays don't add up over time.
+type jitterSchedule struct {
+	schedule
+	max time.Duration
+}
+
+// delay returns a random delay for one run
+func (s jitterSchedule) delay() time.Duration {
+	return rand.N(s.max)
+}
+
+// String returns the underlying schedule with the jitter, e.g. "every 30s, jitter 5s"
+func (s jitterSchedule) String() string {
+	return s.schedule.String() + ", jitter " + s.max.String()
+}
+
+// intervalSchedule runs a cycle at a fixed interval
+type intervalSchedule time.Duration
+
+// Next returns the time one interval after the given time
+func (s intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(s))
+}
+
+// String returns the interval, e.g. "every 30s"
+func (s intervalSchedule) String() string {
+	return "every " + time.Duration(s).String()
+}
+
+// cronMacros are the supported shorthand cron expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule runs a cycle at times matching a standard 5-field cron expression
+// (minute hour day-of-month month day-of-week), evaluated in local time
+type cronSchedule struct {
+	spec   string
+	minute uint64 // Bit sets of allowed values
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	domAny bool // Day of month is "*"
+	dowAny bool // Day of week is "*"
+}
+
+// cronField describes the valid range of a cron field
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 0 and 7 are Sunday
+}
+
+// parseCron parses a 5-field cron expression or one of the @ macros (e.g. @daily)
+func parseCron(spec string) (*cronSchedule, error) {
+	expr := strings.TrimSpace(spec)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day-of-month month day-of-week)", spec)
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+		bits[i] = set
+	}
+
+	// Sunday may be written as 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	sched := &cronSchedule{
+		spec:   strings.TrimSpace(spec),
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}
+	if sched.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid cron expression %q: never matches", spec)
+	}
+	return sched, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges (a-b) and steps (*/n, a-b/n)
+func parseCronField(field string, def cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", def.name, stepPart)
+			}
+			step = n
+		}
+
+		low, high := def.min, def.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = cronValue(from, def); err != nil {
+				return 0, err
+			}
+			if high, err = cronValue(to, def); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("%s: invalid range %q", def.name, rangePart)
+			}
+		default:
+			value, err := cronValue(rangePart, def)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if !hasStep {
+				high = value
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// cronValue parses a single numeric cron value within the field's range
+func cronValue(value string, def cronField) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < def.min || n > def.max {
+		return 0, fmt.Errorf("%s: value %q out of range %d-%d", def.name, value, def.min, def.max)
+	}
+	return n, nil
+}
+
+// Next returns the first matching minute after the given time
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+
+	// Every valid expression matches within a few years (Feb 29 needs up to 8)
+	limit := t.AddDate(9, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron day rule: when both day of month and day of week are
+// restricted, a day matching either one matches
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// String returns the cron expression as configured
+func (s *cronSchedule) String() string {
+	return s.spec
+}
+
+// maxLoopRestarts bounds how often the watchdog replaces a stalled loop, since every restart
+// leaves the hung goroutine behind
+const maxLoopRestarts = 3
+
+// loopRun is a running schedule loop. Restarting it starts a new generation; the goroutine of
+// the previous one exits once its hung cycle returns.
+type loopRun struct {
+	sched      schedule
+	collect    func()
+	generation int
+	restarts   int
+}
+
+// scheduleLoop runs a subsystem's check cycle on its schedule until the service stops.
+// Like a ticker, runs that were missed because a cycle overran are skipped. Jitter delays a
+// run without moving the following ones. Loops of a single
+// check are named <subsystem>/<check> (e.g. http/api) and pause with their subsystem.
+func (ms *MonitorService) scheduleLoop(subsystem string, sched schedule, collect func()) {
+	ms.loopsMu.Lock()
+	run := &loopRun{sched: sched, collect: collect}
+	if previous, exists := ms.loops[subsystem]; exists {
+		run.generation = previous.generation + 1
+	}
+	ms.loops[subsystem] = run
+	ms.loopsMu.Unlock()
+
+	ms.runLoop(subsystem, sched, collect, run.generation)
+}
+
+// restartLoop replaces a stalled schedule loop with a new one. The new loop takes over the
+// loop's place in the wait group, so stopping the service doesn't wait for the hung cycle.
+// Loops restarted maxLoopRestarts times already are left alone.
+func (ms *MonitorService) restartLoop(subsystem string) error {
+	ms.loopsMu.Lock()
+	defer ms.loopsMu.Unlock()
+
+	run, exists := ms.loops[subsystem]
+	if !exists {
+		return fmt.Errorf("not a scheduled loop")
+	}
+	if run.restarts >= maxLoopRestarts {
+		return fmt.Errorf("restarted %d times already", run.restarts)
+	}
+	run.generation++
+	run.restarts++
+	go ms.runLoop(subsystem, run.sched, run.collect, run.generation)
+	return nil
+}
+
+// isCurrent reports whether a loop goroutine belongs to the current generation of its loop
+func (ms *MonitorService) isCurrent(subsystem string, generation int) bool {
+	ms.loopsMu.Lock()
+	defer ms.loopsMu.Unlock()
+
+	run, exists := ms.loops[subsystem]
+	return !exists || run.generation == generation
+}
+
+// runLoop runs the cycles of one generation of a schedule loop, until the service stops or the
+// loop is restarted
+func (ms *MonitorService) runLoop(subsystem string, sched schedule, collect func(), generation int) {
+	defer func() {
+		if ms.isCurrent(subsystem, generation) {
+			ms.wg.Done()
+		}
+	}()
+
+	next := sched.Next(time.Now())
+	for {
+		runAt := next
+		if jitter, ok := sched.(jitterSchedule); ok {
+			runAt = next.Add(jitter.delay())
+		}
+		ms.cycles.scheduled(subsystem, sched.String(), runAt)
+
+		timer := time.NewTimer(time.Until(runAt))
+		select {
+		case <-ms.stopChan:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// The interval used for overrun detection is the gap to the following run
+		following := sched.Next(next)
+		if !ms.startup.isPaused(loopSubsystem(subsystem)) {
+			ms.runCycle(subsystem, following.Sub(next), collect)
+		}
+		if !ms.isCurrent(subsystem, generation) {
+			// Replaced while the cycle hung
+			return
+		}
+
+		next = following
+		if now := time.Now(); !next.After(now) {
+			next = sched.Next(now)
+		}
+	}
+}
+
+// loopSubsystem returns the subsystem a schedule loop belongs to, e.g. "http" for "http/api"
+func loopSubsystem(loop string) string {
+	subsystem, _, _ := strings.Cut(loop, "/")
+	return subsystem
+}
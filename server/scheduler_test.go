@@ -0,0 +1,219 @@
+package server
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	// Friday, 16 October 2026
+	from := time.Date(2026, 10, 16, 7, 15, 20, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"30 6 * * *", time.Date(2026, 10, 17, 6, 30, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2026, 10, 16, 7, 20, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 1", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}, // Day of month or day of week
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sched, err := parseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.spec, err)
+			}
+			if got := sched.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Expected next run %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"0 0 30 2 *",
+		"@sometimes",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestMonitorService_BuildSchedules(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{Interval: 10, CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPChecks:   []types.HTTPCheck{{Schedule: "30 6 * * *"}, {Name: "api", CheckInterval: 15}},
+	}
+	service := createTestMonitorService(t, config)
+
+	schedules, err := service.buildSchedules()
+	if err != nil {
+		t.Fatalf("Failed to build schedules: %v", err)
+	}
+	if got := schedules["system"].String(); got != "every 10s" {
+		t.Errorf("Expected the system interval schedule, got %s", got)
+	}
+	if got := schedules["http"].String(); got != "30 6 * * *" {
+		t.Errorf("Expected the HTTP cron schedule, got %s", got)
+	}
+	if got := schedules["http/api"].String(); got != "every 15s" {
+		t.Errorf("Expected the interval of the named HTTP check, got %s", got)
+	}
+
+	// Jitter spreads the checks, with the check's own jitter over the global one
+	config.CheckJitter = 2000
+	config.HTTPChecks[1].Jitter = 500
+	schedules, err = service.buildSchedules()
+	if err != nil {
+		t.Fatalf("Failed to build schedules: %v", err)
+	}
+	if got := schedules["system"].String(); got != "every 10s" {
+		t.Errorf("Expected no jitter of the system collection, got %s", got)
+	}
+	if got := schedules["http"].String(); got != "30 6 * * *, jitter 2s" {
+		t.Errorf("Expected the global jitter, got %s", got)
+	}
+	if got := schedules["http/api"].String(); got != "every 15s, jitter 500ms" {
+		t.Errorf("Expected the jitter of the check, got %s", got)
+	}
+
+	config.DockerChecks.Schedule = "every day"
+	if _, err := service.buildSchedules(); err == nil {
+		t.Error("Expected error for an invalid docker schedule")
+	}
+}
+
+func TestMonitorService_ScheduleLoopReportsNextRun(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+	}
+	service := createTestMonitorService(t, config)
+
+	runs := make(chan struct{}, 10)
+	service.wg.Add(1)
+	go service.scheduleLoop("backup", intervalSchedule(20*time.Millisecond), func() { runs <- struct{}{} })
+
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the scheduled cycle to run")
+	}
+	close(service.stopChan)
+	service.wg.Wait()
+
+	cycles := service.cycles.snapshot()
+	if len(cycles) != 1 || cycles[0].Schedule != "every 20ms" || cycles[0].NextRun.IsZero() || cycles[0].Runs == 0 {
+		t.Errorf("Expected the schedule and next run in the cycle stats, got %+v", cycles)
+	}
+}
+
+func TestMonitorService_ScheduleLoopPausedWithSubsystem(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+	}
+	service := createTestMonitorService(t, config)
+	service.startup.set("http", subsystemRunning, nil)
+	if _, err := service.startup.setPaused("http", true); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := make(chan struct{}, 10)
+	service.wg.Add(1)
+	go service.scheduleLoop("http/api", intervalSchedule(10*time.Millisecond), func() { runs <- struct{}{} })
+	time.Sleep(100 * time.Millisecond)
+	close(service.stopChan)
+	service.wg.Wait()
+
+	if len(runs) != 0 {
+		t.Errorf("Expected no cycles of a check loop while its subsystem is paused, got %d", len(runs))
+	}
+}
+
+func TestMonitorService_RestartStalledLoop(t *testing.T) {
+	config := &types.Config{Watchdog: types.WatchdogConfig{Restart: true}}
+	service := createTestMonitorService(t, config)
+
+	// The first cycle hangs until the end of the test, the following ones run
+	hung := make(chan struct{})
+	runs := make(chan struct{}, 100)
+	var calls atomic.Int32
+	service.wg.Add(1)
+	go service.scheduleLoop("docker", intervalSchedule(10*time.Millisecond), func() {
+		if calls.Add(1) == 1 {
+			<-hung
+			return
+		}
+		runs <- struct{}{}
+	})
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Pretend the cycle has been hanging for an hour
+	service.cycles.begin("docker", 10*time.Millisecond, time.Now().Add(-time.Hour))
+	service.checkLoops()
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the restarted loop to run cycles")
+	}
+	if cycles := service.cycles.snapshot(); len(cycles) != 1 || cycles[0].Restarts != 1 {
+		t.Errorf("Expected one restart in the cycle stats, got %+v", cycles)
+	}
+
+	// Stopping doesn't wait for the hung cycle
+	close(service.stopChan)
+	done := make(chan struct{})
+	go func() {
+		service.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the service to stop while the abandoned cycle hangs")
+	}
+	close(hung)
+
+	service.loops["docker"].restarts = maxLoopRestarts
+	if err := service.restartLoop("docker"); err == nil {
+		t.Error("Expected no restart beyond the limit")
+	}
+	if err := service.restartLoop("alerting"); err == nil {
+		t.Error("Expected no restart of a loop that isn't scheduled")
+	}
+}
+
+func TestJitterSchedule(t *testing.T) {
+	if sched := withJitter(intervalSchedule(time.Minute), 0); sched != intervalSchedule(time.Minute) {
+		t.Errorf("Expected no jitter to keep the schedule, got %v", sched)
+	}
+
+	sched := withJitter(intervalSchedule(time.Minute), 10*time.Second).(jitterSchedule)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Runs stay on the interval, the delay is added to each run on its own
+	if next := sched.Next(start); !next.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected the next run one interval later, got %v", next)
+	}
+	for i := 0; i < 100; i++ {
+		if delay := sched.delay(); delay < 0 || delay >= 10*time.Second {
+			t.Fatalf("Expected a delay below the jitter, got %v", delay)
+		}
+	}
+}
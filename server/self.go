@@ -0,0 +1,205 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+
+	"bconf.com/monic/types"
+)
+
+// selfCheckInterval is how often Monic checks its own monitoring loops for stalls
+const selfCheckInterval = 30 * time.Second
+
+// selfStats contains Monic's own resource usage
+type selfStats struct {
+	PID                 int     `json:"pid"`
+	Goroutines          int     `json:"goroutines"`
+	CPUPercent          float64 `json:"cpu_percent"` // Since the previous sample (100 = one core)
+	CPUSeconds          float64 `json:"cpu_seconds"` // User and system time since startup
+	ResidentMemoryBytes uint64  `json:"resident_memory_bytes"`
+	HeapAllocBytes      uint64  `json:"heap_alloc_bytes"`
+	HeapObjects         uint64  `json:"heap_objects"`
+	SysBytes            uint64  `json:"sys_bytes"` // Memory obtained from the OS by the Go runtime
+	GCRuns              uint32  `json:"gc_runs"`
+	GCPauseSeconds      float64 `json:"gc_pause_seconds"` // Total stop-the-world GC pause time
+	OpenFDs             int32   `json:"open_fds"`
+	Threads             int32   `json:"threads"`
+}
+
+// selfMonitor samples the resource usage of the Monic process
+type selfMonitor struct {
+	mu   sync.Mutex
+	proc *process.Process // Nil when the process cannot be inspected; only Go runtime stats are reported then
+}
+
+// newSelfMonitor creates a monitor of the current process
+func newSelfMonitor() *selfMonitor {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		slog.Warn("Process stats of Monic itself are not available", "error", err)
+		proc = nil
+	}
+	return &selfMonitor{proc: proc}
+}
+
+// collect samples the Go runtime and the process stats of the operating system
+func (m *selfMonitor) collect() selfStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := selfStats{
+		PID:            os.Getpid(),
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapObjects:    mem.HeapObjects,
+		SysBytes:       mem.Sys,
+		GCRuns:         mem.NumGC,
+		GCPauseSeconds: time.Duration(mem.PauseTotalNs).Seconds(),
+	}
+	if m.proc == nil {
+		return stats
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Unavailable values (e.g. file descriptors on some platforms) are left at zero
+	if times, err := m.proc.Times(); err == nil {
+		stats.CPUSeconds = times.User + times.System
+	}
+	if percent, err := m.proc.Percent(0); err == nil {
+		stats.CPUPercent = percent
+	}
+	if info, err := m.proc.MemoryInfo(); err == nil {
+		stats.ResidentMemoryBytes = info.RSS
+	}
+	if fds, err := m.proc.NumFDs(); err == nil {
+		stats.OpenFDs = fds
+	}
+	if threads, err := m.proc.NumThreads(); err == nil {
+		stats.Threads = threads
+	}
+	return stats
+}
+
+// handleSelf handles GET /api/v1/self: Monic's own resource usage, the durations and stall state
+// of its monitoring loops, and its alert delivery latencies
+func (s *StatsServer) handleSelf(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"process":        s.self.collect(),
+		"uptime_seconds": time.Since(s.startTime).Seconds(),
+	}
+
+	if s.service != nil {
+		stalled := make(map[string]bool)
+		for _, status := range s.service.loopStatuses(time.Now()) {
+			stalled[status.Subsystem] = status.Stalled
+		}
+		loops := make([]map[string]interface{}, 0)
+		for _, stats := range s.service.cycles.snapshot() {
+			loops = append(loops, map[string]interface{}{
+				"subsystem":        stats.Subsystem,
+				"interval_ms":      stats.IntervalMs,
+				"last_duration_ms": stats.LastDurationMs,
+				"max_duration_ms":  stats.MaxDurationMs,
+				"runs":             stats.Runs,
+				"overruns":         stats.Overruns,
+				"last_run":         stats.LastRun,
+				"running":          stats.Running,
+				"restarts":         stats.Restarts,
+				"stalled":          stalled[stats.Subsystem],
+			})
+		}
+		response["loops"] = loops
+
+		delivery := make([]map[string]interface{}, 0)
+		for _, stats := range s.service.alertManager.GetChannelStats() {
+			if !stats.Enabled {
+				continue
+			}
+			delivery = append(delivery, map[string]interface{}{
+				"channel":         stats.Channel,
+				"sent":            stats.Sent,
+				"failed":          stats.Failed,
+				"avg_latency_ms":  float64(stats.AvgLatency) / float64(time.Millisecond),
+				"last_latency_ms": float64(stats.LastLatency) / float64(time.Millisecond),
+				"max_latency_ms":  float64(stats.MaxLatency) / float64(time.Millisecond),
+				"circuit_state":   stats.CircuitState,
+			})
+		}
+		response["alert_delivery"] = delivery
+	}
+
+	writeJSON(w, response)
+}
+
+// loopStatuses returns the stall state of all monitoring loops that are not paused
+func (ms *MonitorService) loopStatuses(now time.Time) []types.LoopStatus {
+	var statuses []types.LoopStatus
+	for _, status := range ms.cycles.loopStatuses(now) {
+		if !ms.startup.isPaused(status.Subsystem) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// checkLoops alerts on stalled monitoring loops, e.g. a check hanging on an unresponsive Docker
+// socket or NFS mount, and restarts them when the watchdog is configured to
+func (ms *MonitorService) checkLoops() {
+	now := time.Now()
+	statuses := ms.loopStatuses(now)
+	for _, status := range statuses {
+		if !status.Stalled {
+			continue
+		}
+		slog.Error("Monitoring loop stalled",
+			"subsystem", status.Subsystem,
+			"last_progress", status.LastProgress.Format(time.RFC3339),
+			"interval", status.Interval.String())
+
+		if ms.config.Watchdog.Restart {
+			ms.restartStalled(status.Subsystem, now)
+		}
+	}
+
+	// Alerts are sent by the alerting loop; when that loop is the one stalled, only the log remains
+	if alerts := ms.stateManager.UpdateLoopState(statuses); len(alerts) > 0 {
+		ms.raiseAlerts(alerts)
+	}
+}
+
+// restartStalled replaces a stalled loop with a new one. The alerting and self-monitoring loops,
+// and loops restarted too often already, keep running as they are.
+func (ms *MonitorService) restartStalled(subsystem string, now time.Time) {
+	if err := ms.restartLoop(subsystem); err != nil {
+		slog.Warn("Stalled monitoring loop not restarted", "subsystem", subsystem, "error", err)
+		return
+	}
+	ms.cycles.restarted(subsystem, now)
+	slog.Warn("Restarted stalled monitoring loop", "subsystem", subsystem)
+}
+
+// selfMonitorLoop checks the monitoring loops for stalls. It runs on its own, so it keeps
+// working when any of the loops it checks hangs.
+func (ms *MonitorService) selfMonitorLoop() {
+	defer ms.wg.Done()
+
+	ticker := time.NewTicker(selfCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ms.stopChan:
+			return
+		case <-ticker.C:
+			ms.checkLoops()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestCycleTracker_LoopStatuses(t *testing.T) {
+	now := time.Now()
+	tracker := newCycleTracker(0)
+
+	// Finished recently, finished long ago, hanging, and with a short interval
+	tracker.record("system", time.Minute, time.Second, now.Add(-2*time.Minute))
+	tracker.record("http", time.Minute, time.Second, now.Add(-10*time.Minute))
+	tracker.begin("docker", time.Minute, now.Add(-4*time.Minute))
+	tracker.record("grpc", time.Second, time.Millisecond, now.Add(-30*time.Second))
+	tracker.scheduled("backup", "0 3 * * *", now.Add(time.Hour))
+
+	stalled := make(map[string]bool)
+	for _, status := range tracker.loopStatuses(now) {
+		stalled[status.Subsystem] = status.Stalled
+	}
+	want := map[string]bool{"system": false, "http": true, "docker": true, "grpc": false}
+	if len(stalled) != len(want) {
+		t.Fatalf("Expected statuses of loops with an interval only, got %v", stalled)
+	}
+	for subsystem, expected := range want {
+		if stalled[subsystem] != expected {
+			t.Errorf("Expected %s stalled=%v, got %v", subsystem, expected, stalled[subsystem])
+		}
+	}
+
+	// Longer stall limits tolerate the slow loop
+	tolerant := newCycleTracker(20)
+	tolerant.record("http", time.Minute, time.Second, now.Add(-10*time.Minute))
+	if statuses := tolerant.loopStatuses(now); len(statuses) != 1 || statuses[0].Stalled {
+		t.Errorf("Expected no stall within 20 intervals, got %+v", statuses)
+	}
+
+	// A hanging cycle finishing clears the stall
+	tracker.record("docker", time.Minute, 4*time.Minute, now.Add(-4*time.Minute))
+	for _, status := range tracker.loopStatuses(now) {
+		if status.Subsystem == "docker" && status.Stalled {
+			t.Error("Expected docker to make progress again")
+		}
+	}
+}
+
+func TestMonitorService_CheckLoops(t *testing.T) {
+	ms := createTestMonitorService(t, &types.Config{})
+	ms.cycles.begin("docker", time.Second, time.Now().Add(-time.Hour))
+	ms.cycles.begin("http", time.Second, time.Now().Add(-time.Hour))
+	ms.startup.set("http", subsystemRunning, nil)
+	ms.startup.setPaused("http", true)
+
+	ms.checkLoops()
+	alerts := ms.storage.GetAlerts()
+	if len(alerts) != 1 || alerts[0].Type != "stall_docker" {
+		t.Fatalf("Expected a stall alert for docker only, got %+v", alerts)
+	}
+}
+
+func TestStatsServer_HandleSelf(t *testing.T) {
+	ms := createTestMonitorService(t, &types.Config{})
+	ms.cycles.record("system", time.Minute, 2*time.Second, time.Now())
+
+	w := httptest.NewRecorder()
+	ms.statsServer.routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/self", nil))
+
+	var response struct {
+		Process selfStats
+		Loops   []map[string]interface{}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Invalid response %s: %v", w.Body.String(), err)
+	}
+	if response.Process.Goroutines == 0 || response.Process.HeapAllocBytes == 0 {
+		t.Errorf("Expected runtime stats, got %+v", response.Process)
+	}
+	if len(response.Loops) != 1 || response.Loops[0]["subsystem"] != "system" || response.Loops[0]["last_duration_ms"] != 2000.0 {
+		t.Errorf("Expected the system loop, got %+v", response.Loops)
+	}
+
+	w = httptest.NewRecorder()
+	ms.statsServer.handleMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, metric := range []string{"monic_self_goroutines ", `monic_cycle_stalled{subsystem="system"} 0`} {
+		if !strings.Contains(w.Body.String(), metric) {
+			t.Errorf("Expected metric %s", metric)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"bconf.com/monic/monitor"
@@ -16,17 +21,37 @@ type StatsServer struct {
 	config        *types.HTTPServerConfig
 	systemMonitor *monitor.SystemMonitor
 	storage       Storage
-	stateManager  interface{} // We'll use interface{} to avoid circular dependency
+	stateManager  interface{}               // We'll use interface{} to avoid circular dependency
+	service       *MonitorService           // Attached by NewMonitorService, nil when running standalone
+	bundle        *SupportBundle            // Optional: enables the support bundle endpoint
+	nonces        *nonceCache               // Nonces of accepted signed ingestion requests
+	events        *EventHub                 // Live updates of the /events stream
+	limiter       *rateLimiter              // Per-client request limits, nil when disabled
+	guard         *authGuard                // Lockouts after failed logins, nil when disabled
+	self          *selfMonitor              // Resource usage of Monic itself
+	heartbeats    *monitor.HeartbeatMonitor // Optional: enables the heartbeat endpoint
+	agents        *monitor.AgentMonitor     // Optional: enables the agent report endpoints
+	probes        *monitor.HTTPMonitor      // Optional: runs HTTP checks on behalf of peers
+	apiKeys       *APIKeyStore              // Optional: enables API key authentication
+	metricRules   []metricRule              // Compiled relabel rules of the /metrics endpoint
+	httpServer    *http.Server              // Set by Start, nil while not serving
 	startTime     time.Time
+	// Optional: enables the check management API
+	managedChecks *monitor.ManagedCheckMonitor
 }
 
 // NewStatsServer creates a new stats server instance
-func NewStatsServer(config *types.HTTPServerConfig, systemMonitor *monitor.SystemMonitor, storage Storage, stateManager interface{}) *StatsServer {	
+func NewStatsServer(config *types.HTTPServerConfig, systemMonitor *monitor.SystemMonitor, storage Storage, stateManager interface{}) *StatsServer {
 	return &StatsServer{
 		config:        config,
 		systemMonitor: systemMonitor,
 		storage:       storage,
 		stateManager:  stateManager,
+		nonces:        newNonceCache(),
+		events:        NewEventHub(),
+		limiter:       newRateLimiter(config.RateLimit),
+		guard:         newAuthGuard(config.Auth),
+		self:          newSelfMonitor(),
 		startTime:     time.Now(),
 	}
 }
@@ -38,17 +63,55 @@ func (s *StatsServer) Start() error {
 		return nil
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/stats", s.basicAuth(s.handleStats))
+	rules, err := compileMetricRules(s.config.Metrics)
+	if err != nil {
+		return fmt.Errorf("invalid metrics configuration: %w", err)
+	}
+	s.metricRules = rules
+
+	if err := validateBranding(s.config.Branding); err != nil {
+		return fmt.Errorf("invalid branding configuration: %w", err)
+	}
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.config.Port),
-		Handler: mux,
+		Addr:    net.JoinHostPort(s.config.BindAddress, strconv.Itoa(s.config.Port)),
+		Handler: accessLog(s.config.AccessLog, basePath(s.config.BasePath), s.rateLimit(s.handler())),
+	}
+
+	// Requests share a context that is canceled on shutdown, ending live event streams that
+	// would otherwise keep the shutdown waiting until its deadline
+	baseCtx, cancel := context.WithCancel(context.Background())
+	server.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	server.RegisterOnShutdown(cancel)
+
+	// Serve over HTTPS when configured, so credentials are never sent in plaintext
+	useTLS := s.config.TLS.Enabled()
+	if useTLS {
+		tlsConfig, err := serverTLSConfig(s.config.TLS)
+		if err != nil {
+			return fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		server.TLSConfig = tlsConfig
 	}
 
-	slog.Info("Starting HTTP stats server", "port", s.config.Port)
+	// Bind synchronously so a port conflict fails startup instead of surfacing later
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to listen on %s: %w", server.Addr, err)
+	}
+	s.httpServer = server
+
+	slog.Info("Starting HTTP stats server", "address", server.Addr, "base_path", basePath(s.config.BasePath), "tls", useTLS)
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if useTLS {
+			// The certificate comes from the TLS configuration, not from files passed here
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			slog.Error("HTTP stats server failed", "error", err)
 		}
 	}()
@@ -56,6 +119,125 @@ func (s *StatsServer) Start() error {
 	return nil
 }
 
+// Shutdown stops accepting connections and waits for in-flight requests to finish. Connections
+// still active when ctx expires are closed.
+func (s *StatsServer) Shutdown(ctx context.Context) error {
+	if s.httpServer == nil {
+		return nil
+	}
+	server := s.httpServer
+	s.httpServer = nil
+
+	slog.Info("Stopping HTTP stats server")
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return fmt.Errorf("failed to shut down stats server gracefully: %w", err)
+	}
+	return nil
+}
+
+// handler serves the endpoints under the configured base path
+func (s *StatsServer) handler() http.Handler {
+	prefix := basePath(s.config.BasePath)
+	if prefix == "" {
+		return s.routes()
+	}
+
+	// Pages link to each other with relative URLs, so they work under the prefix as well
+	mux := http.NewServeMux()
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, s.routes()))
+	return mux
+}
+
+// basePath normalizes a base path to "/prefix" without a trailing slash, or "" for none
+func basePath(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
+
+// localURL returns the URL of an endpoint of the stats server running on this host, used by the
+// CLI commands talking to a running instance (see localClient)
+func localURL(config *types.HTTPServerConfig, path string) string {
+	host := config.BindAddress
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	scheme := "http://"
+	if config.TLS.Enabled() {
+		scheme = "https://"
+	}
+	return scheme + net.JoinHostPort(host, strconv.Itoa(config.Port)) + basePath(config.BasePath) + path
+}
+
+// routes registers all HTTP endpoints
+func (s *StatsServer) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	// Polled read endpoints are cacheable (ETag and gzip); streams, downloads and writes are not
+	mux.HandleFunc("/stats", s.requireScope(ScopeReadStats, cacheable(s.handleStats)))
+	mux.HandleFunc("/metrics", s.requireScope(ScopeReadStats, cacheable(s.handleMetrics)))
+	mux.HandleFunc("GET /events", s.requireScope(ScopeReadStats, s.handleEvents))
+	mux.HandleFunc("GET /ws", s.requireScope(ScopeReadStats, s.handleWebSocket))
+	mux.HandleFunc(supportBundlePath, s.requireScope(ScopeAdminConfig, s.handleSupportBundle))
+	mux.HandleFunc("DELETE /api/v1/history", s.requireScope(ScopeAdminConfig, s.handlePurgeHistory))
+	mux.HandleFunc("DELETE /api/v1/history/{type}", s.requireScope(ScopeAdminConfig, s.handlePurgeHistory))
+	mux.HandleFunc("GET /api/v1/history/audit", s.requireScope(ScopeAdminConfig, s.handlePurgeAudit))
+	mux.HandleFunc("GET /api/v1/config", s.requireScope(ScopeAdminConfig, s.handleConfig))
+	mux.HandleFunc("GET /api/v1/storage", s.requireScope(ScopeAdminConfig, s.handleStorageStatus))
+	mux.HandleFunc("POST /api/v1/storage/compact", s.requireScope(ScopeAdminConfig, s.handleCompactStorage))
+	mux.HandleFunc("GET "+exportPath, s.requireScope(ScopeReadStats, s.handleExport))
+	mux.HandleFunc("POST "+importPath, s.requireScope(ScopeAdminConfig, s.handleImport))
+	mux.HandleFunc("GET /api/v1/system/history", s.requireScope(ScopeReadStats, cacheable(s.handleSystemHistory)))
+	mux.HandleFunc("GET /api/v1/http/history", s.requireScope(ScopeReadStats, cacheable(s.handleHTTPHistory)))
+	mux.HandleFunc("GET /api/v1/http-checks/{name}/history.csv", s.requireScope(ScopeReadStats, s.handleCheckHistoryCSV))
+	mux.HandleFunc("GET /api/v1/docker/history", s.requireScope(ScopeReadStats, cacheable(s.handleDockerHistory)))
+	mux.HandleFunc("GET /api/v1/aggregate", s.requireScope(ScopeReadStats, cacheable(s.handleAggregate)))
+	mux.HandleFunc("GET /api/v1/latency", s.requireScope(ScopeReadStats, cacheable(s.handleLatencyAPI)))
+	mux.HandleFunc("GET /api/v1/uptime", s.requireScope(ScopeReadStats, cacheable(s.handleUptime)))
+	mux.HandleFunc("GET /latency", s.requireScope(ScopeReadStats, cacheable(s.handleLatencyView)))
+	mux.HandleFunc("GET /api/v1/status", s.requireScope(ScopeReadStats, cacheable(s.handleStatus)))
+	mux.HandleFunc("GET /api/v1/self", s.requireScope(ScopeReadStats, cacheable(s.handleSelf)))
+	mux.HandleFunc("GET /api/v1/events", s.requireScope(ScopeReadStats, cacheable(s.handleLifecycleEvents)))
+	mux.HandleFunc("GET /api/v1/containers", s.requireScope(ScopeReadStats, cacheable(s.handleContainers)))
+	mux.HandleFunc("POST /api/v1/subsystems/{name}/pause", s.requireScope(ScopeWriteSilences, s.handleSubsystemToggle(true)))
+	mux.HandleFunc("POST /api/v1/subsystems/{name}/resume", s.requireScope(ScopeWriteSilences, s.handleSubsystemToggle(false)))
+	mux.HandleFunc("GET /alerts", s.requireScope(ScopeReadStats, s.handleAlertsPage))
+	mux.HandleFunc("GET /api/v1/alerts", s.requireScope(ScopeReadStats, cacheable(s.handleAlertsAPI)))
+	mux.HandleFunc("GET /api/v1/alerts/states", s.requireScope(ScopeReadStats, cacheable(s.handleAlertStates)))
+	mux.Handle("POST /api/v1/alerts/{type}/acknowledge", alertFormProtection.Handler(s.requireScope(ScopeWriteSilences, s.handleAlertAction("acknowledge"))))
+	mux.Handle("POST /api/v1/alerts/{type}/silence", alertFormProtection.Handler(s.requireScope(ScopeWriteSilences, s.handleAlertAction("silence"))))
+	mux.Handle("POST /api/v1/alerts/{type}/unsilence", alertFormProtection.Handler(s.requireScope(ScopeWriteSilences, s.handleAlertAction("unsilence"))))
+	mux.HandleFunc("GET /api/v1/checks", s.requireScope(ScopeReadStats, cacheable(s.handleListChecks)))
+	mux.HandleFunc("GET /api/v1/checks/{name}", s.requireScope(ScopeReadStats, cacheable(s.handleGetCheck)))
+	mux.HandleFunc("POST /api/v1/checks", s.requireScope(ScopeAdminConfig, s.handleCreateCheck))
+	mux.HandleFunc("PUT /api/v1/checks/{name}", s.requireScope(ScopeAdminConfig, s.handleUpdateCheck))
+	mux.HandleFunc("DELETE /api/v1/checks/{name}", s.requireScope(ScopeAdminConfig, s.handleDeleteCheck))
+	mux.HandleFunc("POST /api/v1/checks/{name}/pause", s.requireScope(ScopeWriteSilences, s.handleCheckToggle(true)))
+	mux.HandleFunc("POST /api/v1/checks/{name}/resume", s.requireScope(ScopeWriteSilences, s.handleCheckToggle(false)))
+	mux.HandleFunc("POST /api/v1/checks/{name}/run", s.requireScope(ScopeWriteSilences, s.handleRunCheck))
+	mux.HandleFunc("POST /api/v1/push/{name}", s.ingestAuth(s.handlePush))
+	mux.HandleFunc("POST /api/heartbeat/{name}", s.ingestAuth(s.handleHeartbeat))
+	mux.HandleFunc("POST /api/v1/agents/report", s.ingestAuthLimit(maxAgentReportSize, s.handleAgentReport))
+	mux.HandleFunc("GET /api/v1/agents", s.requireScope(ScopeReadStats, cacheable(s.handleAgents)))
+	mux.HandleFunc("POST "+probePath, s.ingestAuth(s.handleProbe))
+
+	// The public status page only exposes the configured components, badges only the state of a
+	// check and the logo is shown on the status page, so they skip authentication
+	if s.config.StatusPage.Enabled {
+		mux.HandleFunc("GET /status", cacheable(s.handleStatusPage))
+		mux.HandleFunc("GET /status.json", cacheable(s.handleStatusPageJSON))
+	}
+	if s.config.Badges.Enabled {
+		mux.HandleFunc("GET /badge/{check...}", s.handleBadge)
+	}
+	if s.config.Branding.LogoFile != "" {
+		mux.HandleFunc("GET "+logoPath, s.handleLogo)
+	}
+	return mux
+}
+
 // basicAuth middleware for HTTP basic authentication
 func (s *StatsServer) basicAuth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -65,6 +247,10 @@ func (s *StatsServer) basicAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		if s.authLocked(w, r) {
+			return
+		}
+
 		username, password, ok := r.BasicAuth()
 		if !ok {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Monic Stats"`)
@@ -72,11 +258,16 @@ func (s *StatsServer) basicAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		if username != s.config.Username || password != s.config.Password {
+		// Both are always compared, so the response time does not reveal which one is wrong
+		usernameOK := credentialsEqual(username, s.config.Username)
+		passwordOK := credentialsEqual(password, s.config.Password)
+		if !usernameOK || !passwordOK {
+			s.authFailed(r)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
+		s.authSucceeded(r)
 		next(w, r)
 	}
 }
@@ -88,10 +279,25 @@ func (s *StatsServer) handleStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats := s.getStatsResponse()
-	
-	// Check if client explicitly requests JSON
-	if r.Header.Get("Accept") == "application/json" {
+	// The same URL serves several representations
+	w.Header().Add("Vary", "Accept")
+	format, err := negotiateFormat(r, statsOffers)
+	if err != nil {
+		status := http.StatusNotAcceptable
+		if r.URL.Query().Has("format") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if format == formatPrometheus {
+		s.handleMetrics(w, r)
+		return
+	}
+
+	stats := s.getStatsResponse(splitList(r.URL.Query().Get("tag")))
+
+	if format == formatJSON {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(stats); err != nil {
 			slog.Error("Error encoding stats response", "error", err)
@@ -101,83 +307,147 @@ func (s *StatsServer) handleStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Otherwise serve HTML
-	renderStatsHTML(w, stats)
+	// Otherwise serve HTML, with trend charts and availability of the recent history
+	now := time.Now()
+	stats["service_status"].(map[string]interface{})["uptime"] = now.Sub(s.startTime).String()
+	stats["charts"] = s.dashboardCharts(now)
+	stats["uptime"] = buildUptime(loadUptimeSeries(s.storage, now, 0), now)
+	stats["lifecycle_events"] = recentEvents(s.storage, now)
+	s.renderHTML(w, "templates/stats.html", stats)
 }
 
-// getStatsResponse builds the complete stats response
-func (s *StatsServer) getStatsResponse() map[string]interface{} {
+// getStatsResponse builds the complete stats response; with tags, only the checks, containers
+// and alerts with any of them are included
+func (s *StatsServer) getStatsResponse(tags []string) map[string]interface{} {
 	response := make(map[string]interface{})
 
 	// Service status
+	// Values derived from the clock, like the running time or the age of a result, would change
+	// the response and its ETag on every request, so it holds absolute timestamps instead
 	response["service_status"] = map[string]interface{}{
 		"status":     "running",
 		"started_at": s.startTime.Format(time.RFC3339),
-		"uptime":     time.Since(s.startTime).String(),
 	}
 
-	// System information
+	// System information, without the live values that change between requests: the available
+	// memory (collected in current_system_stats) and the goroutine count (see /api/v1/self)
 	systemInfo := s.systemMonitor.GetSystemInfo()
+	if hostInfo, ok := systemInfo["host"].(map[string]interface{}); ok {
+		delete(hostInfo, "available_memory")
+	}
+	if runtimeInfo, ok := systemInfo["runtime"].(map[string]interface{}); ok {
+		delete(runtimeInfo, "goroutines")
+	}
 	response["system_info"] = systemInfo
 
 	// Current system stats
 	latestStats := s.storage.GetLatestSystemStats()
 	if latestStats != nil {
 		response["current_system_stats"] = map[string]interface{}{
-			"timestamp": latestStats.Timestamp.Format(time.RFC3339),
-			"cpu_usage": latestStats.CPUUsage,
+			"timestamp":      latestStats.Timestamp.Format(time.RFC3339),
+			"cpu_usage":      latestStats.CPUUsage,
+			"per_core_usage": latestStats.PerCoreUsage,
+			"load_average": map[string]interface{}{
+				"load1":    latestStats.LoadAverage.Load1,
+				"load5":    latestStats.LoadAverage.Load5,
+				"load15":   latestStats.LoadAverage.Load15,
+				"cores":    latestStats.LoadAverage.Cores,
+				"per_core": latestStats.LoadAverage.PerCore(),
+			},
 			"memory_usage": map[string]interface{}{
 				"total":        latestStats.MemoryUsage.Total,
 				"used":         latestStats.MemoryUsage.Used,
 				"free":         latestStats.MemoryUsage.Free,
 				"used_percent": latestStats.MemoryUsage.UsedPercent,
 			},
+			"swap_usage": map[string]interface{}{
+				"total":        latestStats.SwapUsage.Total,
+				"used":         latestStats.SwapUsage.Used,
+				"free":         latestStats.SwapUsage.Free,
+				"used_percent": latestStats.SwapUsage.UsedPercent,
+			},
 			"disk_usage": latestStats.DiskUsage,
+			"disk_io":    latestStats.DiskIO,
 		}
 	} else {
 		response["current_system_stats"] = nil
 	}
 
 	// HTTP checks status
-	response["http_checks"] = s.getHTTPChecksStatus()
+	response["http_checks"] = s.getHTTPChecksStatus(tags)
+
+	// Container resource usage of the latest Docker collection
+	containers := s.storage.GetLatestDockerContainerStats()
+	response["containers"] = containersWithTags(containers, tags)
+
+	// Checks and containers grouped by tag, and the tags shown
+	if groups := tagGroups(latestCheckResults(s.storage), containers); len(groups) > 0 {
+		response["tag_groups"] = groups
+	}
+	if len(tags) > 0 {
+		response["tag"] = strings.Join(tags, ",")
+	}
 
 	// Alert status
 	alertsCount := s.storage.GetAlertsCount()
 	response["alerts"] = map[string]interface{}{
 		"active_alerts": alertsCount,
-		"recent_alerts": s.getRecentAlerts(),
+		"recent_alerts": s.getRecentAlerts(tags),
 	}
 
 	// Monitoring thresholds (from system monitor)
 	response["thresholds"] = s.systemMonitor.GetThresholds()
 
+	// Alert channel delivery metrics
+	response["alert_channels"] = s.getAlertChannelsStatus()
+
+	// Subsystem startup state and runtime pauses
+	if s.service != nil {
+		response["subsystems"] = s.service.startup.snapshot()
+
+		// Containers grouped by compose project and service
+		if services := s.service.dockerMonitor.ComposeServices(s.storage.GetLatestDockerContainerStats()); len(services) > 0 {
+			response["compose_services"] = services
+		}
+
+		// Docker's disk usage (docker system df), once collected
+		if usage := s.service.dockerMonitor.LatestDiskUsage(); usage != nil {
+			response["docker_disk_usage"] = usage
+		}
+	}
+
+	// Hosts reporting through remote agents
+	if s.agents != nil {
+		if agents := s.agents.Agents(); len(agents) > 0 {
+			response["agents"] = agentSections(agents)
+		}
+	}
+
 	return response
 }
 
-// getHTTPChecksStatus returns the status of all HTTP checks
-func (s *StatsServer) getHTTPChecksStatus() []map[string]interface{} {
+// getHTTPChecksStatus returns the status of all HTTP checks, or of those with any of the tags
+func (s *StatsServer) getHTTPChecksStatus(tags []string) []map[string]interface{} {
 	var checks []map[string]interface{}
 
-	httpHistory := s.storage.GetHTTPCheckResults()
-	if len(httpHistory) == 0 {
+	statuses := s.storage.GetCheckStatuses()
+	if len(statuses) == 0 {
 		return checks
 	}
 
-	// Group HTTP results by name to get latest status
+	// Group the indexed checks by name to get latest status and last failure
 	latestResults := make(map[string]types.HTTPCheckResult)
-	for _, result := range httpHistory {
-		if existing, exists := latestResults[result.Name]; !exists || result.Timestamp.After(existing.Timestamp) {
-			latestResults[result.Name] = result
-		}
-	}
-
-	// Find last failure for each check
 	lastFailures := make(map[string]time.Time)
-	for _, result := range httpHistory {
-		if !result.Success {
-			if existing, exists := lastFailures[result.Name]; !exists || result.Timestamp.After(existing) {
-				lastFailures[result.Name] = result.Timestamp
-			}
+	for _, status := range statuses {
+		if !hasAnyTag(status.Latest.Tags, tags) {
+			continue
+		}
+		name := status.Latest.Name
+		if existing, exists := latestResults[name]; !exists || status.Latest.Timestamp.After(existing.Timestamp) {
+			latestResults[name] = status.Latest
+		}
+		if status.LastFailure.After(lastFailures[name]) {
+			lastFailures[name] = status.LastFailure
 		}
 	}
 
@@ -190,6 +460,8 @@ func (s *StatsServer) getHTTPChecksStatus() []map[string]interface{} {
 			"last_check":    result.Timestamp.Format(time.RFC3339),
 			"response_time": result.ResponseTime.String(),
 			"status_code":   result.StatusCode,
+			"inverted":      result.Inverted,
+			"tags":          nonNil(result.Tags),
 		}
 
 		if !result.Success {
@@ -197,6 +469,15 @@ func (s *StatsServer) getHTTPChecksStatus() []map[string]interface{} {
 			check["error"] = result.Error
 		}
 
+		if result.Value != "" {
+			check["value"] = result.Value
+		}
+
+		if result.Timing != nil {
+			check["timing"] = newTimingPhases(result.Timing)
+			check["reused_connection"] = result.Timing.ReusedConn
+		}
+
 		if lastFailure, exists := lastFailures[name]; exists {
 			check["last_failure"] = lastFailure.Format(time.RFC3339)
 		}
@@ -207,11 +488,16 @@ func (s *StatsServer) getHTTPChecksStatus() []map[string]interface{} {
 	return checks
 }
 
-// getRecentAlerts returns recent alerts
-func (s *StatsServer) getRecentAlerts() []map[string]interface{} {
+// getRecentAlerts returns recent alerts, or those with any of the tags
+func (s *StatsServer) getRecentAlerts(tags []string) []map[string]interface{} {
 	var recentAlerts []map[string]interface{}
 
-	alerts := s.storage.GetAlerts()
+	var alerts []types.Alert
+	for _, alert := range s.storage.GetAlerts() {
+		if hasAnyTag(alert.Tags, tags) {
+			alerts = append(alerts, alert)
+		}
+	}
 	if len(alerts) == 0 {
 		return recentAlerts
 	}
@@ -229,8 +515,101 @@ func (s *StatsServer) getRecentAlerts() []map[string]interface{} {
 			"message":   alert.Message,
 			"level":     alert.Level,
 			"timestamp": alert.Timestamp.Format(time.RFC3339),
+			"tags":      nonNil(alert.Tags),
 		})
 	}
 
 	return recentAlerts
 }
+
+// getAlertChannelsStatus returns delivery metrics and health of the enabled alert channels
+func (s *StatsServer) getAlertChannelsStatus() []map[string]interface{} {
+	var channels []map[string]interface{}
+
+	if s.service == nil {
+		return channels
+	}
+
+	for _, stats := range s.service.alertManager.GetChannelStats() {
+		if !stats.Enabled {
+			continue
+		}
+
+		channel := map[string]interface{}{
+			"channel":       stats.Channel,
+			"circuit_state": stats.CircuitState,
+			"sent":          stats.Sent,
+			"failed":        stats.Failed,
+			"error_rate":    stats.ErrorRate,
+			"avg_latency":   stats.AvgLatency.Round(time.Millisecond).String(),
+			"last_latency":  stats.LastLatency.Round(time.Millisecond).String(),
+			"max_latency":   stats.MaxLatency.Round(time.Millisecond).String(),
+		}
+		if !stats.LastSuccess.IsZero() {
+			channel["last_success"] = stats.LastSuccess.Format(time.RFC3339)
+		}
+		if stats.LastError != "" {
+			channel["last_error"] = stats.LastError
+			channel["last_error_time"] = stats.LastErrorTime.Format(time.RFC3339)
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels
+}
+
+// containerInventory describes a container in the inventory API
+type containerInventory struct {
+	Name      string    `json:"name"`
+	ID        string    `json:"id"`
+	Image     string    `json:"image"`
+	ImageID   string    `json:"image_id"`
+	State     string    `json:"state"`
+	Health    string    `json:"health,omitempty"`
+	Restarts  int       `json:"restart_count"`
+	Ports     []string  `json:"ports"`
+	Networks  []string  `json:"networks"`
+	Project   string    `json:"compose_project,omitempty"`
+	Service   string    `json:"compose_service,omitempty"`
+	Tags      []string  `json:"tags"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// handleContainers handles GET /api/v1/containers: the image, ports and networks of the
+// containers of the latest Docker collection, sorted by name; "tag" (comma-separated) limits
+// them to containers with any of the tags
+func (s *StatsServer) handleContainers(w http.ResponseWriter, r *http.Request) {
+	inventory := []containerInventory{}
+	tags := splitList(r.URL.Query().Get("tag"))
+	for _, c := range containersWithTags(s.storage.GetLatestDockerContainerStats(), tags) {
+		inventory = append(inventory, containerInventory{
+			Name:      c.Name,
+			ID:        c.ContainerID,
+			Image:     c.Image,
+			ImageID:   c.ImageID,
+			State:     c.State,
+			Health:    c.Health,
+			Restarts:  c.RestartCount,
+			Ports:     nonNil(c.Ports),
+			Networks:  nonNil(c.Networks),
+			Project:   c.ComposeProject,
+			Service:   c.ComposeService,
+			Tags:      nonNil(c.Tags),
+			Timestamp: c.Timestamp,
+		})
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		return inventory[i].Name < inventory[j].Name
+	})
+
+	writeJSON(w, map[string]interface{}{"containers": inventory})
+}
+
+// nonNil returns an empty slice instead of nil so lists encode as [] in JSON
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
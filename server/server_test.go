@@ -1,9 +1,13 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -166,6 +170,22 @@ func TestStatsServer_HandleStats_HTML(t *testing.T) {
 	})
 	
 	storage := NewStorageManager(100)
+	storage.AddSystemStats(types.SystemStats{
+		Timestamp:    time.Now(),
+		CPUUsage:     40,
+		PerCoreUsage: []float64{30, 50},
+		LoadAverage:  types.LoadStats{Load1: 0.5, Load5: 1.5, Load15: 1, Cores: 2},
+		SwapUsage:    types.SwapStats{Total: 2147483648, Used: 536870912, UsedPercent: 25},
+		DiskUsage:    map[string]types.DiskStats{"/": {Path: "/", InodesTotal: 1000, InodesUsed: 925, InodesUsedPercent: 92.5}},
+		DiskIO:       map[string]types.DiskIOStats{"sda": {Device: "sda", ReadIOPS: 12.5, WriteIOPS: 3, UtilPercent: 40}},
+	})
+	storage.AddDockerContainerStats([]types.DockerContainerStats{
+		{Name: "web", Image: "nginx:1.27", Running: true, Ports: []string{"0.0.0.0:8080->80/tcp"}, Networks: []string{"front"}, Timestamp: time.Now()},
+	})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{
+		Name: "shop", URL: "https://shop.example.com", StatusCode: 200, Success: true, Timestamp: time.Now(), ResponseTime: 180 * time.Millisecond,
+		Timing: &types.HTTPTiming{DNS: 3 * time.Millisecond, Connect: 12 * time.Millisecond, TLS: 25 * time.Millisecond, FirstByte: 140 * time.Millisecond, Transfer: time.Millisecond},
+	})
 	server := NewStatsServer(config, systemMonitor, storage, nil)
 
 	// Create a test request (default Accept header)
@@ -189,6 +209,24 @@ func TestStatsServer_HandleStats_HTML(t *testing.T) {
 	if !json.Valid([]byte(body)) && body == "" {
 		t.Error("Expected non-empty body")
 	}
+	if !strings.Contains(body, "0.50 / 1.50 / 1.00") || !strings.Contains(body, "Core 1") {
+		t.Error("Expected load average and per-core usage in HTML")
+	}
+	if !strings.Contains(body, "Disk I/O (sda)") || !strings.Contains(body, "12.5 / 3.0") {
+		t.Error("Expected disk I/O stats in HTML")
+	}
+	if !strings.Contains(body, "DNS 3 &middot; connect 12 &middot; TLS 25 &middot; server 140 &middot; transfer 1 ms") {
+		t.Error("Expected the request phases of the HTTP check in HTML")
+	}
+	if !strings.Contains(body, "92.5% of 1000") {
+		t.Error("Expected inode usage in HTML")
+	}
+	if !strings.Contains(body, "25.0% of 2.0 GB") {
+		t.Error("Expected swap usage in HTML")
+	}
+	if !strings.Contains(body, "nginx:1.27") || !strings.Contains(body, "0.0.0.0:8080-&gt;80/tcp") || !strings.Contains(body, "front") {
+		t.Error("Expected the container inventory in HTML")
+	}
 }
 
 func TestStatsServer_BasicAuth(t *testing.T) {
@@ -299,3 +337,151 @@ func TestStatsServer_MethodNotAllowed(t *testing.T) {
 		t.Errorf("Expected status code %d for POST method, got %d", http.StatusMethodNotAllowed, w.Code)
 	}
 }
+
+func TestStatsServer_HandleContainers(t *testing.T) {
+	collectedAt := time.Now()
+	storage := NewStorageManager(100)
+	storage.AddDockerContainerStats([]types.DockerContainerStats{
+		{Name: "web", ContainerID: "abc123", Image: "nginx:1.27", ImageID: "0123456789ab", State: "running", Running: true,
+			Ports: []string{"0.0.0.0:8080->80/tcp"}, Networks: []string{"front", "back"}, Timestamp: collectedAt},
+		{Name: "db", State: "exited", Timestamp: collectedAt},
+	})
+	server := NewStatsServer(&types.HTTPServerConfig{Enabled: true}, nil, storage, nil)
+
+	w := httptest.NewRecorder()
+	server.handleContainers(w, httptest.NewRequest("GET", "/api/v1/containers", nil))
+
+	var response struct {
+		Containers []containerInventory `json:"containers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Containers) != 2 || response.Containers[0].Name != "db" {
+		t.Fatalf("Expected both containers sorted by name, got %+v", response.Containers)
+	}
+	web := response.Containers[1]
+	if web.Image != "nginx:1.27" || len(web.Ports) != 1 || len(web.Networks) != 2 {
+		t.Errorf("Unexpected inventory entry: %+v", web)
+	}
+	if !strings.Contains(w.Body.String(), `"ports":[]`) {
+		t.Error("Expected empty port lists to encode as []")
+	}
+}
+
+func TestStatsServer_Shutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	server := NewStatsServer(&types.HTTPServerConfig{Enabled: true, Port: port}, nil, NewStorageManager(10), nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+
+	// An open live event stream must not hold up the shutdown
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/events", port))
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected graceful shutdown, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected shutdown not to wait for the event stream, took %v", elapsed)
+	}
+
+	// The port is released
+	listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Fatalf("Expected port to be released: %v", err)
+	}
+	listener.Close()
+
+	if err := server.Shutdown(ctx); err != nil {
+		t.Errorf("Expected shutting down twice to be a no-op, got %v", err)
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	tests := map[string]string{"": "", "/": "", "monic": "/monic", "/monic/": "/monic", " /tools/monic ": "/tools/monic"}
+	for path, want := range tests {
+		if got := basePath(path); got != want {
+			t.Errorf("basePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestStatsServer_BasePath(t *testing.T) {
+	config := &types.HTTPServerConfig{Enabled: true, BasePath: "/monic/"}
+	server := httptest.NewServer(NewStatsServer(config, nil, NewStorageManager(10), nil).handler())
+	defer server.Close()
+
+	for path, want := range map[string]int{
+		"/monic/api/v1/uptime":   http.StatusOK,
+		"/monic/api/v1/agents/x": http.StatusNotFound,
+		"/api/v1/uptime":         http.StatusNotFound,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Request to %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Expected status %d for %s, got %d", want, path, resp.StatusCode)
+		}
+	}
+}
+
+func TestStatsServer_BindAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	config := &types.HTTPServerConfig{Enabled: true, Port: port, BindAddress: "127.0.0.1"}
+	server := NewStatsServer(config, nil, NewStorageManager(10), nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Shutdown(context.Background())
+
+	if addr := server.httpServer.Addr; addr != fmt.Sprintf("127.0.0.1:%d", port) {
+		t.Errorf("Expected the server to listen on 127.0.0.1 only, got %s", addr)
+	}
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/v1/uptime", port))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestLocalURL(t *testing.T) {
+	tests := []struct {
+		config types.HTTPServerConfig
+		want   string
+	}{
+		{types.HTTPServerConfig{Port: 8080}, "http://127.0.0.1:8080/api/v1/status"},
+		{types.HTTPServerConfig{Port: 8080, BindAddress: "0.0.0.0", BasePath: "monic/"}, "http://127.0.0.1:8080/monic/api/v1/status"},
+		{types.HTTPServerConfig{Port: 9090, BindAddress: "::1"}, "http://[::1]:9090/api/v1/status"},
+		{types.HTTPServerConfig{Port: 8443, TLS: types.TLSConfig{ACMEDomains: []string{"monic.example.com"}}}, "https://127.0.0.1:8443/api/v1/status"},
+	}
+	for _, test := range tests {
+		if got := localURL(&test.config, "/api/v1/status"); got != test.want {
+			t.Errorf("Expected %s, got %s", test.want, got)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -11,6 +12,18 @@ import (
 	"bconf.com/monic/types"
 )
 
+// statsServerShutdownTimeout is how long Stop waits for in-flight stats server requests
+const statsServerShutdownTimeout = 10 * time.Second
+
+// CheckRunner is a monitor that runs its own set of checks on an interval
+// and reports them as check results (e.g. gRPC health checks)
+type CheckRunner interface {
+	Name() string
+	Interval() time.Duration
+	Validate() error
+	RunChecks() []types.HTTPCheckResult
+}
+
 // MonitorService represents the main monitoring service
 type MonitorService struct {
 	config        *types.Config
@@ -21,6 +34,20 @@ type MonitorService struct {
 	stateManager  *alert.StateManager
 	statsServer   *StatsServer
 	storage       Storage
+	results       *resultBuffer // Batches check result writes; nil writes every run's results directly
+	checkRunners  []CheckRunner
+	publisher     ResultPublisher
+	exporters     []MetricsExporter
+	agentReporter *AgentReporter
+	peers         *PeerProber
+	startup       *startupTracker
+	cycles        *cycleTracker
+	loopsMu       sync.Mutex
+	loops         map[string]*loopRun // Schedule loops by subsystem, for restarts
+	alerts        *alertTracker
+	saveStateMu   sync.Mutex // Orders alert state saves of the alert loop and the alert actions
+	containers    containerTracker
+	alertsRaised  chan struct{} // Signals alerts to send right away
 	stopChan      chan struct{}
 	wg            sync.WaitGroup
 	startTime     time.Time
@@ -36,8 +63,9 @@ func NewMonitorService(
 	stateManager *alert.StateManager,
 	storage Storage,
 	statsServer *StatsServer,
+	checkRunners ...CheckRunner,
 ) *MonitorService {
-	return &MonitorService{
+	ms := &MonitorService{
 		config:        config,
 		systemMonitor: systemMonitor,
 		httpMonitor:   httpMonitor,
@@ -46,131 +74,304 @@ func NewMonitorService(
 		stateManager:  stateManager,
 		storage:       storage,
 		statsServer:   statsServer,
+		checkRunners:  cacheRunners(checkRunners, config.CheckCaches),
+		startup:       newStartupTracker(),
+		cycles:        newCycleTracker(config.Watchdog.StallIntervals),
+		loops:         make(map[string]*loopRun),
+		alerts:        newAlertTracker(),
+		alertsRaised:  make(chan struct{}, 1),
 		stopChan:      make(chan struct{}),
 		startTime:     time.Now(),
 	}
+
+	if config.Storage.FlushInterval > 0 {
+		ms.results = newResultBuffer(storage)
+	}
+
+	// Give the stats server access to service-level data (alert channels, etc.)
+	if statsServer != nil {
+		statsServer.service = ms
+	}
+
+	return ms
 }
 
-// Start begins the monitoring service
+// Start begins the monitoring service. Subsystems start in priority order: the stats
+// server (API and status endpoints) and alert channels first, then the monitor loops.
+// Docker initializes in the background so a slow or missing daemon can't delay other checks.
 func (ms *MonitorService) Start() error {
 	slog.Info("Starting Monic monitoring service...")
 
-	// Validate HTTP checks configuration
-	if err := ms.httpMonitor.ValidateHTTPCheck(ms.config.HTTPChecks); err != nil {
-		return fmt.Errorf("invalid HTTP check configuration for %s: %w", ms.config.HTTPChecks.URL, err)
+	// Validate checks, schedules and alerting configuration
+	for _, check := range ms.Validate() {
+		if check.Error != nil {
+			return check.Error
+		}
 	}
 
-	// Validate alerting configuration
-	if err := ms.alertManager.ValidateConfig(); err != nil {
-		return fmt.Errorf("invalid alerting configuration: %w", err)
+	// Build check schedules (cron expressions or fixed intervals)
+	schedules, err := ms.buildSchedules()
+	if err != nil {
+		return err
 	}
 
-	// Start HTTP stats server
+	// Continue failure counts and alert cooldowns of the previous run
+	ms.restoreAlertState()
+
+	// Start HTTP stats server first so the API and status endpoint are available during startup
 	if err := ms.statsServer.Start(); err != nil {
+		ms.startup.set("stats_server", subsystemFailed, err)
 		return fmt.Errorf("failed to start HTTP stats server: %w", err)
 	}
+	ms.startup.set("stats_server", enabledState(ms.statsServer.config.Enabled), nil)
+
+	// Alert channels are ready before any monitor can raise an alert
+	ms.startup.set("alerting", enabledState(ms.hasAlertChannels()), nil)
+	ms.wg.Add(1)
+	go ms.alertProcessingLoop()
+
+	// Watches the other loops, so a hanging check is reported instead of silently stopping
+	ms.wg.Add(1)
+	go ms.selfMonitorLoop()
+
+	// Result streaming starts before the monitors so no early results are missed
+	if ms.publisher != nil {
+		if err := ms.publisher.Start(); err != nil {
+			ms.startup.set("nats", subsystemFailed, err)
+			return fmt.Errorf("failed to start NATS publisher: %w", err)
+		}
+		ms.startup.set("nats", subsystemRunning, nil)
+	} else {
+		ms.startup.set("nats", subsystemDisabled, nil)
+	}
+	for _, exporter := range ms.exporters {
+		if err := exporter.Start(); err != nil {
+			ms.startup.set(exporter.Name(), subsystemFailed, err)
+			return fmt.Errorf("failed to start %s exporter: %w", exporter.Name(), err)
+		}
+		ms.startup.set(exporter.Name(), subsystemRunning, nil)
+	}
 
 	// Print system information
 	systemInfo := ms.systemMonitor.GetSystemInfo()
 	slog.Info("System Info", "info", systemInfo)
 
-	// Initialize Docker monitor if enabled
-	if ms.config.DockerChecks.Enabled {
-		if err := ms.dockerMonitor.Initialize(); err != nil {
-			slog.Warn("Failed to initialize Docker monitor", "error", err)
-		} else {
-			ms.wg.Add(1)
-			go ms.dockerMonitoringLoop()
-		}
+	// Check results of all loops are written together
+	if ms.results != nil {
+		ms.wg.Add(1)
+		go ms.resultFlushLoop(time.Duration(ms.config.Storage.FlushInterval) * time.Millisecond)
 	}
 
 	// Start monitoring goroutines
-	ms.wg.Add(3)
-	go ms.systemMonitoringLoop()
-	go ms.httpMonitoringLoop()
-	go ms.alertProcessingLoop()
+	ms.wg.Add(1)
+	go ms.scheduleLoop("system", schedules["system"], ms.collectSystemStats)
+	ms.startup.set("system", subsystemRunning, nil)
+	for _, check := range ms.config.HTTPChecks {
+		ms.wg.Add(1)
+		loop := httpLoopName(check)
+		go ms.scheduleLoop(loop, schedules[loop], func() { ms.collectHTTPStats(check) })
+	}
+	ms.startup.set("http", subsystemRunning, nil)
+
+	for _, runner := range ms.checkRunners {
+		ms.wg.Add(1)
+		subsystem := runnerSubsystem(runner)
+		go ms.scheduleLoop(subsystem, schedules[subsystem], func() { ms.collectCheckRunnerStats(runner) })
+		ms.startup.set(subsystem, subsystemRunning, nil)
+	}
 
+	// Initialize Docker monitor in the background if enabled
+	if ms.config.DockerChecks.Enabled {
+		ms.startup.set("docker", subsystemStarting, nil)
+		ms.wg.Add(1)
+		go ms.startDockerMonitoring(schedules["docker"])
+	} else {
+		ms.startup.set("docker", subsystemDisabled, nil)
+	}
+
+	// Start retention purging if any policy is configured
+	if len(retentionPolicies(&ms.config.Storage.Retention)) > 0 {
+		ms.wg.Add(1)
+		go ms.retentionLoop()
+		ms.startup.set("retention", subsystemRunning, nil)
+	} else {
+		ms.startup.set("retention", subsystemDisabled, nil)
+	}
+
+	// Report to the central instance once local stats are being collected
+	if ms.agentReporter != nil {
+		if err := ms.agentReporter.Start(); err != nil {
+			ms.startup.set("agent", subsystemFailed, err)
+			return fmt.Errorf("failed to start agent reporting: %w", err)
+		}
+		ms.startup.set("agent", subsystemRunning, nil)
+	} else {
+		ms.startup.set("agent", subsystemDisabled, nil)
+	}
+
+	ms.recordEvent(EventServiceStarted, "monic", "Monitoring service started")
 	slog.Info("Monic monitoring service started successfully")
 	return nil
 }
 
-// Stop gracefully stops the monitoring service
-func (ms *MonitorService) Stop() {
-	slog.Info("Stopping Monic monitoring service...")
-	close(ms.stopChan)
-	ms.wg.Wait()
-	slog.Info("Monic monitoring service stopped")
+// hasAlertChannels reports whether at least one alert channel is enabled
+func (ms *MonitorService) hasAlertChannels() bool {
+	for _, channel := range ms.alertManager.GetChannelStats() {
+		if channel.Enabled {
+			return true
+		}
+	}
+	return false
 }
 
-// systemMonitoringLoop handles system resource monitoring
-func (ms *MonitorService) systemMonitoringLoop() {
-	defer ms.wg.Done()
+// buildSchedules returns the schedule of every subsystem: its cron expression if one is
+// configured, otherwise its fixed interval
+func (ms *MonitorService) buildSchedules() (map[string]schedule, error) {
+	dockerInterval := ms.config.DockerChecks.CheckInterval
+	if dockerInterval == 0 {
+		dockerInterval = 60 // Default to 60 seconds
+	}
 
-	ticker := time.NewTicker(time.Duration(ms.config.SystemChecks.Interval) * time.Second)
-	defer ticker.Stop()
+	intervals := map[string]time.Duration{
+		"system": time.Duration(ms.config.SystemChecks.Interval) * time.Second,
+		"docker": time.Duration(dockerInterval) * time.Second,
+	}
+	crons := map[string]string{
+		"system": ms.config.SystemChecks.Schedule,
+		"docker": ms.config.DockerChecks.Schedule,
+	}
+	// Checks are spread by jitter; the system and Docker collections only query the local host
+	jitter := time.Duration(ms.config.CheckJitter) * time.Millisecond
+	jitters := make(map[string]time.Duration)
+	for _, runner := range ms.checkRunners {
+		intervals[runnerSubsystem(runner)] = runner.Interval()
+		jitters[runnerSubsystem(runner)] = jitter
+	}
+	// Every HTTP check runs in its own loop on its own interval
+	for _, check := range ms.config.HTTPChecks {
+		loop := httpLoopName(check)
+		intervals[loop] = time.Duration(check.CheckInterval) * time.Second
+		crons[loop] = check.Schedule
+		jitters[loop] = jitter
+		if check.Jitter > 0 {
+			jitters[loop] = time.Duration(check.Jitter) * time.Millisecond
+		}
+	}
 
-	for {
-		select {
-		case <-ms.stopChan:
-			return
-		case <-ticker.C:
-			ms.collectSystemStats()
+	schedules := make(map[string]schedule, len(intervals))
+	for subsystem, interval := range intervals {
+		sched, err := newSchedule(crons[subsystem], interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s schedule: %w", subsystem, err)
 		}
+		schedules[subsystem] = withJitter(sched, jitters[subsystem])
 	}
+	return schedules, nil
 }
 
-// httpMonitoringLoop handles HTTP endpoint monitoring
-func (ms *MonitorService) httpMonitoringLoop() {
-	defer ms.wg.Done()
+// startDockerMonitoring initializes the Docker monitor and runs its loop once the daemon is reachable
+func (ms *MonitorService) startDockerMonitoring(sched schedule) {
+	if err := ms.dockerMonitor.Initialize(); err != nil {
+		slog.Warn("Failed to initialize Docker monitor", "error", err)
+		ms.startup.set("docker", subsystemFailed, err)
+		ms.wg.Done()
+		return
+	}
 
-	ticker := time.NewTicker(30 * time.Second) // Check every 30 seconds
-	defer ticker.Stop()
+	ms.startup.set("docker", subsystemRunning, nil)
+	ms.scheduleLoop("docker", sched, ms.collectDockerStats)
+}
 
-	for {
-		select {
-		case <-ms.stopChan:
-			return
-		case <-ticker.C:
-			ms.collectHTTPStats()
-		}
-	}
+// SetPublisher enables streaming of check results and alerts (e.g. to NATS)
+func (ms *MonitorService) SetPublisher(publisher ResultPublisher) {
+	ms.publisher = publisher
 }
 
-// dockerMonitoringLoop handles Docker container monitoring
-func (ms *MonitorService) dockerMonitoringLoop() {
-	defer ms.wg.Done()
+// AddExporter forwards system stats and check results to a time series database (e.g. InfluxDB)
+func (ms *MonitorService) AddExporter(exporter MetricsExporter) {
+	ms.exporters = append(ms.exporters, exporter)
+}
+
+// SetAgentReporter makes this instance push its stats to a central Monic instance
+func (ms *MonitorService) SetAgentReporter(reporter *AgentReporter) {
+	ms.agentReporter = reporter
+}
+
+// SetPeerProber runs the HTTP check from peer instances as well, alerting only when it fails
+// from enough locations
+func (ms *MonitorService) SetPeerProber(peers *PeerProber) {
+	ms.peers = peers
+}
 
-	interval := ms.config.DockerChecks.CheckInterval
-	if interval == 0 {
-		interval = 60 // Default to 60 seconds
+// publishCheckResult streams a check result to the publisher and exporters (if configured) and live clients
+func (ms *MonitorService) publishCheckResult(result types.HTTPCheckResult) {
+	if ms.publisher != nil {
+		ms.publisher.PublishCheckResult(result)
 	}
+	for _, exporter := range ms.exporters {
+		exporter.ExportCheckResult(result)
+	}
+	ms.publishEvent(EventCheck, result)
+}
 
-	ticker := time.NewTicker(time.Duration(interval) * time.Second)
-	defer ticker.Stop()
+// publishEvent pushes a live update to the clients of the stats server's event stream
+func (ms *MonitorService) publishEvent(eventType string, data interface{}) {
+	if ms.statsServer != nil {
+		ms.statsServer.events.Publish(eventType, data)
+	}
+}
 
-	for {
-		select {
-		case <-ms.stopChan:
-			return
-		case <-ticker.C:
-			ms.collectDockerStats()
+// Stop gracefully stops the monitoring service
+func (ms *MonitorService) Stop() {
+	slog.Info("Stopping Monic monitoring service...")
+	close(ms.stopChan)
+	ms.wg.Wait()
+	if ms.results != nil {
+		ms.results.flush()
+	}
+	ms.saveAlertState()
+	ms.recordEvent(EventServiceStopped, "monic", "Monitoring service stopped")
+	if ms.statsServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), statsServerShutdownTimeout)
+		if err := ms.statsServer.Shutdown(ctx); err != nil {
+			slog.Error("Error stopping HTTP stats server", "error", err)
 		}
+		cancel()
+	}
+	if ms.agentReporter != nil {
+		ms.agentReporter.Close()
+	}
+	if ms.publisher != nil {
+		ms.publisher.Close()
+	}
+	for _, exporter := range ms.exporters {
+		exporter.Close()
 	}
+	slog.Info("Monic monitoring service stopped")
 }
 
-// alertProcessingLoop handles alert processing and reporting
+// alertProcessingLoop handles alert processing and reporting: every minute, and right away when
+// an alert of an immediate level is raised
 func (ms *MonitorService) alertProcessingLoop() {
 	defer ms.wg.Done()
 
-	ticker := time.NewTicker(60 * time.Second) // Process alerts every minute
+	interval := 60 * time.Second // Process alerts every minute
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ms.stopChan:
 			return
-		case <-ticker.C:
+		case <-ms.alertsRaised:
 			ms.processAlerts()
+			ms.saveAlertState()
+		case <-ticker.C:
+			ms.runCycle("alerting", interval, func() {
+				ms.evaluateSLOs()
+				ms.processAlerts()
+				ms.saveAlertState()
+			})
 		}
 	}
 }
@@ -185,11 +386,15 @@ func (ms *MonitorService) collectSystemStats() {
 
 	// Add to history (keep last 100 entries)
 	ms.storage.AddSystemStats(*stats)
+	for _, exporter := range ms.exporters {
+		exporter.ExportSystemStats(*stats)
+	}
+	ms.publishEvent(EventSystem, *stats)
 
 	// Use state manager to generate alerts with 3 consecutive failures logic
 	alerts := ms.stateManager.UpdateSystemState(stats, &ms.config.SystemChecks)
 	if len(alerts) > 0 {
-		ms.storage.AddAlerts(alerts)
+		ms.raiseAlerts(alerts)
 		slog.Info("System alerts generated", "count", len(alerts))
 	}
 
@@ -200,30 +405,99 @@ func (ms *MonitorService) collectSystemStats() {
 		"disk", ms.getDiskUsageSummary(stats.DiskUsage))
 }
 
-// collectHTTPStats collects and processes HTTP monitoring statistics
-func (ms *MonitorService) collectHTTPStats() {
-	result := ms.httpMonitor.CheckEndpointConcurrent(ms.config.HTTPChecks)
+// validateHTTPChecks validates every HTTP check. Results, history and alert state are kept
+// per check name, so names must be unique; a single check may stay unnamed.
+func (ms *MonitorService) validateHTTPChecks() error {
+	if len(ms.config.HTTPChecks) == 0 {
+		return fmt.Errorf("no HTTP check configured: set MONIC_CHECK_HTTP_URL or MONIC_CHECK_HTTP_0_URL")
+	}
+
+	names := make(map[string]bool, len(ms.config.HTTPChecks))
+	for _, check := range ms.config.HTTPChecks {
+		if err := ms.httpMonitor.ValidateHTTPCheck(check); err != nil {
+			return fmt.Errorf("invalid HTTP check configuration for %s: %w", check.URL, err)
+		}
+		if names[check.Name] {
+			if check.Name == "" {
+				return fmt.Errorf("invalid HTTP check configuration for %s: a name is required when more than one HTTP check is configured", check.URL)
+			}
+			return fmt.Errorf("invalid HTTP check configuration for %s: duplicate name %q", check.URL, check.Name)
+		}
+		names[check.Name] = true
+	}
+	return nil
+}
+
+// httpLoopName returns the schedule loop name of an HTTP check, e.g. "http/api", or "http"
+// for an unnamed check
+func httpLoopName(check types.HTTPCheck) string {
+	if check.Name == "" {
+		return "http"
+	}
+	return "http/" + check.Name
+}
+
+// collectHTTPStats runs an HTTP check and processes its result
+func (ms *MonitorService) collectHTTPStats(check types.HTTPCheck) {
+	result := ms.httpMonitor.CheckEndpointConcurrent(check)
+	if ms.peers != nil {
+		result = ms.peers.Probe(check, result)
+	}
 	results := []types.HTTPCheckResult{result}
 
 	// Add to history (keep last 100 entries)
-	ms.storage.AddHTTPCheckResult(result)
+	ms.storeResults(results)
+	ms.publishCheckResult(result)
 
 	// Use state manager to generate alerts with 3 consecutive failures logic
 	alerts := ms.stateManager.UpdateHTTPState(results)
 	if len(alerts) > 0 {
-		ms.storage.AddAlerts(alerts)
+		ms.raiseAlerts(alerts)
 		slog.Info("HTTP alerts generated", "count", len(alerts))
 	}
 
 	// Log HTTP stats
 	httpStats := ms.httpMonitor.GetHTTPStats(results)
 	slog.Info("HTTP Stats",
+		"check", httpLoopName(check),
 		"total", httpStats["total_checks"],
 		"success", httpStats["successful_checks"],
 		"failed", httpStats["failed_checks"],
 		"rate", fmt.Sprintf("%.1f%%", httpStats["success_rate"]))
 }
 
+// collectCheckRunnerStats collects and processes results of an additional check runner
+func (ms *MonitorService) collectCheckRunnerStats(runner CheckRunner) {
+	results := runner.RunChecks()
+	if len(results) == 0 {
+		return
+	}
+
+	ms.recordCheckResults(runner.Name(), results)
+
+	checkStats := ms.httpMonitor.GetHTTPStats(results)
+	slog.Info(runner.Name()+" Stats",
+		"total", checkStats["total_checks"],
+		"success", checkStats["successful_checks"],
+		"failed", checkStats["failed_checks"],
+		"rate", fmt.Sprintf("%.1f%%", checkStats["success_rate"]))
+}
+
+// recordCheckResults stores check results and queues the alerts they trigger
+func (ms *MonitorService) recordCheckResults(source string, results []types.HTTPCheckResult) {
+	ms.storeResults(results)
+	for _, result := range results {
+		ms.publishCheckResult(result)
+	}
+
+	// Check results share the HTTP state tracking (keyed by check type and name)
+	alerts := ms.stateManager.UpdateHTTPState(results)
+	if len(alerts) > 0 {
+		ms.raiseAlerts(alerts)
+		slog.Info(source+" alerts generated", "count", len(alerts))
+	}
+}
+
 // collectDockerStats collects and processes Docker container statistics
 func (ms *MonitorService) collectDockerStats() {
 	stats, err := ms.dockerMonitor.CheckContainers()
@@ -234,13 +508,58 @@ func (ms *MonitorService) collectDockerStats() {
 
 	// Add to history (keep last 100 entries)
 	ms.storage.AddDockerContainerStats(stats)
+	ms.publishEvent(EventDocker, stats)
+	ms.recordContainerEvents(stats)
+
+	// Check container CPU and memory usage against their thresholds
+	if resourceAlerts := ms.stateManager.UpdateDockerResourceState(stats, &ms.config.DockerChecks); len(resourceAlerts) > 0 {
+		ms.raiseAlerts(resourceAlerts)
+		slog.Info("Docker resource alerts generated", "count", len(resourceAlerts))
+	}
+
+	// Alert when a container's health check turns unhealthy, even if it is still running
+	if healthAlerts := ms.stateManager.UpdateDockerHealthState(stats); len(healthAlerts) > 0 {
+		ms.raiseAlerts(healthAlerts)
+		slog.Info("Docker health alerts generated", "count", len(healthAlerts))
+	}
+
+	// Alert when a container's restart count keeps growing (restart loop)
+	if restartAlerts := ms.stateManager.UpdateDockerRestartState(stats, &ms.config.DockerChecks); len(restartAlerts) > 0 {
+		ms.raiseAlerts(restartAlerts)
+		slog.Info("Docker restart alerts generated", "count", len(restartAlerts))
+	}
+
+	// Compare compose services with their expected number of running replicas
+	if composeAlerts := ms.stateManager.UpdateComposeState(ms.dockerMonitor.ComposeServices(stats)); len(composeAlerts) > 0 {
+		ms.raiseAlerts(composeAlerts)
+		slog.Info("Docker compose alerts generated", "count", len(composeAlerts))
+	}
+
+	// Alert on error patterns in container logs
+	if logAlerts := ms.dockerMonitor.ScanLogs(stats); len(logAlerts) > 0 {
+		ms.raiseAlerts(logAlerts)
+		slog.Info("Docker log alerts generated", "count", len(logAlerts))
+	}
+
+	// Docker's own disk usage, collected less often than the container stats
+	if usage, err := ms.dockerMonitor.CheckDiskUsage(); err != nil {
+		slog.Error("Error collecting Docker disk usage", "error", err)
+	} else if usage != nil {
+		slog.Info("Docker Disk Usage",
+			"total_gb", fmt.Sprintf("%.2f", float64(usage.Total())/(1024*1024*1024)),
+			"reclaimable_gb", fmt.Sprintf("%.2f", float64(usage.Reclaimable)/(1024*1024*1024)))
+		if diskAlerts := ms.stateManager.UpdateDockerDiskState(usage, ms.config.DockerChecks.DiskUsageThreshold); len(diskAlerts) > 0 {
+			ms.raiseAlerts(diskAlerts)
+			slog.Info("Docker disk alerts generated", "count", len(diskAlerts))
+		}
+	}
 
 	// Check for container status alerts
 	alerts, err := ms.dockerMonitor.CheckContainerStatus()
 	if err != nil {
 		slog.Error("Error checking Docker container status", "error", err)
 	} else if len(alerts) > 0 {
-		ms.storage.AddAlerts(alerts)
+		ms.raiseAlerts(alerts)
 		slog.Info("Docker alerts generated", "count", len(alerts))
 	}
 
@@ -253,9 +572,36 @@ func (ms *MonitorService) collectDockerStats() {
 		"percentage", fmt.Sprintf("%.1f%%", summary["running_percentage"]))
 }
 
+// raiseAlerts stores alerts for the alerting loop and wakes it up if one of them has an immediate
+// level, so it doesn't wait for the next alert cycle
+func (ms *MonitorService) raiseAlerts(alerts []types.Alert) {
+	ms.storage.AddAlerts(alerts)
+
+	for _, alert := range alerts {
+		if ms.isImmediate(alert.Level) {
+			select {
+			case ms.alertsRaised <- struct{}{}:
+			default: // The loop is already signaled
+			}
+			return
+		}
+	}
+}
+
+// isImmediate reports whether alerts of a level are sent as soon as they are raised: critical
+// alerts by default, none when the immediate levels are "none"
+func (ms *MonitorService) isImmediate(level string) bool {
+	levels := ms.config.Alerting.ImmediateLevels
+	if len(levels) == 0 {
+		levels = []string{"critical"}
+	}
+	return containsFold(levels, level)
+}
+
 // processAlerts processes and reports alerts
 func (ms *MonitorService) processAlerts() {
-	alerts := ms.storage.GetAlerts()
+	// Taken before sending, so alerts raised while sending are processed next time
+	alerts := ms.storage.TakeAlerts()
 	if len(alerts) == 0 {
 		return
 	}
@@ -265,13 +611,31 @@ func (ms *MonitorService) processAlerts() {
 		slog.Info("ALERT", "level", alert.Level, "type", alert.Type, "message", alert.Message)
 	}
 
-	// Send alerts via configured channels (email, Mailgun, etc.)
-	if err := ms.alertManager.SendAlerts(alerts); err != nil {
-		slog.Error("Failed to send some alerts", "error", err)
+	// Alerts are streamed even while alerting is paused; pausing only mutes the alert channels
+	for _, alert := range alerts {
+		if ms.publisher != nil {
+			ms.publisher.PublishAlert(alert)
+		}
+		ms.publishEvent(EventAlert, alert)
 	}
 
-	// Clear processed alerts
-	ms.storage.ClearAlerts()
+	// Alerting paused (e.g. during maintenance): alerts are logged and recorded instead of sent.
+	// Acknowledged and silenced alerts are recorded but not sent either.
+	paused := ms.startup.isPaused("alerting")
+	send, records := ms.alerts.process(alerts, ms.stateManager.Snapshot(), paused, time.Now())
+	ms.storage.AddAlertRecords(records)
+	if paused {
+		slog.Info("Alerting is paused, alerts not sent", "count", len(alerts))
+	} else if muted := len(alerts) - len(send); muted > 0 {
+		slog.Info("Acknowledged or silenced alerts not sent", "count", muted)
+	}
+
+	// Send alerts via configured channels (email, Mailgun, etc.)
+	if len(send) > 0 {
+		if err := ms.alertManager.SendAlerts(send); err != nil {
+			slog.Error("Failed to send some alerts", "error", err)
+		}
+	}
 }
 
 // getDiskUsageSummary creates a summary of disk usage
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -49,13 +50,15 @@ func TestNewMonitorService(t *testing.T) {
 			DiskThreshold:   90,
 			DiskPaths:       []string{"/"},
 		},
-		HTTPChecks: types.HTTPCheck		{
+		HTTPChecks: []types.HTTPCheck{
+			{
 				URL:            "http://localhost:8080/health",
 				Method:         "GET",
 				Timeout:        10,
 				ExpectedStatus: 200,
 				CheckInterval:  30,
 			},
+		},
 	}
 
 	service := createTestMonitorService(t, config)
@@ -100,7 +103,6 @@ func TestMonitorService_GetDiskUsageSummary(t *testing.T) {
 			DiskThreshold:   90,
 			DiskPaths:       []string{"/"},
 		},
-		HTTPChecks: types.HTTPCheck{},
 	}
 
 	service := createTestMonitorService(t, config)
@@ -142,7 +144,6 @@ func TestMonitorService_GetDiskUsageSummary_Empty(t *testing.T) {
 			DiskThreshold:   90,
 			DiskPaths:       []string{"/"},
 		},
-		HTTPChecks: types.HTTPCheck{},
 	}
 
 	service := createTestMonitorService(t, config)
@@ -209,7 +210,6 @@ func TestMonitorService_ProcessAlerts(t *testing.T) {
 			DiskThreshold:   90,
 			DiskPaths:       []string{"/"},
 		},
-		HTTPChecks: types.HTTPCheck{},
 	}
 
 	service := createTestMonitorService(t, config)
@@ -245,6 +245,170 @@ func TestMonitorService_ProcessAlerts(t *testing.T) {
 	}
 }
 
+// raisingNotifier raises another alert while it sends one, like a check failing during a slow send
+type raisingNotifier struct {
+	storage Storage
+}
+
+func (n *raisingNotifier) Name() string    { return "raising" }
+func (n *raisingNotifier) Validate() error { return nil }
+
+func (n *raisingNotifier) Send(ctx context.Context, a types.Alert, recipient string) error {
+	if a.Type == "cpu" {
+		n.storage.AddAlert(types.Alert{Type: "memory", Level: "critical", Timestamp: time.Now()})
+	}
+	return nil
+}
+
+func TestMonitorService_ProcessAlertsKeepsNewAlerts(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	service.alertManager.RegisterNotifier(&raisingNotifier{storage: service.storage}, true, 0)
+
+	service.storage.AddAlert(types.Alert{Type: "cpu", Level: "critical", Timestamp: time.Now()})
+	service.processAlerts()
+
+	alerts := service.storage.GetAlerts()
+	if len(alerts) != 1 || alerts[0].Type != "memory" {
+		t.Errorf("Expected the alert raised while sending to stay pending, got %+v", alerts)
+	}
+}
+
+func TestMonitorService_RaiseAlerts(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels []string
+		level  string
+		want   bool
+	}{
+		{"critical by default", nil, "critical", true},
+		{"warning waits by default", nil, "warning", false},
+		{"configured levels", []string{"warning", "critical"}, "Warning", true},
+		{"no immediate levels", []string{"none"}, "critical", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := createTestMonitorService(t, &types.Config{Alerting: types.AlertingConfig{ImmediateLevels: tt.levels}})
+			service.raiseAlerts([]types.Alert{{Type: "cpu", Level: tt.level, Timestamp: time.Now()}})
+
+			if service.storage.GetAlertsCount() != 1 {
+				t.Errorf("Expected the alert in storage, got %d alerts", service.storage.GetAlertsCount())
+			}
+			signaled := len(service.alertsRaised) == 1
+			if signaled != tt.want {
+				t.Errorf("Expected signaled = %v, got %v", tt.want, signaled)
+			}
+		})
+	}
+}
+
+func TestMonitorService_AlertLoopSendsImmediately(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	service.wg.Add(1)
+	go service.alertProcessingLoop()
+	defer func() {
+		close(service.stopChan)
+		service.wg.Wait()
+	}()
+
+	// A pending warning goes out along with the critical alert, long before the minutely cycle
+	service.raiseAlerts([]types.Alert{{Type: "memory", Level: "warning", Timestamp: time.Now()}})
+	service.raiseAlerts([]types.Alert{{Type: "http_api", Level: "critical", Timestamp: time.Now()}})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for service.storage.GetAlertsCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the alerts to be processed right away, %d still pending", service.storage.GetAlertsCount())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMonitorService_AlertStatePersistence(t *testing.T) {
+	config := &types.Config{
+		AppName:      "web-01",
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+	}
+	high := &types.SystemStats{CPUUsage: 95}
+
+	// Two failing checks and an alert in its cooldown before the restart
+	service := createTestMonitorService(t, config)
+	service.stateManager.UpdateSystemState(high, &config.SystemChecks)
+	service.stateManager.UpdateSystemState(high, &config.SystemChecks)
+	sentAt := time.Now().Add(-30 * time.Second)
+	service.alertManager.RestoreLastSent(map[string]time.Time{"memory": sentAt})
+	service.alerts.silence("disk", "admin", time.Now().Add(time.Hour))
+	service.saveAlertState()
+
+	restarted := createTestMonitorService(t, config)
+	restarted.storage = service.storage
+	restarted.restoreAlertState()
+
+	// The third failing check after the restart triggers the alert
+	alerts := restarted.stateManager.UpdateSystemState(high, &config.SystemChecks)
+	if len(alerts) != 1 || alerts[0].Type != "cpu" {
+		t.Errorf("Expected a CPU alert on the third consecutive failure, got %+v", alerts)
+	}
+	if lastSent := restarted.alertManager.LastSent()["memory"]; !lastSent.Equal(sentAt) {
+		t.Errorf("Expected restored cooldown from %v, got %v", sentAt, lastSent)
+	}
+	if !restarted.alerts.silenced("disk", time.Now()) {
+		t.Error("Expected the disk silence to be restored")
+	}
+}
+
+func TestMonitorService_AlertHistoryPersistence(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+	}
+	service := createTestMonitorService(t, config)
+	service.storage.AddAlerts([]types.Alert{{Type: "cpu", Level: "critical", Timestamp: time.Now()}, {Type: "disk", Level: "warning", Timestamp: time.Now()}})
+	service.processAlerts()
+
+	// The alerts page of the restarted service still lists the alerts, and new ones continue the IDs
+	restarted := createTestMonitorService(t, config)
+	restarted.storage = service.storage
+	restarted.restoreAlertState()
+	restarted.storage.AddAlert(types.Alert{Type: "memory", Level: "critical", Timestamp: time.Now()})
+	restarted.processAlerts()
+
+	records, total := restarted.alerts.query(alertQuery{Limit: alertHistorySize})
+	if total != 3 || records[0].Type != "memory" || records[0].ID != 3 || records[2].Type != "cpu" {
+		t.Errorf("Expected the restored history followed by the new alert, got %+v", records)
+	}
+}
+
+func TestMonitorService_ValidateHTTPChecks(t *testing.T) {
+	check := func(name, url string) types.HTTPCheck {
+		return types.HTTPCheck{Name: name, URL: url, Method: "GET", Timeout: 5, ExpectedStatus: 200, CheckInterval: 30}
+	}
+	tests := []struct {
+		name    string
+		checks  []types.HTTPCheck
+		wantErr string
+	}{
+		{"single unnamed", []types.HTTPCheck{check("", "https://example.com")}, ""},
+		{"named", []types.HTTPCheck{check("", "https://example.com"), check("api", "https://api.example.com")}, ""},
+		{"none", nil, "no HTTP check configured"},
+		{"two unnamed", []types.HTTPCheck{check("", "https://example.com"), check("", "https://api.example.com")}, "a name is required"},
+		{"duplicate name", []types.HTTPCheck{check("api", "https://example.com"), check("api", "https://api.example.com")}, `duplicate name "api"`},
+		{"invalid", []types.HTTPCheck{check("api", "example.com")}, "URL must start with"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := createTestMonitorService(t, &types.Config{HTTPChecks: tt.checks})
+			err := service.validateHTTPChecks()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Expected valid checks, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 // contains is a helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
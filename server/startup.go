@@ -0,0 +1,135 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Subsystem startup states reported by /api/v1/status
+const (
+	subsystemStarting = "starting"
+	subsystemRunning  = "running"
+	subsystemFailed   = "failed"
+	subsystemDisabled = "disabled"
+)
+
+// enabledState returns the state of a subsystem that starts synchronously
+func enabledState(enabled bool) string {
+	if enabled {
+		return subsystemRunning
+	}
+	return subsystemDisabled
+}
+
+// subsystemStatus is the startup state of a single part of the service
+type subsystemStatus struct {
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	Paused    bool      `json:"paused"` // Paused at runtime via the API or CLI
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// startupTracker records per-subsystem startup status in boot order
+type startupTracker struct {
+	mu       sync.Mutex
+	order    []string
+	statuses map[string]*subsystemStatus
+}
+
+// newStartupTracker creates an empty startup tracker
+func newStartupTracker() *startupTracker {
+	return &startupTracker{statuses: make(map[string]*subsystemStatus)}
+}
+
+// set updates the state of a subsystem (err is recorded for failed subsystems)
+func (t *startupTracker) set(name, state string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, exists := t.statuses[name]
+	if !exists {
+		status = &subsystemStatus{Name: name}
+		t.statuses[name] = status
+		t.order = append(t.order, name)
+	}
+
+	status.State = state
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+	status.UpdatedAt = time.Now()
+}
+
+// snapshot returns a copy of all subsystem statuses in boot order
+func (t *startupTracker) snapshot() []subsystemStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]subsystemStatus, 0, len(t.order))
+	for _, name := range t.order {
+		result = append(result, *t.statuses[name])
+	}
+	return result
+}
+
+// setPaused pauses or resumes a subsystem
+func (t *startupTracker) setPaused(name string, paused bool) (subsystemStatus, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, exists := t.statuses[name]
+	if !exists {
+		return subsystemStatus{}, fmt.Errorf("unknown subsystem: %s", name)
+	}
+	if name == "stats_server" {
+		return subsystemStatus{}, fmt.Errorf("subsystem %s cannot be paused", name)
+	}
+
+	status.Paused = paused
+	status.UpdatedAt = time.Now()
+	return *status, nil
+}
+
+// isPaused reports whether a subsystem is paused
+func (t *startupTracker) isPaused(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, exists := t.statuses[name]
+	return exists && status.Paused
+}
+
+// overall summarizes subsystem states: "degraded" if any failed, "starting" while any is still starting
+func (t *startupTracker) overall() string {
+	state := subsystemRunning
+	for _, status := range t.snapshot() {
+		switch status.State {
+		case subsystemFailed:
+			return "degraded"
+		case subsystemStarting:
+			state = subsystemStarting
+		}
+	}
+	return state
+}
+
+// handleStatus handles GET /api/v1/status
+func (s *StatsServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if s.service == nil {
+		http.Error(w, "Status is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"status":      s.service.startup.overall(),
+		"started_at":  s.service.startTime.Format(time.RFC3339),
+		"uptime":      time.Since(s.service.startTime).String(),
+		"subsystems":  s.service.startup.snapshot(),
+		"cycles":      s.service.cycles.snapshot(),
+		"check_cache": s.service.checkCacheStatuses(),
+	})
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestStartupTracker(t *testing.T) {
+	tracker := newStartupTracker()
+	tracker.set("stats_server", subsystemRunning, nil)
+	tracker.set("docker", subsystemStarting, nil)
+
+	if state := tracker.overall(); state != subsystemStarting {
+		t.Errorf("Expected overall state 'starting', got '%s'", state)
+	}
+
+	tracker.set("docker", subsystemFailed, errors.New("daemon not reachable"))
+	if state := tracker.overall(); state != "degraded" {
+		t.Errorf("Expected overall state 'degraded', got '%s'", state)
+	}
+
+	statuses := tracker.snapshot()
+	if len(statuses) != 2 || statuses[0].Name != "stats_server" || statuses[1].Name != "docker" {
+		t.Fatalf("Expected statuses in boot order, got %+v", statuses)
+	}
+	if statuses[1].Error != "daemon not reachable" {
+		t.Errorf("Expected docker error to be recorded, got '%s'", statuses[1].Error)
+	}
+
+	tracker.set("docker", subsystemDisabled, nil)
+	if state := tracker.overall(); state != subsystemRunning || tracker.snapshot()[1].Error != "" {
+		t.Errorf("Expected running state with cleared error, got %s / %+v", state, tracker.snapshot()[1])
+	}
+}
+
+func TestMonitorService_StartupStatus(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{Interval: 60, CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPChecks:   []types.HTTPCheck{{URL: "http://localhost", Method: "GET", Timeout: 1, ExpectedStatus: 200, CheckInterval: 30}},
+		Alerting:     types.AlertingConfig{Telegram: types.TelegramConfig{Enabled: true, BotToken: "token", ChatID: "chat"}},
+		DockerChecks: types.DockerConfig{Enabled: true},
+		HTTPServer:   types.HTTPServerConfig{Enabled: true, Port: 0},
+	}
+	service := createTestMonitorService(t, config)
+
+	if err := service.Start(); err != nil {
+		t.Fatalf("Failed to start service: %v", err)
+	}
+	defer service.Stop()
+
+	// HTTP checks must not wait for Docker initialization
+	states := make(map[string]string)
+	for _, status := range service.startup.snapshot() {
+		states[status.Name] = status.State
+	}
+	for name, want := range map[string]string{"stats_server": "running", "alerting": "running", "system": "running", "http": "running", "retention": "disabled"} {
+		if states[name] != want {
+			t.Errorf("Expected %s to be %s, got '%s'", name, want, states[name])
+		}
+	}
+
+	// Docker finishes initializing in the background (running with a daemon, failed without)
+	deadline := time.Now().Add(10 * time.Second)
+	for service.startup.overall() == subsystemStarting && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/status", nil)
+	w := httptest.NewRecorder()
+	service.statsServer.routes().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Status     string            `json:"status"`
+		Subsystems []subsystemStatus `json:"subsystems"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Subsystems[0].Name != "stats_server" || response.Subsystems[1].Name != "alerting" {
+		t.Errorf("Expected stats server and alerting to start first, got %+v", response.Subsystems)
+	}
+	for _, status := range response.Subsystems {
+		if status.Name == "docker" && status.State != subsystemRunning && status.State != subsystemFailed {
+			t.Errorf("Expected docker to finish initializing, got '%s'", status.State)
+		}
+	}
+	if response.Status == subsystemStarting {
+		t.Errorf("Expected startup to be complete, got '%s'", response.Status)
+	}
+}
+
+func TestMonitorService_StartFailsOnPortConflict(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	defer listener.Close()
+
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{Interval: 60},
+		HTTPChecks:   []types.HTTPCheck{{URL: "http://localhost", Method: "GET", Timeout: 1, ExpectedStatus: 200, CheckInterval: 30}},
+		Alerting:     types.AlertingConfig{Telegram: types.TelegramConfig{Enabled: true, BotToken: "token", ChatID: "chat"}},
+		HTTPServer:   types.HTTPServerConfig{Enabled: true, Port: listener.Addr().(*net.TCPAddr).Port},
+	}
+	service := createTestMonitorService(t, config)
+
+	if err := service.Start(); err == nil {
+		service.Stop()
+		t.Fatal("Expected start to fail when the stats server port is taken")
+	}
+	if states := service.startup.snapshot(); len(states) != 1 || states[0].State != subsystemFailed {
+		t.Errorf("Expected only a failed stats server, got %+v", states)
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// Component states shown on the public status page
+const (
+	componentOperational = "operational"
+	componentDegraded    = "degraded"
+	componentOutage      = "outage"
+	componentUnknown     = "unknown"
+)
+
+// statusPageComponent is a component as rendered on the public status page. Internal check
+// names and errors are deliberately not included.
+type statusPageComponent struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Text        string `json:"text,omitempty"`
+	State       string `json:"state,omitempty"` // Empty for text-only blocks
+}
+
+// buildStatusComponents maps the latest check results onto the configured components
+func buildStatusComponents(components []types.StatusComponent, latest []types.HTTPCheckResult) []statusPageComponent {
+	result := make([]statusPageComponent, 0, len(components))
+	for _, component := range components {
+		entry := statusPageComponent{
+			Name:        component.Name,
+			Description: component.Description,
+			Text:        component.Text,
+		}
+		if len(component.Checks) > 0 {
+			entry.State = componentState(component.Checks, latest)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// componentState derives a component state from the latest results of its checks
+func componentState(checks []string, latest []types.HTTPCheckResult) string {
+	total, failed := 0, 0
+	for _, result := range latest {
+		if !matchesCheck(result, checks) {
+			continue
+		}
+		total++
+		if !result.Success {
+			failed++
+		}
+	}
+
+	switch {
+	case total == 0:
+		return componentUnknown
+	case failed == 0:
+		return componentOperational
+	case failed == total:
+		return componentOutage
+	default:
+		return componentDegraded
+	}
+}
+
+// overallComponentState summarizes all components: the worst state wins, unknown components are ignored
+func overallComponentState(components []statusPageComponent) string {
+	state := componentOperational
+	for _, component := range components {
+		switch component.State {
+		case componentOutage:
+			return componentOutage
+		case componentDegraded:
+			state = componentDegraded
+		}
+	}
+	return state
+}
+
+// statusPageData builds the data shared by the HTML and JSON status page
+func (s *StatsServer) statusPageData() map[string]interface{} {
+	title := s.config.StatusPage.Title
+	if title == "" {
+		title = "Service Status"
+	}
+
+	components := buildStatusComponents(s.config.StatusPage.Components, latestCheckResults(s.storage))
+	return map[string]interface{}{
+		"title":      title,
+		"status":     overallComponentState(components),
+		"components": components,
+		"updated_at": time.Now().Format(time.RFC3339),
+	}
+}
+
+// handleStatusPage handles GET /status (public, no authentication)
+func (s *StatsServer) handleStatusPage(w http.ResponseWriter, r *http.Request) {
+	s.renderHTML(w, "templates/status.html", s.statusPageData())
+}
+
+// handleStatusPageJSON handles GET /status.json (public, no authentication)
+func (s *StatsServer) handleStatusPageJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.statusPageData())
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestBuildStatusComponents(t *testing.T) {
+	now := time.Now()
+	storage := NewStorageManager(100)
+	for _, result := range []types.HTTPCheckResult{
+		{Name: "api-eu", Success: true, Timestamp: now},
+		{Name: "api-us", Success: false, Timestamp: now},
+		{Name: "db", Type: "grpc", Success: false, Timestamp: now},
+		{Name: "website", Success: false, Timestamp: now.Add(-time.Minute)},
+		{Name: "website", Success: true, Timestamp: now},
+	} {
+		storage.AddHTTPCheckResult(result)
+	}
+	components := []types.StatusComponent{
+		{Name: "API", Checks: []string{"api-eu", "api-us"}},
+		{Name: "Database", Checks: []string{"grpc/db"}},
+		{Name: "Website", Checks: []string{"website"}},
+		{Name: "Billing", Checks: []string{"billing"}},
+		{Name: "Maintenance", Text: "Planned upgrade on Sunday"},
+	}
+
+	result := buildStatusComponents(components, latestCheckResults(storage))
+	want := []string{componentDegraded, componentOutage, componentOperational, componentUnknown, ""}
+	for i, state := range want {
+		if result[i].State != state {
+			t.Errorf("Component %s: expected state %q, got %q", result[i].Name, state, result[i].State)
+		}
+	}
+
+	if overall := overallComponentState(result); overall != componentOutage {
+		t.Errorf("Expected overall outage, got %s", overall)
+	}
+	if overall := overallComponentState(result[2:]); overall != componentOperational {
+		t.Errorf("Expected overall operational ignoring unknown components, got %s", overall)
+	}
+}
+
+func TestStatsServer_StatusPage(t *testing.T) {
+	storage := NewStorageManager(100)
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "internal-api-check", Success: true, Timestamp: time.Now()})
+
+	config := &types.HTTPServerConfig{
+		Username: "admin",
+		Password: "secret",
+		StatusPage: types.StatusPageConfig{
+			Enabled: true,
+			Title:   "Acme Status",
+			Components: []types.StatusComponent{
+				{Name: "API", Description: "Public REST API", Checks: []string{"internal-api-check"}},
+			},
+		},
+	}
+	mux := NewStatsServer(config, nil, storage, nil).routes()
+
+	// Served without credentials
+	req := httptest.NewRequest("GET", "/status", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Acme Status", "Public REST API", "All systems operational"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected status page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "internal-api-check") {
+		t.Error("Status page must not expose internal check names")
+	}
+
+	req = httptest.NewRequest("GET", "/status.json", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var response struct {
+		Status     string                `json:"status"`
+		Components []statusPageComponent `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Status != componentOperational || len(response.Components) != 1 || response.Components[0].State != componentOperational {
+		t.Errorf("Unexpected status response: %+v", response)
+	}
+
+	// Not registered when disabled
+	config.StatusPage.Enabled = false
+	mux = NewStatsServer(config, nil, storage, nil).routes()
+	req = httptest.NewRequest("GET", "/status", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when the status page is disabled, got %d", w.Code)
+	}
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,15 +16,179 @@ type Storage interface {
 	GetLatestSystemStats() *types.SystemStats
 	GetAlertsCount() int
 	GetHTTPCheckResults() []types.HTTPCheckResult
+	GetCheckStatuses() []CheckStatus
 	GetAlerts() []types.Alert
+	GetLatestDockerContainerStats() []types.DockerContainerStats
 	
 	// Methods used by MonitorService
 	AddSystemStats(stats types.SystemStats)
 	AddAlert(alert types.Alert)
 	AddAlerts(alerts []types.Alert)
 	AddHTTPCheckResult(result types.HTTPCheckResult)
+	AddHTTPCheckResults(results []types.HTTPCheckResult) // One write for a batch of results
 	AddDockerContainerStats(stats []types.DockerContainerStats)
 	ClearAlerts()
+	TakeAlerts() []types.Alert // Returns and removes the pending alerts in one step
+
+	// Methods used by SupportBundle
+	GetStatus() map[string]interface{}
+
+	// Methods used by retention policies and the history API
+	PurgeHistory(dataType string, before time.Time) (int, error)
+	AddAuditEntry(entry types.PurgeAuditEntry)
+	GetAuditEntries() []types.PurgeAuditEntry
+	// Entries with a timestamp between from and to (inclusive), oldest first
+	GetSystemStatsRange(from, to time.Time) []types.SystemStats
+	GetHTTPCheckResultsRange(from, to time.Time) []types.HTTPCheckResult
+	GetDockerContainerStatsRange(from, to time.Time) []types.DockerContainerStats
+
+	// Lifecycle events (restarts, checks added, containers started), kept apart from alerts
+	AddEvent(event types.LifecycleEvent)
+	GetEventsRange(from, to time.Time) []types.LifecycleEvent
+
+	// History of processed alerts shown on the alerts page and API, kept across restarts
+	AddAlertRecords(records []types.AlertRecord)
+	GetAlertRecords(limit int) []types.AlertRecord // The newest records, oldest first
+
+	// Alerting state of an instance, kept across restarts. LoadAlertState returns nil if none was saved.
+	SaveAlertState(instance string, state types.SavedAlertState) error
+	LoadAlertState(instance string) (*types.SavedAlertState, error)
+
+	// Close releases resources held by the storage backend
+	Close() error
+}
+
+// compacter is implemented by storage backends whose file does not shrink when entries are removed
+type compacter interface {
+	// Compact reclaims free space if worthwhile, or always when forced, and returns the file
+	// size before and after
+	Compact(force bool) (before, after int64, err error)
+}
+
+// compactThreshold is the share of free space in a storage file above which it is compacted
+// after a purge
+const compactThreshold = 0.5
+
+// History data types that can be purged
+const (
+	HistoryAlerts      = "alerts"
+	HistorySystemStats = "system_stats"
+	HistoryChecks      = "checks" // HTTP, gRPC and mail check results
+	HistoryDocker      = "docker"
+	HistoryEvents      = "events" // Lifecycle events
+)
+
+// HistoryTypes lists all purgeable history data types
+var HistoryTypes = []string{HistoryAlerts, HistorySystemStats, HistoryChecks, HistoryDocker, HistoryEvents}
+
+// Default history sizes per storage driver
+const (
+	defaultMemoryHistorySize  = 100
+	defaultDurableHistorySize = 10000
+)
+
+// NewStorage creates the storage backend selected by the configuration
+func NewStorage(config *types.StorageConfig) (Storage, error) {
+	maxHistory := config.MaxHistory
+	driver := strings.ToLower(config.Driver)
+
+	if maxHistory <= 0 && driver != "" && driver != "memory" {
+		maxHistory = defaultDurableHistorySize
+	}
+
+	var storage interface {
+		Storage
+		setHistorySizes(sizes map[string]int)
+	}
+	var err error
+	switch driver {
+	case "", "memory":
+		storage = NewStorageManager(maxHistory)
+	case "bolt", "boltdb":
+		path := config.Path
+		if path == "" {
+			path = "monic.db"
+		}
+		storage, err = NewBoltStorage(path, maxHistory)
+	case "sqlite", "sqlite3":
+		path := config.Path
+		if path == "" {
+			path = "monic.sqlite"
+		}
+		storage, err = NewSQLiteStorage(path, maxHistory)
+	case "postgres", "postgresql":
+		if config.DSN == "" {
+			return nil, fmt.Errorf("postgres storage requires a DSN")
+		}
+		storage, err = NewPostgresStorage(config.DSN, maxHistory)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", config.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Count limits per history type, on top of the age limits applied by the retention loop
+	storage.setHistorySizes(historySizes(&config.Retention))
+	return storage, nil
+}
+
+// historyLimits holds the number of entries kept per history type
+type historyLimits struct {
+	maxHistorySize int            // Default for all types
+	historySizes   map[string]int // Overrides by history type
+}
+
+// historySize returns the number of entries kept of a history type ("" for the default)
+func (l *historyLimits) historySize(dataType string) int {
+	if size := l.historySizes[dataType]; size > 0 {
+		return size
+	}
+	return l.maxHistorySize
+}
+
+// setHistorySizes overrides the number of entries kept of some history types. It is called
+// before the storage is used.
+func (l *historyLimits) setHistorySizes(sizes map[string]int) {
+	l.historySizes = sizes
+}
+
+// allHistorySizes returns the number of entries kept per history type, for status reports
+func (l *historyLimits) allHistorySizes() map[string]int {
+	sizes := make(map[string]int, len(HistoryTypes))
+	for _, dataType := range HistoryTypes {
+		sizes[dataType] = l.historySize(dataType)
+	}
+	return sizes
+}
+
+// historyTypeOf returns the history type kept in a table or bucket, "" for other data such as
+// the audit log
+func historyTypeOf(names map[string]string, name string) string {
+	for dataType, n := range names {
+		if n == name {
+			return dataType
+		}
+	}
+	return ""
+}
+
+// historySizes returns the configured count limits per history type
+func historySizes(config *types.RetentionConfig) map[string]int {
+	sizes := make(map[string]int)
+	counts := map[string]int{
+		HistoryAlerts:      config.AlertsMax,
+		HistorySystemStats: config.StatsMax,
+		HistoryChecks:      config.ChecksMax,
+		HistoryDocker:      config.DockerMax,
+		HistoryEvents:      config.EventsMax,
+	}
+	for dataType, count := range counts {
+		if count > 0 {
+			sizes[dataType] = count
+		}
+	}
+	return sizes
 }
 
 // StorageManager provides thread-safe storage for monitoring data
@@ -30,19 +197,28 @@ type StorageManager struct {
 	statsHistory  []types.SystemStats
 	httpHistory   []types.HTTPCheckResult
 	dockerHistory []types.DockerContainerStats
+	eventLog      []types.LifecycleEvent
+	auditLog      []types.PurgeAuditEntry
+	alertRecords  []types.AlertRecord
+	alertStates   map[string]types.SavedAlertState
 
 	alertsMu        sync.RWMutex
 	statsHistoryMu  sync.RWMutex
 	httpHistoryMu   sync.RWMutex
 	dockerHistoryMu sync.RWMutex
+	eventLogMu      sync.RWMutex
+	auditLogMu      sync.RWMutex
+	alertRecordsMu  sync.RWMutex
+	alertStatesMu   sync.RWMutex
 
-	maxHistorySize int
+	historyLimits
+	checkIndex
 }
 
 // NewStorageManager creates a new thread-safe storage manager
 func NewStorageManager(maxHistorySize int) *StorageManager {
 	if maxHistorySize <= 0 {
-		maxHistorySize = 100 // Default to 100 entries
+		maxHistorySize = defaultMemoryHistorySize
 	}
 
 	return &StorageManager{
@@ -50,7 +226,8 @@ func NewStorageManager(maxHistorySize int) *StorageManager {
 		statsHistory:  make([]types.SystemStats, 0),
 		httpHistory:   make([]types.HTTPCheckResult, 0),
 		dockerHistory: make([]types.DockerContainerStats, 0),
-		maxHistorySize: maxHistorySize,
+		alertStates:   make(map[string]types.SavedAlertState),
+		historyLimits: historyLimits{maxHistorySize: maxHistorySize},
 	}
 }
 
@@ -59,10 +236,7 @@ func (sm *StorageManager) AddAlert(alert types.Alert) {
 	sm.alertsMu.Lock()
 	defer sm.alertsMu.Unlock()
 
-	sm.alerts = append(sm.alerts, alert)
-	if len(sm.alerts) > sm.maxHistorySize {
-		sm.alerts = sm.alerts[1:]
-	}
+	sm.alerts = trimHistory(append(sm.alerts, alert), sm.historySize(HistoryAlerts))
 }
 
 // AddAlerts adds multiple alerts to storage
@@ -74,10 +248,7 @@ func (sm *StorageManager) AddAlerts(alerts []types.Alert) {
 	sm.alertsMu.Lock()
 	defer sm.alertsMu.Unlock()
 
-	sm.alerts = append(sm.alerts, alerts...)
-	if len(sm.alerts) > sm.maxHistorySize {
-		sm.alerts = sm.alerts[len(sm.alerts)-sm.maxHistorySize:]
-	}
+	sm.alerts = trimHistory(append(sm.alerts, alerts...), sm.historySize(HistoryAlerts))
 }
 
 // GetAlerts returns all alerts
@@ -99,15 +270,22 @@ func (sm *StorageManager) ClearAlerts() {
 	sm.alerts = make([]types.Alert, 0)
 }
 
+// TakeAlerts returns all alerts and removes them from storage, so alerts added meanwhile stay
+func (sm *StorageManager) TakeAlerts() []types.Alert {
+	sm.alertsMu.Lock()
+	defer sm.alertsMu.Unlock()
+
+	alerts := sm.alerts
+	sm.alerts = make([]types.Alert, 0)
+	return alerts
+}
+
 // AddSystemStats adds system stats to history
 func (sm *StorageManager) AddSystemStats(stats types.SystemStats) {
 	sm.statsHistoryMu.Lock()
 	defer sm.statsHistoryMu.Unlock()
 
-	sm.statsHistory = append(sm.statsHistory, stats)
-	if len(sm.statsHistory) > sm.maxHistorySize {
-		sm.statsHistory = sm.statsHistory[1:]
-	}
+	sm.statsHistory = trimHistory(append(sm.statsHistory, stats), sm.historySize(HistorySystemStats))
 }
 
 // GetSystemStats returns all system stats history
@@ -139,10 +317,21 @@ func (sm *StorageManager) AddHTTPCheckResult(result types.HTTPCheckResult) {
 	sm.httpHistoryMu.Lock()
 	defer sm.httpHistoryMu.Unlock()
 
-	sm.httpHistory = append(sm.httpHistory, result)
-	if len(sm.httpHistory) > sm.maxHistorySize {
-		sm.httpHistory = sm.httpHistory[1:]
+	sm.httpHistory = trimHistory(append(sm.httpHistory, result), sm.historySize(HistoryChecks))
+	sm.indexResult(result)
+}
+
+// AddHTTPCheckResults adds multiple HTTP check results to history
+func (sm *StorageManager) AddHTTPCheckResults(results []types.HTTPCheckResult) {
+	if len(results) == 0 {
+		return
 	}
+
+	sm.httpHistoryMu.Lock()
+	defer sm.httpHistoryMu.Unlock()
+
+	sm.httpHistory = trimHistory(append(sm.httpHistory, results...), sm.historySize(HistoryChecks))
+	sm.indexResults(results)
 }
 
 // GetHTTPCheckResults returns all HTTP check results
@@ -157,16 +346,14 @@ func (sm *StorageManager) GetHTTPCheckResults() []types.HTTPCheckResult {
 
 // GetLatestHTTPCheckResult returns the most recent HTTP check result for a given name
 func (sm *StorageManager) GetLatestHTTPCheckResult(name string) *types.HTTPCheckResult {
-	sm.httpHistoryMu.RLock()
-	defer sm.httpHistoryMu.RUnlock()
-
-	for i := len(sm.httpHistory) - 1; i >= 0; i-- {
-		if sm.httpHistory[i].Name == name {
-			result := sm.httpHistory[i]
-			return &result
+	var latest *types.HTTPCheckResult
+	for _, status := range sm.GetCheckStatuses() {
+		if status.Latest.Name == name && (latest == nil || status.Latest.Timestamp.After(latest.Timestamp)) {
+			result := status.Latest
+			latest = &result
 		}
 	}
-	return nil
+	return latest
 }
 
 // AddDockerContainerStats adds Docker container stats to history
@@ -178,10 +365,7 @@ func (sm *StorageManager) AddDockerContainerStats(stats []types.DockerContainerS
 	sm.dockerHistoryMu.Lock()
 	defer sm.dockerHistoryMu.Unlock()
 
-	sm.dockerHistory = append(sm.dockerHistory, stats...)
-	if len(sm.dockerHistory) > sm.maxHistorySize {
-		sm.dockerHistory = sm.dockerHistory[len(sm.dockerHistory)-sm.maxHistorySize:]
-	}
+	sm.dockerHistory = trimHistory(append(sm.dockerHistory, stats...), sm.historySize(HistoryDocker))
 }
 
 // GetDockerContainerStats returns all Docker container stats
@@ -194,29 +378,77 @@ func (sm *StorageManager) GetDockerContainerStats() []types.DockerContainerStats
 	return result
 }
 
+// GetLatestDockerContainerStats returns the container stats of the most recent collection
+func (sm *StorageManager) GetLatestDockerContainerStats() []types.DockerContainerStats {
+	return latestDockerStats(sm.GetDockerContainerStats())
+}
+
+// latestDockerStats returns the entries of the most recent collection, which share one timestamp
+func latestDockerStats(history []types.DockerContainerStats) []types.DockerContainerStats {
+	var latest []types.DockerContainerStats
+	for _, stats := range history {
+		switch {
+		case len(latest) == 0 || stats.Timestamp.After(latest[0].Timestamp):
+			latest = []types.DockerContainerStats{stats}
+		case stats.Timestamp.Equal(latest[0].Timestamp):
+			latest = append(latest, stats)
+		}
+	}
+	return latest
+}
+
 // GetStatus returns the current status of storage
 func (sm *StorageManager) GetStatus() map[string]interface{} {
 	sm.alertsMu.RLock()
 	sm.statsHistoryMu.RLock()
 	sm.httpHistoryMu.RLock()
 	sm.dockerHistoryMu.RLock()
+	sm.eventLogMu.RLock()
 	defer func() {
 		sm.alertsMu.RUnlock()
 		sm.statsHistoryMu.RUnlock()
 		sm.httpHistoryMu.RUnlock()
 		sm.dockerHistoryMu.RUnlock()
+		sm.eventLogMu.RUnlock()
 	}()
 
 	return map[string]interface{}{
+		"driver":              "memory",
 		"alerts_count":        len(sm.alerts),
 		"stats_history_count": len(sm.statsHistory),
 		"http_history_count":  len(sm.httpHistory),
 		"docker_history_count": len(sm.dockerHistory),
+		"events_count":        len(sm.eventLog),
 		"max_history_size":    sm.maxHistorySize,
-		"timestamp":           time.Now().Format(time.RFC3339),
+		"max_history_sizes":   sm.allHistorySizes(),
+		"size_bytes": map[string]int64{
+			HistoryAlerts:      estimatedSize(sm.alerts),
+			HistorySystemStats: estimatedSize(sm.statsHistory),
+			HistoryChecks:      estimatedSize(sm.httpHistory),
+			HistoryDocker:      estimatedSize(sm.dockerHistory),
+			HistoryEvents:      estimatedSize(sm.eventLog),
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
 	}
 }
 
+// sizeSampleCount is the number of entries whose size is measured to estimate a history's size
+const sizeSampleCount = 100
+
+// estimatedSize estimates the memory used by entries from the encoded size of the newest ones.
+// It is a rough figure for capacity planning, not an exact count of allocated bytes.
+func estimatedSize[T any](items []T) int64 {
+	sample := items[max(0, len(items)-sizeSampleCount):]
+	if len(sample) == 0 {
+		return 0
+	}
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data)) * int64(len(items)) / int64(len(sample))
+}
+
 // GetAlertsCount returns the number of alerts
 func (sm *StorageManager) GetAlertsCount() int {
 	sm.alertsMu.RLock()
@@ -230,3 +462,170 @@ func (sm *StorageManager) GetDockerContainerStatsCount() int {
 	defer sm.dockerHistoryMu.RUnlock()
 	return len(sm.dockerHistory)
 }
+
+// Close is a no-op for in-memory storage
+func (sm *StorageManager) Close() error {
+	return nil
+}
+
+// trimHistory drops the oldest entries beyond size
+func trimHistory[T any](items []T, size int) []T {
+	if len(items) > size {
+		return items[len(items)-size:]
+	}
+	return items
+}
+
+// inRange returns the entries with a timestamp between from and to (inclusive)
+func inRange[T any](items []T, from, to time.Time, timestamp func(T) time.Time) []T {
+	result := make([]T, 0)
+	for _, item := range items {
+		if ts := timestamp(item); !ts.Before(from) && !ts.After(to) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// GetSystemStatsRange returns the system stats collected between from and to
+func (sm *StorageManager) GetSystemStatsRange(from, to time.Time) []types.SystemStats {
+	sm.statsHistoryMu.RLock()
+	defer sm.statsHistoryMu.RUnlock()
+	return inRange(sm.statsHistory, from, to, func(s types.SystemStats) time.Time { return s.Timestamp })
+}
+
+// GetHTTPCheckResultsRange returns the check results recorded between from and to
+func (sm *StorageManager) GetHTTPCheckResultsRange(from, to time.Time) []types.HTTPCheckResult {
+	sm.httpHistoryMu.RLock()
+	defer sm.httpHistoryMu.RUnlock()
+	return inRange(sm.httpHistory, from, to, func(r types.HTTPCheckResult) time.Time { return r.Timestamp })
+}
+
+// GetDockerContainerStatsRange returns the container stats collected between from and to
+func (sm *StorageManager) GetDockerContainerStatsRange(from, to time.Time) []types.DockerContainerStats {
+	sm.dockerHistoryMu.RLock()
+	defer sm.dockerHistoryMu.RUnlock()
+	return inRange(sm.dockerHistory, from, to, func(d types.DockerContainerStats) time.Time { return d.Timestamp })
+}
+
+// AddEvent records a lifecycle event
+func (sm *StorageManager) AddEvent(event types.LifecycleEvent) {
+	sm.eventLogMu.Lock()
+	defer sm.eventLogMu.Unlock()
+
+	sm.eventLog = trimHistory(append(sm.eventLog, event), sm.historySize(HistoryEvents))
+}
+
+// GetEventsRange returns the lifecycle events recorded between from and to
+func (sm *StorageManager) GetEventsRange(from, to time.Time) []types.LifecycleEvent {
+	sm.eventLogMu.RLock()
+	defer sm.eventLogMu.RUnlock()
+	return inRange(sm.eventLog, from, to, func(e types.LifecycleEvent) time.Time { return e.Timestamp })
+}
+
+// purgeBefore removes entries older than before (all entries when before is zero) and returns the remaining entries
+func purgeBefore[T any](items []T, before time.Time, timestamp func(T) time.Time) ([]T, int) {
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		if !before.IsZero() && !timestamp(item).Before(before) {
+			kept = append(kept, item)
+		}
+	}
+	return kept, len(items) - len(kept)
+}
+
+// PurgeHistory removes entries of a data type recorded before the given time (all entries when before is zero)
+func (sm *StorageManager) PurgeHistory(dataType string, before time.Time) (int, error) {
+	var deleted int
+
+	switch dataType {
+	case HistoryAlerts:
+		sm.alertsMu.Lock()
+		sm.alerts, deleted = purgeBefore(sm.alerts, before, func(a types.Alert) time.Time { return a.Timestamp })
+		sm.alertsMu.Unlock()
+	case HistorySystemStats:
+		sm.statsHistoryMu.Lock()
+		sm.statsHistory, deleted = purgeBefore(sm.statsHistory, before, func(s types.SystemStats) time.Time { return s.Timestamp })
+		sm.statsHistoryMu.Unlock()
+	case HistoryChecks:
+		sm.httpHistoryMu.Lock()
+		sm.httpHistory, deleted = purgeBefore(sm.httpHistory, before, func(r types.HTTPCheckResult) time.Time { return r.Timestamp })
+		sm.rebuildIndex(sm.httpHistory)
+		sm.httpHistoryMu.Unlock()
+	case HistoryDocker:
+		sm.dockerHistoryMu.Lock()
+		sm.dockerHistory, deleted = purgeBefore(sm.dockerHistory, before, func(d types.DockerContainerStats) time.Time { return d.Timestamp })
+		sm.dockerHistoryMu.Unlock()
+	case HistoryEvents:
+		sm.eventLogMu.Lock()
+		sm.eventLog, deleted = purgeBefore(sm.eventLog, before, func(e types.LifecycleEvent) time.Time { return e.Timestamp })
+		sm.eventLogMu.Unlock()
+	default:
+		return 0, fmt.Errorf("unknown history type: %s", dataType)
+	}
+
+	return deleted, nil
+}
+
+// AddAuditEntry records a history purge
+func (sm *StorageManager) AddAuditEntry(entry types.PurgeAuditEntry) {
+	sm.auditLogMu.Lock()
+	defer sm.auditLogMu.Unlock()
+
+	sm.auditLog = trimHistory(append(sm.auditLog, entry), sm.maxHistorySize)
+}
+
+// GetAuditEntries returns all recorded history purges
+func (sm *StorageManager) GetAuditEntries() []types.PurgeAuditEntry {
+	sm.auditLogMu.RLock()
+	defer sm.auditLogMu.RUnlock()
+
+	result := make([]types.PurgeAuditEntry, len(sm.auditLog))
+	copy(result, sm.auditLog)
+	return result
+}
+
+// AddAlertRecords adds processed alerts to the alert history
+func (sm *StorageManager) AddAlertRecords(records []types.AlertRecord) {
+	if len(records) == 0 {
+		return
+	}
+
+	sm.alertRecordsMu.Lock()
+	defer sm.alertRecordsMu.Unlock()
+
+	sm.alertRecords = trimHistory(append(sm.alertRecords, records...), alertHistorySize)
+}
+
+// GetAlertRecords returns up to limit of the newest alert history records, oldest first
+func (sm *StorageManager) GetAlertRecords(limit int) []types.AlertRecord {
+	sm.alertRecordsMu.RLock()
+	defer sm.alertRecordsMu.RUnlock()
+
+	records := trimHistory(sm.alertRecords, limit)
+	result := make([]types.AlertRecord, len(records))
+	copy(result, records)
+	return result
+}
+
+// SaveAlertState keeps the alerting state of an instance; in memory it only survives a restart
+// of the service, not of the process
+func (sm *StorageManager) SaveAlertState(instance string, state types.SavedAlertState) error {
+	sm.alertStatesMu.Lock()
+	defer sm.alertStatesMu.Unlock()
+
+	sm.alertStates[instance] = state
+	return nil
+}
+
+// LoadAlertState returns the saved alerting state of an instance, nil if none was saved
+func (sm *StorageManager) LoadAlertState(instance string) (*types.SavedAlertState, error) {
+	sm.alertStatesMu.RLock()
+	defer sm.alertStatesMu.RUnlock()
+
+	state, exists := sm.alertStates[instance]
+	if !exists {
+		return nil, nil
+	}
+	return &state, nil
+}
@@ -0,0 +1,634 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltDB bucket names, one per history type
+var (
+	boltAlertsBucket       = []byte("alerts")
+	boltSystemStatsBucket  = []byte("system_stats")
+	boltHTTPResultsBucket  = []byte("http_results")
+	boltDockerStatsBucket  = []byte("docker_stats")
+	boltEventsBucket       = []byte("events")
+	boltAuditBucket        = []byte("audit")
+	boltAlertHistoryBucket = []byte("alert_history") // Processed alerts of the alerts page
+	boltAlertStateBucket   = []byte("alert_state")   // Keyed by instance
+)
+
+// boltHistoryBuckets maps purgeable history types to their buckets
+var boltHistoryBuckets = map[string][]byte{
+	HistoryAlerts:      boltAlertsBucket,
+	HistorySystemStats: boltSystemStatsBucket,
+	HistoryChecks:      boltHTTPResultsBucket,
+	HistoryDocker:      boltDockerStatsBucket,
+	HistoryEvents:      boltEventsBucket,
+}
+
+// boltCompactTxSize is the amount of data copied per transaction while compacting
+const boltCompactTxSize = 64 << 20
+
+// boltHistoryBucketNames maps purgeable history types to their bucket names
+var boltHistoryBucketNames = func() map[string]string {
+	names := make(map[string]string, len(boltHistoryBuckets))
+	for dataType, bucket := range boltHistoryBuckets {
+		names[dataType] = string(bucket)
+	}
+	return names
+}()
+
+// BoltStorage persists monitoring data in a single BoltDB file (pure Go, no external service)
+type BoltStorage struct {
+	mu            sync.RWMutex // Guards db, which compaction replaces
+	db            *bolt.DB
+	path          string
+	historyLimits // Entries kept per history type
+	checkIndex    // Latest result per check
+}
+
+// NewBoltStorage opens (or creates) a BoltDB file for monitoring data
+func NewBoltStorage(path string, maxHistorySize int) (*BoltStorage, error) {
+	if maxHistorySize <= 0 {
+		maxHistorySize = defaultDurableHistorySize
+	}
+
+	// A compaction interrupted while swapping the files leaves the original aside
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if os.Rename(path+".old", path) == nil {
+			slog.Warn("Restored bolt database from an interrupted compaction", "path", path)
+		}
+	}
+
+	db, err := openBolt(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt database %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{boltAlertsBucket, boltSystemStatsBucket, boltHTTPResultsBucket, boltDockerStatsBucket, boltEventsBucket, boltAuditBucket, boltAlertHistoryBucket, boltAlertStateBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create bolt buckets: %w", err)
+	}
+
+	storage := &BoltStorage{
+		db:            db,
+		path:          path,
+		historyLimits: historyLimits{maxHistorySize: maxHistorySize},
+	}
+	storage.rebuildIndex(storage.GetHTTPCheckResults())
+	return storage, nil
+}
+
+// openBolt opens a BoltDB file without waiting long for the lock of another process (a variable
+// so tests can make it fail)
+var openBolt = func(path string) (*bolt.DB, error) {
+	return bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+}
+
+// view runs a read-only transaction
+func (bs *BoltStorage) view(fn func(tx *bolt.Tx) error) error {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return bs.db.View(fn)
+}
+
+// update runs a read-write transaction
+func (bs *BoltStorage) update(fn func(tx *bolt.Tx) error) error {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return bs.db.Update(fn)
+}
+
+// freeRatio returns the share of the file taken by free pages
+func (bs *BoltStorage) freeRatio() float64 {
+	var size int64
+	bs.view(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	if size == 0 {
+		return 0
+	}
+	return float64(bs.freeBytes()) / float64(size)
+}
+
+// Compact rewrites the file without its free pages when they make up more than half of it (or
+// when forced), and returns the file size before and after. BoltDB reuses freed pages but never
+// shrinks its file, so purging a large history leaves it at its peak size. Reads and writes wait
+// while the data is copied.
+func (bs *BoltStorage) Compact(force bool) (int64, int64, error) {
+	if !force && bs.freeRatio() < compactThreshold {
+		size := fileSize(bs.path)
+		return size, size, nil
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	before := fileSize(bs.path)
+	tmpPath := bs.path + ".compact"
+	os.Remove(tmpPath) // Left over from an interrupted compaction
+
+	dst, err := openBolt(tmpPath)
+	if err != nil {
+		return before, before, fmt.Errorf("failed to create compacted bolt database: %w", err)
+	}
+	if err := bolt.Compact(dst, bs.db, boltCompactTxSize); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return before, before, fmt.Errorf("failed to compact bolt database: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return before, before, fmt.Errorf("failed to write compacted bolt database: %w", err)
+	}
+
+	// Swap the files, keeping the original aside until the compacted one is open, so a failed
+	// swap puts it back and the storage continues on it
+	if err := bs.db.Close(); err != nil {
+		os.Remove(tmpPath)
+		return before, before, fmt.Errorf("failed to close bolt database: %w", err)
+	}
+	oldPath := bs.path + ".old"
+	if err := os.Rename(bs.path, oldPath); err != nil {
+		return before, before, bs.restore(oldPath, tmpPath, fmt.Errorf("failed to replace bolt database: %w", err))
+	}
+	if err := os.Rename(tmpPath, bs.path); err != nil {
+		return before, before, bs.restore(oldPath, tmpPath, fmt.Errorf("failed to replace bolt database: %w", err))
+	}
+	db, err := openBolt(bs.path)
+	if err != nil {
+		return before, before, bs.restore(oldPath, tmpPath, fmt.Errorf("failed to open compacted bolt database: %w", err))
+	}
+	bs.db = db
+	os.Remove(oldPath)
+
+	return before, fileSize(bs.path), nil
+}
+
+// restore moves the original file back after a failed compaction swap and reopens it, returning
+// the cause of the failure. Called with the lock held and the database closed.
+func (bs *BoltStorage) restore(oldPath, tmpPath string, cause error) error {
+	os.Remove(tmpPath)
+	if _, err := os.Stat(oldPath); err == nil {
+		os.Remove(bs.path)
+		if err := os.Rename(oldPath, bs.path); err != nil {
+			return fmt.Errorf("%w; failed to restore the original from %s: %v", cause, oldPath, err)
+		}
+	}
+	db, err := openBolt(bs.path)
+	if err != nil {
+		return fmt.Errorf("%w; failed to reopen the original: %v", cause, err)
+	}
+	bs.db = db
+	return cause
+}
+
+// fileSize returns the size of a file, 0 if it can't be read
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// boltKey encodes a sequence number as a sortable key
+func boltKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// boltCount returns the number of entries in a bucket. Keys are sequential and only
+// ever removed from the front, so the count is derived from the first key and the sequence.
+func boltCount(b *bolt.Bucket) int {
+	first, _ := b.Cursor().First()
+	if first == nil {
+		return 0
+	}
+	return int(b.Sequence() - binary.BigEndian.Uint64(first) + 1)
+}
+
+// append stores values at the end of a bucket and trims it to the maximum history size
+func (bs *BoltStorage) append(bucket []byte, values ...interface{}) {
+	err := bs.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for _, value := range values {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			if err := b.Put(boltKey(seq), data); err != nil {
+				return err
+			}
+		}
+
+		// Trim oldest entries
+		c := b.Cursor()
+		size := bs.historySize(historyTypeOf(boltHistoryBucketNames, string(bucket)))
+		for excess := boltCount(b) - size; excess > 0; excess-- {
+			if k, _ := c.First(); k != nil {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to write to bolt storage", "bucket", string(bucket), "error", err)
+	}
+}
+
+// boltReadLast returns up to limit most recent entries of a bucket, oldest first
+func boltReadLast[T any](bs *BoltStorage, bucket []byte, limit int) []T {
+	result := make([]T, 0)
+	err := bs.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.Last(); k != nil && len(result) < limit; k, v = c.Prev() {
+			var item T
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			result = append(result, item)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to read from bolt storage", "bucket", string(bucket), "error", err)
+		return make([]T, 0)
+	}
+
+	// Reverse to chronological order
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// boltReadRange returns the entries of a bucket with a timestamp between from and to, oldest
+// first. Entries are appended in time order, so reading stops at the first entry before from.
+func boltReadRange[T any](bs *BoltStorage, bucket []byte, from, to time.Time) []T {
+	result := make([]T, 0)
+	err := bs.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var entry struct{ Timestamp time.Time }
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			if entry.Timestamp.Before(from) {
+				break
+			}
+			if entry.Timestamp.After(to) {
+				continue
+			}
+			var item T
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			result = append(result, item)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to read from bolt storage", "bucket", string(bucket), "error", err)
+		return make([]T, 0)
+	}
+
+	// Reverse to chronological order
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// count returns the number of entries in a bucket
+func (bs *BoltStorage) count(bucket []byte) int {
+	count := 0
+	bs.view(func(tx *bolt.Tx) error {
+		count = boltCount(tx.Bucket(bucket))
+		return nil
+	})
+	return count
+}
+
+// AddAlert adds an alert to storage
+func (bs *BoltStorage) AddAlert(alert types.Alert) {
+	bs.append(boltAlertsBucket, alert)
+}
+
+// AddAlerts adds multiple alerts to storage
+func (bs *BoltStorage) AddAlerts(alerts []types.Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(alerts))
+	for i, alert := range alerts {
+		values[i] = alert
+	}
+	bs.append(boltAlertsBucket, values...)
+}
+
+// GetAlerts returns all pending alerts
+func (bs *BoltStorage) GetAlerts() []types.Alert {
+	return boltReadLast[types.Alert](bs, boltAlertsBucket, bs.historySize(HistoryAlerts))
+}
+
+// GetAlertsCount returns the number of pending alerts
+func (bs *BoltStorage) GetAlertsCount() int {
+	return bs.count(boltAlertsBucket)
+}
+
+// ClearAlerts removes all pending alerts
+func (bs *BoltStorage) ClearAlerts() {
+	if err := bs.update(func(tx *bolt.Tx) error { return boltResetBucket(tx, boltAlertsBucket) }); err != nil {
+		slog.Error("Failed to clear alerts in bolt storage", "error", err)
+	}
+}
+
+// TakeAlerts returns and removes all pending alerts in one transaction
+func (bs *BoltStorage) TakeAlerts() []types.Alert {
+	alerts := make([]types.Alert, 0)
+	err := bs.update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltAlertsBucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var alert types.Alert
+			if err := json.Unmarshal(v, &alert); err != nil {
+				return err
+			}
+			alerts = append(alerts, alert)
+		}
+		return boltResetBucket(tx, boltAlertsBucket)
+	})
+	if err != nil {
+		slog.Error("Failed to take alerts from bolt storage", "error", err)
+		return make([]types.Alert, 0)
+	}
+	return alerts
+}
+
+// boltResetBucket removes all entries of a bucket
+func boltResetBucket(tx *bolt.Tx, bucket []byte) error {
+	if err := tx.DeleteBucket(bucket); err != nil {
+		return err
+	}
+	_, err := tx.CreateBucket(bucket)
+	return err
+}
+
+// AddSystemStats adds system stats to history
+func (bs *BoltStorage) AddSystemStats(stats types.SystemStats) {
+	bs.append(boltSystemStatsBucket, stats)
+}
+
+// GetLatestSystemStats returns the most recent system stats
+func (bs *BoltStorage) GetLatestSystemStats() *types.SystemStats {
+	stats := boltReadLast[types.SystemStats](bs, boltSystemStatsBucket, 1)
+	if len(stats) == 0 {
+		return nil
+	}
+	return &stats[0]
+}
+
+// AddHTTPCheckResult adds a check result to history
+func (bs *BoltStorage) AddHTTPCheckResult(result types.HTTPCheckResult) {
+	bs.append(boltHTTPResultsBucket, result)
+	bs.indexResult(result)
+}
+
+// AddHTTPCheckResults adds multiple check results to history in one transaction
+func (bs *BoltStorage) AddHTTPCheckResults(results []types.HTTPCheckResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(results))
+	for i, result := range results {
+		values[i] = result
+	}
+	bs.append(boltHTTPResultsBucket, values...)
+	bs.indexResults(results)
+}
+
+// GetHTTPCheckResults returns check result history
+func (bs *BoltStorage) GetHTTPCheckResults() []types.HTTPCheckResult {
+	return boltReadLast[types.HTTPCheckResult](bs, boltHTTPResultsBucket, bs.historySize(HistoryChecks))
+}
+
+// AddDockerContainerStats adds Docker container stats to history
+func (bs *BoltStorage) AddDockerContainerStats(stats []types.DockerContainerStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(stats))
+	for i, s := range stats {
+		values[i] = s
+	}
+	bs.append(boltDockerStatsBucket, values...)
+}
+
+// GetLatestDockerContainerStats returns the container stats of the most recent collection
+func (bs *BoltStorage) GetLatestDockerContainerStats() []types.DockerContainerStats {
+	return latestDockerStats(boltReadLast[types.DockerContainerStats](bs, boltDockerStatsBucket, bs.historySize(HistoryDocker)))
+}
+
+// GetStatus returns the current status of storage
+func (bs *BoltStorage) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"driver":               "bolt",
+		"path":                 bs.path,
+		"alerts_count":         bs.count(boltAlertsBucket),
+		"stats_history_count":  bs.count(boltSystemStatsBucket),
+		"http_history_count":   bs.count(boltHTTPResultsBucket),
+		"docker_history_count": bs.count(boltDockerStatsBucket),
+		"events_count":         bs.count(boltEventsBucket),
+		"max_history_size":     bs.maxHistorySize,
+		"max_history_sizes":    bs.allHistorySizes(),
+		"size_bytes":           bs.bucketSizes(),
+		"file_size_bytes":      fileSize(bs.path),
+		"free_bytes":           bs.freeBytes(),
+		"timestamp":            time.Now().Format(time.RFC3339),
+	}
+}
+
+// bucketSizes returns the bytes used by the pages of each history type's bucket
+func (bs *BoltStorage) bucketSizes() map[string]int64 {
+	sizes := make(map[string]int64, len(boltHistoryBuckets))
+	bs.view(func(tx *bolt.Tx) error {
+		for dataType, bucket := range boltHistoryBuckets {
+			stats := tx.Bucket(bucket).Stats()
+			sizes[dataType] = int64(stats.BranchInuse + stats.LeafInuse + stats.InlineBucketInuse)
+		}
+		return nil
+	})
+	return sizes
+}
+
+// freeBytes returns the size of the free pages, which compaction would give back
+func (bs *BoltStorage) freeBytes() int64 {
+	var free int64
+	bs.view(func(tx *bolt.Tx) error {
+		stats := bs.db.Stats()
+		free = int64(stats.FreePageN+stats.PendingPageN) * int64(bs.db.Info().PageSize)
+		return nil
+	})
+	return free
+}
+
+// PurgeHistory removes entries of a data type recorded before the given time (all entries when before is zero).
+// Entries are appended in time order, so purging stops at the first entry that is not older than before.
+func (bs *BoltStorage) PurgeHistory(dataType string, before time.Time) (int, error) {
+	bucket, exists := boltHistoryBuckets[dataType]
+	if !exists {
+		return 0, fmt.Errorf("unknown history type: %s", dataType)
+	}
+
+	deleted := 0
+	err := bs.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if before.IsZero() {
+			deleted = boltCount(b)
+			return boltResetBucket(tx, bucket)
+		}
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.First() {
+			var entry struct{ Timestamp time.Time }
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			if !entry.Timestamp.Before(before) {
+				break
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge %s: %w", dataType, err)
+	}
+
+	if dataType == HistoryChecks {
+		bs.rebuildIndex(bs.GetHTTPCheckResults())
+	}
+	return deleted, nil
+}
+
+// AddAuditEntry records a history purge
+func (bs *BoltStorage) AddAuditEntry(entry types.PurgeAuditEntry) {
+	bs.append(boltAuditBucket, entry)
+}
+
+// GetAuditEntries returns recorded history purges
+func (bs *BoltStorage) GetAuditEntries() []types.PurgeAuditEntry {
+	return boltReadLast[types.PurgeAuditEntry](bs, boltAuditBucket, bs.maxHistorySize)
+}
+
+// AddAlertRecords adds processed alerts to the alert history
+func (bs *BoltStorage) AddAlertRecords(records []types.AlertRecord) {
+	if len(records) == 0 {
+		return
+	}
+	values := make([]interface{}, len(records))
+	for i, record := range records {
+		values[i] = record
+	}
+	bs.append(boltAlertHistoryBucket, values...)
+}
+
+// GetAlertRecords returns up to limit of the newest alert history records, oldest first
+func (bs *BoltStorage) GetAlertRecords(limit int) []types.AlertRecord {
+	return boltReadLast[types.AlertRecord](bs, boltAlertHistoryBucket, limit)
+}
+
+// SaveAlertState stores the alerting state of an instance
+func (bs *BoltStorage) SaveAlertState(instance string, state types.SavedAlertState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert state: %w", err)
+	}
+	err = bs.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltAlertStateBucket).Put([]byte(instance), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save alert state: %w", err)
+	}
+	return nil
+}
+
+// LoadAlertState returns the saved alerting state of an instance, nil if none was saved
+func (bs *BoltStorage) LoadAlertState(instance string) (*types.SavedAlertState, error) {
+	var state *types.SavedAlertState
+	err := bs.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltAlertStateBucket).Get([]byte(instance))
+		if data == nil {
+			return nil
+		}
+		state = &types.SavedAlertState{}
+		return json.Unmarshal(data, state)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert state: %w", err)
+	}
+	return state, nil
+}
+
+// GetSystemStatsRange returns the system stats collected between from and to
+func (bs *BoltStorage) GetSystemStatsRange(from, to time.Time) []types.SystemStats {
+	return boltReadRange[types.SystemStats](bs, boltSystemStatsBucket, from, to)
+}
+
+// GetHTTPCheckResultsRange returns the check results recorded between from and to
+func (bs *BoltStorage) GetHTTPCheckResultsRange(from, to time.Time) []types.HTTPCheckResult {
+	return boltReadRange[types.HTTPCheckResult](bs, boltHTTPResultsBucket, from, to)
+}
+
+// GetDockerContainerStatsRange returns the container stats collected between from and to
+func (bs *BoltStorage) GetDockerContainerStatsRange(from, to time.Time) []types.DockerContainerStats {
+	return boltReadRange[types.DockerContainerStats](bs, boltDockerStatsBucket, from, to)
+}
+
+// AddEvent records a lifecycle event
+func (bs *BoltStorage) AddEvent(event types.LifecycleEvent) {
+	bs.append(boltEventsBucket, event)
+}
+
+// GetEventsRange returns the lifecycle events recorded between from and to
+func (bs *BoltStorage) GetEventsRange(from, to time.Time) []types.LifecycleEvent {
+	return boltReadRange[types.LifecycleEvent](bs, boltEventsBucket, from, to)
+}
+
+// Close closes the BoltDB file
+func (bs *BoltStorage) Close() error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.db.Close()
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltStorage_Backend(t *testing.T) {
+	storage, err := NewBoltStorage(filepath.Join(t.TempDir(), "monic.db"), 5)
+	if err != nil {
+		t.Fatalf("Failed to open bolt storage: %v", err)
+	}
+	defer storage.Close()
+
+	testStorageBackend(t, storage, 5)
+}
+
+func TestBoltStorage_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monic.db")
+
+	storage, err := NewBoltStorage(path, 10)
+	if err != nil {
+		t.Fatalf("Failed to open bolt storage: %v", err)
+	}
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Success: true})
+	storage.SaveAlertState("web-01", types.SavedAlertState{States: []types.AlertState{{Type: "cpu", CurrentState: "critical", ConsecutiveChecks: 2}}})
+	if err := storage.Close(); err != nil {
+		t.Fatalf("Failed to close bolt storage: %v", err)
+	}
+
+	// History survives a restart
+	storage, err = NewBoltStorage(path, 10)
+	if err != nil {
+		t.Fatalf("Failed to reopen bolt storage: %v", err)
+	}
+	defer storage.Close()
+
+	results := storage.GetHTTPCheckResults()
+	if len(results) != 1 || results[0].Name != "api" {
+		t.Errorf("Expected persisted HTTP result, got %+v", results)
+	}
+	if statuses := storage.GetCheckStatuses(); len(statuses) != 1 || statuses[0].Latest.Name != "api" {
+		t.Errorf("Expected check index rebuilt from history, got %+v", statuses)
+	}
+	if saved, err := storage.LoadAlertState("web-01"); err != nil || saved == nil || saved.States[0].ConsecutiveChecks != 2 {
+		t.Errorf("Expected persisted alert state, got %+v (%v)", saved, err)
+	}
+}
+
+func TestBoltStorage_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monic.db")
+	storage, err := NewBoltStorage(path, 5000)
+	if err != nil {
+		t.Fatalf("Failed to open bolt storage: %v", err)
+	}
+	defer storage.Close()
+
+	// Nothing to reclaim yet
+	if before, after, err := storage.Compact(false); err != nil || before != after {
+		t.Fatalf("Expected no compaction of a full file, got %d -> %d (%v)", before, after, err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	for i := 0; i < 2000; i++ {
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Error: strings.Repeat("x", 500), Timestamp: old})
+	}
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "recent", Timestamp: time.Now()})
+	if _, err := storage.PurgeHistory(HistoryChecks, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+
+	before, after, err := storage.Compact(false)
+	if err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	if after >= before {
+		t.Errorf("Expected the file to shrink, got %d -> %d", before, after)
+	}
+
+	// The storage keeps working on the compacted file
+	results := storage.GetHTTPCheckResults()
+	if len(results) != 1 || results[0].Name != "recent" {
+		t.Errorf("Expected the remaining result after compaction, got %d results", len(results))
+	}
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "after", Timestamp: time.Now()})
+	if count := storage.GetStatus()["http_history_count"]; count != 2 {
+		t.Errorf("Expected 2 results, got %v", count)
+	}
+}
+
+func TestBoltStorage_CompactReopenFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monic.db")
+	storage, err := NewBoltStorage(path, 5000)
+	if err != nil {
+		t.Fatalf("Failed to open bolt storage: %v", err)
+	}
+	defer storage.Close()
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Timestamp: time.Now()})
+
+	// The compacted file cannot be opened once it replaced the original
+	open := openBolt
+	t.Cleanup(func() { openBolt = open })
+	failed := false
+	openBolt = func(p string) (*bolt.DB, error) {
+		if p == path && !failed {
+			failed = true
+			return nil, errors.New("disk error")
+		}
+		return open(p)
+	}
+
+	if _, _, err := storage.Compact(true); err == nil || !strings.Contains(err.Error(), "disk error") {
+		t.Fatalf("Expected the reopen failure, got %v", err)
+	}
+	if _, err := os.Stat(path + ".old"); !os.IsNotExist(err) {
+		t.Errorf("Expected the original file to be moved back, got %v", err)
+	}
+
+	// The storage continues on the original file
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "after", Timestamp: time.Now()})
+	if results := storage.GetHTTPCheckResults(); len(results) != 2 {
+		t.Errorf("Expected 2 results after the failed compaction, got %d", len(results))
+	}
+}
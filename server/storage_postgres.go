@@ -0,0 +1,447 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bconf.com/monic/types"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// PostgreSQL table names, one per history type
+const (
+	pgAlertsTable       = "monic_alerts"
+	pgSystemStatsTable  = "monic_system_stats"
+	pgHTTPResultsTable  = "monic_http_results"
+	pgDockerStatsTable  = "monic_docker_stats"
+	pgEventsTable       = "monic_events"
+	pgAuditTable        = "monic_audit"
+	pgAlertHistoryTable = "monic_alert_history" // Processed alerts of the alerts page
+	pgAlertStateTable   = "monic_alert_state"   // One row per instance sharing the database
+)
+
+// pgHistoryTables maps purgeable history types to their tables
+var pgHistoryTables = map[string]string{
+	HistoryAlerts:      pgAlertsTable,
+	HistorySystemStats: pgSystemStatsTable,
+	HistoryChecks:      pgHTTPResultsTable,
+	HistoryDocker:      pgDockerStatsTable,
+	HistoryEvents:      pgEventsTable,
+}
+
+// pgQueryTimeout bounds every storage query so a slow database cannot stall monitoring loops
+const pgQueryTimeout = 5 * time.Second
+
+// PostgresStorage persists monitoring data in PostgreSQL so several instances can share history
+type PostgresStorage struct {
+	db            *sql.DB
+	historyLimits // Entries kept per history type
+	checkIndex    // Latest result per check
+}
+
+// NewPostgresStorage connects to PostgreSQL and creates the monitoring tables if needed
+func NewPostgresStorage(dsn string, maxHistorySize int) (*PostgresStorage, error) {
+	if maxHistorySize <= 0 {
+		maxHistorySize = defaultDurableHistorySize
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres connection: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	for _, table := range []string{pgAlertsTable, pgSystemStatsTable, pgHTTPResultsTable, pgDockerStatsTable, pgEventsTable, pgAuditTable, pgAlertHistoryTable} {
+		query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			data JSONB NOT NULL
+		)`, table)
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create table %s: %w", table, err)
+		}
+	}
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		instance TEXT PRIMARY KEY,
+		saved_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		data JSONB NOT NULL
+	)`, pgAlertStateTable)
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create table %s: %w", pgAlertStateTable, err)
+	}
+
+	storage := &PostgresStorage{
+		db:            db,
+		historyLimits: historyLimits{maxHistorySize: maxHistorySize},
+	}
+	storage.rebuildIndex(storage.GetHTTPCheckResults())
+	return storage, nil
+}
+
+// insert stores values in a table and trims it to the maximum history size
+func (ps *PostgresStorage) insert(table string, values ...interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	err := func() error {
+		tx, err := ps.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, value := range values {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (data) VALUES ($1)", table), data); err != nil {
+				return err
+			}
+		}
+
+		// Trim oldest entries
+		trim := fmt.Sprintf("DELETE FROM %[1]s WHERE id <= (SELECT id FROM %[1]s ORDER BY id DESC OFFSET $1 LIMIT 1)", table)
+		if _, err := tx.ExecContext(ctx, trim, ps.historySize(historyTypeOf(pgHistoryTables, table))); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}()
+	if err != nil {
+		slog.Error("Failed to write to postgres storage", "table", table, "error", err)
+	}
+}
+
+// pgReadRange returns the entries of a table with a timestamp between from and to, oldest first
+func pgReadRange[T any](ps *PostgresStorage, table string, from, to time.Time) []T {
+	query := fmt.Sprintf("SELECT data FROM %s WHERE (data->>'Timestamp')::timestamptz BETWEEN $1 AND $2 ORDER BY id", table)
+	return pgQuery[T](ps, table, query, from, to)
+}
+
+// pgReadLast returns up to limit most recent entries of a table, oldest first
+func pgReadLast[T any](ps *PostgresStorage, table string, limit int) []T {
+	query := fmt.Sprintf("SELECT data FROM (SELECT id, data FROM %s ORDER BY id DESC LIMIT $1) latest ORDER BY id", table)
+	return pgQuery[T](ps, table, query, limit)
+}
+
+// pgQuery runs a query selecting the data column and decodes the rows
+func pgQuery[T any](ps *PostgresStorage, table, query string, args ...interface{}) []T {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("Failed to read from postgres storage", "table", table, "error", err)
+		return make([]T, 0)
+	}
+	defer rows.Close()
+
+	result := make([]T, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			slog.Error("Failed to scan postgres row", "table", table, "error", err)
+			return make([]T, 0)
+		}
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			slog.Error("Failed to decode postgres row", "table", table, "error", err)
+			return make([]T, 0)
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Failed to read from postgres storage", "table", table, "error", err)
+		return make([]T, 0)
+	}
+	return result
+}
+
+// count returns the number of rows in a table
+func (ps *PostgresStorage) count(table string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	var count int
+	if err := ps.db.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s", table)).Scan(&count); err != nil {
+		slog.Error("Failed to count postgres rows", "table", table, "error", err)
+		return 0
+	}
+	return count
+}
+
+// AddAlert adds an alert to storage
+func (ps *PostgresStorage) AddAlert(alert types.Alert) {
+	ps.insert(pgAlertsTable, alert)
+}
+
+// AddAlerts adds multiple alerts to storage
+func (ps *PostgresStorage) AddAlerts(alerts []types.Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(alerts))
+	for i, alert := range alerts {
+		values[i] = alert
+	}
+	ps.insert(pgAlertsTable, values...)
+}
+
+// GetAlerts returns all pending alerts
+func (ps *PostgresStorage) GetAlerts() []types.Alert {
+	return pgReadLast[types.Alert](ps, pgAlertsTable, ps.historySize(HistoryAlerts))
+}
+
+// GetAlertsCount returns the number of pending alerts
+func (ps *PostgresStorage) GetAlertsCount() int {
+	return ps.count(pgAlertsTable)
+}
+
+// ClearAlerts removes all pending alerts
+func (ps *PostgresStorage) ClearAlerts() {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	if _, err := ps.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", pgAlertsTable)); err != nil {
+		slog.Error("Failed to clear alerts in postgres storage", "error", err)
+	}
+}
+
+// TakeAlerts returns and removes all pending alerts in one statement
+func (ps *PostgresStorage) TakeAlerts() []types.Alert {
+	query := fmt.Sprintf("WITH taken AS (DELETE FROM %s RETURNING id, data) SELECT data FROM taken ORDER BY id", pgAlertsTable)
+	return pgQuery[types.Alert](ps, pgAlertsTable, query)
+}
+
+// AddSystemStats adds system stats to history
+func (ps *PostgresStorage) AddSystemStats(stats types.SystemStats) {
+	ps.insert(pgSystemStatsTable, stats)
+}
+
+// GetLatestSystemStats returns the most recent system stats
+func (ps *PostgresStorage) GetLatestSystemStats() *types.SystemStats {
+	stats := pgReadLast[types.SystemStats](ps, pgSystemStatsTable, 1)
+	if len(stats) == 0 {
+		return nil
+	}
+	return &stats[0]
+}
+
+// AddHTTPCheckResult adds a check result to history
+func (ps *PostgresStorage) AddHTTPCheckResult(result types.HTTPCheckResult) {
+	ps.insert(pgHTTPResultsTable, result)
+	ps.indexResult(result)
+}
+
+// AddHTTPCheckResults adds multiple check results to history in one transaction
+func (ps *PostgresStorage) AddHTTPCheckResults(results []types.HTTPCheckResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(results))
+	for i, result := range results {
+		values[i] = result
+	}
+	ps.insert(pgHTTPResultsTable, values...)
+	ps.indexResults(results)
+}
+
+// GetHTTPCheckResults returns check result history
+func (ps *PostgresStorage) GetHTTPCheckResults() []types.HTTPCheckResult {
+	return pgReadLast[types.HTTPCheckResult](ps, pgHTTPResultsTable, ps.historySize(HistoryChecks))
+}
+
+// AddDockerContainerStats adds Docker container stats to history
+func (ps *PostgresStorage) AddDockerContainerStats(stats []types.DockerContainerStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(stats))
+	for i, s := range stats {
+		values[i] = s
+	}
+	ps.insert(pgDockerStatsTable, values...)
+}
+
+// GetLatestDockerContainerStats returns the container stats of the most recent collection
+func (ps *PostgresStorage) GetLatestDockerContainerStats() []types.DockerContainerStats {
+	return latestDockerStats(pgReadLast[types.DockerContainerStats](ps, pgDockerStatsTable, ps.historySize(HistoryDocker)))
+}
+
+// GetStatus returns the current status of storage
+func (ps *PostgresStorage) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"driver":               "postgres",
+		"alerts_count":         ps.count(pgAlertsTable),
+		"stats_history_count":  ps.count(pgSystemStatsTable),
+		"http_history_count":   ps.count(pgHTTPResultsTable),
+		"docker_history_count": ps.count(pgDockerStatsTable),
+		"events_count":         ps.count(pgEventsTable),
+		"max_history_size":     ps.maxHistorySize,
+		"max_history_sizes":    ps.allHistorySizes(),
+		"size_bytes":           ps.tableSizes(),
+		"timestamp":            time.Now().Format(time.RFC3339),
+	}
+}
+
+// tableSizes returns the disk space of each history type's table, including indexes and TOAST data
+func (ps *PostgresStorage) tableSizes() map[string]int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	sizes := make(map[string]int64, len(pgHistoryTables))
+	for dataType, table := range pgHistoryTables {
+		var size int64
+		if err := ps.db.QueryRowContext(ctx, "SELECT pg_total_relation_size($1)", table).Scan(&size); err != nil {
+			slog.Error("Failed to measure postgres table", "table", table, "error", err)
+		}
+		sizes[dataType] = size
+	}
+	return sizes
+}
+
+// PurgeHistory removes entries of a data type recorded before the given time (all entries when before is zero)
+func (ps *PostgresStorage) PurgeHistory(dataType string, before time.Time) (int, error) {
+	table, exists := pgHistoryTables[dataType]
+	if !exists {
+		return 0, fmt.Errorf("unknown history type: %s", dataType)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	var result sql.Result
+	var err error
+	if before.IsZero() {
+		result, err = ps.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", table))
+	} else {
+		result, err = ps.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE (data->>'Timestamp')::timestamptz < $1", table), before)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge %s: %w", dataType, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge %s: %w", dataType, err)
+	}
+
+	if dataType == HistoryChecks {
+		ps.rebuildIndex(ps.GetHTTPCheckResults())
+	}
+	return int(deleted), nil
+}
+
+// AddAuditEntry records a history purge
+func (ps *PostgresStorage) AddAuditEntry(entry types.PurgeAuditEntry) {
+	ps.insert(pgAuditTable, entry)
+}
+
+// GetAuditEntries returns recorded history purges
+func (ps *PostgresStorage) GetAuditEntries() []types.PurgeAuditEntry {
+	return pgReadLast[types.PurgeAuditEntry](ps, pgAuditTable, ps.maxHistorySize)
+}
+
+// AddAlertRecords adds processed alerts to the alert history
+func (ps *PostgresStorage) AddAlertRecords(records []types.AlertRecord) {
+	if len(records) == 0 {
+		return
+	}
+	values := make([]interface{}, len(records))
+	for i, record := range records {
+		values[i] = record
+	}
+	ps.insert(pgAlertHistoryTable, values...)
+}
+
+// GetAlertRecords returns up to limit of the newest alert history records, oldest first
+func (ps *PostgresStorage) GetAlertRecords(limit int) []types.AlertRecord {
+	return pgReadLast[types.AlertRecord](ps, pgAlertHistoryTable, limit)
+}
+
+// SaveAlertState stores the alerting state of an instance
+func (ps *PostgresStorage) SaveAlertState(instance string, state types.SavedAlertState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert state: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT INTO %s (instance, saved_at, data) VALUES ($1, now(), $2)
+		ON CONFLICT (instance) DO UPDATE SET saved_at = excluded.saved_at, data = excluded.data`, pgAlertStateTable)
+	if _, err := ps.db.ExecContext(ctx, query, instance, data); err != nil {
+		return fmt.Errorf("failed to save alert state: %w", err)
+	}
+	return nil
+}
+
+// LoadAlertState returns the saved alerting state of an instance, nil if none was saved
+func (ps *PostgresStorage) LoadAlertState(instance string) (*types.SavedAlertState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	var data []byte
+	err := ps.db.QueryRowContext(ctx, fmt.Sprintf("SELECT data FROM %s WHERE instance = $1", pgAlertStateTable), instance).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert state: %w", err)
+	}
+
+	var state types.SavedAlertState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid saved alert state: %w", err)
+	}
+	return &state, nil
+}
+
+// GetSystemStatsRange returns the system stats collected between from and to
+func (ps *PostgresStorage) GetSystemStatsRange(from, to time.Time) []types.SystemStats {
+	return pgReadRange[types.SystemStats](ps, pgSystemStatsTable, from, to)
+}
+
+// GetHTTPCheckResultsRange returns the check results recorded between from and to
+func (ps *PostgresStorage) GetHTTPCheckResultsRange(from, to time.Time) []types.HTTPCheckResult {
+	return pgReadRange[types.HTTPCheckResult](ps, pgHTTPResultsTable, from, to)
+}
+
+// GetDockerContainerStatsRange returns the container stats collected between from and to
+func (ps *PostgresStorage) GetDockerContainerStatsRange(from, to time.Time) []types.DockerContainerStats {
+	return pgReadRange[types.DockerContainerStats](ps, pgDockerStatsTable, from, to)
+}
+
+// AddEvent records a lifecycle event
+func (ps *PostgresStorage) AddEvent(event types.LifecycleEvent) {
+	ps.insert(pgEventsTable, event)
+}
+
+// GetEventsRange returns the lifecycle events recorded between from and to
+func (ps *PostgresStorage) GetEventsRange(from, to time.Time) []types.LifecycleEvent {
+	return pgReadRange[types.LifecycleEvent](ps, pgEventsTable, from, to)
+}
+
+// Close closes the database connection pool
+func (ps *PostgresStorage) Close() error {
+	return ps.db.Close()
+}
@@ -0,0 +1,506 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bconf.com/monic/types"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver (cgo)
+)
+
+// SQLite table names, one per history type
+const (
+	sqliteAlertsTable       = "alerts"
+	sqliteSystemStatsTable  = "system_stats"
+	sqliteHTTPResultsTable  = "http_results"
+	sqliteDockerStatsTable  = "docker_stats"
+	sqliteEventsTable       = "events"
+	sqliteAuditTable        = "audit"
+	sqliteAlertHistoryTable = "alert_history" // Processed alerts of the alerts page
+	sqliteAlertStateTable   = "alert_state"   // One row per instance
+)
+
+// sqliteHistoryTables maps purgeable history types to their tables
+var sqliteHistoryTables = map[string]string{
+	HistoryAlerts:      sqliteAlertsTable,
+	HistorySystemStats: sqliteSystemStatsTable,
+	HistoryChecks:      sqliteHTTPResultsTable,
+	HistoryDocker:      sqliteDockerStatsTable,
+	HistoryEvents:      sqliteEventsTable,
+}
+
+// sqliteQueryTimeout bounds every storage query so a locked database cannot stall monitoring loops
+const sqliteQueryTimeout = 5 * time.Second
+
+// sqliteCompactTimeout bounds a VACUUM, which rewrites the whole database
+const sqliteCompactTimeout = 10 * time.Minute
+
+// SQLiteStorage persists monitoring data in a single SQLite file, which unlike BoltDB can be
+// inspected and queried with the sqlite3 shell
+type SQLiteStorage struct {
+	db            *sql.DB
+	path          string
+	historyLimits // Entries kept per history type
+	checkIndex    // Latest result per check
+}
+
+// NewSQLiteStorage opens (or creates) a SQLite database for monitoring data
+func NewSQLiteStorage(path string, maxHistorySize int) (*SQLiteStorage, error) {
+	if maxHistorySize <= 0 {
+		maxHistorySize = defaultDurableHistorySize
+	}
+
+	// WAL lets the dashboard read while a monitoring loop writes
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+	// SQLite allows one writer at a time; a single connection queues writes instead of failing them
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	for _, table := range []string{sqliteAlertsTable, sqliteSystemStatsTable, sqliteHTTPResultsTable, sqliteDockerStatsTable, sqliteEventsTable, sqliteAuditTable, sqliteAlertHistoryTable} {
+		// The timestamp (Unix nanoseconds) is kept apart from the JSON data, so time ranges use the index
+		query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp INTEGER NOT NULL,
+			data TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS %[1]s_timestamp ON %[1]s (timestamp)`, table)
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create sqlite table %s: %w", table, err)
+		}
+	}
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (instance TEXT PRIMARY KEY, data TEXT NOT NULL)", sqliteAlertStateTable)
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite table %s: %w", sqliteAlertStateTable, err)
+	}
+
+	storage := &SQLiteStorage{
+		db:            db,
+		path:          path,
+		historyLimits: historyLimits{maxHistorySize: maxHistorySize},
+	}
+	storage.rebuildIndex(storage.GetHTTPCheckResults())
+	return storage, nil
+}
+
+// insert stores values in a table and trims it to the maximum history size
+func (ss *SQLiteStorage) insert(table string, values ...interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	err := func() error {
+		tx, err := ss.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, value := range values {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			var entry struct{ Timestamp time.Time }
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return err
+			}
+			query := fmt.Sprintf("INSERT INTO %s (timestamp, data) VALUES (?, ?)", table)
+			if _, err := tx.ExecContext(ctx, query, entry.Timestamp.UnixNano(), string(data)); err != nil {
+				return err
+			}
+		}
+
+		// Trim oldest entries
+		trim := fmt.Sprintf("DELETE FROM %[1]s WHERE id <= (SELECT id FROM %[1]s ORDER BY id DESC LIMIT 1 OFFSET ?)", table)
+		if _, err := tx.ExecContext(ctx, trim, ss.historySize(historyTypeOf(sqliteHistoryTables, table))); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}()
+	if err != nil {
+		slog.Error("Failed to write to sqlite storage", "table", table, "error", err)
+	}
+}
+
+// sqliteReadRange returns the entries of a table with a timestamp between from and to, oldest first
+func sqliteReadRange[T any](ss *SQLiteStorage, table string, from, to time.Time) []T {
+	query := fmt.Sprintf("SELECT data FROM %s WHERE timestamp BETWEEN ? AND ? ORDER BY id", table)
+	return sqliteQuery[T](ss, table, query, from.UnixNano(), to.UnixNano())
+}
+
+// sqliteReadLast returns up to limit most recent entries of a table, oldest first
+func sqliteReadLast[T any](ss *SQLiteStorage, table string, limit int) []T {
+	query := fmt.Sprintf("SELECT data FROM (SELECT id, data FROM %s ORDER BY id DESC LIMIT ?) ORDER BY id", table)
+	return sqliteQuery[T](ss, table, query, limit)
+}
+
+// sqliteQuery runs a query selecting the data column and decodes the rows
+func sqliteQuery[T any](ss *SQLiteStorage, table, query string, args ...interface{}) []T {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("Failed to read from sqlite storage", "table", table, "error", err)
+		return make([]T, 0)
+	}
+	defer rows.Close()
+
+	result := make([]T, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			slog.Error("Failed to scan sqlite row", "table", table, "error", err)
+			return make([]T, 0)
+		}
+		var item T
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			slog.Error("Failed to decode sqlite row", "table", table, "error", err)
+			return make([]T, 0)
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Failed to read from sqlite storage", "table", table, "error", err)
+		return make([]T, 0)
+	}
+	return result
+}
+
+// count returns the number of rows in a table
+func (ss *SQLiteStorage) count(table string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	var count int
+	if err := ss.db.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s", table)).Scan(&count); err != nil {
+		slog.Error("Failed to count sqlite rows", "table", table, "error", err)
+		return 0
+	}
+	return count
+}
+
+// AddAlert adds an alert to storage
+func (ss *SQLiteStorage) AddAlert(alert types.Alert) {
+	ss.insert(sqliteAlertsTable, alert)
+}
+
+// AddAlerts adds multiple alerts to storage
+func (ss *SQLiteStorage) AddAlerts(alerts []types.Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(alerts))
+	for i, alert := range alerts {
+		values[i] = alert
+	}
+	ss.insert(sqliteAlertsTable, values...)
+}
+
+// GetAlerts returns all pending alerts
+func (ss *SQLiteStorage) GetAlerts() []types.Alert {
+	return sqliteReadLast[types.Alert](ss, sqliteAlertsTable, ss.historySize(HistoryAlerts))
+}
+
+// GetAlertsCount returns the number of pending alerts
+func (ss *SQLiteStorage) GetAlertsCount() int {
+	return ss.count(sqliteAlertsTable)
+}
+
+// ClearAlerts removes all pending alerts
+func (ss *SQLiteStorage) ClearAlerts() {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	if _, err := ss.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", sqliteAlertsTable)); err != nil {
+		slog.Error("Failed to clear alerts in sqlite storage", "error", err)
+	}
+}
+
+// TakeAlerts returns and removes all pending alerts. Only the rows read are deleted, so alerts
+// inserted meanwhile, which get higher ids, stay pending.
+func (ss *SQLiteStorage) TakeAlerts() []types.Alert {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	var last int64
+	query := fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", sqliteAlertsTable)
+	if err := ss.db.QueryRowContext(ctx, query).Scan(&last); err != nil {
+		slog.Error("Failed to take alerts from sqlite storage", "error", err)
+		return make([]types.Alert, 0)
+	}
+	alerts := sqliteQuery[types.Alert](ss, sqliteAlertsTable, fmt.Sprintf("SELECT data FROM %s WHERE id <= ? ORDER BY id", sqliteAlertsTable), last)
+	if _, err := ss.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id <= ?", sqliteAlertsTable), last); err != nil {
+		slog.Error("Failed to take alerts from sqlite storage", "error", err)
+	}
+	return alerts
+}
+
+// AddSystemStats adds system stats to history
+func (ss *SQLiteStorage) AddSystemStats(stats types.SystemStats) {
+	ss.insert(sqliteSystemStatsTable, stats)
+}
+
+// GetLatestSystemStats returns the most recent system stats
+func (ss *SQLiteStorage) GetLatestSystemStats() *types.SystemStats {
+	stats := sqliteReadLast[types.SystemStats](ss, sqliteSystemStatsTable, 1)
+	if len(stats) == 0 {
+		return nil
+	}
+	return &stats[0]
+}
+
+// AddHTTPCheckResult adds a check result to history
+func (ss *SQLiteStorage) AddHTTPCheckResult(result types.HTTPCheckResult) {
+	ss.insert(sqliteHTTPResultsTable, result)
+	ss.indexResult(result)
+}
+
+// AddHTTPCheckResults adds multiple check results to history in one transaction
+func (ss *SQLiteStorage) AddHTTPCheckResults(results []types.HTTPCheckResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(results))
+	for i, result := range results {
+		values[i] = result
+	}
+	ss.insert(sqliteHTTPResultsTable, values...)
+	ss.indexResults(results)
+}
+
+// GetHTTPCheckResults returns check result history
+func (ss *SQLiteStorage) GetHTTPCheckResults() []types.HTTPCheckResult {
+	return sqliteReadLast[types.HTTPCheckResult](ss, sqliteHTTPResultsTable, ss.historySize(HistoryChecks))
+}
+
+// AddDockerContainerStats adds Docker container stats to history
+func (ss *SQLiteStorage) AddDockerContainerStats(stats []types.DockerContainerStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	values := make([]interface{}, len(stats))
+	for i, s := range stats {
+		values[i] = s
+	}
+	ss.insert(sqliteDockerStatsTable, values...)
+}
+
+// GetLatestDockerContainerStats returns the container stats of the most recent collection
+func (ss *SQLiteStorage) GetLatestDockerContainerStats() []types.DockerContainerStats {
+	return latestDockerStats(sqliteReadLast[types.DockerContainerStats](ss, sqliteDockerStatsTable, ss.historySize(HistoryDocker)))
+}
+
+// GetStatus returns the current status of storage
+func (ss *SQLiteStorage) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"driver":               "sqlite",
+		"path":                 ss.path,
+		"alerts_count":         ss.count(sqliteAlertsTable),
+		"stats_history_count":  ss.count(sqliteSystemStatsTable),
+		"http_history_count":   ss.count(sqliteHTTPResultsTable),
+		"docker_history_count": ss.count(sqliteDockerStatsTable),
+		"events_count":         ss.count(sqliteEventsTable),
+		"max_history_size":     ss.maxHistorySize,
+		"max_history_sizes":    ss.allHistorySizes(),
+		"size_bytes":           ss.tableSizes(),
+		"file_size_bytes":      ss.pragma("page_count") * ss.pragma("page_size"),
+		"free_bytes":           ss.pragma("freelist_count") * ss.pragma("page_size"),
+		"timestamp":            time.Now().Format(time.RFC3339),
+	}
+}
+
+// tableSizes returns the size of the JSON data stored for each history type
+func (ss *SQLiteStorage) tableSizes() map[string]int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	sizes := make(map[string]int64, len(sqliteHistoryTables))
+	for dataType, table := range sqliteHistoryTables {
+		var size int64
+		query := fmt.Sprintf("SELECT COALESCE(SUM(LENGTH(data)), 0) FROM %s", table)
+		if err := ss.db.QueryRowContext(ctx, query).Scan(&size); err != nil {
+			slog.Error("Failed to measure sqlite table", "table", table, "error", err)
+		}
+		sizes[dataType] = size
+	}
+	return sizes
+}
+
+// pragma returns the value of a numeric pragma such as page_count, 0 if it can't be read
+func (ss *SQLiteStorage) pragma(name string) int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	var value int64
+	if err := ss.db.QueryRowContext(ctx, "PRAGMA "+name).Scan(&value); err != nil {
+		slog.Error("Failed to read sqlite pragma", "pragma", name, "error", err)
+	}
+	return value
+}
+
+// Compact rebuilds the database without its free pages when they make up more than half of it
+// (or when forced), and returns the database size before and after. Writes wait until the
+// rebuild is done.
+func (ss *SQLiteStorage) Compact(force bool) (int64, int64, error) {
+	pageSize := ss.pragma("page_size")
+	before := ss.pragma("page_count") * pageSize
+	if before == 0 || (!force && float64(ss.pragma("freelist_count")*pageSize)/float64(before) < compactThreshold) {
+		return before, before, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteCompactTimeout)
+	defer cancel()
+
+	if _, err := ss.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return before, before, fmt.Errorf("failed to vacuum sqlite database: %w", err)
+	}
+	// VACUUM goes through the write-ahead log; truncate it so the disk space is given back
+	if _, err := ss.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		slog.Warn("Failed to truncate sqlite write-ahead log", "error", err)
+	}
+	return before, ss.pragma("page_count") * pageSize, nil
+}
+
+// PurgeHistory removes entries of a data type recorded before the given time (all entries when before is zero)
+func (ss *SQLiteStorage) PurgeHistory(dataType string, before time.Time) (int, error) {
+	table, exists := sqliteHistoryTables[dataType]
+	if !exists {
+		return 0, fmt.Errorf("unknown history type: %s", dataType)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	var result sql.Result
+	var err error
+	if before.IsZero() {
+		result, err = ss.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", table))
+	} else {
+		result, err = ss.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE timestamp < ?", table), before.UnixNano())
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge %s: %w", dataType, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge %s: %w", dataType, err)
+	}
+
+	if dataType == HistoryChecks {
+		ss.rebuildIndex(ss.GetHTTPCheckResults())
+	}
+	return int(deleted), nil
+}
+
+// AddAuditEntry records a history purge
+func (ss *SQLiteStorage) AddAuditEntry(entry types.PurgeAuditEntry) {
+	ss.insert(sqliteAuditTable, entry)
+}
+
+// GetAuditEntries returns recorded history purges
+func (ss *SQLiteStorage) GetAuditEntries() []types.PurgeAuditEntry {
+	return sqliteReadLast[types.PurgeAuditEntry](ss, sqliteAuditTable, ss.maxHistorySize)
+}
+
+// AddAlertRecords adds processed alerts to the alert history
+func (ss *SQLiteStorage) AddAlertRecords(records []types.AlertRecord) {
+	if len(records) == 0 {
+		return
+	}
+	values := make([]interface{}, len(records))
+	for i, record := range records {
+		values[i] = record
+	}
+	ss.insert(sqliteAlertHistoryTable, values...)
+}
+
+// GetAlertRecords returns up to limit of the newest alert history records, oldest first
+func (ss *SQLiteStorage) GetAlertRecords(limit int) []types.AlertRecord {
+	return sqliteReadLast[types.AlertRecord](ss, sqliteAlertHistoryTable, limit)
+}
+
+// SaveAlertState stores the alerting state of an instance
+func (ss *SQLiteStorage) SaveAlertState(instance string, state types.SavedAlertState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert state: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	query := fmt.Sprintf("INSERT INTO %s (instance, data) VALUES (?, ?) ON CONFLICT (instance) DO UPDATE SET data = excluded.data", sqliteAlertStateTable)
+	if _, err := ss.db.ExecContext(ctx, query, instance, string(data)); err != nil {
+		return fmt.Errorf("failed to save alert state: %w", err)
+	}
+	return nil
+}
+
+// LoadAlertState returns the saved alerting state of an instance, nil if none was saved
+func (ss *SQLiteStorage) LoadAlertState(instance string) (*types.SavedAlertState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	var data string
+	query := fmt.Sprintf("SELECT data FROM %s WHERE instance = ?", sqliteAlertStateTable)
+	err := ss.db.QueryRowContext(ctx, query, instance).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert state: %w", err)
+	}
+
+	var state types.SavedAlertState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, fmt.Errorf("invalid saved alert state: %w", err)
+	}
+	return &state, nil
+}
+
+// GetSystemStatsRange returns the system stats collected between from and to
+func (ss *SQLiteStorage) GetSystemStatsRange(from, to time.Time) []types.SystemStats {
+	return sqliteReadRange[types.SystemStats](ss, sqliteSystemStatsTable, from, to)
+}
+
+// GetHTTPCheckResultsRange returns the check results recorded between from and to
+func (ss *SQLiteStorage) GetHTTPCheckResultsRange(from, to time.Time) []types.HTTPCheckResult {
+	return sqliteReadRange[types.HTTPCheckResult](ss, sqliteHTTPResultsTable, from, to)
+}
+
+// GetDockerContainerStatsRange returns the container stats collected between from and to
+func (ss *SQLiteStorage) GetDockerContainerStatsRange(from, to time.Time) []types.DockerContainerStats {
+	return sqliteReadRange[types.DockerContainerStats](ss, sqliteDockerStatsTable, from, to)
+}
+
+// AddEvent records a lifecycle event
+func (ss *SQLiteStorage) AddEvent(event types.LifecycleEvent) {
+	ss.insert(sqliteEventsTable, event)
+}
+
+// GetEventsRange returns the lifecycle events recorded between from and to
+func (ss *SQLiteStorage) GetEventsRange(from, to time.Time) []types.LifecycleEvent {
+	return sqliteReadRange[types.LifecycleEvent](ss, sqliteEventsTable, from, to)
+}
+
+// Close closes the database
+func (ss *SQLiteStorage) Close() error {
+	return ss.db.Close()
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestSQLiteStorage_Backend(t *testing.T) {
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "monic.sqlite"), 5)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+	defer storage.Close()
+
+	testStorageBackend(t, storage, 5)
+}
+
+func TestSQLiteStorage_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monic.sqlite")
+
+	storage, err := NewSQLiteStorage(path, 10)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+	now := time.Now()
+	storage.AddSystemStats(types.SystemStats{Timestamp: now, CPUUsage: 42})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Success: true, Timestamp: now})
+	storage.AddDockerContainerStats([]types.DockerContainerStats{{Name: "web", Timestamp: now}})
+	storage.AddAlert(types.Alert{Type: "cpu", Timestamp: now})
+	if err := storage.Close(); err != nil {
+		t.Fatalf("Failed to close sqlite storage: %v", err)
+	}
+
+	// History survives a restart
+	storage, err = NewSQLiteStorage(path, 10)
+	if err != nil {
+		t.Fatalf("Failed to reopen sqlite storage: %v", err)
+	}
+	defer storage.Close()
+
+	if stats := storage.GetLatestSystemStats(); stats == nil || stats.CPUUsage != 42 {
+		t.Errorf("Expected persisted system stats, got %+v", stats)
+	}
+	if results := storage.GetHTTPCheckResults(); len(results) != 1 || results[0].Name != "api" {
+		t.Errorf("Expected persisted HTTP result, got %+v", results)
+	}
+	if containers := storage.GetLatestDockerContainerStats(); len(containers) != 1 || containers[0].Name != "web" {
+		t.Errorf("Expected persisted container stats, got %+v", containers)
+	}
+	if alerts := storage.GetAlerts(); len(alerts) != 1 || alerts[0].Type != "cpu" {
+		t.Errorf("Expected persisted alert, got %+v", alerts)
+	}
+}
+
+func TestSQLiteStorage_Compact(t *testing.T) {
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "monic.sqlite"), 5000)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+	defer storage.Close()
+
+	for i := 0; i < 1000; i++ {
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Error: strings.Repeat("x", 500), Timestamp: time.Now()})
+	}
+	if _, err := storage.PurgeHistory(HistoryChecks, time.Time{}); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	if free := storage.GetStatus()["free_bytes"].(int64); free == 0 {
+		t.Fatal("Expected free pages after purging")
+	}
+
+	before, after, err := storage.Compact(false)
+	if err != nil || after >= before {
+		t.Fatalf("Expected the database to shrink, got %d -> %d (%v)", before, after, err)
+	}
+	if free := storage.GetStatus()["free_bytes"].(int64); free != 0 {
+		t.Errorf("Expected no free pages after compaction, got %d", free)
+	}
+
+	// Nothing left to reclaim unless forced
+	if before, after, err := storage.Compact(false); err != nil || before != after {
+		t.Errorf("Expected no compaction without free pages, got %d -> %d (%v)", before, after, err)
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -182,3 +183,225 @@ func TestStorageManager_GetLatestHTTPCheckResult(t *testing.T) {
 		t.Error("Expected nil for non-existent service")
 	}
 }
+
+// testStorageBackend exercises the Storage interface contract shared by all drivers
+func testStorageBackend(t *testing.T, storage Storage, maxHistory int) {
+	t.Helper()
+
+	if storage.GetLatestSystemStats() != nil {
+		t.Error("Expected no system stats in empty storage")
+	}
+
+	// History is trimmed to the maximum size, keeping the newest entries in order
+	for i := 0; i < maxHistory+2; i++ {
+		storage.AddSystemStats(types.SystemStats{Timestamp: time.Now(), CPUUsage: float64(i)})
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", StatusCode: 200 + i, ResponseTime: time.Millisecond, Success: true})
+	}
+
+	latest := storage.GetLatestSystemStats()
+	if latest == nil || latest.CPUUsage != float64(maxHistory+1) {
+		t.Errorf("Expected latest CPU usage %d, got %+v", maxHistory+1, latest)
+	}
+
+	results := storage.GetHTTPCheckResults()
+	if len(results) != maxHistory {
+		t.Fatalf("Expected %d HTTP results, got %d", maxHistory, len(results))
+	}
+	if results[0].StatusCode != 202 || results[len(results)-1].StatusCode != 201+maxHistory {
+		t.Errorf("Expected oldest entries to be trimmed, got first=%d last=%d", results[0].StatusCode, results[len(results)-1].StatusCode)
+	}
+	if results[0].ResponseTime != time.Millisecond || !results[0].Success {
+		t.Errorf("Expected result fields to round-trip, got %+v", results[0])
+	}
+
+	// Pending alerts
+	storage.AddAlert(types.Alert{Type: "cpu", Level: "critical"})
+	storage.AddAlerts([]types.Alert{{Type: "memory"}, {Type: "disk"}})
+	if storage.GetAlertsCount() != 3 {
+		t.Errorf("Expected 3 alerts, got %d", storage.GetAlertsCount())
+	}
+	alerts := storage.GetAlerts()
+	if len(alerts) != 3 || alerts[0].Type != "cpu" || alerts[2].Type != "disk" {
+		t.Errorf("Unexpected alerts: %+v", alerts)
+	}
+
+	storage.ClearAlerts()
+	if storage.GetAlertsCount() != 0 || len(storage.GetAlerts()) != 0 {
+		t.Error("Expected no alerts after clearing")
+	}
+
+	storage.AddAlerts([]types.Alert{{Type: "cpu"}, {Type: "memory"}})
+	if taken := storage.TakeAlerts(); len(taken) != 2 || taken[0].Type != "cpu" || taken[1].Type != "memory" {
+		t.Errorf("Expected to take 2 alerts in order, got %+v", taken)
+	}
+	storage.AddAlert(types.Alert{Type: "disk"})
+	if taken := storage.TakeAlerts(); len(taken) != 1 || taken[0].Type != "disk" || storage.GetAlertsCount() != 0 {
+		t.Errorf("Expected to take only the new alert, got %+v", taken)
+	}
+
+	// The alert history is kept apart from pending alerts
+	storage.AddAlertRecords([]types.AlertRecord{{ID: 1, Type: "cpu"}, {ID: 2, Type: "disk", Suppressed: suppressedSilenced}})
+	storage.AddAlertRecords([]types.AlertRecord{{ID: 3, Type: "memory", Tags: []string{"db"}}})
+	if records := storage.GetAlertRecords(2); len(records) != 2 || records[0].ID != 2 || records[0].Suppressed != suppressedSilenced || records[1].Tags[0] != "db" {
+		t.Errorf("Expected the 2 newest alert records, oldest first, got %+v", records)
+	}
+	if records := storage.GetAlertRecords(10); len(records) != 3 || records[0].Type != "cpu" || storage.GetAlertsCount() != 0 {
+		t.Errorf("Expected all 3 alert records, got %+v", records)
+	}
+
+	storage.AddDockerContainerStats([]types.DockerContainerStats{{Name: "web"}, {Name: "db"}})
+
+	status := storage.GetStatus()
+	if status["http_history_count"] != maxHistory || status["docker_history_count"] != 2 || status["alerts_count"] != 0 {
+		t.Errorf("Unexpected storage status: %+v", status)
+	}
+	if sizes, ok := status["size_bytes"].(map[string]int64); !ok || sizes[HistoryChecks] <= sizes[HistoryAlerts] {
+		t.Errorf("Expected stored check results to take more space than no alerts, got %v", status["size_bytes"])
+	}
+
+	// Lifecycle events are kept apart from alerts
+	startedAt := time.Now().Add(-time.Hour)
+	storage.AddEvent(types.LifecycleEvent{Timestamp: startedAt, Kind: EventServiceStarted, Subject: "monic", Actor: systemActor})
+	storage.AddEvent(types.LifecycleEvent{Timestamp: time.Now(), Kind: EventCheckAdded, Subject: "db", Actor: "admin"})
+	if events := storage.GetEventsRange(startedAt.Add(time.Second), time.Now()); len(events) != 1 || events[0].Kind != EventCheckAdded || events[0].Actor != "admin" {
+		t.Errorf("Expected the check_added event in range, got %+v", events)
+	}
+	if deleted, err := storage.PurgeHistory(HistoryEvents, startedAt.Add(time.Second)); err != nil || deleted != 1 || storage.GetAlertsCount() != 0 {
+		t.Errorf("Expected 1 purged event, got %d (err: %v)", deleted, err)
+	}
+
+	// The latest result and last failure of each check are indexed
+	failedAt := time.Now().Add(-time.Minute)
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "web", Timestamp: failedAt})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "web", Success: true, Timestamp: time.Now()})
+	statuses := storage.GetCheckStatuses()
+	if len(statuses) != 2 || statuses[0].Latest.Name != "api" || statuses[1].Latest.Name != "web" {
+		t.Fatalf("Expected statuses of api and web, got %+v", statuses)
+	}
+	if statuses[0].Latest.StatusCode != 201+maxHistory || !statuses[0].LastFailure.IsZero() {
+		t.Errorf("Expected latest api result without failures, got %+v", statuses[0])
+	}
+	if !statuses[1].Latest.Success || !statuses[1].LastFailure.Equal(failedAt) {
+		t.Errorf("Expected successful web check that failed at %v, got %+v", failedAt, statuses[1])
+	}
+
+	// Purging check history drops the checks without remaining results
+	if _, err := storage.PurgeHistory(HistoryChecks, failedAt.Add(time.Second)); err != nil {
+		t.Fatalf("Failed to purge checks: %v", err)
+	}
+	statuses = storage.GetCheckStatuses()
+	if len(statuses) != 1 || statuses[0].Latest.Name != "web" || !statuses[0].LastFailure.IsZero() {
+		t.Errorf("Expected only the web check without failures after purge, got %+v", statuses)
+	}
+
+	// A batch of results is written in order and indexed
+	storage.AddHTTPCheckResults([]types.HTTPCheckResult{
+		{Name: "db", Timestamp: time.Now()},
+		{Name: "db", Success: true, StatusCode: 204, Timestamp: time.Now()},
+	})
+	results = storage.GetHTTPCheckResults()
+	if last := results[len(results)-1]; last.Name != "db" || last.StatusCode != 204 || results[len(results)-2].Name != "db" {
+		t.Errorf("Expected the batch at the end of the history, got %+v", results)
+	}
+	statuses = storage.GetCheckStatuses()
+	if len(statuses) != 2 || statuses[0].Latest.Name != "db" || !statuses[0].Latest.Success || statuses[0].LastFailure.IsZero() {
+		t.Errorf("Expected the db check indexed from the batch, got %+v", statuses)
+	}
+
+	// Alert states are saved per instance and replaced on every save
+	if saved, err := storage.LoadAlertState("web-01"); err != nil || saved != nil {
+		t.Fatalf("Expected no saved alert state, got %+v (%v)", saved, err)
+	}
+	sentAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, checks := range []int{2, 3} {
+		state := types.SavedAlertState{
+			States:   []types.AlertState{{Type: "http/api", CurrentState: "critical", ConsecutiveChecks: checks, LastAlertSent: sentAt}},
+			LastSent: map[string]time.Time{"http/api": sentAt},
+		}
+		if err := storage.SaveAlertState("web-01", state); err != nil {
+			t.Fatalf("Failed to save alert state: %v", err)
+		}
+	}
+	saved, err := storage.LoadAlertState("web-01")
+	if err != nil || saved == nil || len(saved.States) != 1 || saved.States[0].ConsecutiveChecks != 3 || !saved.LastSent["http/api"].Equal(sentAt) {
+		t.Errorf("Expected the latest saved alert state, got %+v (%v)", saved, err)
+	}
+	if saved, _ := storage.LoadAlertState("web-02"); saved != nil {
+		t.Errorf("Expected no alert state of another instance, got %+v", saved)
+	}
+}
+
+func TestStorageManager_Backend(t *testing.T) {
+	testStorageBackend(t, NewStorageManager(5), 5)
+}
+
+func TestNewStorage(t *testing.T) {
+	storage, err := NewStorage(&types.StorageConfig{})
+	if err != nil {
+		t.Fatalf("Expected default memory storage, got error: %v", err)
+	}
+	if _, ok := storage.(*StorageManager); !ok {
+		t.Errorf("Expected *StorageManager, got %T", storage)
+	}
+
+	storage, err = NewStorage(&types.StorageConfig{Driver: "bolt", Path: filepath.Join(t.TempDir(), "monic.db")})
+	if err != nil {
+		t.Fatalf("Failed to create bolt storage: %v", err)
+	}
+	defer storage.Close()
+	if status := storage.GetStatus(); status["max_history_size"] != defaultDurableHistorySize {
+		t.Errorf("Expected durable default history size, got %v", status["max_history_size"])
+	}
+
+	sqlite, err := NewStorage(&types.StorageConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "monic.sqlite")})
+	if err != nil {
+		t.Fatalf("Failed to create sqlite storage: %v", err)
+	}
+	defer sqlite.Close()
+	if _, ok := sqlite.(*SQLiteStorage); !ok {
+		t.Errorf("Expected *SQLiteStorage, got %T", sqlite)
+	}
+
+	if _, err := NewStorage(&types.StorageConfig{Driver: "postgres"}); err == nil {
+		t.Error("Expected error for postgres storage without DSN")
+	}
+	if _, err := NewStorage(&types.StorageConfig{Driver: "mongo"}); err == nil {
+		t.Error("Expected error for unknown storage driver")
+	}
+}
+
+func TestNewStorage_HistorySizes(t *testing.T) {
+	retention := types.RetentionConfig{AlertsMax: 2, ChecksMax: 3}
+	for _, config := range []types.StorageConfig{
+		{MaxHistory: 5, Retention: retention},
+		{Driver: "bolt", Path: filepath.Join(t.TempDir(), "monic.db"), MaxHistory: 5, Retention: retention},
+		{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "monic.sqlite"), MaxHistory: 5, Retention: retention},
+	} {
+		storage, err := NewStorage(&config)
+		if err != nil {
+			t.Fatalf("Failed to create %s storage: %v", config.Driver, err)
+		}
+		defer storage.Close()
+
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			storage.AddAlert(types.Alert{Type: "cpu", Timestamp: now})
+			storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Timestamp: now})
+			storage.AddSystemStats(types.SystemStats{Timestamp: now})
+		}
+
+		status := storage.GetStatus()
+		expected := map[string]int{"alerts_count": 2, "http_history_count": 3, "stats_history_count": 5}
+		for key, count := range expected {
+			if status[key] != count {
+				t.Errorf("%s storage: expected %s %d, got %v", status["driver"], key, count, status[key])
+			}
+		}
+		if len(storage.GetHTTPCheckResults()) != 3 {
+			t.Errorf("%s storage: expected 3 HTTP results, got %d", status["driver"], len(storage.GetHTTPCheckResults()))
+		}
+		if sizes := status["max_history_sizes"].(map[string]int); sizes[HistoryAlerts] != 2 || sizes[HistoryDocker] != 5 {
+			t.Errorf("%s storage: unexpected history sizes %v", status["driver"], sizes)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// runnerSubsystem returns the subsystem name of a check runner (e.g. "grpc", "mail")
+func runnerSubsystem(runner CheckRunner) string {
+	return strings.ToLower(runner.Name())
+}
+
+// handleSubsystemToggle returns a handler for POST /api/v1/subsystems/{name}/pause and /resume.
+// Paused monitors skip their collection cycles; paused alerting logs alerts without sending them.
+func (s *StatsServer) handleSubsystemToggle(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.service == nil {
+			http.Error(w, "Subsystems are not available", http.StatusServiceUnavailable)
+			return
+		}
+
+		name := strings.ToLower(r.PathValue("name"))
+		status, err := s.service.startup.setPaused(name, paused)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		action, kind := "resumed", EventSubsystemResumed
+		if paused {
+			action, kind = "paused", EventSubsystemPaused
+		}
+		slog.Info("Subsystem "+action, "subsystem", name, "actor", requestActor(r))
+		s.recordEvent(kind, name, "Subsystem "+action, r)
+
+		writeJSON(w, status)
+	}
+}
+
+// ToggleSubsystem pauses or resumes a subsystem of the running instance via its stats server
+func ToggleSubsystem(config *types.HTTPServerConfig, name string, paused bool) error {
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+
+	url := localURL(config, fmt.Sprintf("/api/v1/subsystems/%s/%s", name, action))
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if config.Username != "" && config.Password != "" {
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	client, err := localClient(config, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
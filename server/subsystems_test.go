@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestStartupTracker_SetPaused(t *testing.T) {
+	tracker := newStartupTracker()
+	tracker.set("stats_server", subsystemRunning, nil)
+	tracker.set("http", subsystemRunning, nil)
+
+	status, err := tracker.setPaused("http", true)
+	if err != nil || !status.Paused || !tracker.isPaused("http") {
+		t.Fatalf("Expected http to be paused, got %+v (err: %v)", status, err)
+	}
+	if state := tracker.overall(); state != subsystemRunning {
+		t.Errorf("Expected paused subsystems not to degrade the overall state, got '%s'", state)
+	}
+
+	if _, err := tracker.setPaused("http", false); err != nil || tracker.isPaused("http") {
+		t.Errorf("Expected http to be resumed (err: %v)", err)
+	}
+	if _, err := tracker.setPaused("unknown", true); err == nil {
+		t.Error("Expected error for unknown subsystem")
+	}
+	if _, err := tracker.setPaused("stats_server", true); err == nil {
+		t.Error("Expected error when pausing the stats server")
+	}
+}
+
+func TestStatsServer_SubsystemToggleAPI(t *testing.T) {
+	config := &types.Config{
+		SystemChecks: types.SystemChecksConfig{CPUThreshold: 80, MemoryThreshold: 85, DiskThreshold: 90},
+		HTTPServer:   types.HTTPServerConfig{Username: "admin", Password: "secret"},
+	}
+	service := createTestMonitorService(t, config)
+	service.startup.set("stats_server", subsystemRunning, nil)
+	service.startup.set("alerting", subsystemRunning, nil)
+	service.startup.set("grpc", subsystemRunning, nil)
+	mux := service.statsServer.routes()
+
+	// Subsystem names are matched case-insensitively
+	req := httptest.NewRequest("POST", "/api/v1/subsystems/gRPC/pause", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var status subsystemStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || status.Name != "grpc" || !status.Paused {
+		t.Errorf("Expected paused grpc subsystem, got %+v (err: %v)", status, err)
+	}
+
+	// Pauses are reflected in the status endpoint and the dashboard
+	req = httptest.NewRequest("GET", "/api/v1/status", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"name":"grpc","state":"running","paused":true`) {
+		t.Errorf("Expected paused grpc in status, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/stats", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "● Paused") {
+		t.Error("Expected paused subsystem on the dashboard")
+	}
+
+	for path, code := range map[string]int{
+		"/api/v1/subsystems/grpc/resume":        http.StatusOK,
+		"/api/v1/subsystems/unknown/pause":      http.StatusNotFound,
+		"/api/v1/subsystems/stats_server/pause": http.StatusNotFound,
+	} {
+		req = httptest.NewRequest("POST", path, nil)
+		req.SetBasicAuth("admin", "secret")
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Errorf("Expected %d for %s, got %d", code, path, w.Code)
+		}
+	}
+	if service.startup.isPaused("grpc") {
+		t.Error("Expected grpc to be resumed")
+	}
+}
+
+func TestMonitorService_ProcessAlertsWhileAlertingPaused(t *testing.T) {
+	config := &types.Config{
+		Alerting: types.AlertingConfig{Telegram: types.TelegramConfig{Enabled: true, BotToken: "token", ChatID: "chat"}},
+	}
+	service := createTestMonitorService(t, config)
+	service.startup.set("alerting", subsystemRunning, nil)
+	if _, err := service.startup.setPaused("alerting", true); err != nil {
+		t.Fatalf("Failed to pause alerting: %v", err)
+	}
+
+	service.storage.AddAlert(types.Alert{Type: "cpu", Level: "critical", Timestamp: time.Now()})
+	service.processAlerts()
+
+	if service.storage.GetAlertsCount() != 0 {
+		t.Error("Expected alerts to be dropped while alerting is paused")
+	}
+	for _, channel := range service.alertManager.GetChannelStats() {
+		if channel.Sent+channel.Failed != 0 {
+			t.Errorf("Expected no delivery attempts on %s while alerting is paused", channel.Channel)
+		}
+	}
+}
+
+func TestToggleSubsystem(t *testing.T) {
+	config := &types.Config{}
+	service := createTestMonitorService(t, config)
+	service.startup.set("docker", subsystemRunning, nil)
+
+	ts := httptest.NewServer(service.statsServer.routes())
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+	client := &types.HTTPServerConfig{Port: port}
+
+	if err := ToggleSubsystem(client, "docker", true); err != nil {
+		t.Fatalf("Failed to pause docker: %v", err)
+	}
+	if !service.startup.isPaused("docker") {
+		t.Error("Expected docker to be paused")
+	}
+
+	err := ToggleSubsystem(client, "nope", true)
+	if err == nil || !strings.Contains(err.Error(), "unknown subsystem") {
+		t.Errorf("Expected unknown subsystem error, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"sort"
+
+	"bconf.com/monic/types"
+)
+
+// tagGroup summarizes the checks and containers sharing a tag, so groups such as
+// payment-critical can be viewed separately on the dashboard
+type tagGroup struct {
+	Tag        string `json:"tag"`
+	Checks     int    `json:"checks"`
+	Failing    int    `json:"failing"`    // Checks whose latest result failed
+	Containers int    `json:"containers"` // Containers of the latest Docker collection
+	Stopped    int    `json:"stopped"`    // Containers that are not running
+}
+
+// tagGroups returns a group for every tag of the checks and containers, sorted by tag
+func tagGroups(checks []types.HTTPCheckResult, containers []types.DockerContainerStats) []tagGroup {
+	groups := make(map[string]*tagGroup)
+	group := func(tag string) *tagGroup {
+		if groups[tag] == nil {
+			groups[tag] = &tagGroup{Tag: tag}
+		}
+		return groups[tag]
+	}
+
+	for _, check := range checks {
+		for _, tag := range check.Tags {
+			g := group(tag)
+			g.Checks++
+			if !check.Success {
+				g.Failing++
+			}
+		}
+	}
+	for _, container := range containers {
+		for _, tag := range container.Tags {
+			g := group(tag)
+			g.Containers++
+			if !container.Running {
+				g.Stopped++
+			}
+		}
+	}
+
+	sorted := make([]tagGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, *g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Tag < sorted[j].Tag
+	})
+	return sorted
+}
+
+// hasAnyTag reports whether tags include any of the wanted tags, ignoring case; no wanted tags
+// match everything
+func hasAnyTag(tags, wanted []string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if containsFold(wanted, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// containersWithTags returns the containers with any of the wanted tags
+func containersWithTags(containers []types.DockerContainerStats, wanted []string) []types.DockerContainerStats {
+	if len(wanted) == 0 {
+		return containers
+	}
+	var matching []types.DockerContainerStats
+	for _, container := range containers {
+		if hasAnyTag(container.Tags, wanted) {
+			matching = append(matching, container)
+		}
+	}
+	return matching
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+func TestTagGroups(t *testing.T) {
+	checks := []types.HTTPCheckResult{
+		{Name: "checkout", Success: false, Tags: []string{"payment-critical"}},
+		{Name: "cart", Success: true, Tags: []string{"payment-critical", "shop"}},
+		{Name: "blog", Success: true},
+	}
+	containers := []types.DockerContainerStats{
+		{Name: "payments", Running: false, Tags: []string{"payment-critical"}},
+		{Name: "web", Running: true, Tags: []string{"shop"}},
+	}
+
+	got := tagGroups(checks, containers)
+	want := []tagGroup{
+		{Tag: "payment-critical", Checks: 2, Failing: 1, Containers: 1, Stopped: 1},
+		{Tag: "shop", Checks: 1, Containers: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestStatsServer_TagFilter(t *testing.T) {
+	now := time.Now()
+	storage := NewStorageManager(100)
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "checkout", Success: true, Timestamp: now, Tags: []string{"payment-critical"}})
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "blog", Success: true, Timestamp: now})
+	storage.AddDockerContainerStats([]types.DockerContainerStats{
+		{Name: "payments", Running: true, Timestamp: now, Tags: []string{"payment-critical"}},
+		{Name: "web", Running: true, Timestamp: now},
+	})
+	systemMonitor := monitor.NewSystemMonitor(&types.SystemChecksConfig{DiskPaths: []string{"/"}})
+	server := NewStatsServer(&types.HTTPServerConfig{Enabled: true}, systemMonitor, storage, nil)
+
+	stats := server.getStatsResponse([]string{"payment-critical"})
+	checks := stats["http_checks"].([]map[string]interface{})
+	if len(checks) != 1 || checks[0]["name"] != "checkout" {
+		t.Errorf("Expected only the tagged check, got %v", checks)
+	}
+	containers := stats["containers"].([]types.DockerContainerStats)
+	if len(containers) != 1 || containers[0].Name != "payments" {
+		t.Errorf("Expected only the tagged container, got %+v", containers)
+	}
+	if groups := stats["tag_groups"].([]tagGroup); len(groups) != 1 || groups[0].Checks != 1 || groups[0].Containers != 1 {
+		t.Errorf("Expected one group with a check and a container, got %+v", groups)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleContainers(w, httptest.NewRequest("GET", "/api/v1/containers?tag=payment-critical", nil))
+	var response struct {
+		Containers []containerInventory `json:"containers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Containers) != 1 || !reflect.DeepEqual(response.Containers[0].Tags, []string{"payment-critical"}) {
+		t.Errorf("Expected only the tagged container, got %+v", response.Containers)
+	}
+}
@@ -1,15 +1,26 @@
 package server
 
 import (
+	"bytes"
 	"embed"
+	"errors"
+	"fmt"
 	"html/template"
+	"io/fs"
 	"log/slog"
 	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 )
 
-//go:embed templates/stats.html
+//go:embed templates/stats.html templates/latency.html templates/status.html templates/alerts.html
 var templateFS embed.FS
 
+// templateNames are the embedded templates, which can be replaced by files of the same name in
+// the branding template directory
+var templateNames = []string{"templates/stats.html", "templates/latency.html", "templates/status.html", "templates/alerts.html"}
+
 // funcMap defines template helper functions
 var funcMap = template.FuncMap{
 	"ge": func(a, b float64) bool {
@@ -21,29 +32,102 @@ var funcMap = template.FuncMap{
 	"float64": func(n uint64) float64 {
 		return float64(n)
 	},
+	"gigabytes": func(n int64) float64 {
+		return float64(n) / (1024 * 1024 * 1024)
+	},
 }
 
-// renderStatsHTML renders the stats page using the HTML template
-func renderStatsHTML(w http.ResponseWriter, stats map[string]interface{}) {
-	w.Header().Set("Content-Type", "text/html")
+// renderHTML renders an HTML template with the shared helper functions and the branding. A
+// custom template that fails is logged and replaced by the embedded one, so a broken template
+// never takes the dashboard down.
+func (s *StatsServer) renderHTML(w http.ResponseWriter, name string, data map[string]interface{}) {
+	data["branding"] = brandingData(s.config.Branding)
 
-	htmlBytes, err := templateFS.ReadFile("templates/stats.html")
+	var page bytes.Buffer
+	custom, err := customTemplate(s.config.Branding.TemplateDir, name)
+	if err == nil && custom != "" {
+		if err = executeTemplate(&page, name, custom, data); err == nil {
+			writeHTML(w, page.Bytes())
+			return
+		}
+	}
 	if err != nil {
-		slog.Error("Error reading embedded template", "error", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		slog.Error("Error rendering custom template, using the embedded one", "template", name, "error", err)
+		page.Reset()
 	}
 
-	tmpl, err := template.New("stats").Funcs(funcMap).Parse(string(htmlBytes))
+	htmlBytes, err := templateFS.ReadFile(name)
 	if err != nil {
-		slog.Error("Error parsing HTML template", "error", err)
+		slog.Error("Error reading embedded template", "template", name, "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-
-	if err := tmpl.Execute(w, stats); err != nil {
-		slog.Error("Error executing HTML template", "error", err)
+	if err := executeTemplate(&page, name, string(htmlBytes), data); err != nil {
+		slog.Error("Error rendering HTML template", "template", name, "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	writeHTML(w, page.Bytes())
+}
+
+// customTemplate reads the replacement of an embedded template from the template directory; it
+// returns "" when there is none
+func customTemplate(dir, name string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	content, err := os.ReadFile(filepath.Join(dir, path.Base(name)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read custom template: %w", err)
+	}
+	return string(content), nil
+}
+
+// executeTemplate parses and executes a template into a buffer, so a failing template does not
+// leave a half-written page behind
+func executeTemplate(page *bytes.Buffer, name, text string, data map[string]interface{}) error {
+	tmpl, err := template.New(name).Funcs(funcMap).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	if err := tmpl.Execute(page, data); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	return nil
+}
+
+// writeHTML sends a rendered page
+func writeHTML(w http.ResponseWriter, page []byte) {
+	w.Header().Set("Content-Type", "text/html")
+	if _, err := w.Write(page); err != nil {
+		slog.Debug("Error writing HTML response", "error", err)
+	}
+}
+
+// validateTemplates parses the custom templates of the template directory, so mistakes are
+// reported at startup instead of on the first page view
+func validateTemplates(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("template directory %s is not readable", dir)
+	}
+	for _, name := range templateNames {
+		text, err := customTemplate(dir, name)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			continue
+		}
+		if _, err := template.New(name).Funcs(funcMap).Parse(text); err != nil {
+			return fmt.Errorf("invalid template %s: %w", path.Base(name), err)
+		}
+		slog.Info("Using custom template", "template", filepath.Join(dir, path.Base(name)))
+	}
+	return nil
 }
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"bconf.com/monic/types"
+)
+
+// defaultACMECache is the directory ACME certificates are stored in when none is configured
+const defaultACMECache = "acme-certs"
+
+// serverTLSConfig builds the TLS configuration of the stats server from certificate files or
+// an ACME certificate manager
+func serverTLSConfig(config types.TLSConfig) (*tls.Config, error) {
+	files := config.CertFile != "" || config.KeyFile != ""
+	if files && len(config.ACMEDomains) > 0 {
+		return nil, fmt.Errorf("certificate files and ACME domains cannot be used together")
+	}
+
+	if len(config.ACMEDomains) > 0 {
+		cache := config.ACMECache
+		if cache == "" {
+			cache = defaultACMECache
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.ACMEDomains...),
+			Cache:      autocert.DirCache(cache),
+			Email:      config.ACMEEmail,
+		}
+		if config.ACMEDirectoryURL != "" {
+			manager.Client = &acme.Client{DirectoryURL: config.ACMEDirectoryURL}
+		}
+		// Answers TLS-ALPN-01 challenges on the stats server port itself
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, nil
+	}
+
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, fmt.Errorf("both certificate and key file are required")
+	}
+	reloader, err := newCertReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}, nil
+}
+
+// localClient returns a client for the stats server running on this host (see localURL). Over
+// HTTPS it trusts the configured certificate file, or verifies the ACME certificate for the first
+// domain, as the server is reached by its local address rather than a name of its certificate.
+func localClient(config *types.HTTPServerConfig, timeout time.Duration) (*http.Client, error) {
+	client := &http.Client{Timeout: timeout}
+	if !config.TLS.Enabled() {
+		return client, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(config.TLS.ACMEDomains) > 0 {
+		tlsConfig.ServerName = config.TLS.ACMEDomains[0]
+	} else {
+		data, err := os.ReadFile(config.TLS.CertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		certs := 0
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid certificate %s: %w", config.TLS.CertFile, err)
+			}
+			// The first certificate is the server's own, the name it is verified against
+			if certs == 0 && len(cert.DNSNames) > 0 {
+				tlsConfig.ServerName = cert.DNSNames[0]
+			}
+			tlsConfig.RootCAs.AddCert(cert)
+			certs++
+		}
+		if certs == 0 {
+			return nil, fmt.Errorf("no certificate found in %s", config.TLS.CertFile)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+	return client, nil
+}
+
+// certReloader serves a certificate from files and loads it again when they change, so renewed
+// certificates (e.g. by certbot) are picked up without a restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// newCertReloader loads the certificate files, failing if they cannot be used
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads the certificate and key files if they changed since they were last loaded
+func (r *certReloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	if r.cert != nil && modTime.Equal(r.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	if r.cert != nil {
+		slog.Info("Reloaded TLS certificate", "cert_file", r.certFile)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// latestModTime returns the modification time of the newer of the two files
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, fmt.Errorf("failed to read certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate; while the files cannot be read (e.g. in
+// the middle of a renewal) the previous certificate is kept
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(); err != nil {
+		slog.Warn("Keeping previous TLS certificate", "error", err)
+	}
+	return r.cert, nil
+}
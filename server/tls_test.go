@@ -0,0 +1,184 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// writeTestCert writes a self-signed certificate with the given serial number and its key
+func writeTestCert(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// servedSerial returns the serial number of the certificate served by a TLS configuration
+func servedSerial(t *testing.T, config *tls.Config) int64 {
+	t.Helper()
+	cert, err := config.GetCertificate(&tls.ClientHelloInfo{ServerName: "localhost"})
+	if err != nil || cert == nil {
+		t.Fatalf("Failed to get certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Invalid certificate: %v", err)
+	}
+	return leaf.SerialNumber.Int64()
+}
+
+func TestServerTLSConfig_CertFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, 1)
+
+	config, err := serverTLSConfig(types.TLSConfig{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("Failed to build TLS config: %v", err)
+	}
+	if config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Expected TLS 1.2 minimum, got %x", config.MinVersion)
+	}
+	if serial := servedSerial(t, config); serial != 1 {
+		t.Errorf("Expected certificate 1, got %d", serial)
+	}
+
+	// A renewed certificate is served without a restart
+	writeTestCert(t, certFile, keyFile, 2)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	if serial := servedSerial(t, config); serial != 2 {
+		t.Errorf("Expected renewed certificate 2, got %d", serial)
+	}
+
+	// While the files are unreadable the previous certificate is kept
+	os.Remove(keyFile)
+	if serial := servedSerial(t, config); serial != 2 {
+		t.Errorf("Expected previous certificate 2, got %d", serial)
+	}
+}
+
+func TestServerTLSConfig_ACME(t *testing.T) {
+	config, err := serverTLSConfig(types.TLSConfig{ACMEDomains: []string{"monic.example.com"}, ACMECache: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to build ACME TLS config: %v", err)
+	}
+	if config.GetCertificate == nil {
+		t.Error("Expected certificates from the ACME manager")
+	}
+	found := false
+	for _, proto := range config.NextProtos {
+		found = found || proto == "acme-tls/1"
+	}
+	if !found {
+		t.Errorf("Expected TLS-ALPN-01 challenge support, got %v", config.NextProtos)
+	}
+
+	// Hosts other than the configured domains never trigger certificate requests
+	if _, err := config.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"}); err == nil {
+		t.Error("Expected error for a host outside the ACME domains")
+	}
+}
+
+func TestServerTLSConfig_Invalid(t *testing.T) {
+	tests := []types.TLSConfig{
+		{CertFile: "cert.pem"},
+		{KeyFile: "key.pem"},
+		{CertFile: "missing.pem", KeyFile: "missing.pem"},
+		{CertFile: "cert.pem", KeyFile: "key.pem", ACMEDomains: []string{"monic.example.com"}},
+	}
+	for _, config := range tests {
+		if _, err := serverTLSConfig(config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
+
+func TestStatsServer_StartInvalidTLS(t *testing.T) {
+	config := &types.HTTPServerConfig{Enabled: true, Port: 0, TLS: types.TLSConfig{CertFile: "missing.pem", KeyFile: "missing.pem"}}
+	server := NewStatsServer(config, nil, NewStorageManager(10), nil)
+	if err := server.Start(); err == nil {
+		t.Error("Expected startup to fail with unreadable certificate files")
+	}
+}
+
+func TestToggleSubsystem_TLS(t *testing.T) {
+	service := createTestMonitorService(t, &types.Config{})
+	service.startup.set("docker", subsystemRunning, nil)
+
+	ts := httptest.NewTLSServer(service.statsServer.routes())
+	defer ts.Close()
+	certFile := filepath.Join(t.TempDir(), "cert.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+	config := &types.HTTPServerConfig{Port: port, TLS: types.TLSConfig{CertFile: certFile, KeyFile: "key.pem"}}
+	if err := ToggleSubsystem(config, "docker", true); err != nil {
+		t.Fatalf("Failed to pause docker over HTTPS: %v", err)
+	}
+	if !service.startup.isPaused("docker") {
+		t.Error("Expected docker to be paused")
+	}
+
+	// A certificate other than the served one is not trusted
+	otherCert := filepath.Join(t.TempDir(), "other.pem")
+	writeTestCert(t, otherCert, filepath.Join(t.TempDir(), "other.key"), 1)
+	config.TLS.CertFile = otherCert
+	if err := ToggleSubsystem(config, "docker", false); err == nil {
+		t.Error("Expected an untrusted certificate to be rejected")
+	}
+}
+
+func TestLocalClient_ACME(t *testing.T) {
+	config := &types.HTTPServerConfig{TLS: types.TLSConfig{ACMEDomains: []string{"monic.example.com", "www.example.com"}}}
+	client, err := localClient(config, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if name := client.Transport.(*http.Transport).TLSClientConfig.ServerName; name != "monic.example.com" {
+		t.Errorf("Expected the certificate to be verified for monic.example.com, got %q", name)
+	}
+}
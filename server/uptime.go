@@ -0,0 +1,247 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+const (
+	// defaultSLOWindowDays is the SLO window when none is configured
+	defaultSLOWindowDays = 30
+	// minSLOSamples avoids SLO alerts based on a handful of samples, e.g. right after startup
+	minSLOSamples = 10
+)
+
+// uptimeWindow is a rolling window of the availability report
+type uptimeWindow struct {
+	Name     string
+	Duration time.Duration
+}
+
+// uptimeWindows are the windows reported per check and container
+var uptimeWindows = []uptimeWindow{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// availability is the share of successful samples in a window
+type availability struct {
+	Percent  *float64 `json:"percent"` // Null without samples
+	Samples  int      `json:"samples"`
+	Failures int      `json:"failures"`
+}
+
+// String formats the availability for the dashboard, e.g. "99.95%"
+func (a availability) String() string {
+	if a.Percent == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", *a.Percent)
+}
+
+// uptimeEntry is the availability of a check or container in each window. The windows only
+// cover the stored history, which starts at Since.
+type uptimeEntry struct {
+	Name    string                  `json:"name"`
+	Type    string                  `json:"type"` // Check type or "container"
+	Since   time.Time               `json:"since"`
+	Windows map[string]availability `json:"windows"`
+}
+
+// uptimeSample is a check result or container sample reduced to what availability needs
+type uptimeSample struct {
+	Time time.Time
+	Up   bool
+}
+
+// uptimeSeries groups samples per check ("type/name") or container ("container/name")
+type uptimeSeries map[string][]uptimeSample
+
+// newUptimeSeries groups check results and container stats; a container counts as up while running
+func newUptimeSeries(results []types.HTTPCheckResult, containers []types.DockerContainerStats) uptimeSeries {
+	series := make(uptimeSeries)
+	for _, result := range results {
+		key := checkKey(result)
+		series[key] = append(series[key], uptimeSample{result.Timestamp, result.Success})
+	}
+	for _, container := range containers {
+		key := "container/" + container.Name
+		series[key] = append(series[key], uptimeSample{container.Timestamp, container.Running})
+	}
+	return series
+}
+
+// availabilitySince computes the availability of samples at or after since
+func availabilitySince(samples []uptimeSample, since time.Time) availability {
+	var a availability
+	for _, sample := range samples {
+		if sample.Time.Before(since) {
+			continue
+		}
+		a.Samples++
+		if !sample.Up {
+			a.Failures++
+		}
+	}
+	if a.Samples > 0 {
+		percent := 100 * float64(a.Samples-a.Failures) / float64(a.Samples)
+		a.Percent = &percent
+	}
+	return a
+}
+
+// buildUptime computes the availability of every check and container in all uptime windows
+func buildUptime(series uptimeSeries, now time.Time) []uptimeEntry {
+	entries := make([]uptimeEntry, 0, len(series))
+	for key, samples := range series {
+		entry := uptimeEntry{Windows: make(map[string]availability, len(uptimeWindows))}
+		entry.Type, entry.Name = splitCheckKey(key)
+		for _, sample := range samples {
+			if entry.Since.IsZero() || sample.Time.Before(entry.Since) {
+				entry.Since = sample.Time
+			}
+		}
+		for _, window := range uptimeWindows {
+			entry.Windows[window.Name] = availabilitySince(samples, now.Add(-window.Duration))
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		// Checks first, then containers
+		containerI, containerJ := entries[i].Type == "container", entries[j].Type == "container"
+		if containerI != containerJ {
+			return containerJ
+		}
+		if entries[i].Type != entries[j].Type {
+			return entries[i].Type < entries[j].Type
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// splitCheckKey splits a "type/name" key
+func splitCheckKey(key string) (string, string) {
+	checkType, name, _ := strings.Cut(key, "/")
+	return checkType, name
+}
+
+// sloStatuses measures every check and container with enough samples against the SLO target,
+// limited to the configured SLO checks
+func sloStatuses(series uptimeSeries, config types.SLOConfig, now time.Time) []types.SLOStatus {
+	windowDays := config.WindowDays
+	if windowDays <= 0 {
+		windowDays = defaultSLOWindowDays
+	}
+	since := now.AddDate(0, 0, -windowDays)
+
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var statuses []types.SLOStatus
+	for _, key := range keys {
+		checkType, name := splitCheckKey(key)
+		if len(config.Checks) > 0 && !matchesCheck(types.HTTPCheckResult{Type: checkType, Name: name}, config.Checks) {
+			continue
+		}
+		a := availabilitySince(series[key], since)
+		if a.Samples < minSLOSamples {
+			continue
+		}
+		statuses = append(statuses, types.SLOStatus{
+			Name:         key,
+			Availability: *a.Percent,
+			Target:       config.Target,
+			WindowDays:   windowDays,
+			Samples:      a.Samples,
+		})
+	}
+	return statuses
+}
+
+// errorBudgetRemaining returns the share of the error budget (allowed failures) that is left, in
+// percent; negative once the budget is overspent
+func errorBudgetRemaining(status types.SLOStatus) float64 {
+	allowed := 100 - status.Target
+	if allowed <= 0 {
+		if status.Exhausted() {
+			return -100
+		}
+		return 100
+	}
+	return 100 * (1 - (100-status.Availability)/allowed)
+}
+
+// loadUptimeSeries loads the stored samples of the longest uptime window, or of the SLO window
+// when it is longer
+func loadUptimeSeries(storage Storage, now time.Time, sloDays int) uptimeSeries {
+	since := now.Add(-uptimeWindows[len(uptimeWindows)-1].Duration)
+	if slo := now.AddDate(0, 0, -sloDays); slo.Before(since) {
+		since = slo
+	}
+	return newUptimeSeries(storage.GetHTTPCheckResultsRange(since, now), storage.GetDockerContainerStatsRange(since, now))
+}
+
+// handleUptime handles GET /api/v1/uptime: availability per check and container over the last
+// 24 hours, 7 and 30 days, and their SLO state when an SLO target is configured
+func (s *StatsServer) handleUptime(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	var slo types.SLOConfig
+	if s.service != nil {
+		slo = s.service.config.SLO
+	}
+
+	windows := make([]string, 0, len(uptimeWindows))
+	for _, window := range uptimeWindows {
+		windows = append(windows, window.Name)
+	}
+
+	series := loadUptimeSeries(s.storage, now, slo.WindowDays)
+	response := map[string]interface{}{
+		"windows": windows,
+		"entries": buildUptime(series, now),
+	}
+
+	if slo.Target > 0 {
+		statuses := make([]map[string]interface{}, 0)
+		for _, status := range sloStatuses(series, slo, now) {
+			statuses = append(statuses, map[string]interface{}{
+				"name":                   status.Name,
+				"availability":           status.Availability,
+				"target":                 status.Target,
+				"window_days":            status.WindowDays,
+				"samples":                status.Samples,
+				"error_budget_remaining": errorBudgetRemaining(status),
+				"exhausted":              status.Exhausted(),
+			})
+		}
+		response["slo"] = statuses
+	}
+
+	writeJSON(w, response)
+}
+
+// evaluateSLOs alerts on checks and containers whose SLO error budget is exhausted
+func (ms *MonitorService) evaluateSLOs() {
+	if ms.config.SLO.Target <= 0 {
+		return
+	}
+
+	now := time.Now()
+	series := loadUptimeSeries(ms.storage, now, ms.config.SLO.WindowDays)
+	if alerts := ms.stateManager.UpdateSLOState(sloStatuses(series, ms.config.SLO, now)); len(alerts) > 0 {
+		ms.raiseAlerts(alerts)
+		slog.Info("SLO alerts generated", "count", len(alerts))
+	}
+}
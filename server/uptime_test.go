@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bconf.com/monic/monitor"
+	"bconf.com/monic/types"
+)
+
+// seedUptimeHistory stores an "api" check that failed 1 of 4 times in the last day and 2 of 10
+// times over the last week, and a container stopped once
+func seedUptimeHistory(storage Storage, now time.Time) {
+	for i := 0; i < 10; i++ {
+		ts := now.Add(-time.Duration(i) * 15 * time.Hour)
+		storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "api", Type: "http", Success: i != 1 && i != 6, Timestamp: ts})
+		storage.AddDockerContainerStats([]types.DockerContainerStats{{Name: "web", Running: i != 0, Timestamp: ts}})
+	}
+}
+
+func TestBuildUptime(t *testing.T) {
+	now := time.Now()
+	storage := NewStorageManager(100)
+	seedUptimeHistory(storage, now)
+
+	entries := buildUptime(loadUptimeSeries(storage, now, 0), now)
+	if len(entries) != 2 || entries[0].Name != "api" || entries[0].Type != "http" || entries[1].Type != "container" {
+		t.Fatalf("Expected the api check before the web container, got %+v", entries)
+	}
+
+	day := entries[0].Windows["24h"]
+	if day.Samples != 2 || day.Failures != 1 || *day.Percent != 50 {
+		t.Errorf("Expected 1 of 2 samples failed in 24h, got %+v", day)
+	}
+	week := entries[0].Windows["7d"]
+	if week.Samples != 10 || week.Failures != 2 || *week.Percent != 80 || week.String() != "80.00%" {
+		t.Errorf("Expected 80%% availability in 7d, got %+v", week)
+	}
+	if container := entries[1].Windows["30d"]; container.Samples != 10 || *container.Percent != 90 {
+		t.Errorf("Expected 90%% container availability, got %+v", container)
+	}
+	if !entries[0].Since.Equal(now.Add(-135 * time.Hour)) {
+		t.Errorf("Expected history since the oldest sample, got %v", entries[0].Since)
+	}
+
+	if (availability{}).String() != "-" {
+		t.Error("Expected no availability without samples")
+	}
+}
+
+func TestSLOStatuses(t *testing.T) {
+	now := time.Now()
+	storage := NewStorageManager(100)
+	seedUptimeHistory(storage, now)
+	storage.AddHTTPCheckResult(types.HTTPCheckResult{Name: "new", Type: "grpc", Success: false, Timestamp: now})
+
+	series := loadUptimeSeries(storage, now, 7)
+	statuses := sloStatuses(series, types.SLOConfig{Target: 85, WindowDays: 7}, now)
+	// The grpc check has too few samples to be judged
+	if len(statuses) != 2 || statuses[0].Name != "container/web" || statuses[1].Name != "http/api" {
+		t.Fatalf("Expected container/web and http/api, got %+v", statuses)
+	}
+	if statuses[0].Exhausted() || !statuses[1].Exhausted() {
+		t.Errorf("Expected only the api budget to be exhausted, got %+v", statuses)
+	}
+	if remaining := errorBudgetRemaining(statuses[0]); math.Abs(remaining-100.0/3) > 0.001 {
+		t.Errorf("Expected a third of the container budget left, got %f", remaining)
+	}
+	if remaining := errorBudgetRemaining(statuses[1]); math.Abs(remaining+100.0/3) > 0.001 {
+		t.Errorf("Expected the api budget overspent by a third, got %f", remaining)
+	}
+
+	filtered := sloStatuses(series, types.SLOConfig{Target: 85, Checks: []string{"api"}}, now)
+	if len(filtered) != 1 || filtered[0].Name != "http/api" || filtered[0].WindowDays != defaultSLOWindowDays {
+		t.Errorf("Expected only http/api over the default window, got %+v", filtered)
+	}
+}
+
+func TestStatsServer_HandleUptime(t *testing.T) {
+	config := &types.Config{SLO: types.SLOConfig{Target: 85, WindowDays: 7}}
+	ms := createTestMonitorService(t, config)
+	seedUptimeHistory(ms.storage, time.Now())
+
+	w := httptest.NewRecorder()
+	ms.statsServer.routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/uptime", nil))
+
+	var response struct {
+		Windows []string
+		Entries []uptimeEntry
+		SLO     []map[string]interface{}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Invalid response %s: %v", w.Body.String(), err)
+	}
+	if len(response.Windows) != 3 || len(response.Entries) != 2 {
+		t.Errorf("Expected 3 windows and 2 entries, got %+v", response)
+	}
+	if len(response.SLO) != 2 || response.SLO[1]["name"] != "http/api" || response.SLO[1]["exhausted"] != true {
+		t.Errorf("Expected exhausted SLO of http/api, got %+v", response.SLO)
+	}
+}
+
+func TestMonitorService_EvaluateSLOs(t *testing.T) {
+	config := &types.Config{SLO: types.SLOConfig{Target: 85, WindowDays: 7, Checks: []string{"api"}}}
+	ms := createTestMonitorService(t, config)
+	seedUptimeHistory(ms.storage, time.Now())
+
+	ms.evaluateSLOs()
+	alerts := ms.storage.GetAlerts()
+	if len(alerts) != 1 || alerts[0].Type != "slo_http_api" || alerts[0].Level != "critical" {
+		t.Fatalf("Expected an SLO alert for http/api, got %+v", alerts)
+	}
+
+	// The alert is only sent once while the budget stays exhausted
+	ms.storage.ClearAlerts()
+	ms.evaluateSLOs()
+	if alerts := ms.storage.GetAlerts(); len(alerts) != 0 {
+		t.Errorf("Expected no repeated SLO alert, got %+v", alerts)
+	}
+}
+
+func TestStatsServer_HandleStats_Availability(t *testing.T) {
+	storage := NewStorageManager(100)
+	seedUptimeHistory(storage, time.Now())
+	systemMonitor := monitor.NewSystemMonitor(&types.SystemChecksConfig{DiskPaths: []string{"/"}})
+	server := NewStatsServer(&types.HTTPServerConfig{Enabled: true}, systemMonitor, storage, nil)
+
+	w := httptest.NewRecorder()
+	server.handleStats(w, httptest.NewRequest("GET", "/stats", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, "<h2>Availability</h2>") || !strings.Contains(body, "80.00%") {
+		t.Error("Expected the availability table in HTML")
+	}
+}
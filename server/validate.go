@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+// ConfigCheck is the outcome of one configuration check, e.g. of the HTTP checks or of the DNS
+// resolution of a target
+type ConfigCheck struct {
+	Name  string
+	Error error // nil when the check passed
+}
+
+// Validate runs the configuration checks of Start without starting anything and returns the
+// outcome of each, so all problems are reported at once instead of only the first
+func (ms *MonitorService) Validate() []ConfigCheck {
+	checks := []ConfigCheck{{Name: "http", Error: ms.validateHTTPChecks()}}
+
+	if ms.peers != nil {
+		var err error
+		if err = ms.peers.Validate(); err != nil {
+			err = fmt.Errorf("invalid peer configuration: %w", err)
+		}
+		checks = append(checks, ConfigCheck{Name: "peers", Error: err})
+	}
+
+	for _, runner := range ms.checkRunners {
+		var err error
+		if err = runner.Validate(); err != nil {
+			err = fmt.Errorf("invalid %s check configuration: %w", runner.Name(), err)
+		}
+		checks = append(checks, ConfigCheck{Name: runnerSubsystem(runner), Error: err})
+	}
+
+	if ms.config.DockerChecks.Enabled {
+		var err error
+		if err = ms.dockerMonitor.Validate(); err != nil {
+			err = fmt.Errorf("invalid Docker check configuration: %w", err)
+		}
+		checks = append(checks, ConfigCheck{Name: "docker", Error: err})
+	}
+
+	var err error
+	if err = validateCheckCaches(ms.checkRunners, ms.config.CheckCaches); err != nil {
+		err = fmt.Errorf("invalid check cache configuration: %w", err)
+	}
+	checks = append(checks, ConfigCheck{Name: "check_cache", Error: err})
+
+	_, err = ms.buildSchedules()
+	checks = append(checks, ConfigCheck{Name: "schedules", Error: err})
+
+	if err = ms.alertManager.ValidateConfig(); err != nil {
+		err = fmt.Errorf("invalid alerting configuration: %w", err)
+	}
+	checks = append(checks, ConfigCheck{Name: "alerting", Error: err})
+
+	return checks
+}
+
+// configTarget is a host name Monic connects to, with the setting it comes from
+type configTarget struct {
+	Host   string
+	Source string // e.g. "http/api" or "influxdb"
+}
+
+// configTargets returns the host names of all checks, peers, alert channels and exporters in
+// the order of the configuration. IP addresses are left out, as they need no resolution.
+func configTargets(cfg *types.Config) []configTarget {
+	var targets []configTarget
+	add := func(source, address string) {
+		host := targetHost(address)
+		if host != "" && net.ParseIP(host) == nil {
+			targets = append(targets, configTarget{Host: host, Source: source})
+		}
+	}
+
+	for _, check := range cfg.HTTPChecks {
+		add(httpLoopName(check), check.URL)
+	}
+	for _, check := range cfg.GRPCChecks {
+		add("grpc/"+check.Name, check.Address)
+	}
+	for _, check := range cfg.MailChecks {
+		add("mail/"+check.Name, check.Host)
+	}
+	for _, check := range cfg.NTPChecks {
+		add("ntp/"+check.Name, check.Server)
+	}
+	for _, check := range cfg.SNMPChecks {
+		add("snmp/"+check.Name, check.Target)
+	}
+	for _, check := range cfg.MQTTChecks {
+		add("mqtt/"+check.Name, check.Broker)
+	}
+	for _, check := range cfg.QueueChecks {
+		add("queue/"+check.Name, check.URL)
+		for _, broker := range check.Brokers {
+			add("queue/"+check.Name, broker)
+		}
+	}
+	for _, peer := range cfg.Peers {
+		add("peer/"+peer.Name, peer.URL)
+	}
+
+	if cfg.Alerting.Email.Enabled {
+		add("alerting/email", cfg.Alerting.Email.SMTPHost)
+	}
+	add("agent", cfg.Agent.ServerURL)
+	for _, address := range strings.Split(cfg.NATS.URL, ",") {
+		add("nats", address)
+	}
+	add("influxdb", cfg.InfluxDB.URL)
+	add("remote_write", cfg.RemoteWrite.URL)
+	add("graphite", cfg.Graphite.Address)
+	add("statsd", cfg.StatsD.Address)
+	return targets
+}
+
+// targetHost returns the host name of a URL, a host:port address or a plain host name
+func targetHost(address string) string {
+	address = strings.TrimSpace(address)
+	if strings.Contains(address, "://") {
+		parsed, err := url.Parse(address)
+		if err != nil {
+			return ""
+		}
+		return parsed.Hostname()
+	}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// ResolveTargets resolves the host name of every check, peer, alert channel and exporter target
+// and returns one check per host name
+func ResolveTargets(cfg *types.Config, timeout time.Duration) []ConfigCheck {
+	return resolveTargets(configTargets(cfg), timeout, net.DefaultResolver.LookupHost)
+}
+
+// resolveTargets looks up the targets concurrently, each host name once, keeping their order
+func resolveTargets(targets []configTarget, timeout time.Duration, lookup func(ctx context.Context, host string) ([]string, error)) []ConfigCheck {
+	var unique []configTarget
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		if !seen[target.Host] {
+			seen[target.Host] = true
+			unique = append(unique, target)
+		}
+	}
+
+	checks := make([]ConfigCheck, len(unique))
+	var wg sync.WaitGroup
+	for i, target := range unique {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			checks[i].Name = fmt.Sprintf("dns %s (%s)", target.Host, target.Source)
+			if _, err := lookup(ctx, target.Host); err != nil {
+				checks[i].Error = fmt.Errorf("failed to resolve %s: %w", target.Host, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return checks
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bconf.com/monic/types"
+)
+
+func TestMonitorService_Validate(t *testing.T) {
+	config := &types.Config{
+		HTTPChecks:   []types.HTTPCheck{{URL: "example.com", Method: "GET", Timeout: 5, ExpectedStatus: 200, CheckInterval: 30}},
+		SystemChecks: types.SystemChecksConfig{Schedule: "every day"},
+		Alerting:     types.AlertingConfig{Telegram: types.TelegramConfig{Enabled: true, BotToken: "token", ChatID: "42"}},
+	}
+	service := createTestMonitorService(t, config)
+
+	failed := make(map[string]bool)
+	for _, check := range service.Validate() {
+		failed[check.Name] = check.Error != nil
+	}
+	// All problems are reported, not only the first
+	if !failed["http"] || !failed["schedules"] || failed["alerting"] {
+		t.Errorf("Expected failing HTTP checks and schedules only, got %v", failed)
+	}
+}
+
+func TestResolveTargets(t *testing.T) {
+	config := &types.Config{
+		HTTPChecks: []types.HTTPCheck{
+			{Name: "api", URL: "https://api.example.com/health"},
+			{Name: "admin", URL: "https://api.example.com/admin"},
+		},
+		GRPCChecks: []types.GRPCCheck{{Name: "payments", Address: "payments.internal:443"}},
+		NTPChecks:  []types.NTPCheck{{Name: "local", Server: "10.0.0.1"}},
+		Graphite:   types.GraphiteConfig{Address: "graphite:2003"},
+	}
+
+	lookup := func(ctx context.Context, host string) ([]string, error) {
+		if host == "payments.internal" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"192.0.2.1"}, nil
+	}
+	checks := resolveTargets(configTargets(config), time.Second, lookup)
+
+	// Each host name is resolved once; IP addresses are skipped
+	want := []struct {
+		name   string
+		failed bool
+	}{
+		{"dns api.example.com (http/api)", false},
+		{"dns payments.internal (grpc/payments)", true},
+		{"dns graphite (graphite)", false},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("Expected %d checks, got %+v", len(want), checks)
+	}
+	for i, w := range want {
+		if checks[i].Name != w.name || (checks[i].Error != nil) != w.failed {
+			t.Errorf("Expected %s (failed: %v), got %+v", w.name, w.failed, checks[i])
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteTimeout limits how long a single message may take to reach the client
+	wsWriteTimeout = 10 * time.Second
+	// wsPongTimeout closes connections that stop answering pings
+	wsPongTimeout = 2 * eventKeepalive
+	// wsMaxCommandSize limits the size of subscription commands sent by clients
+	wsMaxCommandSize = 4096
+)
+
+// wsUpgrader upgrades /ws requests; the default origin check only accepts same-origin pages
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// wsMessage is a message sent to WebSocket clients: an event with the same data as the SSE
+// stream, the current subscriptions after a command ("subscribed") or a command error ("error")
+type wsMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// wsCommand changes the event types a WebSocket connection receives
+type wsCommand struct {
+	Action string   `json:"action"` // "subscribe" or "unsubscribe"
+	Types  []string `json:"types"`
+}
+
+// handleWebSocket handles GET /ws: the live events of /events over a WebSocket. The event types
+// can be chosen with "types" and changed at any time with subscribe and unsubscribe commands.
+func (s *StatsServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	selected, err := parseEventTypes(r.URL.Query().Get("types"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied with an error status
+		slog.Debug("WebSocket upgrade failed", "remote", r.RemoteAddr, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	// Commands are read in their own goroutine; all writes happen in the loop below
+	commands := make(chan []byte)
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(wsMaxCommandSize)
+		conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		})
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				slog.Debug("WebSocket connection closed", "remote", r.RemoteAddr, "error", err)
+				return
+			}
+			select {
+			case commands <- message:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(eventKeepalive)
+	defer ping.Stop()
+
+	send := func(message wsMessage) bool {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(message) == nil
+	}
+
+	if !send(wsMessage{Type: "subscribed", Data: subscribedTypes(selected)}) {
+		return
+	}
+
+	for {
+		var ok bool
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			// The server is shutting down
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(wsWriteTimeout))
+			return
+		case <-ping.C:
+			ok = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)) == nil
+		case message := <-commands:
+			ok = send(applyWSCommand(selected, message))
+		case event := <-events:
+			if !selected[event.Type] {
+				continue
+			}
+			ok = send(wsMessage{Type: event.Type, Data: event.Data})
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// applyWSCommand updates the subscriptions of a connection and returns the reply to the command.
+// Without types, a command applies to all event types.
+func applyWSCommand(selected map[string]bool, message []byte) wsMessage {
+	var command wsCommand
+	if err := json.Unmarshal(message, &command); err != nil {
+		return wsMessage{Type: "error", Data: "invalid command: " + err.Error()}
+	}
+	if command.Action != "subscribe" && command.Action != "unsubscribe" {
+		return wsMessage{Type: "error", Data: fmt.Sprintf("unknown action %q (must be subscribe or unsubscribe)", command.Action)}
+	}
+
+	requested, err := parseEventTypes(strings.Join(command.Types, ","))
+	if err != nil {
+		return wsMessage{Type: "error", Data: err.Error()}
+	}
+	for eventType := range requested {
+		selected[eventType] = command.Action == "subscribe"
+	}
+	return wsMessage{Type: "subscribed", Data: subscribedTypes(selected)}
+}
+
+// subscribedTypes returns the selected event types in a stable order
+func subscribedTypes(selected map[string]bool) []string {
+	result := make([]string, 0, len(selected))
+	for eventType, subscribed := range selected {
+		if subscribed {
+			result = append(result, eventType)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
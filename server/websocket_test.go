@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"bconf.com/monic/types"
+)
+
+// dialWebSocket connects to the /ws endpoint of a test server and reads the initial subscriptions
+func dialWebSocket(t *testing.T, ts *httptest.Server, query string) (*websocket.Conn, []string) {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws"+query, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	message := readWSMessage(t, conn)
+	if message.Type != "subscribed" {
+		t.Fatalf("Expected subscriptions first, got %+v", message)
+	}
+	var subscribed []string
+	json.Unmarshal(message.Data, &subscribed)
+	return conn, subscribed
+}
+
+// readWSMessage reads the next message of a WebSocket connection
+func readWSMessage(t *testing.T, conn *websocket.Conn) struct {
+	Type string
+	Data json.RawMessage
+} {
+	t.Helper()
+	var message struct {
+		Type string
+		Data json.RawMessage
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	return message
+}
+
+func TestStatsServer_HandleWebSocket(t *testing.T) {
+	server := NewStatsServer(&types.HTTPServerConfig{Enabled: true}, nil, NewStorageManager(10), nil)
+	ts := httptest.NewServer(server.routes())
+	defer ts.Close()
+
+	conn, subscribed := dialWebSocket(t, ts, "?types=docker")
+	if !reflect.DeepEqual(subscribed, []string{"docker"}) {
+		t.Fatalf("Expected docker subscription, got %v", subscribed)
+	}
+
+	// Only subscribed event types are delivered
+	server.events.Publish(EventCheck, types.HTTPCheckResult{Name: "api"})
+	server.events.Publish(EventDocker, []types.DockerContainerStats{{Name: "web"}})
+	message := readWSMessage(t, conn)
+	var containers []types.DockerContainerStats
+	if message.Type != EventDocker || json.Unmarshal(message.Data, &containers) != nil || containers[0].Name != "web" {
+		t.Fatalf("Expected docker event, got %+v", message)
+	}
+
+	conn.WriteJSON(map[string]interface{}{"action": "subscribe", "types": []string{"check"}})
+	message = readWSMessage(t, conn)
+	json.Unmarshal(message.Data, &subscribed)
+	if message.Type != "subscribed" || !reflect.DeepEqual(subscribed, []string{"check", "docker"}) {
+		t.Fatalf("Expected check and docker subscriptions, got %+v", message)
+	}
+
+	conn.WriteJSON(map[string]interface{}{"action": "unsubscribe", "types": []string{"docker"}})
+	readWSMessage(t, conn)
+	server.events.Publish(EventDocker, []types.DockerContainerStats{{Name: "web"}})
+	server.events.Publish(EventCheck, types.HTTPCheckResult{Name: "api"})
+	if message = readWSMessage(t, conn); message.Type != EventCheck {
+		t.Fatalf("Expected check event after unsubscribing from docker, got %+v", message)
+	}
+
+	conn.WriteJSON(map[string]interface{}{"action": "subscribe", "types": []string{"logs"}})
+	if message = readWSMessage(t, conn); message.Type != "error" {
+		t.Errorf("Expected error for an unknown event type, got %+v", message)
+	}
+}
+
+func TestStatsServer_HandleWebSocket_AllTypesByDefault(t *testing.T) {
+	server := NewStatsServer(&types.HTTPServerConfig{Enabled: true}, nil, NewStorageManager(10), nil)
+	ts := httptest.NewServer(server.routes())
+	defer ts.Close()
+
+	_, subscribed := dialWebSocket(t, ts, "")
+	if !reflect.DeepEqual(subscribed, []string{"alert", "check", "docker", "lifecycle", "system"}) {
+		t.Errorf("Expected all event types, got %v", subscribed)
+	}
+}
+
+func TestStatsServer_HandleWebSocket_InvalidTypes(t *testing.T) {
+	server := NewStatsServer(&types.HTTPServerConfig{Enabled: true}, nil, NewStorageManager(10), nil)
+
+	w := httptest.NewRecorder()
+	server.routes().ServeHTTP(w, httptest.NewRequest("GET", "/ws?types=logs", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown event type, got %d", w.Code)
+	}
+}
+
+func TestApplyWSCommand(t *testing.T) {
+	selected := map[string]bool{EventSystem: true}
+
+	if reply := applyWSCommand(selected, []byte(`{"action":"unsubscribe"}`)); reply.Type != "subscribed" || len(reply.Data.([]string)) != 0 {
+		t.Errorf("Expected unsubscribing without types to clear all subscriptions, got %+v", reply)
+	}
+	if reply := applyWSCommand(selected, []byte(`not json`)); reply.Type != "error" {
+		t.Errorf("Expected error for invalid JSON, got %+v", reply)
+	}
+	if reply := applyWSCommand(selected, []byte(`{"action":"mute"}`)); reply.Type != "error" {
+		t.Errorf("Expected error for an unknown action, got %+v", reply)
+	}
+}
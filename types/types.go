@@ -2,40 +2,445 @@ package types
 
 import "time"
 
-// Config represents the main configuration structure
+// Config represents the main configuration structure. Lists are loaded from indexed variables;
+// their envconfig tag is the variable prefix. Durations are plain numbers in the unit of their
+// unit tag (s, m or ms); configuration also accepts duration strings such as 5m for them.
 type Config struct {
 	AppName      string             `envconfig:"APP_NAME"`
+	LogLevel     string             `envconfig:"LOG_LEVEL"`  // debug, info, warn or error (default: info)
+	LogFormat    string             `envconfig:"LOG_FORMAT"` // json or text (default: json)
 	SystemChecks SystemChecksConfig `envconfig:"CHECK_SYSTEM"`
-	HTTPChecks   HTTPCheck          `envconfig:"CHECK_HTTP"`
-	Alerting     AlertingConfig     `envconfig:"ALERTING"`
-	DockerChecks DockerConfig       `envconfig:"CHECK_DOCKER"`
-	HTTPServer   HTTPServerConfig   `envconfig:"HTTP_SERVER"`
+	HTTPChecks   []HTTPCheck        `envconfig:"CHECK_HTTP" ignored:"true"`   // Loaded from MONIC_CHECK_HTTP_* and MONIC_CHECK_HTTP_<N>_* variables
+	GRPCChecks   []GRPCCheck        `envconfig:"CHECK_GRPC" ignored:"true"`   // Loaded from MONIC_CHECK_GRPC_<N>_* variables
+	MailChecks   []MailCheck        `envconfig:"CHECK_MAIL" ignored:"true"`   // Loaded from MONIC_CHECK_MAIL_<N>_* variables
+	NTPChecks    []NTPCheck         `envconfig:"CHECK_NTP" ignored:"true"`    // Loaded from MONIC_CHECK_NTP_<N>_* variables
+	SNMPChecks   []SNMPCheck        `envconfig:"CHECK_SNMP" ignored:"true"`   // Loaded from MONIC_CHECK_SNMP_<N>_* variables
+	MQTTChecks   []MQTTCheck        `envconfig:"CHECK_MQTT" ignored:"true"`   // Loaded from MONIC_CHECK_MQTT_<N>_* variables
+	QueueChecks  []QueueCheck       `envconfig:"CHECK_QUEUE" ignored:"true"`  // Loaded from MONIC_CHECK_QUEUE_<N>_* variables
+	ScriptChecks []ScriptCheck      `envconfig:"CHECK_SCRIPT" ignored:"true"` // Loaded from MONIC_CHECK_SCRIPT_<N>_* variables
+	Heartbeats   []HeartbeatCheck   `envconfig:"HEARTBEAT" ignored:"true"`    // Loaded from MONIC_HEARTBEAT_<N>_* variables
+	CheckCaches  []CheckCache       `envconfig:"CHECK_CACHE" ignored:"true"`  // Loaded from MONIC_CHECK_CACHE_<N>_* variables
+	Peers        []PeerConfig       `envconfig:"PEER" ignored:"true"`         // Loaded from MONIC_PEER_<N>_* variables
+	// CheckJitter delays every run of the HTTP checks and the other check loops by a random time up
+	// to this many milliseconds, so checks on the same interval don't all run at once
+	CheckJitter int `envconfig:"CHECK_JITTER" unit:"ms"`
+	// CheckMaxConcurrent limits the HTTP and managed checks running at once (default: 50), and
+	// CheckMaxPerHost the ones against the same host (default: 0, no limit)
+	CheckMaxConcurrent int `envconfig:"CHECK_MAX_CONCURRENT"`
+	CheckMaxPerHost    int `envconfig:"CHECK_MAX_PER_HOST"`
+	// CheckTransport tunes the connections of the HTTP checks
+	CheckTransport HTTPTransportConfig `envconfig:"CHECK_TRANSPORT"`
+	// CheckAlertingSMTP adds a mail check for the SMTP server used to deliver email alerts
+	CheckAlertingSMTP bool             `envconfig:"CHECK_MAIL_ALERTING_SMTP"`
+	Alerting          AlertingConfig   `envconfig:"ALERTING"`
+	DockerChecks      DockerConfig     `envconfig:"CHECK_DOCKER"`
+	HTTPServer        HTTPServerConfig `envconfig:"HTTP_SERVER"`
+	Storage           StorageConfig    `envconfig:"STORAGE"`
+	NATS              NATSConfig       `envconfig:"NATS"`
+	InfluxDB          InfluxDBConfig   `envconfig:"INFLUXDB"`
+	Agent             AgentConfig      `envconfig:"AGENT"`
+	SLO               SLOConfig        `envconfig:"SLO"`
+	// RemoteWrite pushes system stats and check results with the Prometheus remote write protocol
+	RemoteWrite RemoteWriteConfig `envconfig:"REMOTE_WRITE"`
+	// Graphite and StatsD receive system gauges and check latencies on every collection
+	Graphite GraphiteConfig `envconfig:"GRAPHITE"`
+	StatsD   StatsDConfig   `envconfig:"STATSD"`
+	// ManagedChecks are HTTP, TCP and DNS checks created at runtime through the check management API
+	ManagedChecks ManagedChecksConfig `envconfig:"MANAGED_CHECKS"`
+	// Watchdog detects monitoring loops that stopped making progress
+	Watchdog WatchdogConfig `envconfig:"WATCHDOG"`
+}
+
+// WatchdogConfig controls when a monitoring loop counts as stalled, e.g. on a hung Docker socket
+// or NFS mount, and whether it is restarted
+type WatchdogConfig struct {
+	StallIntervals int  `envconfig:"STALL_INTERVALS"` // Intervals without progress after which a loop is stalled (default: 3)
+	Restart        bool `envconfig:"RESTART"`         // Replace a stalled loop with a new one, abandoning its hung cycle
+}
+
+// HTTPTransportConfig controls how HTTP checks reuse connections and resolve host names, so
+// frequent checks don't resolve and handshake on every run
+type HTTPTransportConfig struct {
+	MaxIdleConns        int `envconfig:"MAX_IDLE_CONNS"`             // Idle connections kept open across all hosts (default: 100)
+	MaxIdleConnsPerHost int `envconfig:"MAX_IDLE_CONNS_PER_HOST"`    // Idle connections kept open per host (default: 2)
+	IdleConnTimeout     int `envconfig:"IDLE_CONN_TIMEOUT" unit:"s"` // Time an idle connection is kept open (default: 90s)
+	DNSCacheTTL         int `envconfig:"DNS_CACHE_TTL" unit:"s"`     // Time resolved addresses are reused (default: 0, resolve on every connection)
+}
+
+// SLOConfig enables alerts when the availability of a check or container drops below a target
+type SLOConfig struct {
+	Target float64 `envconfig:"TARGET"` // Availability target in percent, e.g. 99.9 (0 disables SLO alerts)
+	// WindowDays is the rolling window the availability is measured over (default: 30)
+	WindowDays int `envconfig:"WINDOW_DAYS"`
+	// Checks limits SLO alerts to these checks ("type/name", plain name or "container/name");
+	// empty covers all checks and containers
+	Checks []string `envconfig:"CHECKS"`
+}
+
+// SLOStatus is the availability of a check or container measured against the SLO target
+type SLOStatus struct {
+	Name         string  // "type/name" of a check or "container/name"
+	Availability float64 // Percent of successful samples in the window
+	Target       float64
+	WindowDays   int
+	Samples      int
+}
+
+// Exhausted reports whether the error budget is used up, i.e. availability is below the target
+func (s SLOStatus) Exhausted() bool {
+	return s.Availability < s.Target
+}
+
+// LoopStatus is the progress of one of Monic's own monitoring loops
+type LoopStatus struct {
+	Subsystem    string
+	Stalled      bool          // No cycle completed for several intervals, or a cycle hangs
+	LastProgress time.Time     // Last finished cycle, or the start of the hanging one
+	Interval     time.Duration // Expected time between cycles
 }
 
 // SystemChecksConfig contains system monitoring settings
 type SystemChecksConfig struct {
-	Interval        int      `envconfig:"INTERVAL"`
+	Interval        int      `envconfig:"INTERVAL" unit:"s"`
+	Schedule        string   `envconfig:"SCHEDULE"` // Cron expression (e.g. "*/5 * * * *"), replaces the interval when set
 	CPUThreshold    int      `envconfig:"CPU_THRESHOLD"`
 	MemoryThreshold int      `envconfig:"MEMORY_THRESHOLD"`
 	DiskThreshold   int      `envconfig:"DISK_THRESHOLD"`
 	DiskPaths       []string `envconfig:"DISK_PATHS"`
+	// LoadThreshold is the 5-minute load average per CPU core that triggers an alert (0 disables)
+	LoadThreshold float64 `envconfig:"LOAD_THRESHOLD"`
+	// SwapThreshold is the swap usage percentage that triggers an alert (0 disables)
+	SwapThreshold int `envconfig:"SWAP_THRESHOLD"`
+	// InodeThreshold is the inode usage percentage per disk that triggers an alert (0 disables)
+	InodeThreshold int `envconfig:"INODE_THRESHOLD"`
+	// DiskIODevices limits I/O stats to these block devices (default: all except loop and ram devices)
+	DiskIODevices []string `envconfig:"DISK_IO_DEVICES"`
+	// DiskAwaitThreshold is the average I/O request time per device in milliseconds that triggers an alert (0 disables)
+	DiskAwaitThreshold float64 `envconfig:"DISK_AWAIT_THRESHOLD"`
+	// Tags are added to the alerts of the system checks, e.g. infra
+	Tags []string `envconfig:"TAGS"`
 }
 
 // HTTPCheck defines a single HTTP/HTTPS endpoint to monitor
 type HTTPCheck struct {
-	URL            string    `envconfig:"URL"`
-	Method         string    `envconfig:"METHOD"`
-	Timeout        int       `envconfig:"TIMEOUT"`
-	ExpectedStatus int       `envconfig:"EXPECTED_STATUS"`
-	CheckInterval  int       `envconfig:"INTERVAL"`
-	LastCheck      time.Time ``
+	Name              string      `envconfig:"NAME"` // Required when more than one HTTP check is configured
+	URL               string      `envconfig:"URL"`
+	Method            string      `envconfig:"METHOD"`
+	Timeout           int         `envconfig:"TIMEOUT" unit:"s"`
+	ExpectedStatus    int         `envconfig:"EXPECTED_STATUS"`
+	CheckInterval     int         `envconfig:"INTERVAL" unit:"s"`
+	Schedule          string      `envconfig:"SCHEDULE"`              // Cron expression (e.g. "30 6 * * *"), replaces the interval when set
+	Jitter            int         `envconfig:"JITTER" unit:"ms"`      // Maximum random delay of each run (default: MONIC_CHECK_JITTER)
+	Retries           int         `envconfig:"RETRIES"`               // Quick retries of a failed run before it counts as a failure
+	RetryDelay        int         `envconfig:"RETRY_DELAY" unit:"ms"` // Delay before the first retry, doubled for each further one (default: 1s)
+	Invert            bool        `envconfig:"INVERT"`                // Canary mode: alert if the check succeeds
+	DisableKeepAlives bool        `envconfig:"DISABLE_KEEP_ALIVES"`   // Connect and handshake on every run instead of reusing connections
+	MinLocations      int         `envconfig:"MIN_LOCATIONS"`         // Locations (this instance and peers) that must succeed (default: half)
+	Tags              []string    `envconfig:"TAGS"`                  // Groups the check, e.g. payment-critical; added to its results and alerts
+	Alert             AlertTarget `envconfig:"ALERT"`                 // Channels and recipients of the check's alerts, replacing the routes
+	LastCheck         time.Time   ``
+}
+
+// defaultRetryDelay is the delay before the first retry of a failed HTTP check run
+const defaultRetryDelay = time.Second
+
+// RetryDelays returns the delays before each retry of a failed run, starting at RetryDelay and
+// doubling for each further retry
+func (c HTTPCheck) RetryDelays() []time.Duration {
+	delay := time.Duration(c.RetryDelay) * time.Millisecond
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	delays := make([]time.Duration, 0, max(c.Retries, 0))
+	for i := 0; i < c.Retries; i++ {
+		delays = append(delays, delay)
+		delay *= 2
+	}
+	return delays
+}
+
+// GRPCCheck defines a gRPC endpoint checked via the grpc.health.v1 Health/Check protocol
+type GRPCCheck struct {
+	Name               string      `envconfig:"NAME"`
+	Address            string      `envconfig:"ADDRESS"` // host:port
+	Service            string      `envconfig:"SERVICE"` // Empty string checks overall server health
+	TLS                bool        `envconfig:"TLS"`
+	InsecureSkipVerify bool        `envconfig:"INSECURE_SKIP_VERIFY"`
+	ServerName         string      `envconfig:"SERVER_NAME"` // Overrides the TLS server name
+	Timeout            int         `envconfig:"TIMEOUT" unit:"s"`
+	CheckInterval      int         `envconfig:"INTERVAL" unit:"s"`
+	Invert             bool        `envconfig:"INVERT"` // Canary mode: alert if the service is reachable
+	Alert              AlertTarget `envconfig:"ALERT"`  // Channels and recipients of the check's alerts, replacing the routes
+}
+
+// MailCheck defines an SMTP or IMAP server handshake/login probe
+type MailCheck struct {
+	Name               string      `envconfig:"NAME"`
+	Protocol           string      `envconfig:"PROTOCOL"` // "smtp" or "imap"
+	Host               string      `envconfig:"HOST"`
+	Port               int         `envconfig:"PORT"`
+	TLS                bool        `envconfig:"TLS"`      // Implicit TLS (e.g. 465 for SMTP, 993 for IMAP)
+	StartTLS           bool        `envconfig:"STARTTLS"` // Upgrade the connection with STARTTLS
+	InsecureSkipVerify bool        `envconfig:"INSECURE_SKIP_VERIFY"`
+	Username           string      `envconfig:"USERNAME"` // Optional: performs SMTP AUTH / IMAP LOGIN
+	Password           string      `envconfig:"PASSWORD"`
+	Timeout            int         `envconfig:"TIMEOUT" unit:"s"`
+	CheckInterval      int         `envconfig:"INTERVAL" unit:"s"`
+	Alert              AlertTarget `envconfig:"ALERT"` // Channels and recipients of the check's alerts, replacing the routes
+}
+
+// NTPCheck defines an NTP server queried to measure the drift of the host clock
+type NTPCheck struct {
+	Name          string      `envconfig:"NAME"`
+	Server        string      `envconfig:"SERVER"`              // host or host:port (default port 123)
+	MaxDrift      int         `envconfig:"MAX_DRIFT" unit:"ms"` // Maximum allowed clock offset in milliseconds
+	Timeout       int         `envconfig:"TIMEOUT" unit:"s"`
+	CheckInterval int         `envconfig:"INTERVAL" unit:"s"`
+	Alert         AlertTarget `envconfig:"ALERT"` // Channels and recipients of the check's alerts, replacing the routes
+}
+
+// SNMPCheck defines an OID polled on a network device (switch, UPS, ...) and the range its value must stay in
+type SNMPCheck struct {
+	Name      string `envconfig:"NAME"`
+	Target    string `envconfig:"TARGET"`    // host or host:port (default port 161)
+	Version   string `envconfig:"VERSION"`   // 1, 2c or 3 (default: 2c)
+	Community string `envconfig:"COMMUNITY"` // v1/v2c community (default: public)
+	// SNMPv3 user-based security; authentication and privacy are enabled by their protocols
+	Username     string `envconfig:"USERNAME"`
+	AuthProtocol string `envconfig:"AUTH_PROTOCOL"` // MD5, SHA, SHA224, SHA256, SHA384 or SHA512
+	AuthPassword string `envconfig:"AUTH_PASSWORD"`
+	PrivProtocol string `envconfig:"PRIV_PROTOCOL"` // DES, AES, AES192 or AES256
+	PrivPassword string `envconfig:"PRIV_PASSWORD"`
+	OID          string `envconfig:"OID"`
+	// Min and Max bound numeric values; Expect requires an exact (string) value
+	Min           *float64    `envconfig:"MIN"`
+	Max           *float64    `envconfig:"MAX"`
+	Expect        string      `envconfig:"EXPECT"`
+	Timeout       int         `envconfig:"TIMEOUT" unit:"s"`
+	CheckInterval int         `envconfig:"INTERVAL" unit:"s"`
+	Alert         AlertTarget `envconfig:"ALERT"` // Channels and recipients of the check's alerts, replacing the routes
+}
+
+// MQTTCheck defines an MQTT broker and optionally a topic that must carry messages
+type MQTTCheck struct {
+	Name               string `envconfig:"NAME"`
+	Broker             string `envconfig:"BROKER"`    // tcp://, ssl://, ws:// or wss:// URL
+	ClientID           string `envconfig:"CLIENT_ID"` // Default: monic-<host>-<name>
+	Username           string `envconfig:"USERNAME"`
+	Password           string `envconfig:"PASSWORD"`
+	InsecureSkipVerify bool   `envconfig:"INSECURE_SKIP_VERIFY"`
+	// Topic enables message checks: with Publish a canary message is published and must be
+	// received back, otherwise some message has to arrive on the topic within Window seconds
+	Topic         string      `envconfig:"TOPIC"`
+	Publish       bool        `envconfig:"PUBLISH"`
+	Window        int         `envconfig:"WINDOW" unit:"s"`
+	QoS           int         `envconfig:"QOS"`
+	Timeout       int         `envconfig:"TIMEOUT" unit:"s"`
+	CheckInterval int         `envconfig:"INTERVAL" unit:"s"`
+	Alert         AlertTarget `envconfig:"ALERT"` // Channels and recipients of the check's alerts, replacing the routes
+}
+
+// QueueCheck defines a message queue whose backlog must stay below a limit: the depth of a
+// RabbitMQ queue (management API) or the lag of a Kafka consumer group
+type QueueCheck struct {
+	Name string `envconfig:"NAME"`
+	Type string `envconfig:"TYPE"` // "rabbitmq" or "kafka"
+	// RabbitMQ
+	URL   string `envconfig:"URL"`   // Management API, e.g. http://rabbitmq:15672
+	VHost string `envconfig:"VHOST"` // Default: /
+	Queue string `envconfig:"QUEUE"`
+	// Kafka
+	Brokers []string `envconfig:"BROKERS"` // Bootstrap brokers (host:port)
+	Group   string   `envconfig:"GROUP"`   // Consumer group
+	Topic   string   `envconfig:"TOPIC"`   // Default: all topics the group committed offsets for
+	TLS     bool     `envconfig:"TLS"`
+	// Username and Password are the management API credentials (RabbitMQ) or SASL/PLAIN credentials (Kafka)
+	Username           string      `envconfig:"USERNAME"`
+	Password           string      `envconfig:"PASSWORD"`
+	InsecureSkipVerify bool        `envconfig:"INSECURE_SKIP_VERIFY"`
+	MaxBacklog         int64       `envconfig:"MAX_BACKLOG"` // Maximum queued messages (RabbitMQ) or consumer lag (Kafka)
+	Timeout            int         `envconfig:"TIMEOUT" unit:"s"`
+	CheckInterval      int         `envconfig:"INTERVAL" unit:"s"`
+	Alert              AlertTarget `envconfig:"ALERT"` // Channels and recipients of the check's alerts, replacing the routes
+}
+
+// ScriptCheck defines an external plugin: an executable whose exit code and output (a JSON
+// object or Nagios plugin output) become the check result
+type ScriptCheck struct {
+	Name          string      `envconfig:"NAME"`
+	Command       string      `envconfig:"COMMAND"` // Path of the executable, or a name looked up in PATH
+	Args          []string    `envconfig:"ARGS"`    // Comma-separated arguments
+	Format        string      `envconfig:"FORMAT"`  // json, nagios or auto (default: auto, JSON when the output starts with "{")
+	Timeout       int         `envconfig:"TIMEOUT" unit:"s"`
+	CheckInterval int         `envconfig:"INTERVAL" unit:"s"`
+	Alert         AlertTarget `envconfig:"ALERT"` // Channels and recipients of the check's alerts, replacing the routes
+}
+
+// HeartbeatCheck defines an external job that must report in via POST /api/heartbeat/{name}
+type HeartbeatCheck struct {
+	Name     string      `envconfig:"NAME"`
+	Interval int         `envconfig:"INTERVAL" unit:"s"` // Expected time between heartbeats in seconds
+	Grace    int         `envconfig:"GRACE" unit:"s"`    // Extra seconds to wait before a heartbeat counts as missed
+	Alert    AlertTarget `envconfig:"ALERT"`             // Channels and recipients of the job's missed-heartbeat alerts, replacing the routes
+}
+
+// ManagedChecksConfig enables the check management API
+type ManagedChecksConfig struct {
+	File string `envconfig:"FILE"` // JSON file the checks are persisted to (empty disables the API)
+}
+
+// ManagedCheck is an HTTP, TCP or DNS check created, changed and deleted at runtime
+type ManagedCheck struct {
+	Name           string      `json:"name"`
+	Type           string      `json:"type"`                      // "http", "tcp" or "dns"
+	Target         string      `json:"target"`                    // URL (http), host:port (tcp) or host name (dns)
+	Method         string      `json:"method,omitempty"`          // HTTP method (default: GET)
+	ExpectedStatus int         `json:"expected_status,omitempty"` // HTTP status code (default: 200)
+	Expected       string      `json:"expected,omitempty"`        // Address the host name must resolve to (dns, default: any)
+	Timeout        int         `json:"timeout"`                   // Seconds (default: 10)
+	Interval       int         `json:"interval"`                  // Seconds between checks (default: 60)
+	Paused         bool        `json:"paused"`
+	Alert          AlertTarget `json:"alert,omitzero"` // Channels and recipients of the check's alerts, replacing the routes
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// CheckCache marks the checks of a check runner as expensive: they run in the background and
+// their latest results are reused, so a slow collection is not treated as a failure
+type CheckCache struct {
+	Subsystem string `envconfig:"SUBSYSTEM"`        // Check runner subsystem, e.g. mail or ntp
+	MaxAge    int    `envconfig:"MAX_AGE" unit:"s"` // Seconds results may be reused before they count as failed (default: 3 intervals)
+}
+
+// NATSConfig configures streaming of check results and alerts to NATS JetStream
+type NATSConfig struct {
+	URL           string `envconfig:"URL"`            // e.g. nats://localhost:4222 (empty disables publishing)
+	SubjectPrefix string `envconfig:"SUBJECT_PREFIX"` // Subject prefix (default: monic)
+	Stream        string `envconfig:"STREAM"`         // Stream created for <prefix>.> if missing (empty: an existing stream must cover the subjects)
+	Username      string `envconfig:"USERNAME"`
+	Password      string `envconfig:"PASSWORD"`
+	Token         string `envconfig:"TOKEN"`
+	BufferSize    int    `envconfig:"BUFFER_SIZE"` // Messages kept while NATS is unreachable (default: 10000)
+}
+
+// InfluxDBConfig configures forwarding of system stats and check results to InfluxDB. InfluxDB 2.x
+// and 3.x are written to with Org, Bucket and Token, InfluxDB 1.x with Database and optional
+// Username and Password.
+type InfluxDBConfig struct {
+	URL               string `envconfig:"URL"` // e.g. http://localhost:8086 (empty disables forwarding)
+	Org               string `envconfig:"ORG"`
+	Bucket            string `envconfig:"BUCKET"`
+	Token             string `envconfig:"TOKEN"`
+	Database          string `envconfig:"DATABASE"`
+	Username          string `envconfig:"USERNAME"`
+	Password          string `envconfig:"PASSWORD"`
+	MeasurementPrefix string `envconfig:"MEASUREMENT_PREFIX"`      // Prefix of measurement names (default: monic)
+	FlushInterval     int    `envconfig:"FLUSH_INTERVAL" unit:"s"` // Seconds between writes (default: 10)
+	BatchSize         int    `envconfig:"BATCH_SIZE"`              // Points per write request (default: 1000)
+	BufferSize        int    `envconfig:"BUFFER_SIZE"`             // Points kept while InfluxDB is unreachable (default: 10000)
+	Timeout           int    `envconfig:"TIMEOUT" unit:"s"`        // Seconds per write request (default: 10)
+}
+
+// RemoteWriteConfig configures pushing of system stats and check results with the Prometheus
+// remote write protocol, e.g. to Mimir, VictoriaMetrics, Thanos or Prometheus itself
+type RemoteWriteConfig struct {
+	URL         string            `envconfig:"URL"` // e.g. http://mimir:9009/api/v1/push (empty disables pushing)
+	Username    string            `envconfig:"USERNAME"`
+	Password    string            `envconfig:"PASSWORD"`
+	BearerToken string            `envconfig:"BEARER_TOKEN"`
+	Headers     map[string]string `envconfig:"HEADERS"` // Extra request headers, e.g. X-Scope-OrgID:tenant-1
+	// Labels are added to every series, e.g. env:prod (instance defaults to the host name)
+	Labels        map[string]string `envconfig:"LABELS"`
+	FlushInterval int               `envconfig:"FLUSH_INTERVAL" unit:"s"` // Seconds between pushes (default: 10)
+	BatchSize     int               `envconfig:"BATCH_SIZE"`              // Samples per request (default: 1000)
+	BufferSize    int               `envconfig:"BUFFER_SIZE"`             // Samples kept while the receiver is unreachable (default: 10000)
+	Timeout       int               `envconfig:"TIMEOUT" unit:"s"`        // Seconds per request (default: 10)
+}
+
+// GraphiteConfig configures sending of system gauges and check latencies to Graphite (Carbon
+// plaintext protocol over TCP)
+type GraphiteConfig struct {
+	Address string `envconfig:"ADDRESS"`          // host:port of Carbon, e.g. graphite:2003 (empty disables sending)
+	Prefix  string `envconfig:"PREFIX"`           // Prefix of metric paths (default: monic.<host>)
+	Timeout int    `envconfig:"TIMEOUT" unit:"s"` // Seconds to connect and send (default: 5)
+}
+
+// StatsDConfig configures sending of system gauges and check latencies to StatsD over UDP
+type StatsDConfig struct {
+	Address string `envconfig:"ADDRESS"` // host:port of StatsD, e.g. statsd:8125 (empty disables sending)
+	Prefix  string `envconfig:"PREFIX"`  // Prefix of metric names (default: monic.<host>)
+}
+
+// PeerConfig is another Monic instance, usually in a different region, that runs the HTTP check
+// on behalf of this one (it needs MONIC_HTTP_SERVER_PROBES_ENABLED=true)
+type PeerConfig struct {
+	Name string `envconfig:"NAME"` // Location name shown in check errors, e.g. eu-west
+	URL  string `envconfig:"URL"`  // Stats server of the peer, e.g. https://monic-eu.example.com
+	// Secret signs probe requests (the peer's MONIC_HTTP_SERVER_INGEST_SECRET); without it the peer's
+	// basic auth credentials are used
+	Secret   string `envconfig:"SECRET"`
+	Username string `envconfig:"USERNAME"`
+	Password string `envconfig:"PASSWORD"`
+}
+
+// AgentConfig runs Monic as a remote agent that pushes its system and Docker stats to a central
+// Monic instance (which needs MONIC_HTTP_SERVER_AGENTS_ENABLED=true)
+type AgentConfig struct {
+	ServerURL string `envconfig:"SERVER_URL"`        // Central instance, e.g. https://monic.example.com (empty disables agent mode)
+	Host      string `envconfig:"HOST"`              // Host name shown on the central instance (default: app name or hostname)
+	Interval  int    `envconfig:"INTERVAL" unit:"s"` // Seconds between reports (default: 30)
+	Timeout   int    `envconfig:"TIMEOUT" unit:"s"`  // Report request timeout in seconds (default: 10)
+	// Secret signs reports like other ingestion requests (the central MONIC_HTTP_SERVER_INGEST_SECRET);
+	// without it the central basic auth credentials are used
+	Secret   string `envconfig:"SECRET"`
+	Username string `envconfig:"USERNAME"`
+	Password string `envconfig:"PASSWORD"`
 }
 
 // AlertingConfig contains alert notification settings
 type AlertingConfig struct {
-	Email    EmailConfig    `envconfig:"EMAIL"`
-	Mailgun  MailgunConfig  `envconfig:"MAILGUN"`
-	Telegram TelegramConfig `envconfig:"TELEGRAM"`
+	// Enabled is false when MONIC_ALERTING_ENABLED=false turned off every alert channel
+	Enabled        bool
+	Email          EmailConfig          `envconfig:"EMAIL"`
+	Mailgun        MailgunConfig        `envconfig:"MAILGUN"`
+	Telegram       TelegramConfig       `envconfig:"TELEGRAM"`
+	CircuitBreaker CircuitBreakerConfig `envconfig:"CIRCUIT_BREAKER"`
+	// Routes send the alerts of matching checks to other channels or recipients; loaded from
+	// MONIC_ALERTING_ROUTE_<N>_*
+	Routes []AlertRoute `envconfig:"ROUTE" ignored:"true"`
+	// ImmediateLevels are the levels of alerts sent as soon as they are raised, along with the
+	// other pending alerts, instead of with the next alert cycle (default: critical; none for no
+	// immediate alerts)
+	ImmediateLevels []string `envconfig:"IMMEDIATE_LEVELS"`
+}
+
+// AlertRoute sends the alerts of the checks matching all of its conditions to its target, e.g.
+// the alerts tagged db to the Telegram group of the DBAs
+type AlertRoute struct {
+	Checks []string    `envconfig:"CHECKS"` // Alert types, e.g. http_api or docker_*; a trailing * matches a prefix
+	Tags   []string    `envconfig:"TAGS"`   // Tags of which the alert needs at least one
+	To     AlertTarget `envconfig:"TO"`
+}
+
+// AlertTarget selects the channels and recipients of alerts. Channels default to the ones with a
+// recipient, or all channels without any; channels without a recipient use their global one.
+type AlertTarget struct {
+	Channels     []string `envconfig:"CHANNELS" json:"channels,omitempty"`           // email, mailgun, telegram
+	Email        string   `envconfig:"EMAIL" json:"email,omitempty"`                 // Recipient of email alerts
+	Mailgun      string   `envconfig:"MAILGUN" json:"mailgun,omitempty"`             // Recipient of Mailgun alerts
+	TelegramChat string   `envconfig:"TELEGRAM_CHAT" json:"telegram_chat,omitempty"` // Telegram chat ID
+}
+
+// IsZero reports whether the target selects no channel or recipient, so the defaults apply
+func (t AlertTarget) IsZero() bool {
+	return len(t.Channels) == 0 && t.Email == "" && t.Mailgun == "" && t.TelegramChat == ""
+}
+
+// CircuitBreakerConfig controls when a failing notification provider is temporarily disabled
+type CircuitBreakerConfig struct {
+	FailureThreshold int `envconfig:"FAILURE_THRESHOLD"`        // Consecutive failures before opening (default: 3)
+	OpenPeriod       int `envconfig:"OPEN_PERIOD" unit:"s"`     // Initial open period in seconds (default: 300)
+	MaxOpenPeriod    int `envconfig:"MAX_OPEN_PERIOD" unit:"s"` // Upper bound for exponential backoff in seconds (default: 3600)
 }
 
 // EmailConfig contains SMTP email settings
@@ -48,6 +453,7 @@ type EmailConfig struct {
 	From     string `envconfig:"FROM"`
 	To       string `envconfig:"TO"`
 	UseTLS   bool   `envconfig:"USE_TLS"`
+	Timeout  int    `envconfig:"TIMEOUT" unit:"s"` // Seconds a delivery may take (default: 30)
 }
 
 // MailgunConfig contains Mailgun API settings
@@ -57,7 +463,8 @@ type MailgunConfig struct {
 	Domain  string `envconfig:"DOMAIN"`
 	From    string `envconfig:"FROM"`
 	To      string `envconfig:"TO"`
-	BaseURL string `envconfig:"BASE_URL"` // Default: "https://api.mailgun.net/v3"
+	BaseURL string `envconfig:"BASE_URL"`         // Default: "https://api.mailgun.net/v3"
+	Timeout int    `envconfig:"TIMEOUT" unit:"s"` // Seconds a delivery may take (default: 30)
 }
 
 // TelegramConfig contains Telegram bot settings
@@ -65,14 +472,56 @@ type TelegramConfig struct {
 	Enabled  bool
 	BotToken string `envconfig:"BOT_TOKEN"`
 	ChatID   string `envconfig:"CHAT_ID"`
+	Timeout  int    `envconfig:"TIMEOUT" unit:"s"` // Seconds a delivery may take (default: 30)
 }
 
 // SystemStats contains collected system statistics
 type SystemStats struct {
-	Timestamp   time.Time
-	CPUUsage    float64
-	MemoryUsage MemoryStats
-	DiskUsage   map[string]DiskStats
+	Timestamp    time.Time
+	CPUUsage     float64
+	PerCoreUsage []float64 // CPU usage per logical core
+	LoadAverage  LoadStats
+	MemoryUsage  MemoryStats
+	SwapUsage    SwapStats
+	DiskUsage    map[string]DiskStats
+	DiskIO       map[string]DiskIOStats // Per block device; empty on the first collection
+}
+
+// DiskIOStats contains I/O rates of a block device, computed between two collections
+type DiskIOStats struct {
+	Device           string
+	ReadIOPS         float64
+	WriteIOPS        float64
+	ReadBytesPerSec  float64
+	WriteBytesPerSec float64
+	ReadAwaitMs      float64 // Average time per read request
+	WriteAwaitMs     float64 // Average time per write request
+	AwaitMs          float64 // Average time per request (reads and writes)
+	UtilPercent      float64 // Share of time the device was busy
+}
+
+// SwapStats contains swap usage information (all zero when the host has no swap)
+type SwapStats struct {
+	Total       uint64
+	Used        uint64
+	Free        uint64
+	UsedPercent float64
+}
+
+// LoadStats contains system load averages
+type LoadStats struct {
+	Load1  float64
+	Load5  float64
+	Load15 float64
+	Cores  int // Logical CPU count used to normalize load
+}
+
+// PerCore returns the 5-minute load average divided by the number of cores
+func (l LoadStats) PerCore() float64 {
+	if l.Cores <= 0 {
+		return 0
+	}
+	return l.Load5 / float64(l.Cores)
 }
 
 // MemoryStats contains memory usage information
@@ -90,27 +539,85 @@ type DiskStats struct {
 	Used        uint64
 	Free        uint64
 	UsedPercent float64
+	// Inode usage (all zero on filesystems without a fixed inode table, e.g. btrfs)
+	InodesTotal       uint64
+	InodesUsed        uint64
+	InodesFree        uint64
+	InodesUsedPercent float64
+}
+
+// AgentReport is the latest state of a host pushed by a remote agent
+type AgentReport struct {
+	Host       string
+	Version    string
+	Interval   int // Seconds between reports
+	System     *SystemStats
+	Containers []DockerContainerStats
+	Timestamp  time.Time
 }
 
 // HTTPCheckResult contains the result of an HTTP check
 type HTTPCheckResult struct {
 	Name         string
+	Type         string // Check type ("http", "grpc", ...); empty means "http"
 	URL          string
 	StatusCode   int
 	ResponseTime time.Duration
 	Success      bool
+	Inverted     bool        // Canary check: Success means the target was NOT reachable
+	Attempts     int         `json:",omitempty"` // Requests of the run, more than one when a failure was retried
+	Timing       *HTTPTiming `json:",omitempty"` // Phases of the request of HTTP checks
+	Value        string      `json:",omitempty"` // Polled value of value checks (e.g. SNMP)
+	Tags         []string    `json:",omitempty"` // Tags of the check
 	Error        string
 	Timestamp    time.Time
 }
 
+// HTTPTiming is the time an HTTP check request spent in each phase. Phases that didn't happen,
+// such as DNS and connecting on a reused connection, are zero.
+type HTTPTiming struct {
+	DNS        time.Duration // Resolving the host name
+	Connect    time.Duration // Establishing the TCP connection
+	TLS        time.Duration // TLS handshake
+	FirstByte  time.Duration // From the request being sent to the first response byte (server processing)
+	Transfer   time.Duration // Reading the response body (up to 1KB)
+	ReusedConn bool          // The request used a kept-alive connection
+}
+
 // Alert represents a monitoring alert
 type Alert struct {
-	Type      string
-	Message   string
-	Level     string // info, warning, critical
+	Type       string
+	Message    string
+	Level      string // info, warning, critical
+	Timestamp  time.Time
+	Correlated []CorrelatedChange `json:",omitempty"` // Other checks that changed state around the same time
+	Tags       []string           `json:",omitempty"` // Tags of the check, container or system checks that raised it
+}
+
+// CorrelatedChange is a state change of another check or metric close to an alert's own state change
+type CorrelatedChange struct {
+	Type      string // Alert type of the other check (e.g. "http_db-ping", "cpu")
+	State     string // State it changed to ("ok", "critical")
 	Timestamp time.Time
 }
 
+// NotificationChannelStats contains delivery metrics for a single alert channel
+type NotificationChannelStats struct {
+	Channel       string
+	Enabled       bool
+	CircuitState  string
+	Sent          int
+	Failed        int
+	ErrorRate     float64 // Percentage of failed deliveries
+	LastLatency   time.Duration
+	AvgLatency    time.Duration
+	MaxLatency    time.Duration
+	TotalLatency  time.Duration // Sum of successful delivery latencies (detection to delivery)
+	LastSuccess   time.Time
+	LastError     string
+	LastErrorTime time.Time
+}
+
 // AlertState tracks the state of alerts for deduplication
 type AlertState struct {
 	Type              string
@@ -120,11 +627,168 @@ type AlertState struct {
 	LastStateChange   time.Time
 }
 
+// AlertRecord is a processed alert in the history of the alerts page and API
+type AlertRecord struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	Message    string    `json:"message"`
+	Level      string    `json:"level"`
+	Timestamp  time.Time `json:"timestamp"`
+	Tags       []string  `json:"tags,omitempty"`
+	Suppressed string    `json:"suppressed,omitempty"` // Why the alert was not sent, empty if it was
+}
+
+// SavedAlertState is the alerting state saved to storage, so a restart neither resets
+// consecutive failure counts nor re-sends alerts that are still in their cooldown
+type SavedAlertState struct {
+	States   []AlertState            `json:"states"`
+	LastSent map[string]time.Time    `json:"last_sent"`          // Last alert sent per alert type
+	Acks     map[string]AlertAck     `json:"acks,omitempty"`     // Acknowledged alert types
+	Silences map[string]AlertSilence `json:"silences,omitempty"` // Silenced alert types
+	SavedAt  time.Time               `json:"saved_at"`
+}
+
+// AlertAck is an acknowledgement of an active alert
+type AlertAck struct {
+	By string    `json:"by"`
+	At time.Time `json:"at"`
+}
+
+// AlertSilence mutes the notifications of an alert type until it expires
+type AlertSilence struct {
+	By    string    `json:"by"`
+	Until time.Time `json:"until"`
+}
+
 // DockerConfig contains Docker container monitoring settings
 type DockerConfig struct {
 	Enabled       bool
-	CheckInterval int      `envconfig:"INTERVAL"`
+	CheckInterval int      `envconfig:"INTERVAL" unit:"s"`
+	Schedule      string   `envconfig:"SCHEDULE"` // Cron expression, replaces the interval when set
 	Containers    []string `envconfig:"CONTAINERS"`
+	// Concurrency is the number of containers inspected and sampled at once (default: 8)
+	Concurrency int `envconfig:"CONCURRENCY"`
+	// IncludeNames and IncludeLabels select containers by name regular expression or by label
+	// ("key", "key=value" or "key!=value"); a container matching any include rule is monitored
+	IncludeNames  []string `envconfig:"INCLUDE_NAMES"`
+	IncludeLabels []string `envconfig:"INCLUDE_LABELS"`
+	// ExcludeNames and ExcludeLabels skip containers even if they match an include rule
+	ExcludeNames  []string `envconfig:"EXCLUDE_NAMES"`
+	ExcludeLabels []string `envconfig:"EXCLUDE_LABELS"`
+	// CPUThreshold is the container CPU usage percentage that triggers an alert (0 disables; 100 = one core)
+	CPUThreshold float64 `envconfig:"CPU_THRESHOLD"`
+	// MemoryThreshold is the container memory usage in percent of its limit that triggers an alert (0 disables)
+	MemoryThreshold float64 `envconfig:"MEMORY_THRESHOLD"`
+	// Thresholds overrides the thresholds for single containers
+	Thresholds []ContainerThreshold `envconfig:"THRESHOLD" ignored:"true"` // Loaded from MONIC_CHECK_DOCKER_THRESHOLD_<N>_* variables
+	// LogScan tails the logs of running monitored containers and alerts on lines matching the log patterns
+	LogScan bool `envconfig:"LOG_SCAN"`
+	// LogPatterns are regular expressions matched against the logs of all containers (default: panic, OOM, fatal)
+	LogPatterns []string `envconfig:"LOG_PATTERNS"`
+	// LogRateLimit is the maximum number of log lines scanned per container and minute (default: 600)
+	LogRateLimit int `envconfig:"LOG_RATE_LIMIT"`
+	// LogRules adds log patterns for single containers
+	LogRules []ContainerLogRule `envconfig:"LOG" ignored:"true"` // Loaded from MONIC_CHECK_DOCKER_LOG_<N>_* variables
+	// DiskUsage collects the disk usage of images, containers, volumes and build cache (docker system df)
+	DiskUsage bool `envconfig:"DISK_USAGE"`
+	// DiskUsageInterval is the time between disk usage collections in seconds (default: 300)
+	DiskUsageInterval int `envconfig:"DISK_USAGE_INTERVAL" unit:"s"`
+	// DiskUsageThreshold is Docker's total disk usage in GB that triggers an alert (0 disables; enables DiskUsage)
+	DiskUsageThreshold float64 `envconfig:"DISK_USAGE_THRESHOLD"`
+	// ExpectedState is the state of containers without an expectation: running, stopped or any (default: running)
+	ExpectedState string `envconfig:"EXPECTED_STATE"`
+	// Expectations declare the expected state of single containers
+	Expectations []ContainerExpectation `envconfig:"EXPECT" ignored:"true"` // Loaded from MONIC_CHECK_DOCKER_EXPECT_<N>_* variables
+	// RestartThreshold is the number of restarts between two checks that alerts on a restart loop
+	// (default: 1, negative disables)
+	RestartThreshold int `envconfig:"RESTART_THRESHOLD"`
+	// Compose declares the expected number of running replicas of compose services
+	Compose []ComposeReplicas `envconfig:"COMPOSE" ignored:"true"` // Loaded from MONIC_CHECK_DOCKER_COMPOSE_<N>_* variables
+	// TagRules tag single containers, in addition to the tags of their monic.tags label
+	TagRules []ContainerTags `envconfig:"TAG" ignored:"true"` // Loaded from MONIC_CHECK_DOCKER_TAG_<N>_* variables
+}
+
+// ComposeReplicas is the expected number of running containers of a compose service
+type ComposeReplicas struct {
+	Project  string `envconfig:"PROJECT"`  // com.docker.compose.project label
+	Service  string `envconfig:"SERVICE"`  // com.docker.compose.service label
+	Replicas int    `envconfig:"REPLICAS"` // Expected running containers (at least 1)
+}
+
+// ComposeServiceStatus groups the monitored containers of a compose service
+type ComposeServiceStatus struct {
+	Project    string
+	Service    string
+	Running    int
+	Total      int
+	Expected   int      // Expected running replicas (0 without expectation)
+	Containers []string // Container names, sorted
+}
+
+// ContainerThreshold overrides the resource thresholds of a single container
+type ContainerThreshold struct {
+	Container string  `envconfig:"CONTAINER"`
+	CPU       float64 `envconfig:"CPU"`    // 0 keeps the default threshold, negative disables the alert
+	Memory    float64 `envconfig:"MEMORY"` // 0 keeps the default threshold, negative disables the alert
+}
+
+// ContainerExpectation declares the expected state of a single container
+type ContainerExpectation struct {
+	Container string `envconfig:"CONTAINER"`
+	State     string `envconfig:"STATE"` // running, stopped or any
+}
+
+// ContainerLogRule adds log patterns for a single container
+type ContainerLogRule struct {
+	Container string   `envconfig:"CONTAINER"`
+	Patterns  []string `envconfig:"PATTERNS"` // Comma-separated regular expressions
+}
+
+// ContainerTags tags a single container
+type ContainerTags struct {
+	Container string   `envconfig:"CONTAINER"`
+	Tags      []string `envconfig:"TAGS"` // Comma-separated, e.g. payment-critical,db
+}
+
+// TagsFor returns the tags the tag rules give a container
+func (c DockerConfig) TagsFor(container string) []string {
+	var tags []string
+	for _, rule := range c.TagRules {
+		if rule.Container == container {
+			tags = append(tags, rule.Tags...)
+		}
+	}
+	return tags
+}
+
+// ExpectedStateFor returns the expected state of a container: running, stopped or any
+func (c DockerConfig) ExpectedStateFor(container string) string {
+	for _, expectation := range c.Expectations {
+		if expectation.Container == container {
+			return expectation.State
+		}
+	}
+	if c.ExpectedState == "" {
+		return "running"
+	}
+	return c.ExpectedState
+}
+
+// ThresholdsFor returns the CPU and memory thresholds of a container (0 when disabled)
+func (c DockerConfig) ThresholdsFor(container string) (cpu, memory float64) {
+	cpu, memory = c.CPUThreshold, c.MemoryThreshold
+	for _, override := range c.Thresholds {
+		if override.Container != container {
+			continue
+		}
+		if override.CPU != 0 {
+			cpu = override.CPU
+		}
+		if override.Memory != 0 {
+			memory = override.Memory
+		}
+	}
+	return max(cpu, 0), max(memory, 0)
 }
 
 // DockerContainerStats contains Docker container status information
@@ -140,12 +804,239 @@ type DockerContainerStats struct {
 	ExitCode    int
 	Error       string
 	Timestamp   time.Time
+	// RestartCount is the number of times Docker restarted the container (restart policy)
+	RestartCount int
+
+	// Resource usage from the Docker stats API (running containers only)
+	CPUPercent     float64 // 100 = one core
+	MemoryUsage    uint64  // Bytes, excluding inactive page cache
+	MemoryLimit    uint64
+	MemoryPercent  float64
+	NetworkRxBytes uint64 // Totals since the container started
+	NetworkTxBytes uint64
+
+	// Health check status from docker inspect: healthy, unhealthy or starting (empty without HEALTHCHECK)
+	Health              string
+	HealthFailingStreak int    // Consecutive failed health probes
+	HealthOutput        string // Output of the latest health probe
+
+	// Inventory from the container list
+	Image    string   // Image reference the container was created from, e.g. nginx:1.27
+	ImageID  string   // Short image ID
+	Ports    []string // Port mappings in docker ps format, e.g. 0.0.0.0:8080->80/tcp
+	Networks []string // Names of the attached networks
+
+	// Compose project and service from the com.docker.compose.* labels (empty for other containers)
+	ComposeProject string
+	ComposeService string
+
+	// Tags from the monic.tags label and the tag rules of the container
+	Tags []string `json:",omitempty"`
+}
+
+// DockerDiskUsage is the disk space used by Docker objects (docker system df), in bytes
+type DockerDiskUsage struct {
+	Images      int64 // Image layers, shared layers counted once
+	Containers  int64 // Writable layers of containers
+	Volumes     int64 // Local volumes
+	BuildCache  int64
+	Reclaimable int64 // Unused images, stopped containers, unreferenced volumes and unused build cache
+
+	ImageCount     int
+	ContainerCount int
+	VolumeCount    int
+	Timestamp      time.Time
+}
+
+// Total returns the disk space used by all Docker objects
+func (u DockerDiskUsage) Total() int64 {
+	return u.Images + u.Containers + u.Volumes + u.BuildCache
 }
 
 // HTTPServerConfig contains HTTP server settings for stats endpoint
 type HTTPServerConfig struct {
-	Enabled  bool
-	Port     int    `envconfig:"PORT"`
-	Username string `envconfig:"USERNAME"`
-	Password string `envconfig:"PASSWORD"`
+	Enabled    bool
+	Port       int              `envconfig:"PORT"`
+	Username   string           `envconfig:"USERNAME"`
+	Password   string           `envconfig:"PASSWORD"`
+	StatusPage StatusPageConfig `envconfig:"STATUS_PAGE"`
+	Ingest     IngestConfig     `envconfig:"INGEST"`
+	// BindAddress is the interface to listen on, e.g. 127.0.0.1 behind a local reverse proxy (default: all interfaces)
+	BindAddress string `envconfig:"BIND_ADDRESS"`
+	// BasePath serves all endpoints under a URL prefix, e.g. /monic behind a reverse proxy (default: none)
+	BasePath string `envconfig:"BASE_PATH"`
+	// KeysFile is the JSON file holding scoped API keys managed with "monic apikey" (empty disables API keys)
+	KeysFile string        `envconfig:"API_KEYS_FILE"`
+	Metrics  MetricsConfig `envconfig:"METRICS"`
+	Agents   AgentsConfig  `envconfig:"AGENTS"`
+	Probes   ProbesConfig  `envconfig:"PROBES"`
+	Badges   BadgesConfig  `envconfig:"BADGES"`
+	TLS      TLSConfig     `envconfig:"TLS"`
+	// Logo, title, colors and templates of the web pages
+	Branding BrandingConfig `envconfig:"BRANDING"`
+	// Brute-force protection and per-client request limits
+	Auth      AuthConfig      `envconfig:"AUTH"`
+	RateLimit RateLimitConfig `envconfig:"RATE_LIMIT"`
+	// Structured log line per request, for auditing API use
+	AccessLog AccessLogConfig `envconfig:"ACCESS_LOG"`
+}
+
+// BrandingConfig customizes the look of the dashboard and status page
+type BrandingConfig struct {
+	// TemplateDir holds replacements for the embedded templates (stats.html, latency.html, status.html);
+	// missing or broken files fall back to the embedded ones
+	TemplateDir     string `envconfig:"TEMPLATE_DIR"`
+	Title           string `envconfig:"TITLE"`     // Dashboard title (default: Monic Status)
+	LogoFile        string `envconfig:"LOGO_FILE"` // Image shown in the page headers
+	AccentColor     string `envconfig:"ACCENT_COLOR"`
+	BackgroundColor string `envconfig:"BACKGROUND_COLOR"`
+	CardColor       string `envconfig:"CARD_COLOR"`
+	TextColor       string `envconfig:"TEXT_COLOR"`
+}
+
+// AuthConfig locks out clients (by IP) after repeated failed logins
+type AuthConfig struct {
+	MaxFailures int `envconfig:"MAX_FAILURES"`     // Failed logins before a lockout (default: 5, negative disables lockouts)
+	Lockout     int `envconfig:"LOCKOUT" unit:"s"` // Lockout duration in seconds (default: 300)
+}
+
+// RateLimitConfig limits the requests of each client (by IP) to the stats server
+type RateLimitConfig struct {
+	Requests float64 `envconfig:"REQUESTS"` // Requests per second (0 disables rate limiting)
+	Burst    int     `envconfig:"BURST"`    // Requests allowed at once (default: 10 seconds worth of requests, at least 10)
+}
+
+// AccessLogConfig logs the requests to the stats server
+type AccessLogConfig struct {
+	Enabled bool `envconfig:"ENABLED"`
+	// Exclude lists paths not to log, e.g. polled health endpoints; a trailing * matches a prefix
+	Exclude []string `envconfig:"EXCLUDE"`
+}
+
+// TLSConfig serves the stats server over HTTPS, with certificate files or certificates obtained
+// automatically from an ACME CA such as Let's Encrypt
+type TLSConfig struct {
+	CertFile string `envconfig:"CERT_FILE"`
+	KeyFile  string `envconfig:"KEY_FILE"`
+	// ACMEDomains enables ACME certificates for these domains (instead of certificate files)
+	ACMEDomains []string `envconfig:"ACME_DOMAINS"`
+	ACMEEmail   string   `envconfig:"ACME_EMAIL"`     // Contact for expiry notices (optional)
+	ACMECache   string   `envconfig:"ACME_CACHE_DIR"` // Directory storing account and certificates (default: acme-certs)
+	// ACMEDirectoryURL selects the ACME CA (default: Let's Encrypt production)
+	ACMEDirectoryURL string `envconfig:"ACME_DIRECTORY_URL"`
+}
+
+// Enabled reports whether the stats server is served over HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || len(c.ACMEDomains) > 0
+}
+
+// BadgesConfig configures the public (unauthenticated) status badges of checks
+type BadgesConfig struct {
+	Enabled bool `envconfig:"ENABLED"`
+	// Checks limits badges to these checks ("type/name" or plain name); empty allows all checks
+	Checks []string `envconfig:"CHECKS"`
+}
+
+// ProbesConfig lets peer instances run their HTTP checks from this instance (see PeerConfig)
+type ProbesConfig struct {
+	Enabled bool `envconfig:"ENABLED"`
+}
+
+// AgentsConfig lets remote agents push their stats to this instance (see AgentConfig)
+type AgentsConfig struct {
+	Enabled bool `envconfig:"ENABLED"`
+	// MissedReports is the number of missed reports after which an agent counts as down (default: 3)
+	MissedReports int `envconfig:"MISSED_REPORTS"`
+}
+
+// MetricsConfig controls the labels exported by the /metrics endpoint
+type MetricsConfig struct {
+	// CheckLabels are the check labels exported besides the name: type, url (default: type)
+	CheckLabels []string `envconfig:"CHECK_LABELS"`
+	// Labels are added to every sample, e.g. host:web-01,env:prod
+	Labels map[string]string `envconfig:"LABELS"`
+	// Relabel rules are applied to every sample in order
+	Relabel []MetricRelabel `envconfig:"RELABEL" ignored:"true"` // Loaded from MONIC_HTTP_SERVER_METRICS_RELABEL_<N>_* variables
+}
+
+// MetricRelabel is a relabel rule for the /metrics endpoint, modeled on Prometheus relabeling
+type MetricRelabel struct {
+	Action      string `envconfig:"ACTION"`       // drop, keep, replace or labeldrop
+	SourceLabel string `envconfig:"SOURCE_LABEL"` // Label matched against the regex (__name__ is the metric name)
+	Regex       string `envconfig:"REGEX"`        // Fully anchored regular expression (default: .*)
+	TargetLabel string `envconfig:"TARGET_LABEL"` // replace: label to set
+	Replacement string `envconfig:"REPLACEMENT"`  // replace: value with $1-style group references (default: $1)
+}
+
+// IngestConfig secures the inbound push endpoints with HMAC request signatures
+type IngestConfig struct {
+	// Secret is the shared HMAC-SHA256 key; when set, push requests must be signed instead of using basic auth
+	Secret string `envconfig:"SECRET"`
+	// Tolerance is the allowed age of a signed request in seconds (default: 300)
+	Tolerance int `envconfig:"TOLERANCE" unit:"s"`
+}
+
+// StatusPageConfig configures the public (unauthenticated) status page
+type StatusPageConfig struct {
+	Enabled    bool              `envconfig:"ENABLED"`
+	Title      string            `envconfig:"TITLE"`
+	Components []StatusComponent `envconfig:"COMPONENT" ignored:"true"` // Loaded from MONIC_HTTP_SERVER_STATUS_PAGE_COMPONENT_<N>_* variables
+}
+
+// StatusComponent is an entry of the public status page, shown under a friendly name
+// independent of internal check names
+type StatusComponent struct {
+	Name        string   `envconfig:"NAME"` // Friendly name, e.g. "API" or "Website"
+	Description string   `envconfig:"DESCRIPTION"`
+	Checks      []string `envconfig:"CHECKS"` // Check names or type/name keys; empty for a text-only block
+	Text        string   `envconfig:"TEXT"`   // Custom text shown below the component, e.g. a maintenance note
+}
+
+// StorageConfig selects and configures the storage backend for monitoring history
+type StorageConfig struct {
+	Driver     string `envconfig:"DRIVER"`      // "memory" (default), "bolt", "sqlite" or "postgres"
+	Path       string `envconfig:"PATH"`        // Database file (default: monic.db for bolt, monic.sqlite for sqlite)
+	DSN        string `envconfig:"DSN"`         // PostgreSQL connection string
+	MaxHistory int    `envconfig:"MAX_HISTORY"` // Entries kept per history type (default: 100 in memory, 10000 otherwise)
+	// Milliseconds check results are collected before they are written in one batch (default: 0,
+	// every check run writes its own results)
+	FlushInterval int             `envconfig:"FLUSH_INTERVAL" unit:"ms"`
+	Retention     RetentionConfig `envconfig:"RETENTION"`
+}
+
+// RetentionConfig defines how long each type of history is kept before it is purged automatically (0 keeps it)
+type RetentionConfig struct {
+	AlertsDays int `envconfig:"ALERTS_DAYS"`
+	StatsDays  int `envconfig:"STATS_DAYS"`  // System stats
+	ChecksDays int `envconfig:"CHECKS_DAYS"` // HTTP, gRPC and mail check results
+	DockerDays int `envconfig:"DOCKER_DAYS"`
+	EventsDays int `envconfig:"EVENTS_DAYS"`       // Lifecycle event log
+	Interval   int `envconfig:"INTERVAL" unit:"m"` // Minutes between retention runs (default: 60)
+	// Entries kept per history type, the oldest are dropped on insert (default: MaxHistory)
+	AlertsMax int `envconfig:"ALERTS_MAX"`
+	StatsMax  int `envconfig:"STATS_MAX"`
+	ChecksMax int `envconfig:"CHECKS_MAX"`
+	DockerMax int `envconfig:"DOCKER_MAX"`
+	EventsMax int `envconfig:"EVENTS_MAX"`
+}
+
+// LifecycleEvent is a notable change that is not an alert, e.g. a restart of the service, a
+// check added at runtime or a container that started
+type LifecycleEvent struct {
+	Timestamp time.Time
+	Kind      string // e.g. service_started, check_added, container_started
+	Subject   string // What the event is about, e.g. a check or container name
+	Message   string
+	Actor     string // API user for changes made through the API, "system" otherwise
+}
+
+// PurgeAuditEntry records a history purge (via API or retention policy)
+type PurgeAuditEntry struct {
+	Timestamp time.Time
+	DataType  string
+	Before    time.Time // Entries older than this were removed; zero means all entries
+	Deleted   int
+	Trigger   string // "api" or "retention"
+	Actor     string // API user or remote address, "system" for retention
 }